@@ -0,0 +1,111 @@
+package aging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/clock"
+	"todo-list/database"
+	"todo-list/model"
+	"todo-list/notify"
+)
+
+// Store 老化提醒需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	ListAllStaleTodosContext(ctx context.Context, status string, before time.Time) ([]database.StaleTodo, error)
+	LastAgingAlertContext(ctx context.Context, todoID int, rule string) (*time.Time, error)
+	RecordAgingAlertContext(ctx context.Context, todoID int, rule, channel string, at time.Time) error
+}
+
+// Alerter 按规则检查停滞事项并发送提醒，由调度器周期调用
+type Alerter struct {
+	rules      *Rules
+	store      Store
+	dispatcher *notify.Dispatcher
+	userID     string // 尚未支持指派，提醒发给默认用户
+	clock      clock.Clock
+}
+
+// NewAlerter 创建老化提醒器
+func NewAlerter(rules *Rules, store Store, dispatcher *notify.Dispatcher, userID string, clk clock.Clock) *Alerter {
+	return &Alerter{
+		rules:      rules,
+		store:      store,
+		dispatcher: dispatcher,
+		userID:     userID,
+		clock:      clk,
+	}
+}
+
+// Run 执行一轮检查（接受 Context 参数，供调度器使用）
+func (a *Alerter) Run(ctx context.Context) {
+	notified, err := a.evaluate(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("停滞事项检查超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("停滞事项检查已取消")
+			return
+		}
+		log.Printf("停滞事项检查失败: %v", err)
+		return
+	}
+
+	if notified > 0 {
+		log.Printf("停滞事项提醒已发送: count=%d", notified)
+	}
+}
+
+// evaluate 按规则逐条检查，返回发送的提醒数量
+func (a *Alerter) evaluate(ctx context.Context) (int, error) {
+	now := a.clock.Now().UTC()
+
+	notified := 0
+	for _, rule := range a.rules.Rules {
+		todos, err := a.store.ListAllStaleTodosContext(ctx, rule.Status, rule.Threshold(now))
+		if err != nil {
+			return notified, err
+		}
+
+		for _, todo := range todos {
+			if err := ctx.Err(); err != nil {
+				return notified, err
+			}
+
+			last, err := a.store.LastAgingAlertContext(ctx, todo.ID, rule.Name)
+			if err != nil {
+				return notified, err
+			}
+			if !rule.ShouldNotify(last, todo.UpdatedAt, now) {
+				continue
+			}
+
+			err = a.dispatcher.Send(ctx, rule.Channel, notify.Notification{
+				UserID:    a.userID,
+				Kind:      model.NotificationStale,
+				TodoID:    todo.ID,
+				ProjectID: todo.ProjectID,
+				Title:     fmt.Sprintf("待办事项停滞：%s", todo.Title),
+				Body:      fmt.Sprintf("状态为 %s，已 %d 天没有更新", todo.Status, int(now.Sub(todo.UpdatedAt).Hours()/24)),
+			})
+			if errors.Is(err, notify.ErrSuppressed) {
+				continue
+			}
+			if err != nil {
+				log.Printf("发送停滞提醒失败: todo_id=%d, rule=%s, error=%v", todo.ID, rule.Name, err)
+				continue
+			}
+
+			if err := a.store.RecordAgingAlertContext(ctx, todo.ID, rule.Name, rule.Channel, now); err != nil {
+				return notified, err
+			}
+			notified++
+		}
+	}
+
+	return notified, nil
+}
@@ -0,0 +1,120 @@
+// Package aging 按规则找出长时间没有更新的待办事项（例如"进行中超过 5 天未动"），
+// 由调度器周期检查并发送提醒，同样的规则也用于 /todos/views/stale 视图
+package aging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"todo-list/model"
+	"todo-list/workflow"
+)
+
+// Rule 一条老化规则：状态为 Status 且超过 AfterDays 天没有更新的事项视为停滞，
+// 每隔 RepeatEveryHours 通过 Channel 提醒一次，事项被更新后重新计时
+type Rule struct {
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	AfterDays        int    `json:"after_days"`
+	RepeatEveryHours int    `json:"repeat_every_hours"`
+	Channel          string `json:"channel"`
+}
+
+// Rules 老化规则集合
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadRules 从 JSON 文件加载老化规则
+//
+//	{
+//	  "rules": [
+//	    {"name": "stuck_in_progress", "status": "in_progress", "after_days": 5,
+//	     "repeat_every_hours": 24, "channel": "in_app"}
+//	  ]
+//	}
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取老化规则失败：%w", err)
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("解析老化规则失败：%w", err)
+	}
+
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// Validate 校验规则本身
+func (rs *Rules) Validate() error {
+	if len(rs.Rules) == 0 {
+		return fmt.Errorf("老化规则至少需要一条")
+	}
+
+	seen := make(map[string]bool, len(rs.Rules))
+	for i, rule := range rs.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("第 %d 条: name 不能为空", i+1)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("第 %d 条: name %q 重复", i+1, rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if rule.Status == "" {
+			return fmt.Errorf("第 %d 条: status 不能为空", i+1)
+		}
+		if rule.AfterDays <= 0 {
+			return fmt.Errorf("第 %d 条: after_days 必须大于 0", i+1)
+		}
+		if rule.RepeatEveryHours <= 0 {
+			return fmt.Errorf("第 %d 条: repeat_every_hours 必须大于 0", i+1)
+		}
+		if !isKnownChannel(rule.Channel) {
+			return fmt.Errorf("第 %d 条: 未知的通知渠道 %q", i+1, rule.Channel)
+		}
+	}
+	return nil
+}
+
+// CheckStatuses 确认规则引用的状态都在工作流中定义，且不是终态（已完成的事项不会"停滞"）
+func (rs *Rules) CheckStatuses(wf *workflow.Workflow) error {
+	for _, rule := range rs.Rules {
+		if !wf.Has(rule.Status) {
+			return fmt.Errorf("规则 %q: 工作流中没有状态 %q", rule.Name, rule.Status)
+		}
+		if wf.IsTerminal(rule.Status) {
+			return fmt.Errorf("规则 %q: 不能针对终态 %q", rule.Name, rule.Status)
+		}
+	}
+	return nil
+}
+
+// Threshold 更新时间早于该时刻的事项视为停滞
+func (r Rule) Threshold(now time.Time) time.Time {
+	return now.AddDate(0, 0, -r.AfterDays)
+}
+
+// ShouldNotify 判断此刻是否需要（再次）提醒
+// 上次提醒之后事项被更新过，说明已经重新计时，此时只要再次满足停滞条件就提醒
+func (r Rule) ShouldNotify(lastNotified *time.Time, updatedAt, now time.Time) bool {
+	if lastNotified == nil || lastNotified.Before(updatedAt) {
+		return true
+	}
+	return now.Sub(*lastNotified) >= time.Duration(r.RepeatEveryHours)*time.Hour
+}
+
+func isKnownChannel(channel string) bool {
+	for _, c := range model.NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
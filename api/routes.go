@@ -3,15 +3,19 @@ package api
 import (
 	"log"
 	"net/http"
+	"todo-list/config"
 	"todo-list/handler"
+
+	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 // corsMiddleware 处理 CORS 跨域请求
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Range, X-Workspace, If-Match, X-API-Key, Last-Event-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After, X-Batch-Max-Size, Deprecation, Sunset, Link, ETag")
 
 		// 处理预检请求
 		if r.Method == http.MethodOptions {
@@ -48,43 +52,307 @@ func SetupRoutes(h *handler.Handler) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	withMiddlewares := func(f http.HandlerFunc) http.HandlerFunc {
-		return chain(f, corsMiddleware, recoverMiddleware)
+		return chain(f, h.TrackTraffic, corsMiddleware, recoverMiddleware, h.ReadOnly, h.RateLimit, h.Authenticate, h.ResolveWorkspace)
+	}
+
+	// 公开路由不经过认证扩展：分享页本身就是公开的，入站 webhook 由各集成自己校验签名
+	public := func(f http.HandlerFunc) http.HandlerFunc {
+		return chain(f, h.TrackTraffic, corsMiddleware, recoverMiddleware, h.ReadOnly, h.RateLimit, h.ResolveWorkspace)
+	}
+
+	// 依赖 SQLite 中其他数据的待办事项功能，DB_DRIVER=postgres / memory 时返回 FEATURE_DISABLED，见 handler.RequireSQLiteTodos
+	sqliteOnly := h.RequireSQLiteTodos
+	// 项目级操作、评论、变更事件和出站 webhook 需要待办事项和 storage.Repositories 是同一个实现，见 handler.RequireTodoRepositories
+	shared := h.RequireTodoRepositories
+	// 后台任务、备份和分片上传的记录保存在 SQLite 中，DB_DRIVER=memory 时不可用
+	dbOnly := h.RequireDatabase
+
+	// 管理接口（/api/v1/admin/*）：认证之后还要求管理员，见 handler.RequireAdmin
+	admin := func(f http.HandlerFunc) http.HandlerFunc {
+		return withMiddlewares(h.RequireAdmin(f))
 	}
 
 	optionsHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}
 
-	registerTodoRoutes := func(base string) {
+	registerTodoRoutes := func(base string, withMiddlewares func(http.HandlerFunc) http.HandlerFunc) {
 		mux.HandleFunc("GET "+base, withMiddlewares(h.ListTodos))
 		mux.HandleFunc("POST "+base, withMiddlewares(h.CreateTodo))
 		mux.HandleFunc("OPTIONS "+base, withMiddlewares(optionsHandler))
 
 		mux.HandleFunc("GET "+base+"/stats", withMiddlewares(h.GetStats))
+		mux.HandleFunc("GET "+base+"/views/workload", withMiddlewares(sqliteOnly(h.GetWorkload)))
+		mux.HandleFunc("GET "+base+"/views/stale", withMiddlewares(h.GetStaleTodos))
+		mux.HandleFunc("GET "+base+"/views/inbox", withMiddlewares(h.GetInbox))
+		mux.HandleFunc("POST "+base+"/suggest", withMiddlewares(h.SuggestDueDate))
+		mux.HandleFunc("GET "+base+"/suggest", withMiddlewares(sqliteOnly(h.SuggestTitles)))
+		mux.HandleFunc("GET "+base+"/recent", withMiddlewares(sqliteOnly(h.ListRecentTodos)))
+		mux.HandleFunc("GET "+base+"/events", withMiddlewares(shared(h.StreamTodoEvents)))
+		mux.HandleFunc("GET "+base+"/numbers", withMiddlewares(sqliteOnly(h.ResolveTodoNumbers)))
+		mux.HandleFunc("OPTIONS "+base+"/suggest", withMiddlewares(optionsHandler))
+		mux.HandleFunc("GET "+base+"/similar", withMiddlewares(sqliteOnly(h.FindSimilarTodos)))
+		mux.HandleFunc("OPTIONS "+base+"/similar", withMiddlewares(optionsHandler))
 
 		// 批量操作端点（部分成功策略，替换教学-5的全有或全无策略）
-		mux.HandleFunc("POST "+base+"/batch/complete", withMiddlewares(h.BatchCompleteTodosPartial))
-		mux.HandleFunc("POST "+base+"/batch/delete", withMiddlewares(h.BatchDeleteTodosPartial))
+		// 批量上限通过 X-Batch-Max-Size 头返回，OPTIONS 预检也能拿到
+		mux.HandleFunc("POST "+base+"/batch/complete", h.BatchLimitHeader(withMiddlewares(h.BatchCompleteTodosPartial)))
+		mux.HandleFunc("POST "+base+"/batch/delete", h.BatchLimitHeader(withMiddlewares(h.BatchDeleteTodosPartial)))
 		// 处理跨域的预请求，默认返回 200
-		mux.HandleFunc("OPTIONS "+base+"/batch/complete", withMiddlewares(optionsHandler))
-		mux.HandleFunc("OPTIONS "+base+"/batch/delete", withMiddlewares(optionsHandler))
+		mux.HandleFunc("OPTIONS "+base+"/batch/complete", h.BatchLimitHeader(withMiddlewares(optionsHandler)))
+		mux.HandleFunc("OPTIONS "+base+"/batch/delete", h.BatchLimitHeader(withMiddlewares(optionsHandler)))
+		// 批量移动在 SQLite 中调整目标列表的顺序，项目也保存在 SQLite 中
+		mux.HandleFunc("POST "+base+"/batch/move", h.BatchLimitHeader(withMiddlewares(sqliteOnly(h.BatchMoveTodos))))
+		mux.HandleFunc("OPTIONS "+base+"/batch/move", h.BatchLimitHeader(withMiddlewares(optionsHandler)))
+
+		// 按条件批量操作：服务端分批处理，不需要传 ID 列表
+		mux.HandleFunc("POST "+base+"/batch/complete-by-filter", h.BatchLimitHeader(withMiddlewares(sqliteOnly(h.BatchCompleteByFilter))))
+		mux.HandleFunc("POST "+base+"/batch/delete-by-filter", h.BatchLimitHeader(withMiddlewares(sqliteOnly(h.BatchDeleteByFilter))))
+		mux.HandleFunc("OPTIONS "+base+"/batch/complete-by-filter", h.BatchLimitHeader(withMiddlewares(optionsHandler)))
+		mux.HandleFunc("OPTIONS "+base+"/batch/delete-by-filter", h.BatchLimitHeader(withMiddlewares(optionsHandler)))
 
 		// 导入导出路由
-		mux.HandleFunc("GET "+base+"/export", withMiddlewares(h.ExportTodos))
-		mux.HandleFunc("POST "+base+"/import", withMiddlewares(h.ImportTodos))
+		mux.HandleFunc("GET "+base+"/export", withMiddlewares(h.LimitConcurrency(config.ConcurrencyExport, h.ExportTodos)))
+		mux.HandleFunc("POST "+base+"/import", withMiddlewares(h.LimitConcurrency(config.ConcurrencyImport, h.ImportTodos)))
 		mux.HandleFunc("OPTIONS "+base+"/export", withMiddlewares(optionsHandler))
 		mux.HandleFunc("OPTIONS "+base+"/import", withMiddlewares(optionsHandler))
 
-		mux.HandleFunc("PUT "+base+"/{id}", withMiddlewares(h.UpdateTodo))
-		mux.HandleFunc("DELETE "+base+"/{id}", withMiddlewares(h.DeleteTodo))
+		// {id} 既可以是整数 ID，也可以是 public_id
+		withTodo := func(f http.HandlerFunc) http.HandlerFunc {
+			return withMiddlewares(h.ResolveTodoID(f))
+		}
+		mux.HandleFunc("GET "+base+"/{id}", withTodo(h.GetTodo))
+		mux.HandleFunc("PUT "+base+"/{id}", withTodo(h.UpdateTodo))
+		mux.HandleFunc("PATCH "+base+"/{id}", withTodo(h.PatchTodo))
+		mux.HandleFunc("DELETE "+base+"/{id}", withTodo(h.DeleteTodo))
 		mux.HandleFunc("OPTIONS "+base+"/{id}", withMiddlewares(optionsHandler))
+
+		// 链接资源
+		mux.HandleFunc("GET "+base+"/{id}/links", withTodo(sqliteOnly(h.ListLinks)))
+		mux.HandleFunc("POST "+base+"/{id}/links", withTodo(sqliteOnly(h.AddLink)))
+		mux.HandleFunc("DELETE "+base+"/{id}/links/{linkId}", withTodo(sqliteOnly(h.DeleteLink)))
+		mux.HandleFunc("OPTIONS "+base+"/{id}/links", withMiddlewares(optionsHandler))
+		mux.HandleFunc("OPTIONS "+base+"/{id}/links/{linkId}", withMiddlewares(optionsHandler))
+
+		// 文件附件
+		mux.HandleFunc("GET "+base+"/{id}/attachments", withTodo(sqliteOnly(h.ListAttachments)))
+		mux.HandleFunc("POST "+base+"/{id}/attachments", withTodo(sqliteOnly(h.UploadAttachment)))
+		mux.HandleFunc("GET "+base+"/{id}/attachments/{attachmentId}", withTodo(sqliteOnly(h.DownloadAttachment)))
+		mux.HandleFunc("DELETE "+base+"/{id}/attachments/{attachmentId}", withTodo(sqliteOnly(h.DeleteAttachment)))
+		mux.HandleFunc("OPTIONS "+base+"/{id}/attachments", withMiddlewares(optionsHandler))
+		mux.HandleFunc("OPTIONS "+base+"/{id}/attachments/{attachmentId}", withMiddlewares(optionsHandler))
+
+		// 评论
+		mux.HandleFunc("GET "+base+"/{id}/comments", withTodo(shared(h.ListComments)))
+		mux.HandleFunc("POST "+base+"/{id}/comments", withTodo(shared(h.AddComment)))
+		mux.HandleFunc("OPTIONS "+base+"/{id}/comments", withMiddlewares(optionsHandler))
+
+		// 重复待办事项接下来的发生时间
+		mux.HandleFunc("GET "+base+"/{id}/occurrences", withTodo(h.GetTodoOccurrences))
+
+		// 公开分享
+		mux.HandleFunc("POST "+base+"/{id}/share", withTodo(h.CreateShareLink))
+		mux.HandleFunc("OPTIONS "+base+"/{id}/share", withMiddlewares(optionsHandler))
 	}
 
 	// Versioned routes with legacy aliases for backward compatibility
-	registerTodoRoutes("/api/v1/todos")
-	registerTodoRoutes("/api/todos")
+	registerTodoRoutes("/api/v1/todos", withMiddlewares)
+	if h.LegacyRoutesEnabled() {
+		// 旧路由带弃用提示，可以通过 LEGACY_ROUTES=false 完全关闭
+		deprecated := h.Deprecated("/api/todos", "/api/v1/todos")
+		registerTodoRoutes("/api/todos", func(f http.HandlerFunc) http.HandlerFunc {
+			return deprecated(withMiddlewares(f))
+		})
+	}
+
+	// 工作区（多租户）：todo 路由也可以挂在 /api/v1/workspaces/{workspace} 前缀下，
+	// 或者通过 X-Workspace 请求头切换
+	mux.HandleFunc("GET /api/v1/workspaces", withMiddlewares(h.ListWorkspaces))
+	mux.HandleFunc("POST /api/v1/workspaces", withMiddlewares(h.CreateWorkspace))
+	mux.HandleFunc("OPTIONS /api/v1/workspaces", withMiddlewares(optionsHandler))
+	registerTodoRoutes("/api/v1/workspaces/{workspace}/todos", withMiddlewares)
+
+	// 功能发现：客户端据此适配不同配置的部署
+	mux.HandleFunc("GET /api/v1/capabilities", withMiddlewares(h.GetCapabilities))
+
+	// 错误码目录：客户端按错误码处理错误
+	mux.HandleFunc("GET /api/v1/errors", withMiddlewares(h.GetErrorCatalog))
+
+	// 事件载荷结构：通知渠道（如 webhook）的消费方按 schema_version 适配
+	mux.HandleFunc("GET /api/v1/events/schema", withMiddlewares(h.GetEventSchema))
+
+	// 后台任务队列（管理接口）
+	mux.HandleFunc("GET /api/v1/admin/jobs", admin(dbOnly(h.ListJobs)))
+	mux.HandleFunc("POST /api/v1/admin/jobs/{id}/requeue", admin(dbOnly(h.RequeueJob)))
+
+	// 通过接口提交的长时间操作（导入、导出、批量操作、备份）：轮询状态和进度，下载结果
+	mux.HandleFunc("GET /api/v1/jobs/{id}", withMiddlewares(dbOnly(h.GetJob)))
+	mux.HandleFunc("GET /api/v1/jobs/{id}/result", withMiddlewares(dbOnly(h.GetJobResult)))
+	mux.HandleFunc("POST /api/v1/admin/backup", admin(dbOnly(h.StartBackup)))
+	mux.HandleFunc("OPTIONS /api/v1/admin/backup", withMiddlewares(optionsHandler))
+
+	// 分片上传：大附件和导入文件分段上传，断线后从已接收的位置继续
+	mux.HandleFunc("POST /api/v1/uploads", withMiddlewares(dbOnly(h.CreateUpload)))
+	mux.HandleFunc("GET /api/v1/uploads/{id}", withMiddlewares(dbOnly(h.GetUpload)))
+	mux.HandleFunc("PUT /api/v1/uploads/{id}", withMiddlewares(dbOnly(h.UploadChunk)))
+	mux.HandleFunc("DELETE /api/v1/uploads/{id}", withMiddlewares(dbOnly(h.DeleteUpload)))
+	mux.HandleFunc("POST /api/v1/uploads/{id}/complete", withMiddlewares(dbOnly(h.CompleteUpload)))
+	mux.HandleFunc("OPTIONS /api/v1/uploads", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/uploads/{id}", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/uploads/{id}/complete", withMiddlewares(optionsHandler))
+
+	// 定时任务计划（管理接口）
+	mux.HandleFunc("GET /api/v1/admin/schedule", admin(h.GetSchedule))
+	mux.HandleFunc("PUT /api/v1/admin/schedule/{name}", admin(h.UpdateSchedule))
+
+	// 实验性功能开关（管理接口）
+	mux.HandleFunc("GET /api/v1/admin/features", admin(h.ListFeatures))
+	mux.HandleFunc("PUT /api/v1/admin/features/{name}", admin(h.UpdateFeature))
+	mux.HandleFunc("OPTIONS /api/v1/admin/features/{name}", withMiddlewares(optionsHandler))
+
+	// 自动化规则（管理接口）
+	mux.HandleFunc("GET /api/v1/admin/automation-rules", admin(h.ListAutomationRules))
+	mux.HandleFunc("POST /api/v1/admin/automation-rules", admin(h.CreateAutomationRule))
+	mux.HandleFunc("PUT /api/v1/admin/automation-rules/{id}", admin(h.UpdateAutomationRule))
+	mux.HandleFunc("DELETE /api/v1/admin/automation-rules/{id}", admin(h.DeleteAutomationRule))
+	mux.HandleFunc("OPTIONS /api/v1/admin/automation-rules", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/admin/automation-rules/{id}", withMiddlewares(optionsHandler))
+
+	// 习惯：调度器按周期自动生成待办事项
+	mux.HandleFunc("GET /api/v1/habits", withMiddlewares(sqliteOnly(h.ListHabits)))
+	mux.HandleFunc("POST /api/v1/habits", withMiddlewares(sqliteOnly(h.CreateHabit)))
+	mux.HandleFunc("GET /api/v1/habits/{id}", withMiddlewares(sqliteOnly(h.GetHabit)))
+	mux.HandleFunc("PUT /api/v1/habits/{id}", withMiddlewares(sqliteOnly(h.UpdateHabit)))
+	mux.HandleFunc("DELETE /api/v1/habits/{id}", withMiddlewares(sqliteOnly(h.DeleteHabit)))
+	mux.HandleFunc("OPTIONS /api/v1/habits", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/habits/{id}", withMiddlewares(optionsHandler))
+
+	// 重复规则预览：设置重复规则之前查看接下来的发生时间
+	mux.HandleFunc("GET /api/v1/recurrence/preview", withMiddlewares(h.PreviewRecurrence))
+
+	// 项目：把待办事项分组
+	mux.HandleFunc("GET /api/v1/projects", withMiddlewares(h.ListProjects))
+	mux.HandleFunc("POST /api/v1/projects", withMiddlewares(h.CreateProject))
+	mux.HandleFunc("GET /api/v1/projects/{id}", withMiddlewares(h.GetProject))
+	mux.HandleFunc("PUT /api/v1/projects/{id}", withMiddlewares(h.UpdateProject))
+	mux.HandleFunc("DELETE /api/v1/projects/{id}", withMiddlewares(h.DeleteProject))
+	// 项目级操作在一个事务中修改项目中的所有待办事项
+	mux.HandleFunc("POST /api/v1/projects/{id}/archive", withMiddlewares(shared(h.ArchiveProject)))
+	mux.HandleFunc("POST /api/v1/projects/{id}/unarchive", withMiddlewares(shared(h.UnarchiveProject)))
+	mux.HandleFunc("POST /api/v1/projects/{id}/complete", withMiddlewares(shared(h.CompleteProjectTodos)))
+	mux.HandleFunc("POST /api/v1/projects/{id}/move", withMiddlewares(shared(h.MoveProjectTodos)))
+	mux.HandleFunc("OPTIONS /api/v1/projects", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/projects/{id}", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/projects/{id}/{action}", withMiddlewares(optionsHandler))
+
+	// API key：脚本和集成通过 X-API-Key 认证
+	mux.HandleFunc("GET /api/v1/api-keys", withMiddlewares(h.ListAPIKeys))
+	mux.HandleFunc("POST /api/v1/api-keys", withMiddlewares(h.CreateAPIKey))
+	mux.HandleFunc("DELETE /api/v1/api-keys/{id}", withMiddlewares(h.RevokeAPIKey))
+	mux.HandleFunc("OPTIONS /api/v1/api-keys", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/api-keys/{id}", withMiddlewares(optionsHandler))
+
+	// 出站 webhook：待办事项变更时推送给外部系统
+	mux.HandleFunc("GET /api/v1/webhooks", withMiddlewares(shared(h.ListWebhooks)))
+	mux.HandleFunc("POST /api/v1/webhooks", withMiddlewares(shared(h.CreateWebhook)))
+	mux.HandleFunc("GET /api/v1/webhooks/{id}", withMiddlewares(shared(h.GetWebhook)))
+	mux.HandleFunc("PUT /api/v1/webhooks/{id}", withMiddlewares(shared(h.UpdateWebhook)))
+	mux.HandleFunc("DELETE /api/v1/webhooks/{id}", withMiddlewares(shared(h.DeleteWebhook)))
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/test", withMiddlewares(shared(h.TestWebhook)))
+	mux.HandleFunc("GET /api/v1/webhooks/{id}/deliveries", withMiddlewares(shared(h.ListWebhookDeliveries)))
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/deliveries/{delivery_id}/retry", withMiddlewares(shared(h.RetryWebhookDelivery)))
+	mux.HandleFunc("OPTIONS /api/v1/webhooks", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/webhooks/{id}", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/webhooks/{id}/test", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/webhooks/{id}/deliveries", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/webhooks/{id}/deliveries/{delivery_id}/retry", withMiddlewares(optionsHandler))
+
+	// 目标：进度由关联的待办事项计算
+	mux.HandleFunc("GET /api/v1/goals", withMiddlewares(sqliteOnly(h.ListGoals)))
+	mux.HandleFunc("POST /api/v1/goals", withMiddlewares(sqliteOnly(h.CreateGoal)))
+	mux.HandleFunc("GET /api/v1/goals/{id}", withMiddlewares(sqliteOnly(h.GetGoal)))
+	mux.HandleFunc("PUT /api/v1/goals/{id}", withMiddlewares(sqliteOnly(h.UpdateGoal)))
+	mux.HandleFunc("DELETE /api/v1/goals/{id}", withMiddlewares(sqliteOnly(h.DeleteGoal)))
+	mux.HandleFunc("POST /api/v1/goals/{id}/todos", withMiddlewares(sqliteOnly(h.LinkGoalTodos)))
+	mux.HandleFunc("DELETE /api/v1/goals/{id}/todos/{todoId}", withMiddlewares(sqliteOnly(h.UnlinkGoalTodo)))
+	mux.HandleFunc("OPTIONS /api/v1/goals", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/goals/{id}", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/goals/{id}/todos", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/goals/{id}/todos/{todoId}", withMiddlewares(optionsHandler))
+
+	// 周回顾：分组列出需要回顾的事项，批量提交处理结果
+	mux.HandleFunc("GET /api/v1/review", withMiddlewares(sqliteOnly(h.GetReview)))
+	mux.HandleFunc("POST /api/v1/review/decisions", withMiddlewares(sqliteOnly(h.ApplyReviewDecisions)))
+	mux.HandleFunc("OPTIONS /api/v1/review/decisions", withMiddlewares(optionsHandler))
+
+	// 生效的配置（密钥已脱敏）
+	mux.HandleFunc("GET /api/v1/admin/config", admin(h.GetConfig))
+
+	// 请求流量统计：各接口收发字节数，最近的大响应和慢请求
+	mux.HandleFunc("GET /api/v1/admin/traffic", admin(h.GetTraffic))
+
+	// 外部集成熔断器状态
+	mux.HandleFunc("GET /api/v1/admin/breakers", admin(h.ListBreakers))
+
+	// 摘流：滚动发布前让 /ready 失败，负载均衡摘除本实例后再停止
+	mux.HandleFunc("GET /api/v1/admin/drain", admin(h.GetDrain))
+	mux.HandleFunc("POST /api/v1/admin/drain", admin(h.StartDrainHandler))
+	mux.HandleFunc("DELETE /api/v1/admin/drain", admin(h.StopDrainHandler))
+	mux.HandleFunc("OPTIONS /api/v1/admin/drain", withMiddlewares(optionsHandler))
+
+	// 工作区归档：整体导出，导入到另一个部署的空工作区（管理接口）
+	mux.HandleFunc("GET /api/v1/admin/export", admin(shared(h.LimitConcurrency(config.ConcurrencyExport, h.ExportArchive))))
+	mux.HandleFunc("POST /api/v1/admin/import", admin(shared(h.LimitConcurrency(config.ConcurrencyImport, h.ImportArchive))))
+	mux.HandleFunc("OPTIONS /api/v1/admin/import", withMiddlewares(optionsHandler))
+
+	// 统计计数由触发器维护，不一致时可以按实际数据重建（管理接口）
+	mux.HandleFunc("POST /api/v1/admin/stats/rebuild", admin(sqliteOnly(h.LimitConcurrency(config.ConcurrencyRebuild, h.RebuildStatsCounters))))
+	mux.HandleFunc("OPTIONS /api/v1/admin/stats/rebuild", withMiddlewares(optionsHandler))
+
+	// 状态工作流
+	mux.HandleFunc("GET /api/v1/workflow", withMiddlewares(h.GetWorkflow))
+
+	// 配额用量
+	mux.HandleFunc("GET /api/v1/usage", withMiddlewares(h.GetUsage))
+	mux.HandleFunc("GET /api/v1/workspaces/{workspace}/usage", withMiddlewares(h.GetUsage))
+	mux.HandleFunc("GET /api/v1/me/usage", withMiddlewares(h.GetMyUsage))
+
+	// 从 Jira / Linear 导入指派给自己的工单
+	mux.HandleFunc("GET /api/v1/integrations/issues", withMiddlewares(sqliteOnly(h.ListIssueImports)))
+	mux.HandleFunc("POST /api/v1/integrations/issues/sync", withMiddlewares(sqliteOnly(h.SyncIssues)))
+	mux.HandleFunc("OPTIONS /api/v1/integrations/issues/sync", withMiddlewares(optionsHandler))
+
+	// 入站 webhook（GitHub / Slack / Mailgun 等，按集成校验签名）；邮件入站只通过 /api/v1/hooks/mailgun 接收
+	mux.HandleFunc("POST /api/v1/hooks/{provider}", public(h.ReceiveHook))
+
+	// 语音助手 / 快捷指令使用的纯文本接口
+	mux.HandleFunc("GET /api/v1/simple", withMiddlewares(h.SimpleList))
+	mux.HandleFunc("POST /api/v1/simple", withMiddlewares(h.SimpleCreate))
+
+	// 通知偏好
+	mux.HandleFunc("GET /api/v1/notification-preferences", withMiddlewares(h.GetNotificationPreferences))
+	mux.HandleFunc("PUT /api/v1/notification-preferences", withMiddlewares(h.UpdateNotificationPreferences))
+	mux.HandleFunc("OPTIONS /api/v1/notification-preferences", withMiddlewares(optionsHandler))
+
+	// 站内通知
+	mux.HandleFunc("GET /api/v1/notifications", withMiddlewares(h.ListNotifications))
+	mux.HandleFunc("GET /api/v1/notifications/unread-count", withMiddlewares(h.GetUnreadNotificationCount))
+	mux.HandleFunc("POST /api/v1/notifications/read-all", withMiddlewares(h.MarkAllNotificationsRead))
+	mux.HandleFunc("POST /api/v1/notifications/{id}/read", withMiddlewares(h.MarkNotificationRead))
+	mux.HandleFunc("OPTIONS /api/v1/notifications/read-all", withMiddlewares(optionsHandler))
+	mux.HandleFunc("OPTIONS /api/v1/notifications/{id}/read", withMiddlewares(optionsHandler))
+
+	// 公开只读分享页（无需登录）
+	mux.HandleFunc("GET /share/{token}", public(h.GetSharedTodo))
 
 	mux.HandleFunc("/health", h.HealthCheck)
+	mux.HandleFunc("GET /ready", h.ReadyCheck)
+	mux.HandleFunc("GET /metrics", h.Metrics)
+
+	// 接口文档，可以通过 SWAGGER_ENABLED=false 关闭，或者设置 SWAGGER_USER / SWAGGER_PASSWORD 要求认证
+	if h.SwaggerEnabled() {
+		mux.Handle("/swagger/", h.DocsAuth(httpSwagger.WrapHandler))
+	}
 
 	return mux
 }
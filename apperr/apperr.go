@@ -0,0 +1,112 @@
+// Package apperr 统一的错误码目录
+//
+// 每个错误码（Code）属于一个错误类别（Kind），类别决定 HTTP 状态码。
+// 错误码一经发布就不再修改含义，客户端可以直接按错误码做判断，完整目录见 Catalog。
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kind 错误类别，同一类别的错误码共用一个 HTTP 状态码
+// 类别本身也是 error，可以用 errors.Is(err, apperr.ErrNotFound) 判断错误属于哪一类
+type Kind struct {
+	name   string
+	status int
+}
+
+func (k *Kind) Error() string {
+	return k.name
+}
+
+// Name 类别名称
+func (k *Kind) Name() string {
+	return k.name
+}
+
+// Status 类别对应的 HTTP 状态码
+func (k *Kind) Status() int {
+	return k.status
+}
+
+// 错误类别
+var (
+	ErrValidation           = &Kind{"validation", http.StatusBadRequest}
+	ErrUnauthorized         = &Kind{"unauthorized", http.StatusUnauthorized}
+	ErrForbidden            = &Kind{"forbidden", http.StatusForbidden}
+	ErrNotFound             = &Kind{"not_found", http.StatusNotFound}
+	ErrMethodNotAllowed     = &Kind{"method_not_allowed", http.StatusMethodNotAllowed}
+	ErrTimeout              = &Kind{"timeout", http.StatusRequestTimeout}
+	ErrConflict             = &Kind{"conflict", http.StatusConflict}
+	ErrGone                 = &Kind{"gone", http.StatusGone}
+	ErrTooLarge             = &Kind{"too_large", http.StatusRequestEntityTooLarge}
+	ErrUnsupportedMediaType = &Kind{"unsupported_media_type", http.StatusUnsupportedMediaType}
+	ErrUnprocessable        = &Kind{"unprocessable", http.StatusUnprocessableEntity}
+	ErrPreconditionRequired = &Kind{"precondition_required", http.StatusPreconditionRequired}
+	ErrRateLimited          = &Kind{"rate_limited", http.StatusTooManyRequests}
+	ErrInternal             = &Kind{"internal", http.StatusInternalServerError}
+	ErrUnavailable          = &Kind{"unavailable", http.StatusServiceUnavailable}
+)
+
+// Error 返回给客户端的错误：错误码 + 提示 + 可选的机器可读详情
+// Err 是原始错误，只写日志，不返回给客户端
+type Error struct {
+	Code    Code
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+// New 创建错误
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap 包装原始错误，message 是返回给客户端的提示
+func Wrap(err error, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// WithDetails 附加机器可读的详情
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+// Unwrap 让 errors.Is 能识别出原始错误中的超时和取消
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 按类别匹配，例如 errors.Is(err, apperr.ErrNotFound)
+func (e *Error) Is(target error) bool {
+	k, ok := target.(*Kind)
+	return ok && k == e.Code.Kind()
+}
+
+// Status 错误对应的 HTTP 状态码
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// KindOf 返回错误所属的类别：*Error 按错误码判断，Context 超时属于 ErrTimeout，其他都是 ErrInternal
+func KindOf(err error) *Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code.Kind()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ErrInternal
+}
@@ -0,0 +1,189 @@
+package apperr
+
+import "sort"
+
+// Code 稳定的错误码，响应中的 error.code 字段
+type Code string
+
+// 错误码
+const (
+	// 请求参数
+	CodeValidationError  Code = "VALIDATION_ERROR"
+	CodeInvalidJSON      Code = "INVALID_JSON"
+	CodeInvalidID        Code = "INVALID_ID"
+	CodeInvalidParam     Code = "INVALID_PARAM"
+	CodeInvalidStatus    Code = "INVALID_STATUS"
+	CodeInvalidWorkspace Code = "INVALID_WORKSPACE"
+	CodeInvalidRequest   Code = "INVALID_REQUEST"
+	CodeInvalidFormat    Code = "INVALID_FORMAT"
+	CodeInvalidSchedule  Code = "INVALID_SCHEDULE"
+	CodeParseError       Code = "PARSE_ERROR"
+	CodeEmptyData        Code = "EMPTY_DATA"
+	CodeBatchTooLarge    Code = "BATCH_TOO_LARGE"
+	CodeInvalidArchive   Code = "INVALID_ARCHIVE"
+
+	// 鉴权与权限
+	CodeUnauthorized          Code = "UNAUTHORIZED"
+	CodeStaleRequest          Code = "STALE_REQUEST"
+	CodeInvalidSignature      Code = "INVALID_SIGNATURE"
+	CodeForbidden             Code = "FORBIDDEN"
+	CodeQuotaExceeded         Code = "QUOTA_EXCEEDED"
+	CodeAttachmentQuarantined Code = "ATTACHMENT_QUARANTINED"
+
+	// 资源
+	CodeNotFound          Code = "NOT_FOUND"
+	CodeWorkspaceNotFound Code = "WORKSPACE_NOT_FOUND"
+	CodeMethodNotAllowed  Code = "METHOD_NOT_ALLOWED"
+	CodeUnsupportedMedia  Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeShareExpired      Code = "SHARE_EXPIRED"
+	CodeFeatureDisabled   Code = "FEATURE_DISABLED"
+
+	// 冲突与前置条件
+	CodeInvalidTransition    Code = "INVALID_TRANSITION"
+	CodeVersionConflict      Code = "VERSION_CONFLICT"
+	CodeWorkspaceExists      Code = "WORKSPACE_EXISTS"
+	CodeProjectExists        Code = "PROJECT_EXISTS"
+	CodeWorkspaceNotEmpty    Code = "WORKSPACE_NOT_EMPTY"
+	CodePreconditionRequired Code = "PRECONDITION_REQUIRED"
+	CodeJobNotFinished       Code = "JOB_NOT_FINISHED"
+	CodeUploadOffsetMismatch Code = "UPLOAD_OFFSET_MISMATCH"
+	CodeUploadIncomplete     Code = "UPLOAD_INCOMPLETE"
+	CodeAlreadyDelivered     Code = "ALREADY_DELIVERED"
+
+	// 附件
+	CodeAttachmentTooLarge Code = "ATTACHMENT_TOO_LARGE"
+	CodeAttachmentInfected Code = "ATTACHMENT_INFECTED"
+
+	// 字段约束
+	CodeConstraintViolation Code = "CONSTRAINT_VIOLATION"
+
+	// 限流与超时
+	CodeTimeout            Code = "TIMEOUT"
+	CodeRateLimited        Code = "RATE_LIMITED"
+	CodeTooBusy            Code = "TOO_BUSY"
+	CodeDailyQuotaExceeded Code = "DAILY_QUOTA_EXCEEDED"
+
+	// 服务端
+	CodeInternalError       Code = "INTERNAL_ERROR"
+	CodeDatabaseError       Code = "DATABASE_ERROR"
+	CodeStorageError        Code = "STORAGE_ERROR"
+	CodeBatchError          Code = "BATCH_ERROR"
+	CodeBatchOperationError Code = "BATCH_OPERATION_ERROR"
+	CodeImportError         Code = "IMPORT_ERROR"
+	CodeExportError         Code = "EXPORT_ERROR"
+	CodeDatabaseUnavailable Code = "DATABASE_UNAVAILABLE"
+	CodeScanUnavailable     Code = "SCAN_UNAVAILABLE"
+	CodeReadOnly            Code = "READ_ONLY"
+	CodeDraining            Code = "DRAINING"
+)
+
+// codeInfo 错误码的类别和说明
+type codeInfo struct {
+	kind        *Kind
+	description string
+}
+
+// catalog 错误码目录，新增错误码必须登记在这里
+var catalog = map[Code]codeInfo{
+	CodeValidationError:  {ErrValidation, "请求参数校验失败"},
+	CodeInvalidJSON:      {ErrValidation, "请求体不是合法的 JSON"},
+	CodeInvalidID:        {ErrValidation, "路径中的 ID 无效"},
+	CodeInvalidParam:     {ErrValidation, "查询参数无效"},
+	CodeInvalidStatus:    {ErrValidation, "未知的待办状态"},
+	CodeInvalidWorkspace: {ErrValidation, "无效的工作区标识"},
+	CodeInvalidRequest:   {ErrValidation, "集成请求格式错误"},
+	CodeInvalidFormat:    {ErrValidation, "不支持的导入导出格式"},
+	CodeInvalidSchedule:  {ErrValidation, "无效的 cron 表达式"},
+	CodeParseError:       {ErrValidation, "请求内容解析失败"},
+	CodeEmptyData:        {ErrValidation, "没有可导入的数据"},
+	CodeBatchTooLarge:    {ErrValidation, "批量操作数量超过上限"},
+	CodeInvalidArchive:   {ErrValidation, "归档版本不支持或内容不一致"},
+
+	CodeUnauthorized:          {ErrUnauthorized, "需要身份认证"},
+	CodeStaleRequest:          {ErrUnauthorized, "请求时间戳已过期"},
+	CodeInvalidSignature:      {ErrUnauthorized, "签名校验失败"},
+	CodeForbidden:             {ErrForbidden, "没有权限访问该资源"},
+	CodeQuotaExceeded:         {ErrForbidden, "超出工作区配额"},
+	CodeAttachmentQuarantined: {ErrForbidden, "附件已被隔离，不允许下载"},
+
+	CodeNotFound:          {ErrNotFound, "资源不存在"},
+	CodeWorkspaceNotFound: {ErrNotFound, "工作区不存在"},
+	CodeMethodNotAllowed:  {ErrMethodNotAllowed, "不支持的请求方法"},
+	CodeUnsupportedMedia:  {ErrUnsupportedMediaType, "不支持的请求体类型"},
+	CodeShareExpired:      {ErrGone, "分享链接已过期"},
+	CodeFeatureDisabled:   {ErrNotFound, "功能未启用，见 GET /api/v1/capabilities 的 features"},
+
+	CodeInvalidTransition:    {ErrConflict, "不允许的状态流转"},
+	CodeVersionConflict:      {ErrConflict, "版本冲突，需要刷新后重试"},
+	CodeWorkspaceExists:      {ErrConflict, "工作区标识已存在"},
+	CodeProjectExists:        {ErrConflict, "项目名称已存在"},
+	CodeWorkspaceNotEmpty:    {ErrConflict, "目标工作区已有数据，只能导入到空工作区"},
+	CodePreconditionRequired: {ErrPreconditionRequired, "更新必须提供 version 字段或 If-Match 请求头"},
+	CodeJobNotFinished:       {ErrConflict, "任务尚未完成"},
+	CodeUploadOffsetMismatch: {ErrConflict, "分片的起始位置与已接收的字节数不一致，请先查询 offset"},
+	CodeUploadIncomplete:     {ErrConflict, "文件尚未上传完整"},
+	CodeAlreadyDelivered:     {ErrConflict, "该推送已经成功送达，不需要重试"},
+
+	CodeAttachmentTooLarge: {ErrTooLarge, "附件超过大小上限"},
+	CodeAttachmentInfected: {ErrUnprocessable, "附件未通过病毒扫描"},
+
+	CodeConstraintViolation: {ErrUnprocessable, "字段未通过约束校验，details.violations 列出每一项"},
+
+	CodeTimeout:            {ErrTimeout, "请求处理超时"},
+	CodeRateLimited:        {ErrRateLimited, "请求过于频繁"},
+	CodeTooBusy:            {ErrUnavailable, "同类请求正在执行的数量已达上限，请稍后重试"},
+	CodeDailyQuotaExceeded: {ErrRateLimited, "超出每日配额，Retry-After 给出重试等待秒数"},
+
+	CodeInternalError:       {ErrInternal, "服务器内部错误"},
+	CodeDatabaseError:       {ErrInternal, "数据库操作失败"},
+	CodeStorageError:        {ErrInternal, "附件存储失败"},
+	CodeBatchError:          {ErrInternal, "批量操作失败"},
+	CodeBatchOperationError: {ErrInternal, "批量操作失败（部分成功模式）"},
+	CodeImportError:         {ErrInternal, "导入失败"},
+	CodeExportError:         {ErrInternal, "导出失败"},
+	CodeDatabaseUnavailable: {ErrUnavailable, "数据库不可用"},
+	CodeScanUnavailable:     {ErrUnavailable, "病毒扫描不可用"},
+	CodeReadOnly:            {ErrUnavailable, "服务处于只读模式，不接受修改"},
+	CodeDraining:            {ErrUnavailable, "实例正在摘流，不再接收新请求"},
+}
+
+// Kind 错误码所属的类别，未登记的错误码视为 ErrInternal
+func (c Code) Kind() *Kind {
+	if info, ok := catalog[c]; ok {
+		return info.kind
+	}
+	return ErrInternal
+}
+
+// Status 错误码对应的 HTTP 状态码
+func (c Code) Status() int {
+	return c.Kind().Status()
+}
+
+// Entry 错误码目录中的一项
+type Entry struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+}
+
+// Catalog 返回完整的错误码目录，按状态码、错误码排序
+func Catalog() []Entry {
+	entries := make([]Entry, 0, len(catalog))
+	for code, info := range catalog {
+		entries = append(entries, Entry{
+			Code:        code,
+			Status:      info.kind.Status(),
+			Kind:        info.kind.Name(),
+			Description: info.description,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Status != entries[j].Status {
+			return entries[i].Status < entries[j].Status
+		}
+		return entries[i].Code < entries[j].Code
+	})
+	return entries
+}
@@ -0,0 +1,224 @@
+// Package breaker 外部集成（通知渠道、出站 HTTP 请求）的熔断器
+//
+// 下游连续失败达到阈值后熔断器打开，冷却期内的调用直接返回 ErrOpen，不再占用 goroutine 等待超时；
+// 冷却期过后放行一次试探调用（半开），成功则恢复，失败则重新打开。
+// 只在单进程内存中计数，多实例部署时每个实例各自熔断。
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+	"todo-list/clock"
+)
+
+// ErrOpen 熔断器打开，调用被直接拒绝
+var ErrOpen = errors.New("breaker: circuit open")
+
+// 熔断器状态
+const (
+	StateClosed   = "closed"    // 正常放行
+	StateOpen     = "open"      // 拒绝调用，等待冷却
+	StateHalfOpen = "half_open" // 冷却结束，正在试探
+)
+
+// 默认配置
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// maxTracked 最多保留的熔断器数量，超出时清理没有失败记录的熔断器
+// 出站请求按目标主机建熔断器，主机来自用户输入，需要限制数量
+const maxTracked = 256
+
+// Options 熔断器配置
+type Options struct {
+	Threshold int           // 连续失败多少次后打开，0 表示不启用熔断
+	Cooldown  time.Duration // 打开后多久放行试探调用
+}
+
+// Status 熔断器状态，供指标和管理接口展示
+type Status struct {
+	Name                string     `json:"name" example:"notify:webhook"`
+	State               string     `json:"state" example:"closed"` // closed / open / half_open
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Trips               int64      `json:"trips"`    // 累计打开次数
+	Rejected            int64      `json:"rejected"` // 累计直接拒绝的调用数
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// Breaker 单个下游的熔断器
+type Breaker struct {
+	name string
+	set  *Set
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool // 半开状态下已经放行了一次试探调用
+	trips     int64
+	rejected  int64
+	lastError string
+}
+
+// Allow 是否放行本次调用；放行后必须调用 Record 报告结果
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.set.clock.Now().Sub(b.openedAt) < b.set.opts.Cooldown {
+			b.rejected++
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		if b.probing {
+			b.rejected++
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// Record 报告调用结果；调用方自己取消（context.Canceled）不算下游失败，只让出试探机会
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil {
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == StateHalfOpen || b.failures >= b.set.opts.Threshold {
+		if b.state != StateOpen {
+			b.trips++
+		}
+		b.state = StateOpen
+		b.openedAt = b.set.clock.Now()
+	}
+}
+
+// Do 在熔断器保护下执行 fn
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Record(err)
+	return err
+}
+
+// Status 返回当前状态
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Status{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Trips:               b.trips,
+		Rejected:            b.rejected,
+		LastError:           b.lastError,
+	}
+	if b.state == StateOpen {
+		until := b.openedAt.Add(b.set.opts.Cooldown)
+		s.OpenUntil = &until
+	}
+	return s
+}
+
+// idle 没有失败记录，可以被清理
+func (b *Breaker) idle() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == StateClosed && b.failures == 0
+}
+
+// Set 按名称管理一组熔断器，名称约定为 "类别:目标"，例如 notify:email、http:api.example.com
+type Set struct {
+	opts  Options
+	clock clock.Clock
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewSet 创建熔断器集合，Threshold 为 0 时返回 nil（Get 返回的 nil 熔断器总是放行）
+func NewSet(opts Options, clk clock.Clock) *Set {
+	if opts.Threshold <= 0 {
+		return nil
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultCooldown
+	}
+	return &Set{opts: opts, clock: clk, breakers: make(map[string]*Breaker)}
+}
+
+// Get 返回名称对应的熔断器，不存在时创建
+func (s *Set) Get(name string) *Breaker {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.breakers[name]; ok {
+		return b
+	}
+	if len(s.breakers) >= maxTracked {
+		for key, b := range s.breakers {
+			if b.idle() {
+				delete(s.breakers, key)
+			}
+		}
+	}
+	b := &Breaker{name: name, set: s, state: StateClosed}
+	s.breakers[name] = b
+	return b
+}
+
+// Statuses 返回所有熔断器的状态，按名称排序
+func (s *Set) Statuses() []Status {
+	if s == nil {
+		return []Status{}
+	}
+	s.mu.Lock()
+	breakers := make([]*Breaker, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		breakers = append(breakers, b)
+	}
+	s.mu.Unlock()
+
+	statuses := make([]Status, len(breakers))
+	for i, b := range breakers {
+		statuses[i] = b.Status()
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,18 @@
+// Package cache 可选的外部缓存（目前只有 Redis），用于热点读取
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache 键值缓存
+// 缓存只是加速手段：调用方在出错时应当直接回源，不能因为缓存不可用而让请求失败
+type Cache interface {
+	// Get 读取缓存，不存在时返回 nil, false, nil
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set 写入缓存，ttl 到期后自动删除
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除若干个键，不存在的键忽略
+	Delete(ctx context.Context, keys ...string) error
+}
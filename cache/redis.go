@@ -0,0 +1,235 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxIdleConns 连接池中最多保留的空闲连接
+const maxIdleConns = 8
+
+// Redis 基于 RESP 协议的最小 Redis 客户端，只实现缓存需要的 GET / SET / DEL
+type Redis struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// redisError 服务端返回的错误（-ERR ...），连接本身仍然可用
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// ParseRedisURL 解析 redis://[:password@]host[:port][/db] 形式的地址
+func ParseRedisURL(raw string, timeout time.Duration) (*Redis, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "redis" || u.Host == "" {
+		return nil, fmt.Errorf("无效的 Redis 地址 %q，格式为 redis://[:password@]host[:port][/db]", raw)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	r := &Redis{addr: addr, timeout: timeout, idle: make(chan *redisConn, maxIdleConns)}
+	if u.User != nil {
+		r.password, _ = u.User.Password()
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		r.db, err = strconv.Atoi(path)
+		if err != nil || r.db < 0 {
+			return nil, fmt.Errorf("无效的 Redis 数据库编号 %q", path)
+		}
+	}
+	return r, nil
+}
+
+// Get 读取缓存
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: GET 返回了意外的类型 %T", reply)
+	}
+	return value, true, nil
+}
+
+// Set 写入缓存
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// Delete 删除若干个键
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, "DEL", keys...)
+	return err
+}
+
+// do 发送一条命令并读取回复
+// 网络错误时丢弃连接；服务端错误（redisError）时连接仍放回连接池
+func (r *Redis) do(ctx context.Context, cmd string, args ...string) (interface{}, error) {
+	c, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(r.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+
+	reply, err := c.roundTrip(cmd, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		c.conn.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+// get 从连接池取一个连接，没有空闲连接时新建（并完成认证和选库）
+func (r *Redis) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: r.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, fmt.Errorf("连接 Redis 失败：%w", err)
+	}
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn)}
+	conn.SetDeadline(time.Now().Add(r.timeout))
+
+	if r.password != "" {
+		if _, err := c.roundTrip("AUTH", r.password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("Redis 认证失败：%w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.roundTrip("SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("选择 Redis 数据库失败：%w", err)
+		}
+	}
+	return c, nil
+}
+
+// put 把连接放回连接池，池满时关闭
+func (r *Redis) put(c *redisConn) {
+	select {
+	case r.idle <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+// roundTrip 以 RESP 数组格式写出命令并读取一条回复
+func (c *redisConn) roundTrip(cmd string, args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n$%d\r\n%s\r\n", len(args)+1, len(cmd), cmd)
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply 读取一条 RESP 回复：简单字符串和批量字符串返回 []byte，整数返回 int64，
+// 空值返回 nil，数组返回 []interface{}
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: 空回复")
+	}
+
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: 无效的长度 %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: 无效的长度 %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := c.readReply()
+			var replyErr redisError
+			if errors.As(err, &replyErr) {
+				// 数组中的错误元素不影响后续元素的读取
+				items[i] = replyErr
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: 无法识别的回复 %q", line)
+	}
+}
@@ -0,0 +1,58 @@
+// Package clock 当前时间的来源
+//
+// 判断逾期、统计、计算提醒和习惯周期的代码都通过 Clock 取当前时间，不直接调用 time.Now；
+// 测试中换成 Fake 即可固定时间，逐步推进，覆盖跨天、夏令时切换等边界情况。
+// 后台任务（webhook 推送、任务队列、停滞提醒、习惯、邀请、熔断器、维护任务等）在构造时传入时钟；
+// database.DB、handler.Handler（连同限流和入站 webhook）、scheduler.Scheduler、notify.Dispatcher、escalation.Escalator
+// 提供 SetClock；notify.InAppSender 和 model.Presenter 通过 Clock 字段设置。模型层不持有时钟，由调用方传入当前时间。
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 当前时间的来源
+type Clock interface {
+	Now() time.Time
+}
+
+// Real 系统时钟
+type Real struct{}
+
+// Now 返回系统当前时间
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fake 手动控制的时钟，只有调用 Set / Advance 时才会变化，可以在多个协程中使用
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake 创建停在 t 的时钟
+func NewFake(t time.Time) *Fake {
+	return &Fake{now: t}
+}
+
+// Now 返回当前设定的时间
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set 把时钟拨到 t
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = t
+}
+
+// Advance 把时钟向前推进 d（d 为负数时回拨）
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
@@ -0,0 +1,6 @@
+package main
+
+// 编译期启用的扩展：匿名导入扩展包，包在 init 中调用 extension.Register 完成注册
+// 示例：
+//
+//	import _ "example.com/fork/audit"
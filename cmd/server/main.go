@@ -2,46 +2,353 @@
 // @version 1.0
 // @description Todo List RESTful API
 // @host localhost:7789
-// @BasePath /api/v1
+// @BasePath /
 // @schemes http
 package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"strings"
 	"syscall"
 	"time"
 
-	httpSwagger "github.com/swaggo/http-swagger"
-
+	"todo-list/aging"
 	"todo-list/api"
+	"todo-list/breaker"
+	"todo-list/clock"
+	"todo-list/config"
 	"todo-list/database"
+	"todo-list/database/postgres"
 	_ "todo-list/docs"
+	"todo-list/escalation"
+	"todo-list/eventbus"
+	"todo-list/extension"
+	"todo-list/habits"
 	"todo-list/handler"
+	"todo-list/invite"
+	"todo-list/issues"
+	"todo-list/jobs"
+	"todo-list/mail"
+	"todo-list/maintenance"
+	"todo-list/model"
+	"todo-list/notify"
+	"todo-list/outbound"
+	"todo-list/recurrence"
+	"todo-list/scheduler"
+	"todo-list/storage"
+	"todo-list/storage/memory"
+	"todo-list/webhook"
+	"todo-list/workflow"
 )
 
 func main() {
-	// 支持环境变量配置数据库路径
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./todos.db"
+	// 配置档：--profile=low-memory 用于树莓派 Zero 等小内存设备，也可以通过 PROFILE 环境变量设置
+	profile := flag.String("profile", os.Getenv("PROFILE"), "运行配置档："+strings.Join(config.ProfileNames(), "、"))
+	// 待办事项存储：--storage=memory 用于演示，等同于 DB_DRIVER 环境变量
+	storageDriver := flag.String("storage", "", "待办事项存储：sqlite、postgres 或 memory（默认使用 DB_DRIVER）")
+	// 创建第一个 API key：开启 AUTH_REQUIRED 后接口（包括创建 key 的接口）都需要 key，只能从命令行创建；
+	// 命令行创建的是 admin key，可以再用它通过接口创建 member key
+	createAPIKey := flag.String("create-api-key", "", "创建指定名称的 admin API key，输出后退出")
+	flag.Parse()
+	if *storageDriver != "" {
+		os.Setenv("DB_DRIVER", *storageDriver)
 	}
 
-	// 初始化数据库
-	db, err := database.New(dbPath)
+	// 加载配置（环境变量）
+	cfg, err := config.LoadProfile(*profile)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.LogSettings()
+	if cfg.MemoryLimit > 0 && os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(cfg.MemoryLimit)
+	}
+	// 所有组件共用一个时钟
+	clk := clock.Real{}
+	cfg.Outbound.Breakers = breaker.NewSet(cfg.Breaker, clk)
+
+	// 初始化数据库：DB_DRIVER=memory 时所有数据都在内存中，不打开 DB_PATH
+	var db *database.DB
+	if cfg.DBDriver == "memory" {
+		if *createAPIKey != "" {
+			log.Fatalf("Failed to create API key: DB_DRIVER=memory 不保存数据，创建的 key 在退出后丢失")
+		}
+	} else {
+		db, err = database.New(cfg.DBPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		if *createAPIKey != "" {
+			key := model.APIKey{Name: *createAPIKey, UserID: handler.DefaultUserID, Role: model.APIKeyRoleAdmin}
+			if err := key.Validate(); err != nil {
+				log.Fatalf("Failed to create API key: %v", err)
+			}
+			raw := model.NewAPIKey(&key)
+			if err := db.CreateAPIKeyContext(context.Background(), &key, model.HashAPIKey(raw)); err != nil {
+				log.Fatalf("Failed to create API key: %v", err)
+			}
+			fmt.Println(raw)
+			db.Close()
+			return
+		}
+		db.SetClock(clk)
+		db.SetBatchLimit(cfg.BatchMaxSize)
+		db.ConfigurePool(cfg.Pool)
+		if cfg.Cache.Backend != nil {
+			db.SetCache(cfg.Cache.Backend, cfg.Cache.TodoTTL, cfg.Cache.StatsTTL)
+			log.Println("已启用 Redis 读缓存")
+		}
+	}
+
+	// 状态流转规则；存储按其中的终态区分未完成和已完成（未完成列表、逾期、统计、批量完成）
+	wf := workflow.Default()
+	if cfg.WorkflowFile != "" {
+		wf, err = workflow.Load(cfg.WorkflowFile)
+		if err != nil {
+			log.Fatalf("Failed to load workflow: %v", err)
+		}
+	}
+	statuses := storage.NewStatusSets(wf.Names(), wf.IsTerminal)
+	if db != nil {
+		db.SetStatuses(statuses)
+	}
+	// 输出待办事项时按同样的未完成状态计算 is_overdue
+	presenter := model.Presenter{Clock: clk, OpenStatuses: statuses.Open, Scale: cfg.PriorityScale}
+
+	// 待办事项和项目、通知、webhook 等数据默认保存在 SQLite 中，DB_DRIVER=postgres 时保存在 PostgreSQL 中，
+	// memory 时保存在内存中
+	var todos storage.TodoRepository
+	var repos repositories
+	var local handler.Store // 只保存在 SQLite 中的数据，memory 时为 nil
+	if db != nil {
+		todos, repos, local = db, db, db
+	}
+	var pg *postgres.Store
+	switch cfg.DBDriver {
+	case "postgres":
+		pg, err = postgres.Open(cfg.DBDSN)
+		if err != nil {
+			log.Fatalf("Failed to initialize postgres: %v", err)
+		}
+		pg.SetClock(clk)
+		pg.SetBatchLimit(cfg.BatchMaxSize)
+		pg.SetStatuses(statuses)
+		todos, repos = pg, pg
+		log.Println("待办事项和项目、通知、webhook 等数据使用 PostgreSQL 存储；后台任务队列、备份和待办事项附件仍在各实例的 DB_PATH 中")
+	case "memory":
+		mem := memory.New()
+		mem.SetClock(clk)
+		mem.SetBatchLimit(cfg.BatchMaxSize)
+		mem.SetStatuses(statuses)
+		todos, repos = mem, mem
+		log.Println("所有数据保存在内存中，重启后丢失；后台任务队列、备份和分片上传不可用")
 	}
 
 	// 创建处理器
-	h := handler.NewHandler(db)
+	h := handler.NewHandler(todos, repos, local, cfg)
+	h.SetClock(clk)
+	h.SetWorkflow(wf)
+
+	// 老化规则同时用于停滞视图和后台提醒
+	var agingRules *aging.Rules
+	if cfg.AgingRulesFile != "" {
+		agingRules, err = aging.LoadRules(cfg.AgingRulesFile)
+		if err == nil {
+			err = agingRules.CheckStatuses(wf)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load aging rules: %v", err)
+		}
+		h.SetAgingRules(agingRules)
+	}
+
+	// 通知分发：站内通知写入通知表，配置了 SMTP 时邮件通过它发送，其他渠道接入前先用日志占位
+	templates, err := mail.LoadTemplates(cfg.Mail.TemplateDir)
+	if err != nil {
+		log.Fatalf("Failed to load mail templates: %v", err)
+	}
+	var emailSender notify.Sender = notify.LogSender{ChannelName: model.ChannelEmail}
+	if cfg.Mail.Enabled() {
+		emailSender = notify.EmailSender{Mailer: &cfg.Mail.SMTP, Templates: templates, To: []string{cfg.Mail.To}}
+	}
+	dispatcher := notify.NewDispatcher(repos,
+		notify.InAppSender{Store: repos, Clock: clk},
+		emailSender,
+		notify.LogSender{ChannelName: model.ChannelWebhook},
+	)
+	dispatcher.SetClock(clk)
+	for _, sender := range extension.Notifiers() {
+		dispatcher.Register(sender)
+	}
+	if names := extension.Names(); len(names) > 0 {
+		log.Printf("已加载扩展: %v", names)
+	}
+
+	// 持久化任务队列：通知发送失败后在这里重试，重试次数用完进入死信；
+	// 导入、导出、批量操作和备份也可以通过接口提交到这里异步执行（GET /api/v1/jobs/{id} 查询）
+	// 任务保存在 SQLite 中，DB_DRIVER=memory 时没有队列，发送失败不重试
+	var queue *jobs.Queue
+	var retry webhook.RetryQueue
+	if db != nil {
+		queue = jobs.NewQueue(db, cfg.JobMaxAttempts, clk)
+		queue.Register(notify.RetryJobKind, dispatcher.HandleRetryJob)
+		dispatcher.SetRetryQueue(queue)
+		h.SetJobQueue(queue)
+		retry = queue
+	}
+	dispatcher.SetBreakers(cfg.Outbound.Breakers)
+
+	// 出站 webhook：首次推送失败的事件转入任务队列重试
+	webhooks := webhook.NewDispatcher(repos, outbound.NewClient(cfg.Outbound), retry, clk)
+	webhooks.SetPresenter(presenter)
+	h.SetWebhookDispatcher(webhooks)
+	if queue != nil {
+		queue.Register(webhook.RetryJobKind, webhooks.HandleRetryJob)
+		if err := queue.Recover(context.Background()); err != nil {
+			log.Fatalf("Failed to recover jobs: %v", err)
+		}
+	}
+
+	// 后台定时任务
+	sched := scheduler.New()
+	sched.SetClock(clk)
+	// 租约放在各实例共享的数据库中：DB_DRIVER=postgres 时每个实例的 SQLite 是本地文件，租约必须放在 PostgreSQL 中；
+	// memory 时只有一个实例，不需要租约
+	var locker scheduler.Locker
+	switch {
+	case pg != nil:
+		locker = pg
+	case db != nil:
+		locker = db
+	}
+	sched.SetLocker(locker, cfg.InstanceID)
+	// 只读模式下后台任务同样不能写入，只保留备份
+	sched.SetReadOnly(func() bool { return cfg.ReadOnly }, maintenance.JobBackup)
+	// 队列中有导入等长时间操作，超时放宽；提交任务后立即执行一轮，不必等到下次轮询
+	if queue != nil {
+		sched.Register("后台任务队列", cfg.JobPollInterval, 10*time.Minute, queue.Run)
+		queue.OnSubmit(func() {
+			if err := sched.RunNow("后台任务队列"); err != nil {
+				log.Printf("触发后台任务队列失败: %v", err)
+			}
+		})
+	}
+	if cfg.EscalationPolicyFile != "" {
+		policy, err := escalation.LoadPolicy(cfg.EscalationPolicyFile)
+		if err != nil {
+			log.Fatalf("Failed to load escalation policy: %v", err)
+		}
+		escalator := escalation.NewEscalator(policy, db, dispatcher, handler.DefaultUserID)
+		escalator.SetClock(clk)
+		sched.Register("逾期升级提醒", cfg.EscalationInterval, time.Minute, escalator.Run)
+	}
+	// 这些任务直接查询和修改 SQLite 的 todos 表，待办事项在其他存储中时不启动
+	// （逾期升级、日历邀请和工单导入在加载配置时已经拒绝，见 config.checkTodoStorage）
+	if cfg.DBDriver == "sqlite" {
+		if agingRules != nil {
+			alerter := aging.NewAlerter(agingRules, db, dispatcher, handler.DefaultUserID, clk)
+			sched.Register("停滞事项提醒", cfg.AgingInterval, time.Minute, alerter.Run)
+		}
+		sched.Register("习惯生成", cfg.HabitInterval, time.Minute, habits.NewGenerator(db, handler.DefaultUserID, clk).Run)
+		sched.Register(recurrence.TaskName, cfg.RecurrenceInterval, time.Minute, recurrence.NewSpawner(db, handler.DefaultUserID, clk).Run)
+	} else {
+		log.Printf("待办事项不在 SQLite 中（DB_DRIVER=%s），停滞事项提醒、习惯生成和重复事项生成不启动", cfg.DBDriver)
+	}
+	if db != nil {
+		sched.Register("清理过期上传", time.Hour, time.Minute, h.PurgeExpiredUploads)
+	}
+	sched.Register(webhook.TaskName, cfg.WebhookInterval, time.Minute, webhooks.Run)
+	// DB_DRIVER=postgres 时各实例通过 LISTEN/NOTIFY 互相通知新的变更事件：任一实例上的修改立即唤醒所有实例上的 SSE 连接，
+	// 并让 webhook 推送提前执行一轮（仍由持有租约的实例执行）；没有通知时两者按各自的间隔轮询
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	defer stopListening()
+	if pg != nil && cfg.EventNotify {
+		bus := eventbus.New()
+		go func() {
+			if err := pg.ListenTodoEvents(listenCtx, bus.Publish); err != nil {
+				log.Printf("变更事件通知不可用，SSE 和 webhook 推送退回轮询: %v", err)
+			}
+		}()
+		wake, cancel := bus.Subscribe()
+		go func() {
+			defer cancel()
+			for {
+				select {
+				case <-listenCtx.Done():
+					return
+				case <-wake:
+					if err := sched.RunNow(webhook.TaskName); err != nil {
+						log.Printf("触发 webhook 推送失败: %v", err)
+					}
+				}
+			}
+		}()
+		h.SetEventBus(bus)
+		log.Println("已启用 PostgreSQL 变更事件通知（LISTEN/NOTIFY）")
+	}
+	if cfg.Invites.Enabled {
+		inviter := invite.NewInviter(db, &cfg.Mail.SMTP, templates, wf, invite.Options{
+			MinPriority:   cfg.Invites.MinPriority,
+			To:            cfg.Mail.To,
+			From:          cfg.Mail.SMTP.From,
+			PriorityScale: cfg.PriorityScale,
+		}, handler.DefaultUserID, clk)
+		sched.Register(invite.TaskName, cfg.Invites.Interval, time.Minute, inviter.Run)
+	}
+	if cfg.Issues.Enabled() {
+		importer := issues.NewImporter(db, issueSources(cfg), issues.Options{
+			Project:       cfg.Issues.Project,
+			SyncStatus:    cfg.Issues.SyncStatus,
+			PriorityScale: cfg.PriorityScale,
+		}, handler.DefaultUserID, clk)
+		h.SetIssueImporter(importer)
+		if cfg.Issues.Interval > 0 {
+			sched.Register(issues.TaskName, cfg.Issues.Interval, 5*time.Minute, importer.Run)
+		}
+		log.Printf("已启用工单导入: %v", importer.Sources())
+	}
+
+	// 内置维护任务按 cron 计划执行，计划可以通过管理接口临时调整
+	schedule := maintenance.DefaultSchedule()
+	if cfg.ScheduleFile != "" {
+		schedule, err = maintenance.LoadSchedule(cfg.ScheduleFile)
+		if err != nil {
+			log.Fatalf("Failed to load schedule: %v", err)
+		}
+	}
+	// 后台任务和备份只在 SQLite 中，DB_DRIVER=memory 时只清理内存中的通知、变更事件和推送记录
+	var finishedJobs maintenance.JobPurgeStore
+	builtins := map[string]func(ctx context.Context){}
+	if db != nil {
+		finishedJobs = db
+		builtins[maintenance.JobBackup] = maintenance.NewBackup(db, cfg.BackupDir, cfg.BackupKeep, clk).Run
+	}
+	builtins[maintenance.JobPurge] = maintenance.NewPurger(repos, finishedJobs, cfg.PurgeRetention, clk).Run
+	for _, entry := range schedule.Jobs {
+		if entry.Disabled {
+			continue
+		}
+		run, ok := builtins[entry.Name]
+		if !ok {
+			log.Printf("%s 需要 SQLite（DB_DRIVER=%s），不启动", entry.Name, cfg.DBDriver)
+			continue
+		}
+		if err := sched.RegisterCron(entry.Name, entry.Schedule, 10*time.Minute, run); err != nil {
+			log.Fatalf("Failed to register %s: %v", entry.Name, err)
+		}
+	}
+	h.SetScheduler(sched)
+	sched.Start()
 
 	// 设置路由
 	mux := api.SetupRoutes(h)
-	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
 	// 配置 HTTP 服务器
 	server := &http.Server{
@@ -53,6 +360,11 @@ func main() {
 		MaxHeaderBytes: 1 << 20,          // 1MB 头部限制
 	}
 
+	// 摘流期间关闭 Keep-Alive，已建立的长连接处理完当前请求就断开，客户端重连时落到其他实例
+	h.OnDrain(func(draining bool) {
+		server.SetKeepAlivesEnabled(!draining)
+	})
+
 	// 优雅关闭 - 在 goroutine 中启动服务器
 	go func() {
 		log.Println("Server started on http://localhost:7789")
@@ -77,10 +389,23 @@ func main() {
 	// 记录收到的信号类型
 	log.Printf("收到信号 %v，开始优雅关闭...", sig)
 
+	// 先摘流：/ready 返回 503，等负载均衡摘除本实例后再停止接收请求；期间再收到一次信号则立即关闭
+	if wait := h.StartDrain(); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case sig = <-quit:
+			log.Printf("再次收到信号 %v，跳过摘流等待", sig)
+		}
+	}
+
 	// 等待最多30秒让现有请求完成
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// 先停止定时任务和变更事件监听，避免关闭数据库时它们还在运行
+	sched.Stop()
+	stopListening()
+
 	// 区分优雅关闭成功/失败，添加强制关闭逻辑
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("服务器关闭超时：%v，强制关闭", err)
@@ -93,11 +418,45 @@ func main() {
 	}
 
 	// 显式关闭数据库,记录详细日志
-	if err := db.Close(); err != nil {
-		log.Printf("数据库关闭失败：%v", err)
-	} else {
-		log.Println("数据库连接已关闭")
+	if db != nil {
+		if err := db.Close(); err != nil {
+			log.Printf("数据库关闭失败：%v", err)
+		} else {
+			log.Println("数据库连接已关闭")
+		}
+	}
+	if pg != nil {
+		if err := pg.Close(); err != nil {
+			log.Printf("PostgreSQL 连接关闭失败：%v", err)
+		}
 	}
 
 	log.Println("服务器已完全停止")
 }
+
+// repositories 项目、通知、变更事件、webhook 等数据所在的存储（database.DB 或 memory.Store），
+// 通知分发、webhook 推送和过期数据清理也从这里读写
+type repositories interface {
+	storage.Repositories
+	webhook.Store
+	maintenance.PurgeStore
+}
+
+// issueSources 按配置创建工单来源，请求通过出站客户端发出
+func issueSources(cfg *config.Config) []issues.Source {
+	client := outbound.NewClient(cfg.Outbound)
+	var sources []issues.Source
+	if cfg.Issues.JiraBaseURL != "" {
+		sources = append(sources, &issues.Jira{
+			BaseURL: cfg.Issues.JiraBaseURL,
+			Email:   cfg.Issues.JiraEmail,
+			Token:   cfg.Issues.JiraToken,
+			JQL:     cfg.Issues.JiraJQL,
+			Client:  client,
+		})
+	}
+	if cfg.Issues.LinearAPIKey != "" {
+		sources = append(sources, &issues.Linear{APIKey: cfg.Issues.LinearAPIKey, Client: client})
+	}
+	return sources
+}
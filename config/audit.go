@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/storage"
+)
+
+// redacted 密钥类配置在日志和管理接口中的显示值
+const redacted = "******"
+
+// Setting 一项生效的配置，Key 为对应的环境变量
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // env：来自环境变量；default：默认值；profile：来自配置档；flag：来自启动参数；generated：启动时生成
+}
+
+// settings 按顺序收集配置项
+type settings []Setting
+
+func (s *settings) add(key, value string) {
+	source := "default"
+	if os.Getenv(key) != "" {
+		source = "env"
+	}
+	*s = append(*s, Setting{Key: key, Value: value, Source: source})
+}
+
+// secret 密钥只显示是否设置，不显示内容
+func (s *settings) secret(key, value string) {
+	if value != "" {
+		value = redacted
+	}
+	s.add(key, value)
+}
+
+// url 隐藏地址中的密码
+func (s *settings) url(key, raw string) {
+	if u, err := url.Parse(raw); err == nil {
+		raw = u.Redacted()
+	}
+	s.add(key, raw)
+}
+
+func (s *settings) int(key string, n int) {
+	s.add(key, strconv.Itoa(n))
+}
+
+func (s *settings) bool(key string, b bool) {
+	s.add(key, strconv.FormatBool(b))
+}
+
+// duration 按环境变量的单位显示时长
+func (s *settings) duration(key string, d, unit time.Duration) {
+	s.add(key, strconv.FormatInt(int64(d/unit), 10))
+}
+
+// Settings 返回合并环境变量和默认值之后实际生效的配置，密钥已脱敏
+func (c *Config) Settings() []Setting {
+	var s settings
+
+	s.add("PROFILE", c.Profile)
+	if c.Profile != ProfileDefault && os.Getenv("PROFILE") == "" {
+		s[len(s)-1].Source = "flag"
+	}
+	s.add("DB_PATH", c.DBPath)
+	s.add("DB_DRIVER", c.DBDriver)
+	s.secret("DB_DSN", c.DBDSN)
+	s.int("DB_MAX_OPEN_CONNS", c.Pool.MaxOpenConns)
+	s.int("DB_MAX_IDLE_CONNS", c.Pool.MaxIdleConns)
+	s.duration("DB_CONN_MAX_LIFETIME_SECONDS", c.Pool.ConnMaxLifetime, time.Second)
+	s.duration("DB_CONN_MAX_IDLE_SECONDS", c.Pool.ConnMaxIdleTime, time.Second)
+	s.add("DB_SOFT_HEAP_LIMIT_MB", strconv.FormatInt(c.Pool.SoftHeapLimit>>20, 10))
+	s.add("MEMORY_LIMIT_MB", strconv.FormatInt(c.MemoryLimit>>20, 10))
+	s.add("INSTANCE_ID", c.InstanceID)
+
+	s.secret("SHARE_SECRET", c.ShareSecret)
+	if os.Getenv("SHARE_SECRET") == "" {
+		s[len(s)-1].Source = "generated"
+	}
+	s.duration("SHARE_LINK_TTL_HOURS", c.ShareLinkTTL, time.Hour)
+
+	s.add("ESCALATION_POLICY_FILE", c.EscalationPolicyFile)
+	s.duration("ESCALATION_INTERVAL_MINUTES", c.EscalationInterval, time.Minute)
+	s.add("AGING_RULES_FILE", c.AgingRulesFile)
+	s.duration("AGING_INTERVAL_MINUTES", c.AgingInterval, time.Minute)
+	s.duration("HABIT_INTERVAL_MINUTES", c.HabitInterval, time.Minute)
+	s.duration("RECURRENCE_INTERVAL_MINUTES", c.RecurrenceInterval, time.Minute)
+	s.add("WORKFLOW_FILE", c.WorkflowFile)
+
+	s.int("JOB_MAX_ATTEMPTS", c.JobMaxAttempts)
+	s.duration("JOB_POLL_SECONDS", c.JobPollInterval, time.Second)
+	s.duration("WEBHOOK_POLL_SECONDS", c.WebhookInterval, time.Second)
+	s.bool("EVENT_NOTIFY", c.EventNotify)
+	s.add("SCHEDULE_FILE", c.ScheduleFile)
+	s.add("BACKUP_DIR", c.BackupDir)
+	s.int("BACKUP_KEEP", c.BackupKeep)
+	s.duration("PURGE_RETENTION_DAYS", c.PurgeRetention, 24*time.Hour)
+	s.add("UPLOAD_DIR", c.UploadDir)
+	s.duration("UPLOAD_TTL_HOURS", c.UploadTTL, time.Hour)
+
+	s.secret("GITHUB_WEBHOOK_SECRET", c.GitHubWebhookSecret)
+	s.secret("SLACK_SIGNING_SECRET", c.SlackSigningSecret)
+	s.secret("MAILGUN_SIGNING_KEY", c.MailgunSigningKey)
+
+	s.add("JIRA_BASE_URL", c.Issues.JiraBaseURL)
+	s.add("JIRA_EMAIL", c.Issues.JiraEmail)
+	s.secret("JIRA_API_TOKEN", c.Issues.JiraToken)
+	s.add("JIRA_JQL", c.Issues.JiraJQL)
+	s.secret("LINEAR_API_KEY", c.Issues.LinearAPIKey)
+	s.add("ISSUE_IMPORT_PROJECT", c.Issues.Project)
+	s.duration("ISSUE_SYNC_INTERVAL_MINUTES", c.Issues.Interval, time.Minute)
+	s.bool("ISSUE_SYNC_STATUS", c.Issues.SyncStatus)
+
+	s.add("SMTP_HOST", c.Mail.SMTP.Host)
+	s.int("SMTP_PORT", c.Mail.SMTP.Port)
+	s.add("SMTP_USERNAME", c.Mail.SMTP.Username)
+	s.secret("SMTP_PASSWORD", c.Mail.SMTP.Password)
+	s.add("SMTP_FROM", c.Mail.SMTP.From)
+	s.add("SMTP_SECURITY", c.Mail.SMTP.Security)
+	s.add("MAIL_TO", c.Mail.To)
+	s.add("MAIL_TEMPLATE_DIR", c.Mail.TemplateDir)
+	s.bool("CALENDAR_INVITES", c.Invites.Enabled)
+	s.int("CALENDAR_INVITE_MIN_PRIORITY", c.Invites.MinPriority)
+	s.duration("CALENDAR_INVITE_INTERVAL_MINUTES", c.Invites.Interval, time.Minute)
+
+	proxy := ""
+	if c.Outbound.Proxy != nil {
+		proxy = c.Outbound.Proxy.Redacted()
+	}
+	s.add("OUTBOUND_PROXY", proxy)
+	s.duration("OUTBOUND_TIMEOUT_SECONDS", c.Outbound.Timeout, time.Second)
+	s.add("OUTBOUND_MAX_RESPONSE_BYTES", strconv.FormatInt(c.Outbound.MaxResponseBytes, 10))
+	s.add("OUTBOUND_ALLOW_HOSTS", strings.Join(c.Outbound.AllowHosts, ","))
+	s.add("OUTBOUND_DENY_HOSTS", strings.Join(c.Outbound.DenyHosts, ","))
+	s.add("OUTBOUND_ALLOW_CIDRS", os.Getenv("OUTBOUND_ALLOW_CIDRS"))
+	s.add("OUTBOUND_DENY_CIDRS", os.Getenv("OUTBOUND_DENY_CIDRS"))
+	s.int("BREAKER_THRESHOLD", c.Breaker.Threshold)
+	s.duration("BREAKER_COOLDOWN_SECONDS", c.Breaker.Cooldown, time.Second)
+
+	s.int("QUOTA_MAX_TODOS", c.Quota.MaxTodos)
+	s.int("QUOTA_MAX_TODOS_PER_DAY", c.Quota.MaxTodosPerDay)
+	s.int("QUOTA_MAX_LINKS_PER_TODO", c.Quota.MaxLinksPerTodo)
+	s.int("QUOTA_MAX_WEBHOOKS", c.Quota.MaxWebhooks)
+	s.add("QUOTA_MAX_ATTACHMENT_BYTES", strconv.FormatInt(c.Quota.MaxAttachmentBytes, 10))
+	s.int("RATE_LIMIT_PER_MINUTE", c.RateLimitPerMinute)
+	s.bool("RATE_LIMIT_SOFT", c.RateLimitSoft)
+	s.add("CONCURRENCY_LIMITS", formatConcurrencyLimits(c.ConcurrencyLimits))
+	s.int("BATCH_MAX_SIZE", c.BatchMaxSize)
+	s.int("DEFAULT_PAGE_SIZE", c.DefaultPageSize)
+	s.int("MAX_PAGE_SIZE", c.MaxPageSize)
+	s.add("PRIORITY_LABELS", c.PriorityScale.String())
+	s.int("MAX_TITLE_LENGTH", c.TextLimits.MaxTitle)
+	s.int("MAX_DESCRIPTION_LENGTH", c.TextLimits.MaxDescription)
+	s.bool("REJECT_PAST_DUE_DATES", c.RejectPastDueDates)
+	s.bool("STRICT_VERSIONING", c.StrictVersioning)
+
+	s.bool("READ_ONLY", c.ReadOnly)
+	s.bool("AUTH_REQUIRED", c.AuthRequired)
+	s.add("ADMIN_SUBJECTS", strings.Join(c.AdminSubjects, ","))
+	s.duration("DRAIN_PERIOD_SECONDS", c.DrainPeriod, time.Second)
+	s.bool("LEGACY_ROUTES", c.LegacyRoutes)
+	s.bool("LINK_PREVIEW", c.LinkPreview)
+	s.int("TRAFFIC_LOG_SIZE", c.Traffic.LogSize)
+	s.add("TRAFFIC_HEAVY_BYTES", strconv.FormatInt(c.Traffic.HeavyBytes, 10))
+	s.duration("TRAFFIC_SLOW_MS", c.Traffic.Slow, time.Millisecond)
+	sunset := ""
+	if !c.LegacySunset.IsZero() {
+		sunset = c.LegacySunset.Format("2006-01-02")
+	}
+	s.add("LEGACY_SUNSET_DATE", sunset)
+
+	s.add("ATTACHMENT_DIR", c.Attachments.Dir)
+	s.add("ATTACHMENT_MAX_BYTES", strconv.FormatInt(c.Attachments.MaxBytes, 10))
+	s.add("ATTACHMENT_SCAN_COMMAND", os.Getenv("ATTACHMENT_SCAN_COMMAND"))
+	s.add("CLAMD_ADDRESS", os.Getenv("CLAMD_ADDRESS"))
+	s.bool("ATTACHMENT_REQUIRE_SCAN", c.Attachments.RequireScan)
+	if store, ok := c.Attachments.Storage.(*storage.S3Store); ok {
+		s.add("STORAGE_BACKEND", "s3")
+		s.add("S3_ENDPOINT", store.Endpoint.Redacted())
+		s.add("S3_REGION", store.Region)
+		s.add("S3_BUCKET", store.Bucket)
+		s.secret("S3_ACCESS_KEY", store.AccessKey)
+		s.secret("S3_SECRET_KEY", store.SecretKey)
+		s.bool("S3_PATH_STYLE", store.PathStyle)
+		s.duration("S3_PRESIGN_TTL_MINUTES", c.Attachments.PresignTTL, time.Minute)
+	} else {
+		s.add("STORAGE_BACKEND", "local")
+	}
+
+	s.url("REDIS_URL", os.Getenv("REDIS_URL"))
+	s.duration("CACHE_TODO_TTL_SECONDS", c.Cache.TodoTTL, time.Second)
+	s.duration("CACHE_STATS_TTL_SECONDS", c.Cache.StatsTTL, time.Second)
+
+	var flags []string
+	for flag, enabled := range c.Features {
+		flags = append(flags, string(flag)+"="+strconv.FormatBool(enabled))
+	}
+	sort.Strings(flags)
+	s.add("FEATURE_FLAGS", strings.Join(flags, ","))
+
+	s.bool("SWAGGER_ENABLED", c.Swagger.Enabled)
+	s.add("SWAGGER_USER", c.Swagger.User)
+	s.secret("SWAGGER_PASSWORD", c.Swagger.Password)
+
+	for i := range s {
+		if c.profileKeys[s[i].Key] {
+			s[i].Source = "profile"
+		}
+	}
+	return s
+}
+
+// LogSettings 启动时把生效的配置以一行 JSON 写入日志，方便排查“为什么用了错误的配置”
+func (c *Config) LogSettings() {
+	data, err := json.Marshal(c.Settings())
+	if err != nil {
+		log.Printf("failed to encode config: %v", err)
+		return
+	}
+	log.Printf("effective config: %s", data)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 可以限制并发的接口分组（CONCURRENCY_LIMITS 中的名称）
+const (
+	ConcurrencyExport  = "export"  // 导出待办事项、导出工作区归档
+	ConcurrencyImport  = "import"  // 导入待办事项、导入工作区归档
+	ConcurrencyRebuild = "rebuild" // 重建统计计数
+)
+
+// DefaultConcurrencyLimits 未设置 CONCURRENCY_LIMITS 时各分组的并发上限
+// SQLite 同一时间只有一个写连接，导入和重建同时只执行一个，多出的请求直接返回 503，不排队占用连接
+func DefaultConcurrencyLimits() map[string]int {
+	return map[string]int{
+		ConcurrencyExport:  2,
+		ConcurrencyImport:  1,
+		ConcurrencyRebuild: 1,
+	}
+}
+
+// parseConcurrencyLimits 解析 "export=4,import=1" 格式的并发上限，写入 limits
+// 未列出的分组保持原值，0 表示不限制
+func parseConcurrencyLimits(s string, limits map[string]int) error {
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return fmt.Errorf("格式应为 分组=数量：%q", part)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := limits[name]; !known {
+			return fmt.Errorf("未知的分组 %q", name)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return fmt.Errorf("数量无效：%q", part)
+		}
+		limits[name] = n
+	}
+	return nil
+}
+
+// formatConcurrencyLimits 按 CONCURRENCY_LIMITS 的格式输出，分组按名称排序
+func formatConcurrencyLimits(limits map[string]int) string {
+	parts := make([]string, 0, len(limits))
+	for name, n := range limits {
+		parts = append(parts, name+"="+strconv.Itoa(n))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
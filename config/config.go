@@ -0,0 +1,1128 @@
+// Package config 从环境变量加载服务配置
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/breaker"
+	"todo-list/cache"
+	"todo-list/database"
+	"todo-list/features"
+	"todo-list/mail"
+	"todo-list/model"
+	"todo-list/outbound"
+	"todo-list/scan"
+	"todo-list/storage"
+	"todo-list/traffic"
+)
+
+// Config 服务配置
+type Config struct {
+	DBPath string // 数据库文件路径（DB_PATH），DB_DRIVER=memory 时不使用
+
+	// 待办事项的存储后端（DB_DRIVER）：sqlite（默认，保存在 DBPath）、postgres（连接串为 DB_DSN）或 memory（不持久化）
+	// postgres 时待办事项和项目、评论、变更事件、webhook、API key、工作区等保存在 PostgreSQL 中，多个实例可以共用，见 database/postgres；
+	// 后台任务、备份和分片上传仍在各实例的 DBPath 中。memory 时这些数据保存在内存中，不打开 DBPath，见 storage/memory。
+	// 链接、附件、目标、习惯等只能在 SQLite 中使用的接口返回 FEATURE_DISABLED（见 handler.RequireSQLiteTodos），相关后台任务不启动
+	DBDriver string
+	DBDSN    string
+
+	// 分享链接
+	ShareSecret     string        // 分享链接签名密钥（SHARE_SECRET），为空时启动随机生成
+	ShareLinkTTL    time.Duration // 分享链接默认有效期（SHARE_LINK_TTL_HOURS）
+	ShareLinkMaxTTL time.Duration // 分享链接最长有效期
+
+	// 逾期升级提醒
+	EscalationPolicyFile string        // 升级策略文件（ESCALATION_POLICY_FILE），为空表示不启用
+	EscalationInterval   time.Duration // 检查间隔（ESCALATION_INTERVAL_MINUTES）
+
+	// 停滞事项提醒
+	AgingRulesFile string        // 老化规则文件（AGING_RULES_FILE），为空表示不启用
+	AgingInterval  time.Duration // 检查间隔（AGING_INTERVAL_MINUTES）
+
+	// 习惯：每隔 HabitInterval 检查一次是否需要生成新周期的待办事项（HABIT_INTERVAL_MINUTES）
+	HabitInterval time.Duration
+
+	// 重复待办事项：完成时立即生成下一次，另外每隔 RecurrenceInterval 补查一次批量完成等途径遗漏的（RECURRENCE_INTERVAL_MINUTES）
+	RecurrenceInterval time.Duration
+
+	// 后台任务队列：每个任务最多执行 JobMaxAttempts 次（JOB_MAX_ATTEMPTS），
+	// 每隔 JobPollInterval 检查一次到期任务（JOB_POLL_SECONDS）
+	JobMaxAttempts  int
+	JobPollInterval time.Duration
+
+	// 出站 webhook：每隔 WebhookInterval 检查一次新的变更事件并推送（WEBHOOK_POLL_SECONDS）
+	WebhookInterval time.Duration
+
+	// DB_DRIVER=postgres 时通过 LISTEN/NOTIFY 把新的变更事件通知到所有实例，SSE 连接和出站 webhook 立即读取（EVENT_NOTIFY，默认开启）；
+	// 经过不支持 LISTEN 的连接池（如 PgBouncer 的事务模式）时关闭，退回轮询
+	EventNotify bool
+
+	// 本实例的标识（INSTANCE_ID），默认为 主机名-进程号
+	// 多个实例共用数据库时，用它区分定时任务租约的持有者，保证每个任务同一时刻只有一个实例执行
+	InstanceID string
+
+	// 内置维护任务（备份、清理）的 cron 计划文件（SCHEDULE_FILE），为空时使用默认计划
+	ScheduleFile string
+	// 数据库备份目录（BACKUP_DIR）和保留的备份份数（BACKUP_KEEP）
+	BackupDir  string
+	BackupKeep int
+	// 已完成的后台任务、已读通知、待办事项变更事件和 webhook 推送记录的保留天数（PURGE_RETENTION_DAYS）
+	PurgeRetention time.Duration
+	// 分片上传的暂存目录（UPLOAD_DIR）和未完成上传的保留时间（UPLOAD_TTL_HOURS）
+	// 多实例部署时暂存目录必须是共享目录，否则断点续传可能落到没有前几段数据的实例上
+	UploadDir string
+	UploadTTL time.Duration
+
+	// 自定义状态工作流文件（WORKFLOW_FILE），为空时只有 pending / completed
+	WorkflowFile string
+
+	// 入站 webhook 签名密钥，未设置的集成不启用
+	GitHubWebhookSecret string // GITHUB_WEBHOOK_SECRET
+	SlackSigningSecret  string // SLACK_SIGNING_SECRET
+	MailgunSigningKey   string // MAILGUN_SIGNING_KEY
+
+	// 工单导入（JIRA_*、LINEAR_*、ISSUE_*），见 loadIssues；没有配置任何来源时不启用
+	Issues Issues
+
+	// 发送邮件（SMTP_*、MAIL_*），见 loadMail；未设置 SMTP_HOST 时邮件渠道只写日志
+	Mail Mail
+
+	// 截止日期日历邀请（CALENDAR_INVITE*），见 loadInvites；需要先配置邮件
+	Invites Invites
+
+	// 出站 HTTP 请求（OUTBOUND_*），见 loadOutbound
+	Outbound outbound.Options
+
+	// 外部集成熔断：连续失败 BREAKER_THRESHOLD 次后熔断 BREAKER_COOLDOWN_SECONDS 秒，阈值为 0 表示不启用
+	// 熔断器集合由 main 按该配置创建，同时用于通知渠道和出站请求（Outbound.Breakers）
+	Breaker breaker.Options
+
+	// 配额，0 表示不限制；工作区自己设置了 max_todos 时以工作区为准
+	Quota Quota
+
+	// 限流：每个客户端每分钟的请求数（RATE_LIMIT_PER_MINUTE），0 表示不启用
+	// RateLimitSoft 为 true（RATE_LIMIT_SOFT）时只返回 X-RateLimit-* 头，超限也不拒绝请求
+	RateLimitPerMinute int
+	RateLimitSoft      bool
+
+	// 导出、导入、重建统计等开销大的接口按分组限制同时执行的请求数（CONCURRENCY_LIMITS，
+	// 例如 "export=2,import=1,rebuild=1"），超出时返回 503；0 表示不限制，见 DefaultConcurrencyLimits
+	ConcurrencyLimits map[string]int
+
+	// 数据库连接池（DB_MAX_OPEN_CONNS、DB_MAX_IDLE_CONNS、DB_CONN_MAX_LIFETIME_SECONDS、
+	// DB_CONN_MAX_IDLE_SECONDS、DB_SOFT_HEAP_LIMIT_MB），未设置的项保持驱动默认值
+	Pool database.PoolOptions
+
+	// 运行配置档（--profile 或 PROFILE），见 profiles；profileKeys 为配置档实际提供的环境变量，用于配置审计
+	Profile     string
+	profileKeys map[string]bool
+
+	// Go 运行时的内存软上限（MEMORY_LIMIT_MB），0 表示不限制；已设置 GOMEMLIMIT 时以 GOMEMLIMIT 为准
+	MemoryLimit int64
+
+	// 添加链接后是否在后台抓取标题和图标（LINK_PREVIEW），关闭后链接状态为 skipped
+	LinkPreview bool
+
+	// 请求流量统计：保留最近 TRAFFIC_LOG_SIZE 条响应超过 TRAFFIC_HEAVY_BYTES 字节或耗时超过 TRAFFIC_SLOW_MS 的请求，
+	// 通过 GET /api/v1/admin/traffic 查看；TRAFFIC_LOG_SIZE 为 0 时只统计各接口的总量
+	Traffic traffic.Options
+
+	// 单次批量操作的最大 ID 数量（BATCH_MAX_SIZE）
+	BatchMaxSize int
+
+	// 列表分页：不传 limit 时每页 DefaultPageSize 条（DEFAULT_PAGE_SIZE），
+	// 超过 MaxPageSize（MAX_PAGE_SIZE）时按上限返回并在响应中给出 warning
+	DefaultPageSize int
+	MaxPageSize     int
+
+	// 优先级名称与数值的映射（PRIORITY_LABELS，例如 low=0,medium=1,high=2,urgent=3），
+	// 接口同时返回 priority 和 priority_label，请求中两种写法都接受
+	PriorityScale model.PriorityScale
+
+	// 旧版 /api/todos 路由：LEGACY_ROUTES=false 时完全不注册；
+	// 注册时返回 Deprecation 头，设置了 LEGACY_SUNSET_DATE（YYYY-MM-DD）时再加 Sunset 头
+	LegacyRoutes bool
+	LegacySunset time.Time
+
+	// 只读模式（READ_ONLY）：所有修改请求返回 503，用于公开只读镜像或数据迁移期间；
+	// 会修改数据的定时任务（习惯、重复事项、提醒、任务队列、清理等）也暂停，只有备份照常执行
+	ReadOnly bool
+
+	// 必须认证（AUTH_REQUIRED）：没有认证扩展时所有接口都必须带 X-API-Key；
+	// 不开启时，创建过 API key 之后同样必须带 key，之前（本机单用户使用）不检查。
+	// 第一个 key 用 --create-api-key 命令行参数创建
+	AuthRequired bool
+
+	// 管理员（ADMIN_SUBJECTS）：逗号分隔，认证扩展返回的这些请求方可以调用 /api/v1/admin/* 管理接口（扩展没有返回请求方标识时不是管理员）；
+	// 使用 API key 时按 key 的角色判断，不看这里
+	AdminSubjects []string
+
+	// 摘流等待时间（DRAIN_PERIOD_SECONDS）：收到 SIGTERM 或调用 POST /api/v1/admin/drain 后，
+	// /ready 返回 503 并继续处理请求这么久，再开始关闭；应大于负载均衡健康检查的间隔乘以失败次数，0 表示不等待
+	DrainPeriod time.Duration
+
+	// 附件（ATTACHMENT_*），见 loadAttachments
+	Attachments Attachments
+
+	// 读缓存（REDIS_URL），见 loadCache；未设置时不使用缓存
+	Cache Cache
+
+	// 标题、描述的最大字符数（MAX_TITLE_LENGTH、MAX_DESCRIPTION_LENGTH），0 表示不限制
+	TextLimits model.TextLimits
+
+	// 拒绝早于当前时间的截止日期（REJECT_PAST_DUE_DATES），默认允许（例如补录已过期的事项）
+	RejectPastDueDates bool
+
+	// 严格乐观锁（STRICT_VERSIONING）：更新必须带 version 或 If-Match，否则返回 428
+	StrictVersioning bool
+
+	// 接口文档（SWAGGER_*），见 loadSwagger
+	Swagger Swagger
+
+	// 实验性功能开关（FEATURE_FLAGS），例如 "fts_search,graphql=false"，未列出的开关为关闭
+	// 运行时可以通过 /api/v1/admin/features 临时切换，见 features 包
+	Features map[features.Flag]bool
+}
+
+// Swagger 接口文档配置
+// 生产环境建议关闭，或者设置用户名和密码，避免把可调试的接口文档暴露在公网
+type Swagger struct {
+	Enabled  bool   // 是否提供 /swagger/（SWAGGER_ENABLED），默认开启
+	User     string // Basic 认证用户名（SWAGGER_USER），和 SWAGGER_PASSWORD 同时设置时才要求认证
+	Password string // Basic 认证密码（SWAGGER_PASSWORD）
+}
+
+// Attachments 附件存储和扫描配置
+type Attachments struct {
+	Dir         string        // 本地存储目录（ATTACHMENT_DIR），使用对象存储时忽略
+	Storage     storage.Store // STORAGE_BACKEND=s3 时为 S3Store，否则为本地目录
+	PresignTTL  time.Duration // 对象存储临时下载地址的有效期（S3_PRESIGN_TTL_MINUTES）
+	MaxBytes    int64         // 单个附件大小上限（ATTACHMENT_MAX_BYTES）
+	Scanner     scan.Scanner  // 病毒扫描器，未配置时为 nil
+	RequireScan bool          // 为 true（ATTACHMENT_REQUIRE_SCAN）时没有扫描器就拒绝上传
+}
+
+// Issues 从 Jira、Linear 导入指派给自己的工单
+type Issues struct {
+	JiraBaseURL  string        // Jira 地址（JIRA_BASE_URL），例如 https://example.atlassian.net
+	JiraEmail    string        // Jira Cloud 账号邮箱（JIRA_EMAIL），为空时 JIRA_API_TOKEN 按 Server / Data Center 的个人访问令牌使用
+	JiraToken    string        // JIRA_API_TOKEN
+	JiraJQL      string        // 拉取工单的 JQL（JIRA_JQL），为空时取指派给自己的未完成工单
+	LinearAPIKey string        // Linear 个人 API key（LINEAR_API_KEY）
+	Project      string        // 导入到的项目名称（ISSUE_IMPORT_PROJECT），不存在时创建
+	Interval     time.Duration // 同步间隔（ISSUE_SYNC_INTERVAL_MINUTES），0 表示只在调用同步接口时执行
+	SyncStatus   bool          // 是否按外部状态完成 / 重新打开已导入的待办事项（ISSUE_SYNC_STATUS）
+}
+
+// Enabled 是否配置了至少一个来源
+func (i Issues) Enabled() bool {
+	return i.JiraBaseURL != "" || i.LinearAPIKey != ""
+}
+
+// Mail 邮件发送配置
+type Mail struct {
+	SMTP        mail.SMTP // SMTP 服务器和发件地址
+	To          string    // 收件人（MAIL_TO），邮件通知和日历邀请都发到这里
+	TemplateDir string    // 覆盖内置邮件模板的目录（MAIL_TEMPLATE_DIR），见 mail.LoadTemplates
+}
+
+// Enabled 是否配置了 SMTP 服务器
+func (m Mail) Enabled() bool {
+	return m.SMTP.Host != ""
+}
+
+// Invites 为高优先级待办事项的截止日期发送日历邀请
+type Invites struct {
+	Enabled     bool          // CALENDAR_INVITES，默认关闭
+	MinPriority int           // 只为优先级不低于它的待办事项发送（CALENDAR_INVITE_MIN_PRIORITY，数值或名称，默认 high）
+	Interval    time.Duration // 检查间隔（CALENDAR_INVITE_INTERVAL_MINUTES），修改截止日期后也会立即检查一次
+}
+
+// Cache 热点读取缓存配置
+type Cache struct {
+	Backend  cache.Cache   // 未启用时为 nil
+	TodoTTL  time.Duration // 单个待办事项的缓存时间（CACHE_TODO_TTL_SECONDS）
+	StatsTTL time.Duration // 统计信息的缓存时间（CACHE_STATS_TTL_SECONDS），统计含“今天到期”等随时间变化的数据，不宜过长
+}
+
+// Quota 每个工作区的资源配额
+type Quota struct {
+	MaxTodos        int // 待办事项总数上限（QUOTA_MAX_TODOS）
+	MaxTodosPerDay  int // 每天最多新建的待办事项数（QUOTA_MAX_TODOS_PER_DAY），超出返回 429
+	MaxLinksPerTodo int // 每个待办事项的链接数上限（QUOTA_MAX_LINKS_PER_TODO）
+	MaxWebhooks     int // 出站 webhook 数量上限（QUOTA_MAX_WEBHOOKS）
+
+	MaxAttachmentBytes int64 // 附件总大小上限（QUOTA_MAX_ATTACHMENT_BYTES），单个附件的上限见 Attachments.MaxBytes
+}
+
+// Load 从环境变量加载配置，未设置的项使用默认值，配置档取 PROFILE
+func Load() (*Config, error) {
+	return LoadProfile(os.Getenv("PROFILE"))
+}
+
+// LoadProfile 按配置档加载配置，为空时使用 default；配置档只提供默认值，环境变量优先，见 profiles
+func LoadProfile(profile string) (*Config, error) {
+	if profile == "" {
+		profile = ProfileDefault
+	}
+	applied, err := applyProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Profile:     profile,
+		profileKeys: applied,
+
+		DBPath:          getEnv("DB_PATH", "./todos.db"),
+		DBDriver:        getEnv("DB_DRIVER", "sqlite"),
+		DBDSN:           os.Getenv("DB_DSN"),
+		ShareSecret:     os.Getenv("SHARE_SECRET"),
+		ShareLinkTTL:    7 * 24 * time.Hour,
+		ShareLinkMaxTTL: 30 * 24 * time.Hour,
+
+		EscalationPolicyFile: os.Getenv("ESCALATION_POLICY_FILE"),
+		EscalationInterval:   time.Hour,
+
+		AgingRulesFile: os.Getenv("AGING_RULES_FILE"),
+		AgingInterval:  time.Hour,
+
+		HabitInterval:      15 * time.Minute,
+		RecurrenceInterval: 15 * time.Minute,
+
+		JobMaxAttempts:  5,
+		JobPollInterval: 30 * time.Second,
+		WebhookInterval: 5 * time.Second,
+		EventNotify:     true,
+
+		InstanceID: os.Getenv("INSTANCE_ID"),
+
+		ScheduleFile:   os.Getenv("SCHEDULE_FILE"),
+		BackupDir:      getEnv("BACKUP_DIR", "./backups"),
+		BackupKeep:     7,
+		PurgeRetention: 30 * 24 * time.Hour,
+		UploadDir:      getEnv("UPLOAD_DIR", "./uploads"),
+		UploadTTL:      24 * time.Hour,
+
+		WorkflowFile: os.Getenv("WORKFLOW_FILE"),
+
+		GitHubWebhookSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		SlackSigningSecret:  os.Getenv("SLACK_SIGNING_SECRET"),
+		MailgunSigningKey:   os.Getenv("MAILGUN_SIGNING_KEY"),
+
+		Outbound: outbound.DefaultOptions(),
+		Breaker: breaker.Options{
+			Threshold: breaker.DefaultThreshold,
+			Cooldown:  breaker.DefaultCooldown,
+		},
+
+		Attachments: Attachments{
+			Dir:        getEnv("ATTACHMENT_DIR", "./attachments"),
+			PresignTTL: 15 * time.Minute,
+			MaxBytes:   10 << 20,
+		},
+
+		Cache: Cache{
+			TodoTTL:  5 * time.Minute,
+			StatsTTL: 30 * time.Second,
+		},
+
+		TextLimits: model.TextLimits{
+			MaxTitle:       model.DefaultMaxTitleLength,
+			MaxDescription: model.DefaultMaxDescriptionLength,
+		},
+
+		BatchMaxSize: 100,
+
+		DefaultPageSize: 50,
+		MaxPageSize:     200,
+
+		PriorityScale: model.DefaultPriorityScale,
+
+		LegacyRoutes: true,
+		LinkPreview:  true,
+
+		Traffic: traffic.Options{
+			LogSize:    traffic.DefaultLogSize,
+			HeavyBytes: traffic.DefaultHeavyBytes,
+			Slow:       traffic.DefaultSlow,
+		},
+
+		DrainPeriod: 15 * time.Second,
+
+		Swagger: Swagger{Enabled: true},
+
+		Issues: Issues{
+			Project:    "工单",
+			Interval:   30 * time.Minute,
+			SyncStatus: true,
+		},
+
+		Invites: Invites{Interval: 5 * time.Minute},
+	}
+
+	if err := loadOutbound(&cfg.Outbound); err != nil {
+		return nil, err
+	}
+	if err := loadAttachments(&cfg.Attachments); err != nil {
+		return nil, err
+	}
+	if err := loadCache(&cfg.Cache); err != nil {
+		return nil, err
+	}
+	if err := loadSwagger(&cfg.Swagger); err != nil {
+		return nil, err
+	}
+	if err := loadIssues(&cfg.Issues); err != nil {
+		return nil, err
+	}
+	if err := loadMail(&cfg.Mail); err != nil {
+		return nil, err
+	}
+
+	for _, q := range []struct {
+		key    string
+		target *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &cfg.Pool.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &cfg.Pool.MaxIdleConns},
+		{"QUOTA_MAX_TODOS", &cfg.Quota.MaxTodos},
+		{"QUOTA_MAX_TODOS_PER_DAY", &cfg.Quota.MaxTodosPerDay},
+		{"QUOTA_MAX_LINKS_PER_TODO", &cfg.Quota.MaxLinksPerTodo},
+		{"QUOTA_MAX_WEBHOOKS", &cfg.Quota.MaxWebhooks},
+		{"RATE_LIMIT_PER_MINUTE", &cfg.RateLimitPerMinute},
+		{"BREAKER_THRESHOLD", &cfg.Breaker.Threshold},
+		{"TRAFFIC_LOG_SIZE", &cfg.Traffic.LogSize},
+		{"MAX_TITLE_LENGTH", &cfg.TextLimits.MaxTitle},
+		{"MAX_DESCRIPTION_LENGTH", &cfg.TextLimits.MaxDescription},
+	} {
+		if v := os.Getenv(q.key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s: %q", q.key, v)
+			}
+			*q.target = n
+		}
+	}
+
+	if v := os.Getenv("QUOTA_MAX_ATTACHMENT_BYTES"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid QUOTA_MAX_ATTACHMENT_BYTES: %q", v)
+		}
+		cfg.Quota.MaxAttachmentBytes = size
+	}
+
+	if v := os.Getenv("SHARE_LINK_TTL_HOURS"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours <= 0 {
+			return nil, fmt.Errorf("invalid SHARE_LINK_TTL_HOURS: %q", v)
+		}
+		cfg.ShareLinkTTL = time.Duration(hours) * time.Hour
+	}
+
+	if v := os.Getenv("ESCALATION_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("invalid ESCALATION_INTERVAL_MINUTES: %q", v)
+		}
+		cfg.EscalationInterval = time.Duration(minutes) * time.Minute
+	}
+
+	if v := os.Getenv("AGING_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("invalid AGING_INTERVAL_MINUTES: %q", v)
+		}
+		cfg.AgingInterval = time.Duration(minutes) * time.Minute
+	}
+
+	if v := os.Getenv("HABIT_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("invalid HABIT_INTERVAL_MINUTES: %q", v)
+		}
+		cfg.HabitInterval = time.Duration(minutes) * time.Minute
+	}
+
+	if v := os.Getenv("RECURRENCE_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Errorf("invalid RECURRENCE_INTERVAL_MINUTES: %q", v)
+		}
+		cfg.RecurrenceInterval = time.Duration(minutes) * time.Minute
+	}
+
+	if v := os.Getenv("JOB_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			return nil, fmt.Errorf("invalid JOB_MAX_ATTEMPTS: %q", v)
+		}
+		cfg.JobMaxAttempts = n
+	}
+
+	if v := os.Getenv("JOB_POLL_SECONDS"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid JOB_POLL_SECONDS: %q", v)
+		}
+		cfg.JobPollInterval = time.Duration(seconds) * time.Second
+	}
+
+	if v := os.Getenv("WEBHOOK_POLL_SECONDS"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid WEBHOOK_POLL_SECONDS: %q", v)
+		}
+		cfg.WebhookInterval = time.Duration(seconds) * time.Second
+	}
+
+	if v := os.Getenv("EVENT_NOTIFY"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EVENT_NOTIFY: %q", v)
+		}
+		cfg.EventNotify = enabled
+	}
+
+	for _, d := range []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"DB_CONN_MAX_LIFETIME_SECONDS", &cfg.Pool.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_SECONDS", &cfg.Pool.ConnMaxIdleTime},
+	} {
+		if v := os.Getenv(d.key); v != "" {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds < 0 {
+				return nil, fmt.Errorf("invalid %s: %q", d.key, v)
+			}
+			*d.target = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v := os.Getenv("BREAKER_COOLDOWN_SECONDS"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("invalid BREAKER_COOLDOWN_SECONDS: %q", v)
+		}
+		cfg.Breaker.Cooldown = time.Duration(seconds) * time.Second
+	}
+
+	if v := os.Getenv("BACKUP_KEEP"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid BACKUP_KEEP: %q", v)
+		}
+		cfg.BackupKeep = n
+	}
+
+	if v := os.Getenv("PURGE_RETENTION_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid PURGE_RETENTION_DAYS: %q", v)
+		}
+		cfg.PurgeRetention = time.Duration(days) * 24 * time.Hour
+	}
+
+	if v := os.Getenv("UPLOAD_TTL_HOURS"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours <= 0 {
+			return nil, fmt.Errorf("invalid UPLOAD_TTL_HOURS: %q", v)
+		}
+		cfg.UploadTTL = time.Duration(hours) * time.Hour
+	}
+
+	switch cfg.DBDriver {
+	case "sqlite", "memory":
+	case "postgres":
+		if cfg.DBDSN == "" {
+			return nil, fmt.Errorf("DB_DSN is required when DB_DRIVER=postgres")
+		}
+	default:
+		return nil, fmt.Errorf("invalid DB_DRIVER: %q", cfg.DBDriver)
+	}
+
+	if v := os.Getenv("BATCH_MAX_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 10000 {
+			return nil, fmt.Errorf("invalid BATCH_MAX_SIZE: %q", v)
+		}
+		cfg.BatchMaxSize = n
+	}
+
+	cfg.ConcurrencyLimits = DefaultConcurrencyLimits()
+	if v := os.Getenv("CONCURRENCY_LIMITS"); v != "" {
+		if err := parseConcurrencyLimits(v, cfg.ConcurrencyLimits); err != nil {
+			return nil, fmt.Errorf("invalid CONCURRENCY_LIMITS: %w", err)
+		}
+	}
+
+	if v := os.Getenv("DEFAULT_PAGE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid DEFAULT_PAGE_SIZE: %q", v)
+		}
+		cfg.DefaultPageSize = n
+	}
+	if v := os.Getenv("MAX_PAGE_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 10000 {
+			return nil, fmt.Errorf("invalid MAX_PAGE_SIZE: %q", v)
+		}
+		cfg.MaxPageSize = n
+	}
+	if cfg.DefaultPageSize > cfg.MaxPageSize {
+		return nil, fmt.Errorf("DEFAULT_PAGE_SIZE (%d) must not exceed MAX_PAGE_SIZE (%d)", cfg.DefaultPageSize, cfg.MaxPageSize)
+	}
+
+	if v := os.Getenv("PRIORITY_LABELS"); v != "" {
+		scale, err := model.ParsePriorityScale(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PRIORITY_LABELS: %w", err)
+		}
+		cfg.PriorityScale = scale
+	}
+
+	if err := loadInvites(&cfg.Invites, cfg.Mail, cfg.PriorityScale); err != nil {
+		return nil, err
+	}
+
+	if v := os.Getenv("STRICT_VERSIONING"); v != "" {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STRICT_VERSIONING: %q", v)
+		}
+		cfg.StrictVersioning = strict
+	}
+
+	if v := os.Getenv("REJECT_PAST_DUE_DATES"); v != "" {
+		reject, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REJECT_PAST_DUE_DATES: %q", v)
+		}
+		cfg.RejectPastDueDates = reject
+	}
+
+	if v := os.Getenv("LEGACY_ROUTES"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LEGACY_ROUTES: %q", v)
+		}
+		cfg.LegacyRoutes = enabled
+	}
+
+	if v := os.Getenv("TRAFFIC_HEAVY_BYTES"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid TRAFFIC_HEAVY_BYTES: %q", v)
+		}
+		cfg.Traffic.HeavyBytes = size
+	}
+
+	if v := os.Getenv("TRAFFIC_SLOW_MS"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid TRAFFIC_SLOW_MS: %q", v)
+		}
+		cfg.Traffic.Slow = time.Duration(ms) * time.Millisecond
+	}
+
+	if v := os.Getenv("LINK_PREVIEW"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LINK_PREVIEW: %q", v)
+		}
+		cfg.LinkPreview = enabled
+	}
+
+	for _, m := range []struct {
+		key    string
+		target *int64
+	}{
+		{"MEMORY_LIMIT_MB", &cfg.MemoryLimit},
+		{"DB_SOFT_HEAP_LIMIT_MB", &cfg.Pool.SoftHeapLimit},
+	} {
+		if v := os.Getenv(m.key); v != "" {
+			mb, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || mb < 0 {
+				return nil, fmt.Errorf("invalid %s: %q", m.key, v)
+			}
+			*m.target = mb << 20
+		}
+	}
+
+	if v := os.Getenv("READ_ONLY"); v != "" {
+		readOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid READ_ONLY: %q", v)
+		}
+		cfg.ReadOnly = readOnly
+	}
+
+	if v := os.Getenv("AUTH_REQUIRED"); v != "" {
+		required, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AUTH_REQUIRED: %q", v)
+		}
+		cfg.AuthRequired = required
+	}
+	cfg.AdminSubjects = splitList(os.Getenv("ADMIN_SUBJECTS"))
+
+	if v := os.Getenv("DRAIN_PERIOD_SECONDS"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid DRAIN_PERIOD_SECONDS: %q", v)
+		}
+		cfg.DrainPeriod = time.Duration(seconds) * time.Second
+	}
+
+	if v := os.Getenv("LEGACY_SUNSET_DATE"); v != "" {
+		sunset, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LEGACY_SUNSET_DATE: %q", v)
+		}
+		cfg.LegacySunset = sunset
+	}
+
+	flags, err := features.Parse(os.Getenv("FEATURE_FLAGS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEATURE_FLAGS: %w", err)
+	}
+	cfg.Features = flags
+
+	if v := os.Getenv("RATE_LIMIT_SOFT"); v != "" {
+		soft, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_SOFT: %q", v)
+		}
+		cfg.RateLimitSoft = soft
+	}
+
+	if err := cfg.checkTodoStorage(); err != nil {
+		return nil, err
+	}
+
+	if cfg.InstanceID == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+		cfg.InstanceID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	if cfg.ShareSecret == "" {
+		// 随机密钥意味着重启后之前生成的分享链接全部失效
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate share secret: %w", err)
+		}
+		cfg.ShareSecret = hex.EncodeToString(buf)
+		log.Println("未设置 SHARE_SECRET，已生成临时密钥，重启后分享链接将失效")
+	}
+
+	return cfg, nil
+}
+
+// checkTodoStorage 待办事项不在 SQLite 中时（DB_DRIVER=postgres 或 memory），拒绝启用只能处理 SQLite 中待办事项的后台任务：
+// 逾期升级、日历邀请和工单导入直接查询和修改 SQLite 的 todos 表，看不到其他存储中的待办事项
+func (c *Config) checkTodoStorage() error {
+	if c.DBDriver == "sqlite" {
+		return nil
+	}
+	var settings []string
+	if c.EscalationPolicyFile != "" {
+		settings = append(settings, "ESCALATION_POLICY_FILE")
+	}
+	if c.Invites.Enabled {
+		settings = append(settings, "CALENDAR_INVITES")
+	}
+	if c.Issues.Enabled() {
+		settings = append(settings, "JIRA_BASE_URL / LINEAR_API_KEY")
+	}
+	if len(settings) > 0 {
+		return fmt.Errorf("%s requires DB_DRIVER=sqlite", strings.Join(settings, ", "))
+	}
+	return nil
+}
+
+// loadSwagger 读取接口文档配置
+//
+//	SWAGGER_ENABLED    是否提供 /swagger/，默认 true
+//	SWAGGER_USER       Basic 认证用户名
+//	SWAGGER_PASSWORD   Basic 认证密码，和 SWAGGER_USER 必须同时设置
+func loadSwagger(s *Swagger) error {
+	if v := os.Getenv("SWAGGER_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid SWAGGER_ENABLED: %q", v)
+		}
+		s.Enabled = enabled
+	}
+	s.User = os.Getenv("SWAGGER_USER")
+	s.Password = os.Getenv("SWAGGER_PASSWORD")
+	if (s.User == "") != (s.Password == "") {
+		return fmt.Errorf("SWAGGER_USER and SWAGGER_PASSWORD must be set together")
+	}
+	return nil
+}
+
+// loadIssues 读取工单导入配置
+//
+//	JIRA_BASE_URL                Jira 地址，和 JIRA_API_TOKEN 同时设置时启用 Jira
+//	JIRA_EMAIL                   Jira Cloud 账号邮箱
+//	JIRA_API_TOKEN               Jira Cloud 的 API token，或 Server / Data Center 的个人访问令牌
+//	JIRA_JQL                     拉取工单的 JQL
+//	LINEAR_API_KEY               Linear 个人 API key，设置时启用 Linear
+//	ISSUE_IMPORT_PROJECT         导入到的项目名称，默认“工单”，设为空字符串时不放入项目
+//	ISSUE_SYNC_INTERVAL_MINUTES  同步间隔，默认 30，0 表示不定时同步
+//	ISSUE_SYNC_STATUS            是否同步完成状态，默认 true
+func loadIssues(i *Issues) error {
+	i.JiraBaseURL = strings.TrimRight(os.Getenv("JIRA_BASE_URL"), "/")
+	i.JiraEmail = os.Getenv("JIRA_EMAIL")
+	i.JiraToken = os.Getenv("JIRA_API_TOKEN")
+	i.JiraJQL = os.Getenv("JIRA_JQL")
+	i.LinearAPIKey = os.Getenv("LINEAR_API_KEY")
+	if i.JiraBaseURL != "" {
+		u, err := url.Parse(i.JiraBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid JIRA_BASE_URL: %q", i.JiraBaseURL)
+		}
+		if i.JiraToken == "" {
+			return fmt.Errorf("JIRA_API_TOKEN is required when JIRA_BASE_URL is set")
+		}
+	}
+
+	if v, ok := os.LookupEnv("ISSUE_IMPORT_PROJECT"); ok {
+		i.Project = strings.TrimSpace(v)
+	}
+	if v := os.Getenv("ISSUE_SYNC_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes < 0 {
+			return fmt.Errorf("invalid ISSUE_SYNC_INTERVAL_MINUTES: %q", v)
+		}
+		i.Interval = time.Duration(minutes) * time.Minute
+	}
+	if v := os.Getenv("ISSUE_SYNC_STATUS"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid ISSUE_SYNC_STATUS: %q", v)
+		}
+		i.SyncStatus = enabled
+	}
+	return nil
+}
+
+// loadMail 读取邮件配置
+//
+//	SMTP_HOST          SMTP 服务器，设置后邮件渠道通过它发送
+//	SMTP_PORT          端口，默认 587（SMTP_SECURITY=tls 时为 465）
+//	SMTP_USERNAME      认证用户名，为空时不认证
+//	SMTP_PASSWORD      认证密码
+//	SMTP_FROM          发件地址，例如 "待办事项 <todo@example.com>"
+//	SMTP_SECURITY      starttls（默认）、tls 或 none
+//	MAIL_TO            收件人
+//	MAIL_TEMPLATE_DIR  覆盖内置模板的目录
+func loadMail(m *Mail) error {
+	m.SMTP.Host = os.Getenv("SMTP_HOST")
+	m.SMTP.Username = os.Getenv("SMTP_USERNAME")
+	m.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	m.SMTP.From = os.Getenv("SMTP_FROM")
+	m.To = os.Getenv("MAIL_TO")
+	m.TemplateDir = os.Getenv("MAIL_TEMPLATE_DIR")
+	if m.SMTP.Host == "" {
+		return nil
+	}
+
+	m.SMTP.Security = getEnv("SMTP_SECURITY", mail.SecurityStartTLS)
+	m.SMTP.Port = 587
+	switch m.SMTP.Security {
+	case mail.SecurityTLS:
+		m.SMTP.Port = 465
+	case mail.SecurityStartTLS, mail.SecurityNone:
+	default:
+		return fmt.Errorf("invalid SMTP_SECURITY: %q", m.SMTP.Security)
+	}
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid SMTP_PORT: %q", v)
+		}
+		m.SMTP.Port = port
+	}
+
+	if _, err := netmail.ParseAddress(m.SMTP.From); err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %q", m.SMTP.From)
+	}
+	if _, err := netmail.ParseAddress(m.To); err != nil {
+		return fmt.Errorf("invalid MAIL_TO: %q", m.To)
+	}
+	return nil
+}
+
+// loadInvites 读取截止日期邀请配置
+//
+//	CALENDAR_INVITES                   是否发送，默认 false，开启时必须配置 SMTP_HOST
+//	CALENDAR_INVITE_MIN_PRIORITY       最低优先级，数值或 PRIORITY_LABELS 中的名称，默认 high（没有时取最高一档）
+//	CALENDAR_INVITE_INTERVAL_MINUTES   检查间隔，默认 5
+func loadInvites(i *Invites, m Mail, scale model.PriorityScale) error {
+	if v := os.Getenv("CALENDAR_INVITES"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid CALENDAR_INVITES: %q", v)
+		}
+		i.Enabled = enabled
+	}
+	if i.Enabled && !m.Enabled() {
+		return fmt.Errorf("CALENDAR_INVITES requires SMTP_HOST")
+	}
+
+	if v, ok := scale.Value("high"); ok {
+		i.MinPriority = v
+	} else if len(scale) > 0 {
+		i.MinPriority = scale[len(scale)-1].Value
+	}
+	if v := os.Getenv("CALENDAR_INVITE_MIN_PRIORITY"); v != "" {
+		p, err := model.ParsePriority(v).Resolve(scale)
+		if err != nil {
+			return fmt.Errorf("invalid CALENDAR_INVITE_MIN_PRIORITY: %w", err)
+		}
+		i.MinPriority = p
+	}
+
+	if v := os.Getenv("CALENDAR_INVITE_INTERVAL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 {
+			return fmt.Errorf("invalid CALENDAR_INVITE_INTERVAL_MINUTES: %q", v)
+		}
+		i.Interval = time.Duration(minutes) * time.Minute
+	}
+	return nil
+}
+
+// loadOutbound 读取出站请求配置
+//
+//	OUTBOUND_PROXY               出站代理地址，例如 http://proxy.internal:3128
+//	OUTBOUND_TIMEOUT_SECONDS     请求超时
+//	OUTBOUND_MAX_RESPONSE_BYTES  响应体上限
+//	OUTBOUND_ALLOW_HOSTS         只允许访问的主机，逗号分隔，支持 *.example.com
+//	OUTBOUND_DENY_HOSTS          禁止访问的主机，逗号分隔
+//	OUTBOUND_ALLOW_CIDRS         额外放行的网段，逗号分隔，例如 10.1.0.0/16
+//	OUTBOUND_DENY_CIDRS          额外禁止的网段，逗号分隔
+func loadOutbound(opts *outbound.Options) error {
+	if v := os.Getenv("OUTBOUND_PROXY"); v != "" {
+		proxy, err := url.Parse(v)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("invalid OUTBOUND_PROXY: %q", v)
+		}
+		opts.Proxy = proxy
+	}
+
+	if v := os.Getenv("OUTBOUND_TIMEOUT_SECONDS"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			return fmt.Errorf("invalid OUTBOUND_TIMEOUT_SECONDS: %q", v)
+		}
+		opts.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	if v := os.Getenv("OUTBOUND_MAX_RESPONSE_BYTES"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid OUTBOUND_MAX_RESPONSE_BYTES: %q", v)
+		}
+		opts.MaxResponseBytes = size
+	}
+
+	opts.AllowHosts = splitList(os.Getenv("OUTBOUND_ALLOW_HOSTS"))
+	opts.DenyHosts = splitList(os.Getenv("OUTBOUND_DENY_HOSTS"))
+
+	var err error
+	if opts.AllowCIDRs, err = parseCIDRs("OUTBOUND_ALLOW_CIDRS"); err != nil {
+		return err
+	}
+	if opts.DenyCIDRs, err = parseCIDRs("OUTBOUND_DENY_CIDRS"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// loadCache 读取缓存配置
+//
+//	REDIS_URL                 redis://[:password@]host[:port][/db]，设置后启用缓存
+//	CACHE_TODO_TTL_SECONDS    单个待办事项的缓存时间，默认 300
+//	CACHE_STATS_TTL_SECONDS   统计信息的缓存时间，默认 30
+func loadCache(c *Cache) error {
+	for _, t := range []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"CACHE_TODO_TTL_SECONDS", &c.TodoTTL},
+		{"CACHE_STATS_TTL_SECONDS", &c.StatsTTL},
+	} {
+		if v := os.Getenv(t.key); v != "" {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return fmt.Errorf("invalid %s: %q", t.key, v)
+			}
+			*t.target = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if v := os.Getenv("REDIS_URL"); v != "" {
+		redis, err := cache.ParseRedisURL(v, 2*time.Second)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		c.Backend = redis
+	}
+	return nil
+}
+
+// loadAttachments 读取附件配置
+//
+//	ATTACHMENT_MAX_BYTES     单个附件大小上限，默认 10MB
+//	ATTACHMENT_SCAN_COMMAND  扫描命令，文件路径追加在最后，例如 "clamdscan --no-summary --fdpass"
+//	CLAMD_ADDRESS            clamd 地址，例如 unix:/run/clamav/clamd.ctl 或 127.0.0.1:3310
+//	ATTACHMENT_REQUIRE_SCAN  没有配置扫描器时拒绝上传
+//	STORAGE_BACKEND          local（默认）或 s3，s3 的配置见 loadS3
+func loadAttachments(a *Attachments) error {
+	switch backend := getEnv("STORAGE_BACKEND", "local"); backend {
+	case "local":
+		a.Storage = storage.LocalStore{Dir: a.Dir}
+	case "s3":
+		store, err := loadS3()
+		if err != nil {
+			return err
+		}
+		a.Storage = store
+	default:
+		return fmt.Errorf("invalid STORAGE_BACKEND: %q", backend)
+	}
+
+	if v := os.Getenv("S3_PRESIGN_TTL_MINUTES"); v != "" {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes <= 0 || minutes > 7*24*60 {
+			return fmt.Errorf("invalid S3_PRESIGN_TTL_MINUTES: %q", v)
+		}
+		a.PresignTTL = time.Duration(minutes) * time.Minute
+	}
+
+	if v := os.Getenv("ATTACHMENT_MAX_BYTES"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid ATTACHMENT_MAX_BYTES: %q", v)
+		}
+		a.MaxBytes = size
+	}
+
+	command := strings.Fields(os.Getenv("ATTACHMENT_SCAN_COMMAND"))
+	clamd := os.Getenv("CLAMD_ADDRESS")
+	switch {
+	case len(command) > 0 && clamd != "":
+		return fmt.Errorf("ATTACHMENT_SCAN_COMMAND and CLAMD_ADDRESS are mutually exclusive")
+	case len(command) > 0:
+		a.Scanner = scan.CommandScanner{Command: command}
+	case clamd != "":
+		scanner, err := scan.ParseClamdAddress(clamd)
+		if err != nil {
+			return fmt.Errorf("invalid CLAMD_ADDRESS: %q", clamd)
+		}
+		a.Scanner = scanner
+	}
+
+	if v := os.Getenv("ATTACHMENT_REQUIRE_SCAN"); v != "" {
+		require, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid ATTACHMENT_REQUIRE_SCAN: %q", v)
+		}
+		a.RequireScan = require
+	}
+
+	return nil
+}
+
+// loadS3 读取 S3 兼容对象存储配置
+//
+//	S3_ENDPOINT    例如 https://s3.us-east-1.amazonaws.com 或 http://minio:9000
+//	S3_REGION      默认 us-east-1
+//	S3_BUCKET      存储桶
+//	S3_ACCESS_KEY  访问密钥
+//	S3_SECRET_KEY  私有密钥
+//	S3_PATH_STYLE  使用 {endpoint}/{bucket}/{key} 形式的地址，默认 true（MinIO 需要）
+//
+// 对象存储通常部署在内网，不经过出站 SSRF 防护
+func loadS3() (*storage.S3Store, error) {
+	endpoint, err := url.Parse(os.Getenv("S3_ENDPOINT"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT: %q", os.Getenv("S3_ENDPOINT"))
+	}
+	endpoint.Path = ""
+
+	store := &storage.S3Store{
+		Endpoint:  endpoint,
+		Region:    getEnv("S3_REGION", "us-east-1"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: true,
+		Client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+	if store.Bucket == "" || store.AccessKey == "" || store.SecretKey == "" {
+		return nil, fmt.Errorf("S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required when STORAGE_BACKEND=s3")
+	}
+
+	if v := os.Getenv("S3_PATH_STYLE"); v != "" {
+		pathStyle, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid S3_PATH_STYLE: %q", v)
+		}
+		store.PathStyle = pathStyle
+	}
+
+	return store, nil
+}
+
+// splitList 解析逗号分隔的列表，忽略空项并统一转为小写
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseCIDRs 解析环境变量中逗号分隔的网段
+func parseCIDRs(key string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, item := range splitList(os.Getenv(key)) {
+		_, n, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", key, item)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// getEnv 读取环境变量，为空时返回默认值
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 运行配置档（启动参数 --profile 或 PROFILE）
+const (
+	ProfileDefault   = "default"
+	ProfileLowMemory = "low-memory" // 树莓派 Zero 等内存只有几百 MB 的设备
+)
+
+// profiles 每个配置档是一组环境变量的默认值，显式设置的环境变量优先；
+// 复用环境变量的解析和校验，配置档里的值和手写环境变量完全等价
+var profiles = map[string]map[string]string{
+	ProfileDefault: {},
+	ProfileLowMemory: {
+		// 运行时和 SQLite 的内存上限，超过后更积极地回收、缩小页缓存
+		"MEMORY_LIMIT_MB":       "64",
+		"DB_SOFT_HEAP_LIMIT_MB": "8",
+		"DB_MAX_OPEN_CONNS":     "2",
+		"DB_MAX_IDLE_CONNS":     "1",
+
+		// 单次请求能占用的内存
+		"CONCURRENCY_LIMITS":          "export=1,import=1,rebuild=1",
+		"BATCH_MAX_SIZE":              "50",
+		"DEFAULT_PAGE_SIZE":           "20",
+		"MAX_PAGE_SIZE":               "100",
+		"ATTACHMENT_MAX_BYTES":        "2097152",
+		"OUTBOUND_MAX_RESPONSE_BYTES": "1048576",
+
+		// 后台功能：不抓取链接标题，定时任务放慢
+		"LINK_PREVIEW":                "false",
+		"TRAFFIC_LOG_SIZE":            "20",
+		"HABIT_INTERVAL_MINUTES":      "60",
+		"RECURRENCE_INTERVAL_MINUTES": "60",
+		"ESCALATION_INTERVAL_MINUTES": "240",
+		"AGING_INTERVAL_MINUTES":      "240",
+		"JOB_POLL_SECONDS":            "120",
+	},
+}
+
+// ProfileNames 返回支持的配置档名称
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile 把配置档中未显式设置的环境变量补上，返回实际由配置档提供的变量名
+func applyProfile(name string) (map[string]bool, error) {
+	values, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	applied := make(map[string]bool, len(values))
+	for key, value := range values {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return nil, err
+		}
+		applied[key] = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// StaleTodo 长时间没有更新的待办事项（老化提醒使用）
+type StaleTodo struct {
+	ID          int
+	WorkspaceID string
+	ProjectID   int // 0 表示不属于任何项目
+	Title       string
+	Status      string
+	UpdatedAt   time.Time
+}
+
+// initAgingSchema 初始化老化提醒记录表
+func (db *DB) initAgingSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_aging_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		rule TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		notified_at DATETIME NOT NULL,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_aging_alerts_todo_rule ON todo_aging_alerts(todo_id, rule);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_aging_alerts table: %w", err)
+	}
+	return nil
+}
+
+// ListAllStaleTodosContext 跨所有工作区查询停滞事项（后台任务使用）
+func (db *DB) ListAllStaleTodosContext(ctx context.Context, status string, before time.Time) ([]StaleTodo, error) {
+	query, args := newTodoQuery().staleSince(status, before).
+		selectSQL("id, workspace_id, COALESCE(project_id, 0), title, status, updated_at", "ORDER BY updated_at ASC")
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询停滞事项失败：%w", err)
+	}
+	defer rows.Close()
+
+	var todos []StaleTodo
+	for rows.Next() {
+		var todo StaleTodo
+		if err := rows.Scan(&todo.ID, &todo.WorkspaceID, &todo.ProjectID, &todo.Title, &todo.Status, &todo.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return todos, nil
+}
+
+// LastAgingAlertContext 查询某事项在某条规则下最近一次提醒的时间，没有则返回 nil
+func (db *DB) LastAgingAlertContext(ctx context.Context, todoID int, rule string) (*time.Time, error) {
+	var last sql.NullString
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT MAX(notified_at) FROM todo_aging_alerts WHERE todo_id = ? AND rule = ?
+	`, todoID, rule).Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("查询老化提醒记录失败：%w", err)
+	}
+
+	if !last.Valid {
+		return nil, nil
+	}
+
+	t, err := parseDBTime(last.String)
+	if err != nil {
+		return nil, fmt.Errorf("解析 notified_at 失败：%w", err)
+	}
+	return &t, nil
+}
+
+// RecordAgingAlertContext 记录一次老化提醒
+func (db *DB) RecordAgingAlertContext(ctx context.Context, todoID int, rule, channel string, at time.Time) error {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO todo_aging_alerts (todo_id, rule, channel, notified_at) VALUES (?, ?, ?, ?)
+	`, todoID, rule, channel, at.UTC())
+	if err != nil {
+		return fmt.Errorf("记录老化提醒失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"todo-list/model"
+)
+
+// apiKeyTouchInterval last_used_at 的更新间隔，脚本频繁调用时不必每个请求都写一次数据库
+const apiKeyTouchInterval = time.Minute
+
+// apiKeyColumns 查询 API key 的列，与 scanAPIKey 的顺序一致；绑定的工作区以逗号拼接（工作区标识不含逗号）
+const apiKeyColumns = `id, name, prefix, user_id, role, created_at, expires_at, last_used_at, revoked_at,
+	(SELECT GROUP_CONCAT(workspace) FROM api_key_workspaces WHERE key_id = api_keys.id)`
+
+// initAPIKeysSchema 初始化 API key 表：只保存 key 的 SHA-256，撤销后保留记录，列表中仍然可以看到
+func (db *DB) initAPIKeysSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		user_id TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT 'member',
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		revoked_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id, created_at);
+
+	-- member key 可以访问的工作区，admin key 不受限制
+	CREATE TABLE IF NOT EXISTS api_key_workspaces (
+		key_id INTEGER NOT NULL,
+		workspace TEXT NOT NULL,
+		PRIMARY KEY (key_id, workspace),
+		FOREIGN KEY (key_id) REFERENCES api_keys(id) ON DELETE CASCADE
+	);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init api_keys table: %w", err)
+	}
+	// 区分角色之前创建的 key 都能调用管理接口，升级后保持 admin，避免管理员被锁在外面
+	return db.ensureTableColumn("api_keys", "role", "TEXT NOT NULL DEFAULT 'admin'")
+}
+
+// scanAPIKey 扫描一行 API key（列顺序见 apiKeyColumns）
+func scanAPIKey(s rowScanner) (*model.APIKey, error) {
+	var k model.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	var workspaces sql.NullString
+	if err := s.Scan(&k.ID, &k.Name, &k.Prefix, &k.UserID, &k.Role, &k.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt, &workspaces); err != nil {
+		return nil, err
+	}
+	if workspaces.Valid && workspaces.String != "" {
+		k.Workspaces = strings.Split(workspaces.String, ",")
+		sort.Strings(k.Workspaces)
+	}
+	if expiresAt.Valid {
+		k.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
+
+// CreateAPIKeyContext 保存 API key 和它绑定的工作区，hash 为 model.HashAPIKey 的结果
+func (db *DB) CreateAPIKeyContext(ctx context.Context, key *model.APIKey, hash string) error {
+	key.CreatedAt = db.clock.Now().UTC()
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, user_id, role, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, key.Name, key.Prefix, hash, key.UserID, key.Role, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("保存 API key 失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取 API key ID 失败：%w", err)
+	}
+	for _, slug := range key.Workspaces {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO api_key_workspaces (key_id, workspace) VALUES (?, ?)`, id, slug); err != nil {
+			return fmt.Errorf("保存 API key 的工作区失败：%w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	key.ID = int(id)
+	return nil
+}
+
+// ListAPIKeysContext 用户的所有 API key（包括已撤销和已过期的），最新的在前
+func (db *DB) ListAPIKeysContext(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE user_id = ? ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return keys, nil
+}
+
+// HasAPIKeysContext 是否创建过 API key（包括已撤销和已过期的）
+func (db *DB) HasAPIKeysContext(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := db.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM api_keys)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+	return exists, nil
+}
+
+// RevokeAPIKeyContext 撤销用户的 API key，已撤销的保持原来的撤销时间；不存在时返回 ErrNotFound
+func (db *DB) RevokeAPIKeyContext(ctx context.Context, userID string, id int) (*model.APIKey, error) {
+	if _, err := db.conn.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`, db.clock.Now().UTC(), id, userID); err != nil {
+		return nil, fmt.Errorf("撤销 API key 失败：%w", err)
+	}
+
+	row := db.conn.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ? AND user_id = ?`, id, userID)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("api key %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+	return key, nil
+}
+
+// AuthenticateAPIKeyContext 按哈希查找可以使用的 API key 并记录使用时间
+// 不存在、已撤销或已过期时都返回 ErrNotFound，调用方不区分这几种情况
+func (db *DB) AuthenticateAPIKeyContext(ctx context.Context, hash string) (*model.APIKey, error) {
+	row := db.conn.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, hash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("api key: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+
+	now := db.clock.Now().UTC()
+	if !key.Active(now) {
+		return nil, fmt.Errorf("api key %d: %w", key.ID, ErrNotFound)
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if _, err := db.conn.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now, key.ID); err != nil {
+			return nil, fmt.Errorf("更新 API key 使用时间失败：%w", err)
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
@@ -0,0 +1,433 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// ArchiveVersion 当前的归档格式版本，见 storage.ArchiveVersion
+const ArchiveVersion = storage.ArchiveVersion
+
+// ErrWorkspaceNotEmpty 导入归档时目标工作区已有数据
+var ErrWorkspaceNotEmpty = storage.ErrWorkspaceNotEmpty
+
+// Archive、ArchiveGoal、ArchiveHabit、HabitInstance、ArchiveImportResult、ArchiveError 归档格式，定义见 storage
+type (
+	Archive             = storage.Archive
+	ArchiveGoal         = storage.ArchiveGoal
+	ArchiveHabit        = storage.ArchiveHabit
+	HabitInstance       = storage.HabitInstance
+	ArchiveImportResult = storage.ArchiveImportResult
+	ArchiveError        = storage.ArchiveError
+)
+
+// queryEach 执行查询并对每一行调用 fn
+func queryEach(ctx context.Context, tx *sql.Tx, fn func(rowScanner) error, query string, args ...interface{}) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportArchiveContext 导出当前工作区的全部数据，在一个只读事务中读取，保证各部分相互一致
+func (db *DB) ExportArchiveContext(ctx context.Context) (*Archive, error) {
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	archive := storage.NewArchive(model.Workspace{}, db.clock.Now().UTC())
+
+	err = tx.QueryRowContext(ctx, `SELECT slug, name, max_todos, created_at FROM workspaces WHERE slug = ?`, workspace).
+		Scan(&archive.Workspace.Slug, &archive.Workspace.Name, &archive.Workspace.MaxTodos, &archive.Workspace.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("查询工作区失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var p model.Project
+		var archivedAt sql.NullTime
+		if err := s.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &archivedAt); err != nil {
+			return err
+		}
+		if archivedAt.Valid {
+			p.ArchivedAt = &archivedAt.Time
+		}
+		archive.Projects = append(archive.Projects, p)
+		return nil
+	}, `SELECT id, name, description, created_at, updated_at, archived_at FROM projects WHERE workspace_id = ? ORDER BY id ASC`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出项目失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		todo, err := scanTodo(s)
+		if err != nil {
+			return err
+		}
+		archive.Todos = append(archive.Todos, *todo)
+		return nil
+	}, `SELECT `+todoColumns+` FROM todos WHERE workspace_id = ? ORDER BY id ASC`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出待办事项失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var c model.Comment
+		var mentions string
+		if err := s.Scan(&c.ID, &c.TodoID, &c.Author, &c.Body, &mentions, &c.CreatedAt); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(mentions), &c.Mentions); err != nil {
+			return fmt.Errorf("解析 mentions 失败：%w", err)
+		}
+		archive.Comments = append(archive.Comments, c)
+		return nil
+	}, `
+		SELECT id, todo_id, author, body, mentions, created_at FROM todo_comments
+		WHERE todo_id IN (SELECT id FROM todos WHERE workspace_id = ?) ORDER BY id ASC
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出评论失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var link model.TodoLink
+		var fetchedAt sql.NullTime
+		if err := s.Scan(&link.ID, &link.TodoID, &link.URL, &link.Title, &link.FaviconURL, &link.Status,
+			&link.CreatedAt, &fetchedAt); err != nil {
+			return err
+		}
+		if fetchedAt.Valid {
+			link.FetchedAt = &fetchedAt.Time
+		}
+		archive.Links = append(archive.Links, link)
+		return nil
+	}, `
+		SELECT id, todo_id, url, title, favicon_url, status, created_at, fetched_at FROM todo_links
+		WHERE todo_id IN (SELECT id FROM todos WHERE workspace_id = ?) ORDER BY id ASC
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出链接失败：%w", err)
+	}
+
+	goals := make(map[int]int) // 目标 ID -> archive.Goals 中的下标
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		g := ArchiveGoal{TodoIDs: make([]int, 0)}
+		if err := s.Scan(&g.ID, &g.Title, &g.Description, &g.TargetDate, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return err
+		}
+		goals[g.ID] = len(archive.Goals)
+		archive.Goals = append(archive.Goals, g)
+		return nil
+	}, `
+		SELECT id, title, description, target_date, created_at, updated_at FROM goals
+		WHERE workspace_id = ? ORDER BY id ASC
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出目标失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var goalID, todoID int
+		if err := s.Scan(&goalID, &todoID); err != nil {
+			return err
+		}
+		g := &archive.Goals[goals[goalID]]
+		g.TodoIDs = append(g.TodoIDs, todoID)
+		return nil
+	}, `
+		SELECT goal_id, todo_id FROM goal_todos
+		WHERE goal_id IN (SELECT id FROM goals WHERE workspace_id = ?) ORDER BY goal_id, todo_id
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出目标关联失败：%w", err)
+	}
+
+	habits := make(map[int]int) // 习惯 ID -> archive.Habits 中的下标
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		habit, err := scanHabit(s)
+		if err != nil {
+			return err
+		}
+		habits[habit.ID] = len(archive.Habits)
+		archive.Habits = append(archive.Habits, ArchiveHabit{Habit: *habit, Instances: make([]HabitInstance, 0)})
+		return nil
+	}, `SELECT `+habitColumns+` FROM habits WHERE workspace_id = ? ORDER BY id ASC`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出习惯失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var habitID int
+		var inst HabitInstance
+		var todoID sql.NullInt64
+		var completedAt sql.NullTime
+		if err := s.Scan(&habitID, &inst.Period, &todoID, &completedAt); err != nil {
+			return err
+		}
+		if todoID.Valid {
+			id := int(todoID.Int64)
+			inst.TodoID = &id
+		}
+		if completedAt.Valid {
+			inst.CompletedAt = &completedAt.Time
+		}
+		h := &archive.Habits[habits[habitID]]
+		h.Instances = append(h.Instances, inst)
+		return nil
+	}, `
+		SELECT habit_id, period, todo_id, completed_at FROM habit_instances
+		WHERE habit_id IN (SELECT id FROM habits WHERE workspace_id = ?) ORDER BY habit_id, period
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出习惯记录失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		rule, err := scanAutomationRule(s)
+		if err != nil {
+			return err
+		}
+		archive.AutomationRules = append(archive.AutomationRules, *rule)
+		return nil
+	}, `
+		SELECT id, name, event, condition, conditions, actions, enabled, created_at FROM automation_rules
+		WHERE workspace_id = ? ORDER BY id ASC
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出自动化规则失败：%w", err)
+	}
+
+	return archive, nil
+}
+
+// ImportArchiveContext 把归档导入当前工作区，全部成功或全部回滚
+// 目标工作区必须为空（没有待办事项、目标、习惯和自动化规则），否则返回 ErrWorkspaceNotEmpty；
+// 所有记录重新分配 ID，归档内的相互引用按新 ID 改写，引用了归档中不存在的记录时返回 *ArchiveError
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) ImportArchiveContext(ctx context.Context, archive *Archive) (result ArchiveImportResult, err error) {
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	var existing int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM todos WHERE workspace_id = ?)
+		     + (SELECT COUNT(*) FROM projects WHERE workspace_id = ?)
+		     + (SELECT COUNT(*) FROM goals WHERE workspace_id = ?)
+		     + (SELECT COUNT(*) FROM habits WHERE workspace_id = ?)
+		     + (SELECT COUNT(*) FROM automation_rules WHERE workspace_id = ?)
+	`, workspace, workspace, workspace, workspace, workspace).Scan(&existing)
+	if err != nil {
+		return result, fmt.Errorf("检查工作区失败：%w", err)
+	}
+	if existing > 0 {
+		return result, fmt.Errorf("workspace %s: %w", workspace, ErrWorkspaceNotEmpty)
+	}
+
+	if archive.Workspace.Name != "" {
+		if _, err = tx.ExecContext(ctx, `UPDATE workspaces SET name = ?, max_todos = ? WHERE slug = ?`,
+			archive.Workspace.Name, archive.Workspace.MaxTodos, workspace); err != nil {
+			return result, fmt.Errorf("更新工作区失败：%w", err)
+		}
+	}
+
+	// 归档中的待办事项 ID -> 新 ID
+	todoIDs := make(map[int]int, len(archive.Todos))
+	mapTodo := func(kind string, id int) (int, error) {
+		newID, ok := todoIDs[id]
+		if !ok {
+			return 0, &ArchiveError{Message: fmt.Sprintf("%s引用了不存在的待办事项 %d", kind, id)}
+		}
+		return newID, nil
+	}
+	insert := func(query string, args ...interface{}) (int, error) {
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return 0, err
+		}
+		id, err := res.LastInsertId()
+		return int(id), err
+	}
+
+	// 归档中的项目 ID -> 新 ID；已归档项目中的待办事项导入时由触发器隐藏
+	projectIDs := make(map[int]int, len(archive.Projects))
+	for _, p := range archive.Projects {
+		var projectID int
+		projectID, err = insert(`
+			INSERT INTO projects (workspace_id, name, description, created_at, updated_at, archived_at) VALUES (?, ?, ?, ?, ?, ?)
+		`, workspace, p.Name, p.Description, p.CreatedAt, p.UpdatedAt, p.ArchivedAt)
+		if err != nil {
+			return result, fmt.Errorf("导入项目 %d 失败：%w", p.ID, projectWriteError(err, "导入"))
+		}
+		projectIDs[p.ID] = projectID
+		result.Projects++
+	}
+
+	for _, todo := range archive.Todos {
+		if todo.Version < 1 {
+			todo.Version = 1
+		}
+		if todo.ProjectID != nil {
+			projectID, ok := projectIDs[*todo.ProjectID]
+			if !ok {
+				return result, &ArchiveError{Message: fmt.Sprintf("待办事项 %d 引用了不存在的项目 %d", todo.ID, *todo.ProjectID)}
+			}
+			todo.ProjectID = &projectID
+		}
+		if err = claimPublicID(ctx, tx, &todo); err != nil {
+			return result, err
+		}
+		var id int
+		id, err = insert(`
+			INSERT INTO todos (version, title, description, status, priority, due_date, created_at, updated_at,
+			                   completed_at, latitude, longitude, radius, estimated_minutes, public_id, workspace_id,
+			                   recurrence, recurrence_start, next_occurrence_id, project_id, secret_note, secret_note_key_hint)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, todo.Version, todo.Title, todo.Description, todo.Status, todo.Priority, todo.DueDate, todo.CreatedAt,
+			todo.UpdatedAt, todo.CompletedAt, todo.Latitude, todo.Longitude, todo.Radius, todo.EstimatedMinutes,
+			todo.PublicID, workspace, nullString(todo.Recurrence), todo.RecurrenceStart, archivedOccurrence(&todo),
+			todo.ProjectID, noteCiphertext(todo.SecretNote), noteKeyHint(todo.SecretNote))
+		if err != nil {
+			return result, fmt.Errorf("导入待办事项 %d 失败：%w", todo.ID, err)
+		}
+		todoIDs[todo.ID] = id
+		result.Todos++
+	}
+
+	for _, c := range archive.Comments {
+		var todoID int
+		if todoID, err = mapTodo("评论", c.TodoID); err != nil {
+			return result, err
+		}
+		var mentions []byte
+		if mentions, err = json.Marshal(c.Mentions); err != nil {
+			return result, fmt.Errorf("序列化 mentions 失败：%w", err)
+		}
+		if _, err = insert(`
+			INSERT INTO todo_comments (todo_id, author, body, mentions, created_at) VALUES (?, ?, ?, ?, ?)
+		`, todoID, c.Author, c.Body, string(mentions), c.CreatedAt); err != nil {
+			return result, fmt.Errorf("导入评论 %d 失败：%w", c.ID, err)
+		}
+		result.Comments++
+	}
+
+	for _, link := range archive.Links {
+		var todoID int
+		if todoID, err = mapTodo("链接", link.TodoID); err != nil {
+			return result, err
+		}
+		if _, err = insert(`
+			INSERT INTO todo_links (todo_id, url, title, favicon_url, status, created_at, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, todoID, link.URL, link.Title, link.FaviconURL, link.Status, link.CreatedAt, link.FetchedAt); err != nil {
+			return result, fmt.Errorf("导入链接 %d 失败：%w", link.ID, err)
+		}
+		result.Links++
+	}
+
+	for _, g := range archive.Goals {
+		var goalID int
+		goalID, err = insert(`
+			INSERT INTO goals (workspace_id, title, description, target_date, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, workspace, g.Title, g.Description, g.TargetDate, g.CreatedAt, g.UpdatedAt)
+		if err != nil {
+			return result, fmt.Errorf("导入目标 %d 失败：%w", g.ID, err)
+		}
+		for _, id := range g.TodoIDs {
+			var todoID int
+			if todoID, err = mapTodo("目标", id); err != nil {
+				return result, err
+			}
+			if _, err = tx.ExecContext(ctx,
+				`INSERT OR IGNORE INTO goal_todos (goal_id, todo_id) VALUES (?, ?)`, goalID, todoID); err != nil {
+				return result, fmt.Errorf("导入目标关联失败：%w", err)
+			}
+		}
+		result.Goals++
+	}
+
+	for _, h := range archive.Habits {
+		// 最近生成的待办事项可能已被删除，找不到时不再引用
+		var lastTodoID *int
+		if h.LastTodoID != nil {
+			if id, ok := todoIDs[*h.LastTodoID]; ok {
+				lastTodoID = &id
+			}
+		}
+		var habitID int
+		habitID, err = insert(`
+			INSERT INTO habits (workspace_id, title, description, frequency, weekday, active,
+			                    streak, best_streak, last_period, last_todo_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, workspace, h.Title, h.Description, h.Frequency, h.Weekday, h.Active,
+			h.Streak, h.BestStreak, h.LastPeriod, lastTodoID, h.CreatedAt)
+		if err != nil {
+			return result, fmt.Errorf("导入习惯 %d 失败：%w", h.ID, err)
+		}
+		for _, inst := range h.Instances {
+			var todoID *int
+			if inst.TodoID != nil {
+				if id, ok := todoIDs[*inst.TodoID]; ok {
+					todoID = &id
+				}
+			}
+			if _, err = tx.ExecContext(ctx, `
+				INSERT OR IGNORE INTO habit_instances (habit_id, period, todo_id, completed_at) VALUES (?, ?, ?, ?)
+			`, habitID, inst.Period, todoID, inst.CompletedAt); err != nil {
+				return result, fmt.Errorf("导入习惯记录失败：%w", err)
+			}
+		}
+		result.Habits++
+	}
+
+	for i := range archive.AutomationRules {
+		rule := &archive.AutomationRules[i]
+		var actions string
+		if actions, err = marshalRule(rule); err != nil {
+			return result, err
+		}
+		if _, err = insert(`
+			INSERT INTO automation_rules (workspace_id, name, event, condition, actions, enabled, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, workspace, rule.Name, rule.Event, rule.Condition, actions, rule.Enabled, rule.CreatedAt); err != nil {
+			return result, fmt.Errorf("导入自动化规则 %d 失败：%w", rule.ID, err)
+		}
+		result.AutomationRules++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return result, fmt.Errorf("提交事务失败：%w", err)
+	}
+
+	db.invalidateTodos(ctx)
+	return result, nil
+}
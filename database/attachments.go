@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"todo-list/model"
+)
+
+// attachmentColumns 查询附件时统一使用的列，顺序必须与 scanAttachment 保持一致
+const attachmentColumns = `id, todo_id, filename, content_type, size, storage_key, status, scan_result, created_at`
+
+// initAttachmentsSchema 初始化附件表
+func (db *DB) initAttachmentsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_attachments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		filename TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		storage_key TEXT NOT NULL,
+		status TEXT NOT NULL,
+		scan_result TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_attachments_todo_id ON todo_attachments(todo_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_attachments table: %w", err)
+	}
+	return nil
+}
+
+// scanAttachment 扫描一行附件（列顺序见 attachmentColumns）
+func scanAttachment(s rowScanner) (*model.Attachment, error) {
+	var a model.Attachment
+	err := s.Scan(&a.ID, &a.TodoID, &a.Filename, &a.ContentType, &a.Size,
+		&a.StorageKey, &a.Status, &a.ScanResult, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateAttachmentContext 保存附件记录
+func (db *DB) CreateAttachmentContext(ctx context.Context, a *model.Attachment) error {
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO todo_attachments (todo_id, filename, content_type, size, storage_key, status, scan_result, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.TodoID, a.Filename, a.ContentType, a.Size, a.StorageKey, a.Status, a.ScanResult, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存附件失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取附件 ID 失败：%w", err)
+	}
+	a.ID = int(id)
+	return nil
+}
+
+// ListAttachmentsContext 获取待办事项的附件
+func (db *DB) ListAttachmentsContext(ctx context.Context, todoID int) ([]model.Attachment, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM todo_attachments
+		WHERE todo_id = ? AND todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+		ORDER BY id ASC
+	`, todoID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询附件失败：%w", err)
+	}
+	defer rows.Close()
+
+	attachments := make([]model.Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		attachments = append(attachments, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachmentContext 获取单个附件，不存在时返回 nil, nil
+func (db *DB) GetAttachmentContext(ctx context.Context, todoID, id int) (*model.Attachment, error) {
+	row := db.conn.QueryRowContext(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM todo_attachments
+		WHERE id = ? AND todo_id = ? AND todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+	`, id, todoID, WorkspaceFromContext(ctx))
+
+	a, err := scanAttachment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询附件失败：%w", err)
+	}
+	return a, nil
+}
+
+// DeleteAttachmentContext 删除附件记录（文件由调用方从存储中删除）
+func (db *DB) DeleteAttachmentContext(ctx context.Context, todoID, id int) error {
+	_, err := db.conn.ExecContext(ctx, `
+		DELETE FROM todo_attachments
+		WHERE id = ? AND todo_id = ? AND todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+	`, id, todoID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除附件失败：%w", err)
+	}
+	return nil
+}
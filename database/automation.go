@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"todo-list/model"
+)
+
+// initAutomationSchema 初始化自动化规则表
+func (db *DB) initAutomationSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS automation_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id TEXT NOT NULL DEFAULT 'default',
+		name TEXT NOT NULL,
+		event TEXT NOT NULL,
+		conditions TEXT NOT NULL DEFAULT '[]',
+		actions TEXT NOT NULL DEFAULT '[]',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_automation_rules_event ON automation_rules(workspace_id, event);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init automation_rules table: %w", err)
+	}
+	// condition 是 CEL 表达式；conditions 是旧版的条件列表，读取时转换为表达式，新写入的规则总是 '[]'
+	return db.ensureTableColumn("automation_rules", "condition", "TEXT NOT NULL DEFAULT ''")
+}
+
+// CreateAutomationRuleContext 保存自动化规则
+func (db *DB) CreateAutomationRuleContext(ctx context.Context, rule *model.AutomationRule) error {
+	actions, err := marshalRule(rule)
+	if err != nil {
+		return err
+	}
+
+	rule.CreatedAt = db.clock.Now().UTC()
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO automation_rules (workspace_id, name, event, condition, actions, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, WorkspaceFromContext(ctx), rule.Name, rule.Event, rule.Condition, actions, rule.Enabled, rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存自动化规则失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取规则 ID 失败：%w", err)
+	}
+	rule.ID = int(id)
+	return nil
+}
+
+// UpdateAutomationRuleContext 整体替换规则内容，规则不存在时返回 ErrNotFound
+func (db *DB) UpdateAutomationRuleContext(ctx context.Context, rule *model.AutomationRule) error {
+	actions, err := marshalRule(rule)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE automation_rules
+		SET name = ?, event = ?, condition = ?, conditions = '[]', actions = ?, enabled = ?
+		WHERE id = ? AND workspace_id = ?
+	`, rule.Name, rule.Event, rule.Condition, actions, rule.Enabled, rule.ID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("更新自动化规则失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("automation rule %d: %w", rule.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteAutomationRuleContext 删除规则，规则不存在时返回 ErrNotFound
+func (db *DB) DeleteAutomationRuleContext(ctx context.Context, id int) error {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM automation_rules WHERE id = ? AND workspace_id = ?
+	`, id, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除自动化规则失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("automation rule %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ListAutomationRulesContext 获取当前工作区的规则，按创建顺序排列
+// event 不为空时只返回该事件下已启用的规则，供规则执行使用
+func (db *DB) ListAutomationRulesContext(ctx context.Context, event string) ([]model.AutomationRule, error) {
+	query := `
+		SELECT id, name, event, condition, conditions, actions, enabled, created_at
+		FROM automation_rules
+		WHERE workspace_id = ?`
+	args := []interface{}{WorkspaceFromContext(ctx)}
+	if event != "" {
+		query += ` AND event = ? AND enabled = 1`
+		args = append(args, event)
+	}
+	query += ` ORDER BY id ASC`
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询自动化规则失败：%w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]model.AutomationRule, 0)
+	for rows.Next() {
+		rule, err := scanAutomationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return rules, nil
+}
+
+// GetAutomationRuleContext 获取一条规则，不存在时返回 ErrNotFound
+func (db *DB) GetAutomationRuleContext(ctx context.Context, id int) (*model.AutomationRule, error) {
+	row := db.conn.QueryRowContext(ctx, `
+		SELECT id, name, event, condition, conditions, actions, enabled, created_at
+		FROM automation_rules
+		WHERE id = ? AND workspace_id = ?
+	`, id, WorkspaceFromContext(ctx))
+
+	rule, err := scanAutomationRule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("automation rule %d: %w", id, ErrNotFound)
+	}
+	return rule, err
+}
+
+// marshalRule 把旧版条件转换为表达式，并把动作序列化为 JSON 文本
+func marshalRule(rule *model.AutomationRule) (string, error) {
+	if err := rule.MigrateConditions(); err != nil {
+		return "", err
+	}
+	a, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return "", fmt.Errorf("序列化 actions 失败：%w", err)
+	}
+	return string(a), nil
+}
+
+// scanAutomationRule 扫描一行规则，没有结果时原样返回 sql.ErrNoRows
+func scanAutomationRule(s rowScanner) (*model.AutomationRule, error) {
+	var rule model.AutomationRule
+	var conditions, actions string
+	if err := s.Scan(&rule.ID, &rule.Name, &rule.Event, &rule.Condition, &conditions, &actions, &rule.Enabled, &rule.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描失败：%w", err)
+	}
+	if rule.Condition == "" {
+		if err := json.Unmarshal([]byte(conditions), &rule.Conditions); err != nil {
+			return nil, fmt.Errorf("解析 conditions 失败：%w", err)
+		}
+		if err := rule.MigrateConditions(); err != nil {
+			return nil, fmt.Errorf("转换规则 %d 的旧版条件失败：%w", rule.ID, err)
+		}
+	}
+	if err := json.Unmarshal([]byte(actions), &rule.Actions); err != nil {
+		return nil, fmt.Errorf("解析 actions 失败：%w", err)
+	}
+	return &rule, nil
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"todo-list/storage"
+)
+
+// 按条件批量操作的类型
+const (
+	BatchOpComplete = "complete"
+	BatchOpDelete   = "delete"
+)
+
+// BatchFilter 按条件批量操作的范围，零值的字段不参与过滤
+type BatchFilter struct {
+	Status          string
+	Search          string
+	CreatedBefore   *time.Time
+	UpdatedBefore   *time.Time
+	CompletedBefore *time.Time
+}
+
+// Empty 没有任何过滤条件（会命中整个工作区）
+func (f BatchFilter) Empty() bool {
+	return f.Status == "" && f.Search == "" &&
+		f.CreatedBefore == nil && f.UpdatedBefore == nil && f.CompletedBefore == nil
+}
+
+// BatchProgress 按条件批量操作的进度，每处理完一批回调一次
+type BatchProgress struct {
+	Chunks    int   `json:"chunks"`    // 已提交的批数
+	Processed int   `json:"processed"` // 已处理的数量
+	IDs       []int `json:"-"`         // 刚提交的这一批的 ID
+}
+
+// batchFilter 添加按条件批量操作的过滤条件
+func (q *todoQuery) batchFilter(f BatchFilter, s storage.StatusSets) *todoQuery {
+	q.filter(TodoFilter{Status: f.Status, Search: f.Search}, s)
+	for _, c := range []struct {
+		column string
+		before *time.Time
+	}{
+		{"created_at", f.CreatedBefore},
+		{"updated_at", f.UpdatedBefore},
+		{"completed_at", f.CompletedBefore},
+	} {
+		if c.before != nil {
+			q.where("julianday("+c.column+") < julianday(?)", c.before.UTC().Format("2006-01-02 15:04:05"))
+		}
+	}
+	return q
+}
+
+// BatchByFilterContext 对当前工作区中符合条件的待办事项执行批量完成或删除
+// 按 ID 顺序每次取出 BATCH_MAX_SIZE 条，在各自的事务中处理并提交，
+// 大量数据不会长时间占用写锁；中途失败或取消时已提交的批次保留，返回值为已完成的进度。
+// 批量完成只处理未完成（工作流中的非终态）的事项，与 BatchCompleteTodosPartialContext 一致
+func (db *DB) BatchByFilterContext(ctx context.Context, op string, f BatchFilter, progress func(BatchProgress)) (BatchProgress, error) {
+	var done BatchProgress
+	if op != BatchOpComplete && op != BatchOpDelete {
+		return done, fmt.Errorf("未知的批量操作：%s", op)
+	}
+
+	q := scopedTodoQuery(ctx).batchFilter(f, db.statuses)
+	if op == BatchOpComplete {
+		q.where(db.statuses.OpenSQL())
+	}
+
+	lastID := 0
+	for {
+		ids, err := db.batchChunkContext(ctx, op, q, lastID)
+		if err != nil {
+			return done, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		db.invalidateTodos(ctx, ids...)
+
+		lastID = ids[len(ids)-1]
+		done.Chunks++
+		done.Processed += len(ids)
+		done.IDs = ids
+		if progress != nil {
+			progress(done)
+		}
+		if len(ids) < db.batchLimit {
+			break
+		}
+	}
+	done.IDs = nil
+	return done, nil
+}
+
+// batchChunkContext 在一个事务中取出 lastID 之后的一批并执行操作，返回处理的 ID
+func (db *DB) batchChunkContext(ctx context.Context, op string, q *todoQuery, lastID int) (ids []int, err error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("事务回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	query, args := q.clone().where("id > ?", lastID).selectSQL("id", "ORDER BY id LIMIT ?", db.batchLimit)
+	err = queryEach(ctx, tx, func(rows rowScanner) error {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询待处理的待办事项失败：%w", err)
+	}
+	if len(ids) == 0 {
+		return nil, tx.Rollback()
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	idArgs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		idArgs[i] = id
+	}
+
+	switch op {
+	case BatchOpComplete:
+		now := db.clock.Now().UTC()
+		_, err = tx.ExecContext(ctx, `
+			UPDATE todos
+			SET status = 'completed', completed_at = ?, updated_at = ?, version = version + 1
+			WHERE id IN (`+placeholders+`)
+		`, append([]interface{}{now, now}, idArgs...)...)
+	case BatchOpDelete:
+		_, err = tx.ExecContext(ctx, `DELETE FROM todos WHERE id IN (`+placeholders+`)`, idArgs...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("执行批量操作失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败：%w", err)
+	}
+	return ids, nil
+}
+
+// CountByFilterContext 统计当前工作区中符合批量操作条件的待办事项数量（预览使用）
+func (db *DB) CountByFilterContext(ctx context.Context, op string, f BatchFilter) (int, error) {
+	q := scopedTodoQuery(ctx).batchFilter(f, db.statuses)
+	if op == BatchOpComplete {
+		q.where(db.statuses.OpenSQL())
+	}
+	query, args := q.countSQL()
+	var n int
+	if err := db.conn.QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("统计待办事项失败：%w", err)
+	}
+	return n, nil
+}
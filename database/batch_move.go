@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// ErrTodosNotFound 批量移动的 ID 中有不存在于当前工作区的待办事项
+var ErrTodosNotFound = errors.New("todos not found")
+
+// BatchMoveTodosContext 在一个事务中把待办事项移到目标项目（target 为 0 表示移出项目），并调整目标列表中的顺序
+// 移动的事项按 ids 的顺序插入到目标列表（按 position、id 排列）的第 index 个位置，index 为 nil 或超出范围时放在最后；
+// 目标列表中所有事项的 position 重新编号为 1、2、3……，只修改编号有变化的行。
+// ids 中有不存在的事项时整批失败，返回 ErrTodosNotFound；目标项目不存在时返回 ErrTargetProjectNotFound。
+// 移动的事项版本号加一并更新 updated_at（产生变更事件），只是编号变化的事项不算修改
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) BatchMoveTodosContext(ctx context.Context, ids []int, target int, index *int) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+	if len(ids) > db.batchLimit {
+		return fmt.Errorf("批量操作最多支持%d个项目，当前：%d", db.batchLimit, len(ids))
+	}
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("事务回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	var targetID interface{}
+	if target != 0 {
+		if err = checkProjectTx(ctx, tx, target, workspace); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				err = fmt.Errorf("project %d: %w", target, ErrTargetProjectNotFound)
+			}
+			return err
+		}
+		targetID = target
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	idArgs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		idArgs[i] = id
+	}
+
+	// 确认所有事项都在当前工作区
+	var found []int
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var id int
+		if err := s.Scan(&id); err != nil {
+			return err
+		}
+		found = append(found, id)
+		return nil
+	}, `SELECT id FROM todos WHERE workspace_id = ? AND id IN (`+placeholders+`)`, append([]interface{}{workspace}, idArgs...)...)
+	if err != nil {
+		return fmt.Errorf("查询待办事项失败：%w", err)
+	}
+	var missing []string
+	for _, id := range ids {
+		if !slices.Contains(found, id) {
+			missing = append(missing, fmt.Sprint(id))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("todo %s: %w", strings.Join(missing, ", "), ErrTodosNotFound)
+	}
+
+	// 目标列表中原有的事项（不含移动的事项）
+	var order []int
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var id int
+		if err := s.Scan(&id); err != nil {
+			return err
+		}
+		order = append(order, id)
+		return nil
+	}, `SELECT id FROM todos WHERE workspace_id = ? AND project_id IS ? AND id NOT IN (`+placeholders+`)
+		ORDER BY position ASC, id ASC`, append([]interface{}{workspace, targetID}, idArgs...)...)
+	if err != nil {
+		return fmt.Errorf("查询目标列表失败：%w", err)
+	}
+	at := len(order)
+	if index != nil && *index >= 0 && *index < at {
+		at = *index
+	}
+	order = slices.Insert(order, at, ids...)
+
+	now := db.clock.Now().UTC()
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE todos SET project_id = ?, updated_at = ?, version = version + 1
+		WHERE id IN (`+placeholders+`)
+	`, append([]interface{}{targetID, now}, idArgs...)...); err != nil {
+		return fmt.Errorf("移动待办事项失败：%w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE todos SET position = ? WHERE id = ? AND position != ?`)
+	if err != nil {
+		return fmt.Errorf("准备语句失败：%w", err)
+	}
+	defer stmt.Close()
+	for i, id := range order {
+		if _, err = stmt.ExecContext(ctx, i+1, id, i+1); err != nil {
+			return fmt.Errorf("更新顺序失败：%w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	db.invalidateTodos(ctx, ids...)
+	return nil
+}
@@ -0,0 +1,241 @@
+package database_test
+
+// 数据库层基准测试：在生成的 1 万 / 10 万条数据上测量列表过滤、批量操作和统计的耗时
+//
+// 用法：
+//
+//	go test -run '^$' -bench . ./database                       # 默认 10000,100000 两种规模
+//	go test -run '^$' -bench ListTodos ./database -args -rows 10000 # 只跑列表查询、只用 1 万条
+//	go test -run '^$' -bench . -cpuprofile cpu.out ./database     # 同时采集 CPU profile，用 go tool pprof 查看
+//
+// 同一规模的数据集在各个基准之间共享，批量操作会在其中追加数据
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/database"
+	"todo-list/model"
+	"todo-list/workflow"
+)
+
+var benchRows = flag.String("rows", "10000,100000", "基准测试的数据规模，逗号分隔")
+
+// benchEnv 一个规模的数据集
+type benchEnv struct {
+	db     *database.DB
+	rows   int
+	nextID int // 下一条插入的 ID（AUTOINCREMENT 单写者下连续递增）
+}
+
+var (
+	benchDir  string
+	benchEnvs = make(map[int]*benchEnv)
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	for _, e := range benchEnvs {
+		e.db.Close()
+	}
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// runSizes 对每种数据规模运行一次 fn，子基准名称为 rows=N
+func runSizes(b *testing.B, fn func(b *testing.B, e *benchEnv)) {
+	for _, part := range strings.Split(*benchRows, ",") {
+		rows, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || rows <= 0 {
+			b.Fatalf("无效的数据规模 %q", part)
+		}
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			e := benchSetup(b, rows)
+			b.ReportAllocs()
+			b.ResetTimer()
+			fn(b, e)
+		})
+	}
+}
+
+// benchSetup 返回 rows 条数据的数据集，第一次使用时创建
+func benchSetup(b *testing.B, rows int) *benchEnv {
+	if e, ok := benchEnvs[rows]; ok {
+		return e
+	}
+
+	if benchDir == "" {
+		dir, err := os.MkdirTemp("", "todo-bench-")
+		if err != nil {
+			b.Fatalf("创建临时目录失败: %v", err)
+		}
+		benchDir = dir
+	}
+	db, err := database.New(filepath.Join(benchDir, fmt.Sprintf("bench-%d.db", rows)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	start := time.Now()
+	rng := rand.New(rand.NewSource(1))
+	ctx := context.Background()
+	for remaining := rows; remaining > 0; remaining -= database.MaxImportSize {
+		n := min(remaining, database.MaxImportSize)
+		if _, err := db.ImportTodosContext(ctx, generate(n, rng)); err != nil {
+			db.Close()
+			b.Fatalf("生成数据失败: %v", err)
+		}
+	}
+	b.Logf("已生成 %d 条数据: %s", rows, time.Since(start).Round(time.Millisecond))
+
+	e := &benchEnv{db: db, rows: rows, nextID: rows + 1}
+	benchEnvs[rows] = e
+	return e
+}
+
+// 生成数据用的标题片段，其中一部分包含搜索用例的关键字
+var titleWords = []string{"整理", "周报", "报告", "会议", "采购", "复盘", "发布", "面试", "预算", "合同"}
+
+// generate 生成 n 条数据：约一半已完成，七成有截止日期，一成带位置
+func generate(n int, rng *rand.Rand) []model.Todo {
+	now := time.Now()
+	todos := make([]model.Todo, n)
+	for i := range todos {
+		t := model.Todo{
+			Title:       titleWords[rng.Intn(len(titleWords))] + " " + titleWords[rng.Intn(len(titleWords))],
+			Description: strings.Repeat("描述", rng.Intn(20)),
+			Status:      workflow.StatusPending,
+			CreatedAt:   now.Add(-time.Duration(rng.Intn(365*24)) * time.Hour),
+		}
+		if rng.Intn(2) == 0 {
+			t.Status = workflow.StatusCompleted
+		}
+		if rng.Intn(10) < 7 {
+			due := now.Add(time.Duration(rng.Intn(60*24)-30*24) * time.Hour)
+			t.DueDate = &due
+		}
+		if rng.Intn(10) == 0 {
+			lat := 31.23 + rng.Float64()*0.2 - 0.1
+			lng := 121.47 + rng.Float64()*0.2 - 0.1
+			t.Latitude, t.Longitude = &lat, &lng
+		}
+		todos[i] = t
+	}
+	return todos
+}
+
+// benchList 列表查询（含总数）
+func benchList(b *testing.B, filter func(e *benchEnv) database.TodoFilter) {
+	runSizes(b, func(b *testing.B, e *benchEnv) {
+		ctx := context.Background()
+		f := filter(e)
+		for i := 0; i < b.N; i++ {
+			if _, _, err := e.db.ListTodosContext(ctx, f); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkListTodos(b *testing.B) {
+	b.Run("default", func(b *testing.B) {
+		benchList(b, func(*benchEnv) database.TodoFilter { return database.TodoFilter{} })
+	})
+	b.Run("status=pending", func(b *testing.B) {
+		benchList(b, func(*benchEnv) database.TodoFilter { return database.TodoFilter{Status: workflow.StatusPending} })
+	})
+	b.Run("search", func(b *testing.B) {
+		benchList(b, func(*benchEnv) database.TodoFilter { return database.TodoFilter{Search: "报告"} })
+	})
+	b.Run("sort=due_date", func(b *testing.B) {
+		benchList(b, func(*benchEnv) database.TodoFilter { return database.TodoFilter{Sort: "due_date", Order: "asc"} })
+	})
+	b.Run("deep-offset", func(b *testing.B) {
+		benchList(b, func(e *benchEnv) database.TodoFilter { return database.TodoFilter{Offset: e.rows - 50} })
+	})
+	b.Run("near", func(b *testing.B) {
+		benchList(b, func(*benchEnv) database.TodoFilter {
+			return database.TodoFilter{Near: &database.GeoPoint{Lat: 31.23, Lng: 121.47}, RadiusMeters: 2000}
+		})
+	})
+}
+
+// benchBatch 每轮先插入 100 条新数据（不计时），再对这 100 条执行批量操作
+func benchBatch(b *testing.B, op func(ctx context.Context, db *database.DB, ids []int) error) {
+	runSizes(b, func(b *testing.B, e *benchEnv) {
+		ctx := context.Background()
+		rng := rand.New(rand.NewSource(3))
+		ids := make([]int, 100)
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			todos := generate(len(ids), rng)
+			for j := range todos {
+				todos[j].Status = workflow.StatusPending
+			}
+			if _, err := e.db.ImportTodosContext(ctx, todos); err != nil {
+				b.Fatal(err)
+			}
+			for j := range ids {
+				ids[j] = e.nextID + j
+			}
+			e.nextID += len(ids)
+			b.StartTimer()
+
+			if err := op(ctx, e.db, ids); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkBatchComplete(b *testing.B) {
+	benchBatch(b, func(ctx context.Context, db *database.DB, ids []int) error {
+		_, err := db.BatchCompleteTodosPartialContext(ctx, ids)
+		return err
+	})
+}
+
+func BenchmarkBatchDelete(b *testing.B) {
+	benchBatch(b, func(ctx context.Context, db *database.DB, ids []int) error {
+		_, err := db.BatchDeleteTodosPartialContext(ctx, ids)
+		return err
+	})
+}
+
+func BenchmarkImport(b *testing.B) {
+	runSizes(b, func(b *testing.B, e *benchEnv) {
+		ctx := context.Background()
+		b.StopTimer()
+		todos := generate(1000, rand.New(rand.NewSource(2)))
+		b.StartTimer()
+		for i := 0; i < b.N; i++ {
+			n, err := e.db.ImportTodosContext(ctx, todos)
+			if err != nil {
+				b.Fatal(err)
+			}
+			e.nextID += n
+		}
+	})
+}
+
+func BenchmarkGetStats(b *testing.B) {
+	runSizes(b, func(b *testing.B, e *benchEnv) {
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			if _, err := e.db.GetStatsContext(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+	"todo-list/cache"
+)
+
+// cacheKeyPrefix 缓存键前缀，多个服务共用一个 Redis 时避免冲突
+const cacheKeyPrefix = "todo-list:"
+
+// SetCache 启用读缓存：单个待办事项缓存 todoTTL，统计信息缓存 statsTTL
+// 写入待办事项时会立即删除相关的缓存；未调用时所有读取直接查数据库
+func (db *DB) SetCache(c cache.Cache, todoTTL, statsTTL time.Duration) {
+	db.cache = c
+	db.todoTTL = todoTTL
+	db.statsTTL = statsTTL
+}
+
+// todoCacheKey 单个待办事项的缓存键
+func todoCacheKey(workspace string, id int) string {
+	return cacheKeyPrefix + "todo:" + workspace + ":" + strconv.Itoa(id)
+}
+
+// statsCacheKey 工作区统计信息的缓存键
+func statsCacheKey(workspace string) string {
+	return cacheKeyPrefix + "stats:" + workspace
+}
+
+// cacheGet 读取缓存并解码到 dest，未启用、未命中或出错时返回 false（调用方回源）
+func (db *DB) cacheGet(ctx context.Context, key string, dest interface{}) bool {
+	if db.cache == nil {
+		return false
+	}
+
+	data, ok, err := db.cache.Get(ctx, key)
+	if err != nil {
+		log.Printf("读取缓存失败: key=%s, error=%v", key, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		log.Printf("解码缓存失败: key=%s, error=%v", key, err)
+		return false
+	}
+	return true
+}
+
+// cacheSet 写入缓存，失败只记日志
+func (db *DB) cacheSet(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	if db.cache == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("编码缓存失败: key=%s, error=%v", key, err)
+		return
+	}
+	if err := db.cache.Set(ctx, key, data, ttl); err != nil {
+		log.Printf("写入缓存失败: key=%s, error=%v", key, err)
+	}
+}
+
+// invalidateTodos 删除当前工作区的统计缓存和指定待办事项的缓存（写入成功后调用）
+// 删除失败时旧数据最多保留到 TTL 到期
+func (db *DB) invalidateTodos(ctx context.Context, ids ...int) {
+	if db.cache == nil {
+		return
+	}
+
+	workspace := WorkspaceFromContext(ctx)
+	keys := make([]string, 0, len(ids)+1)
+	keys = append(keys, statsCacheKey(workspace))
+	for _, id := range ids {
+		keys = append(keys, todoCacheKey(workspace, id))
+	}
+
+	// 请求的 Context 可能已经取消，失效操作不能跟着放弃
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if err := db.cache.Delete(ctx, keys...); err != nil {
+		log.Printf("删除缓存失败: keys=%v, error=%v", keys, err)
+	}
+}
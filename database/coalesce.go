@@ -0,0 +1,27 @@
+package database
+
+import "context"
+
+// coalesce 合并同一时刻相同 key 的重复读取：只有第一个调用真正执行 fn，其余调用等待并共享结果
+// 用于统计这类开销大、幂等的查询，仪表盘同时刷新时不会把相同的 SQL 发给 SQLite 几十次
+//
+// fn 在脱离调用方取消信号的 Context 中执行（保留 Context 中的值，例如工作区，以及第一个调用的截止时间），
+// 某个调用方提前取消只影响它自己的等待；结果由多个调用方共享，调用方修改前需要自行复制
+func (db *DB) coalesce(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := db.flight.DoChan(key, func() (interface{}, error) {
+		shared := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			shared, cancel = context.WithDeadline(shared, deadline)
+			defer cancel()
+		}
+		return fn(shared)
+	})
+
+	select {
+	case res := <-ch:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"todo-list/model"
+)
+
+// initCommentsSchema 初始化评论表
+func (db *DB) initCommentsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		author TEXT NOT NULL,
+		body TEXT NOT NULL,
+		mentions TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_comments_todo_id ON todo_comments(todo_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_comments table: %w", err)
+	}
+	return nil
+}
+
+// CreateCommentContext 保存评论，并在同一事务中写入提及产生的通知
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) CreateCommentContext(ctx context.Context, comment *model.Comment, notifications []model.Notification) (err error) {
+	mentions, err := json.Marshal(comment.Mentions)
+	if err != nil {
+		return fmt.Errorf("序列化 mentions 失败：%w", err)
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO todo_comments (todo_id, author, body, mentions, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, comment.TodoID, comment.Author, comment.Body, string(mentions), comment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存评论失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取评论 ID 失败：%w", err)
+	}
+
+	for i := range notifications {
+		if err = insertNotification(ctx, tx, &notifications[i]); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+
+	comment.ID = int(id)
+	return nil
+}
+
+// ListCommentsContext 获取待办事项的评论，按时间先后排列
+func (db *DB) ListCommentsContext(ctx context.Context, todoID int) ([]model.Comment, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, todo_id, author, body, mentions, created_at
+		FROM todo_comments
+		WHERE todo_id = ? AND todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+		ORDER BY id ASC
+	`, todoID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]model.Comment, 0)
+	for rows.Next() {
+		var c model.Comment
+		var mentions string
+		if err := rows.Scan(&c.ID, &c.TodoID, &c.Author, &c.Body, &mentions, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if err := json.Unmarshal([]byte(mentions), &c.Mentions); err != nil {
+			return nil, fmt.Errorf("解析 mentions 失败：%w", err)
+		}
+		comments = append(comments, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return comments, nil
+}
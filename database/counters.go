@@ -0,0 +1,541 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+// initCountersSchema 初始化统计计数表和维护它们的触发器
+// 计数在写入待办事项的同一个事务中由触发器更新，GetStatsContext 直接读取，不需要扫描 todos：
+//   - todo_counters：按工作区、状态
+//   - todo_project_counters：按项目（0 表示不属于任何项目）、状态，项目列表的数量读这里
+//   - todo_due_counters：还没完成的事项按截止日期（UTC）、状态，weight 为优先级权重之和（专注度）
+//   - todo_completion_counters：已完成的事项按完成日期（UTC），cleared 为完成时已经逾期的数量
+//   - todo_latency_counters：从创建到完成的耗时按状态、优先级和整分钟分桶（见 latencyMinuteSQL），total_hours 为桶内耗时之和
+//
+// 已归档项目中的事项（hidden = 1）与列表一致不计入统计，只计入 todo_project_counters；
+// 归档和取消归档修改 hidden 时由触发器移出或重新计入。触发器用到 hidden 列，要在 initProjectsSchema 之后初始化
+//
+// 计数表刚建立（或被清空、升级后新增了计数表）而 todos 中已有数据时，按现有数据重建一次；
+// 触发器每次启动时重新建立，旧版本的触发器把 hidden 的事项也计入了统计，升级时同样重建；
+// 旧版本的耗时计数按整小时分桶，升级时删掉旧表按分钟重建
+func (db *DB) initCountersSchema() error {
+	var legacy, hourBuckets bool
+	if err := db.conn.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'trigger' AND name = 'trg_todo_counters_insert' AND sql NOT LIKE '%hidden%')
+	`).Scan(&legacy); err != nil {
+		return fmt.Errorf("failed to inspect todo_counters triggers: %w", err)
+	}
+	if err := db.conn.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM pragma_table_info('todo_latency_counters') WHERE name = 'hours')
+	`).Scan(&hourBuckets); err != nil {
+		return fmt.Errorf("failed to inspect todo_latency_counters: %w", err)
+	}
+	if hourBuckets {
+		if _, err := db.conn.Exec(`DROP TABLE todo_latency_counters`); err != nil {
+			return fmt.Errorf("failed to drop hourly todo_latency_counters: %w", err)
+		}
+		legacy = true
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_counters (
+		workspace_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (workspace_id, status)
+	);
+
+	DROP TRIGGER IF EXISTS trg_todo_counters_insert;
+	CREATE TRIGGER trg_todo_counters_insert AFTER INSERT ON todos
+	WHEN NEW.hidden = 0
+	BEGIN
+		INSERT INTO todo_counters (workspace_id, status, count) VALUES (NEW.workspace_id, NEW.status, 1)
+		ON CONFLICT (workspace_id, status) DO UPDATE SET count = count + 1;
+	END;
+
+	DROP TRIGGER IF EXISTS trg_todo_counters_delete;
+	CREATE TRIGGER trg_todo_counters_delete AFTER DELETE ON todos
+	WHEN OLD.hidden = 0
+	BEGIN
+		UPDATE todo_counters SET count = count - 1
+		WHERE workspace_id = OLD.workspace_id AND status = OLD.status;
+	END;
+
+	DROP TRIGGER IF EXISTS trg_todo_counters_update;
+	CREATE TRIGGER trg_todo_counters_update AFTER UPDATE OF status, workspace_id, hidden ON todos
+	WHEN OLD.status IS NOT NEW.status OR OLD.workspace_id IS NOT NEW.workspace_id OR OLD.hidden IS NOT NEW.hidden
+	BEGIN
+		UPDATE todo_counters SET count = count - 1
+		WHERE workspace_id = OLD.workspace_id AND status = OLD.status AND OLD.hidden = 0;
+		INSERT INTO todo_counters (workspace_id, status, count)
+		SELECT NEW.workspace_id, NEW.status, 1
+		WHERE NEW.hidden = 0
+		ON CONFLICT (workspace_id, status) DO UPDATE SET count = count + 1;
+	END;
+
+	CREATE TABLE IF NOT EXISTS todo_project_counters (
+		workspace_id TEXT NOT NULL,
+		project_id INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (workspace_id, project_id, status)
+	);
+
+	CREATE TABLE IF NOT EXISTS todo_due_counters (
+		workspace_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		status TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		weight INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (workspace_id, day, status)
+	);
+
+	CREATE TABLE IF NOT EXISTS todo_completion_counters (
+		workspace_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		weight INTEGER NOT NULL DEFAULT 0,
+		cleared INTEGER NOT NULL DEFAULT 0,
+		cleared_weight INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (workspace_id, day)
+	);
+
+	CREATE TABLE IF NOT EXISTS todo_latency_counters (
+		workspace_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		priority INTEGER NOT NULL,
+		minutes INTEGER NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		total_hours REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY (workspace_id, status, priority, minutes)
+	);
+
+	DROP TRIGGER IF EXISTS trg_todo_stats_insert;
+	CREATE TRIGGER trg_todo_stats_insert AFTER INSERT ON todos
+	BEGIN` + fmt.Sprintf(statsCountersDelta, "NEW", "1") + `END;
+
+	DROP TRIGGER IF EXISTS trg_todo_stats_delete;
+	CREATE TRIGGER trg_todo_stats_delete AFTER DELETE ON todos
+	BEGIN` + fmt.Sprintf(statsCountersDelta, "OLD", "-1") + `END;
+
+	DROP TRIGGER IF EXISTS trg_todo_stats_update;
+	CREATE TRIGGER trg_todo_stats_update
+	AFTER UPDATE OF workspace_id, status, project_id, priority, due_date, completed_at, created_at, hidden ON todos
+	WHEN OLD.workspace_id IS NOT NEW.workspace_id OR OLD.status IS NOT NEW.status OR OLD.project_id IS NOT NEW.project_id
+		OR OLD.priority IS NOT NEW.priority OR OLD.due_date IS NOT NEW.due_date
+		OR OLD.completed_at IS NOT NEW.completed_at OR OLD.created_at IS NOT NEW.created_at
+		OR OLD.hidden IS NOT NEW.hidden
+	BEGIN` + fmt.Sprintf(statsCountersDelta, "OLD", "-1") + fmt.Sprintf(statsCountersDelta, "NEW", "1") + `END;
+
+	-- 今天到期和逾期的零头、收件箱按索引查询，只涉及相应的少量事项
+	CREATE INDEX IF NOT EXISTS idx_todos_open ON todos(workspace_id, due_date) WHERE completed_at IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_todos_inbox ON todos(workspace_id)
+		WHERE completed_at IS NULL AND due_date IS NULL AND project_id IS NULL;
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_counters table: %w", err)
+	}
+
+	// 每条待办事项都计入 todo_project_counters，它为空而 todos 不为空说明计数还没有建立
+	var hasCounters, hasTodos bool
+	if err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM todo_project_counters)`).Scan(&hasCounters); err != nil {
+		return fmt.Errorf("failed to check todo_counters: %w", err)
+	}
+	if err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM todos)`).Scan(&hasTodos); err != nil {
+		return fmt.Errorf("failed to check todos: %w", err)
+	}
+	if (!hasCounters || legacy) && hasTodos {
+		if _, err := db.RebuildCountersContext(context.Background()); err != nil {
+			return err
+		}
+		log.Printf("已按现有数据重建统计计数")
+	}
+	return nil
+}
+
+// latencyMinuteSQL 把耗时（小时）折算成所在的整分钟分桶：先四舍五入到毫秒再整除，与 storage/memory 的 latencyMinute 相同
+// %s 为耗时（小时）的表达式；statsCountersDelta 中按同样的方式直接写出
+const latencyMinuteSQL = "CAST(ROUND((%s) * 3600000) AS INTEGER) / 60000"
+
+// statsCountersDelta 把一行待办事项计入（或移出）按项目、日期和耗时的计数表，hidden 的事项只计入按项目的计数
+// %[1]s 为 NEW 或 OLD，%[2]s 为 1 或 -1；计数为 0 的行保留，读取时跳过
+const statsCountersDelta = `
+		INSERT INTO todo_project_counters (workspace_id, project_id, status, count)
+		SELECT %[1]s.workspace_id, COALESCE(%[1]s.project_id, 0), %[1]s.status, %[2]s
+		WHERE 1
+		ON CONFLICT (workspace_id, project_id, status) DO UPDATE SET count = count + excluded.count;
+
+		INSERT INTO todo_due_counters (workspace_id, day, status, count, weight)
+		SELECT %[1]s.workspace_id, IFNULL(date(%[1]s.due_date), ''), %[1]s.status, %[2]s, %[2]s * MAX(%[1]s.priority + 1, 1)
+		WHERE %[1]s.due_date IS NOT NULL AND %[1]s.completed_at IS NULL AND %[1]s.hidden = 0
+		ON CONFLICT (workspace_id, day, status) DO UPDATE SET
+			count = count + excluded.count, weight = weight + excluded.weight;
+
+		INSERT INTO todo_completion_counters (workspace_id, day, count, weight, cleared, cleared_weight)
+		SELECT %[1]s.workspace_id, IFNULL(date(%[1]s.completed_at), ''), %[2]s, %[2]s * MAX(%[1]s.priority + 1, 1),
+			%[2]s * (%[1]s.due_date IS NOT NULL AND julianday(%[1]s.due_date) < julianday(%[1]s.completed_at)),
+			%[2]s * (%[1]s.due_date IS NOT NULL AND julianday(%[1]s.due_date) < julianday(%[1]s.completed_at)) * MAX(%[1]s.priority + 1, 1)
+		WHERE %[1]s.completed_at IS NOT NULL AND %[1]s.hidden = 0
+		ON CONFLICT (workspace_id, day) DO UPDATE SET
+			count = count + excluded.count, weight = weight + excluded.weight,
+			cleared = cleared + excluded.cleared, cleared_weight = cleared_weight + excluded.cleared_weight;
+
+		INSERT INTO todo_latency_counters (workspace_id, status, priority, minutes, count, total_hours)
+		SELECT %[1]s.workspace_id, %[1]s.status, %[1]s.priority,
+			CAST(ROUND((julianday(%[1]s.completed_at) - julianday(%[1]s.created_at)) * 86400000) AS INTEGER) / 60000, %[2]s,
+			%[2]s * (julianday(%[1]s.completed_at) - julianday(%[1]s.created_at)) * 24
+		WHERE %[1]s.completed_at IS NOT NULL AND %[1]s.hidden = 0 AND julianday(%[1]s.completed_at) IS NOT NULL AND julianday(%[1]s.created_at) IS NOT NULL
+		ON CONFLICT (workspace_id, status, priority, minutes) DO UPDATE SET
+			count = count + excluded.count, total_hours = total_hours + excluded.total_hours;
+	`
+
+// rebuildCountersSQL 按 todos 重新填充各计数表，与 statsCountersDelta 的口径一致
+var rebuildCountersSQL = []string{
+	`INSERT INTO todo_counters (workspace_id, status, count)
+	SELECT workspace_id, status, COUNT(*) FROM todos WHERE hidden = 0 GROUP BY workspace_id, status`,
+
+	`INSERT INTO todo_project_counters (workspace_id, project_id, status, count)
+	SELECT workspace_id, COALESCE(project_id, 0), status, COUNT(*) FROM todos GROUP BY 1, 2, 3`,
+
+	`INSERT INTO todo_due_counters (workspace_id, day, status, count, weight)
+	SELECT workspace_id, IFNULL(date(due_date), ''), status, COUNT(*), SUM(MAX(priority + 1, 1))
+	FROM todos WHERE due_date IS NOT NULL AND completed_at IS NULL AND hidden = 0 GROUP BY 1, 2, 3`,
+
+	`INSERT INTO todo_completion_counters (workspace_id, day, count, weight, cleared, cleared_weight)
+	SELECT workspace_id, day, COUNT(*), SUM(w), SUM(c), SUM(c * w)
+	FROM (
+		SELECT workspace_id, IFNULL(date(completed_at), '') AS day, MAX(priority + 1, 1) AS w,
+		       (due_date IS NOT NULL AND julianday(due_date) < julianday(completed_at)) AS c
+		FROM todos WHERE completed_at IS NOT NULL AND hidden = 0
+	) GROUP BY 1, 2`,
+
+	`INSERT INTO todo_latency_counters (workspace_id, status, priority, minutes, count, total_hours)
+	SELECT workspace_id, status, priority, ` + fmt.Sprintf(latencyMinuteSQL, "h") + `, COUNT(*), SUM(h)
+	FROM (
+		SELECT workspace_id, status, priority, (julianday(completed_at) - julianday(created_at)) * 24 AS h
+		FROM todos WHERE completed_at IS NOT NULL AND hidden = 0
+	) WHERE h IS NOT NULL GROUP BY 1, 2, 3, 4`,
+}
+
+// counterTables 所有计数表，重建时先清空
+var counterTables = []string{
+	"todo_counters", "todo_project_counters", "todo_due_counters", "todo_completion_counters", "todo_latency_counters",
+}
+
+// RebuildCountersContext 按 todos 的实际数据重建所有工作区的计数，返回重建后按状态的计数
+// 计数只由触发器维护，正常情况下不需要调用；直接修改过数据库文件等情况下用来修复
+func (db *DB) RebuildCountersContext(ctx context.Context) (counts map[string]map[string]int, err error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("事务回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	for _, table := range counterTables {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+			return nil, fmt.Errorf("清空计数失败：%w", err)
+		}
+	}
+	for _, query := range rebuildCountersSQL {
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			return nil, fmt.Errorf("重建计数失败：%w", err)
+		}
+	}
+
+	counts = make(map[string]map[string]int)
+	err = queryEach(ctx, tx, func(rows rowScanner) error {
+		var workspace, status string
+		var n int
+		if err := rows.Scan(&workspace, &status, &n); err != nil {
+			return err
+		}
+		if counts[workspace] == nil {
+			counts[workspace] = make(map[string]int)
+		}
+		counts[workspace][status] = n
+		return nil
+	}, `SELECT workspace_id, status, count FROM todo_counters`)
+	if err != nil {
+		return nil, fmt.Errorf("读取计数失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败：%w", err)
+	}
+
+	// 计数变化后统计缓存也要失效
+	for workspace := range counts {
+		db.invalidateTodos(WithWorkspace(ctx, workspace))
+	}
+	return counts, nil
+}
+
+// countersByStatusContext 从计数表读取当前工作区各状态的数量，数量为 0 的状态不返回
+func (db *DB) countersByStatusContext(ctx context.Context) (map[string]int, error) {
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT status, count FROM todo_counters WHERE workspace_id = ? AND count > 0`, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("读取统计计数失败：%w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("扫描统计计数失败：%w", err)
+		}
+		counts[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历统计计数失败：%w", err)
+	}
+	return counts, nil
+}
+
+// dueDay 某个截止日期（UTC）上还没完成的事项数量和权重
+type dueDay struct {
+	day    string
+	count  int
+	weight int
+}
+
+// counterStatsContext 从计数表读取当前工作区的统计信息，与 queryStatsContext 不带过滤条件时的结果一致（都不含已归档项目中的事项）
+// 读取的行数与状态、有截止日期的天数和耗时分桶数有关，与待办事项的数量无关；
+// 只有今天到期的事项（判断是否已过截止时刻）、逾期时长分界当天的事项和收件箱需要按索引查询 todos
+func (db *DB) counterStatsContext(ctx context.Context) (*TodoStats, error) {
+	now := db.clock.Now().UTC()
+	today := now.Format("2006-01-02")
+	weekLater := now.AddDate(0, 0, 7).Format("2006-01-02")
+
+	byStatus, err := db.countersByStatusContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := TodoStats{ByStatus: byStatus}
+	for _, n := range byStatus {
+		stats.Total += n
+	}
+	stats.Pending = db.statuses.CountOpen(byStatus)
+	stats.Completed = db.statuses.CountDone(byStatus)
+
+	days, err := db.dueDaysContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remaining := 0
+	for _, d := range days {
+		if d.day == today {
+			stats.Today += d.count
+		}
+		if d.day >= today && d.day <= weekLater {
+			stats.ThisWeek += d.count
+		}
+		if d.day <= today {
+			remaining += d.weight
+		}
+	}
+
+	// 逾期时长按截止时刻划分：超过 7 天、1 到 7 天、不到 1 天
+	overdue, err := db.dueBeforeContext(ctx, days, now)
+	if err != nil {
+		return nil, err
+	}
+	overSeven, err := db.dueBeforeContext(ctx, days, now.AddDate(0, 0, -7))
+	if err != nil {
+		return nil, err
+	}
+	oneDay, err := db.dueBeforeContext(ctx, days, now.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	stats.Overdue = overdue
+	stats.OverdueBuckets = &OverdueBuckets{
+		LessThanDay: overdue - oneDay,
+		OneToSeven:  oneDay - overSeven,
+		OverSeven:   overSeven,
+	}
+
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE workspace_id = ? AND `+inboxCondition,
+		WorkspaceFromContext(ctx)).Scan(&stats.Inbox); err != nil {
+		return nil, fmt.Errorf("查询收件箱数量失败：%w", err)
+	}
+
+	if stats.Focus, err = db.counterFocusContext(ctx, today, remaining); err != nil {
+		return nil, err
+	}
+	if stats.CompletionLatency, err = db.counterLatencyContext(ctx); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// dueDaysContext 读取当前工作区还没完成的事项按截止日期的数量和权重，按日期排列
+func (db *DB) dueDaysContext(ctx context.Context) ([]dueDay, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT day, SUM(count), SUM(weight) FROM todo_due_counters
+		WHERE workspace_id = ? AND `+db.statuses.OpenSQL()+` AND count > 0
+		GROUP BY day ORDER BY day
+	`, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("读取到期计数失败：%w", err)
+	}
+	defer rows.Close()
+
+	var days []dueDay
+	for rows.Next() {
+		var d dueDay
+		if err := rows.Scan(&d.day, &d.count, &d.weight); err != nil {
+			return nil, fmt.Errorf("扫描到期计数失败：%w", err)
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历到期计数失败：%w", err)
+	}
+	return days, nil
+}
+
+// dueBeforeContext 截止时间早于 t 的未完成事项数量：t 之前的整天来自计数，t 当天按索引查询
+func (db *DB) dueBeforeContext(ctx context.Context, days []dueDay, t time.Time) (int, error) {
+	day := t.Format("2006-01-02")
+	n := 0
+	for _, d := range days {
+		if d.day < day {
+			n += d.count
+		}
+	}
+
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	var partial int
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM todos
+		WHERE workspace_id = ? AND completed_at IS NULL AND hidden = 0 AND due_date >= ? AND due_date < ? AND `+db.statuses.OpenSQL(),
+		WorkspaceFromContext(ctx), start, t).Scan(&partial)
+	if err != nil {
+		return 0, fmt.Errorf("查询逾期数量失败：%w", err)
+	}
+	return n + partial, nil
+}
+
+// counterFocusContext 按完成计数计算当天的专注度，remaining 为今天及以前到期、还没完成的权重之和
+func (db *DB) counterFocusContext(ctx context.Context, today string, remaining int) (*FocusScore, error) {
+	var completed, cleared, weight, clearedWeight int
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT count, cleared, weight, cleared_weight FROM todo_completion_counters WHERE workspace_id = ? AND day = ?
+	`, WorkspaceFromContext(ctx), today).Scan(&completed, &cleared, &weight, &clearedWeight)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("读取完成计数失败：%w", err)
+	}
+
+	focus := &FocusScore{
+		Date:           today,
+		Completed:      completed,
+		OverdueCleared: cleared,
+		Points:         weight + clearedWeight,
+		Remaining:      remaining,
+	}
+	if total := focus.Points + focus.Remaining; total > 0 {
+		focus.Score = int(math.Round(float64(focus.Points) * 100 / float64(total)))
+	}
+	return focus, nil
+}
+
+// latencyBucket 耗时分桶中的一个整分钟
+type latencyBucket struct {
+	minutes int
+	count   int
+	total   float64
+}
+
+// counterLatencyContext 按耗时计数统计当前工作区已完成事项的耗时
+// 平均值是精确的；中位数取中间位置所在分桶的平均耗时，同一分钟内的差别不再区分，与 latencyQuery 相同
+func (db *DB) counterLatencyContext(ctx context.Context) (*CompletionLatency, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT priority, minutes, SUM(count), SUM(total_hours) FROM todo_latency_counters
+		WHERE workspace_id = ? AND `+db.statuses.DoneSQL()+` AND count > 0
+		GROUP BY priority, minutes ORDER BY priority, minutes
+	`, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("读取耗时计数失败：%w", err)
+	}
+	defer rows.Close()
+
+	byPriority := make(map[int][]latencyBucket)
+	overall := make(map[int]*latencyBucket)
+	var priorities []int
+	for rows.Next() {
+		var priority int
+		var b latencyBucket
+		if err := rows.Scan(&priority, &b.minutes, &b.count, &b.total); err != nil {
+			return nil, fmt.Errorf("扫描耗时计数失败：%w", err)
+		}
+		if _, ok := byPriority[priority]; !ok {
+			priorities = append(priorities, priority)
+		}
+		byPriority[priority] = append(byPriority[priority], b)
+		if o := overall[b.minutes]; o != nil {
+			o.count += b.count
+			o.total += b.total
+		} else {
+			overall[b.minutes] = &latencyBucket{minutes: b.minutes, count: b.count, total: b.total}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历耗时计数失败：%w", err)
+	}
+
+	result := &CompletionLatency{ByPriority: make([]PriorityLatency, 0, len(priorities))}
+	for _, p := range priorities {
+		result.ByPriority = append(result.ByPriority, PriorityLatency{Priority: p, LatencyStats: bucketLatency(byPriority[p])})
+	}
+	merged := make([]latencyBucket, 0, len(overall))
+	for _, b := range overall {
+		merged = append(merged, *b)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].minutes < merged[j].minutes })
+	result.Overall = bucketLatency(merged)
+	return result, nil
+}
+
+// bucketLatency 由按分钟升序排列的分桶计算数量、平均值和中位数（与 latencyQuery 相同，偶数个时取中间两个的平均）
+func bucketLatency(buckets []latencyBucket) LatencyStats {
+	var s LatencyStats
+	var total float64
+	for _, b := range buckets {
+		s.Count += b.count
+		total += b.total
+	}
+	if s.Count == 0 {
+		return s
+	}
+	s.AvgHours = total / float64(s.Count)
+
+	// rank 为第几个（从 1 开始），返回它所在分桶的平均耗时
+	at := func(rank int) float64 {
+		seen := 0
+		for _, b := range buckets {
+			seen += b.count
+			if seen >= rank {
+				return b.total / float64(b.count)
+			}
+		}
+		return 0
+	}
+	s.MedianHours = (at((s.Count+1)/2) + at((s.Count+2)/2)) / 2
+	return s
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo-list/model"
+)
+
+// 旧版本按整小时分桶的耗时计数表在启动时删掉，按分钟重建
+func TestHourlyLatencyCountersRebuilt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latency.db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	created := time.Date(2026, 6, 10, 8, 0, 0, 0, time.UTC)
+	var todos []model.Todo
+	for _, d := range []time.Duration{10 * time.Minute, 10*time.Minute + 30*time.Second, 95 * time.Minute} {
+		completedAt := created.Add(d)
+		todos = append(todos, model.Todo{Title: "done", Status: "completed", CreatedAt: created, CompletedAt: &completedAt})
+	}
+	if _, err := db.ImportTodosContext(ctx, todos); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec(`
+		DROP TABLE todo_latency_counters;
+		CREATE TABLE todo_latency_counters (
+			workspace_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			priority INTEGER NOT NULL,
+			hours INTEGER NOT NULL,
+			count INTEGER NOT NULL DEFAULT 0,
+			total_hours REAL NOT NULL DEFAULT 0,
+			PRIMARY KEY (workspace_id, status, priority, hours)
+		);
+	`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	stats, err := db.GetStatsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10 分钟和 10 分 30 秒落在同一个分桶，中位数取这个分桶的平均
+	if l := stats.CompletionLatency; l == nil || l.Overall.Count != 3 || math.Abs(l.Overall.MedianHours-10.25/60) > 1e-6 {
+		t.Errorf("CompletionLatency after upgrade = %+v, want 3 completed with median 10.25 minutes", l)
+	}
+}
@@ -6,21 +6,72 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"maps"
 	"time"
+	"todo-list/cache"
+	"todo-list/clock"
 	"todo-list/model"
 
-	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/sync/singleflight"
+	"todo-list/storage"
 )
 
 type DB struct {
-	conn *sql.DB
+	conn       *sql.DB
+	batchLimit int // 单次批量操作的最大 ID 数量
+
+	// 可选的读缓存，见 SetCache
+	cache    cache.Cache
+	todoTTL  time.Duration
+	statsTTL time.Duration
+
+	// 合并相同的并发读取，见 coalesce
+	flight singleflight.Group
+
+	// 当前时间的来源，见 SetClock
+	clock clock.Clock
+
+	// 工作流中的未完成状态和终态，见 SetStatuses
+	statuses storage.StatusSets
 }
 
-var ErrVersionConflict = errors.New("todo version conflict")
+// ErrVersionConflict 版本号不一致（乐观锁），定义见 storage
+var ErrVersionConflict = storage.ErrVersionConflict
+
+// ErrNotFound 记录不存在（或不属于当前工作区），Get/Update/Delete 统一返回该错误，定义见 storage
+var ErrNotFound = storage.ErrNotFound
+
+// 批量写入上限，见 storage
+const (
+	DefaultBatchLimit = storage.DefaultBatchLimit
+	MaxImportSize     = storage.MaxImportSize
+)
+
+// SetBatchLimit 设置单次批量操作上限，n <= 0 时恢复默认值
+func (db *DB) SetBatchLimit(n int) {
+	if n <= 0 {
+		n = DefaultBatchLimit
+	}
+	db.batchLimit = n
+}
+
+// SetClock 替换取当前时间的时钟（测试中使用 clock.Fake），逾期统计、周回顾等按该时钟判断
+func (db *DB) SetClock(c clock.Clock) {
+	db.clock = c
+}
+
+// SetStatuses 按工作流设置未完成状态和终态，未完成列表、逾期、统计、工作量和批量完成都按它判断
+func (db *DB) SetStatuses(s storage.StatusSets) {
+	db.statuses = s
+}
+
+// BatchLimit 返回当前的批量操作上限
+func (db *DB) BatchLimit() int {
+	return db.batchLimit
+}
 
 func New(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	conn, err := sql.Open(driverName, driverDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -29,7 +80,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, batchLimit: DefaultBatchLimit, clock: clock.Real{}, statuses: storage.DefaultStatusSets()}
 
 	if err := db.initSchema(); err != nil {
 		return nil, err
@@ -52,7 +103,19 @@ func (db *DB) initSchema() error {
   		due_date TEXT,
   		created_at DATETIME NOT NULL,
   		updated_at DATETIME NOT NULL,
-  		completed_at DATETIME
+  		completed_at DATETIME,
+  		latitude REAL,
+  		longitude REAL,
+  		radius REAL,
+  		estimated_minutes INTEGER,
+  		workspace_id TEXT NOT NULL DEFAULT 'default',
+  		public_id TEXT,
+  		recurrence TEXT,
+  		recurrence_start TEXT,
+  		next_occurrence_id INTEGER,
+  		project_id INTEGER,
+  		secret_note TEXT,
+  		secret_note_key_hint TEXT
   	);
 
   	CREATE INDEX IF NOT EXISTS idx_status ON todos(status);
@@ -63,7 +126,108 @@ func (db *DB) initSchema() error {
 		return err
 	}
 
-	return db.ensureVersionColumn()
+	if err := db.ensureVersionColumn(); err != nil {
+		return err
+	}
+
+	// 位置、工作区、预估耗时、重复规则、项目、加密备注、列表中的排列顺序字段（旧数据库没有这些列）
+	for _, col := range []struct{ name, definition string }{
+		{"latitude", "REAL"},
+		{"longitude", "REAL"},
+		{"radius", "REAL"},
+		{"workspace_id", "TEXT NOT NULL DEFAULT 'default'"},
+		{"estimated_minutes", "INTEGER"},
+		{"public_id", "TEXT"},
+		{"recurrence", "TEXT"},
+		{"recurrence_start", "TEXT"},
+		{"next_occurrence_id", "INTEGER"},
+		{"project_id", "INTEGER"},
+		{"secret_note", "TEXT"},
+		{"secret_note_key_hint", "TEXT"},
+		{"position", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := db.ensureColumn(col.name, col.definition); err != nil {
+			return err
+		}
+	}
+
+	// 其他功能的附属表
+	for _, initTable := range []func() error{
+		db.initLinksSchema,
+		db.initNotificationSchema,
+		db.initEscalationSchema,
+		db.initWorkspaceSchema,
+		db.initAgingSchema,
+		db.initCommentsSchema,
+		db.initNotificationsSchema,
+		db.initAttachmentsSchema,
+		db.initJobsSchema,
+		db.initLeasesSchema,
+		db.initAutomationSchema,
+		db.initHabitsSchema,
+		db.initGoalsSchema,
+		db.initPublicIDSchema,
+		db.initUploadsSchema,
+		db.initRecurrenceSchema,
+		db.initProjectsSchema,
+		db.initCountersSchema, // 用到 initProjectsSchema 添加的 hidden 列
+		db.initAccessSchema,
+		db.initNumbersSchema,
+		db.initAPIKeysSchema,
+		db.initIssuesSchema,
+		db.initInvitesSchema,
+		db.initTodoEventsSchema,
+		db.initWebhooksSchema,
+		db.initWebhookDeliveriesSchema,
+		db.initHookDeliveriesSchema,
+	} {
+		if err := initTable(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureColumn 如果 todos 表缺少指定列则自动添加（只适用于可为 NULL 或带默认值的列）
+func (db *DB) ensureColumn(name, definition string) error {
+	return db.ensureTableColumn("todos", name, definition)
+}
+
+// ensureTableColumn 为旧版本建立的表补上新增的列，table 只能传入代码中的常量
+func (db *DB) ensureTableColumn(table, name, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf(`PRAGMA table_info(%s);`, table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s table: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			colName    string
+			dataType   string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &colName, &dataType, &notNull, &defaultVal, &pk); err != nil {
+			return fmt.Errorf("failed to scan %s schema: %w", table, err)
+		}
+		if colName == name {
+			return nil
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate %s schema: %w", table, err)
+	}
+
+	if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition)); err != nil {
+		return fmt.Errorf("failed to add %s column: %w", name, err)
+	}
+
+	return nil
 }
 
 func (db *DB) ensureVersionColumn() error {
@@ -120,9 +284,12 @@ func (db *DB) Close() error {
 // CreateTodo 创建待办事项
 func (db *DB) CreateTodo(todo *model.Todo) error {
 	query := `
-  		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version)
-  		VALUES (?, ?, ?, ?, ?, ?, ?)
+  		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+  		                   latitude, longitude, radius, estimated_minutes, priority, public_id,
+  		                   recurrence, recurrence_start, project_id, secret_note, secret_note_key_hint)
+  		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
+	ensurePublicID(todo)
 
 	result, err := db.conn.Exec(
 		query,
@@ -133,6 +300,17 @@ func (db *DB) CreateTodo(todo *model.Todo) error {
 		todo.CreatedAt,
 		todo.UpdatedAt,
 		todo.Version,
+		todo.Latitude,
+		todo.Longitude,
+		todo.Radius,
+		todo.EstimatedMinutes,
+		todo.Priority,
+		todo.PublicID,
+		nullString(todo.Recurrence),
+		todo.RecurrenceStart,
+		todo.ProjectID,
+		noteCiphertext(todo.SecretNote),
+		noteKeyHint(todo.SecretNote),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create todo: %w", err)
@@ -147,91 +325,24 @@ func (db *DB) CreateTodo(todo *model.Todo) error {
 	return nil
 }
 
-// TodoFilter 查询过滤器
-type TodoFilter struct {
-	Status string
-	Search string
-	Sort   string
-	Order  string
-	Limit  int
-	Offset int
-}
+// TodoFilter 查询过滤器，定义见 storage
+type TodoFilter = storage.TodoFilter
 
 // ListTodos 获取待办事项列表（支持筛选、搜索、分页）
 func (db *DB) ListTodos(filter TodoFilter) ([]model.Todo, int, error) {
-	// 设置默认值
-	if filter.Sort == "" {
-		filter.Sort = "created_at"
-	}
-	if filter.Order == "" {
-		filter.Order = "DESC"
-	} else {
-		filter.Order = strings.ToUpper(filter.Order) // 转换大写
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 50
-	}
-	if filter.Status == "" {
-		filter.Status = "all"
-	}
-
-	baseQuery := "SELECT id, version, title, description, status, due_date, created_at, updated_at, completed_at FROM todos WHERE 1=1"
-	args := []interface{}{}
-
-	// 动态添加查询条件
-	if filter.Status != "" && filter.Status != "all" {
-		baseQuery += " AND status = ?"
-		args = append(args, filter.Status)
-	}
-
-	if filter.Search != "" {
-		baseQuery += " AND (title LIKE ? OR description LIKE ?)"
-		searchPattern := "%" + filter.Search + "%"
-		args = append(args, searchPattern, searchPattern)
-	}
+	filter = NormalizeFilter(filter)
+	q := newTodoQuery().filter(filter, db.statuses)
 
 	// 查询总数
-	countQuery := "SELECT COUNT(*) FROM todos WHERE 1=1"
-	countArgs := []interface{}{}
-
-	// 复制筛选条件到计数查询
-	if filter.Status != "" && filter.Status != "all" {
-		countQuery += " AND status = ?"
-		countArgs = append(countArgs, filter.Status)
-	}
-	if filter.Search != "" {
-		countQuery += " AND (title LIKE ? OR description LIKE ?)"
-		searchPattern := "%" + filter.Search + "%"
-		countArgs = append(countArgs, searchPattern, searchPattern)
-	}
-
+	countQuery, countArgs := q.countSQL()
 	var total int
 	err := db.conn.QueryRow(countQuery, countArgs...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("查询总数失败: %w", err)
 	}
 
-	// 添加排序和分页（验证 sort 和 order 防止 SQL 注入）
-	allowedSortFields := map[string]bool{
-		"created_at": true,
-		"due_date":   true,
-		"status":     true,
-	}
-	allowedOrders := map[string]bool{
-		"ASC":  true,
-		"DESC": true,
-	}
-
-	if !allowedSortFields[filter.Sort] {
-		filter.Sort = "created_at"
-	}
-	if !allowedOrders[filter.Order] {
-		filter.Order = "DESC"
-	}
-
-	// 这里 sort 和 order 已经验证过，可以安全拼接
-	baseQuery += fmt.Sprintf(" ORDER BY %s %s LIMIT ? OFFSET ?", filter.Sort, filter.Order)
-	args = append(args, filter.Limit, filter.Offset)
+	// 添加排序和分页
+	baseQuery, args := q.pageSQL(todoColumns, filter)
 
 	// 执行查询
 	rows, err := db.conn.Query(baseQuery, args...)
@@ -242,35 +353,12 @@ func (db *DB) ListTodos(filter TodoFilter) ([]model.Todo, int, error) {
 
 	var todos []model.Todo
 	for rows.Next() {
-		var todo model.Todo
-		var dueDate, completedAt sql.NullString
-
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Version,
-			&todo.Title,
-			&todo.Description,
-			&todo.Status,
-			&dueDate,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-			&completedAt,
-		)
+		todo, err := scanTodo(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("扫描失败: %w", err)
 		}
 
-		if dueDate.Valid {
-			t, _ := time.Parse(time.RFC3339, dueDate.String)
-			todo.DueDate = &t
-		}
-
-		if completedAt.Valid {
-			t, _ := time.Parse(time.RFC3339, completedAt.String)
-			todo.CompletedAt = &t
-		}
-
-		todos = append(todos, todo)
+		todos = append(todos, *todo)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -282,35 +370,18 @@ func (db *DB) ListTodos(filter TodoFilter) ([]model.Todo, int, error) {
 
 // GetTodoByID 根据ID获取待办事项
 func (db *DB) GetTodoByID(id int) (*model.Todo, error) {
-	query := `
-  		SELECT id, version, title, description, status, due_date,
-  		       created_at, updated_at, completed_at
-  		FROM todos
-  		WHERE id = ?
-	`
+	query := "SELECT " + todoColumns + " FROM todos WHERE id = ?"
 
-	var todo model.Todo
-
-	err := db.conn.QueryRow(query, id).Scan(
-		&todo.ID,
-		&todo.Version,
-		&todo.Title,
-		&todo.Description,
-		&todo.Status,
-		&todo.DueDate,
-		&todo.CreatedAt,
-		&todo.UpdatedAt,
-		&todo.CompletedAt,
-	)
+	todo, err := scanTodo(db.conn.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return nil, fmt.Errorf("todo %d: %w", id, ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
 
-	return &todo, nil
+	return todo, nil
 }
 
 // UpdateTodo 更新待办事项
@@ -318,11 +389,14 @@ func (db *DB) UpdateTodo(todo *model.Todo) error {
 	query := `
   		UPDATE todos
   		SET title = ?, description = ?, status = ?,
-  		    due_date = ?, updated_at = ?, completed_at = ?, version = version + 1
+  		    due_date = ?, updated_at = ?, completed_at = ?,
+  		    latitude = ?, longitude = ?, radius = ?, estimated_minutes = ?, priority = ?,
+  		    recurrence = ?, recurrence_start = ?, project_id = ?,
+  		    secret_note = ?, secret_note_key_hint = ?, version = version + 1
   		WHERE id = ? AND version = ?
 	`
 
-	todo.UpdatedAt = time.Now()
+	todo.UpdatedAt = db.clock.Now()
 
 	result, err := db.conn.Exec(
 		query,
@@ -332,6 +406,16 @@ func (db *DB) UpdateTodo(todo *model.Todo) error {
 		todo.DueDate,
 		todo.UpdatedAt,
 		todo.CompletedAt,
+		todo.Latitude,
+		todo.Longitude,
+		todo.Radius,
+		todo.EstimatedMinutes,
+		todo.Priority,
+		nullString(todo.Recurrence),
+		todo.RecurrenceStart,
+		todo.ProjectID,
+		noteCiphertext(todo.SecretNote),
+		noteKeyHint(todo.SecretNote),
 		todo.ID,
 		todo.Version,
 	)
@@ -346,7 +430,7 @@ func (db *DB) UpdateTodo(todo *model.Todo) error {
 	}
 
 	if rows == 0 {
-		return ErrVersionConflict
+		return db.updateMissError(context.Background(), todo.ID, "")
 	}
 
 	todo.Version++
@@ -369,37 +453,31 @@ func (db *DB) DeleteTodo(id int) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("todo not found")
+		return fmt.Errorf("todo %d: %w", id, ErrNotFound)
 	}
 
 	return nil
 }
 
-// TodoStats 统计信息
-type TodoStats struct {
-	Total     int `json:"total"`     // 总数量
-	Pending   int `json:"pending"`   // 未完成
-	Completed int `json:"completed"` // 已完成
-	Overdue   int `json:"overdue"`   // 已逾期
-	Today     int `json:"today"`     // 今天到期
-	ThisWeek  int `json:"this_week"` // 本周到期
-}
+// TodoStats 统计信息，定义见 storage
+type TodoStats = storage.TodoStats
 
 // GetStats 获取待办事项统计信息
 func (db *DB) GetStats() (*TodoStats, error) {
 	// 获取 UTC 时间
-	now := time.Now().UTC()
+	now := db.clock.Now().UTC()
 	today := now.Format("2006-01-02")
 	weekLater := now.AddDate(0, 0, 7).Format("2006-01-02")
 
+	open := db.statuses.OpenSQL()
 	query := `
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END) as pending,
-			SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) as completed,
-			SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND due_date < ? THEN 1 ELSE 0 END) as overdue,
-			SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND date(due_date) = ? THEN 1 ELSE 0 END) as today,
-			SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND date(due_date) BETWEEN ? AND ? THEN 1 ELSE 0 END) as this_week
+			SUM(CASE WHEN ` + open + ` THEN 1 ELSE 0 END) as pending,
+			SUM(CASE WHEN ` + db.statuses.DoneSQL() + ` THEN 1 ELSE 0 END) as completed,
+			SUM(CASE WHEN ` + open + ` AND due_date IS NOT NULL AND due_date < ? THEN 1 ELSE 0 END) as overdue,
+			SUM(CASE WHEN ` + open + ` AND due_date IS NOT NULL AND date(due_date) = ? THEN 1 ELSE 0 END) as today,
+			SUM(CASE WHEN ` + open + ` AND due_date IS NOT NULL AND date(due_date) BETWEEN ? AND ? THEN 1 ELSE 0 END) as this_week
 		FROM todos
 	`
 
@@ -441,71 +519,22 @@ func (db *DB) GetStats() (*TodoStats, error) {
 
 // ListTodosContext 获取待办事项列表(支持 Context)
 func (db *DB) ListTodosContext(ctx context.Context, filter TodoFilter) ([]model.Todo, int, error) {
-	// 设置默认值
-	if filter.Sort == "" {
-		filter.Sort = "created_at"
-	}
-	if filter.Order == "" {
-		filter.Order = "DESC"
-	} else {
-		filter.Order = strings.ToUpper(filter.Order)
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 50
-	}
-	if filter.Status == "" {
-		filter.Status = "all"
-	}
-
-	baseQuery := "SELECT id, version, title, description, status, due_date, created_at, updated_at, completed_at FROM todos WHERE 1=1"
-	args := []interface{}{}
-
-	// 查询总数(带 Context)
-	countQuery := "SELECT COUNT(*) FROM todos WHERE 1=1"
-
-	// 动态添加查询条件
-	if filter.Status != "" && filter.Status != "all" {
-		whereClause := " AND status = ?"
-		baseQuery += whereClause
-		countQuery += whereClause
-		args = append(args, filter.Status)
-	}
-
-	if filter.Search != "" {
-		searchPattern := "%" + filter.Search + "%"
-		whereClause := " AND (title LIKE ? OR description LIKE ?)"
-		baseQuery += whereClause
-		countQuery += whereClause
-		args = append(args, searchPattern, searchPattern)
-	}
-
-	var total int
-	// 使用 QueryRowContext 而不是 QueryRow
-	err := db.conn.QueryRowContext(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("查询总数失败：%w", err)
-	}
+	filter = NormalizeFilter(filter)
 
-	// 添加排序和分页
-	allowedSortFields := map[string]bool{
-		"created_at": true,
-		"due_date":   true,
-		"status":     true,
-	}
-	allowedOrders := map[string]bool{
-		"ASC":  true,
-		"DESC": true,
+	// 只查询当前工作区的数据
+	q := scopedTodoQuery(ctx).filter(filter, db.statuses)
+	// 已归档项目中的事项只在按项目查看或明确要求时列出
+	if filter.ProjectID == nil && !filter.IncludeArchived {
+		q.where("hidden = 0")
 	}
 
-	if !allowedSortFields[filter.Sort] {
-		filter.Sort = "created_at"
-	}
-	if !allowedOrders[filter.Order] {
-		filter.Order = "DESC"
+	// 总数和当前页在同一次查询中返回：COUNT(*) OVER() 在 LIMIT 之前计算，每一行都带着过滤后的总数
+	total := -1
+	columns := todoColumns
+	if !filter.SkipTotal {
+		columns += ", COUNT(*) OVER()"
 	}
-
-	baseQuery += fmt.Sprintf(" ORDER BY %s %s LIMIT ? OFFSET ?", filter.Sort, filter.Order)
-	args = append(args, filter.Limit, filter.Offset)
+	baseQuery, args := q.pageSQL(columns, filter)
 
 	// 执行查询(带 Context)
 	rows, err := db.conn.QueryContext(ctx, baseQuery, args...)
@@ -535,42 +564,16 @@ func (db *DB) ListTodosContext(ctx context.Context, filter TodoFilter) ([]model.
 			// 不阻塞，继续执行
 		}
 
-		var todo model.Todo
-		var dueDate, completedAt sql.NullString
-
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Version,
-			&todo.Title,
-			&todo.Description,
-			&todo.Status,
-			&dueDate,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-			&completedAt,
-		)
+		var scanner rowScanner = rows
+		if !filter.SkipTotal {
+			scanner = withTotal{rows, &total}
+		}
+		todo, err := scanTodo(scanner)
 		if err != nil {
 			return nil, 0, fmt.Errorf("扫描失败：%w", err)
 		}
 
-		if dueDate.Valid {
-			if t, err := time.Parse(time.RFC3339, dueDate.String); err == nil {
-				todo.DueDate = &t
-			} else {
-				return nil, 0, fmt.Errorf("解析 due_date 失败：%w", err)
-			}
-		}
-
-		if completedAt.Valid {
-			if t, err := time.Parse(time.RFC3339, completedAt.String); err == nil {
-				todo.CompletedAt = &t
-			} else {
-				return nil, 0, fmt.Errorf("解析 completed_at 失败：%w", err)
-			}
-
-		}
-
-		todos = append(todos, todo)
+		todos = append(todos, *todo)
 	}
 
 	// 检查迭代过程中的错误
@@ -578,15 +581,29 @@ func (db *DB) ListTodosContext(ctx context.Context, filter TodoFilter) ([]model.
 		return nil, 0, fmt.Errorf("rows iteration error: %w", err)
 	}
 
+	// 当前页没有数据时拿不到窗口函数的结果：从第一页开始说明总数为 0，否则偏移量超出了范围，需要单独计数
+	if !filter.SkipTotal && len(todos) == 0 {
+		total = 0
+		if filter.Offset > 0 {
+			countQuery, countArgs := q.countSQL()
+			if err := db.conn.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+				return nil, 0, fmt.Errorf("查询总数失败：%w", err)
+			}
+		}
+	}
+
 	return todos, total, nil
 }
 
 // CreateTodoContext 创建待办事项(支持 Context)
 func (db *DB) CreateTodoContext(ctx context.Context, todo *model.Todo) error {
 	query := `
-		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+		                   latitude, longitude, radius, estimated_minutes, priority, public_id, workspace_id,
+		                   recurrence, recurrence_start, project_id, secret_note, secret_note_key_hint)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
+	ensurePublicID(todo)
 
 	result, err := db.conn.ExecContext(
 		ctx,
@@ -598,6 +615,18 @@ func (db *DB) CreateTodoContext(ctx context.Context, todo *model.Todo) error {
 		todo.CreatedAt,
 		todo.UpdatedAt,
 		todo.Version,
+		todo.Latitude,
+		todo.Longitude,
+		todo.Radius,
+		todo.EstimatedMinutes,
+		todo.Priority,
+		todo.PublicID,
+		WorkspaceFromContext(ctx),
+		nullString(todo.Recurrence),
+		todo.RecurrenceStart,
+		todo.ProjectID,
+		noteCiphertext(todo.SecretNote),
+		noteKeyHint(todo.SecretNote),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create todo: %w", err)
@@ -609,19 +638,49 @@ func (db *DB) CreateTodoContext(ctx context.Context, todo *model.Todo) error {
 	}
 
 	todo.ID = int(id)
+	db.invalidateTodos(ctx)
 	return nil
 }
 
+// GetTodoByIDContext 根据ID获取当前工作区的待办事项(支持 Context)
+// 启用缓存时先查缓存，未命中再查数据库并写回缓存
+func (db *DB) GetTodoByIDContext(ctx context.Context, id int) (*model.Todo, error) {
+	workspace := WorkspaceFromContext(ctx)
+	key := todoCacheKey(workspace, id)
+
+	var cached model.Todo
+	if db.cacheGet(ctx, key, &cached) {
+		return &cached, nil
+	}
+
+	query := "SELECT " + todoColumns + " FROM todos WHERE id = ? AND workspace_id = ?"
+
+	todo, err := scanTodo(db.conn.QueryRowContext(ctx, query, id, workspace))
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("todo %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	db.cacheSet(ctx, key, todo, db.todoTTL)
+	return todo, nil
+}
+
 // UpdateTodoContext 更新待办事项(支持 Context)
 func (db *DB) UpdateTodoContext(ctx context.Context, todo *model.Todo) error {
 	query := `
 		UPDATE todos
 		SET title = ?, description = ?, status = ?,
-		    due_date = ?, updated_at = ?, completed_at = ?, version = version + 1
-		WHERE id = ? AND version = ?
+		    due_date = ?, updated_at = ?, completed_at = ?,
+		    latitude = ?, longitude = ?, radius = ?, estimated_minutes = ?, priority = ?,
+		    recurrence = ?, recurrence_start = ?, project_id = ?,
+		    secret_note = ?, secret_note_key_hint = ?, version = version + 1
+		WHERE id = ? AND version = ? AND workspace_id = ?
 	`
 
-	todo.UpdatedAt = time.Now()
+	todo.UpdatedAt = db.clock.Now()
 
 	result, err := db.conn.ExecContext(
 		ctx,
@@ -632,8 +691,19 @@ func (db *DB) UpdateTodoContext(ctx context.Context, todo *model.Todo) error {
 		todo.DueDate,
 		todo.UpdatedAt,
 		todo.CompletedAt,
+		todo.Latitude,
+		todo.Longitude,
+		todo.Radius,
+		todo.EstimatedMinutes,
+		todo.Priority,
+		nullString(todo.Recurrence),
+		todo.RecurrenceStart,
+		todo.ProjectID,
+		noteCiphertext(todo.SecretNote),
+		noteKeyHint(todo.SecretNote),
 		todo.ID,
 		todo.Version,
+		WorkspaceFromContext(ctx),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update todo: %w", err)
@@ -645,19 +715,41 @@ func (db *DB) UpdateTodoContext(ctx context.Context, todo *model.Todo) error {
 	}
 
 	if rows == 0 {
-		return ErrVersionConflict
+		return db.updateMissError(ctx, todo.ID, WorkspaceFromContext(ctx))
 	}
 
 	todo.Version++
+	db.invalidateTodos(ctx, todo.ID)
 
 	return nil
 }
 
+// updateMissError 更新没有匹配到行时区分原因：记录不存在返回 ErrNotFound，否则是版本冲突
+// workspace 为空时不按工作区过滤（旧版无 Context 接口）
+func (db *DB) updateMissError(ctx context.Context, id int, workspace string) error {
+	query := "SELECT 1 FROM todos WHERE id = ?"
+	args := []interface{}{id}
+	if workspace != "" {
+		query += " AND workspace_id = ?"
+		args = append(args, workspace)
+	}
+
+	var exists int
+	err := db.conn.QueryRowContext(ctx, query, args...).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check todo: %w", err)
+	}
+	return ErrVersionConflict
+}
+
 // DeleteTodoContext 删除待办事项(支持 Context)
 func (db *DB) DeleteTodoContext(ctx context.Context, id int) error {
-	query := `DELETE FROM todos WHERE id = ?`
+	query := `DELETE FROM todos WHERE id = ? AND workspace_id = ?`
 
-	result, err := db.conn.ExecContext(ctx, query, id)
+	result, err := db.conn.ExecContext(ctx, query, id, WorkspaceFromContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
@@ -668,52 +760,118 @@ func (db *DB) DeleteTodoContext(ctx context.Context, id int) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("todo not found")
+		return fmt.Errorf("todo %d: %w", id, ErrNotFound)
 	}
 
+	db.invalidateTodos(ctx, id)
 	return nil
 }
 
 // GetStatsContext 获取统计信息(支持 Context)
+// 先查缓存；未命中时同一工作区的并发请求只查询一次数据库，返回的是各自的副本
 func (db *DB) GetStatsContext(ctx context.Context) (*TodoStats, error) {
-	now := time.Now().UTC()
+	key := statsCacheKey(WorkspaceFromContext(ctx))
+	var cached TodoStats
+	if db.cacheGet(ctx, key, &cached) {
+		return &cached, nil
+	}
+
+	v, err := db.coalesce(ctx, key, func(ctx context.Context) (interface{}, error) {
+		// 不带过滤条件时直接读计数表，不需要扫描全部待办事项
+		stats, err := db.counterStatsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		db.cacheSet(ctx, key, stats, db.statsTTL)
+		return stats, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ByStatus 会被调用方补齐，复制一份避免并发修改同一个 map
+	stats := *v.(*TodoStats)
+	stats.ByStatus = maps.Clone(stats.ByStatus)
+	return &stats, nil
+}
+
+// GetFilteredStatsContext 只统计符合列表过滤条件（关键字、位置、优先级、项目、视图）的待办事项，Status 不参与过滤
+// 没有过滤条件时与 GetStatsContext 相同；有过滤条件时组合太多，结果不缓存
+func (db *DB) GetFilteredStatsContext(ctx context.Context, filter TodoFilter) (*TodoStats, error) {
+	// 排序、分页和 Status 不影响统计范围，清掉之后没有任何条件才等同于全部统计，可以用计数表和缓存
+	filter.Status, filter.Sort, filter.Order = "", "", ""
+	filter.Limit, filter.Offset, filter.SkipTotal, filter.IncludeArchived = 0, 0, false, false
+	if filter == (TodoFilter{}) {
+		return db.GetStatsContext(ctx)
+	}
+	q := scopedTodoQuery(ctx).filter(filter, db.statuses)
+	// 与 ListTodosContext 一致：不按项目过滤时不统计已归档项目中的事项
+	if filter.ProjectID == nil {
+		q.where("hidden = 0")
+	}
+	return db.queryStatsContext(ctx, q)
+}
+
+// queryStatsContext 按过滤条件 q 现场计算统计信息，不带过滤条件时改用 counterStatsContext 读计数表
+// 总数、未完成、已完成都由各状态的数量得出，其余几项只需要查询还没完成的事项
+func (db *DB) queryStatsContext(ctx context.Context, q *todoQuery) (*TodoStats, error) {
+	now := db.clock.Now().UTC()
 	today := now.Format("2006-01-02")
 	weekLater := now.AddDate(0, 0, 7).Format("2006-01-02")
 
+	var stats TodoStats
+	byStatus, err := db.countByStatusContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	stats.ByStatus = byStatus
+	for _, n := range byStatus {
+		stats.Total += n
+	}
+	stats.Pending = db.statuses.CountOpen(byStatus)
+	stats.Completed = db.statuses.CountDone(byStatus)
+
+	pending := db.statuses.OpenSQL()
+	open := q.clone().where("completed_at IS NULL")
 	query := `
 		SELECT
-			COUNT(*) as total,
-			SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END) as pending,
-			SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) as completed,
-			SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND due_date < ? THEN 1 ELSE 0 END) as overdue,
-			SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND date(due_date) = ? THEN 1 ELSE 0 END) as today,
-			SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND date(due_date) BETWEEN ? AND ? THEN 1 ELSE 0 END) as this_week
+			SUM(CASE WHEN ` + pending + ` AND due_date IS NOT NULL AND due_date < ? THEN 1 ELSE 0 END) as overdue,
+			SUM(CASE WHEN ` + pending + ` AND due_date IS NOT NULL AND date(due_date) = ? THEN 1 ELSE 0 END) as today,
+			SUM(CASE WHEN ` + pending + ` AND due_date IS NOT NULL AND date(due_date) BETWEEN ? AND ? THEN 1 ELSE 0 END) as this_week,
+			SUM(CASE WHEN ` + inboxCondition + ` THEN 1 ELSE 0 END) as inbox
 		FROM todos
-	`
+	` + open.whereSQL()
+	args := append([]interface{}{now, today, today, weekLater}, open.queryArgs()...)
 
-	var stats TodoStats
-	var pending, completed, overdue, todayCount, thisWeek sql.NullInt64
+	var overdue, todayCount, thisWeek, inbox sql.NullInt64
 
-	err := db.conn.QueryRowContext(ctx, query, now, today, today, weekLater).Scan(
-		&stats.Total,
-		&pending,
-		&completed,
+	err = db.conn.QueryRowContext(ctx, query, args...).Scan(
 		&overdue,
 		&todayCount,
 		&thisWeek,
+		&inbox,
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("查询统计信息失败：%w", err)
 	}
 
-	// 处理 NULL 值
-	if pending.Valid {
-		stats.Pending = int(pending.Int64)
+	stats.OverdueBuckets, err = db.getOverdueBucketsContext(ctx, q, now)
+	if err != nil {
+		return nil, err
 	}
-	if completed.Valid {
-		stats.Completed = int(completed.Int64)
+
+	stats.CompletionLatency, err = db.queryCompletionLatencyContext(ctx, q)
+	if err != nil {
+		return nil, err
 	}
+
+	stats.Focus, err = db.getFocusScoreContext(ctx, q, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// 处理 NULL 值
 	if overdue.Valid {
 		stats.Overdue = int(overdue.Int64)
 	}
@@ -723,6 +881,9 @@ func (db *DB) GetStatsContext(ctx context.Context) (*TodoStats, error) {
 	if thisWeek.Valid {
 		stats.ThisWeek = int(thisWeek.Int64)
 	}
+	if inbox.Valid {
+		stats.Inbox = int(inbox.Int64)
+	}
 
 	return &stats, nil
 }
@@ -735,11 +896,13 @@ func (db *DB) BatchCompleteTodosContext(ctx context.Context, ids []int) (err err
 		return nil
 	}
 
-	if len(ids) > 100 {
-		return fmt.Errorf("批量操作最多支持100个项目，当前：%d", len(ids))
+	if len(ids) > db.batchLimit {
+		return fmt.Errorf("批量操作最多支持%d个项目，当前：%d", db.batchLimit, len(ids))
 	}
 
 	// （使用 BeginTx 支持 Context）
+	workspace := WorkspaceFromContext(ctx)
+
 	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("开启事务失败：%w", err)
@@ -759,7 +922,7 @@ func (db *DB) BatchCompleteTodosContext(ctx context.Context, ids []int) (err err
 	// 预先声明变量，避免在循环中使用 := 导致变量遮蔽
 	var result sql.Result
 	var rows int64
-	now := time.Now().UTC()
+	now := db.clock.Now().UTC()
 
 	// 批量更新
 	for _, id := range ids {
@@ -778,8 +941,8 @@ func (db *DB) BatchCompleteTodosContext(ctx context.Context, ids []int) (err err
             SET status = 'completed',
                 completed_at = ?,
                 updated_at = ?
-            WHERE id = ? AND status = 'pending'
-		`, now, now, id)
+            WHERE id = ? AND `+db.statuses.OpenSQL()+` AND workspace_id = ?
+		`, now, now, id, workspace)
 
 		if err != nil {
 			return fmt.Errorf("更新 ID %d 失败：%w", id, err)
@@ -802,6 +965,7 @@ func (db *DB) BatchCompleteTodosContext(ctx context.Context, ids []int) (err err
 		return fmt.Errorf("提交事务失败：%w", err)
 	}
 
+	db.invalidateTodos(ctx, ids...)
 	return nil
 }
 
@@ -813,11 +977,13 @@ func (db *DB) BatchDeleteTodosContext(ctx context.Context, ids []int) (err error
 		return nil
 	}
 
-	if len(ids) > 100 {
-		return fmt.Errorf("批量操作最多支持100个项目，当前：%d", len(ids))
+	if len(ids) > db.batchLimit {
+		return fmt.Errorf("批量操作最多支持%d个项目，当前：%d", db.batchLimit, len(ids))
 	}
 
 	// 开启事务（使用 BeginTx 支持 Context）
+	workspace := WorkspaceFromContext(ctx)
+
 	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("开启事务失败：%w", err)
@@ -846,7 +1012,7 @@ func (db *DB) BatchDeleteTodosContext(ctx context.Context, ids []int) (err error
 		default:
 		}
 
-		result, err = tx.ExecContext(ctx, "DELETE FROM todos WHERE id = ?", id)
+		result, err = tx.ExecContext(ctx, "DELETE FROM todos WHERE id = ? AND workspace_id = ?", id, workspace)
 
 		if err != nil {
 			return fmt.Errorf("删除 ID %d 失败：%w", id, err)
@@ -869,21 +1035,15 @@ func (db *DB) BatchDeleteTodosContext(ctx context.Context, ids []int) (err error
 		return fmt.Errorf("提交事务失败：%w", err)
 	}
 
+	db.invalidateTodos(ctx, ids...)
 	return nil
 }
 
-// BatchError 批量操作中的单个错误
-type BatchError struct {
-	ID    int    `json:"id"`
-	Error string `json:"error"`
-}
-
-// BatchResult 批量操作结果
-type BatchResult struct {
-	SuccessCount int          `json:"success_count"`
-	FailedCount  int          `json:"failed_count"`
-	Errors       []BatchError `json:"errors,omitempty"`
-}
+// BatchError、BatchResult 批量操作结果，定义见 storage
+type (
+	BatchError  = storage.BatchError
+	BatchResult = storage.BatchResult
+)
 
 // BatchCompleteTodosPartialContext 批量完成待办事项（部分成功策略）
 // 与教学-5的 BatchCompleteTodosContext（全有或全无）不同，
@@ -894,11 +1054,13 @@ func (db *DB) BatchCompleteTodosPartialContext(ctx context.Context, ids []int) (
 	}
 
 	// 限制批量大小
-	if len(ids) > 100 {
-		return nil, fmt.Errorf("批量操作最多支持 100 个 ID，当前：%d", len(ids))
+	if len(ids) > db.batchLimit {
+		return nil, fmt.Errorf("批量操作最多支持 %d 个 ID，当前：%d", db.batchLimit, len(ids))
 	}
 
 	// 使用 BeginTx 支持 Context
+	workspace := WorkspaceFromContext(ctx)
+
 	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -931,15 +1093,15 @@ func (db *DB) BatchCompleteTodosPartialContext(ctx context.Context, ids []int) (
 		}
 
 		// 在 Go 层生成时间戳（统一使用 UTC）
-		now := time.Now().UTC()
+		now := db.clock.Now().UTC()
 
 		res, err = tx.ExecContext(ctx, `
 			UPDATE todos
 			SET status = 'completed',
 			    completed_at = ?,
 			    updated_at = ?
-			WHERE id = ? AND status = 'pending'
-		`, now, now, id)
+			WHERE id = ? AND `+db.statuses.OpenSQL()+` AND workspace_id = ?
+		`, now, now, id, workspace)
 
 		if err != nil {
 			result.FailedCount++
@@ -978,6 +1140,7 @@ func (db *DB) BatchCompleteTodosPartialContext(ctx context.Context, ids []int) (
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	db.invalidateTodos(ctx, ids...)
 	return result, nil
 }
 
@@ -989,11 +1152,13 @@ func (db *DB) BatchDeleteTodosPartialContext(ctx context.Context, ids []int) (re
 	}
 
 	// 限制批量大小
-	if len(ids) > 100 {
-		return nil, fmt.Errorf("批量操作最多支持 100 个 ID，当前: %d", len(ids))
+	if len(ids) > db.batchLimit {
+		return nil, fmt.Errorf("批量操作最多支持 %d 个 ID，当前: %d", db.batchLimit, len(ids))
 	}
 
 	// 使用 BeginTx 支持 Context
+	workspace := WorkspaceFromContext(ctx)
+
 	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -1025,7 +1190,7 @@ func (db *DB) BatchDeleteTodosPartialContext(ctx context.Context, ids []int) (re
 		default:
 		}
 
-		res, err = tx.ExecContext(ctx, `DELETE FROM todos WHERE id = ?`, id)
+		res, err = tx.ExecContext(ctx, `DELETE FROM todos WHERE id = ? AND workspace_id = ?`, id, workspace)
 
 		if err != nil {
 			result.FailedCount++
@@ -1064,6 +1229,7 @@ func (db *DB) BatchDeleteTodosPartialContext(ctx context.Context, ids []int) (re
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	db.invalidateTodos(ctx, ids...)
 	return result, nil
 }
 
@@ -1074,11 +1240,13 @@ func (db *DB) ImportTodosContext(ctx context.Context, todos []model.Todo) (impor
 		return 0, nil
 	}
 
-	if len(todos) > 1000 {
-		return 0, fmt.Errorf("单次导入最多 1000 条，当前：%d", len(todos))
+	if len(todos) > MaxImportSize {
+		return 0, fmt.Errorf("单次导入最多 %d 条，当前：%d", MaxImportSize, len(todos))
 	}
 
 	// 使用 BeginTx 支持 Context
+	workspace := WorkspaceFromContext(ctx)
+
 	tx, err := db.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("开启事务失败：%w", err)
@@ -1095,15 +1263,17 @@ func (db *DB) ImportTodosContext(ctx context.Context, todos []model.Todo) (impor
 	// 预先声明 stmt，避免使用 := 带来的潜在混淆
 	var stmt *sql.Stmt
 	stmt, err = tx.PrepareContext(ctx, `
-        INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version)
-        VALUES (?, ?, ?, ?, ?, ?, 1)
+        INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+                           latitude, longitude, radius, estimated_minutes, priority, public_id, workspace_id,
+                           recurrence, recurrence_start, completed_at, project_id, secret_note, secret_note_key_hint)
+        VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return 0, fmt.Errorf("准备语句失败：%w", err)
 	}
 	defer stmt.Close()
 
-	now := time.Now().UTC()
+	now := db.clock.Now().UTC()
 	// imported 已在命名返回值中声明，默认值为 0
 
 	for _, todo := range todos {
@@ -1126,6 +1296,9 @@ func (db *DB) ImportTodosContext(ctx context.Context, todos []model.Todo) (impor
 			todo.CreatedAt = now
 		}
 		todo.UpdatedAt = now
+		if err = claimPublicID(ctx, tx, &todo); err != nil {
+			return imported, err
+		}
 
 		_, err = stmt.ExecContext(ctx,
 			todo.Title,
@@ -1134,6 +1307,19 @@ func (db *DB) ImportTodosContext(ctx context.Context, todos []model.Todo) (impor
 			todo.DueDate,
 			todo.CreatedAt,
 			todo.UpdatedAt,
+			todo.Latitude,
+			todo.Longitude,
+			todo.Radius,
+			todo.EstimatedMinutes,
+			todo.Priority,
+			todo.PublicID,
+			workspace,
+			nullString(todo.Recurrence),
+			todo.RecurrenceStart,
+			todo.CompletedAt,
+			todo.ProjectID,
+			noteCiphertext(todo.SecretNote),
+			noteKeyHint(todo.SecretNote),
 		)
 		if err != nil {
 			return imported, fmt.Errorf("插入第 %d 条失败：%w", imported+1, err)
@@ -1145,19 +1331,15 @@ func (db *DB) ImportTodosContext(ctx context.Context, todos []model.Todo) (impor
 		return 0, fmt.Errorf("提交事务失败：%w", err)
 	}
 
+	db.invalidateTodos(ctx)
 	return imported, nil
 }
 
 // ExportTodosContext 导出所有待办事项(用于导出功能，支持 Context)
 func (db *DB) ExportTodosContext(ctx context.Context) ([]model.Todo, error) {
-	query := `
-        SELECT id, version, title, description, status, due_date,
-               created_at, updated_at, completed_at
-        FROM todos
-        ORDER BY created_at DESC
-    `
+	query, args := scopedTodoQuery(ctx).selectSQL(todoColumns, "ORDER BY created_at DESC")
 
-	rows, err := db.conn.QueryContext(ctx, query)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("查询失败：%w", err)
 	}
@@ -1172,36 +1354,12 @@ func (db *DB) ExportTodosContext(ctx context.Context) ([]model.Todo, error) {
 		default:
 		}
 
-		var todo model.Todo
-		var dueDate, completedAt sql.NullString
-
-		err := rows.Scan(
-			&todo.ID,
-			&todo.Version,
-			&todo.Title,
-			&todo.Description,
-			&todo.Status,
-			&dueDate,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-			&completedAt,
-		)
+		todo, err := scanTodo(rows)
 		if err != nil {
 			return nil, fmt.Errorf("扫描失败：%w", err)
 		}
 
-		if dueDate.Valid {
-			if t, parseErr := time.Parse(time.RFC3339, dueDate.String); parseErr == nil {
-				todo.DueDate = &t
-			}
-		}
-		if completedAt.Valid {
-			if t, parseErr := time.Parse(time.RFC3339, completedAt.String); parseErr == nil {
-				todo.CompletedAt = &t
-			}
-		}
-
-		todos = append(todos, todo)
+		todos = append(todos, *todo)
 	}
 
 	// 这里捕获的是"迭代过程中的网络/数据库"错误
@@ -0,0 +1,31 @@
+//go:build cgo && !sqlite_purego
+
+package database
+
+import (
+	"database/sql"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName 注册了自定义函数的 SQLite 驱动名
+// 默认使用 mattn/go-sqlite3（需要 CGO）；不开 CGO 或者加 sqlite_purego 构建标签时改用纯 Go 驱动，见 driver_purego.go
+const driverName = "sqlite3_todo"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			// 外键约束是按连接生效的，连接池里的每个连接都要打开
+			if _, err := conn.Exec("PRAGMA foreign_keys = ON", nil); err != nil {
+				return err
+			}
+			// SQLite 默认没有三角函数，注册 haversine_m(lat1, lng1, lat2, lng2) 供位置过滤使用
+			return conn.RegisterFunc("haversine_m", HaversineMeters, true)
+		},
+	})
+}
+
+// driverDSN 把数据库路径转换为驱动的连接串
+func driverDSN(path string) string {
+	return path
+}
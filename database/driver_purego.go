@@ -0,0 +1,52 @@
+//go:build !cgo || sqlite_purego
+
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// driverName 纯 Go 的 SQLite 驱动（modernc.org/sqlite），不需要 C 工具链，可以交叉编译静态二进制，例如：
+//
+//	CGO_ENABLED=0 GOOS=linux GOARCH=arm64 go build -o todo-server ./cmd/server
+//
+// 开着 CGO 时也可以用 -tags sqlite_purego 强制使用；两个驱动读写同一个数据库文件，可以随时切换
+const driverName = "sqlite"
+
+func init() {
+	// SQLite 默认没有三角函数，注册 haversine_m(lat1, lng1, lat2, lng2) 供位置过滤使用
+	err := sqlite.RegisterDeterministicScalarFunction("haversine_m", 4, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var v [4]float64
+		for i, arg := range args {
+			switch x := arg.(type) {
+			case nil:
+				return nil, nil
+			case int64:
+				v[i] = float64(x)
+			case float64:
+				v[i] = x
+			default:
+				return nil, fmt.Errorf("haversine_m: argument %d is not a number", i+1)
+			}
+		}
+		return HaversineMeters(v[0], v[1], v[2], v[3]), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// driverDSN 把数据库路径转换为驱动的连接串
+// 外键约束是按连接生效的，通过 _pragma 让连接池里的每个连接都打开；
+// 时间按 SQLite 格式写入（和 mattn/go-sqlite3 相同），否则 julianday() 无法解析
+func driverDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)&_time_format=sqlite"
+}
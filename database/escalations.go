@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// OverdueTodo 逾期待办事项（升级提醒使用）
+type OverdueTodo struct {
+	ID        int
+	ProjectID int // 0 表示不属于任何项目
+	Title     string
+	Priority  int
+	DueDate   time.Time
+}
+
+// initEscalationSchema 初始化升级提醒记录表
+func (db *DB) initEscalationSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_escalations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		step INTEGER NOT NULL,
+		channel TEXT NOT NULL,
+		notified_at DATETIME NOT NULL,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_escalations_todo_step ON todo_escalations(todo_id, step);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_escalations table: %w", err)
+	}
+	return nil
+}
+
+// ListOverdueTodosContext 查询已逾期且优先级不低于 minPriority 的未完成事项
+func (db *DB) ListOverdueTodosContext(ctx context.Context, now time.Time, minPriority int) ([]OverdueTodo, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, COALESCE(project_id, 0), title, priority, due_date
+		FROM todos
+		WHERE `+db.statuses.OpenSQL()+` AND due_date IS NOT NULL AND due_date < ? AND priority >= ?
+		ORDER BY due_date ASC
+	`, now.UTC(), minPriority)
+	if err != nil {
+		return nil, fmt.Errorf("查询逾期事项失败：%w", err)
+	}
+	defer rows.Close()
+
+	var todos []OverdueTodo
+	for rows.Next() {
+		var todo OverdueTodo
+		var dueDate string
+
+		if err := rows.Scan(&todo.ID, &todo.ProjectID, &todo.Title, &todo.Priority, &dueDate); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+
+		t, err := parseDBTime(dueDate)
+		if err != nil {
+			return nil, fmt.Errorf("解析 due_date 失败：%w", err)
+		}
+		todo.DueDate = t
+
+		todos = append(todos, todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return todos, nil
+}
+
+// LastEscalationContext 查询某事项在某一级最近一次提醒的时间，没有则返回 nil
+func (db *DB) LastEscalationContext(ctx context.Context, todoID, step int) (*time.Time, error) {
+	// 聚合函数的结果没有列类型，驱动会返回字符串而不是 time.Time
+	var last sql.NullString
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT MAX(notified_at) FROM todo_escalations WHERE todo_id = ? AND step = ?
+	`, todoID, step).Scan(&last)
+	if err != nil {
+		return nil, fmt.Errorf("查询升级记录失败：%w", err)
+	}
+
+	if !last.Valid {
+		return nil, nil
+	}
+
+	t, err := parseDBTime(last.String)
+	if err != nil {
+		return nil, fmt.Errorf("解析 notified_at 失败：%w", err)
+	}
+	return &t, nil
+}
+
+// RecordEscalationContext 记录一次升级提醒
+func (db *DB) RecordEscalationContext(ctx context.Context, todoID, step int, channel string, at time.Time) error {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO todo_escalations (todo_id, step, channel, notified_at) VALUES (?, ?, ?, ?)
+	`, todoID, step, channel, at.UTC())
+	if err != nil {
+		return fmt.Errorf("记录升级提醒失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+	"todo-list/storage"
+)
+
+// FocusScore 当天的专注度，定义见 storage
+type FocusScore = storage.FocusScore
+
+// focusWeight 优先级权重的 SQL 表达式
+const focusWeight = "MAX(priority + 1, 1)"
+
+// getFocusScoreContext 计算 q 范围内当天的专注度
+func (db *DB) getFocusScoreContext(ctx context.Context, q *todoQuery, now time.Time) (*FocusScore, error) {
+	today := now.UTC().Format("2006-01-02")
+	completedToday := "completed_at IS NOT NULL AND date(completed_at) = ?"
+	clearedToday := completedToday + " AND due_date IS NOT NULL AND julianday(due_date) < julianday(completed_at)"
+
+	query, args := q.selectSQL(`
+		SUM(CASE WHEN `+completedToday+` THEN 1 ELSE 0 END),
+		SUM(CASE WHEN `+clearedToday+` THEN 1 ELSE 0 END),
+		SUM(CASE WHEN `+completedToday+` THEN `+focusWeight+` ELSE 0 END),
+		SUM(CASE WHEN `+clearedToday+` THEN `+focusWeight+` ELSE 0 END),
+		SUM(CASE WHEN `+db.statuses.OpenSQL()+` AND due_date IS NOT NULL AND date(due_date) <= ? THEN `+focusWeight+` ELSE 0 END)`,
+		"")
+	args = append([]interface{}{today, today, today, today, today}, args...)
+
+	var completed, cleared, points, bonus, remaining sql.NullInt64
+	if err := db.conn.QueryRowContext(ctx, query, args...).Scan(&completed, &cleared, &points, &bonus, &remaining); err != nil {
+		return nil, fmt.Errorf("查询专注度失败：%w", err)
+	}
+
+	focus := &FocusScore{
+		Date:           today,
+		Completed:      int(completed.Int64),
+		OverdueCleared: int(cleared.Int64),
+		Points:         int(points.Int64 + bonus.Int64),
+		Remaining:      int(remaining.Int64),
+	}
+	if total := focus.Points + focus.Remaining; total > 0 {
+		focus.Score = int(math.Round(float64(focus.Points) * 100 / float64(total)))
+	}
+	return focus, nil
+}
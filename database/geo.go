@@ -0,0 +1,28 @@
+package database
+
+import "todo-list/storage"
+
+// DefaultRadiusMeters 默认提醒半径（米），见 storage
+const DefaultRadiusMeters = storage.DefaultRadiusMeters
+
+// GeoPoint 经纬度坐标，定义见 storage
+type GeoPoint = storage.GeoPoint
+
+// HaversineMeters 计算两个经纬度坐标之间的球面距离（米），见 storage
+func HaversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	return storage.HaversineMeters(lat1, lng1, lat2, lng2)
+}
+
+// nearClause 位置过滤条件：待办事项与当前位置的距离不超过半径
+// 半径优先级：请求指定 > 待办事项自身设置 > DefaultRadiusMeters
+const nearClause = "latitude IS NOT NULL AND longitude IS NOT NULL" +
+	" AND haversine_m(latitude, longitude, ?, ?) <= COALESCE(?, radius, ?)"
+
+// nearArgs 生成 nearClause 对应的参数
+func nearArgs(filter TodoFilter) []interface{} {
+	var radius interface{}
+	if filter.RadiusMeters > 0 {
+		radius = filter.RadiusMeters
+	}
+	return []interface{}{filter.Near.Lat, filter.Near.Lng, radius, DefaultRadiusMeters}
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"todo-list/model"
+)
+
+// initGoalsSchema 初始化目标表和目标-待办事项关联表
+func (db *DB) initGoalsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS goals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id TEXT NOT NULL DEFAULT 'default',
+		title TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		target_date TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_goals_workspace ON goals(workspace_id);
+
+	CREATE TABLE IF NOT EXISTS goal_todos (
+		goal_id INTEGER NOT NULL,
+		todo_id INTEGER NOT NULL,
+		PRIMARY KEY (goal_id, todo_id),
+		FOREIGN KEY (goal_id) REFERENCES goals(id) ON DELETE CASCADE,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_goal_todos_todo ON goal_todos(todo_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init goals table: %w", err)
+	}
+	return nil
+}
+
+// goalQuery 查询目标及其进度，completed_at 不为空表示待办事项处于终态
+const goalQuery = `
+	SELECT g.id, g.title, g.description, g.target_date, g.created_at, g.updated_at,
+	       COUNT(t.id), COUNT(t.completed_at)
+	FROM goals g
+	LEFT JOIN goal_todos gt ON gt.goal_id = g.id
+	LEFT JOIN todos t ON t.id = gt.todo_id
+	WHERE g.workspace_id = ?`
+
+// scanGoal 扫描一行目标（列顺序见 goalQuery），没有结果时原样返回 sql.ErrNoRows
+func scanGoal(s rowScanner) (*model.Goal, error) {
+	var g model.Goal
+	var total, completed int
+	if err := s.Scan(&g.ID, &g.Title, &g.Description, &g.TargetDate, &g.CreatedAt, &g.UpdatedAt, &total, &completed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描失败：%w", err)
+	}
+	g.Progress = model.NewGoalProgress(total, completed)
+	return &g, nil
+}
+
+// CreateGoalContext 保存目标
+func (db *DB) CreateGoalContext(ctx context.Context, goal *model.Goal) error {
+	now := db.clock.Now().UTC()
+	goal.CreatedAt, goal.UpdatedAt = now, now
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO goals (workspace_id, title, description, target_date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, WorkspaceFromContext(ctx), goal.Title, goal.Description, goal.TargetDate, goal.CreatedAt, goal.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("保存目标失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取目标 ID 失败：%w", err)
+	}
+	goal.ID = int(id)
+	return nil
+}
+
+// GetGoalContext 获取当前工作区的目标及进度，不存在时返回 ErrNotFound
+func (db *DB) GetGoalContext(ctx context.Context, id int) (*model.Goal, error) {
+	row := db.conn.QueryRowContext(ctx, goalQuery+` AND g.id = ? GROUP BY g.id`, WorkspaceFromContext(ctx), id)
+	goal, err := scanGoal(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotFound)
+	}
+	return goal, err
+}
+
+// ListGoalsContext 获取当前工作区的目标及进度，有目标日期的按日期排在前面
+func (db *DB) ListGoalsContext(ctx context.Context) ([]model.Goal, error) {
+	rows, err := db.conn.QueryContext(ctx, goalQuery+`
+		GROUP BY g.id
+		ORDER BY g.target_date = '', g.target_date ASC, g.id ASC
+	`, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询目标失败：%w", err)
+	}
+	defer rows.Close()
+
+	goals := make([]model.Goal, 0)
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *goal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return goals, nil
+}
+
+// UpdateGoalContext 修改目标的标题、描述和目标日期，目标不存在时返回 ErrNotFound
+func (db *DB) UpdateGoalContext(ctx context.Context, goal *model.Goal) error {
+	goal.UpdatedAt = db.clock.Now().UTC()
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE goals SET title = ?, description = ?, target_date = ?, updated_at = ?
+		WHERE id = ? AND workspace_id = ?
+	`, goal.Title, goal.Description, goal.TargetDate, goal.UpdatedAt, goal.ID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("更新目标失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("goal %d: %w", goal.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteGoalContext 删除目标和关联关系，关联的待办事项保留
+func (db *DB) DeleteGoalContext(ctx context.Context, id int) error {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM goals WHERE id = ? AND workspace_id = ?
+	`, id, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除目标失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("goal %d: %w", id, ErrNotFound)
+	}
+
+	// 关联关系影响统计中的收件箱数量
+	db.invalidateTodos(ctx)
+	return nil
+}
+
+// LinkGoalTodosContext 把待办事项关联到目标，已经关联的忽略
+// 任何一个待办事项不在当前工作区时整体失败并返回 ErrNotFound
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) LinkGoalTodosContext(ctx context.Context, goalID int, todoIDs []int) (err error) {
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	for _, todoID := range todoIDs {
+		var exists int
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE id = ? AND workspace_id = ?`, todoID, workspace).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("查询待办事项失败：%w", err)
+		}
+		if exists == 0 {
+			return fmt.Errorf("todo %d: %w", todoID, ErrNotFound)
+		}
+
+		if _, err = tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO goal_todos (goal_id, todo_id) VALUES (?, ?)
+		`, goalID, todoID); err != nil {
+			return fmt.Errorf("关联待办事项失败：%w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+
+	db.invalidateTodos(ctx)
+	return nil
+}
+
+// UnlinkGoalTodoContext 取消待办事项与目标的关联，没有关联时返回 ErrNotFound
+func (db *DB) UnlinkGoalTodoContext(ctx context.Context, goalID, todoID int) error {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM goal_todos
+		WHERE goal_id = ? AND todo_id = ?
+		  AND goal_id IN (SELECT id FROM goals WHERE workspace_id = ?)
+	`, goalID, todoID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("取消关联失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("goal %d todo %d: %w", goalID, todoID, ErrNotFound)
+	}
+
+	db.invalidateTodos(ctx)
+	return nil
+}
+
+// ListGoalTodosContext 获取关联到目标的待办事项，按 ID 排列
+func (db *DB) ListGoalTodosContext(ctx context.Context, goalID int) ([]model.Todo, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+todoColumns+` FROM todos
+		WHERE workspace_id = ? AND id IN (SELECT todo_id FROM goal_todos WHERE goal_id = ?)
+		ORDER BY id ASC
+	`, WorkspaceFromContext(ctx), goalID)
+	if err != nil {
+		return nil, fmt.Errorf("查询目标的待办事项失败：%w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]model.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return todos, nil
+}
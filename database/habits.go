@@ -0,0 +1,318 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/model"
+)
+
+// initHabitsSchema 初始化习惯表和习惯生成记录表
+// habit_instances 的主键保证同一个习惯在同一个周期只生成一次（多实例同时执行也不会重复）
+func (db *DB) initHabitsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS habits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id TEXT NOT NULL DEFAULT 'default',
+		title TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		frequency TEXT NOT NULL,
+		weekday INTEGER NOT NULL DEFAULT 1,
+		active INTEGER NOT NULL DEFAULT 1,
+		streak INTEGER NOT NULL DEFAULT 0,
+		best_streak INTEGER NOT NULL DEFAULT 0,
+		last_period TEXT NOT NULL DEFAULT '',
+		last_todo_id INTEGER,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_habits_workspace ON habits(workspace_id);
+
+	CREATE TABLE IF NOT EXISTS habit_instances (
+		habit_id INTEGER NOT NULL,
+		period TEXT NOT NULL,
+		todo_id INTEGER,
+		completed_at DATETIME,
+		PRIMARY KEY (habit_id, period),
+		FOREIGN KEY (habit_id) REFERENCES habits(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_habit_instances_todo ON habit_instances(todo_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init habits table: %w", err)
+	}
+	return nil
+}
+
+// habitColumns 查询习惯时统一使用的列，顺序必须与 scanHabit 保持一致
+const habitColumns = `id, workspace_id, title, description, frequency, weekday, active,
+	streak, best_streak, last_period, last_todo_id, created_at`
+
+// scanHabit 扫描一行习惯，没有结果时原样返回 sql.ErrNoRows
+func scanHabit(s rowScanner) (*model.Habit, error) {
+	var h model.Habit
+	var lastTodoID sql.NullInt64
+	if err := s.Scan(&h.ID, &h.Workspace, &h.Title, &h.Description, &h.Frequency, &h.Weekday, &h.Active,
+		&h.Streak, &h.BestStreak, &h.LastPeriod, &lastTodoID, &h.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描失败：%w", err)
+	}
+	if lastTodoID.Valid {
+		id := int(lastTodoID.Int64)
+		h.LastTodoID = &id
+	}
+	return &h, nil
+}
+
+// CreateHabitContext 保存习惯
+func (db *DB) CreateHabitContext(ctx context.Context, habit *model.Habit) error {
+	habit.Workspace = WorkspaceFromContext(ctx)
+	habit.CreatedAt = db.clock.Now().UTC()
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO habits (workspace_id, title, description, frequency, weekday, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, habit.Workspace, habit.Title, habit.Description, habit.Frequency, habit.Weekday, habit.Active, habit.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存习惯失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取习惯 ID 失败：%w", err)
+	}
+	habit.ID = int(id)
+	return nil
+}
+
+// GetHabitContext 获取当前工作区的习惯，不存在时返回 ErrNotFound
+func (db *DB) GetHabitContext(ctx context.Context, id int) (*model.Habit, error) {
+	row := db.conn.QueryRowContext(ctx, `SELECT `+habitColumns+` FROM habits WHERE id = ? AND workspace_id = ?`,
+		id, WorkspaceFromContext(ctx))
+	habit, err := scanHabit(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotFound)
+	}
+	return habit, err
+}
+
+// ListHabitsContext 获取当前工作区的习惯，按创建顺序排列
+func (db *DB) ListHabitsContext(ctx context.Context) ([]model.Habit, error) {
+	return db.queryHabits(ctx, `SELECT `+habitColumns+` FROM habits WHERE workspace_id = ? ORDER BY id ASC`,
+		WorkspaceFromContext(ctx))
+}
+
+// ListAllActiveHabitsContext 获取所有工作区中未暂停的习惯，供后台生成任务使用
+func (db *DB) ListAllActiveHabitsContext(ctx context.Context) ([]model.Habit, error) {
+	return db.queryHabits(ctx, `SELECT `+habitColumns+` FROM habits WHERE active = 1 ORDER BY id ASC`)
+}
+
+func (db *DB) queryHabits(ctx context.Context, query string, args ...interface{}) ([]model.Habit, error) {
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询习惯失败：%w", err)
+	}
+	defer rows.Close()
+
+	habits := make([]model.Habit, 0)
+	for rows.Next() {
+		habit, err := scanHabit(rows)
+		if err != nil {
+			return nil, err
+		}
+		habits = append(habits, *habit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return habits, nil
+}
+
+// UpdateHabitContext 修改习惯的模板、频率和暂停状态，连续记录不受影响
+func (db *DB) UpdateHabitContext(ctx context.Context, habit *model.Habit) error {
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE habits
+		SET title = ?, description = ?, frequency = ?, weekday = ?, active = ?
+		WHERE id = ? AND workspace_id = ?
+	`, habit.Title, habit.Description, habit.Frequency, habit.Weekday, habit.Active, habit.ID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("更新习惯失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("habit %d: %w", habit.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteHabitContext 删除习惯和生成记录，已经生成的待办事项保留
+func (db *DB) DeleteHabitContext(ctx context.Context, id int) (err error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM habits WHERE id = ? AND workspace_id = ?`, id, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除习惯失败：%w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("habit %d: %w", id, ErrNotFound)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM habit_instances WHERE habit_id = ?`, id); err != nil {
+		return fmt.Errorf("删除习惯生成记录失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	return nil
+}
+
+// CreateHabitTodoContext 为习惯的某个周期生成待办事项，该周期已经生成过时返回 false
+// 上一个周期生成的待办事项没有完成时，连续记录清零
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) CreateHabitTodoContext(ctx context.Context, habit *model.Habit, period string, todo *model.Todo) (created bool, err error) {
+	ctx = WithWorkspace(ctx, habit.Workspace)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil || !created {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT OR IGNORE INTO habit_instances (habit_id, period) VALUES (?, ?)`, habit.ID, period)
+	if err != nil {
+		return false, fmt.Errorf("记录习惯周期失败：%w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	ensurePublicID(todo)
+	result, err = tx.ExecContext(ctx, `
+		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version, public_id, workspace_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, todo.Title, todo.Description, todo.Status, todo.DueDate, todo.CreatedAt, todo.UpdatedAt, todo.Version,
+		todo.PublicID, habit.Workspace)
+	if err != nil {
+		return false, fmt.Errorf("failed to create todo: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	todo.ID = int(id)
+
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE habit_instances SET todo_id = ? WHERE habit_id = ? AND period = ?`, todo.ID, habit.ID, period); err != nil {
+		return false, fmt.Errorf("记录习惯周期失败：%w", err)
+	}
+
+	// 上一个周期没有完成，连续记录中断
+	var missed int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM habit_instances
+		WHERE habit_id = ? AND period = (
+			SELECT MAX(period) FROM habit_instances WHERE habit_id = ? AND period < ?
+		) AND completed_at IS NULL
+	`, habit.ID, habit.ID, period).Scan(&missed)
+	if err != nil {
+		return false, fmt.Errorf("查询上一周期失败：%w", err)
+	}
+
+	streakReset := ""
+	if missed > 0 {
+		streakReset = ", streak = 0"
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE habits SET last_period = ?, last_todo_id = ?`+streakReset+` WHERE id = ?
+	`, period, todo.ID, habit.ID); err != nil {
+		return false, fmt.Errorf("更新习惯失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交事务失败：%w", err)
+	}
+	created = true
+
+	db.invalidateTodos(ctx)
+	return true, nil
+}
+
+// CompleteHabitTodoContext 习惯生成的待办事项完成后累加连续记录
+// 待办事项不是习惯生成的，或者已经计过一次（重新打开后再次完成）时不做任何修改
+func (db *DB) CompleteHabitTodoContext(ctx context.Context, todoID int, at time.Time) (err error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE habit_instances SET completed_at = ?
+		WHERE todo_id = ? AND completed_at IS NULL
+	`, at.UTC(), todoID)
+	if err != nil {
+		return fmt.Errorf("记录习惯完成失败：%w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows > 0 {
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE habits
+			SET streak = streak + 1, best_streak = MAX(best_streak, streak + 1)
+			WHERE id = (SELECT habit_id FROM habit_instances WHERE todo_id = ?)
+		`, todoID); err != nil {
+			return fmt.Errorf("更新连续记录失败：%w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	return nil
+}
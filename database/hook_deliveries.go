@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// initHookDeliveriesSchema 初始化入站 webhook 投递记录表：重放保护记录已处理的投递，
+// 重启之后和共用数据库的多个实例之间仍然有效
+func (db *DB) initHookDeliveriesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS hook_deliveries (
+		key TEXT PRIMARY KEY,
+		expires_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_hook_deliveries_expires ON hook_deliveries(expires_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init hook_deliveries table: %w", err)
+	}
+	return nil
+}
+
+// MarkHookDeliveryContext 记录一次投递到 expiresAt 为止；同一个 key 已经记录过且未过期时返回 true
+// 顺便删除过期的记录，表的大小只与窗口内的投递量有关
+func (db *DB) MarkHookDeliveryContext(ctx context.Context, key string, expiresAt time.Time) (bool, error) {
+	now := db.clock.Now().UTC().Format("2006-01-02 15:04:05")
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM hook_deliveries WHERE julianday(expires_at) < julianday(?)`, now); err != nil {
+		return false, fmt.Errorf("清理投递记录失败：%w", err)
+	}
+
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO hook_deliveries (key, expires_at) VALUES (?, ?)
+		ON CONFLICT(key) DO NOTHING
+	`, key, expiresAt.UTC())
+	if err != nil {
+		return false, fmt.Errorf("记录投递失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows == 0, nil
+}
+
+// ForgetHookDeliveryContext 删除一次投递的记录，处理失败后发送方的重试会被再次处理
+func (db *DB) ForgetHookDeliveryContext(ctx context.Context, key string) error {
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM hook_deliveries WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("删除投递记录失败：%w", err)
+	}
+	return nil
+}
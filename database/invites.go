@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// InviteCandidate 可能需要发送（或取消）截止日期邀请的待办事项
+type InviteCandidate struct {
+	ID          int
+	PublicID    string
+	Title       string
+	Description string
+	Status      string
+	Priority    int
+	DueDate     *time.Time
+	Project     string
+	Invite      *model.CalendarInvite // 还没有发送过邀请时为 nil
+}
+
+// initInvitesSchema 初始化截止日期邀请表：每个待办事项一行，记录最近一次发送的内容
+func (db *DB) initInvitesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS calendar_invites (
+		todo_id INTEGER PRIMARY KEY,
+		due_date DATETIME NOT NULL,
+		sequence INTEGER NOT NULL DEFAULT 0,
+		canceled INTEGER NOT NULL DEFAULT 0,
+		sent_at DATETIME NOT NULL,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init calendar_invites table: %w", err)
+	}
+	return nil
+}
+
+// ListInviteCandidatesContext 查询优先级不低于 minPriority 且有截止日期的待办事项，
+// 以及邀请仍然有效（没有取消）的待办事项；是否需要发送由调用方根据状态和上次邀请判断
+func (db *DB) ListInviteCandidatesContext(ctx context.Context, minPriority int) ([]InviteCandidate, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT t.id, COALESCE(t.public_id, ''), t.title, COALESCE(t.description, ''), t.status, t.priority, t.due_date,
+			COALESCE(p.name, ''), i.due_date, i.sequence, i.canceled, i.sent_at
+		FROM todos t
+		LEFT JOIN projects p ON p.id = t.project_id
+		LEFT JOIN calendar_invites i ON i.todo_id = t.id
+		WHERE (t.priority >= ? AND t.due_date IS NOT NULL) OR (i.todo_id IS NOT NULL AND i.canceled = 0)
+		ORDER BY t.id ASC
+	`, minPriority)
+	if err != nil {
+		return nil, fmt.Errorf("查询截止日期邀请失败：%w", err)
+	}
+	defer rows.Close()
+
+	var candidates []InviteCandidate
+	for rows.Next() {
+		var c InviteCandidate
+		var dueDate, inviteDue, sentAt sql.NullString
+		var sequence sql.NullInt64
+		var canceled sql.NullBool
+		if err := rows.Scan(&c.ID, &c.PublicID, &c.Title, &c.Description, &c.Status, &c.Priority, &dueDate,
+			&c.Project, &inviteDue, &sequence, &canceled, &sentAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+
+		if dueDate.Valid {
+			t, err := parseDBTime(dueDate.String)
+			if err != nil {
+				return nil, fmt.Errorf("解析 due_date 失败：%w", err)
+			}
+			c.DueDate = &t
+		}
+		if inviteDue.Valid {
+			invite := &model.CalendarInvite{TodoID: c.ID, Sequence: int(sequence.Int64), Canceled: canceled.Bool}
+			if invite.DueDate, err = parseDBTime(inviteDue.String); err != nil {
+				return nil, fmt.Errorf("解析邀请 due_date 失败：%w", err)
+			}
+			if invite.SentAt, err = parseDBTime(sentAt.String); err != nil {
+				return nil, fmt.Errorf("解析 sent_at 失败：%w", err)
+			}
+			c.Invite = invite
+		}
+		candidates = append(candidates, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return candidates, nil
+}
+
+// RecordInviteContext 记录发送的邀请（每个待办事项只保留最近一次）
+func (db *DB) RecordInviteContext(ctx context.Context, invite *model.CalendarInvite) error {
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO calendar_invites (todo_id, due_date, sequence, canceled, sent_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(todo_id) DO UPDATE SET
+			due_date = excluded.due_date, sequence = excluded.sequence,
+			canceled = excluded.canceled, sent_at = excluded.sent_at
+	`, invite.TodoID, invite.DueDate.UTC(), invite.Sequence, invite.Canceled, invite.SentAt.UTC())
+	if err != nil {
+		return fmt.Errorf("记录截止日期邀请失败：%w", err)
+	}
+	return nil
+}
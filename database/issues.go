@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"todo-list/model"
+)
+
+// initIssuesSchema 初始化外部工单导入表：记录每个工单对应的待办事项和上次看到的外部状态
+func (db *DB) initIssuesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS issue_imports (
+		workspace_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		issue_key TEXT NOT NULL,
+		todo_id INTEGER,
+		status TEXT NOT NULL DEFAULT '',
+		done INTEGER NOT NULL DEFAULT 0,
+		synced_at DATETIME NOT NULL,
+		PRIMARY KEY (workspace_id, source, issue_key),
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE SET NULL
+	);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init issue_imports table: %w", err)
+	}
+	return nil
+}
+
+// ListIssueImportsContext 当前工作区从 source 导入过的工单；待办事项已删除的 TodoID 为 0
+func (db *DB) ListIssueImportsContext(ctx context.Context, source string) ([]model.IssueImport, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT source, issue_key, COALESCE(todo_id, 0), status, done, synced_at
+		FROM issue_imports WHERE workspace_id = ? AND source = ?
+		ORDER BY issue_key ASC
+	`, WorkspaceFromContext(ctx), source)
+	if err != nil {
+		return nil, fmt.Errorf("查询工单导入记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	var imports []model.IssueImport
+	for rows.Next() {
+		var imp model.IssueImport
+		if err := rows.Scan(&imp.Source, &imp.Key, &imp.TodoID, &imp.Status, &imp.Done, &imp.SyncedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		imports = append(imports, imp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return imports, nil
+}
+
+// CreateIssueTodoContext 为工单创建待办事项，并把工单地址作为链接（反向链接）挂在待办事项上
+// 同一个工单已经导入过时不创建，返回 false
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) CreateIssueTodoContext(ctx context.Context, imp *model.IssueImport, todo *model.Todo, link *model.TodoLink) (created bool, err error) {
+	workspace := WorkspaceFromContext(ctx)
+	now := db.clock.Now().UTC()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil || !created {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO issue_imports (workspace_id, source, issue_key, status, done, synced_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, workspace, imp.Source, imp.Key, imp.Status, imp.Done, now)
+	if err != nil {
+		return false, fmt.Errorf("记录工单导入失败：%w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	ensurePublicID(todo)
+	result, err = tx.ExecContext(ctx, `
+		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+		                   priority, public_id, workspace_id, project_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, todo.Title, todo.Description, todo.Status, todo.DueDate, todo.CreatedAt, todo.UpdatedAt, todo.Version,
+		todo.Priority, todo.PublicID, workspace, todo.ProjectID)
+	if err != nil {
+		return false, fmt.Errorf("failed to create todo: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	todo.ID = int(id)
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE issue_imports SET todo_id = ? WHERE workspace_id = ? AND source = ? AND issue_key = ?
+	`, todo.ID, workspace, imp.Source, imp.Key); err != nil {
+		return false, fmt.Errorf("记录工单导入失败：%w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO todo_links (todo_id, url, title, status, created_at) VALUES (?, ?, ?, ?, ?)
+	`, todo.ID, link.URL, link.Title, link.Status, now); err != nil {
+		return false, fmt.Errorf("添加工单链接失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交事务失败：%w", err)
+	}
+	imp.TodoID, imp.SyncedAt = todo.ID, now
+	db.invalidateTodos(ctx)
+	return true, nil
+}
+
+// SyncIssueStatusContext 记录工单新的外部状态，并按是否完成更新对应的待办事项：
+// 外部完成时把未完成的待办事项标记为 completed，外部重新打开时把 completed 改回 pending；
+// 自定义工作流中的其他状态不改动。返回待办事项是否被修改
+func (db *DB) SyncIssueStatusContext(ctx context.Context, imp *model.IssueImport) (changed bool, err error) {
+	workspace := WorkspaceFromContext(ctx)
+	now := db.clock.Now().UTC()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE issue_imports SET status = ?, done = ?, synced_at = ?
+		WHERE workspace_id = ? AND source = ? AND issue_key = ?
+	`, imp.Status, imp.Done, now, workspace, imp.Source, imp.Key); err != nil {
+		return false, fmt.Errorf("更新工单状态失败：%w", err)
+	}
+
+	if imp.TodoID > 0 {
+		query := `UPDATE todos SET status = 'completed', completed_at = ?, updated_at = ?, version = version + 1
+			WHERE id = ? AND workspace_id = ? AND ` + db.statuses.OpenSQL()
+		args := []interface{}{now, now, imp.TodoID, workspace}
+		if !imp.Done {
+			query = `UPDATE todos SET status = 'pending', completed_at = NULL, updated_at = ?, version = version + 1
+				WHERE id = ? AND workspace_id = ? AND ` + db.statuses.DoneSQL()
+			args = []interface{}{now, imp.TodoID, workspace}
+		}
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return false, fmt.Errorf("更新待办事项状态失败：%w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		changed = rows > 0
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交事务失败：%w", err)
+	}
+	imp.SyncedAt = now
+	if changed {
+		db.invalidateTodos(ctx, imp.TodoID)
+	}
+	return changed, nil
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// jobColumns 查询后台任务时统一使用的列，顺序必须与 scanJob 保持一致
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, run_at, last_error, created_at, updated_at,
+	workspace_id, progress_done, progress_total, result`
+
+// initJobsSchema 初始化后台任务队列表
+func (db *DB) initJobsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL DEFAULT '{}',
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		run_at DATETIME NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		workspace_id TEXT NOT NULL DEFAULT '',
+		progress_done INTEGER NOT NULL DEFAULT 0,
+		progress_total INTEGER NOT NULL DEFAULT 0,
+		result TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs(status, run_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init jobs table: %w", err)
+	}
+
+	// 旧版本建立的 jobs 表没有进度和结果
+	for _, col := range []struct{ name, definition string }{
+		{"workspace_id", "TEXT NOT NULL DEFAULT ''"},
+		{"progress_done", "INTEGER NOT NULL DEFAULT 0"},
+		{"progress_total", "INTEGER NOT NULL DEFAULT 0"},
+		{"result", "TEXT"},
+	} {
+		if err := db.ensureTableColumn("jobs", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanJob 扫描一行后台任务（列顺序见 jobColumns）
+func scanJob(s rowScanner) (*model.Job, error) {
+	var job model.Job
+	var payload string
+	var result sql.NullString
+	err := s.Scan(&job.ID, &job.Kind, &payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.RunAt, &job.LastError, &job.CreatedAt, &job.UpdatedAt,
+		&job.Workspace, &job.ProgressDone, &job.ProgressTotal, &result)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = []byte(payload)
+	if result.Valid {
+		job.Result = []byte(result.String)
+	}
+	return &job, nil
+}
+
+// EnqueueJobContext 新增一个待执行的任务
+func (db *DB) EnqueueJobContext(ctx context.Context, job *model.Job) error {
+	now := db.clock.Now().UTC()
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	job.Status = model.JobPending
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO jobs (kind, payload, status, attempts, max_attempts, run_at, created_at, updated_at, workspace_id)
+		VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?)
+	`, job.Kind, string(job.Payload), job.Status, job.MaxAttempts, job.RunAt.UTC(), job.CreatedAt, job.UpdatedAt,
+		job.Workspace)
+	if err != nil {
+		return fmt.Errorf("任务入队失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取任务 ID 失败：%w", err)
+	}
+	job.ID = int(id)
+	return nil
+}
+
+// ClaimJobsContext 领取最多 limit 个到期的任务并标记为 running
+// 单条 UPDATE ... RETURNING 保证同一个任务不会被领取两次
+func (db *DB) ClaimJobsContext(ctx context.Context, now time.Time, limit int) ([]model.Job, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		UPDATE jobs
+		SET status = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE status = ? AND julianday(run_at) <= julianday(?)
+			ORDER BY run_at ASC
+			LIMIT ?
+		)
+		RETURNING `+jobColumns,
+		model.JobRunning, now.UTC(), model.JobPending, now.UTC().Format("2006-01-02 15:04:05.000"), limit)
+	if err != nil {
+		return nil, fmt.Errorf("领取任务失败：%w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]model.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return jobs, nil
+}
+
+// CompleteJobContext 任务执行成功，result 为空表示任务没有结果
+func (db *DB) CompleteJobContext(ctx context.Context, id int, result json.RawMessage) error {
+	var value interface{}
+	if result != nil {
+		value = string(result)
+	}
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, last_error = '', result = ?, updated_at = ? WHERE id = ?
+	`, model.JobDone, value, db.clock.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("更新任务状态失败：%w", err)
+	}
+	return nil
+}
+
+// FailJobContext 任务执行失败：retryAt 为空时进入死信，否则等待重试
+func (db *DB) FailJobContext(ctx context.Context, id int, lastError string, retryAt *time.Time) error {
+	status := model.JobDead
+	var runAt interface{}
+	if retryAt != nil {
+		status = model.JobPending
+		runAt = retryAt.UTC()
+	}
+
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = ?, last_error = ?, run_at = COALESCE(?, run_at), updated_at = ?
+		WHERE id = ?
+	`, status, lastError, runAt, db.clock.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("更新任务状态失败：%w", err)
+	}
+	return nil
+}
+
+// UpdateJobProgressContext 记录任务进度
+func (db *DB) UpdateJobProgressContext(ctx context.Context, id, done, total int) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE jobs SET progress_done = ?, progress_total = ?, updated_at = ? WHERE id = ?
+	`, done, total, db.clock.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("更新任务进度失败：%w", err)
+	}
+	return nil
+}
+
+// GetWorkspaceJobContext 查询当前工作区通过接口提交的任务，不存在时返回 ErrNotFound
+// 内部任务（workspace_id 为空）不会被查到
+func (db *DB) GetWorkspaceJobContext(ctx context.Context, id int) (*model.Job, error) {
+	row := db.conn.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ? AND workspace_id = ?`,
+		id, WorkspaceFromContext(ctx))
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("job %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败：%w", err)
+	}
+	return job, nil
+}
+
+// ResetRunningJobsContext 把 running 状态的任务放回队列（上次进程在执行中退出）
+func (db *DB) ResetRunningJobsContext(ctx context.Context) (int, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE jobs SET status = ?, updated_at = ? WHERE status = ?
+	`, model.JobPending, db.clock.Now().UTC(), model.JobRunning)
+	if err != nil {
+		return 0, fmt.Errorf("重置任务失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ListJobsContext 按状态查询任务（status 为空表示全部），最新的在前
+func (db *DB) ListJobsContext(ctx context.Context, status string, limit int) ([]model.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务失败：%w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]model.Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return jobs, nil
+}
+
+// RequeueJobContext 把死信任务重新放回队列并清零重试次数，任务不存在或不是死信时返回 nil, nil
+func (db *DB) RequeueJobContext(ctx context.Context, id int) (*model.Job, error) {
+	now := db.clock.Now().UTC()
+	row := db.conn.QueryRowContext(ctx, `
+		UPDATE jobs
+		SET status = ?, attempts = 0, run_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?
+		RETURNING `+jobColumns,
+		model.JobPending, now, now, id, model.JobDead)
+
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("重新入队失败：%w", err)
+	}
+	return job, nil
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// initLeasesSchema 初始化任务租约表：多个实例共用同一个数据库时，
+// 同一个定时任务只有持有租约的实例才会执行
+func (db *DB) initLeasesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS job_leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init job_leases table: %w", err)
+	}
+	return nil
+}
+
+// AcquireLeaseContext 尝试获取或续期租约：租约不存在、已过期或本来就属于 holder 时成功，
+// 否则返回 false（其他实例正在持有）
+func (db *DB) AcquireLeaseContext(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := db.clock.Now().UTC()
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO job_leases (name, holder, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET holder = excluded.holder, expires_at = excluded.expires_at
+		WHERE job_leases.holder = excluded.holder OR julianday(job_leases.expires_at) < julianday(?)
+	`, name, holder, now.Add(ttl), now.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return false, fmt.Errorf("获取租约失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ReleaseLeasesContext 释放 holder 持有的所有租约（实例退出时调用，其他实例可以立即接手）
+func (db *DB) ReleaseLeasesContext(ctx context.Context, holder string) error {
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM job_leases WHERE holder = ?`, holder); err != nil {
+		return fmt.Errorf("释放租约失败：%w", err)
+	}
+	return nil
+}
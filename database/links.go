@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"todo-list/model"
+)
+
+// initLinksSchema 初始化链接表
+func (db *DB) initLinksSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_links (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		favicon_url TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'pending',
+		created_at DATETIME NOT NULL,
+		fetched_at DATETIME,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_links_todo_id ON todo_links(todo_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_links table: %w", err)
+	}
+	return nil
+}
+
+// CreateLinkContext 为待办事项添加链接（元数据稍后异步填充）
+func (db *DB) CreateLinkContext(ctx context.Context, link *model.TodoLink) error {
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO todo_links (todo_id, url, status, created_at)
+		VALUES (?, ?, ?, ?)
+	`, link.TodoID, link.URL, link.Status, link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create link: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	link.ID = int(id)
+	return nil
+}
+
+// ListLinksContext 获取待办事项的所有链接
+func (db *DB) ListLinksContext(ctx context.Context, todoID int) ([]model.TodoLink, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, todo_id, url, title, favicon_url, status, created_at, fetched_at
+		FROM todo_links
+		WHERE todo_id = ? AND todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+		ORDER BY id ASC
+	`, todoID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询链接失败：%w", err)
+	}
+	defer rows.Close()
+
+	links := make([]model.TodoLink, 0)
+	for rows.Next() {
+		var link model.TodoLink
+		var fetchedAt sql.NullTime
+
+		if err := rows.Scan(
+			&link.ID,
+			&link.TodoID,
+			&link.URL,
+			&link.Title,
+			&link.FaviconURL,
+			&link.Status,
+			&link.CreatedAt,
+			&fetchedAt,
+		); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+
+		if fetchedAt.Valid {
+			link.FetchedAt = &fetchedAt.Time
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return links, nil
+}
+
+// UpdateLinkMetadataContext 写入抓取到的链接元数据
+func (db *DB) UpdateLinkMetadataContext(ctx context.Context, id int, title, faviconURL, status string) error {
+	_, err := db.conn.ExecContext(ctx, `
+		UPDATE todo_links
+		SET title = ?, favicon_url = ?, status = ?, fetched_at = ?
+		WHERE id = ?
+	`, title, faviconURL, status, db.clock.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update link metadata: %w", err)
+	}
+	return nil
+}
+
+// DeleteLinkContext 删除待办事项的某个链接
+func (db *DB) DeleteLinkContext(ctx context.Context, todoID, linkID int) error {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM todo_links
+		WHERE id = ? AND todo_id = ? AND todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+	`, linkID, todoID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("link %d: %w", linkID, ErrNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// BackupContext 用 VACUUM INTO 把整个数据库写到 path（目标文件必须不存在），备份期间不阻塞读写
+func (db *DB) BackupContext(ctx context.Context, path string) error {
+	if _, err := db.conn.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("备份数据库失败：%w", err)
+	}
+	return nil
+}
+
+// PurgeFinishedJobsContext 删除 before 之前已完成的后台任务，死信任务保留以便排查
+func (db *DB) PurgeFinishedJobsContext(ctx context.Context, before time.Time) (int, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM jobs WHERE status = ? AND julianday(updated_at) < julianday(?)
+	`, model.JobDone, before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("清理后台任务失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// PurgeReadNotificationsContext 删除 before 之前已读的通知，未读通知不受影响
+func (db *DB) PurgeReadNotificationsContext(ctx context.Context, before time.Time) (int, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM notifications WHERE read_at IS NOT NULL AND julianday(read_at) < julianday(?)
+	`, before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("清理通知失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
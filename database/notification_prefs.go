@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"todo-list/model"
+)
+
+// initNotificationSchema 初始化通知偏好表
+func (db *DB) initNotificationSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id TEXT PRIMARY KEY,
+		channels TEXT NOT NULL DEFAULT '{}',
+		quiet_hours_start TEXT NOT NULL DEFAULT '',
+		quiet_hours_end TEXT NOT NULL DEFAULT '',
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		digest_time TEXT NOT NULL DEFAULT '',
+		muted_projects TEXT NOT NULL DEFAULT '[]',
+		updated_at DATETIME NOT NULL
+	);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init notification_preferences table: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationPreferencesContext 获取用户通知偏好，没有记录时返回默认值
+func (db *DB) GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	var prefs model.NotificationPreferences
+	var channels, muted string
+
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT user_id, channels, quiet_hours_start, quiet_hours_end, timezone, digest_time, muted_projects, updated_at
+		FROM notification_preferences
+		WHERE user_id = ?
+	`, userID).Scan(
+		&prefs.UserID,
+		&channels,
+		&prefs.QuietHoursStart,
+		&prefs.QuietHoursEnd,
+		&prefs.Timezone,
+		&prefs.DigestTime,
+		&muted,
+		&prefs.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return model.DefaultNotificationPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败：%w", err)
+	}
+
+	if err := json.Unmarshal([]byte(channels), &prefs.Channels); err != nil {
+		return nil, fmt.Errorf("解析 channels 失败：%w", err)
+	}
+	if prefs.MutedProjects, err = parseMutedProjects(muted); err != nil {
+		return nil, err
+	}
+
+	return &prefs, nil
+}
+
+// SaveNotificationPreferencesContext 保存用户通知偏好（不存在则插入）
+func (db *DB) SaveNotificationPreferencesContext(ctx context.Context, prefs *model.NotificationPreferences) error {
+	channels, err := json.Marshal(prefs.Channels)
+	if err != nil {
+		return fmt.Errorf("序列化 channels 失败：%w", err)
+	}
+	if prefs.MutedProjects == nil {
+		prefs.MutedProjects = []int{}
+	}
+	muted, err := json.Marshal(prefs.MutedProjects)
+	if err != nil {
+		return fmt.Errorf("序列化 muted_projects 失败：%w", err)
+	}
+
+	prefs.UpdatedAt = db.clock.Now().UTC()
+
+	_, err = db.conn.ExecContext(ctx, `
+		INSERT INTO notification_preferences
+			(user_id, channels, quiet_hours_start, quiet_hours_end, timezone, digest_time, muted_projects, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			channels = excluded.channels,
+			quiet_hours_start = excluded.quiet_hours_start,
+			quiet_hours_end = excluded.quiet_hours_end,
+			timezone = excluded.timezone,
+			digest_time = excluded.digest_time,
+			muted_projects = excluded.muted_projects,
+			updated_at = excluded.updated_at
+	`, prefs.UserID, string(channels), prefs.QuietHoursStart, prefs.QuietHoursEnd,
+		prefs.Timezone, prefs.DigestTime, string(muted), prefs.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("保存通知偏好失败：%w", err)
+	}
+
+	return nil
+}
+
+// parseMutedProjects 解析 muted_projects 列
+// 早期版本按项目名称保存（字符串），这些记录从未生效，读取时跳过，下次保存后变为 ID 列表
+func parseMutedProjects(raw string) ([]int, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, fmt.Errorf("解析 muted_projects 失败：%w", err)
+	}
+	ids := make([]int, 0, len(values))
+	for _, v := range values {
+		var id int
+		if json.Unmarshal(v, &id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
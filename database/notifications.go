@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"todo-list/model"
+)
+
+// 单次最多返回的通知数量
+const maxNotificationsLimit = 100
+
+// initNotificationsSchema 初始化站内通知表
+func (db *DB) initNotificationsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		todo_id INTEGER,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL DEFAULT '',
+		read_at DATETIME,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, read_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init notifications table: %w", err)
+	}
+	return nil
+}
+
+// execer 同时兼容 *sql.DB 和 *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertNotification 写入一条通知
+func insertNotification(ctx context.Context, e execer, n *model.Notification) error {
+	result, err := e.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, kind, todo_id, title, body, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, n.UserID, n.Kind, n.TodoID, n.Title, n.Body, n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("保存通知失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取通知 ID 失败：%w", err)
+	}
+	n.ID = int(id)
+	return nil
+}
+
+// CreateNotificationContext 写入一条站内通知
+func (db *DB) CreateNotificationContext(ctx context.Context, n *model.Notification) error {
+	return insertNotification(ctx, db.conn, n)
+}
+
+// CountUnreadNotificationsContext 用户的未读通知数量
+func (db *DB) CountUnreadNotificationsContext(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("查询未读通知数量失败：%w", err)
+	}
+	return count, nil
+}
+
+// MarkAllNotificationsReadContext 将用户的所有未读通知标记为已读，返回标记的数量
+func (db *DB) MarkAllNotificationsReadContext(ctx context.Context, userID string) (int, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL
+	`, db.clock.Now().UTC(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("标记全部已读失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ListNotificationsContext 获取用户的通知，最新的在前；unreadOnly 为 true 时只返回未读
+func (db *DB) ListNotificationsContext(ctx context.Context, userID string, unreadOnly bool, limit int) ([]model.Notification, error) {
+	if limit <= 0 || limit > maxNotificationsLimit {
+		limit = maxNotificationsLimit
+	}
+
+	query := `
+		SELECT id, user_id, kind, todo_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = ?`
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+
+	rows, err := db.conn.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知失败：%w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]model.Notification, 0)
+	for rows.Next() {
+		var n model.Notification
+		var todoID sql.NullInt64
+		var readAt sql.NullTime
+
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &todoID, &n.Title, &n.Body, &readAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if todoID.Valid {
+			id := int(todoID.Int64)
+			n.TodoID = &id
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationReadContext 将用户的某条通知标记为已读，通知不存在时返回 false
+// 已读的通知再次标记不会修改 read_at
+func (db *DB) MarkNotificationReadContext(ctx context.Context, userID string, id int) (bool, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, ?)
+		WHERE id = ? AND user_id = ?
+	`, db.clock.Now().UTC(), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("标记已读失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// numberingTTL 编号范围超过这个时间没有重新分配就清理掉，命令行的每个会话通常各用一个范围
+const numberingTTL = 30 * 24 * time.Hour
+
+// TodoNumber 编号与待办事项的对应关系
+type TodoNumber struct {
+	Number   int    `json:"number"`
+	ID       int    `json:"id"`
+	PublicID string `json:"public_id"`
+	Title    string `json:"title"`
+}
+
+// initNumbersSchema 初始化显示编号表：编号在（工作区，范围）内从 1 开始，待办事项删除后编号失效
+func (db *DB) initNumbersSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_numbers (
+		workspace_id TEXT NOT NULL,
+		scope TEXT NOT NULL,
+		number INTEGER NOT NULL,
+		todo_id INTEGER NOT NULL,
+		assigned_at DATETIME NOT NULL,
+		PRIMARY KEY (workspace_id, scope, number),
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_numbers_assigned ON todo_numbers(assigned_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_numbers table: %w", err)
+	}
+	return nil
+}
+
+// AssignNumbersContext 在 scope 范围内依次给 todoIDs 分配 first、first+1……的编号
+// first 为 1（列表的第一页）时先清空该范围，之前的编号全部作废，和 taskwarrior 每次列出后重新编号一致
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) AssignNumbersContext(ctx context.Context, scope string, first int, todoIDs []int) (err error) {
+	workspace := WorkspaceFromContext(ctx)
+	now := db.clock.Now().UTC()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	if first == 1 {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM todo_numbers WHERE workspace_id = ? AND scope = ?`,
+			workspace, scope); err != nil {
+			return fmt.Errorf("清空编号失败：%w", err)
+		}
+	}
+	for i, id := range todoIDs {
+		if _, err = tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO todo_numbers (workspace_id, scope, number, todo_id, assigned_at) VALUES (?, ?, ?, ?, ?)
+		`, workspace, scope, first+i, id, now); err != nil {
+			return fmt.Errorf("分配编号失败：%w", err)
+		}
+	}
+
+	// 顺带清理很久没有用过的范围，表的大小只与活跃的会话有关
+	if _, err = tx.ExecContext(ctx, `DELETE FROM todo_numbers WHERE assigned_at < ?`, now.Add(-numberingTTL)); err != nil {
+		return fmt.Errorf("清理编号失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	return nil
+}
+
+// ResolveNumbersContext 查询 scope 范围内各编号对应的待办事项，按编号排列；不存在或已失效的编号不在结果中
+func (db *DB) ResolveNumbersContext(ctx context.Context, scope string, numbers []int) ([]TodoNumber, error) {
+	wanted := make(map[int]bool, len(numbers))
+	for _, n := range numbers {
+		wanted[n] = true
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT n.number, t.id, COALESCE(t.public_id, ''), t.title
+		FROM todo_numbers n JOIN todos t ON t.id = n.todo_id
+		WHERE n.workspace_id = ? AND n.scope = ?
+		ORDER BY n.number ASC
+	`, WorkspaceFromContext(ctx), scope)
+	if err != nil {
+		return nil, fmt.Errorf("查询编号失败：%w", err)
+	}
+	defer rows.Close()
+
+	resolved := make([]TodoNumber, 0, len(numbers))
+	for rows.Next() {
+		var n TodoNumber
+		if err := rows.Scan(&n.Number, &n.ID, &n.PublicID, &n.Title); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if wanted[n.Number] {
+			resolved = append(resolved, n)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return resolved, nil
+}
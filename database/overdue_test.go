@@ -0,0 +1,62 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+	"todo-list/clock"
+	"todo-list/database"
+	"todo-list/model"
+)
+
+// 纽约 2026-03-08 02:00 EST 拨快到 03:00 EDT：当地时间 01:30 再过一小时是 03:30，而不是 02:30
+func TestOverdueAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	db, err := database.New(filepath.Join(t.TempDir(), "overdue.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	fake := clock.NewFake(time.Date(2026, 3, 8, 1, 30, 0, 0, loc))
+	db.SetClock(fake)
+	presenter := model.DefaultPresenter(fake)
+
+	ctx := context.Background()
+	due := time.Date(2026, 3, 8, 3, 15, 0, 0, loc).UTC()
+	if _, err := db.ImportTodosContext(ctx, []model.Todo{{Title: "call the bank", Status: "pending", DueDate: &due}}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(wantOverdue bool) {
+		t.Helper()
+		want := 0
+		if wantOverdue {
+			want = 1
+		}
+		stats, err := db.GetFilteredStatsContext(ctx, database.TodoFilter{Search: "bank"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Overdue != want {
+			t.Errorf("at %v: stats overdue = %d, want %d", fake.Now().In(loc), stats.Overdue, want)
+		}
+
+		todos, _, err := db.ListTodosContext(ctx, database.TodoFilter{})
+		if err != nil || len(todos) != 1 {
+			t.Fatalf("ListTodosContext = %d todos, %v", len(todos), err)
+		}
+		presenter.Present(&todos[0])
+		if todos[0].IsOverdue != wantOverdue {
+			t.Errorf("at %v: is_overdue = %v, want %v", fake.Now().In(loc), todos[0].IsOverdue, wantOverdue)
+		}
+	}
+
+	check(false)
+	fake.Advance(time.Hour) // 03:30 EDT，已经过了 03:15
+	check(true)
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// PoolOptions 连接池配置，0 表示保持默认值
+type PoolOptions struct {
+	MaxOpenConns    int           // 最大打开连接数
+	MaxIdleConns    int           // 最大空闲连接数
+	ConnMaxLifetime time.Duration // 连接最长存活时间
+	ConnMaxIdleTime time.Duration // 连接最长空闲时间
+
+	// SoftHeapLimit SQLite 的内存软上限（字节），超过后 SQLite 会缩小页缓存；对整个进程生效，0 表示不限制
+	SoftHeapLimit int64
+}
+
+// ConfigurePool 设置连接池，只覆盖非 0 的项
+// 默认值（database/sql）为不限制打开连接数、保留 2 个空闲连接、连接不过期，对 SQLite 文件数据库足够；
+// 并发高时 PoolStats 中 WaitCount 持续增长说明连接数不够，可以调大 MaxOpenConns
+func (db *DB) ConfigurePool(opts PoolOptions) {
+	if opts.MaxOpenConns > 0 {
+		db.conn.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.conn.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.conn.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		db.conn.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+	if opts.SoftHeapLimit > 0 {
+		// soft_heap_limit 对整个进程生效，在任意一个连接上执行即可
+		if _, err := db.conn.Exec(fmt.Sprintf("PRAGMA soft_heap_limit = %d", opts.SoftHeapLimit)); err != nil {
+			log.Printf("设置 SQLite 内存上限失败: %v", err)
+		}
+	}
+}
+
+// PoolStats 连接池统计（打开 / 使用中 / 空闲连接数、等待次数和等待时长等）
+func (db *DB) PoolStats() sql.DBStats {
+	return db.conn.Stats()
+}
+
+// PingContext 检查数据库是否可用
+func (db *DB) PingContext(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
+}
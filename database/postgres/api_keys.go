@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// apiKeyTouchInterval last_used_at 的更新间隔，与 SQLite 相同
+const apiKeyTouchInterval = time.Minute
+
+// apiKeysSchema API key 表，只保存 key 的 SHA-256，撤销后保留记录；api_key_workspaces 是 member key 可以访问的工作区
+const apiKeysSchema = `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		user_id TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT 'member',
+		created_at TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ,
+		last_used_at TIMESTAMPTZ,
+		revoked_at TIMESTAMPTZ
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS api_key_workspaces (
+		key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+		workspace TEXT NOT NULL,
+		PRIMARY KEY (key_id, workspace)
+	);
+`
+
+// apiKeyColumns 查询 API key 的列，与 scanAPIKey 的顺序一致；绑定的工作区按标识排序后以逗号拼接
+const apiKeyColumns = `id, name, prefix, user_id, role, created_at, expires_at, last_used_at, revoked_at,
+	(SELECT string_agg(workspace, ',' ORDER BY workspace) FROM api_key_workspaces WHERE key_id = api_keys.id)`
+
+// scanAPIKey 扫描一行 API key（列顺序见 apiKeyColumns）
+func scanAPIKey(s rowScanner) (*model.APIKey, error) {
+	var k model.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	var workspaces sql.NullString
+	if err := s.Scan(&k.ID, &k.Name, &k.Prefix, &k.UserID, &k.Role, &k.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt, &workspaces); err != nil {
+		return nil, err
+	}
+	if workspaces.Valid && workspaces.String != "" {
+		k.Workspaces = strings.Split(workspaces.String, ",")
+	}
+	k.CreatedAt = k.CreatedAt.UTC()
+	k.ExpiresAt = utcTime(expiresAt)
+	k.LastUsedAt = utcTime(lastUsedAt)
+	k.RevokedAt = utcTime(revokedAt)
+	return &k, nil
+}
+
+// CreateAPIKeyContext 保存 API key 和它绑定的工作区，hash 为 model.HashAPIKey 的结果
+func (s *Store) CreateAPIKeyContext(ctx context.Context, key *model.APIKey, hash string) error {
+	key.CreatedAt = s.clock.Now().UTC()
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO api_keys (name, prefix, key_hash, user_id, role, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`, key.Name, key.Prefix, hash, key.UserID, key.Role, key.CreatedAt, key.ExpiresAt).Scan(&key.ID); err != nil {
+			return fmt.Errorf("保存 API key 失败：%w", err)
+		}
+		for _, slug := range key.Workspaces {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO api_key_workspaces (key_id, workspace) VALUES ($1, $2) ON CONFLICT DO NOTHING
+			`, key.ID, slug); err != nil {
+				return fmt.Errorf("保存 API key 的工作区失败：%w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListAPIKeysContext 用户的所有 API key（包括已撤销和已过期的），最新的在前
+func (s *Store) ListAPIKeysContext(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return keys, nil
+}
+
+// HasAPIKeysContext 是否创建过 API key（包括已撤销和已过期的）
+func (s *Store) HasAPIKeysContext(ctx context.Context) (bool, error) {
+	var exists bool
+	if err := s.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM api_keys)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+	return exists, nil
+}
+
+// RevokeAPIKeyContext 撤销用户的 API key，已撤销的保持原来的撤销时间；不存在时返回 storage.ErrNotFound
+func (s *Store) RevokeAPIKeyContext(ctx context.Context, userID string, id int) (*model.APIKey, error) {
+	if _, err := s.conn.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`, s.clock.Now().UTC(), id, userID); err != nil {
+		return nil, fmt.Errorf("撤销 API key 失败：%w", err)
+	}
+
+	row := s.conn.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("api key %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+	return key, nil
+}
+
+// AuthenticateAPIKeyContext 按哈希查找可以使用的 API key 并记录使用时间
+// 不存在、已撤销或已过期时都返回 storage.ErrNotFound
+func (s *Store) AuthenticateAPIKeyContext(ctx context.Context, hash string) (*model.APIKey, error) {
+	row := s.conn.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("api key: %w", storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 API key 失败：%w", err)
+	}
+
+	now := s.clock.Now().UTC()
+	if !key.Active(now) {
+		return nil, fmt.Errorf("api key %d: %w", key.ID, storage.ErrNotFound)
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if _, err := s.conn.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, now, key.ID); err != nil {
+			return nil, fmt.Errorf("更新 API key 使用时间失败：%w", err)
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
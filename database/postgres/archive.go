@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// queryEach 执行查询并对每一行调用 fn
+func queryEach(ctx context.Context, q querier, fn func(rowScanner) error, query string, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportArchiveContext 导出当前工作区的全部数据，在一个可重复读的只读事务中读取，保证各部分相互一致
+// 链接、目标和习惯只在 SQLite 中实现，归档中这几项为空
+func (s *Store) ExportArchiveContext(ctx context.Context) (*storage.Archive, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+
+	tx, err := s.conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer tx.Rollback()
+
+	archive := storage.NewArchive(model.Workspace{}, s.clock.Now().UTC())
+	ws := &archive.Workspace
+	err = tx.QueryRowContext(ctx, `SELECT slug, name, max_todos, created_at FROM workspaces WHERE slug = $1`, workspace).
+		Scan(&ws.Slug, &ws.Name, &ws.MaxTodos, &ws.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("查询工作区失败：%w", err)
+	}
+	ws.CreatedAt = ws.CreatedAt.UTC()
+
+	err = queryEach(ctx, tx, func(r rowScanner) error {
+		var p model.Project
+		var archivedAt sql.NullTime
+		if err := r.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &archivedAt); err != nil {
+			return err
+		}
+		p.CreatedAt, p.UpdatedAt = p.CreatedAt.UTC(), p.UpdatedAt.UTC()
+		p.ArchivedAt = utcTime(archivedAt)
+		archive.Projects = append(archive.Projects, p)
+		return nil
+	}, `SELECT id, name, description, created_at, updated_at, archived_at FROM projects WHERE workspace_id = $1 ORDER BY id ASC`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出项目失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(r rowScanner) error {
+		todo, err := scanTodo(r)
+		if err != nil {
+			return err
+		}
+		archive.Todos = append(archive.Todos, *todo)
+		return nil
+	}, `SELECT `+todoColumns+` FROM todos WHERE workspace_id = $1 ORDER BY id ASC`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出待办事项失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(r rowScanner) error {
+		var c model.Comment
+		var mentions []byte
+		if err := r.Scan(&c.ID, &c.TodoID, &c.Author, &c.Body, &mentions, &c.CreatedAt); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(mentions, &c.Mentions); err != nil {
+			return fmt.Errorf("解析 mentions 失败：%w", err)
+		}
+		c.CreatedAt = c.CreatedAt.UTC()
+		archive.Comments = append(archive.Comments, c)
+		return nil
+	}, `
+		SELECT c.id, c.todo_id, c.author, c.body, c.mentions, c.created_at
+		FROM todo_comments c JOIN todos t ON t.id = c.todo_id
+		WHERE t.workspace_id = $1 ORDER BY c.id ASC
+	`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出评论失败：%w", err)
+	}
+
+	err = queryEach(ctx, tx, func(r rowScanner) error {
+		rule, err := scanAutomationRule(r)
+		if err != nil {
+			return err
+		}
+		archive.AutomationRules = append(archive.AutomationRules, *rule)
+		return nil
+	}, `SELECT `+automationColumns+` FROM automation_rules WHERE workspace_id = $1 ORDER BY id ASC`, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("导出自动化规则失败：%w", err)
+	}
+
+	return archive, nil
+}
+
+// ImportArchiveContext 把归档导入当前工作区，全部成功或全部回滚，规则与 SQLite 实现相同：
+// 目标工作区必须为空，否则返回 storage.ErrWorkspaceNotEmpty；所有记录重新分配 ID，归档内的相互引用按新 ID 改写，
+// 引用了归档中不存在的记录时返回 *storage.ArchiveError。链接、目标和习惯不导入，数量记在 Skipped 中
+func (s *Store) ImportArchiveContext(ctx context.Context, archive *storage.Archive) (result storage.ArchiveImportResult, err error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		var existing bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM todos WHERE workspace_id = $1)
+			    OR EXISTS (SELECT 1 FROM projects WHERE workspace_id = $1)
+			    OR EXISTS (SELECT 1 FROM automation_rules WHERE workspace_id = $1)
+		`, workspace).Scan(&existing); err != nil {
+			return fmt.Errorf("检查工作区失败：%w", err)
+		}
+		if existing {
+			return fmt.Errorf("workspace %s: %w", workspace, storage.ErrWorkspaceNotEmpty)
+		}
+
+		if archive.Workspace.Name != "" {
+			if _, err := tx.ExecContext(ctx, `UPDATE workspaces SET name = $1, max_todos = $2 WHERE slug = $3`,
+				archive.Workspace.Name, archive.Workspace.MaxTodos, workspace); err != nil {
+				return fmt.Errorf("更新工作区失败：%w", err)
+			}
+		}
+
+		// 归档中的项目 ID -> 新 ID；已归档项目中的待办事项在查询时隐藏
+		projectIDs := make(map[int]int, len(archive.Projects))
+		for _, p := range archive.Projects {
+			var id int
+			if err := tx.QueryRowContext(ctx, `
+				INSERT INTO projects (workspace_id, name, description, created_at, updated_at, archived_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id
+			`, workspace, p.Name, p.Description, p.CreatedAt, p.UpdatedAt, p.ArchivedAt).Scan(&id); err != nil {
+				return fmt.Errorf("导入项目 %d 失败：%w", p.ID, projectWriteError(err, "导入"))
+			}
+			projectIDs[p.ID] = id
+			result.Projects++
+		}
+
+		// 归档中的待办事项 ID -> 新 ID
+		todoIDs := make(map[int]int, len(archive.Todos))
+		for _, todo := range archive.Todos {
+			if todo.Version < 1 {
+				todo.Version = 1
+			}
+			if todo.ProjectID != nil {
+				projectID, ok := projectIDs[*todo.ProjectID]
+				if !ok {
+					return &storage.ArchiveError{Message: fmt.Sprintf("待办事项 %d 引用了不存在的项目 %d", todo.ID, *todo.ProjectID)}
+				}
+				todo.ProjectID = &projectID
+			}
+			if err := claimPublicID(ctx, tx, &todo); err != nil {
+				return err
+			}
+			note, hint := noteColumns(todo.SecretNote)
+			var id int
+			if err := tx.QueryRowContext(ctx, `
+				INSERT INTO todos (version, title, description, status, priority, due_date, created_at, updated_at,
+				                   completed_at, latitude, longitude, radius, estimated_minutes, public_id, workspace_id,
+				                   recurrence, recurrence_start, next_occurrence_id, project_id, secret_note, secret_note_key_hint)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+				RETURNING id
+			`, todo.Version, todo.Title, todo.Description, todo.Status, todo.Priority, todo.DueDate, todo.CreatedAt,
+				todo.UpdatedAt, todo.CompletedAt, todo.Latitude, todo.Longitude, todo.Radius, todo.EstimatedMinutes,
+				todo.PublicID, workspace, nullString(todo.Recurrence), todo.RecurrenceStart, storage.ImportedOccurrence(&todo),
+				todo.ProjectID, note, hint).Scan(&id); err != nil {
+				return fmt.Errorf("导入待办事项 %d 失败：%w", todo.ID, err)
+			}
+			todoIDs[todo.ID] = id
+			result.Todos++
+		}
+
+		for _, c := range archive.Comments {
+			todoID, ok := todoIDs[c.TodoID]
+			if !ok {
+				return &storage.ArchiveError{Message: fmt.Sprintf("评论引用了不存在的待办事项 %d", c.TodoID)}
+			}
+			mentions, err := json.Marshal(c.Mentions)
+			if err != nil {
+				return fmt.Errorf("序列化 mentions 失败：%w", err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO todo_comments (todo_id, author, body, mentions, created_at) VALUES ($1, $2, $3, $4, $5)
+			`, todoID, c.Author, c.Body, string(mentions), c.CreatedAt); err != nil {
+				return fmt.Errorf("导入评论 %d 失败：%w", c.ID, err)
+			}
+			result.Comments++
+		}
+
+		for i := range archive.AutomationRules {
+			rule := &archive.AutomationRules[i]
+			actions, err := marshalRule(rule)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO automation_rules (workspace_id, name, event, condition, actions, enabled, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, workspace, rule.Name, rule.Event, rule.Condition, actions, rule.Enabled, rule.CreatedAt); err != nil {
+				return fmt.Errorf("导入自动化规则 %d 失败：%w", rule.ID, err)
+			}
+			result.AutomationRules++
+		}
+		return nil
+	})
+	if err != nil {
+		return storage.ArchiveImportResult{}, err
+	}
+	result.SkipUnsupported(archive)
+	return result, nil
+}
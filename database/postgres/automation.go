@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// automationSchema 自动化规则表：condition 是 CEL 表达式，conditions 是旧版的条件列表，新写入的规则总是 '[]'
+const automationSchema = `
+	CREATE TABLE IF NOT EXISTS automation_rules (
+		id SERIAL PRIMARY KEY,
+		workspace_id TEXT NOT NULL DEFAULT 'default',
+		name TEXT NOT NULL,
+		event TEXT NOT NULL,
+		condition TEXT NOT NULL DEFAULT '',
+		conditions JSONB NOT NULL DEFAULT '[]',
+		actions JSONB NOT NULL DEFAULT '[]',
+		enabled BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_automation_rules_event ON automation_rules(workspace_id, event);
+`
+
+// automationColumns 查询规则的列，与 scanAutomationRule 的顺序一致
+const automationColumns = `id, name, event, condition, conditions, actions, enabled, created_at`
+
+// CreateAutomationRuleContext 保存自动化规则
+func (s *Store) CreateAutomationRuleContext(ctx context.Context, rule *model.AutomationRule) error {
+	actions, err := marshalRule(rule)
+	if err != nil {
+		return err
+	}
+
+	rule.CreatedAt = s.clock.Now().UTC()
+	err = s.conn.QueryRowContext(ctx, `
+		INSERT INTO automation_rules (workspace_id, name, event, condition, actions, enabled, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, storage.WorkspaceFromContext(ctx), rule.Name, rule.Event, rule.Condition, actions, rule.Enabled, rule.CreatedAt).Scan(&rule.ID)
+	if err != nil {
+		return fmt.Errorf("保存自动化规则失败：%w", err)
+	}
+	return nil
+}
+
+// UpdateAutomationRuleContext 整体替换规则内容，规则不存在时返回 storage.ErrNotFound
+func (s *Store) UpdateAutomationRuleContext(ctx context.Context, rule *model.AutomationRule) error {
+	actions, err := marshalRule(rule)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.conn.ExecContext(ctx, `
+		UPDATE automation_rules
+		SET name = $1, event = $2, condition = $3, conditions = '[]', actions = $4, enabled = $5
+		WHERE id = $6 AND workspace_id = $7
+	`, rule.Name, rule.Event, rule.Condition, actions, rule.Enabled, rule.ID, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("更新自动化规则失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("automation rule %d: %w", rule.ID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteAutomationRuleContext 删除规则，规则不存在时返回 storage.ErrNotFound
+func (s *Store) DeleteAutomationRuleContext(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, `
+		DELETE FROM automation_rules WHERE id = $1 AND workspace_id = $2
+	`, id, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除自动化规则失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("automation rule %d: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// ListAutomationRulesContext 获取当前工作区的规则，按创建顺序排列
+// event 不为空时只返回该事件下已启用的规则，供规则执行使用
+func (s *Store) ListAutomationRulesContext(ctx context.Context, event string) ([]model.AutomationRule, error) {
+	query := `SELECT ` + automationColumns + ` FROM automation_rules WHERE workspace_id = $1`
+	args := []interface{}{storage.WorkspaceFromContext(ctx)}
+	if event != "" {
+		query += ` AND event = $2 AND enabled`
+		args = append(args, event)
+	}
+	query += ` ORDER BY id ASC`
+
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询自动化规则失败：%w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]model.AutomationRule, 0)
+	for rows.Next() {
+		rule, err := scanAutomationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return rules, nil
+}
+
+// GetAutomationRuleContext 获取一条规则，不存在时返回 storage.ErrNotFound
+func (s *Store) GetAutomationRuleContext(ctx context.Context, id int) (*model.AutomationRule, error) {
+	row := s.conn.QueryRowContext(ctx, `SELECT `+automationColumns+` FROM automation_rules
+		WHERE id = $1 AND workspace_id = $2`, id, storage.WorkspaceFromContext(ctx))
+	rule, err := scanAutomationRule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("automation rule %d: %w", id, storage.ErrNotFound)
+	}
+	return rule, err
+}
+
+// marshalRule 把旧版条件转换为表达式，并把动作序列化为 JSON 文本
+func marshalRule(rule *model.AutomationRule) (string, error) {
+	if err := rule.MigrateConditions(); err != nil {
+		return "", err
+	}
+	a, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return "", fmt.Errorf("序列化 actions 失败：%w", err)
+	}
+	return string(a), nil
+}
+
+// scanAutomationRule 扫描一行规则，没有结果时原样返回 sql.ErrNoRows
+func scanAutomationRule(s rowScanner) (*model.AutomationRule, error) {
+	var rule model.AutomationRule
+	var conditions, actions []byte
+	if err := s.Scan(&rule.ID, &rule.Name, &rule.Event, &rule.Condition, &conditions, &actions, &rule.Enabled, &rule.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描失败：%w", err)
+	}
+	if rule.Condition == "" {
+		if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+			return nil, fmt.Errorf("解析 conditions 失败：%w", err)
+		}
+		if err := rule.MigrateConditions(); err != nil {
+			return nil, fmt.Errorf("转换规则 %d 的旧版条件失败：%w", rule.ID, err)
+		}
+	}
+	if err := json.Unmarshal(actions, &rule.Actions); err != nil {
+		return nil, fmt.Errorf("解析 actions 失败：%w", err)
+	}
+	rule.CreatedAt = rule.CreatedAt.UTC()
+	return &rule, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// commentsSchema 评论表，删除待办事项时评论随之删除
+const commentsSchema = `
+	CREATE TABLE IF NOT EXISTS todo_comments (
+		id SERIAL PRIMARY KEY,
+		todo_id BIGINT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+		author TEXT NOT NULL,
+		body TEXT NOT NULL,
+		mentions JSONB NOT NULL DEFAULT '[]',
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_comments_todo_id ON todo_comments(todo_id);
+`
+
+// CreateCommentContext 保存评论，并在同一事务中写入提及产生的通知
+func (s *Store) CreateCommentContext(ctx context.Context, comment *model.Comment, notifications []model.Notification) error {
+	mentions, err := json.Marshal(comment.Mentions)
+	if err != nil {
+		return fmt.Errorf("序列化 mentions 失败：%w", err)
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO todo_comments (todo_id, author, body, mentions, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, comment.TodoID, comment.Author, comment.Body, string(mentions), comment.CreatedAt).Scan(&comment.ID); err != nil {
+			return fmt.Errorf("保存评论失败：%w", err)
+		}
+		for i := range notifications {
+			if err := insertNotification(ctx, tx, &notifications[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListCommentsContext 获取当前工作区中待办事项的评论，按时间先后排列
+func (s *Store) ListCommentsContext(ctx context.Context, todoID int) ([]model.Comment, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT c.id, c.todo_id, c.author, c.body, c.mentions, c.created_at
+		FROM todo_comments c
+		JOIN todos t ON t.id = c.todo_id
+		WHERE c.todo_id = $1 AND t.workspace_id = $2
+		ORDER BY c.id ASC
+	`, todoID, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]model.Comment, 0)
+	for rows.Next() {
+		var c model.Comment
+		var mentions []byte
+		if err := rows.Scan(&c.ID, &c.TodoID, &c.Author, &c.Body, &mentions, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if err := json.Unmarshal(mentions, &c.Mentions); err != nil {
+			return nil, fmt.Errorf("解析 mentions 失败：%w", err)
+		}
+		c.CreatedAt = c.CreatedAt.UTC()
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return comments, nil
+}
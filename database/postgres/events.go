@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+
+	"github.com/lib/pq"
+)
+
+// eventsSchema 待办事项变更事件表和记录事件的触发器，事件类型与 SQLite 的触发器相同（见 database.initTodoEventsSchema）：
+// 只有 updated_at、状态或工作区变化才算一次修改，移到其他工作区时原工作区收到 deleted、新工作区收到 created
+//
+// 触发器先取得事务级的咨询锁再分配事件 ID，写入待办事项的事务按提交顺序依次分配 ID：
+// 读到某个事件时，ID 更小的事件一定已经提交，按 ID 推进的 SSE 和 webhook 不会漏掉晚提交的事件。
+// 同时在 todo_events 频道上 NOTIFY，事务提交时送达所有实例的 ListenTodoEvents，同一事务内的多次通知合并为一次
+const eventsSchema = `
+	CREATE TABLE IF NOT EXISTS todo_events (
+		id BIGSERIAL PRIMARY KEY,
+		workspace_id TEXT NOT NULL,
+		todo_id BIGINT NOT NULL,
+		public_id TEXT,
+		type TEXT NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0,
+		project_id INTEGER,
+		priority INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_events_workspace ON todo_events(workspace_id, id);
+	CREATE INDEX IF NOT EXISTS idx_todo_events_created_at ON todo_events(created_at);
+
+	CREATE OR REPLACE FUNCTION record_todo_event() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'UPDATE' AND OLD.updated_at IS NOT DISTINCT FROM NEW.updated_at
+			AND OLD.status IS NOT DISTINCT FROM NEW.status AND OLD.workspace_id IS NOT DISTINCT FROM NEW.workspace_id THEN
+			RETURN NULL;
+		END IF;
+		PERFORM pg_advisory_xact_lock(hashtext('todo_events'));
+		PERFORM pg_notify('todo_events', '');
+
+		IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND OLD.workspace_id IS DISTINCT FROM NEW.workspace_id) THEN
+			INSERT INTO todo_events (workspace_id, todo_id, public_id, type, version, project_id, priority)
+			VALUES (OLD.workspace_id, OLD.id, OLD.public_id, 'deleted', OLD.version, OLD.project_id, OLD.priority);
+		END IF;
+		IF TG_OP = 'DELETE' THEN
+			RETURN NULL;
+		END IF;
+
+		INSERT INTO todo_events (workspace_id, todo_id, public_id, type, version, project_id, priority)
+		VALUES (NEW.workspace_id, NEW.id, NEW.public_id,
+			CASE
+				WHEN TG_OP = 'INSERT' OR OLD.workspace_id IS DISTINCT FROM NEW.workspace_id THEN 'created'
+				WHEN NEW.completed_at IS NOT NULL AND OLD.completed_at IS NULL THEN 'completed'
+				ELSE 'updated'
+			END,
+			NEW.version, NEW.project_id, NEW.priority);
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	DROP TRIGGER IF EXISTS trg_todo_events ON todos;
+	CREATE TRIGGER trg_todo_events AFTER INSERT OR UPDATE OR DELETE ON todos
+	FOR EACH ROW EXECUTE FUNCTION record_todo_event();
+`
+
+// 变更事件通知的频道、LISTEN 连接断开后重连的最短和最长间隔，以及长时间没有通知时检查连接的间隔
+const (
+	todoEventsChannel  = "todo_events"
+	listenMinReconnect = time.Second
+	listenMaxReconnect = time.Minute
+	listenPingInterval = 90 * time.Second
+)
+
+// lastEventIDSQL 已分配的最新事件 ID，还没有分配过时为 0
+const lastEventIDSQL = `(SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM todo_events_id_seq)`
+
+// ListTodoEventsContext 查询当前工作区 ID 大于 after 的事件，按 ID 升序，最多 limit 条
+func (s *Store) ListTodoEventsContext(ctx context.Context, after int64, limit int) ([]model.TodoEvent, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT id, type, todo_id, COALESCE(public_id, ''), version, project_id, priority, created_at
+		FROM todo_events
+		WHERE workspace_id = $1 AND id > $2
+		ORDER BY id ASC
+		LIMIT $3
+	`, storage.WorkspaceFromContext(ctx), after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询变更事件失败：%w", err)
+	}
+	defer rows.Close()
+
+	var events []model.TodoEvent
+	for rows.Next() {
+		var e model.TodoEvent
+		var projectID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Type, &e.TodoID, &e.PublicID, &e.Version, &projectID, &e.Priority, &e.At); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if projectID.Valid {
+			id := int(projectID.Int64)
+			e.ProjectID = &id
+		}
+		e.At = e.At.UTC()
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return events, nil
+}
+
+// TodoEventRangeContext 返回仍然保留的最早事件 ID 和已分配的最新事件 ID（所有工作区共用 todo_events_id_seq）
+// 事件全部被清理时 first 为 last + 1
+func (s *Store) TodoEventRangeContext(ctx context.Context) (first, last int64, err error) {
+	err = s.conn.QueryRowContext(ctx, `
+		SELECT `+lastEventIDSQL+`, COALESCE((SELECT MIN(id) FROM todo_events), 0)
+	`).Scan(&last, &first)
+	if err != nil {
+		return 0, 0, fmt.Errorf("查询变更事件失败：%w", err)
+	}
+	if first == 0 {
+		first = last + 1
+	}
+	return first, last, nil
+}
+
+// PurgeTodoEventsContext 删除 before 之前的变更事件
+func (s *Store) PurgeTodoEventsContext(ctx context.Context, before time.Time) (int, error) {
+	return s.purge(ctx, `DELETE FROM todo_events WHERE created_at < $1`, before, "清理变更事件失败")
+}
+
+// ListenTodoEvents 在单独的连接上 LISTEN 变更事件通知，任一实例提交了新事件时调用 notify，直到 ctx 取消
+// 连接断开重连后也调用一次 notify，订阅者借此补读断开期间的事件；首次 LISTEN 失败时返回错误
+func (s *Store) ListenTodoEvents(ctx context.Context, notify func()) error {
+	listener := pq.NewListener(s.dsn, listenMinReconnect, listenMaxReconnect, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("变更事件通知连接异常: %v", err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(todoEventsChannel); err != nil {
+		return fmt.Errorf("监听变更事件失败：%w", err)
+	}
+
+	ping := time.NewTicker(listenPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-listener.Notify:
+			// 重连后收到的是 nil，同样唤醒订阅者
+			notify()
+		case <-ping.C:
+			// 长时间没有通知时检查连接是否还在，断开的连接由 listener 自动重连
+			go listener.Ping()
+		}
+	}
+}
+
+// purge 执行按时间清理的 DELETE，返回删除的行数
+func (s *Store) purge(ctx context.Context, query string, before time.Time, message string) (int, error) {
+	result, err := s.conn.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s：%w", message, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
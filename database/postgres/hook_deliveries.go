@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// hookDeliveriesSchema 入站 webhook 投递记录表，与 SQLite 的 hook_deliveries 相同：
+// 重放保护记录已处理的投递，发送方重新投递到任何一个实例都会被识别出来
+const hookDeliveriesSchema = `
+	CREATE TABLE IF NOT EXISTS hook_deliveries (
+		key TEXT PRIMARY KEY,
+		expires_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_hook_deliveries_expires ON hook_deliveries(expires_at);
+`
+
+// MarkHookDeliveryContext 记录一次投递到 expiresAt 为止；同一个 key 已经记录过且未过期时返回 true
+// 顺便删除过期的记录，表的大小只与窗口内的投递量有关
+func (s *Store) MarkHookDeliveryContext(ctx context.Context, key string, expiresAt time.Time) (bool, error) {
+	now := s.clock.Now().UTC()
+	if _, err := s.conn.ExecContext(ctx, `DELETE FROM hook_deliveries WHERE expires_at < $1`, now); err != nil {
+		return false, fmt.Errorf("清理投递记录失败：%w", err)
+	}
+
+	// 清理和插入之间过期的记录直接覆盖，多个实例同时收到同一次投递时只有一个插入成功
+	result, err := s.conn.ExecContext(ctx, `
+		INSERT INTO hook_deliveries (key, expires_at) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE hook_deliveries.expires_at < $3
+	`, key, expiresAt.UTC(), now)
+	if err != nil {
+		return false, fmt.Errorf("记录投递失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows == 0, nil
+}
+
+// ForgetHookDeliveryContext 删除一次投递的记录，处理失败后发送方的重试会被再次处理
+func (s *Store) ForgetHookDeliveryContext(ctx context.Context, key string) error {
+	if _, err := s.conn.ExecContext(ctx, `DELETE FROM hook_deliveries WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("删除投递记录失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// leasesSchema 定时任务租约表，与 SQLite 的 job_leases 相同：
+// DB_DRIVER=postgres 时多个实例各自使用本地的 SQLite，租约必须放在共享的 PostgreSQL 中才能互斥
+const leasesSchema = `
+	CREATE TABLE IF NOT EXISTS job_leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	);
+`
+
+// AcquireLeaseContext 尝试获取或续期租约：租约不存在、已过期或本来就属于 holder 时成功，
+// 否则返回 false（其他实例正在持有）
+func (s *Store) AcquireLeaseContext(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	now := s.clock.Now().UTC()
+	result, err := s.conn.ExecContext(ctx, `
+		INSERT INTO job_leases (name, holder, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE job_leases.holder = EXCLUDED.holder OR job_leases.expires_at < $4
+	`, name, holder, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("获取租约失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ReleaseLeasesContext 释放 holder 持有的所有租约（实例退出时调用，其他实例可以立即接手）
+func (s *Store) ReleaseLeasesContext(ctx context.Context, holder string) error {
+	if _, err := s.conn.ExecContext(ctx, `DELETE FROM job_leases WHERE holder = $1`, holder); err != nil {
+		return fmt.Errorf("释放租约失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// 单次最多返回的通知数量
+const maxNotificationsLimit = 100
+
+// notificationsSchema 站内通知和通知偏好，按用户区分，不限工作区；引用的待办事项删除时通知随之删除
+const notificationsSchema = `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id SERIAL PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		todo_id BIGINT REFERENCES todos(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL DEFAULT '',
+		read_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, read_at);
+
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id TEXT PRIMARY KEY,
+		channels JSONB NOT NULL DEFAULT '{}',
+		quiet_hours_start TEXT NOT NULL DEFAULT '',
+		quiet_hours_end TEXT NOT NULL DEFAULT '',
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		digest_time TEXT NOT NULL DEFAULT '',
+		muted_projects JSONB NOT NULL DEFAULT '[]',
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+`
+
+// insertNotification 写入一条通知
+func insertNotification(ctx context.Context, q querier, n *model.Notification) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO notifications (user_id, kind, todo_id, title, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, n.UserID, n.Kind, n.TodoID, n.Title, n.Body, n.CreatedAt).Scan(&n.ID)
+	if err != nil {
+		return fmt.Errorf("保存通知失败：%w", err)
+	}
+	return nil
+}
+
+// CreateNotificationContext 写入一条站内通知
+func (s *Store) CreateNotificationContext(ctx context.Context, n *model.Notification) error {
+	return insertNotification(ctx, s.conn, n)
+}
+
+// CountUnreadNotificationsContext 用户的未读通知数量
+func (s *Store) CountUnreadNotificationsContext(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := s.conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("查询未读通知数量失败：%w", err)
+	}
+	return count, nil
+}
+
+// MarkAllNotificationsReadContext 将用户的所有未读通知标记为已读，返回标记的数量
+func (s *Store) MarkAllNotificationsReadContext(ctx context.Context, userID string) (int, error) {
+	result, err := s.conn.ExecContext(ctx, `
+		UPDATE notifications SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL
+	`, s.clock.Now().UTC(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("标记全部已读失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
+
+// ListNotificationsContext 获取用户的通知，最新的在前；unreadOnly 为 true 时只返回未读
+func (s *Store) ListNotificationsContext(ctx context.Context, userID string, unreadOnly bool, limit int) ([]model.Notification, error) {
+	if limit <= 0 || limit > maxNotificationsLimit {
+		limit = maxNotificationsLimit
+	}
+
+	query := `
+		SELECT id, user_id, kind, todo_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1`
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY id DESC LIMIT $2"
+
+	rows, err := s.conn.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知失败：%w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]model.Notification, 0)
+	for rows.Next() {
+		var n model.Notification
+		var todoID sql.NullInt64
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &todoID, &n.Title, &n.Body, &readAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if todoID.Valid {
+			id := int(todoID.Int64)
+			n.TodoID = &id
+		}
+		n.ReadAt = utcTime(readAt)
+		n.CreatedAt = n.CreatedAt.UTC()
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return notifications, nil
+}
+
+// MarkNotificationReadContext 将用户的某条通知标记为已读，通知不存在时返回 false
+// 已读的通知再次标记不会修改 read_at
+func (s *Store) MarkNotificationReadContext(ctx context.Context, userID string, id int) (bool, error) {
+	result, err := s.conn.ExecContext(ctx, `
+		UPDATE notifications SET read_at = COALESCE(read_at, $1) WHERE id = $2 AND user_id = $3
+	`, s.clock.Now().UTC(), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("标记已读失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// PurgeReadNotificationsContext 删除 before 之前已读的通知，未读通知不受影响
+func (s *Store) PurgeReadNotificationsContext(ctx context.Context, before time.Time) (int, error) {
+	return s.purge(ctx, `DELETE FROM notifications WHERE read_at IS NOT NULL AND read_at < $1`, before, "清理通知失败")
+}
+
+// GetNotificationPreferencesContext 获取用户通知偏好，没有记录时返回默认值
+func (s *Store) GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	var prefs model.NotificationPreferences
+	var channels, muted []byte
+	err := s.conn.QueryRowContext(ctx, `
+		SELECT user_id, channels, quiet_hours_start, quiet_hours_end, timezone, digest_time, muted_projects, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID).Scan(&prefs.UserID, &channels, &prefs.QuietHoursStart, &prefs.QuietHoursEnd,
+		&prefs.Timezone, &prefs.DigestTime, &muted, &prefs.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.DefaultNotificationPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败：%w", err)
+	}
+
+	if err := json.Unmarshal(channels, &prefs.Channels); err != nil {
+		return nil, fmt.Errorf("解析 channels 失败：%w", err)
+	}
+	prefs.MutedProjects = make([]int, 0)
+	if err := json.Unmarshal(muted, &prefs.MutedProjects); err != nil {
+		return nil, fmt.Errorf("解析 muted_projects 失败：%w", err)
+	}
+	prefs.UpdatedAt = prefs.UpdatedAt.UTC()
+	return &prefs, nil
+}
+
+// SaveNotificationPreferencesContext 保存用户通知偏好（不存在则插入）
+func (s *Store) SaveNotificationPreferencesContext(ctx context.Context, prefs *model.NotificationPreferences) error {
+	channels, err := json.Marshal(prefs.Channels)
+	if err != nil {
+		return fmt.Errorf("序列化 channels 失败：%w", err)
+	}
+	if prefs.MutedProjects == nil {
+		prefs.MutedProjects = []int{}
+	}
+	muted, err := json.Marshal(prefs.MutedProjects)
+	if err != nil {
+		return fmt.Errorf("序列化 muted_projects 失败：%w", err)
+	}
+
+	prefs.UpdatedAt = s.clock.Now().UTC()
+	_, err = s.conn.ExecContext(ctx, `
+		INSERT INTO notification_preferences
+			(user_id, channels, quiet_hours_start, quiet_hours_end, timezone, digest_time, muted_projects, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			channels = EXCLUDED.channels,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			digest_time = EXCLUDED.digest_time,
+			muted_projects = EXCLUDED.muted_projects,
+			updated_at = EXCLUDED.updated_at
+	`, prefs.UserID, string(channels), prefs.QuietHoursStart, prefs.QuietHoursEnd,
+		prefs.Timezone, prefs.DigestTime, string(muted), prefs.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("保存通知偏好失败：%w", err)
+	}
+	return nil
+}
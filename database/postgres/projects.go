@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// projectsSchema 项目表，todos.project_id 指向项目，为空表示不属于任何项目；名称在工作区内唯一（不区分大小写）
+// 项目归档后其中的待办事项在查询时按 visibleCondition 隐藏
+const projectsSchema = `
+	CREATE TABLE IF NOT EXISTS projects (
+		id SERIAL PRIMARY KEY,
+		workspace_id TEXT NOT NULL DEFAULT 'default',
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		archived_at TIMESTAMPTZ
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_name ON projects(workspace_id, lower(name));
+	CREATE INDEX IF NOT EXISTS idx_todos_project ON todos(project_id);
+`
+
+// projectQuery 查询项目及其待办事项数量，未完成的数量按工作流的未完成状态统计；$1 为工作区
+func (s *Store) projectQuery() string {
+	return `
+	SELECT p.id, p.name, p.description, p.created_at, p.updated_at, p.archived_at,
+	       (SELECT COUNT(*) FROM todos t WHERE t.project_id = p.id),
+	       (SELECT COUNT(*) FROM todos t WHERE t.project_id = p.id AND ` + s.statuses.OpenSQL() + `)
+	FROM projects p
+	WHERE p.workspace_id = $1`
+}
+
+// scanProject 扫描一行项目（列顺序见 projectQuery），没有结果时原样返回 sql.ErrNoRows
+func scanProject(s rowScanner) (*model.Project, error) {
+	var p model.Project
+	var archivedAt sql.NullTime
+	if err := s.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &archivedAt, &p.TodoCount, &p.OpenCount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描失败：%w", err)
+	}
+	p.CreatedAt, p.UpdatedAt = p.CreatedAt.UTC(), p.UpdatedAt.UTC()
+	p.ArchivedAt = utcTime(archivedAt)
+	return &p, nil
+}
+
+// projectWriteError 名称重复时返回 storage.ErrProjectExists
+func projectWriteError(err error, action string) error {
+	if isUniqueViolation(err) {
+		return storage.ErrProjectExists
+	}
+	return fmt.Errorf("%s项目失败：%w", action, err)
+}
+
+// CreateProjectContext 保存项目
+func (s *Store) CreateProjectContext(ctx context.Context, project *model.Project) error {
+	now := s.clock.Now().UTC()
+	project.CreatedAt, project.UpdatedAt = now, now
+	err := s.conn.QueryRowContext(ctx, `
+		INSERT INTO projects (workspace_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, storage.WorkspaceFromContext(ctx), project.Name, project.Description, now, now).Scan(&project.ID)
+	if err != nil {
+		return projectWriteError(err, "保存")
+	}
+	return nil
+}
+
+// GetProjectContext 获取当前工作区的项目，不存在时返回 storage.ErrNotFound
+func (s *Store) GetProjectContext(ctx context.Context, id int) (*model.Project, error) {
+	row := s.conn.QueryRowContext(ctx, s.projectQuery()+` AND p.id = $2`, storage.WorkspaceFromContext(ctx), id)
+	project, err := scanProject(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("project %d: %w", id, storage.ErrNotFound)
+	}
+	return project, err
+}
+
+// ListProjectsContext 获取当前工作区的项目，按名称排列（不区分大小写）
+func (s *Store) ListProjectsContext(ctx context.Context) ([]model.Project, error) {
+	rows, err := s.conn.QueryContext(ctx, s.projectQuery()+` ORDER BY lower(p.name) ASC, p.id ASC`,
+		storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败：%w", err)
+	}
+	defer rows.Close()
+
+	projects := make([]model.Project, 0)
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return projects, nil
+}
+
+// UpdateProjectContext 修改项目名称和描述，项目不存在时返回 storage.ErrNotFound
+func (s *Store) UpdateProjectContext(ctx context.Context, project *model.Project) error {
+	project.UpdatedAt = s.clock.Now().UTC()
+	result, err := s.conn.ExecContext(ctx, `
+		UPDATE projects SET name = $1, description = $2, updated_at = $3
+		WHERE id = $4 AND workspace_id = $5
+	`, project.Name, project.Description, project.UpdatedAt, project.ID, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return projectWriteError(err, "更新")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("project %d: %w", project.ID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteProjectContext 删除项目，项目中的待办事项保留，改为不属于任何项目
+func (s *Store) DeleteProjectContext(ctx context.Context, id int) error {
+	workspace := storage.WorkspaceFromContext(ctx)
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1 AND workspace_id = $2`, id, workspace)
+		if err != nil {
+			return fmt.Errorf("删除项目失败：%w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("project %d: %w", id, storage.ErrNotFound)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE todos SET project_id = NULL, updated_at = $1, version = version + 1
+			WHERE project_id = $2 AND workspace_id = $3
+		`, s.clock.Now().UTC(), id, workspace); err != nil {
+			return fmt.Errorf("移出项目中的待办事项失败：%w", err)
+		}
+		return nil
+	})
+}
+
+// CheckProjectContext 确认项目存在于当前工作区，不存在时返回 storage.ErrNotFound
+func (s *Store) CheckProjectContext(ctx context.Context, id int) error {
+	return checkProject(ctx, s.conn, id, storage.WorkspaceFromContext(ctx))
+}
+
+// EnsureProjectContext 按名称（不区分大小写）查找当前工作区的项目，不存在时创建，返回项目 ID
+// 名称需要调用方先用 model.Project.Validate 规范化
+func (s *Store) EnsureProjectContext(ctx context.Context, name string) (int, error) {
+	var id int
+	err := s.conn.QueryRowContext(ctx, `SELECT id FROM projects WHERE workspace_id = $1 AND lower(name) = lower($2)`,
+		storage.WorkspaceFromContext(ctx), name).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("查询项目失败：%w", err)
+	}
+
+	project := &model.Project{Name: name}
+	if err := s.CreateProjectContext(ctx, project); err != nil {
+		return 0, err
+	}
+	return project.ID, nil
+}
+
+// SetProjectArchivedContext 归档或取消归档项目；项目中的待办事项在列表中出现或消失，同时更新它们的版本号，订阅变更的客户端会收到通知
+// 项目不存在时返回 storage.ErrNotFound；已经是目标状态时不做修改
+func (s *Store) SetProjectArchivedContext(ctx context.Context, id int, archived bool) error {
+	workspace := storage.WorkspaceFromContext(ctx)
+	now := s.clock.Now().UTC()
+	var archivedAt interface{}
+	if archived {
+		archivedAt = now
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProject(ctx, tx, id, workspace); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `
+			UPDATE projects SET archived_at = $1, updated_at = $2
+			WHERE id = $3 AND workspace_id = $4 AND (archived_at IS NULL) = $5
+		`, archivedAt, now, id, workspace, archived)
+		if err != nil {
+			return fmt.Errorf("归档项目失败：%w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE todos SET updated_at = $1, version = version + 1
+			WHERE project_id = $2 AND workspace_id = $3
+		`, now, id, workspace); err != nil {
+			return fmt.Errorf("更新项目中的待办事项失败：%w", err)
+		}
+		return nil
+	})
+}
+
+// CompleteProjectTodosContext 在一个事务中完成项目中所有未完成（工作流中的非终态）的待办事项，返回完成的 ID
+// 与批量完成一样状态改为 completed；项目不存在时返回 storage.ErrNotFound
+func (s *Store) CompleteProjectTodosContext(ctx context.Context, id int) (todoIDs []int, err error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProject(ctx, tx, id, workspace); err != nil {
+			return err
+		}
+		now := s.clock.Now().UTC()
+		ids, err := updateTodosReturning(ctx, tx, `
+			UPDATE todos SET status = 'completed', completed_at = $1, updated_at = $1, version = version + 1
+			WHERE project_id = $2 AND workspace_id = $3 AND `+s.statuses.OpenSQL()+`
+			RETURNING id
+		`, now, id, workspace)
+		if err != nil {
+			return fmt.Errorf("完成项目中的待办事项失败：%w", err)
+		}
+		todoIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todoIDs, nil
+}
+
+// MoveProjectTodosContext 在一个事务中把项目中的所有待办事项移到目标项目，target 为 0 表示移出项目，返回移动的 ID
+// 来源项目不存在时返回 storage.ErrNotFound，目标项目不存在时返回 storage.ErrTargetProjectNotFound
+func (s *Store) MoveProjectTodosContext(ctx context.Context, id, target int) (todoIDs []int, err error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProject(ctx, tx, id, workspace); err != nil {
+			return err
+		}
+		var targetID interface{}
+		if target != 0 {
+			if err := checkProject(ctx, tx, target, workspace); err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					err = fmt.Errorf("project %d: %w", target, storage.ErrTargetProjectNotFound)
+				}
+				return err
+			}
+			targetID = target
+		}
+
+		ids, err := updateTodosReturning(ctx, tx, `
+			UPDATE todos SET project_id = $1, updated_at = $2, version = version + 1
+			WHERE project_id = $3 AND workspace_id = $4
+			RETURNING id
+		`, targetID, s.clock.Now().UTC(), id, workspace)
+		if err != nil {
+			return fmt.Errorf("移动项目中的待办事项失败：%w", err)
+		}
+		todoIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todoIDs, nil
+}
+
+// querier 同时兼容 *sql.DB 和 *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// checkProject 确认项目存在于工作区，不存在时返回 storage.ErrNotFound
+func checkProject(ctx context.Context, q querier, id int, workspace string) error {
+	var exists bool
+	err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND workspace_id = $2)`,
+		id, workspace).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("查询项目失败：%w", err)
+	}
+	if !exists {
+		return fmt.Errorf("project %d: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// updateTodosReturning 执行带 RETURNING id 的 UPDATE，返回修改的待办事项 ID
+func updateTodosReturning(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// inTx 在一个事务中执行 fn，fn 返回错误时回滚
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	return nil
+}
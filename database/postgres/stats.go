@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+	"todo-list/storage"
+)
+
+// overdueBucketsContext 按逾期时长给 q 范围内未完成且已逾期的事项分组，与 SQLite 的 getOverdueBucketsContext 相同
+func (s *Store) overdueBucketsContext(ctx context.Context, q *todoQuery, now time.Time) (*storage.OverdueBuckets, error) {
+	q = q.clone()
+	at := q.arg(now)
+	q.where(s.statuses.OpenSQL()).where("due_date IS NOT NULL").where("due_date < " + at)
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE age < 1),
+			COUNT(*) FILTER (WHERE age >= 1 AND age <= 7),
+			COUNT(*) FILTER (WHERE age > 7)
+		FROM (SELECT EXTRACT(EPOCH FROM ` + at + `::TIMESTAMPTZ - due_date) / 86400 AS age FROM todos` + q.whereSQL() + `) o`
+
+	var buckets storage.OverdueBuckets
+	if err := s.conn.QueryRowContext(ctx, query, q.args...).Scan(&buckets.LessThanDay, &buckets.OneToSeven, &buckets.OverSeven); err != nil {
+		return nil, fmt.Errorf("查询逾期分布失败：%w", err)
+	}
+	return &buckets, nil
+}
+
+// latencyQuery 按分组计算完成耗时（小时）的数量、平均值和中位数
+// 中位数与 SQLite 实现一致：按整分钟分桶（先四舍五入到毫秒再整除），取中间一条所在分桶的平均耗时，偶数条时取中间两条的平均
+// %[1]s 为分组表达式，只能传入代码中的常量；%[2]s 为 todoQuery 生成的 WHERE 子句
+const latencyQuery = `
+	WITH durations AS (
+		SELECT %[1]s AS grp, (EXTRACT(EPOCH FROM completed_at - created_at) / 3600)::DOUBLE PRECISION AS hours
+		FROM todos%[2]s
+	),
+	buckets AS (
+		SELECT grp, ROUND(hours * 3600000)::BIGINT / 60000 AS minutes, COUNT(*) AS n, SUM(hours) AS total
+		FROM durations
+		GROUP BY 1, 2
+	),
+	ranked AS (
+		SELECT grp, n, total,
+		       SUM(n) OVER (PARTITION BY grp ORDER BY minutes)::BIGINT AS seen,
+		       SUM(n) OVER (PARTITION BY grp)::BIGINT AS cnt
+		FROM buckets
+	)
+	SELECT grp, SUM(n)::BIGINT, SUM(total) / SUM(n)::DOUBLE PRECISION,
+	       (MAX(total / n) FILTER (WHERE seen - n < (cnt + 1) / 2 AND seen >= (cnt + 1) / 2) +
+	        MAX(total / n) FILTER (WHERE seen - n < (cnt + 2) / 2 AND seen >= (cnt + 2) / 2)) / 2
+	FROM ranked
+	GROUP BY 1
+	ORDER BY 1
+`
+
+// completionLatencyContext 计算 q 范围内已完成事项从创建到完成的耗时：整体 + 按优先级
+func (s *Store) completionLatencyContext(ctx context.Context, q *todoQuery) (*storage.CompletionLatency, error) {
+	result := &storage.CompletionLatency{ByPriority: make([]storage.PriorityLatency, 0)}
+	q = q.clone().where(s.statuses.DoneSQL()).where("completed_at IS NOT NULL")
+
+	overall, err := s.queryLatency(ctx, q, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(overall) > 0 {
+		result.Overall = overall[0].LatencyStats
+	}
+
+	if result.ByPriority, err = s.queryLatency(ctx, q, "priority"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// queryLatency 按 groupExpr 分组执行 latencyQuery
+func (s *Store) queryLatency(ctx context.Context, q *todoQuery, groupExpr string) ([]storage.PriorityLatency, error) {
+	rows, err := s.conn.QueryContext(ctx, fmt.Sprintf(latencyQuery, groupExpr, q.whereSQL()), q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询完成耗时失败：%w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]storage.PriorityLatency, 0)
+	for rows.Next() {
+		var g storage.PriorityLatency
+		var avg, median sql.NullFloat64
+		if err := rows.Scan(&g.Priority, &g.Count, &avg, &median); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		g.AvgHours = avg.Float64
+		g.MedianHours = median.Float64
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return groups, nil
+}
+
+// focusWeight 优先级权重的 SQL 表达式，与 SQLite 实现相同
+const focusWeight = "GREATEST(priority + 1, 1)"
+
+// focusScoreContext 计算 q 范围内当天（UTC）的专注度，定义见 storage.FocusScore
+func (s *Store) focusScoreContext(ctx context.Context, q *todoQuery, now time.Time) (*storage.FocusScore, error) {
+	today := now.UTC().Format("2006-01-02")
+	q = q.clone()
+	day := q.arg(today) + "::DATE"
+	completedToday := "completed_at IS NOT NULL AND " + utcDate("completed_at") + " = " + day
+	clearedToday := completedToday + " AND due_date IS NOT NULL AND due_date < completed_at"
+
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE ` + completedToday + `),
+			COUNT(*) FILTER (WHERE ` + clearedToday + `),
+			COALESCE(SUM(` + focusWeight + `) FILTER (WHERE ` + completedToday + `), 0),
+			COALESCE(SUM(` + focusWeight + `) FILTER (WHERE ` + clearedToday + `), 0),
+			COALESCE(SUM(` + focusWeight + `) FILTER (WHERE ` + s.statuses.OpenSQL() + ` AND due_date IS NOT NULL AND ` + utcDate("due_date") + ` <= ` + day + `), 0)
+		FROM todos` + q.whereSQL()
+
+	var points, bonus, remaining int
+	focus := &storage.FocusScore{Date: today}
+	if err := s.conn.QueryRowContext(ctx, query, q.args...).Scan(&focus.Completed, &focus.OverdueCleared, &points, &bonus, &remaining); err != nil {
+		return nil, fmt.Errorf("查询专注度失败：%w", err)
+	}
+	focus.Points = points + bonus
+	focus.Remaining = remaining
+	if total := focus.Points + focus.Remaining; total > 0 {
+		focus.Score = int(math.Round(float64(focus.Points) * 100 / float64(total)))
+	}
+	return focus, nil
+}
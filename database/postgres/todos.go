@@ -0,0 +1,523 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// CreateTodoContext 在当前工作区创建待办事项，ID 由 RETURNING 返回
+func (s *Store) CreateTodoContext(ctx context.Context, todo *model.Todo) error {
+	if todo.PublicID == "" {
+		todo.PublicID = model.NewPublicID(todo.CreatedAt)
+	}
+	note, hint := noteColumns(todo.SecretNote)
+
+	err := s.conn.QueryRowContext(ctx, `
+		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+		                   latitude, longitude, radius, estimated_minutes, priority, public_id, workspace_id,
+		                   recurrence, recurrence_start, project_id, secret_note, secret_note_key_hint)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING id
+	`, todo.Title, todo.Description, todo.Status, todo.DueDate, todo.CreatedAt, todo.UpdatedAt, todo.Version,
+		todo.Latitude, todo.Longitude, todo.Radius, todo.EstimatedMinutes, todo.Priority, todo.PublicID,
+		storage.WorkspaceFromContext(ctx), nullString(todo.Recurrence), todo.RecurrenceStart, todo.ProjectID,
+		note, hint).Scan(&todo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+	return nil
+}
+
+// GetTodoByIDContext 根据 ID 获取当前工作区的待办事项，不存在时返回 storage.ErrNotFound
+func (s *Store) GetTodoByIDContext(ctx context.Context, id int) (*model.Todo, error) {
+	todo, err := scanTodo(s.conn.QueryRowContext(ctx, `SELECT `+todoColumns+` FROM todos
+		WHERE id = $1 AND workspace_id = $2`, id, storage.WorkspaceFromContext(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("todo %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+	return todo, nil
+}
+
+// GetTodoIDByPublicIDContext 按外部标识查找当前工作区中待办事项的 ID，不存在时返回 storage.ErrNotFound
+func (s *Store) GetTodoIDByPublicIDContext(ctx context.Context, publicID string) (int, error) {
+	var id int
+	err := s.conn.QueryRowContext(ctx, `SELECT id FROM todos WHERE public_id = $1 AND workspace_id = $2`,
+		publicID, storage.WorkspaceFromContext(ctx)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("todo %s: %w", publicID, storage.ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询待办事项失败：%w", err)
+	}
+	return id, nil
+}
+
+// ListTodosContext 获取当前工作区的待办事项列表，总数与 SQLite 实现一样用窗口函数在同一次查询中返回
+func (s *Store) ListTodosContext(ctx context.Context, filter storage.TodoFilter) ([]model.Todo, int, error) {
+	filter = storage.NormalizeFilter(filter)
+	q := scopedTodoQuery(ctx).filter(filter, s.statuses)
+	// 已归档项目中的事项只在按项目查看或明确要求时列出
+	if filter.ProjectID == nil && !filter.IncludeArchived {
+		q.where(visibleCondition)
+	}
+	count := q.clone()
+
+	total := -1
+	columns := todoColumns
+	if !filter.SkipTotal {
+		columns += ", COUNT(*) OVER()"
+	}
+	// sort 和 order 已经在 NormalizeFilter 中按白名单校验过，可以安全拼接；NULLS LAST 与 SQLite 中 NULL 最小的排序一致
+	nulls := "NULLS FIRST"
+	if filter.Order == "DESC" {
+		nulls = "NULLS LAST"
+	}
+	query := fmt.Sprintf("SELECT %s FROM todos%s ORDER BY %s %s %s LIMIT %s OFFSET %s", columns, q.whereSQL(),
+		filter.Sort, filter.Order, nulls, q.arg(filter.Limit), q.arg(filter.Offset))
+
+	rows, err := s.conn.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询失败：%w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		var extra []interface{}
+		if !filter.SkipTotal {
+			extra = append(extra, &total)
+		}
+		todo, err := scanTodo(rows, extra...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("扫描失败：%w", err)
+		}
+		todos = append(todos, *todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	// 当前页没有数据时拿不到窗口函数的结果：从第一页开始说明总数为 0，否则偏移量超出了范围，需要单独计数
+	if !filter.SkipTotal && len(todos) == 0 {
+		total = 0
+		if filter.Offset > 0 {
+			if err := s.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM todos"+count.whereSQL(), count.args...).Scan(&total); err != nil {
+				return nil, 0, fmt.Errorf("查询总数失败：%w", err)
+			}
+		}
+	}
+	return todos, total, nil
+}
+
+// UpdateTodoContext 按版本号更新待办事项，版本号不一致时返回 storage.ErrVersionConflict
+func (s *Store) UpdateTodoContext(ctx context.Context, todo *model.Todo) error {
+	todo.UpdatedAt = s.clock.Now()
+	note, hint := noteColumns(todo.SecretNote)
+	workspace := storage.WorkspaceFromContext(ctx)
+
+	result, err := s.conn.ExecContext(ctx, `
+		UPDATE todos
+		SET title = $1, description = $2, status = $3,
+		    due_date = $4, updated_at = $5, completed_at = $6,
+		    latitude = $7, longitude = $8, radius = $9, estimated_minutes = $10, priority = $11,
+		    recurrence = $12, recurrence_start = $13, project_id = $14,
+		    secret_note = $15, secret_note_key_hint = $16, version = version + 1
+		WHERE id = $17 AND version = $18 AND workspace_id = $19
+	`, todo.Title, todo.Description, todo.Status, todo.DueDate, todo.UpdatedAt, todo.CompletedAt,
+		todo.Latitude, todo.Longitude, todo.Radius, todo.EstimatedMinutes, todo.Priority,
+		nullString(todo.Recurrence), todo.RecurrenceStart, todo.ProjectID, note, hint,
+		todo.ID, todo.Version, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		// 没有匹配到行时区分原因：记录不存在，还是版本冲突
+		var exists int
+		err := s.conn.QueryRowContext(ctx, `SELECT 1 FROM todos WHERE id = $1 AND workspace_id = $2`, todo.ID, workspace).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("todo %d: %w", todo.ID, storage.ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check todo: %w", err)
+		}
+		return storage.ErrVersionConflict
+	}
+
+	todo.Version++
+	return nil
+}
+
+// DeleteTodoContext 删除当前工作区的待办事项
+func (s *Store) DeleteTodoContext(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, `DELETE FROM todos WHERE id = $1 AND workspace_id = $2`,
+		id, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("todo %d: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// GetFilteredStatsContext 统计符合列表过滤条件的待办事项，Status 不参与过滤
+// 与 ListTodosContext 一致，不按项目过滤时不统计已归档项目中的事项
+func (s *Store) GetFilteredStatsContext(ctx context.Context, filter storage.TodoFilter) (*storage.TodoStats, error) {
+	filter.Status = ""
+	q := scopedTodoQuery(ctx).filter(filter, s.statuses)
+	if filter.ProjectID == nil {
+		q.where(visibleCondition)
+	}
+
+	stats := storage.TodoStats{ByStatus: make(map[string]int)}
+	rows, err := s.conn.QueryContext(ctx, "SELECT status, COUNT(*) FROM todos"+q.whereSQL()+" GROUP BY status", q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("统计状态失败：%w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		stats.ByStatus[status] = n
+		stats.Total += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	stats.Pending = s.statuses.CountOpen(stats.ByStatus)
+	stats.Completed = s.statuses.CountDone(stats.ByStatus)
+
+	now := s.clock.Now().UTC()
+	today := now.Format("2006-01-02")
+	weekLater := now.AddDate(0, 0, 7).Format("2006-01-02")
+	pendingDue := s.statuses.OpenSQL() + " AND due_date IS NOT NULL AND "
+	open := q.clone().where("completed_at IS NULL")
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE ` + pendingDue + `due_date < ` + open.arg(now) + `),
+			COUNT(*) FILTER (WHERE ` + pendingDue + utcDate("due_date") + ` = ` + open.arg(today) + `::DATE),
+			COUNT(*) FILTER (WHERE ` + pendingDue + utcDate("due_date") + ` BETWEEN ` + open.arg(today) + `::DATE AND ` + open.arg(weekLater) + `::DATE),
+			COUNT(*) FILTER (WHERE ` + inboxCondition + `)
+		FROM todos` + open.whereSQL()
+	if err := s.conn.QueryRowContext(ctx, query, open.args...).Scan(&stats.Overdue, &stats.Today, &stats.ThisWeek, &stats.Inbox); err != nil {
+		return nil, fmt.Errorf("查询统计信息失败：%w", err)
+	}
+
+	if stats.OverdueBuckets, err = s.overdueBucketsContext(ctx, q, now); err != nil {
+		return nil, err
+	}
+	if stats.CompletionLatency, err = s.completionLatencyContext(ctx, q); err != nil {
+		return nil, err
+	}
+	if stats.Focus, err = s.focusScoreContext(ctx, q, now); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// CountTodosContext 统计当前工作区的待办事项数量（用于数量上限检查）
+func (s *Store) CountTodosContext(ctx context.Context) (int, error) {
+	var count int
+	err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE workspace_id = $1`,
+		storage.WorkspaceFromContext(ctx)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计待办事项失败：%w", err)
+	}
+	return count, nil
+}
+
+// CountTodosCreatedSinceContext 统计当前工作区在 since 之后创建的待办事项数量
+func (s *Store) CountTodosCreatedSinceContext(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE workspace_id = $1 AND created_at >= $2`,
+		storage.WorkspaceFromContext(ctx), since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计待办事项失败：%w", err)
+	}
+	return count, nil
+}
+
+// deleteSQL 批量删除每个 ID 执行的语句
+const deleteSQL = `DELETE FROM todos WHERE id = $1 AND workspace_id = $2`
+
+// completeSQL 批量完成每个 ID 执行的语句：只处理未完成（工作流中的非终态）的事项
+func (s *Store) completeSQL() string {
+	return `UPDATE todos SET status = 'completed', completed_at = $1, updated_at = $1
+		WHERE id = $2 AND ` + s.statuses.OpenSQL() + ` AND workspace_id = $3`
+}
+
+// BatchCompleteTodosContext 批量完成待办事项（全有或全无）
+func (s *Store) BatchCompleteTodosContext(ctx context.Context, ids []int) error {
+	now := s.clock.Now().UTC()
+	workspace := storage.WorkspaceFromContext(ctx)
+	return s.batchAll(ctx, ids, "不存在或已完成", func(tx *sql.Tx, id int) (sql.Result, error) {
+		return tx.ExecContext(ctx, s.completeSQL(), now, id, workspace)
+	})
+}
+
+// BatchDeleteTodosContext 批量删除待办事项（全有或全无）
+func (s *Store) BatchDeleteTodosContext(ctx context.Context, ids []int) error {
+	workspace := storage.WorkspaceFromContext(ctx)
+	return s.batchAll(ctx, ids, "不存在", func(tx *sql.Tx, id int) (sql.Result, error) {
+		return tx.ExecContext(ctx, deleteSQL, id, workspace)
+	})
+}
+
+// BatchCompleteTodosPartialContext 批量完成待办事项（部分成功，失败的 ID 记录在结果中）
+func (s *Store) BatchCompleteTodosPartialContext(ctx context.Context, ids []int) (*storage.BatchResult, error) {
+	now := s.clock.Now().UTC()
+	workspace := storage.WorkspaceFromContext(ctx)
+	return s.batchPartial(ctx, ids, "待办事项不存在或已完成", func(tx *sql.Tx, id int) (sql.Result, error) {
+		return tx.ExecContext(ctx, s.completeSQL(), now, id, workspace)
+	})
+}
+
+// BatchDeleteTodosPartialContext 批量删除待办事项（部分成功，失败的 ID 记录在结果中）
+func (s *Store) BatchDeleteTodosPartialContext(ctx context.Context, ids []int) (*storage.BatchResult, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	return s.batchPartial(ctx, ids, "待办事项不存在", func(tx *sql.Tx, id int) (sql.Result, error) {
+		return tx.ExecContext(ctx, deleteSQL, id, workspace)
+	})
+}
+
+// checkBatch 检查批量大小
+func (s *Store) checkBatch(ids []int) error {
+	if len(ids) > s.batchLimit {
+		return fmt.Errorf("批量操作最多支持%d个项目，当前：%d", s.batchLimit, len(ids))
+	}
+	return nil
+}
+
+// batchAll 在一个事务中对每个 ID 执行 exec，任何一个失败或没有影响到行都整体回滚
+func (s *Store) batchAll(ctx context.Context, ids []int, missing string, exec func(*sql.Tx, int) (sql.Result, error)) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.checkBatch(ids); err != nil {
+		return err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("事务回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	for _, id := range ids {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		var result sql.Result
+		if result, err = exec(tx, id); err != nil {
+			return fmt.Errorf("处理 ID %d 失败：%w", id, err)
+		}
+		var rows int64
+		if rows, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("获取影响行数失败：%w", err)
+		}
+		if rows == 0 {
+			err = fmt.Errorf("待办事项 %d %s", id, missing)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	return nil
+}
+
+// batchPartial 对每个 ID 执行 exec，失败的记录在结果中，成功的照常提交
+// 每个 ID 使用独立的保存点：PostgreSQL 中一条语句出错后整个事务都会中止，回滚到保存点才能继续
+func (s *Store) batchPartial(ctx context.Context, ids []int, missing string, exec func(*sql.Tx, int) (sql.Result, error)) (result *storage.BatchResult, err error) {
+	if len(ids) == 0 {
+		return &storage.BatchResult{}, nil
+	}
+	if err := s.checkBatch(ids); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败: %v (原始错误: %v)", rbErr, err)
+			}
+		}
+	}()
+
+	result = &storage.BatchResult{Errors: make([]storage.BatchError, 0)}
+	for _, id := range ids {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err = tx.ExecContext(ctx, "SAVEPOINT batch_item"); err != nil {
+			return nil, fmt.Errorf("创建保存点失败：%w", err)
+		}
+
+		res, execErr := exec(tx, id)
+		var rows int64
+		if execErr == nil {
+			rows, execErr = res.RowsAffected()
+		}
+		switch {
+		case execErr != nil:
+			if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+				return nil, fmt.Errorf("回滚到保存点失败：%w", err)
+			}
+			result.FailedCount++
+			result.Errors = append(result.Errors, storage.BatchError{ID: id, Error: execErr.Error()})
+		case rows == 0:
+			result.FailedCount++
+			result.Errors = append(result.Errors, storage.BatchError{ID: id, Error: missing})
+		default:
+			result.SuccessCount++
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// ImportTodosContext 在一个事务中导入待办事项，空标题的跳过
+// 数据中的外部标识格式正确且没有被占用时沿用，否则重新生成
+func (s *Store) ImportTodosContext(ctx context.Context, todos []model.Todo) (imported int, err error) {
+	if len(todos) == 0 {
+		return 0, nil
+	}
+	if len(todos) > storage.MaxImportSize {
+		return 0, fmt.Errorf("单次导入最多 %d 条，当前：%d", storage.MaxImportSize, len(todos))
+	}
+
+	workspace := storage.WorkspaceFromContext(ctx)
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败: %v (原始错误: %v)", rbErr, err)
+			}
+		}
+	}()
+
+	var stmt *sql.Stmt
+	stmt, err = tx.PrepareContext(ctx, `
+		INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+		                   latitude, longitude, radius, estimated_minutes, priority, public_id, workspace_id,
+		                   recurrence, recurrence_start, completed_at, project_id, secret_note, secret_note_key_hint)
+		VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("准备语句失败：%w", err)
+	}
+	defer stmt.Close()
+
+	now := s.clock.Now().UTC()
+	for _, todo := range todos {
+		if err = ctx.Err(); err != nil {
+			return imported, err
+		}
+		if todo.Title == "" {
+			continue // 跳过无效数据
+		}
+		if todo.Status == "" {
+			todo.Status = "pending"
+		}
+		if todo.CreatedAt.IsZero() {
+			todo.CreatedAt = now
+		}
+		todo.UpdatedAt = now
+		if err = claimPublicID(ctx, tx, &todo); err != nil {
+			return imported, err
+		}
+
+		note, hint := noteColumns(todo.SecretNote)
+		_, err = stmt.ExecContext(ctx, todo.Title, todo.Description, todo.Status, todo.DueDate, todo.CreatedAt,
+			todo.UpdatedAt, todo.Latitude, todo.Longitude, todo.Radius, todo.EstimatedMinutes, todo.Priority,
+			todo.PublicID, workspace, nullString(todo.Recurrence), todo.RecurrenceStart, todo.CompletedAt,
+			todo.ProjectID, note, hint)
+		if err != nil {
+			return imported, fmt.Errorf("插入第 %d 条失败：%w", imported+1, err)
+		}
+		imported++
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败：%w", err)
+	}
+	return imported, nil
+}
+
+// claimPublicID 导入时尽量沿用数据中的外部标识；格式不对或已被占用时重新生成
+func claimPublicID(ctx context.Context, tx *sql.Tx, todo *model.Todo) error {
+	if id, ok := model.NormalizePublicID(todo.PublicID); ok {
+		var taken bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM todos WHERE public_id = $1)`, id).Scan(&taken); err != nil {
+			return fmt.Errorf("检查 public_id 失败：%w", err)
+		}
+		if !taken {
+			todo.PublicID = id
+			return nil
+		}
+	}
+	todo.PublicID = model.NewPublicID(todo.CreatedAt)
+	return nil
+}
+
+// ExportTodosContext 导出当前工作区的所有待办事项，按创建时间倒序
+func (s *Store) ExportTodosContext(ctx context.Context) ([]model.Todo, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+todoColumns+` FROM todos
+		WHERE workspace_id = $1 ORDER BY created_at DESC`, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询失败：%w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		todos = append(todos, *todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return todos, nil
+}
@@ -0,0 +1,349 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// maxWebhookDeliveriesLimit 推送记录每次最多返回的条数
+const maxWebhookDeliveriesLimit = 100
+
+// webhooksSchema 出站 webhook 和推送记录，与 SQLite 相同：
+// last_event_id 是已处理到的变更事件 ID，新建的 webhook 从创建时的最新事件开始；webhook 删除后推送记录随之删除
+const webhooksSchema = `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id SERIAL PRIMARY KEY,
+		workspace_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		events TEXT NOT NULL DEFAULT '',
+		project_id INTEGER,
+		min_priority INTEGER,
+		secret TEXT NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		last_event_id BIGINT NOT NULL DEFAULT 0,
+		last_status INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		last_delivery_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhooks_workspace ON webhooks(workspace_id);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id SERIAL PRIMARY KEY,
+		webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		workspace_id TEXT NOT NULL,
+		delivery TEXT NOT NULL,
+		event TEXT NOT NULL,
+		event_id BIGINT NOT NULL DEFAULT 0,
+		attempt INTEGER NOT NULL DEFAULT 1,
+		success BOOLEAN NOT NULL DEFAULT FALSE,
+		status INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		response TEXT NOT NULL DEFAULT '',
+		payload BYTEA,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_delivery ON webhook_deliveries(webhook_id, delivery);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
+`
+
+// webhookColumns 查询 webhook 的列，与 scanWebhook 的顺序一致
+const webhookColumns = `id, url, events, project_id, min_priority, secret, active, last_status, last_error, last_delivery_at, created_at, updated_at`
+
+// webhookDeliveryColumns 查询推送记录的列，与 scanWebhookDelivery 的顺序一致
+const webhookDeliveryColumns = `id, webhook_id, delivery, event, event_id, attempt, success, status, error, latency_ms, response, payload, created_at`
+
+// scanWebhook 扫描一行 webhook（列顺序见 webhookColumns），extra 为追加在后面的列
+func scanWebhook(s rowScanner, extra ...interface{}) (*model.Webhook, error) {
+	var w model.Webhook
+	var events string
+	var projectID, minPriority sql.NullInt64
+	var lastDeliveryAt sql.NullTime
+	dest := []interface{}{&w.ID, &w.URL, &events, &projectID, &minPriority, &w.Secret, &w.Active, &w.LastStatus, &w.LastError,
+		&lastDeliveryAt, &w.CreatedAt, &w.UpdatedAt}
+	if err := s.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	w.Events = []string{}
+	if events != "" {
+		w.Events = strings.Split(events, ",")
+	}
+	if projectID.Valid {
+		id := int(projectID.Int64)
+		w.ProjectID = &id
+	}
+	if minPriority.Valid {
+		p := int(minPriority.Int64)
+		w.MinPriority = &p
+	}
+	w.LastDeliveryAt = utcTime(lastDeliveryAt)
+	w.CreatedAt, w.UpdatedAt = w.CreatedAt.UTC(), w.UpdatedAt.UTC()
+	return &w, nil
+}
+
+// scanWebhookDelivery 扫描一行推送记录（列顺序见 webhookDeliveryColumns）
+func scanWebhookDelivery(s rowScanner) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := s.Scan(&d.ID, &d.WebhookID, &d.Delivery, &d.Event, &d.EventID, &d.Attempt, &d.Success, &d.Status,
+		&d.Error, &d.LatencyMS, &d.Response, &d.Payload, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	d.CreatedAt = d.CreatedAt.UTC()
+	return &d, nil
+}
+
+// CreateWebhookContext 在当前工作区创建 webhook，从现有的最新变更事件之后开始推送
+func (s *Store) CreateWebhookContext(ctx context.Context, w *model.Webhook) error {
+	now := s.clock.Now().UTC()
+	w.CreatedAt, w.UpdatedAt = now, now
+	err := s.conn.QueryRowContext(ctx, `
+		INSERT INTO webhooks (workspace_id, url, events, project_id, min_priority, secret, active, last_event_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, `+lastEventIDSQL+`, $8, $8)
+		RETURNING id
+	`, storage.WorkspaceFromContext(ctx), w.URL, strings.Join(w.Events, ","), w.ProjectID, w.MinPriority, w.Secret,
+		w.Active, now).Scan(&w.ID)
+	if err != nil {
+		return fmt.Errorf("保存 webhook 失败：%w", err)
+	}
+	return nil
+}
+
+// GetWebhookContext 查询当前工作区的 webhook，不存在时返回 storage.ErrNotFound
+func (s *Store) GetWebhookContext(ctx context.Context, id int) (*model.Webhook, error) {
+	row := s.conn.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks
+		WHERE id = $1 AND workspace_id = $2`, id, storage.WorkspaceFromContext(ctx))
+	w, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("webhook %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 失败：%w", err)
+	}
+	return w, nil
+}
+
+// ListWebhooksContext 当前工作区的所有 webhook
+func (s *Store) ListWebhooksContext(ctx context.Context) ([]model.Webhook, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks
+		WHERE workspace_id = $1 ORDER BY id ASC`, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 失败：%w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		webhooks = append(webhooks, *w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhookContext 更新当前工作区 webhook 的 URL、过滤条件和启用状态
+// 从停用恢复启用时推送进度跳到最新事件，停用期间的变更不补发
+func (s *Store) UpdateWebhookContext(ctx context.Context, w *model.Webhook) error {
+	w.UpdatedAt = s.clock.Now().UTC()
+	result, err := s.conn.ExecContext(ctx, `
+		UPDATE webhooks SET url = $1, events = $2, project_id = $3, min_priority = $4, active = $5, updated_at = $6,
+			last_event_id = CASE WHEN NOT active AND $5 THEN `+lastEventIDSQL+` ELSE last_event_id END
+		WHERE id = $7 AND workspace_id = $8
+	`, w.URL, strings.Join(w.Events, ","), w.ProjectID, w.MinPriority, w.Active, w.UpdatedAt, w.ID, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("更新 webhook 失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook %d: %w", w.ID, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteWebhookContext 删除当前工作区的 webhook，已在重试队列中的推送随之放弃
+func (s *Store) DeleteWebhookContext(ctx context.Context, id int) error {
+	result, err := s.conn.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND workspace_id = $2`,
+		id, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除 webhook 失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook %d: %w", id, storage.ErrNotFound)
+	}
+	return nil
+}
+
+// CountWebhooksContext 统计当前工作区的 webhook 数量（用于数量上限检查）
+func (s *Store) CountWebhooksContext(ctx context.Context) (int, error) {
+	var count int
+	err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhooks WHERE workspace_id = $1`,
+		storage.WorkspaceFromContext(ctx)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计 webhook 失败：%w", err)
+	}
+	return count, nil
+}
+
+// ListWebhookTargetsContext 所有工作区中启用的 webhook 及其推送进度
+func (s *Store) ListWebhookTargetsContext(ctx context.Context) ([]storage.WebhookTarget, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+webhookColumns+`, workspace_id, last_event_id FROM webhooks
+		WHERE active ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 失败：%w", err)
+	}
+	defer rows.Close()
+
+	var targets []storage.WebhookTarget
+	for rows.Next() {
+		var t storage.WebhookTarget
+		w, err := scanWebhook(rows, &t.Workspace, &t.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		t.Webhook = *w
+		targets = append(targets, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return targets, nil
+}
+
+// AdvanceWebhookContext 记录 webhook 已处理到的变更事件 ID（不会回退）
+func (s *Store) AdvanceWebhookContext(ctx context.Context, id int, eventID int64) error {
+	if _, err := s.conn.ExecContext(ctx, `
+		UPDATE webhooks SET last_event_id = GREATEST(last_event_id, $1) WHERE id = $2
+	`, eventID, id); err != nil {
+		return fmt.Errorf("更新 webhook 推送进度失败：%w", err)
+	}
+	return nil
+}
+
+// RecordWebhookDeliveryContext 保存一次推送尝试，同时更新 webhook 最近一次推送的结果
+// 写入后 d 的 ID、Attempt（同一推送 ID 的第几次）和 CreatedAt 被填充；webhook 已被删除时返回错误
+func (s *Store) RecordWebhookDeliveryContext(ctx context.Context, d *model.WebhookDelivery) error {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = s.clock.Now()
+	}
+	d.CreatedAt = d.CreatedAt.UTC()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		// 先锁住 webhook 这一行，同一推送 ID 的并发尝试依次计算 attempt
+		result, err := tx.ExecContext(ctx, `
+			UPDATE webhooks SET last_status = $1, last_error = $2, last_delivery_at = $3 WHERE id = $4
+		`, d.Status, d.Error, d.CreatedAt, d.WebhookID)
+		if err != nil {
+			return fmt.Errorf("记录 webhook 推送结果失败：%w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("webhook %d: %w", d.WebhookID, storage.ErrNotFound)
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO webhook_deliveries (webhook_id, workspace_id, delivery, event, event_id, attempt, success, status, error,
+				latency_ms, response, payload, created_at)
+			VALUES ($1, $2, $3, $4, $5, (SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1 AND delivery = $3) + 1,
+				$6, $7, $8, $9, $10, $11, $12)
+			RETURNING id, attempt
+		`, d.WebhookID, storage.WorkspaceFromContext(ctx), d.Delivery, d.Event, d.EventID,
+			d.Success, d.Status, d.Error, d.LatencyMS, d.Response, d.Payload, d.CreatedAt).Scan(&d.ID, &d.Attempt)
+		if err != nil {
+			return fmt.Errorf("记录 webhook 推送失败：%w", err)
+		}
+		return nil
+	})
+}
+
+// ListWebhookDeliveriesContext 当前工作区中 webhook 的推送记录，最新的在前
+// failedOnly 为 true 时只返回失败的尝试；limit 超出范围时使用上限
+func (s *Store) ListWebhookDeliveriesContext(ctx context.Context, webhookID int, failedOnly bool, limit int) ([]model.WebhookDelivery, error) {
+	if limit <= 0 || limit > maxWebhookDeliveriesLimit {
+		limit = maxWebhookDeliveriesLimit
+	}
+
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = $1 AND workspace_id = $2`
+	if failedOnly {
+		query += " AND NOT success"
+	}
+	query += " ORDER BY id DESC LIMIT $3"
+
+	rows, err := s.conn.QueryContext(ctx, query, webhookID, storage.WorkspaceFromContext(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 推送记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDeliveryContext 查询当前工作区中 webhook 的一条推送记录，不存在时返回 storage.ErrNotFound
+// succeeded 表示同一推送 ID 是否已经有成功的尝试
+func (s *Store) GetWebhookDeliveryContext(ctx context.Context, webhookID, id int) (d *model.WebhookDelivery, succeeded bool, err error) {
+	row := s.conn.QueryRowContext(ctx, `SELECT `+webhookDeliveryColumns+`,
+			EXISTS (SELECT 1 FROM webhook_deliveries o WHERE o.webhook_id = d.webhook_id AND o.delivery = d.delivery AND o.success)
+		FROM webhook_deliveries d
+		WHERE id = $1 AND webhook_id = $2 AND workspace_id = $3`, id, webhookID, storage.WorkspaceFromContext(ctx))
+	d, err = scanWebhookDelivery(&appendScanner{row, &succeeded})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("webhook delivery %d: %w", id, storage.ErrNotFound)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("查询 webhook 推送记录失败：%w", err)
+	}
+	return d, succeeded, nil
+}
+
+// PurgeWebhookDeliveriesContext 删除 before 之前的 webhook 推送记录
+func (s *Store) PurgeWebhookDeliveriesContext(ctx context.Context, before time.Time) (int, error) {
+	return s.purge(ctx, `DELETE FROM webhook_deliveries WHERE created_at < $1`, before, "清理 webhook 推送记录失败")
+}
+
+// appendScanner 在 Scan 的目标后面追加 extra 列
+type appendScanner struct {
+	rowScanner
+	extra interface{}
+}
+
+func (s *appendScanner) Scan(dest ...interface{}) error {
+	return s.rowScanner.Scan(append(dest, s.extra)...)
+}
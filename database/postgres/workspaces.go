@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// workspacesSchema 工作区表，默认工作区总是存在
+const workspacesSchema = `
+	CREATE TABLE IF NOT EXISTS workspaces (
+		slug TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		max_todos INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	INSERT INTO workspaces (slug, name, max_todos, created_at)
+	VALUES ('` + model.DefaultWorkspace + `', 'Default', 0, now())
+	ON CONFLICT (slug) DO NOTHING;
+`
+
+// CreateWorkspaceContext 创建工作区，标识已被占用时返回 storage.ErrWorkspaceExists
+func (s *Store) CreateWorkspaceContext(ctx context.Context, ws *model.Workspace) error {
+	ws.CreatedAt = s.clock.Now().UTC()
+	_, err := s.conn.ExecContext(ctx, `
+		INSERT INTO workspaces (slug, name, max_todos, created_at) VALUES ($1, $2, $3, $4)
+	`, ws.Slug, ws.Name, ws.MaxTodos, ws.CreatedAt)
+	if isUniqueViolation(err) {
+		return storage.ErrWorkspaceExists
+	}
+	if err != nil {
+		return fmt.Errorf("创建工作区失败：%w", err)
+	}
+	return nil
+}
+
+// GetWorkspaceContext 获取工作区，不存在时返回 nil
+func (s *Store) GetWorkspaceContext(ctx context.Context, slug string) (*model.Workspace, error) {
+	var ws model.Workspace
+	err := s.conn.QueryRowContext(ctx, `
+		SELECT slug, name, max_todos, created_at FROM workspaces WHERE slug = $1
+	`, slug).Scan(&ws.Slug, &ws.Name, &ws.MaxTodos, &ws.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询工作区失败：%w", err)
+	}
+	ws.CreatedAt = ws.CreatedAt.UTC()
+	return &ws, nil
+}
+
+// ListWorkspacesContext 获取所有工作区，按创建时间排列
+func (s *Store) ListWorkspacesContext(ctx context.Context) ([]model.Workspace, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT slug, name, max_todos, created_at FROM workspaces ORDER BY created_at ASC, slug ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询工作区失败：%w", err)
+	}
+	defer rows.Close()
+
+	workspaces := make([]model.Workspace, 0)
+	for rows.Next() {
+		var ws model.Workspace
+		if err := rows.Scan(&ws.Slug, &ws.Name, &ws.MaxTodos, &ws.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		ws.CreatedAt = ws.CreatedAt.UTC()
+		workspaces = append(workspaces, ws)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return workspaces, nil
+}
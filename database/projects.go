@@ -0,0 +1,439 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// ErrProjectExists 同一工作区中已有同名项目
+var ErrProjectExists = storage.ErrProjectExists
+
+// ErrTargetProjectNotFound 移动待办事项的目标项目不存在
+var ErrTargetProjectNotFound = storage.ErrTargetProjectNotFound
+
+// initProjectsSchema 初始化项目表，todos.project_id 指向项目，为空表示不属于任何项目
+// 项目归档后其中的待办事项 hidden = 1，默认不出现在列表中；移入或移出项目时由触发器重新计算
+func (db *DB) initProjectsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id TEXT NOT NULL DEFAULT 'default',
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		archived_at DATETIME
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_name ON projects(workspace_id, name COLLATE NOCASE);
+	CREATE INDEX IF NOT EXISTS idx_todos_project ON todos(project_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init projects table: %w", err)
+	}
+
+	// 旧版本建立的表没有归档时间和 hidden 列
+	if err := db.ensureTableColumn("projects", "archived_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := db.ensureColumn("hidden", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	triggers := `
+	DROP TRIGGER IF EXISTS trg_todos_project_hidden_insert;
+	CREATE TRIGGER trg_todos_project_hidden_insert AFTER INSERT ON todos
+	WHEN NEW.project_id IS NOT NULL
+	BEGIN
+		UPDATE todos SET hidden = EXISTS (SELECT 1 FROM projects WHERE id = NEW.project_id AND archived_at IS NOT NULL)
+		WHERE id = NEW.id;
+	END;
+
+	DROP TRIGGER IF EXISTS trg_todos_project_hidden_update;
+	CREATE TRIGGER trg_todos_project_hidden_update AFTER UPDATE OF project_id ON todos
+	WHEN OLD.project_id IS NOT NEW.project_id
+	BEGIN
+		UPDATE todos SET hidden = EXISTS (SELECT 1 FROM projects WHERE id = NEW.project_id AND archived_at IS NOT NULL)
+		WHERE id = NEW.id;
+	END;
+	`
+	if _, err := db.conn.Exec(triggers); err != nil {
+		return fmt.Errorf("failed to init project triggers: %w", err)
+	}
+	return nil
+}
+
+// projectQuery 查询项目及其待办事项数量，数量读取 todo_project_counters，不扫描 todos
+// 未完成的数量按工作流的未完成状态统计
+func (db *DB) projectQuery() string {
+	return `
+	SELECT p.id, p.name, p.description, p.created_at, p.updated_at, p.archived_at,
+	       COALESCE(SUM(c.count), 0), COALESCE(SUM(CASE WHEN ` + db.statuses.OpenSQL() + ` THEN c.count ELSE 0 END), 0)
+	FROM projects p
+	LEFT JOIN todo_project_counters c ON c.project_id = p.id
+	WHERE p.workspace_id = ?`
+}
+
+// scanProject 扫描一行项目（列顺序见 projectQuery），没有结果时原样返回 sql.ErrNoRows
+func scanProject(s rowScanner) (*model.Project, error) {
+	var p model.Project
+	var archivedAt sql.NullTime
+	if err := s.Scan(&p.ID, &p.Name, &p.Description, &p.CreatedAt, &p.UpdatedAt, &archivedAt, &p.TodoCount, &p.OpenCount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("扫描失败：%w", err)
+	}
+	if archivedAt.Valid {
+		p.ArchivedAt = &archivedAt.Time
+	}
+	return &p, nil
+}
+
+// projectWriteError 名称重复时返回 ErrProjectExists
+func projectWriteError(err error, action string) error {
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrProjectExists
+	}
+	return fmt.Errorf("%s项目失败：%w", action, err)
+}
+
+// CreateProjectContext 保存项目
+func (db *DB) CreateProjectContext(ctx context.Context, project *model.Project) error {
+	now := db.clock.Now().UTC()
+	project.CreatedAt, project.UpdatedAt = now, now
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO projects (workspace_id, name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, WorkspaceFromContext(ctx), project.Name, project.Description, project.CreatedAt, project.UpdatedAt)
+	if err != nil {
+		return projectWriteError(err, "保存")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取项目 ID 失败：%w", err)
+	}
+	project.ID = int(id)
+	return nil
+}
+
+// GetProjectContext 获取当前工作区的项目，不存在时返回 ErrNotFound
+func (db *DB) GetProjectContext(ctx context.Context, id int) (*model.Project, error) {
+	row := db.conn.QueryRowContext(ctx, db.projectQuery()+` AND p.id = ? GROUP BY p.id`, WorkspaceFromContext(ctx), id)
+	project, err := scanProject(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("project %d: %w", id, ErrNotFound)
+	}
+	return project, err
+}
+
+// ListProjectsContext 获取当前工作区的项目，按名称排列
+func (db *DB) ListProjectsContext(ctx context.Context) ([]model.Project, error) {
+	rows, err := db.conn.QueryContext(ctx, db.projectQuery()+`
+		GROUP BY p.id
+		ORDER BY p.name COLLATE NOCASE ASC, p.id ASC
+	`, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询项目失败：%w", err)
+	}
+	defer rows.Close()
+
+	projects := make([]model.Project, 0)
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return projects, nil
+}
+
+// UpdateProjectContext 修改项目名称和描述，项目不存在时返回 ErrNotFound
+func (db *DB) UpdateProjectContext(ctx context.Context, project *model.Project) error {
+	project.UpdatedAt = db.clock.Now().UTC()
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE projects SET name = ?, description = ?, updated_at = ?
+		WHERE id = ? AND workspace_id = ?
+	`, project.Name, project.Description, project.UpdatedAt, project.ID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return projectWriteError(err, "更新")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("project %d: %w", project.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteProjectContext 删除项目，项目中的待办事项保留，改为不属于任何项目
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) DeleteProjectContext(ctx context.Context, id int) (err error) {
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = ? AND workspace_id = ?`, id, workspace)
+	if err != nil {
+		return fmt.Errorf("删除项目失败：%w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("project %d: %w", id, ErrNotFound)
+	}
+
+	// 记下移出的待办事项，提交后让它们的缓存失效
+	var todoIDs []int
+	err = queryEach(ctx, tx, func(s rowScanner) error {
+		var todoID int
+		if err := s.Scan(&todoID); err != nil {
+			return err
+		}
+		todoIDs = append(todoIDs, todoID)
+		return nil
+	}, `SELECT id FROM todos WHERE project_id = ? AND workspace_id = ?`, id, workspace)
+	if err != nil {
+		return fmt.Errorf("查询项目中的待办事项失败：%w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE todos SET project_id = NULL, updated_at = ?, version = version + 1
+		WHERE project_id = ? AND workspace_id = ?
+	`, db.clock.Now().UTC(), id, workspace); err != nil {
+		return fmt.Errorf("移出项目中的待办事项失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+
+	db.invalidateTodos(ctx, todoIDs...)
+	return nil
+}
+
+// CheckProjectContext 确认项目存在于当前工作区，不存在时返回 ErrNotFound
+func (db *DB) CheckProjectContext(ctx context.Context, id int) error {
+	var exists int
+	err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM projects WHERE id = ? AND workspace_id = ?`,
+		id, WorkspaceFromContext(ctx)).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("查询项目失败：%w", err)
+	}
+	if exists == 0 {
+		return fmt.Errorf("project %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// EnsureProjectContext 按名称（不区分大小写）查找当前工作区的项目，不存在时创建，返回项目 ID
+// 名称需要调用方先用 model.Project.Validate 规范化
+func (db *DB) EnsureProjectContext(ctx context.Context, name string) (int, error) {
+	var id int
+	err := db.conn.QueryRowContext(ctx, `SELECT id FROM projects WHERE workspace_id = ? AND name = ? COLLATE NOCASE`,
+		WorkspaceFromContext(ctx), name).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("查询项目失败：%w", err)
+	}
+
+	project := &model.Project{Name: name}
+	if err := db.CreateProjectContext(ctx, project); err != nil {
+		return 0, err
+	}
+	return project.ID, nil
+}
+
+// SetProjectArchivedContext 归档或取消归档项目，在同一个事务中把项目中待办事项的 hidden 改为相应的值
+// 项目不存在时返回 ErrNotFound；已经是目标状态时不做修改
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) SetProjectArchivedContext(ctx context.Context, id int, archived bool) (err error) {
+	workspace := WorkspaceFromContext(ctx)
+	now := db.clock.Now().UTC()
+	var archivedAt interface{}
+	if archived {
+		archivedAt = now
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	if err = checkProjectTx(ctx, tx, id, workspace); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE projects SET archived_at = ?, updated_at = ?
+		WHERE id = ? AND workspace_id = ? AND (archived_at IS NULL) = ?
+	`, archivedAt, now, id, workspace, archived); err != nil {
+		return fmt.Errorf("归档项目失败：%w", err)
+	}
+
+	todoIDs, err := updateTodosReturning(ctx, tx, `
+		UPDATE todos SET hidden = ?, updated_at = ?, version = version + 1
+		WHERE project_id = ? AND workspace_id = ? AND hidden != ?
+		RETURNING id
+	`, archived, now, id, workspace, archived)
+	if err != nil {
+		return fmt.Errorf("更新项目中的待办事项失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	db.invalidateTodos(ctx, todoIDs...)
+	return nil
+}
+
+// CompleteProjectTodosContext 在一个事务中完成项目中所有未完成（工作流中的非终态）的待办事项，返回完成的 ID
+// 与批量完成一样状态改为 completed；项目不存在时返回 ErrNotFound
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) CompleteProjectTodosContext(ctx context.Context, id int) (todoIDs []int, err error) {
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	if err = checkProjectTx(ctx, tx, id, workspace); err != nil {
+		return nil, err
+	}
+	now := db.clock.Now().UTC()
+	todoIDs, err = updateTodosReturning(ctx, tx, `
+		UPDATE todos SET status = 'completed', completed_at = ?, updated_at = ?, version = version + 1
+		WHERE project_id = ? AND workspace_id = ? AND `+db.statuses.OpenSQL()+`
+		RETURNING id
+	`, now, now, id, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("完成项目中的待办事项失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败：%w", err)
+	}
+	db.invalidateTodos(ctx, todoIDs...)
+	return todoIDs, nil
+}
+
+// MoveProjectTodosContext 在一个事务中把项目中的所有待办事项移到目标项目，target 为 0 表示移出项目，返回移动的 ID
+// 来源项目不存在时返回 ErrNotFound，目标项目不存在时返回 ErrTargetProjectNotFound
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) MoveProjectTodosContext(ctx context.Context, id, target int) (todoIDs []int, err error) {
+	workspace := WorkspaceFromContext(ctx)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	if err = checkProjectTx(ctx, tx, id, workspace); err != nil {
+		return nil, err
+	}
+	var targetID interface{}
+	if target != 0 {
+		if err = checkProjectTx(ctx, tx, target, workspace); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				err = fmt.Errorf("project %d: %w", target, ErrTargetProjectNotFound)
+			}
+			return nil, err
+		}
+		targetID = target
+	}
+
+	// hidden 由触发器按目标项目是否归档重新计算
+	todoIDs, err = updateTodosReturning(ctx, tx, `
+		UPDATE todos SET project_id = ?, updated_at = ?, version = version + 1
+		WHERE project_id = ? AND workspace_id = ?
+		RETURNING id
+	`, targetID, db.clock.Now().UTC(), id, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("移动项目中的待办事项失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败：%w", err)
+	}
+	db.invalidateTodos(ctx, todoIDs...)
+	return todoIDs, nil
+}
+
+// checkProjectTx 在事务中确认项目存在于工作区，不存在时返回 ErrNotFound
+func checkProjectTx(ctx context.Context, tx *sql.Tx, id int, workspace string) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = ? AND workspace_id = ?)`,
+		id, workspace).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("查询项目失败：%w", err)
+	}
+	if !exists {
+		return fmt.Errorf("project %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// updateTodosReturning 执行带 RETURNING id 的 UPDATE，返回修改的待办事项 ID
+func updateTodosReturning(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	ids := make([]int, 0)
+	err := queryEach(ctx, tx, func(s rowScanner) error {
+		var id int
+		if err := s.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	}, query, args...)
+	return ids, err
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"todo-list/model"
+)
+
+// initPublicIDSchema 为还没有外部标识的待办事项补上 public_id，再建立唯一索引
+// 旧数据按创建时间生成，保证外部标识的顺序与创建顺序一致
+func (db *DB) initPublicIDSchema() error {
+	rows, err := db.conn.Query(`SELECT id, created_at FROM todos WHERE public_id IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to query todos without public_id: %w", err)
+	}
+	type pending struct {
+		id       int
+		publicID string
+	}
+	var missing []pending
+	for rows.Next() {
+		var id int
+		var createdAt string
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan todo: %w", err)
+		}
+		t, err := parseDBTime(createdAt)
+		if err != nil {
+			t = db.clock.Now()
+		}
+		missing = append(missing, pending{id: id, publicID: model.NewPublicID(t)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate todos: %w", err)
+	}
+
+	for _, p := range missing {
+		if _, err := db.conn.Exec(`UPDATE todos SET public_id = ? WHERE id = ?`, p.publicID, p.id); err != nil {
+			return fmt.Errorf("failed to backfill public_id: %w", err)
+		}
+	}
+	if len(missing) > 0 {
+		log.Printf("已为 %d 条待办事项生成 public_id", len(missing))
+	}
+
+	if _, err := db.conn.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_todos_public_id ON todos(public_id)`); err != nil {
+		return fmt.Errorf("failed to create public_id index: %w", err)
+	}
+	return nil
+}
+
+// ensurePublicID 新建的待办事项没有外部标识时按创建时间生成
+func ensurePublicID(todo *model.Todo) {
+	if todo.PublicID == "" {
+		todo.PublicID = model.NewPublicID(todo.CreatedAt)
+	}
+}
+
+// claimPublicID 导入时尽量沿用数据中的外部标识，方便客户端继续引用；
+// 格式不对或已被其他待办事项占用（例如同一份数据导入两次）时重新生成
+func claimPublicID(ctx context.Context, tx *sql.Tx, todo *model.Todo) error {
+	if id, ok := model.NormalizePublicID(todo.PublicID); ok {
+		var taken int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos WHERE public_id = ?`, id).Scan(&taken); err != nil {
+			return fmt.Errorf("检查 public_id 失败：%w", err)
+		}
+		if taken == 0 {
+			todo.PublicID = id
+			return nil
+		}
+	}
+	todo.PublicID = ""
+	ensurePublicID(todo)
+	return nil
+}
+
+// GetTodoIDByPublicIDContext 按外部标识查找当前工作区中待办事项的 ID，不存在时返回 ErrNotFound
+func (db *DB) GetTodoIDByPublicIDContext(ctx context.Context, publicID string) (int, error) {
+	var id int
+	err := db.conn.QueryRowContext(ctx, `SELECT id FROM todos WHERE public_id = ? AND workspace_id = ?`,
+		publicID, WorkspaceFromContext(ctx)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("todo %s: %w", publicID, ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询待办事项失败：%w", err)
+	}
+	return id, nil
+}
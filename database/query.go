@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"todo-list/storage"
+)
+
+// todoQuery 组合 todos 表的查询条件，列表、计数、导出和各种视图共用同一套 WHERE 拼接
+// 条件之间用 AND 连接，参数按条件添加的顺序排列；排序字段只能来自 todoSortFields，避免 SQL 注入
+type todoQuery struct {
+	conds []string
+	args  []interface{}
+}
+
+// newTodoQuery 不限工作区的查询（后台任务使用）
+func newTodoQuery() *todoQuery {
+	return &todoQuery{}
+}
+
+// scopedTodoQuery 只查询当前工作区的数据
+func scopedTodoQuery(ctx context.Context) *todoQuery {
+	return newTodoQuery().where("workspace_id = ?", WorkspaceFromContext(ctx))
+}
+
+// where 添加一个条件，cond 中的 ? 与 args 一一对应
+func (q *todoQuery) where(cond string, args ...interface{}) *todoQuery {
+	q.conds = append(q.conds, cond)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// filter 添加列表过滤条件（状态、关键字、位置），空值表示不过滤；PendingOnly 按 s 中的未完成状态判断
+func (q *todoQuery) filter(f TodoFilter, s storage.StatusSets) *todoQuery {
+	if f.Status != "" && f.Status != "all" {
+		q.where("status = ?", f.Status)
+	}
+	if f.Search != "" {
+		pattern := "%" + f.Search + "%"
+		q.where("(title LIKE ? OR description LIKE ?)", pattern, pattern)
+	}
+	if f.Near != nil {
+		q.where(nearClause, nearArgs(f)...)
+	}
+	if f.Priority != nil {
+		q.where("priority = ?", *f.Priority)
+	}
+	if f.MinPriority != nil {
+		q.where("priority >= ?", *f.MinPriority)
+	}
+	if f.ProjectID != nil {
+		if *f.ProjectID == 0 {
+			q.where("project_id IS NULL")
+		} else {
+			q.where("project_id = ?", *f.ProjectID)
+		}
+	}
+	if f.PendingOnly {
+		q.where(s.OpenSQL())
+	}
+	if f.DueDateFrom != "" {
+		q.where("due_date IS NOT NULL AND date(due_date) >= ?", f.DueDateFrom)
+	}
+	if f.DueDateTo != "" {
+		q.where("due_date IS NOT NULL AND date(due_date) <= ?", f.DueDateTo)
+	}
+	if f.DueBefore != nil {
+		q.where("due_date IS NOT NULL AND due_date < ?", *f.DueBefore)
+	}
+	if f.InboxOnly {
+		q.inbox()
+	}
+	if f.UpdatedBefore != nil {
+		q.where("julianday(updated_at) < julianday(?)", f.UpdatedBefore.UTC().Format("2006-01-02 15:04:05"))
+	}
+	return q
+}
+
+// staleSince 状态为 status 且 before 之后没有更新过（停滞事项提醒）
+func (q *todoQuery) staleSince(status string, before time.Time) *todoQuery {
+	return q.where("status = ?", status).
+		where("julianday(updated_at) < julianday(?)", before.UTC().Format("2006-01-02 15:04:05"))
+}
+
+// inboxCondition 收件箱：快速记录下来、还没有整理过的事项
+// 未进入终态、没有截止日期、不属于任何项目、也没有关联到任何目标；设置截止日期、移入项目或关联目标后自动离开收件箱
+const inboxCondition = `completed_at IS NULL AND due_date IS NULL AND project_id IS NULL
+	AND id NOT IN (SELECT todo_id FROM goal_todos)`
+
+// inbox 收件箱中的事项（收件箱视图）
+func (q *todoQuery) inbox() *todoQuery {
+	return q.where("(" + inboxCondition + ")")
+}
+
+// pendingWithDueDate 未完成（s 中的非终态）且有截止日期（工作量视图）
+func (q *todoQuery) pendingWithDueDate(s storage.StatusSets) *todoQuery {
+	return q.where(s.OpenSQL()).where("due_date IS NOT NULL")
+}
+
+// clone 复制一份查询，在副本上追加条件不影响原查询
+func (q *todoQuery) clone() *todoQuery {
+	return &todoQuery{
+		conds: append([]string(nil), q.conds...),
+		args:  q.queryArgs(),
+	}
+}
+
+// whereSQL 生成 WHERE 子句，没有条件时为空字符串
+func (q *todoQuery) whereSQL() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conds, " AND ")
+}
+
+// selectSQL SELECT columns FROM todos WHERE ...，suffix 追加在最后（ORDER BY、GROUP BY 等）
+func (q *todoQuery) selectSQL(columns, suffix string, args ...interface{}) (string, []interface{}) {
+	query := "SELECT " + columns + " FROM todos" + q.whereSQL()
+	if suffix != "" {
+		query += " " + suffix
+	}
+	return query, append(q.queryArgs(), args...)
+}
+
+// countSQL SELECT COUNT(*) FROM todos WHERE ...
+func (q *todoQuery) countSQL() (string, []interface{}) {
+	return q.selectSQL("COUNT(*)", "")
+}
+
+// pageSQL 列表分页查询，f 需要先经过 NormalizeFilter
+func (q *todoQuery) pageSQL(columns string, f TodoFilter) (string, []interface{}) {
+	// sort 和 order 已经在 NormalizeFilter 中按白名单校验过，可以安全拼接
+	order := f.Sort + " " + f.Order
+	if f.Sort == "position" {
+		// 没有调整过顺序的事项 position 都是 0，按 ID 排列
+		order += ", id " + f.Order
+	}
+	return q.selectSQL(columns, fmt.Sprintf("ORDER BY %s LIMIT ? OFFSET ?", order), f.Limit, f.Offset)
+}
+
+// queryArgs 返回参数的副本，多次生成 SQL 时互不影响
+func (q *todoQuery) queryArgs() []interface{} {
+	return append([]interface{}(nil), q.args...)
+}
+
+// NormalizeFilter 填充默认值并校验排序参数，见 storage.NormalizeFilter
+func NormalizeFilter(f TodoFilter) TodoFilter {
+	return storage.NormalizeFilter(f)
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+	"todo-list/storage"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestTodoQueryFilter(t *testing.T) {
+	before := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    TodoFilter
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "empty",
+			filter:    TodoFilter{},
+			wantWhere: " WHERE workspace_id = ?",
+			wantArgs:  []interface{}{"acme"},
+		},
+		{
+			name:      "status all is not a condition",
+			filter:    TodoFilter{Status: "all"},
+			wantWhere: " WHERE workspace_id = ?",
+			wantArgs:  []interface{}{"acme"},
+		},
+		{
+			name:      "status and search keep argument order",
+			filter:    TodoFilter{Status: "pending", Search: "milk"},
+			wantWhere: " WHERE workspace_id = ? AND status = ? AND (title LIKE ? OR description LIKE ?)",
+			wantArgs:  []interface{}{"acme", "pending", "%milk%", "%milk%"},
+		},
+		{
+			name:      "near without radius uses todo radius",
+			filter:    TodoFilter{Near: &GeoPoint{Lat: 31.2, Lng: 121.5}},
+			wantWhere: " WHERE workspace_id = ? AND " + nearClause,
+			wantArgs:  []interface{}{"acme", 31.2, 121.5, nil, DefaultRadiusMeters},
+		},
+		{
+			name:      "near with radius",
+			filter:    TodoFilter{Near: &GeoPoint{Lat: 1, Lng: 2}, RadiusMeters: 300},
+			wantWhere: " WHERE workspace_id = ? AND " + nearClause,
+			wantArgs:  []interface{}{"acme", 1.0, 2.0, 300.0, DefaultRadiusMeters},
+		},
+		{
+			name:      "priority and min priority",
+			filter:    TodoFilter{Priority: intPtr(2), MinPriority: intPtr(1)},
+			wantWhere: " WHERE workspace_id = ? AND priority = ? AND priority >= ?",
+			wantArgs:  []interface{}{"acme", 2, 1},
+		},
+		{
+			name:      "project zero means no project",
+			filter:    TodoFilter{ProjectID: intPtr(0)},
+			wantWhere: " WHERE workspace_id = ? AND project_id IS NULL",
+			wantArgs:  []interface{}{"acme"},
+		},
+		{
+			name:      "project id",
+			filter:    TodoFilter{ProjectID: intPtr(7)},
+			wantWhere: " WHERE workspace_id = ? AND project_id = ?",
+			wantArgs:  []interface{}{"acme", 7},
+		},
+		{
+			name: "view conditions",
+			filter: TodoFilter{PendingOnly: true, DueDateFrom: "2030-01-01", DueDateTo: "2030-01-07",
+				DueBefore: &before, InboxOnly: true, UpdatedBefore: &before},
+			wantWhere: " WHERE workspace_id = ? AND status IN ('pending')" +
+				" AND due_date IS NOT NULL AND date(due_date) >= ?" +
+				" AND due_date IS NOT NULL AND date(due_date) <= ?" +
+				" AND due_date IS NOT NULL AND due_date < ?" +
+				" AND (" + inboxCondition + ")" +
+				" AND julianday(updated_at) < julianday(?)",
+			wantArgs: []interface{}{"acme", "2030-01-01", "2030-01-07", before, "2030-01-02 03:04:05"},
+		},
+	}
+
+	ctx := WithWorkspace(context.Background(), "acme")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := scopedTodoQuery(ctx).filter(tt.filter, storage.DefaultStatusSets())
+			if got := q.whereSQL(); got != tt.wantWhere {
+				t.Errorf("whereSQL() = %q, want %q", got, tt.wantWhere)
+			}
+			if got := q.queryArgs(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestTodoQueryWhereSQLEmpty(t *testing.T) {
+	if got := newTodoQuery().whereSQL(); got != "" {
+		t.Errorf("whereSQL() = %q, want empty", got)
+	}
+	query, args := newTodoQuery().countSQL()
+	if query != "SELECT COUNT(*) FROM todos" || len(args) != 0 {
+		t.Errorf("countSQL() = %q, %v", query, args)
+	}
+}
+
+func TestTodoQueryPageSQL(t *testing.T) {
+	f := NormalizeFilter(TodoFilter{Status: "pending", Sort: "due_date", Order: "asc", Limit: 20, Offset: 40})
+	query, args := newTodoQuery().filter(f, storage.DefaultStatusSets()).pageSQL("id", f)
+
+	wantQuery := "SELECT id FROM todos WHERE status = ? ORDER BY due_date ASC LIMIT ? OFFSET ?"
+	if query != wantQuery {
+		t.Errorf("pageSQL() query = %q, want %q", query, wantQuery)
+	}
+	if want := []interface{}{"pending", 20, 40}; !reflect.DeepEqual(args, want) {
+		t.Errorf("pageSQL() args = %#v, want %#v", args, want)
+	}
+}
+
+func TestTodoQueryClone(t *testing.T) {
+	base := newTodoQuery().where("status = ?", "pending")
+	copied := base.clone().where("priority = ?", 3)
+
+	if got, want := base.whereSQL(), " WHERE status = ?"; got != want {
+		t.Errorf("base whereSQL() = %q, want %q", got, want)
+	}
+	if got := base.queryArgs(); !reflect.DeepEqual(got, []interface{}{"pending"}) {
+		t.Errorf("base args = %#v", got)
+	}
+	if got, want := copied.whereSQL(), " WHERE status = ? AND priority = ?"; got != want {
+		t.Errorf("clone whereSQL() = %q, want %q", got, want)
+	}
+	if got := copied.queryArgs(); !reflect.DeepEqual(got, []interface{}{"pending", 3}) {
+		t.Errorf("clone args = %#v", got)
+	}
+
+	// 生成的参数是副本，调用方追加参数不会影响查询本身
+	_, args := base.selectSQL("id", "LIMIT ?", 1)
+	args[0] = "changed"
+	if got := base.queryArgs(); !reflect.DeepEqual(got, []interface{}{"pending"}) {
+		t.Errorf("selectSQL modified query args: %#v", got)
+	}
+}
+
+func TestNormalizeFilter(t *testing.T) {
+	tests := []struct {
+		name              string
+		in                TodoFilter
+		sort, order, stat string
+		limit             int
+	}{
+		{"defaults", TodoFilter{}, "created_at", "DESC", "all", 50},
+		{"whitelisted sort", TodoFilter{Sort: "priority", Order: "asc", Limit: 10}, "priority", "ASC", "all", 10},
+		{"unknown sort falls back", TodoFilter{Sort: "title; DROP TABLE todos"}, "created_at", "DESC", "all", 50},
+		{"unknown order falls back", TodoFilter{Sort: "due_date", Order: "sideways"}, "due_date", "DESC", "all", 50},
+		{"status kept", TodoFilter{Status: "completed", Limit: -1}, "created_at", "DESC", "completed", 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeFilter(tt.in)
+			if got.Sort != tt.sort || got.Order != tt.order || got.Status != tt.stat || got.Limit != tt.limit {
+				t.Errorf("NormalizeFilter() = sort %q order %q status %q limit %d, want %q %q %q %d",
+					got.Sort, got.Order, got.Status, got.Limit, tt.sort, tt.order, tt.stat, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// 访问记录的类型
+const (
+	AccessViewed   = "viewed"   // 查看了详情
+	AccessModified = "modified" // 创建或修改
+)
+
+// maxAccessPerUser 每个用户最多保留的访问记录，更早的记录在写入时清理
+const maxAccessPerUser = 200
+
+// RecentTodo 最近访问过的待办事项
+type RecentTodo struct {
+	Todo       model.Todo `json:"todo"`
+	ViewedAt   *time.Time `json:"viewed_at,omitempty"`   // 最近一次查看
+	ModifiedAt *time.Time `json:"modified_at,omitempty"` // 最近一次创建或修改
+}
+
+// initAccessSchema 初始化访问记录表：每个用户每个待办事项一行，只保留最近一次查看和修改的时间
+func (db *DB) initAccessSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_access (
+		user_id TEXT NOT NULL,
+		todo_id INTEGER NOT NULL,
+		viewed_at DATETIME,
+		modified_at DATETIME,
+		accessed_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, todo_id),
+		FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_access_user ON todo_access(user_id, accessed_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_access table: %w", err)
+	}
+	return nil
+}
+
+// RecordAccessContext 记录用户查看或修改了待办事项，kind 为 AccessViewed 或 AccessModified
+func (db *DB) RecordAccessContext(ctx context.Context, userID string, todoID int, kind string) error {
+	column := "viewed_at"
+	if kind == AccessModified {
+		column = "modified_at"
+	}
+
+	now := db.clock.Now().UTC()
+	if _, err := db.conn.ExecContext(ctx, `
+		INSERT INTO todo_access (user_id, todo_id, `+column+`, accessed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, todo_id) DO UPDATE SET `+column+` = excluded.accessed_at, accessed_at = excluded.accessed_at
+	`, userID, todoID, now, now); err != nil {
+		return fmt.Errorf("保存访问记录失败：%w", err)
+	}
+
+	if _, err := db.conn.ExecContext(ctx, `
+		DELETE FROM todo_access WHERE user_id = ? AND todo_id NOT IN (
+			SELECT todo_id FROM todo_access WHERE user_id = ? ORDER BY accessed_at DESC LIMIT ?
+		)
+	`, userID, userID, maxAccessPerUser); err != nil {
+		return fmt.Errorf("清理访问记录失败：%w", err)
+	}
+	return nil
+}
+
+// withAccess 在待办事项的列之后多扫描查看时间和修改时间
+type withAccess struct {
+	rowScanner
+	viewedAt, modifiedAt *sql.NullTime
+}
+
+func (w withAccess) Scan(dest ...interface{}) error {
+	return w.rowScanner.Scan(append(dest, w.viewedAt, w.modifiedAt)...)
+}
+
+// ListRecentTodosContext 当前工作区中用户最近访问过的待办事项，最近的在前
+// kind 为 AccessViewed 或 AccessModified 时只按该类访问排序和过滤，为空时两类都算
+func (db *DB) ListRecentTodosContext(ctx context.Context, userID, kind string, limit int) ([]RecentTodo, error) {
+	order := "a.accessed_at"
+	switch kind {
+	case AccessViewed:
+		order = "a.viewed_at"
+	case AccessModified:
+		order = "a.modified_at"
+	}
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+todoColumns+`, a.viewed_at, a.modified_at
+		FROM todo_access a JOIN todos ON todos.id = a.todo_id
+		WHERE a.user_id = ? AND todos.workspace_id = ? AND `+order+` IS NOT NULL
+		ORDER BY `+order+` DESC
+		LIMIT ?
+	`, userID, WorkspaceFromContext(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询最近访问失败：%w", err)
+	}
+	defer rows.Close()
+
+	recent := make([]RecentTodo, 0)
+	for rows.Next() {
+		var viewedAt, modifiedAt sql.NullTime
+		todo, err := scanTodo(withAccess{rows, &viewedAt, &modifiedAt})
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		item := RecentTodo{Todo: *todo}
+		if viewedAt.Valid {
+			item.ViewedAt = &viewedAt.Time
+		}
+		if modifiedAt.Valid {
+			item.ModifiedAt = &modifiedAt.Time
+		}
+		recent = append(recent, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return recent, nil
+}
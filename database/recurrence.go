@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"todo-list/model"
+)
+
+// initRecurrenceSchema 待生成下一次的重复待办事项只占很少一部分，用部分索引让后台任务不必扫描整张表
+func (db *DB) initRecurrenceSchema() error {
+	_, err := db.conn.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_todos_recurrence_due ON todos(completed_at)
+		WHERE recurrence IS NOT NULL AND next_occurrence_id IS NULL
+	`)
+	return err
+}
+
+// nullString 空字符串存为 NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// ListDueRecurrencesContext 返回所有工作区中已完成、还没有生成下一次的重复待办事项，最多 limit 条
+func (db *DB) ListDueRecurrencesContext(ctx context.Context, limit int) ([]model.Todo, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+todoColumns+` FROM todos
+		WHERE recurrence IS NOT NULL AND next_occurrence_id IS NULL AND completed_at IS NOT NULL
+		ORDER BY completed_at ASC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询重复待办事项失败：%w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, rows.Err()
+}
+
+// CreateNextOccurrenceContext 为已完成的重复待办事项 prev 生成下一次 next（与 prev 在同一工作区），
+// next 为 nil 表示规则已经结束，只做标记；prev 已经生成过下一次时返回 false，保证每次完成只生成一个
+func (db *DB) CreateNextOccurrenceContext(ctx context.Context, prev *model.Todo, next *model.Todo) (created bool, err error) {
+	var workspace string
+	if err := db.conn.QueryRowContext(ctx, `SELECT workspace_id FROM todos WHERE id = ?`, prev.ID).Scan(&workspace); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get todo: %w", err)
+	}
+	ctx = WithWorkspace(ctx, workspace)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil || !created {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	// 先占位（0 表示没有下一次），其他实例或上一轮已经处理过时不会匹配
+	result, err := tx.ExecContext(ctx,
+		`UPDATE todos SET next_occurrence_id = 0 WHERE id = ? AND next_occurrence_id IS NULL`, prev.ID)
+	if err != nil {
+		return false, fmt.Errorf("标记重复待办事项失败：%w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if next != nil {
+		ensurePublicID(next)
+		result, err = tx.ExecContext(ctx, `
+			INSERT INTO todos (title, description, status, due_date, created_at, updated_at, version,
+			                   latitude, longitude, radius, estimated_minutes, priority, public_id, workspace_id,
+			                   recurrence, recurrence_start, project_id, secret_note, secret_note_key_hint)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, next.Title, next.Description, next.Status, next.DueDate, next.CreatedAt, next.UpdatedAt, next.Version,
+			next.Latitude, next.Longitude, next.Radius, next.EstimatedMinutes, next.Priority, next.PublicID, workspace,
+			nullString(next.Recurrence), next.RecurrenceStart, next.ProjectID,
+			noteCiphertext(next.SecretNote), noteKeyHint(next.SecretNote))
+		if err != nil {
+			return false, fmt.Errorf("failed to create todo: %w", err)
+		}
+		var id int64
+		if id, err = result.LastInsertId(); err != nil {
+			return false, fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		next.ID = int(id)
+
+		if _, err = tx.ExecContext(ctx,
+			`UPDATE todos SET next_occurrence_id = ? WHERE id = ?`, next.ID, prev.ID); err != nil {
+			return false, fmt.Errorf("标记重复待办事项失败：%w", err)
+		}
+	}
+
+	created = true
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("提交事务失败：%w", err)
+	}
+	db.invalidateTodos(ctx, prev.ID)
+	return true, nil
+}
+
+// archivedOccurrence 归档中已完成的重复待办事项，下一次要么也在归档中，要么当时就没有生成，
+// 导入后标记为已处理（0），不再重新生成
+func archivedOccurrence(todo *model.Todo) sql.NullInt64 {
+	if todo.Recurrence == "" || todo.CompletedAt == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: 0, Valid: true}
+}
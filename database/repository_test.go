@@ -0,0 +1,30 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+	"todo-list/database"
+	"todo-list/storage"
+	"todo-list/storage/storagetest"
+)
+
+func openTestDB(t *testing.T) *database.DB {
+	db, err := database.New(filepath.Join(t.TempDir(), "todos.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.TodoRepository {
+		return openTestDB(t)
+	})
+}
+
+func TestRepositories(t *testing.T) {
+	storagetest.RunRepositories(t, func(t *testing.T) storagetest.Store {
+		return openTestDB(t)
+	})
+}
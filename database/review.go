@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/model"
+)
+
+// 周回顾的分组，每个待办事项只出现在优先级最高的一组：逾期 > 停滞 > 没有截止日期
+const (
+	ReviewOverdue = "overdue"
+	ReviewStale   = "stale"
+	ReviewUndated = "undated"
+)
+
+// 周回顾的处理方式
+const (
+	ReviewReschedule = "reschedule" // 设置新的截止日期
+	ReviewDrop       = "drop"       // 不再做，删除
+	ReviewKeep       = "keep"       // 保持不变，只刷新 updated_at，下次回顾不再算作停滞
+)
+
+// ReviewDecision 对一个待办事项的处理
+type ReviewDecision struct {
+	TodoID  int
+	Action  string
+	DueDate *time.Time // reschedule 时的新截止日期
+}
+
+// reviewQuery 某个分组的查询条件
+// 只包含未进入终态的事项（completed_at 为空，终态与否由工作流决定，见 handler.applyStatus）
+func reviewQuery(ctx context.Context, group string, now, staleBefore time.Time) *todoQuery {
+	q := scopedTodoQuery(ctx).where("completed_at IS NULL")
+	stale := staleBefore.UTC().Format("2006-01-02 15:04:05")
+	switch group {
+	case ReviewOverdue:
+		q.where("due_date IS NOT NULL").where("due_date < ?", now.UTC())
+	case ReviewStale:
+		q.where("(due_date IS NULL OR due_date >= ?)", now.UTC()).
+			where("julianday(updated_at) < julianday(?)", stale)
+	case ReviewUndated:
+		q.where("due_date IS NULL").
+			where("julianday(updated_at) >= julianday(?)", stale)
+	}
+	return q
+}
+
+// ListReviewTodosContext 周回顾某个分组的前 limit 个待办事项及该组总数
+// 逾期按截止日期、其余按最久未更新排在前面
+func (db *DB) ListReviewTodosContext(ctx context.Context, group string, now, staleBefore time.Time, limit int) ([]model.Todo, int, error) {
+	q := reviewQuery(ctx, group, now, staleBefore)
+
+	var total int
+	query, args := q.countSQL()
+	if err := db.conn.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计回顾事项失败：%w", err)
+	}
+
+	order := "ORDER BY updated_at ASC, id ASC LIMIT ?"
+	if group == ReviewOverdue {
+		order = "ORDER BY due_date ASC, id ASC LIMIT ?"
+	}
+	query, args = q.selectSQL(todoColumns, order, limit)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询回顾事项失败：%w", err)
+	}
+	defer rows.Close()
+
+	todos := make([]model.Todo, 0)
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		todos = append(todos, *todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return todos, total, nil
+}
+
+// ApplyReviewDecisionsContext 在一个事务中执行周回顾的处理，任何一个待办事项不存在时全部回滚
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) ApplyReviewDecisionsContext(ctx context.Context, decisions []ReviewDecision) (err error) {
+	workspace := WorkspaceFromContext(ctx)
+	now := db.clock.Now()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("回滚失败：%v（原始错误：%v）", rbErr, err)
+			}
+		}
+	}()
+
+	ids := make([]int, 0, len(decisions))
+	for _, d := range decisions {
+		var query string
+		var args []interface{}
+		switch d.Action {
+		case ReviewReschedule:
+			query = `UPDATE todos SET due_date = ?, updated_at = ?, version = version + 1 WHERE id = ? AND workspace_id = ?`
+			args = []interface{}{d.DueDate, now, d.TodoID, workspace}
+		case ReviewKeep:
+			query = `UPDATE todos SET updated_at = ?, version = version + 1 WHERE id = ? AND workspace_id = ?`
+			args = []interface{}{now, d.TodoID, workspace}
+		case ReviewDrop:
+			query = `DELETE FROM todos WHERE id = ? AND workspace_id = ?`
+			args = []interface{}{d.TodoID, workspace}
+		default:
+			return fmt.Errorf("不支持的处理方式 %q", d.Action)
+		}
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("处理待办事项 %d 失败：%w", d.TodoID, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("todo %d: %w", d.TodoID, ErrNotFound)
+		}
+		ids = append(ids, d.TodoID)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+
+	db.invalidateTodos(ctx, ids...)
+	return nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// todoColumns 查询待办事项时统一使用的列，顺序必须与 scanTodo 保持一致
+const todoColumns = `id, version, title, description, status, due_date,
+	created_at, updated_at, completed_at, latitude, longitude, radius, estimated_minutes, priority, public_id,
+	recurrence, recurrence_start, next_occurrence_id, project_id, secret_note, secret_note_key_hint`
+
+// rowScanner 同时兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// withTotal 在待办事项的列之后多扫描一列总数（COUNT(*) OVER()）
+type withTotal struct {
+	rowScanner
+	total *int
+}
+
+func (w withTotal) Scan(dest ...interface{}) error {
+	return w.rowScanner.Scan(append(dest, w.total)...)
+}
+
+// timeLayouts 数据库中可能出现的时间格式
+// due_date 列声明为 TEXT，驱动会按 "2006-01-02 15:04:05.999999999-07:00" 写入，而不是 RFC3339
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseDBTime 解析数据库中的时间字符串
+func parseDBTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的时间格式: %q", s)
+}
+
+// scanTodo 扫描一行待办事项（列顺序见 todoColumns）
+func scanTodo(s rowScanner) (*model.Todo, error) {
+	var todo model.Todo
+	var dueDate, completedAt sql.NullString
+	var latitude, longitude, radius sql.NullFloat64
+	var estimated sql.NullInt64
+	var publicID sql.NullString
+	var recurrence, recurrenceStart sql.NullString
+	var nextOccurrence, projectID sql.NullInt64
+	var secretNote, secretNoteKeyHint sql.NullString
+
+	err := s.Scan(
+		&todo.ID,
+		&todo.Version,
+		&todo.Title,
+		&todo.Description,
+		&todo.Status,
+		&dueDate,
+		&todo.CreatedAt,
+		&todo.UpdatedAt,
+		&completedAt,
+		&latitude,
+		&longitude,
+		&radius,
+		&estimated,
+		&todo.Priority,
+		&publicID,
+		&recurrence,
+		&recurrenceStart,
+		&nextOccurrence,
+		&projectID,
+		&secretNote,
+		&secretNoteKeyHint,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dueDate.Valid {
+		t, err := parseDBTime(dueDate.String)
+		if err != nil {
+			return nil, fmt.Errorf("解析 due_date 失败：%w", err)
+		}
+		todo.DueDate = &t
+	}
+
+	if completedAt.Valid {
+		t, err := parseDBTime(completedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("解析 completed_at 失败：%w", err)
+		}
+		todo.CompletedAt = &t
+	}
+
+	if latitude.Valid && longitude.Valid {
+		todo.Latitude = &latitude.Float64
+		todo.Longitude = &longitude.Float64
+	}
+	if radius.Valid {
+		todo.Radius = &radius.Float64
+	}
+
+	if estimated.Valid {
+		minutes := int(estimated.Int64)
+		todo.EstimatedMinutes = &minutes
+	}
+
+	todo.PublicID = publicID.String
+
+	todo.Recurrence = recurrence.String
+	if recurrenceStart.Valid {
+		t, err := parseDBTime(recurrenceStart.String)
+		if err != nil {
+			return nil, fmt.Errorf("解析 recurrence_start 失败：%w", err)
+		}
+		todo.RecurrenceStart = &t
+	}
+	// 0 表示规则已经结束，没有下一次
+	if nextOccurrence.Valid && nextOccurrence.Int64 > 0 {
+		id := int(nextOccurrence.Int64)
+		todo.NextOccurrenceID = &id
+	}
+	if projectID.Valid {
+		id := int(projectID.Int64)
+		todo.ProjectID = &id
+	}
+	if secretNote.Valid {
+		todo.SecretNote = &model.SecretNote{Ciphertext: secretNote.String, KeyHint: secretNoteKeyHint.String}
+	}
+
+	return &todo, nil
+}
+
+// noteCiphertext 和 noteKeyHint 是加密备注写入 secret_note、secret_note_key_hint 两列的值，没有备注时为 NULL
+func noteCiphertext(n *model.SecretNote) sql.NullString {
+	if n == nil {
+		return sql.NullString{}
+	}
+	return nullString(n.Ciphertext)
+}
+
+func noteKeyHint(n *model.SecretNote) sql.NullString {
+	if n == nil {
+		return sql.NullString{}
+	}
+	return nullString(n.KeyHint)
+}
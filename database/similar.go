@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"todo-list/suggest"
+)
+
+// ListTitleCandidatesContext 列出当前工作区中用于查重的标题
+// includeCompleted 为 false 时只包含未进入终态的事项
+func (db *DB) ListTitleCandidatesContext(ctx context.Context, includeCompleted bool) ([]suggest.Candidate, error) {
+	q := scopedTodoQuery(ctx)
+	if !includeCompleted {
+		q.where("completed_at IS NULL")
+	}
+	query, args := q.selectSQL("id, title, status", "ORDER BY id DESC")
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询标题失败：%w", err)
+	}
+	defer rows.Close()
+
+	var candidates []suggest.Candidate
+	for rows.Next() {
+		var c suggest.Candidate
+		if err := rows.Scan(&c.ID, &c.Title, &c.Status); err != nil {
+			return nil, fmt.Errorf("扫描行失败：%w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return candidates, nil
+}
+
+// TitleSuggestion 以前完成过的标题，Count 为完成的次数
+type TitleSuggestion struct {
+	Title           string    `json:"title"`
+	Count           int       `json:"count"`
+	LastCompletedAt time.Time `json:"last_completed_at"`
+}
+
+// likeEscaper 转义 LIKE 中的通配符，前缀里的 % 和 _ 按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SuggestTitlesContext 当前工作区中以 prefix 开头（不区分大小写）的已完成标题，
+// 按完成次数从多到少、最近完成时间从新到旧排列，最多 limit 条；prefix 为空时返回最常用的标题
+// 只有大小写不同的标题合并计数，显示最近一次完成时的写法（只有一个 MAX 聚合时 SQLite 取该行的 title）
+func (db *DB) SuggestTitlesContext(ctx context.Context, prefix string, limit int) ([]TitleSuggestion, error) {
+	q := scopedTodoQuery(ctx).where("completed_at IS NOT NULL")
+	if prefix != "" {
+		q.where(`title LIKE ? ESCAPE '\'`, likeEscaper.Replace(prefix)+"%")
+	}
+	query, args := q.selectSQL("title, COUNT(*), MAX(completed_at)",
+		"GROUP BY title COLLATE NOCASE ORDER BY COUNT(*) DESC, MAX(completed_at) DESC LIMIT ?", limit)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询标题失败：%w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []TitleSuggestion{}
+	for rows.Next() {
+		var s TitleSuggestion
+		var last string
+		if err := rows.Scan(&s.Title, &s.Count, &last); err != nil {
+			return nil, fmt.Errorf("扫描行失败：%w", err)
+		}
+		if s.LastCompletedAt, err = parseDBTime(last); err != nil {
+			return nil, fmt.Errorf("解析 completed_at 失败：%w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todo-list/storage"
+)
+
+// OverdueBuckets 逾期时长分布，定义见 storage
+type OverdueBuckets = storage.OverdueBuckets
+
+// getOverdueBucketsContext 统计 q 范围内逾期待办事项的时长分布
+// 逾期判断与 GetStatsContext 的 overdue 保持一致，再用 julianday 计算逾期天数
+func (db *DB) getOverdueBucketsContext(ctx context.Context, q *todoQuery, now time.Time) (*OverdueBuckets, error) {
+	inner, args := q.clone().
+		where(db.statuses.OpenSQL()).where("due_date IS NOT NULL").where("due_date < ?", now).
+		selectSQL("julianday(?) - julianday(due_date) AS age", "")
+	query := `
+		SELECT
+			SUM(CASE WHEN age < 1 THEN 1 ELSE 0 END),
+			SUM(CASE WHEN age >= 1 AND age <= 7 THEN 1 ELSE 0 END),
+			SUM(CASE WHEN age > 7 THEN 1 ELSE 0 END)
+		FROM (` + inner + `)`
+	args = append([]interface{}{now.UTC().Format("2006-01-02 15:04:05")}, args...)
+
+	var lt1, d1to7, gt7 sql.NullInt64
+	err := db.conn.QueryRowContext(ctx, query, args...).Scan(&lt1, &d1to7, &gt7)
+	if err != nil {
+		return nil, fmt.Errorf("查询逾期分布失败：%w", err)
+	}
+
+	// 没有逾期数据时 SUM 返回 NULL，Int64 为 0
+	return &OverdueBuckets{
+		LessThanDay: int(lt1.Int64),
+		OneToSeven:  int(d1to7.Int64),
+		OverSeven:   int(gt7.Int64),
+	}, nil
+}
+
+// LatencyStats、PriorityLatency、CompletionLatency 完成耗时统计，定义见 storage
+type (
+	LatencyStats      = storage.LatencyStats
+	PriorityLatency   = storage.PriorityLatency
+	CompletionLatency = storage.CompletionLatency
+)
+
+// latencyQuery 在 SQL 中计算平均值和中位数
+// SQLite 没有 MEDIAN，先按整分钟分桶（见 latencyMinuteSQL），用窗口函数累计每组的数量，
+// 再取中间一条所在分桶的平均耗时（偶数条时取中间两条的平均），与读计数表的 bucketLatency 结果相同
+// %[1]s 为分组表达式，只能传入代码中的常量；%[2]s 为 todoQuery 生成的 WHERE 子句
+const latencyQuery = `
+	WITH durations AS (
+		SELECT %[1]s AS grp,
+		       (julianday(completed_at) - julianday(created_at)) * 24 AS hours
+		FROM todos
+		%[2]s
+	),
+	buckets AS (
+		SELECT grp, CAST(ROUND(hours * 3600000) AS INTEGER) / 60000 AS minutes, COUNT(*) AS n, SUM(hours) AS total
+		FROM durations
+		WHERE hours IS NOT NULL
+		GROUP BY grp, minutes
+	),
+	ranked AS (
+		SELECT grp, n, total,
+		       SUM(n) OVER (PARTITION BY grp ORDER BY minutes) AS seen,
+		       SUM(n) OVER (PARTITION BY grp) AS cnt
+		FROM buckets
+	)
+	SELECT grp,
+	       SUM(n),
+	       SUM(total) / SUM(n),
+	       (MAX(CASE WHEN seen - n < (cnt + 1) / 2 AND seen >= (cnt + 1) / 2 THEN total / n END) +
+	        MAX(CASE WHEN seen - n < (cnt + 2) / 2 AND seen >= (cnt + 2) / 2 THEN total / n END)) / 2
+	FROM ranked
+	GROUP BY grp
+	ORDER BY grp
+`
+
+// GetCompletionLatencyContext 统计当前工作区待办事项从创建到完成的耗时
+// 读取耗时计数（见 counterLatencyContext），同一工作区的并发请求合并为一次查询，结果共享，调用方不能修改
+func (db *DB) GetCompletionLatencyContext(ctx context.Context) (*CompletionLatency, error) {
+	v, err := db.coalesce(ctx, "latency:"+WorkspaceFromContext(ctx), func(ctx context.Context) (interface{}, error) {
+		return db.counterLatencyContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*CompletionLatency), nil
+}
+
+// queryCompletionLatencyContext 从数据库计算 q 范围内已完成事项的耗时
+func (db *DB) queryCompletionLatencyContext(ctx context.Context, q *todoQuery) (*CompletionLatency, error) {
+	result := &CompletionLatency{ByPriority: make([]PriorityLatency, 0)}
+	q = q.clone().where(db.statuses.DoneSQL()).where("completed_at IS NOT NULL")
+
+	overall, err := db.queryLatency(ctx, q, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(overall) > 0 {
+		result.Overall = overall[0].LatencyStats
+	}
+
+	result.ByPriority, err = db.queryLatency(ctx, q, "priority")
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// queryLatency 按 groupExpr 分组执行 latencyQuery
+func (db *DB) queryLatency(ctx context.Context, q *todoQuery, groupExpr string) ([]PriorityLatency, error) {
+	rows, err := db.conn.QueryContext(ctx, fmt.Sprintf(latencyQuery, groupExpr, q.whereSQL()), q.queryArgs()...)
+	if err != nil {
+		return nil, fmt.Errorf("查询完成耗时失败：%w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]PriorityLatency, 0)
+	for rows.Next() {
+		var g PriorityLatency
+		var avg, median sql.NullFloat64
+		if err := rows.Scan(&g.Priority, &g.Count, &avg, &median); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		g.AvgHours = avg.Float64
+		g.MedianHours = median.Float64
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return groups, nil
+}
+
+// countByStatusContext 按状态分组计数，状态集合由工作流决定，这里不做假设
+func (db *DB) countByStatusContext(ctx context.Context, q *todoQuery) (map[string]int, error) {
+	query, args := q.selectSQL("status, COUNT(*)", "GROUP BY status")
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("按状态统计失败：%w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("扫描状态统计失败：%w", err)
+		}
+		counts[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历状态统计失败：%w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,257 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"todo-list/clock"
+	"todo-list/database"
+	"todo-list/model"
+)
+
+func TestGetFilteredStatsAppliesFullFilter(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	past := time.Now().Add(-48 * time.Hour)
+	todos := []model.Todo{
+		{Title: "urgent", Status: "pending", Priority: 3},
+		{Title: "low overdue", Status: "pending", Priority: 0, DueDate: &past},
+		{Title: "done", Status: "completed", Priority: 1},
+	}
+	if _, err := db.ImportTodosContext(ctx, todos); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	tests := []struct {
+		name   string
+		filter database.TodoFilter
+		total  int
+	}{
+		{"no filter", database.TodoFilter{}, 3},
+		{"status ignored", database.TodoFilter{Status: "completed", Limit: 1}, 3},
+		{"priority", database.TodoFilter{Priority: intPtr(3)}, 1},
+		{"min priority", database.TodoFilter{MinPriority: intPtr(1)}, 2},
+		{"overdue view", database.TodoFilter{PendingOnly: true, DueBefore: &now}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := db.GetFilteredStatsContext(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Total != tt.total {
+				t.Errorf("Total = %d, want %d", stats.Total, tt.total)
+			}
+		})
+	}
+}
+
+// 计数表由触发器维护，经过各种写入之后读计数得到的统计必须与扫描 todos 的结果相同
+// 完成耗时都是不同的整小时，分桶的中位数与精确值一致
+func TestCounterStatsMatchScan(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "counters.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 6, 10, 15, 0, 0, 0, time.UTC)
+	db.SetClock(clock.NewFake(now))
+	ctx := context.Background()
+
+	project := &model.Project{Name: "home"}
+	if err := db.CreateProjectContext(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+	todos := []*model.Todo{
+		{Title: "inbox"},
+		{Title: "due earlier today", DueDate: at(-2 * time.Hour), Priority: 3},
+		{Title: "due later today", DueDate: at(5 * time.Hour), ProjectID: &project.ID},
+		{Title: "due in three days", DueDate: at(72 * time.Hour), Priority: 2},
+		{Title: "overdue yesterday", DueDate: at(-20 * time.Hour)},
+		{Title: "overdue three days", DueDate: at(-72 * time.Hour), ProjectID: &project.ID},
+		{Title: "overdue two weeks", DueDate: at(-14 * 24 * time.Hour), Priority: 0},
+		{Title: "done late", DueDate: at(-30 * time.Hour), CreatedAt: now.Add(-50 * time.Hour)},
+		{Title: "done quickly", CreatedAt: now.Add(-3 * time.Hour), Priority: 2, ProjectID: &project.ID},
+		{Title: "done last week", CreatedAt: now.Add(-9 * 24 * time.Hour)},
+	}
+	for _, todo := range todos {
+		todo.Status = "pending"
+		if todo.CreatedAt.IsZero() {
+			todo.CreatedAt = now.Add(-100 * time.Hour)
+		}
+		if err := db.CreateTodoContext(ctx, todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := database.WithWorkspace(ctx, "other")
+	if err := db.CreateTodoContext(other, &model.Todo{Title: "elsewhere", Status: "pending", DueDate: at(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(todo *model.Todo, change func(*model.Todo)) {
+		t.Helper()
+		change(todo)
+		if err := db.UpdateTodoContext(ctx, todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	complete := func(completedAt time.Time) func(*model.Todo) {
+		return func(todo *model.Todo) { todo.Status, todo.CompletedAt = "completed", &completedAt }
+	}
+	update(todos[7], complete(now.Add(-time.Hour)))
+	update(todos[8], complete(now.Add(-time.Hour)))
+	update(todos[9], complete(now.Add(-6*24*time.Hour)))
+	update(todos[3], func(todo *model.Todo) {
+		todo.DueDate, todo.Priority, todo.ProjectID = at(-26*time.Hour), 1, &project.ID
+	})
+	update(todos[8], func(todo *model.Todo) { todo.Status, todo.CompletedAt = "pending", nil })
+	update(todos[8], complete(now.Add(-2*time.Hour)))
+	if err := db.DeleteTodoContext(ctx, todos[4].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(when string) {
+		t.Helper()
+		got, err := db.GetStatsContext(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := db.GetFilteredStatsContext(ctx, database.TodoFilter{MinPriority: intPtr(0)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: counter stats = %+v, scanned stats = %+v", when, got, want)
+		}
+		if !reflect.DeepEqual(got.OverdueBuckets, want.OverdueBuckets) || !reflect.DeepEqual(got.CompletionLatency, want.CompletionLatency) ||
+			!reflect.DeepEqual(got.Focus, want.Focus) {
+			t.Errorf("%s: buckets %+v / %+v, latency %+v / %+v, focus %+v / %+v", when,
+				got.OverdueBuckets, want.OverdueBuckets, got.CompletionLatency, want.CompletionLatency, got.Focus, want.Focus)
+		}
+
+		p, err := db.GetProjectContext(ctx, project.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.TodoCount != 4 || p.OpenCount != 3 {
+			t.Errorf("%s: project counts = %d/%d, want 4/3", when, p.TodoCount, p.OpenCount)
+		}
+	}
+	check("after writes")
+
+	if _, err := db.RebuildCountersContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	check("after rebuild")
+}
+
+// 已归档项目中的事项不出现在列表中，统计（计数表和现场计算）也不计入，归档和取消归档后与列表保持一致
+func TestStatsSkipArchivedProjects(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "archived.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 6, 10, 15, 0, 0, 0, time.UTC)
+	db.SetClock(clock.NewFake(now))
+	ctx := context.Background()
+
+	project := &model.Project{Name: "old"}
+	if err := db.CreateProjectContext(ctx, project); err != nil {
+		t.Fatal(err)
+	}
+	overdue := now.Add(-30 * time.Hour)
+	completedAt := now.Add(-time.Hour)
+	for _, todo := range []*model.Todo{
+		{Title: "visible", DueDate: &overdue},
+		{Title: "archived overdue", DueDate: &overdue, ProjectID: &project.ID},
+		{Title: "archived done", Status: "completed", CompletedAt: &completedAt, ProjectID: &project.ID},
+	} {
+		if todo.Status == "" {
+			todo.Status = "pending"
+		}
+		todo.CreatedAt = now.Add(-48 * time.Hour)
+		if err := db.CreateTodoContext(ctx, todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(when string, total, overdue, completed int) {
+		t.Helper()
+		_, listed, err := db.ListTodosContext(ctx, database.TodoFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetStatsContext(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanned, err := db.GetFilteredStatsContext(ctx, database.TodoFilter{MinPriority: intPtr(0)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if listed != total || got.Total != total || scanned.Total != total {
+			t.Errorf("%s: listed %d, counter total %d, scanned total %d; want %d", when, listed, got.Total, scanned.Total, total)
+		}
+		if got.Overdue != overdue || got.Completed != completed {
+			t.Errorf("%s: overdue %d, completed %d; want %d, %d", when, got.Overdue, got.Completed, overdue, completed)
+		}
+		if !reflect.DeepEqual(got, scanned) {
+			t.Errorf("%s: counter stats = %+v, scanned stats = %+v", when, got, scanned)
+		}
+
+		// 项目自身的数量仍然包含其中的全部事项
+		p, err := db.GetProjectContext(ctx, project.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.TodoCount != 2 {
+			t.Errorf("%s: project todo count = %d, want 2", when, p.TodoCount)
+		}
+	}
+	check("active project", 3, 2, 1)
+
+	if err := db.SetProjectArchivedContext(ctx, project.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	check("archived", 1, 1, 0)
+
+	// 移入已归档项目的新事项同样不计入
+	if err := db.CreateTodoContext(ctx, &model.Todo{Title: "late addition", Status: "pending", ProjectID: &project.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RebuildCountersContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.GetStatsContext(ctx); err != nil || got.Total != 1 {
+		t.Fatalf("after rebuild: stats = %+v, %v; want total 1", got, err)
+	}
+
+	if err := db.SetProjectArchivedContext(ctx, project.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	_, listed, err := db.ListTodosContext(ctx, database.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetStatsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listed != 4 || got.Total != 4 || got.Overdue != 2 || got.Completed != 1 {
+		t.Errorf("unarchived: listed %d, stats %+v; want 4 todos, 2 overdue, 1 completed", listed, got)
+	}
+}
+
+func intPtr(n int) *int { return &n }
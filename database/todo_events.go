@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// initTodoEventsSchema 初始化待办事项变更事件表和记录事件的触发器
+// 事件在写入待办事项的同一个事务中由触发器记录，不论修改来自接口、批量操作、导入还是后台任务都不会遗漏；
+// 只有 updated_at、状态或工作区变化才算一次修改（内部维护的列，例如 next_occurrence_id，不产生事件）
+// 移到其他工作区时，原工作区收到 deleted，新工作区收到 created
+// 事件同时记录项目和优先级，订阅的过滤条件不必再查询待办事项（已删除的也能判断）
+// 触发器每次启动时重建，修改触发器的定义后旧数据库也会更新
+func (db *DB) initTodoEventsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS todo_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id TEXT NOT NULL,
+		todo_id INTEGER NOT NULL,
+		public_id TEXT,
+		type TEXT NOT NULL,
+		version INTEGER NOT NULL DEFAULT 0,
+		project_id INTEGER,
+		priority INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todo_events_workspace ON todo_events(workspace_id, id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init todo_events table: %w", err)
+	}
+
+	// 旧版本建立的 todo_events 表没有项目和优先级
+	for _, col := range []struct{ name, definition string }{
+		{"project_id", "INTEGER"},
+		{"priority", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := db.ensureTableColumn("todo_events", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+
+	triggers := `
+	DROP TRIGGER IF EXISTS trg_todo_events_insert;
+	CREATE TRIGGER trg_todo_events_insert AFTER INSERT ON todos
+	BEGIN
+		INSERT INTO todo_events (workspace_id, todo_id, public_id, type, version, project_id, priority)
+		VALUES (NEW.workspace_id, NEW.id, NEW.public_id, 'created', COALESCE(NEW.version, 1), NEW.project_id, NEW.priority);
+	END;
+
+	DROP TRIGGER IF EXISTS trg_todo_events_update;
+	CREATE TRIGGER trg_todo_events_update AFTER UPDATE ON todos
+	WHEN OLD.updated_at IS NOT NEW.updated_at OR OLD.status IS NOT NEW.status OR OLD.workspace_id IS NOT NEW.workspace_id
+	BEGIN
+		INSERT INTO todo_events (workspace_id, todo_id, public_id, type, version, project_id, priority)
+		SELECT OLD.workspace_id, OLD.id, OLD.public_id, 'deleted', COALESCE(OLD.version, 1), OLD.project_id, OLD.priority
+		WHERE OLD.workspace_id IS NOT NEW.workspace_id;
+		INSERT INTO todo_events (workspace_id, todo_id, public_id, type, version, project_id, priority)
+		VALUES (NEW.workspace_id, NEW.id, NEW.public_id,
+			CASE
+				WHEN OLD.workspace_id IS NOT NEW.workspace_id THEN 'created'
+				WHEN NEW.completed_at IS NOT NULL AND OLD.completed_at IS NULL THEN 'completed'
+				ELSE 'updated'
+			END,
+			COALESCE(NEW.version, 1), NEW.project_id, NEW.priority);
+	END;
+
+	DROP TRIGGER IF EXISTS trg_todo_events_delete;
+	CREATE TRIGGER trg_todo_events_delete AFTER DELETE ON todos
+	BEGIN
+		INSERT INTO todo_events (workspace_id, todo_id, public_id, type, version, project_id, priority)
+		VALUES (OLD.workspace_id, OLD.id, OLD.public_id, 'deleted', COALESCE(OLD.version, 1), OLD.project_id, OLD.priority);
+	END;
+	`
+
+	if _, err := db.conn.Exec(triggers); err != nil {
+		return fmt.Errorf("failed to init todo_events triggers: %w", err)
+	}
+	return nil
+}
+
+// ListTodoEventsContext 查询当前工作区 ID 大于 after 的事件，按 ID 升序，最多 limit 条
+func (db *DB) ListTodoEventsContext(ctx context.Context, after int64, limit int) ([]model.TodoEvent, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT id, type, todo_id, COALESCE(public_id, ''), version, project_id, priority, created_at
+		FROM todo_events
+		WHERE workspace_id = ? AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, WorkspaceFromContext(ctx), after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询变更事件失败：%w", err)
+	}
+	defer rows.Close()
+
+	var events []model.TodoEvent
+	for rows.Next() {
+		var e model.TodoEvent
+		var projectID sql.NullInt64
+		var at string
+		if err := rows.Scan(&e.ID, &e.Type, &e.TodoID, &e.PublicID, &e.Version, &projectID, &e.Priority, &at); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		if projectID.Valid {
+			id := int(projectID.Int64)
+			e.ProjectID = &id
+		}
+		if e.At, err = parseDBTime(at); err != nil {
+			return nil, fmt.Errorf("解析 created_at 失败：%w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return events, nil
+}
+
+// TodoEventRangeContext 返回仍然保留的最早事件 ID 和已分配的最新事件 ID（所有工作区共用一个序列）
+// 事件全部被清理时 first 为 last + 1；客户端重连时带的 Last-Event-ID 小于 first - 1，说明中间的事件已被清理，无法补发
+func (db *DB) TodoEventRangeContext(ctx context.Context) (first, last int64, err error) {
+	err = db.conn.QueryRowContext(ctx, `
+		SELECT COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'todo_events'), 0),
+			COALESCE((SELECT MIN(id) FROM todo_events), 0)
+	`).Scan(&last, &first)
+	if err != nil {
+		return 0, 0, fmt.Errorf("查询变更事件失败：%w", err)
+	}
+	if first == 0 {
+		first = last + 1
+	}
+	return first, last, nil
+}
+
+// PurgeTodoEventsContext 删除 before 之前的变更事件
+func (db *DB) PurgeTodoEventsContext(ctx context.Context, before time.Time) (int, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM todo_events WHERE julianday(created_at) < julianday(?)
+	`, before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("清理变更事件失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
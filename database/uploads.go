@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// uploadColumns 查询分片上传时统一使用的列，顺序必须与 scanUpload 保持一致
+// offset 是 SQL 关键字，列名使用 received
+const uploadColumns = `id, workspace_id, purpose, todo_id, filename, size, received, created_at, expires_at`
+
+// initUploadsSchema 初始化分片上传会话表，已接收的数据保存在 UPLOAD_DIR 中
+func (db *DB) initUploadsSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS uploads (
+		id TEXT PRIMARY KEY,
+		workspace_id TEXT NOT NULL,
+		purpose TEXT NOT NULL,
+		todo_id INTEGER NOT NULL DEFAULT 0,
+		filename TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		received INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_uploads_expires_at ON uploads(expires_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init uploads table: %w", err)
+	}
+	return nil
+}
+
+// scanUpload 扫描一行分片上传（列顺序见 uploadColumns）
+func scanUpload(s rowScanner) (*model.Upload, error) {
+	var u model.Upload
+	err := s.Scan(&u.ID, &u.Workspace, &u.Purpose, &u.TodoID, &u.Filename, &u.Size, &u.Offset,
+		&u.CreatedAt, &u.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateUploadContext 在当前工作区保存新的分片上传会话
+func (db *DB) CreateUploadContext(ctx context.Context, u *model.Upload) error {
+	u.Workspace = WorkspaceFromContext(ctx)
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO uploads (id, workspace_id, purpose, todo_id, filename, size, received, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, u.ID, u.Workspace, u.Purpose, u.TodoID, u.Filename, u.Size, u.Offset, u.CreatedAt.UTC(), u.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("保存上传会话失败：%w", err)
+	}
+	return nil
+}
+
+// GetUploadContext 查询当前工作区未过期的分片上传，不存在或已过期时返回 ErrNotFound
+func (db *DB) GetUploadContext(ctx context.Context, id string) (*model.Upload, error) {
+	row := db.conn.QueryRowContext(ctx, `
+		SELECT `+uploadColumns+` FROM uploads
+		WHERE id = ? AND workspace_id = ? AND julianday(expires_at) > julianday(?)
+	`, id, WorkspaceFromContext(ctx), db.clock.Now().UTC().Format("2006-01-02 15:04:05.000"))
+	u, err := scanUpload(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("upload %s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询上传会话失败：%w", err)
+	}
+	return u, nil
+}
+
+// SetUploadOffsetContext 记录已接收的字节数
+func (db *DB) SetUploadOffsetContext(ctx context.Context, id string, offset int64) error {
+	_, err := db.conn.ExecContext(ctx, `UPDATE uploads SET received = ? WHERE id = ?`, offset, id)
+	if err != nil {
+		return fmt.Errorf("更新上传进度失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteUploadContext 删除分片上传会话（文件由调用方删除）
+func (db *DB) DeleteUploadContext(ctx context.Context, id string) error {
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM uploads WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("删除上传会话失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredUploadsContext 删除 before 之前过期的会话（所有工作区），返回被删除会话的 ID，供清理已接收的数据
+func (db *DB) DeleteExpiredUploadsContext(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		DELETE FROM uploads WHERE julianday(expires_at) <= julianday(?) RETURNING id
+	`, before.UTC().Format("2006-01-02 15:04:05.000"))
+	if err != nil {
+		return nil, fmt.Errorf("清理过期上传失败：%w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return ids, nil
+}
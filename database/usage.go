@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CountTodosCreatedSinceContext 统计当前工作区在 since 之后创建的待办事项数量
+func (db *DB) CountTodosCreatedSinceContext(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	// created_at 可能带不同时区后缀，用 julianday 统一换算后再比较
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM todos
+		WHERE workspace_id = ? AND julianday(created_at) >= julianday(?)
+	`, WorkspaceFromContext(ctx), since.UTC().Format("2006-01-02 15:04:05")).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计待办事项失败：%w", err)
+	}
+	return count, nil
+}
+
+// CountLinksContext 统计待办事项的链接数量
+func (db *DB) CountLinksContext(ctx context.Context, todoID int) (int, error) {
+	var count int
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM todo_links WHERE todo_id = ?`, todoID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计链接失败：%w", err)
+	}
+	return count, nil
+}
+
+// CountWebhooksContext 统计当前工作区的出站 webhook 数量（包括停用的）
+func (db *DB) CountWebhooksContext(ctx context.Context) (int, error) {
+	var count int
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM webhooks WHERE workspace_id = ?`, WorkspaceFromContext(ctx),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计 webhook 失败：%w", err)
+	}
+	return count, nil
+}
+
+// SumAttachmentBytesContext 当前工作区所有附件的总大小（字节），隔离的附件同样占用存储，也计算在内
+func (db *DB) SumAttachmentBytesContext(ctx context.Context) (int64, error) {
+	var total int64
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(size), 0) FROM todo_attachments
+		WHERE todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+	`, WorkspaceFromContext(ctx)).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("统计附件大小失败：%w", err)
+	}
+	return total, nil
+}
+
+// MaxLinksPerTodoContext 当前工作区中链接最多的待办事项的链接数量
+func (db *DB) MaxLinksPerTodoContext(ctx context.Context) (int, error) {
+	var count sql.NullInt64
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT MAX(n) FROM (
+			SELECT COUNT(*) AS n FROM todo_links
+			WHERE todo_id IN (SELECT id FROM todos WHERE workspace_id = ?)
+			GROUP BY todo_id
+		)
+	`, WorkspaceFromContext(ctx)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计链接失败：%w", err)
+	}
+	return int(count.Int64), nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"todo-list/model"
+)
+
+// maxWebhookDeliveriesLimit 推送记录每次最多返回的条数
+const maxWebhookDeliveriesLimit = 100
+
+// webhookDeliveryColumns 查询推送记录的列，与 scanWebhookDelivery 的顺序一致
+const webhookDeliveryColumns = `id, webhook_id, delivery, event, event_id, attempt, success, status, error, latency_ms, response, payload, created_at`
+
+// initWebhookDeliveriesSchema 初始化 webhook 推送记录表，每次推送尝试一行，超过保留期后由清理任务删除
+// webhook 删除后它的推送记录随之删除
+func (db *DB) initWebhookDeliveriesSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		workspace_id TEXT NOT NULL,
+		delivery TEXT NOT NULL,
+		event TEXT NOT NULL,
+		event_id INTEGER NOT NULL DEFAULT 0,
+		attempt INTEGER NOT NULL DEFAULT 1,
+		success INTEGER NOT NULL DEFAULT 0,
+		status INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		response TEXT NOT NULL DEFAULT '',
+		payload BLOB,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_delivery ON webhook_deliveries(webhook_id, delivery);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init webhook_deliveries table: %w", err)
+	}
+	return nil
+}
+
+// scanWebhookDelivery 扫描一行推送记录（列顺序见 webhookDeliveryColumns）
+func scanWebhookDelivery(s rowScanner) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := s.Scan(&d.ID, &d.WebhookID, &d.Delivery, &d.Event, &d.EventID, &d.Attempt, &d.Success, &d.Status,
+		&d.Error, &d.LatencyMS, &d.Response, &d.Payload, &d.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// RecordWebhookDeliveryContext 保存一次推送尝试，同时更新 webhook 最近一次推送的结果
+// 写入后 d 的 ID、Attempt（同一推送 ID 的第几次）和 CreatedAt 被填充
+// 注意：使用命名返回值 (err error)，让 defer 能访问到错误
+func (db *DB) RecordWebhookDeliveryContext(ctx context.Context, d *model.WebhookDelivery) (err error) {
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = db.clock.Now()
+	}
+	d.CreatedAt = d.CreatedAt.UTC()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, workspace_id, delivery, event, event_id, attempt, success, status, error,
+			latency_ms, response, payload, created_at)
+		VALUES (?, ?, ?, ?, ?, (SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ? AND delivery = ?) + 1,
+			?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, attempt
+	`, d.WebhookID, WorkspaceFromContext(ctx), d.Delivery, d.Event, d.EventID, d.WebhookID, d.Delivery,
+		d.Success, d.Status, d.Error, d.LatencyMS, d.Response, d.Payload, d.CreatedAt).Scan(&d.ID, &d.Attempt)
+	if err != nil {
+		return fmt.Errorf("记录 webhook 推送失败：%w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE webhooks SET last_status = ?, last_error = ?, last_delivery_at = ? WHERE id = ?
+	`, d.Status, d.Error, d.CreatedAt, d.WebhookID); err != nil {
+		return fmt.Errorf("记录 webhook 推送结果失败：%w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败：%w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveriesContext 当前工作区中 webhook 的推送记录，最新的在前
+// failedOnly 为 true 时只返回失败的尝试；limit 超出范围时使用上限
+func (db *DB) ListWebhookDeliveriesContext(ctx context.Context, webhookID int, failedOnly bool, limit int) ([]model.WebhookDelivery, error) {
+	if limit <= 0 || limit > maxWebhookDeliveriesLimit {
+		limit = maxWebhookDeliveriesLimit
+	}
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = ? AND workspace_id = ?`
+	if failedOnly {
+		query += " AND success = 0"
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+
+	rows, err := db.conn.QueryContext(ctx, query, webhookID, WorkspaceFromContext(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 推送记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDeliveryContext 查询当前工作区中 webhook 的一条推送记录，不存在时返回 ErrNotFound
+// succeeded 表示同一推送 ID 是否已经有成功的尝试
+func (db *DB) GetWebhookDeliveryContext(ctx context.Context, webhookID, id int) (d *model.WebhookDelivery, succeeded bool, err error) {
+	row := db.conn.QueryRowContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE id = ? AND webhook_id = ? AND workspace_id = ?`, id, webhookID, WorkspaceFromContext(ctx))
+	d, err = scanWebhookDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("webhook delivery %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("查询 webhook 推送记录失败：%w", err)
+	}
+
+	err = db.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM webhook_deliveries
+		WHERE webhook_id = ? AND delivery = ? AND success = 1)`, webhookID, d.Delivery).Scan(&succeeded)
+	if err != nil {
+		return nil, false, fmt.Errorf("查询 webhook 推送记录失败：%w", err)
+	}
+	return d, succeeded, nil
+}
+
+// PurgeWebhookDeliveriesContext 删除 before 之前的 webhook 推送记录
+func (db *DB) PurgeWebhookDeliveriesContext(ctx context.Context, before time.Time) (int, error) {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries WHERE julianday(created_at) < julianday(?)
+	`, before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, fmt.Errorf("清理 webhook 推送记录失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
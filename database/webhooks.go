@@ -0,0 +1,227 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// webhookColumns 查询 webhook 的列，与 scanWebhook 的顺序一致
+const webhookColumns = `id, url, events, project_id, min_priority, secret, active, last_status, last_error, last_delivery_at, created_at, updated_at`
+
+// WebhookTarget 需要推送事件的 webhook，带所属工作区和推送进度，见 storage.WebhookTarget
+type WebhookTarget = storage.WebhookTarget
+
+// initWebhooksSchema 初始化出站 webhook 表
+// last_event_id 是已处理到的变更事件（todo_events）ID，新建的 webhook 从创建时的最新事件开始，不补发历史事件
+func (db *DB) initWebhooksSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		events TEXT NOT NULL DEFAULT '',
+		project_id INTEGER,
+		min_priority INTEGER,
+		secret TEXT NOT NULL,
+		active INTEGER NOT NULL DEFAULT 1,
+		last_event_id INTEGER NOT NULL DEFAULT 0,
+		last_status INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		last_delivery_at DATETIME,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhooks_workspace ON webhooks(workspace_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init webhooks table: %w", err)
+	}
+
+	// 旧版本建立的 webhooks 表只能按事件类型过滤
+	for _, col := range []struct{ name, definition string }{
+		{"project_id", "INTEGER"},
+		{"min_priority", "INTEGER"},
+	} {
+		if err := db.ensureTableColumn("webhooks", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanWebhook 扫描一行 webhook（列顺序见 webhookColumns），extra 为追加在后面的列
+func scanWebhook(s rowScanner, extra ...interface{}) (*model.Webhook, error) {
+	var w model.Webhook
+	var events string
+	var projectID, minPriority sql.NullInt64
+	var lastDeliveryAt sql.NullTime
+	dest := []interface{}{&w.ID, &w.URL, &events, &projectID, &minPriority, &w.Secret, &w.Active, &w.LastStatus, &w.LastError,
+		&lastDeliveryAt, &w.CreatedAt, &w.UpdatedAt}
+	if err := s.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	w.Events = splitWebhookEvents(events)
+	if projectID.Valid {
+		id := int(projectID.Int64)
+		w.ProjectID = &id
+	}
+	if minPriority.Valid {
+		p := int(minPriority.Int64)
+		w.MinPriority = &p
+	}
+	if lastDeliveryAt.Valid {
+		w.LastDeliveryAt = &lastDeliveryAt.Time
+	}
+	return &w, nil
+}
+
+func splitWebhookEvents(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// CreateWebhookContext 在当前工作区创建 webhook，从现有的最新变更事件之后开始推送
+func (db *DB) CreateWebhookContext(ctx context.Context, w *model.Webhook) error {
+	now := db.clock.Now().UTC()
+	w.CreatedAt, w.UpdatedAt = now, now
+	result, err := db.conn.ExecContext(ctx, `
+		INSERT INTO webhooks (workspace_id, url, events, project_id, min_priority, secret, active, last_event_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'todo_events'), 0), ?, ?)
+	`, WorkspaceFromContext(ctx), w.URL, strings.Join(w.Events, ","), w.ProjectID, w.MinPriority, w.Secret, w.Active, now, now)
+	if err != nil {
+		return fmt.Errorf("保存 webhook 失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取 webhook ID 失败：%w", err)
+	}
+	w.ID = int(id)
+	return nil
+}
+
+// GetWebhookContext 查询当前工作区的 webhook，不存在时返回 ErrNotFound
+func (db *DB) GetWebhookContext(ctx context.Context, id int) (*model.Webhook, error) {
+	row := db.conn.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks
+		WHERE id = ? AND workspace_id = ?`, id, WorkspaceFromContext(ctx))
+	w, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 失败：%w", err)
+	}
+	return w, nil
+}
+
+// ListWebhooksContext 当前工作区的所有 webhook
+func (db *DB) ListWebhooksContext(ctx context.Context) ([]model.Webhook, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks
+		WHERE workspace_id = ? ORDER BY id ASC`, WorkspaceFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 失败：%w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		webhooks = append(webhooks, *w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhookContext 更新当前工作区 webhook 的 URL、过滤条件和启用状态
+// 从停用恢复启用时推送进度跳到最新事件，停用期间的变更不补发
+func (db *DB) UpdateWebhookContext(ctx context.Context, w *model.Webhook) error {
+	w.UpdatedAt = db.clock.Now().UTC()
+	result, err := db.conn.ExecContext(ctx, `
+		UPDATE webhooks SET url = ?, events = ?, project_id = ?, min_priority = ?, active = ?, updated_at = ?,
+			last_event_id = CASE WHEN active = 0 AND ? THEN COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'todo_events'), 0)
+				ELSE last_event_id END
+		WHERE id = ? AND workspace_id = ?
+	`, w.URL, strings.Join(w.Events, ","), w.ProjectID, w.MinPriority, w.Active, w.UpdatedAt, w.Active, w.ID, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("更新 webhook 失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook %d: %w", w.ID, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteWebhookContext 删除当前工作区的 webhook，已在重试队列中的推送随之放弃
+func (db *DB) DeleteWebhookContext(ctx context.Context, id int) error {
+	result, err := db.conn.ExecContext(ctx, `
+		DELETE FROM webhooks WHERE id = ? AND workspace_id = ?
+	`, id, WorkspaceFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("删除 webhook 失败：%w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook %d: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ListWebhookTargetsContext 所有工作区中启用的 webhook 及其推送进度
+func (db *DB) ListWebhookTargetsContext(ctx context.Context) ([]WebhookTarget, error) {
+	rows, err := db.conn.QueryContext(ctx, `SELECT `+webhookColumns+`, workspace_id, last_event_id FROM webhooks
+		WHERE active = 1 ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询 webhook 失败：%w", err)
+	}
+	defer rows.Close()
+
+	var targets []WebhookTarget
+	for rows.Next() {
+		var t WebhookTarget
+		w, err := scanWebhook(rows, &t.Workspace, &t.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		t.Webhook = *w
+		targets = append(targets, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+	return targets, nil
+}
+
+// AdvanceWebhookContext 记录 webhook 已处理到的变更事件 ID（不会回退）
+func (db *DB) AdvanceWebhookContext(ctx context.Context, id int, eventID int64) error {
+	if _, err := db.conn.ExecContext(ctx, `
+		UPDATE webhooks SET last_event_id = MAX(last_event_id, ?) WHERE id = ?
+	`, eventID, id); err != nil {
+		return fmt.Errorf("更新 webhook 推送进度失败：%w", err)
+	}
+	return nil
+}
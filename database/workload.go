@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// WorkloadDay 某天到期的未完成待办事项的预估耗时汇总
+type WorkloadDay struct {
+	Date             string `json:"date"` // YYYY-MM-DD（UTC）
+	EstimatedMinutes int    `json:"estimated_minutes"`
+	Todos            int    `json:"todos"`
+	Unestimated      int    `json:"unestimated"` // 没有填写预估耗时的数量
+}
+
+// WorkloadContext 按到期日汇总 [from, from+days) 内未完成待办事项的预估耗时
+// 返回的切片包含区间内的每一天（没有任务的日期为 0）；相同区间的并发请求合并为一次查询
+func (db *DB) WorkloadContext(ctx context.Context, from time.Time, days int) ([]WorkloadDay, error) {
+	from = from.UTC()
+	key := fmt.Sprintf("workload:%s:%s:%d", WorkspaceFromContext(ctx), from.Format("2006-01-02"), days)
+	v, err := db.coalesce(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return db.queryWorkloadContext(ctx, from, days)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(v.([]WorkloadDay)), nil
+}
+
+// queryWorkloadContext 从数据库汇总工作量
+func (db *DB) queryWorkloadContext(ctx context.Context, from time.Time, days int) ([]WorkloadDay, error) {
+	start := from.Format("2006-01-02")
+	end := from.AddDate(0, 0, days-1).Format("2006-01-02")
+
+	query, args := scopedTodoQuery(ctx).pendingWithDueDate(db.statuses).
+		where("date(due_date) BETWEEN ? AND ?", start, end).
+		selectSQL(`date(due_date) AS day,
+		       COALESCE(SUM(estimated_minutes), 0),
+		       COUNT(*),
+		       SUM(CASE WHEN estimated_minutes IS NULL THEN 1 ELSE 0 END)`, "GROUP BY day")
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询工作量失败：%w", err)
+	}
+	defer rows.Close()
+
+	byDate := make(map[string]WorkloadDay)
+	for rows.Next() {
+		var day WorkloadDay
+		if err := rows.Scan(&day.Date, &day.EstimatedMinutes, &day.Todos, &day.Unestimated); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		byDate[day.Date] = day
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	result := make([]WorkloadDay, 0, days)
+	for i := 0; i < days; i++ {
+		date := from.AddDate(0, 0, i).Format("2006-01-02")
+		day, ok := byDate[date]
+		if !ok {
+			day = WorkloadDay{Date: date}
+		}
+		result = append(result, day)
+	}
+
+	return result, nil
+}
+
+// OverdueWorkloadContext 已逾期（到期日早于 before 当天）且未完成的待办事项的预估耗时和数量
+func (db *DB) OverdueWorkloadContext(ctx context.Context, before time.Time) (minutes, todos int, err error) {
+	var sum sql.NullInt64
+	query, args := scopedTodoQuery(ctx).pendingWithDueDate(db.statuses).
+		where("date(due_date) < ?", before.UTC().Format("2006-01-02")).
+		selectSQL("SUM(estimated_minutes), COUNT(*)", "")
+	err = db.conn.QueryRowContext(ctx, query, args...).Scan(&sum, &todos)
+	if err != nil {
+		return 0, 0, fmt.Errorf("查询逾期工作量失败：%w", err)
+	}
+	return int(sum.Int64), todos, nil
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// ErrWorkspaceExists 工作区标识已被占用
+var ErrWorkspaceExists = storage.ErrWorkspaceExists
+
+// WithWorkspace 返回携带工作区标识的 Context，见 storage.WithWorkspace
+func WithWorkspace(ctx context.Context, slug string) context.Context {
+	return storage.WithWorkspace(ctx, slug)
+}
+
+// WorkspaceFromContext 取出 Context 中的工作区标识，未设置时为默认工作区
+func WorkspaceFromContext(ctx context.Context) string {
+	return storage.WorkspaceFromContext(ctx)
+}
+
+// initWorkspaceSchema 初始化工作区表，并确保默认工作区存在
+func (db *DB) initWorkspaceSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS workspaces (
+		slug TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		max_todos INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_todos_workspace ON todos(workspace_id);
+	`
+
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to init workspaces table: %w", err)
+	}
+
+	if _, err := db.conn.Exec(
+		`INSERT OR IGNORE INTO workspaces (slug, name, max_todos, created_at) VALUES (?, ?, 0, ?)`,
+		model.DefaultWorkspace, "Default", db.clock.Now().UTC(),
+	); err != nil {
+		return fmt.Errorf("failed to create default workspace: %w", err)
+	}
+
+	return nil
+}
+
+// CreateWorkspaceContext 创建工作区
+func (db *DB) CreateWorkspaceContext(ctx context.Context, ws *model.Workspace) error {
+	ws.CreatedAt = db.clock.Now().UTC()
+
+	_, err := db.conn.ExecContext(ctx, `
+		INSERT INTO workspaces (slug, name, max_todos, created_at)
+		VALUES (?, ?, ?, ?)
+	`, ws.Slug, ws.Name, ws.MaxTodos, ws.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrWorkspaceExists
+		}
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return nil
+}
+
+// GetWorkspaceContext 获取工作区，不存在时返回 nil
+func (db *DB) GetWorkspaceContext(ctx context.Context, slug string) (*model.Workspace, error) {
+	var ws model.Workspace
+	err := db.conn.QueryRowContext(ctx, `
+		SELECT slug, name, max_todos, created_at FROM workspaces WHERE slug = ?
+	`, slug).Scan(&ws.Slug, &ws.Name, &ws.MaxTodos, &ws.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return &ws, nil
+}
+
+// ListWorkspacesContext 获取所有工作区
+func (db *DB) ListWorkspacesContext(ctx context.Context) ([]model.Workspace, error) {
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT slug, name, max_todos, created_at FROM workspaces ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询工作区失败：%w", err)
+	}
+	defer rows.Close()
+
+	workspaces := make([]model.Workspace, 0)
+	for rows.Next() {
+		var ws model.Workspace
+		if err := rows.Scan(&ws.Slug, &ws.Name, &ws.MaxTodos, &ws.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描失败：%w", err)
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("迭代行失败：%w", err)
+	}
+
+	return workspaces, nil
+}
+
+// CountTodosContext 统计当前工作区的待办事项数量（用于数量上限检查）
+func (db *DB) CountTodosContext(ctx context.Context) (int, error) {
+	var count int
+	err := db.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM todos WHERE workspace_id = ?`, WorkspaceFromContext(ctx),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("统计待办事项失败：%w", err)
+	}
+	return count, nil
+}
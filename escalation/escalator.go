@@ -0,0 +1,121 @@
+package escalation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/clock"
+	"todo-list/database"
+	"todo-list/model"
+	"todo-list/notify"
+)
+
+// Store 升级提醒需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	ListOverdueTodosContext(ctx context.Context, now time.Time, minPriority int) ([]database.OverdueTodo, error)
+	LastEscalationContext(ctx context.Context, todoID, step int) (*time.Time, error)
+	RecordEscalationContext(ctx context.Context, todoID, step int, channel string, at time.Time) error
+}
+
+// Escalator 按策略检查逾期事项并发送升级提醒，由调度器周期调用
+type Escalator struct {
+	policy     *Policy
+	store      Store
+	dispatcher *notify.Dispatcher
+	userID     string
+	clock      clock.Clock
+}
+
+// NewEscalator 创建升级提醒器
+func NewEscalator(policy *Policy, store Store, dispatcher *notify.Dispatcher, userID string) *Escalator {
+	return &Escalator{
+		policy:     policy,
+		store:      store,
+		dispatcher: dispatcher,
+		userID:     userID,
+		clock:      clock.Real{},
+	}
+}
+
+// SetClock 替换判断逾期时长使用的时钟（测试中使用 clock.Fake）
+func (e *Escalator) SetClock(c clock.Clock) {
+	e.clock = c
+}
+
+// Run 执行一轮检查（接受 Context 参数，供调度器使用）
+func (e *Escalator) Run(ctx context.Context) {
+	notified, err := e.evaluate(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("逾期升级检查超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("逾期升级检查已取消")
+			return
+		}
+		log.Printf("逾期升级检查失败: %v", err)
+		return
+	}
+
+	if notified > 0 {
+		log.Printf("逾期升级提醒已发送: count=%d", notified)
+	}
+}
+
+// evaluate 检查所有逾期事项，返回发送的提醒数量
+func (e *Escalator) evaluate(ctx context.Context) (int, error) {
+	now := e.clock.Now().UTC()
+
+	todos, err := e.store.ListOverdueTodosContext(ctx, now, e.policy.MinPriority)
+	if err != nil {
+		return 0, err
+	}
+
+	notified := 0
+	for _, todo := range todos {
+		if err := ctx.Err(); err != nil {
+			return notified, err
+		}
+
+		level := e.policy.StepFor(now.Sub(todo.DueDate))
+		if level < 0 {
+			continue
+		}
+		step := e.policy.Steps[level]
+
+		last, err := e.store.LastEscalationContext(ctx, todo.ID, level)
+		if err != nil {
+			return notified, err
+		}
+		if !step.ShouldNotify(last, now) {
+			continue
+		}
+
+		err = e.dispatcher.Send(ctx, step.Channel, notify.Notification{
+			UserID:    e.userID,
+			Kind:      model.NotificationReminder,
+			TodoID:    todo.ID,
+			ProjectID: todo.ProjectID,
+			Title:     fmt.Sprintf("待办事项已逾期：%s", todo.Title),
+			Body:      fmt.Sprintf("截止于 %s，已逾期 %s", todo.DueDate.Format("2006-01-02 15:04"), now.Sub(todo.DueDate).Round(time.Hour)),
+		})
+		if errors.Is(err, notify.ErrSuppressed) {
+			// 免打扰等原因被拦截，不记录，下一轮再试
+			continue
+		}
+		if err != nil {
+			log.Printf("发送升级提醒失败: todo_id=%d, channel=%s, error=%v", todo.ID, step.Channel, err)
+			continue
+		}
+
+		if err := e.store.RecordEscalationContext(ctx, todo.ID, level, step.Channel, now); err != nil {
+			return notified, err
+		}
+		notified++
+	}
+
+	return notified, nil
+}
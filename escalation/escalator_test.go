@@ -0,0 +1,135 @@
+package escalation_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/clock"
+	"todo-list/database"
+	"todo-list/escalation"
+	"todo-list/model"
+	"todo-list/notify"
+)
+
+// 纽约 2026-03-08 02:00 EST 拨快到 03:00 EDT
+func TestEscalatorQuietHoursAcrossDST(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "escalation.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	prefs := model.DefaultNotificationPreferences("alice")
+	prefs.Timezone = "America/New_York"
+	prefs.QuietHoursStart, prefs.QuietHoursEnd = "22:00", "07:00"
+	if err := db.SaveNotificationPreferencesContext(ctx, prefs); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2026, 3, 8, 1, 0, 0, 0, time.UTC) // 3 月 7 日 20:00 EST
+	if _, err := db.ImportTodosContext(ctx, []model.Todo{{Title: "file taxes", Status: "pending", Priority: 3, DueDate: &due}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10:30 UTC 是 06:30 EDT，仍在免打扰时段；如果按拨快前的 EST 计算，11:30 UTC 也还在（06:30 EST）
+	fake := clock.NewFake(time.Date(2026, 3, 8, 10, 30, 0, 0, time.UTC))
+	dispatcher := notify.NewDispatcher(db, notify.InAppSender{Store: db, Clock: fake})
+	dispatcher.SetClock(fake)
+	policy := &escalation.Policy{MinPriority: 3, Steps: []escalation.Step{
+		{AfterHours: 0, RepeatEveryHours: 24, Channel: model.ChannelInApp},
+	}}
+	escalator := escalation.NewEscalator(policy, db, dispatcher, "alice")
+	escalator.SetClock(fake)
+
+	reminders := func() []model.Notification {
+		t.Helper()
+		list, err := db.ListNotificationsContext(ctx, "alice", false, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	escalator.Run(ctx)
+	if n := len(reminders()); n != 0 {
+		t.Fatalf("reminders during quiet hours = %d, want 0", n)
+	}
+
+	fake.Advance(time.Hour) // 07:30 EDT
+	escalator.Run(ctx)
+	list := reminders()
+	if len(list) != 1 {
+		t.Fatalf("reminders after quiet hours = %d, want 1", len(list))
+	}
+	if !list[0].CreatedAt.Equal(fake.Now()) {
+		t.Errorf("reminder created at %v, want %v", list[0].CreatedAt, fake.Now())
+	}
+
+	// 按 RepeatEveryHours 计算的是实际经过的时间，不受当地时间跳变影响
+	fake.Advance(23 * time.Hour)
+	escalator.Run(ctx)
+	if n := len(reminders()); n != 1 {
+		t.Fatalf("reminders before repeat interval = %d, want 1", n)
+	}
+	fake.Advance(time.Hour)
+	escalator.Run(ctx)
+	if n := len(reminders()); n != 2 {
+		t.Fatalf("reminders after repeat interval = %d, want 2", n)
+	}
+}
+
+func TestEscalatorSkipsMutedProject(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "escalation.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	muted, other := &model.Project{Name: "muted"}, &model.Project{Name: "other"}
+	for _, p := range []*model.Project{muted, other} {
+		if err := db.CreateProjectContext(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prefs := model.DefaultNotificationPreferences("alice")
+	prefs.MutedProjects = []int{muted.ID}
+	if err := db.SaveNotificationPreferencesContext(ctx, prefs); err != nil {
+		t.Fatal(err)
+	}
+
+	due := time.Date(2026, 3, 8, 1, 0, 0, 0, time.UTC)
+	todos := []model.Todo{
+		{Title: "in muted project", Status: "pending", Priority: 3, DueDate: &due, ProjectID: &muted.ID},
+		{Title: "in other project", Status: "pending", Priority: 3, DueDate: &due, ProjectID: &other.ID},
+		{Title: "no project", Status: "pending", Priority: 3, DueDate: &due},
+	}
+	if _, err := db.ImportTodosContext(ctx, todos); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC))
+	dispatcher := notify.NewDispatcher(db, notify.InAppSender{Store: db, Clock: fake})
+	dispatcher.SetClock(fake)
+	policy := &escalation.Policy{MinPriority: 3, Steps: []escalation.Step{
+		{AfterHours: 0, RepeatEveryHours: 24, Channel: model.ChannelInApp},
+	}}
+	escalator := escalation.NewEscalator(policy, db, dispatcher, "alice")
+	escalator.SetClock(fake)
+	escalator.Run(ctx)
+
+	list, err := db.ListNotificationsContext(ctx, "alice", false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("reminders = %d, want 2 (muted project skipped)", len(list))
+	}
+	for _, n := range list {
+		if strings.Contains(n.Title, "in muted project") {
+			t.Errorf("reminder sent for muted project: %q", n.Title)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package escalation 对逾期的高优先级待办事项按策略逐级升级提醒
+package escalation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+	"todo-list/model"
+)
+
+// Step 升级策略中的一级
+// 逾期超过 AfterHours 后进入该级，每隔 RepeatEveryHours 通过 Channel 提醒一次
+type Step struct {
+	AfterHours       int    `json:"after_hours"`
+	RepeatEveryHours int    `json:"repeat_every_hours"`
+	Channel          string `json:"channel"`
+}
+
+// Policy 升级策略
+type Policy struct {
+	MinPriority int    `json:"min_priority"` // 只处理优先级 >= MinPriority 的待办事项
+	Steps       []Step `json:"steps"`        // 按 AfterHours 升序排列
+}
+
+// LoadPolicy 从 JSON 文件加载升级策略
+//
+//	{
+//	  "min_priority": 3,
+//	  "steps": [
+//	    {"after_hours": 0,  "repeat_every_hours": 24, "channel": "in_app"},
+//	    {"after_hours": 72, "repeat_every_hours": 24, "channel": "email"}
+//	  ]
+//	}
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取升级策略失败：%w", err)
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("解析升级策略失败：%w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate 校验策略
+func (p *Policy) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("升级策略至少需要一级")
+	}
+
+	for i, step := range p.Steps {
+		if step.AfterHours < 0 {
+			return fmt.Errorf("第 %d 级: after_hours 不能为负数", i+1)
+		}
+		if step.RepeatEveryHours <= 0 {
+			return fmt.Errorf("第 %d 级: repeat_every_hours 必须大于 0", i+1)
+		}
+		if !isKnownChannel(step.Channel) {
+			return fmt.Errorf("第 %d 级: 未知的通知渠道 %q", i+1, step.Channel)
+		}
+		if i > 0 && step.AfterHours <= p.Steps[i-1].AfterHours {
+			return fmt.Errorf("第 %d 级: after_hours 必须大于上一级", i+1)
+		}
+	}
+	return nil
+}
+
+// StepFor 返回逾期时长对应的级别（下标），还没到第一级时返回 -1
+func (p *Policy) StepFor(overdue time.Duration) int {
+	level := -1
+	for i, step := range p.Steps {
+		if overdue >= time.Duration(step.AfterHours)*time.Hour {
+			level = i
+		}
+	}
+	return level
+}
+
+// ShouldNotify 判断该级别此刻是否需要再次提醒
+func (s Step) ShouldNotify(lastNotified *time.Time, now time.Time) bool {
+	if lastNotified == nil {
+		return true
+	}
+	return now.Sub(*lastNotified) >= time.Duration(s.RepeatEveryHours)*time.Hour
+}
+
+func isKnownChannel(channel string) bool {
+	for _, c := range model.NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
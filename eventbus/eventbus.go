@@ -0,0 +1,47 @@
+// Package eventbus 待办事项变更事件的唤醒通知
+//
+// 通知只表示“有新的变更事件”，不带事件内容：订阅者（SSE 连接、出站 webhook 推送）收到后仍按事件 ID 从存储中读取，
+// 通知丢失、重复或合并都不影响正确性，订阅者另外保留较慢的轮询兜底。
+// 多实例部署时由各实例共享的传输发布通知（DB_DRIVER=postgres 时为 LISTEN/NOTIFY，见 postgres.Store.ListenTodoEvents），
+// 任一实例上的修改都会唤醒所有实例上的订阅者。
+package eventbus
+
+import "sync"
+
+// Bus 在进程内把通知转发给所有订阅者
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+// New 创建没有订阅者的 Bus
+func New() *Bus {
+	return &Bus{subs: make(map[chan struct{}]struct{})}
+}
+
+// Subscribe 订阅通知，返回的通道缓冲为 1，读取之前的多次通知合并为一次；不再需要时调用 cancel 取消订阅
+func (b *Bus) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// Publish 唤醒所有订阅者，不阻塞
+func (b *Bus) Publish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
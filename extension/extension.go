@@ -0,0 +1,136 @@
+// Package extension 编译期注册的扩展点，下游分支不改动 handler 就能扩展行为
+//
+// 扩展在自己包的 init 中调用 Register，再在 cmd/server 中匿名导入该包即可生效：
+//
+//	import _ "example.com/fork/audit"
+//
+// 钩子按注册顺序执行。扩展在进程启动前注册完毕，运行期间只读，不需要加锁。
+package extension
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"todo-list/model"
+	"todo-list/notify"
+)
+
+// Extension 一个扩展，未用到的钩子留空即可
+type Extension struct {
+	Name string
+
+	// BeforeCreate 在待办事项写入数据库之前调用，可以修改 todo，返回错误时拒绝创建
+	// 返回 *apperr.Error 时按其错误码响应，其他错误按 500 处理
+	BeforeCreate func(ctx context.Context, todo *model.Todo) error
+
+	// AfterComplete 在待办事项进入终态并保存之后调用，在请求中同步执行，
+	// 耗时的工作应放入后台任务队列
+	AfterComplete func(ctx context.Context, todo *model.Todo)
+
+	// Notifiers 额外的通知渠道，渠道名与内置渠道相同时替换内置发送器
+	Notifiers []notify.Sender
+
+	// Authenticate 自定义认证，返回请求方的标识；返回错误时请求以 401 拒绝
+	// 整个进程只能注册一个认证扩展
+	Authenticate func(r *http.Request) (subject string, err error)
+}
+
+var registry []Extension
+
+// Register 注册扩展，只能在 init 中调用；名称重复或注册第二个认证扩展时 panic
+func Register(e Extension) {
+	if e.Name == "" {
+		panic("extension: Register with empty name")
+	}
+	for _, existing := range registry {
+		if existing.Name == e.Name {
+			panic(fmt.Sprintf("extension: Register called twice for %q", e.Name))
+		}
+		if existing.Authenticate != nil && e.Authenticate != nil {
+			panic(fmt.Sprintf("extension: %q and %q both provide Authenticate", existing.Name, e.Name))
+		}
+	}
+	registry = append(registry, e)
+}
+
+// Names 已注册扩展的名称，按注册顺序
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for _, e := range registry {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// BeforeCreate 依次执行所有扩展的 BeforeCreate，遇到第一个错误即停止
+func BeforeCreate(ctx context.Context, todo *model.Todo) error {
+	for _, e := range registry {
+		if e.BeforeCreate == nil {
+			continue
+		}
+		if err := e.BeforeCreate(ctx, todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasAfterComplete 是否有扩展关心完成事件，没有时调用方可以省掉额外的查询
+func HasAfterComplete() bool {
+	for _, e := range registry {
+		if e.AfterComplete != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AfterComplete 依次执行所有扩展的 AfterComplete，单个扩展 panic 只记录日志
+func AfterComplete(ctx context.Context, todo *model.Todo) {
+	for _, e := range registry {
+		if e.AfterComplete == nil {
+			continue
+		}
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Printf("extension %s: AfterComplete panic: %v", e.Name, err)
+				}
+			}()
+			e.AfterComplete(ctx, todo)
+		}()
+	}
+}
+
+// Notifiers 所有扩展提供的通知渠道
+func Notifiers() []notify.Sender {
+	var senders []notify.Sender
+	for _, e := range registry {
+		senders = append(senders, e.Notifiers...)
+	}
+	return senders
+}
+
+// Authenticator 注册的认证函数，没有认证扩展时为 nil
+func Authenticator() func(r *http.Request) (string, error) {
+	for _, e := range registry {
+		if e.Authenticate != nil {
+			return e.Authenticate
+		}
+	}
+	return nil
+}
+
+type subjectKey struct{}
+
+// WithSubject 把认证得到的请求方标识放入 Context
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFromContext 取出请求方标识，未启用认证扩展时为空
+func SubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
@@ -0,0 +1,147 @@
+// Package features 功能开关，用于逐步上线实验性功能
+//
+// 开关的默认值来自配置（FEATURE_FLAGS），运行时可以通过管理接口临时切换，
+// 切换只保存在进程内存中，重启后恢复为配置的值；多实例部署时需要逐个实例切换。
+package features
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Flag 功能开关名称
+type Flag string
+
+// 已知的功能开关
+const (
+	FTSSearch Flag = "fts_search" // 全文检索
+	GraphQL   Flag = "graphql"    // GraphQL 接口
+	Sync      Flag = "sync"       // 离线同步
+)
+
+// known 已知开关的说明，新增开关必须登记在这里，默认全部关闭
+var known = map[Flag]string{
+	FTSSearch: "全文检索（实验性）",
+	GraphQL:   "GraphQL 接口（实验性）",
+	Sync:      "离线同步接口（实验性）",
+}
+
+// ErrUnknownFlag 开关名称未登记
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Status 一个开关的当前状态
+type Status struct {
+	Name        Flag   `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Default     bool   `json:"default"`    // 配置中的值
+	Overridden  bool   `json:"overridden"` // 是否在运行时被管理接口修改过
+}
+
+// Parse 解析 FEATURE_FLAGS：逗号分隔，"name" 或 "name=true" 表示开启，"name=false" 表示关闭
+func Parse(spec string) (map[Flag]bool, error) {
+	flags := map[Flag]bool{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(item, "=")
+		flag := Flag(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := known[flag]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownFlag, name)
+		}
+		enabled := true
+		if hasValue {
+			var err error
+			if enabled, err = strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %q", flag, value)
+			}
+		}
+		flags[flag] = enabled
+	}
+	return flags, nil
+}
+
+// Set 当前进程的功能开关，可以并发读写
+type Set struct {
+	mu        sync.RWMutex
+	defaults  map[Flag]bool
+	overrides map[Flag]bool
+}
+
+// New 以配置的值创建开关集合，未出现在 defaults 中的开关为关闭
+func New(defaults map[Flag]bool) *Set {
+	d := make(map[Flag]bool, len(defaults))
+	for flag, enabled := range defaults {
+		d[flag] = enabled
+	}
+	return &Set{defaults: d, overrides: map[Flag]bool{}}
+}
+
+// Enabled 开关是否开启，未登记的开关总是关闭
+func (s *Set) Enabled(flag Flag) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if enabled, ok := s.overrides[flag]; ok {
+		return enabled
+	}
+	return s.defaults[flag]
+}
+
+// Toggle 运行时切换开关，和配置的值相同时视为取消覆盖
+func (s *Set) Toggle(flag Flag, enabled bool) error {
+	if _, ok := known[flag]; !ok {
+		return ErrUnknownFlag
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.defaults[flag] == enabled {
+		delete(s.overrides, flag)
+	} else {
+		s.overrides[flag] = enabled
+	}
+	return nil
+}
+
+// Get 返回一个开关的状态
+func (s *Set) Get(flag Flag) (Status, error) {
+	description, ok := known[flag]
+	if !ok {
+		return Status{}, ErrUnknownFlag
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status := Status{Name: flag, Description: description, Default: s.defaults[flag], Enabled: s.defaults[flag]}
+	if enabled, ok := s.overrides[flag]; ok {
+		status.Enabled = enabled
+		status.Overridden = true
+	}
+	return status, nil
+}
+
+// List 返回所有已知开关的状态，按名称排序
+func (s *Set) List() []Status {
+	list := make([]Status, 0, len(known))
+	for flag := range known {
+		status, _ := s.Get(flag)
+		list = append(list, status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// EnabledFlags 返回开启的开关名称，按名称排序
+func (s *Set) EnabledFlags() []string {
+	names := []string{}
+	for _, status := range s.List() {
+		if status.Enabled {
+			names = append(names, string(status.Name))
+		}
+	}
+	return names
+}
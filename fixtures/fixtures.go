@@ -0,0 +1,184 @@
+// Package fixtures 把 YAML / JSON 写的样例数据载入存储，供测试和演示使用
+//
+// 文件格式与工作区归档（GET /api/v1/admin/export）相同，顶层按类型分节：
+//
+//	todos:
+//	  - id: 1
+//	    title: 写周报
+//	    priority: high
+//	comments:
+//	  - todo_id: 1
+//	    author: alice
+//	    body: 记得附上数据
+//	goals:
+//	  - title: 季度目标
+//	    todo_ids: [1]
+//
+// id 只在文件内部用来相互引用，载入时重新分配；各节的先后顺序不影响载入，
+// 引用关系由归档导入按依赖顺序处理，新增的数据类型只要进入归档就能写在样例中。
+// 省略的字段使用与接口创建时相同的默认值（状态 pending、默认优先级、创建时间为当前时间），
+// priority 可以写数值或名称。
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"todo-list/database"
+	"todo-list/model"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Store 载入样例需要的数据访问（database.DB 实现了该接口）
+// 载入到 Context 中的工作区，工作区必须为空
+type Store interface {
+	ImportArchiveContext(ctx context.Context, archive *database.Archive) (database.ArchiveImportResult, error)
+}
+
+// Load 解析一个或多个样例文件，合并后一次性载入（全部成功或全部回滚）
+// 多个文件之间可以相互引用，id 在所有文件中不能重复；优先级名称按 scale 换算
+func Load(ctx context.Context, store Store, scale model.PriorityScale, paths ...string) (database.ArchiveImportResult, error) {
+	archive, err := Parse(scale, paths...)
+	if err != nil {
+		return database.ArchiveImportResult{}, err
+	}
+	return store.ImportArchiveContext(ctx, archive)
+}
+
+// Parse 解析样例文件并合并为一个归档，按扩展名识别格式（.yaml / .yml / .json）
+func Parse(scale model.PriorityScale, paths ...string) (*database.Archive, error) {
+	merged := &database.Archive{Version: database.ArchiveVersion}
+	for _, path := range paths {
+		archive, err := parseFile(path, scale)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		merge(merged, archive)
+	}
+	return merged, nil
+}
+
+// parseFile 读取一个文件：先解析为通用结构，补齐默认值后再按归档格式解码
+func parseFile(path string, scale model.PriorityScale) (*database.Archive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".json":
+		err = json.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("不支持的样例格式，请使用 .yaml、.yml 或 .json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析失败：%w", err)
+	}
+
+	if err := applyDefaults(doc, time.Now().UTC(), scale); err != nil {
+		return nil, err
+	}
+
+	// YAML 解析出的结构可以直接转成 JSON，复用模型上的 JSON 标签
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("转换失败：%w", err)
+	}
+	var archive database.Archive
+	if err := json.Unmarshal(normalized, &archive); err != nil {
+		return nil, fmt.Errorf("解码失败：%w", err)
+	}
+	return &archive, nil
+}
+
+// applyDefaults 为省略的字段填入默认值
+func applyDefaults(doc map[string]interface{}, now time.Time, scale model.PriorityScale) error {
+	for section, value := range doc {
+		items, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, v := range items {
+			item, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s 中的每一项都必须是对象", section)
+			}
+			setDefault(item, "created_at", now)
+			switch section {
+			case "todos":
+				if err := todoDefaults(item, now, scale); err != nil {
+					return err
+				}
+			case "comments":
+				if body, ok := item["body"].(string); ok {
+					setDefault(item, "mentions", model.ParseMentions(body))
+				}
+			case "links":
+				setDefault(item, "status", model.LinkStatusPending)
+			case "goals":
+				setDefault(item, "updated_at", now)
+			case "habits":
+				setDefault(item, "weekday", 1)
+				setDefault(item, "active", true)
+			case "automation_rules":
+				setDefault(item, "enabled", true)
+			}
+		}
+	}
+	return nil
+}
+
+// todoDefaults 待办事项的默认值与 POST /api/v1/todos 一致
+// 优先级名称换算为数值，截止日期可以使用接口接受的任意写法（不带时区时按 UTC）
+func todoDefaults(item map[string]interface{}, now time.Time, scale model.PriorityScale) error {
+	setDefault(item, "status", "pending")
+	setDefault(item, "version", 1)
+	setDefault(item, "updated_at", item["created_at"])
+	setDefault(item, "priority", model.DefaultPriority)
+
+	if label, ok := item["priority"].(string); ok {
+		value, ok := scale.Value(label)
+		if !ok {
+			return fmt.Errorf("未知的优先级 %q", label)
+		}
+		item["priority"] = value
+	}
+	if due, ok := item["due_date"].(string); ok {
+		t, err := model.ParseDueDate(due, time.UTC)
+		if err != nil {
+			return fmt.Errorf("截止日期 %q：%w", due, err)
+		}
+		item["due_date"] = t
+	}
+	if item["status"] == "completed" {
+		setDefault(item, "completed_at", now)
+	}
+	return nil
+}
+
+func setDefault(item map[string]interface{}, key string, value interface{}) {
+	if _, ok := item[key]; !ok {
+		item[key] = value
+	}
+}
+
+// merge 把 src 的各节追加到 dst，工作区设置以最后一个设置了名称的文件为准
+func merge(dst, src *database.Archive) {
+	if src.Workspace.Name != "" {
+		dst.Workspace = src.Workspace
+	}
+	dst.Todos = append(dst.Todos, src.Todos...)
+	dst.Comments = append(dst.Comments, src.Comments...)
+	dst.Links = append(dst.Links, src.Links...)
+	dst.Goals = append(dst.Goals, src.Goals...)
+	dst.Habits = append(dst.Habits, src.Habits...)
+	dst.AutomationRules = append(dst.AutomationRules, src.AutomationRules...)
+}
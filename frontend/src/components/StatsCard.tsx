@@ -1,5 +1,5 @@
 import React, { memo } from 'react';
-import { TodoStats } from '../types';
+import { FocusScore, TodoStats } from '../types';
 import '../styles/StatsCard.css';
 
 interface StatsCardProps {
@@ -33,6 +33,39 @@ const StatItem = memo<StatItemProps>(({ value, label, variant, refreshing }) =>
 
 StatItem.displayName = 'StatItem';
 
+const RING_RADIUS = 26;
+const RING_CIRCUMFERENCE = 2 * Math.PI * RING_RADIUS;
+
+// 专注度进度环：今天加权完成分占今天全部待处理分的比例
+const FocusRing = memo<{ focus: FocusScore; refreshing: boolean }>(({ focus, refreshing }) => {
+  const offset = RING_CIRCUMFERENCE * (1 - focus.score / 100);
+  const classes = ['stat-card', 'stat-card-focus', refreshing ? 'stat-card-refreshing' : '']
+    .filter(Boolean).join(' ');
+
+  return (
+    <div
+      className={classes}
+      title={`今天完成 ${focus.completed} 项（清理逾期 ${focus.overdue_cleared} 项），剩余 ${focus.remaining} 分`}
+    >
+      <svg className="focus-ring" viewBox="0 0 64 64" aria-hidden="true">
+        <circle className="focus-ring-track" cx="32" cy="32" r={RING_RADIUS} />
+        <circle
+          className="focus-ring-progress"
+          cx="32"
+          cy="32"
+          r={RING_RADIUS}
+          strokeDasharray={RING_CIRCUMFERENCE}
+          strokeDashoffset={offset}
+        />
+        <text x="32" y="37" textAnchor="middle" className="focus-ring-text">{focus.score}</text>
+      </svg>
+      <div className="stat-label">今日专注</div>
+    </div>
+  );
+});
+
+FocusRing.displayName = 'FocusRing';
+
 const StatsCard: React.FC<StatsCardProps> = ({ stats, loading, refreshing = false }) => {
   // 只在首次加载且没有数据时显示 loading
   if (loading && !stats) {
@@ -55,6 +88,7 @@ const StatsCard: React.FC<StatsCardProps> = ({ stats, loading, refreshing = fals
   return (
     <div className={`stats-container ${refreshing ? 'stats-container-refreshing' : ''}`}>
       <div className={`stats-grid ${refreshing ? 'is-refreshing' : ''}`}>
+        {stats.focus && <FocusRing focus={stats.focus} refreshing={refreshing} />}
         <StatItem value={stats.total} label="总任务" variant="primary" refreshing={refreshing} />
         <StatItem value={stats.pending} label="待完成" variant="warning" refreshing={refreshing} />
         <StatItem value={stats.completed} label="已完成" variant="success" refreshing={refreshing} />
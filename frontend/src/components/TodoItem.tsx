@@ -221,6 +221,7 @@ const TodoItem: React.FC<TodoItemProps> = ({
                 value={editTitle}
                 onChange={(e) => setEditTitle(e.target.value)}
                 placeholder="标题"
+                maxLength={100}
               />
               <textarea
                 className="todo-edit-textarea"
@@ -228,6 +229,7 @@ const TodoItem: React.FC<TodoItemProps> = ({
                 onChange={(e) => setEditDescription(e.target.value)}
                 placeholder="描述（可选）"
                 rows={2}
+                maxLength={500}
               />
               <div className="todo-edit-hint">
                 提示: Ctrl+Enter 保存，Esc 取消
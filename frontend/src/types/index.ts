@@ -31,6 +31,10 @@ export interface Todo {
   created_at: string;
   updated_at: string;
   completed_at?: string;
+  latitude?: number;   // 位置提醒（可选）
+  longitude?: number;
+  radius?: number;     // 提醒半径（米）
+  estimated_minutes?: number; // 预估耗时（分钟）
 }
 
 export interface ApiResponse<T> {
@@ -61,6 +65,26 @@ export interface TodoStats {
   overdue: number;
   today: number;
   this_week: number;
+  by_status?: Record<string, number>;
+  overdue_buckets?: OverdueBuckets;
+  focus?: FocusScore;
+}
+
+// 当天的专注度（按优先级加权），score 为 0-100
+export interface FocusScore {
+  date: string;
+  completed: number;
+  overdue_cleared: number;
+  points: number;
+  remaining: number;
+  score: number;
+}
+
+// 逾期时长分布
+export interface OverdueBuckets {
+  lt_1d: number;
+  '1d_7d': number;
+  gt_7d: number;
 }
 
 // 批量操作相关类型
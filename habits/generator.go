@@ -0,0 +1,92 @@
+package habits
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+)
+
+// Store 习惯生成需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	ListAllActiveHabitsContext(ctx context.Context) ([]model.Habit, error)
+	CreateHabitTodoContext(ctx context.Context, habit *model.Habit, period string, todo *model.Todo) (bool, error)
+	GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+}
+
+// Generator 为每个习惯的当前周期生成待办事项，由调度器周期调用
+type Generator struct {
+	store  Store
+	userID string // 周期按默认用户的时区划分
+	clock  clock.Clock
+}
+
+// NewGenerator 创建习惯生成器
+func NewGenerator(store Store, userID string, clk clock.Clock) *Generator {
+	return &Generator{
+		store:  store,
+		userID: userID,
+		clock:  clk,
+	}
+}
+
+// Run 执行一轮生成（接受 Context 参数，供调度器使用）
+func (g *Generator) Run(ctx context.Context) {
+	created, err := g.generate(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("习惯生成超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("习惯生成已取消")
+			return
+		}
+		log.Printf("习惯生成失败: %v", err)
+		return
+	}
+
+	if created > 0 {
+		log.Printf("习惯待办事项已生成: count=%d", created)
+	}
+}
+
+// generate 检查所有未暂停的习惯，返回生成的待办事项数量
+func (g *Generator) generate(ctx context.Context) (int, error) {
+	habits, err := g.store.ListAllActiveHabitsContext(ctx)
+	if err != nil || len(habits) == 0 {
+		return 0, err
+	}
+
+	loc := time.UTC
+	if prefs, err := g.store.GetNotificationPreferencesContext(ctx, g.userID); err == nil {
+		if l, err := time.LoadLocation(prefs.Timezone); err == nil {
+			loc = l
+		}
+	}
+	now := g.clock.Now().In(loc)
+
+	created := 0
+	for i := range habits {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+
+		habit := &habits[i]
+		period := habit.Period(now)
+		if period == "" || period == habit.LastPeriod {
+			continue
+		}
+
+		ok, err := g.store.CreateHabitTodoContext(ctx, habit, period, habit.NewTodo(now))
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
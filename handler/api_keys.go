@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/extension"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// APIKeyHeader 脚本和集成携带 API key 的请求头
+const APIKeyHeader = "X-API-Key"
+
+// CreateAPIKeyRequest 创建 API key 的请求
+type CreateAPIKeyRequest struct {
+	Name          string `json:"name" example:"备份脚本"`
+	ExpiresInDays int    `json:"expires_in_days,omitempty" example:"90"` // 0 表示不过期
+	Role          string `json:"role,omitempty" enums:"admin,member"`    // 默认 member，只有管理员可以创建 admin key
+	// member key 可以访问的工作区，默认只有 default；admin key 可以访问所有工作区，不能指定
+	Workspaces []string `json:"workspaces,omitempty" example:"default,home"`
+}
+
+// CreatedAPIKey 新建的 API key，明文 key 只在这里出现一次
+type CreatedAPIKey struct {
+	model.APIKey
+	Key string `json:"key" example:"tdl_1a2b3c4d..."`
+}
+
+// apiKeyContextKey Context 中本次请求使用的 API key
+type apiKeyContextKey struct{}
+
+// requestAPIKey 本次请求携带的 API key，没有带 key 时返回 nil
+func requestAPIKey(r *http.Request) *model.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey{}).(*model.APIKey)
+	return key
+}
+
+// authenticateAPIKey 校验 X-API-Key，返回 key 本身；key 无效、已撤销或已过期时返回 401
+func (h *Handler) authenticateAPIKey(r *http.Request, raw string) (*model.APIKey, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), DefaultTimeout)
+	defer cancel()
+
+	key, err := h.repos.AuthenticateAPIKeyContext(ctx, model.HashAPIKey(raw))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apperr.New(apperr.CodeUnauthorized, "API key 无效、已撤销或已过期")
+	}
+	if err != nil {
+		return nil, storeError(err, "校验 API key 失败")
+	}
+	return key, nil
+}
+
+// isAdmin 请求方能否调用管理接口（在 Authenticate 之后调用）：
+// 带 API key 时看 key 的角色，认证扩展的请求方要在 ADMIN_SUBJECTS 中（扩展没有给出标识时拒绝）；
+// 只有完全不需要认证的本机单用户部署（没有认证扩展、没有开启 AUTH_REQUIRED、也没有创建过 API key）才放行，其他情况一律拒绝
+func (h *Handler) isAdmin(r *http.Request) bool {
+	if key := requestAPIKey(r); key != nil {
+		return key.IsAdmin()
+	}
+	if h.authenticate != nil {
+		subject := extension.SubjectFromContext(r.Context())
+		return subject != "" && slices.Contains(h.cfg.AdminSubjects, strings.ToLower(subject))
+	}
+	return !h.cfg.AuthRequired && !h.keysExist.Load()
+}
+
+// RequireAdmin 中间件：/api/v1/admin/* 管理接口只允许管理员调用，其他请求方返回 403
+// 放在 Authenticate 之后，浏览器的 CORS 预检请求不带认证信息，始终放行
+func (h *Handler) RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions && !h.isAdmin(r) {
+			h.sendError(w, apperr.CodeForbidden, "需要管理员权限")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// ListAPIKeys API key 列表
+// @Summary API key 列表
+// @Description 当前用户的所有 API key，包括已撤销和已过期的；只返回开头部分（prefix），不返回 key 本身。
+// @Description 用 member key 调用时只返回这个 key 自己
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.APIKey}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/api-keys [get]
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListAPIKeys", timeout: DefaultTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			keys, err := h.repos.ListAPIKeysContext(ctx, currentUserID(r))
+			if err != nil {
+				return nil, storeError(err, "查询 API key 失败")
+			}
+			if caller := requestAPIKey(r); caller != nil && !caller.IsAdmin() {
+				keys = slices.DeleteFunc(keys, func(k model.APIKey) bool { return k.ID != caller.ID })
+			}
+			return keys, nil
+		})
+}
+
+// CreateAPIKey 创建 API key
+// @Summary 创建 API key
+// @Description 脚本和集成在 X-API-Key 请求头中携带 key 即可调用接口，不需要登录；
+// @Description 响应中的 key 只返回这一次，服务端只保存哈希，丢失后只能撤销重建；
+// @Description role 默认 member，admin key 才能调用 /api/v1/admin/* 管理接口，只有管理员可以创建；
+// @Description member key 只能访问 workspaces 中的工作区（默认只有 default），其他工作区返回 404
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body handler.CreateAPIKeyRequest true "名称和有效期"
+// @Success 201 {object} handler.Response{data=handler.CreatedAPIKey}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/api-keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateAPIKey", timeout: CreateTimeout, status: http.StatusCreated, message: "API key 已创建，请妥善保存"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req CreateAPIKeyRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			key := model.APIKey{Name: req.Name, UserID: currentUserID(r), Role: req.Role, Workspaces: req.Workspaces}
+			if err := key.Validate(); err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
+			if key.IsAdmin() && !h.isAdmin(r) {
+				return nil, apperr.New(apperr.CodeForbidden, "只有管理员可以创建 admin key")
+			}
+			if err := h.checkKeyWorkspaces(ctx, r, key.Workspaces); err != nil {
+				return nil, err
+			}
+			if req.ExpiresInDays < 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "expires_in_days 不能为负数")
+			}
+			if req.ExpiresInDays > 0 {
+				expiresAt := h.clock.Now().UTC().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+				key.ExpiresAt = &expiresAt
+			}
+
+			raw := model.NewAPIKey(&key)
+			if err := h.repos.CreateAPIKeyContext(ctx, &key, model.HashAPIKey(raw)); err != nil {
+				return nil, storeError(err, "保存 API key 失败")
+			}
+			h.keysExist.Store(true)
+			return CreatedAPIKey{APIKey: key, Key: raw}, nil
+		})
+}
+
+// checkKeyWorkspaces 新 key 绑定的工作区必须存在，member key 不能把自己访问不了的工作区授权给新 key
+func (h *Handler) checkKeyWorkspaces(ctx context.Context, r *http.Request, slugs []string) error {
+	caller := requestAPIKey(r)
+	for _, slug := range slugs {
+		if caller != nil && !caller.CanAccess(slug) {
+			return apperr.New(apperr.CodeForbidden, fmt.Sprintf("不能授权访问工作区 %s", slug))
+		}
+		if slug == model.DefaultWorkspace {
+			continue
+		}
+		ws, err := h.repos.GetWorkspaceContext(ctx, slug)
+		if err != nil {
+			return storeError(err, "获取工作区失败")
+		}
+		if ws == nil {
+			return apperr.New(apperr.CodeWorkspaceNotFound, fmt.Sprintf("工作区 %s 不存在", slug))
+		}
+	}
+	return nil
+}
+
+// RevokeAPIKey 撤销 API key
+// @Summary 撤销 API key
+// @Description 撤销后立即失效；记录保留在列表中，重复撤销不改变撤销时间。member key 只能撤销自己
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} handler.Response{data=model.APIKey}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "RevokeAPIKey", timeout: UpdateTimeout, message: "API key 已撤销"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			// 用户 ID 在接入认证前都相同，不能区分 key 的归属，member key 只能撤销自己
+			if caller := requestAPIKey(r); caller != nil && !caller.IsAdmin() && caller.ID != id {
+				return nil, apperr.New(apperr.CodeForbidden, "member key 只能撤销自己")
+			}
+			key, err := h.repos.RevokeAPIKeyContext(ctx, currentUserID(r), id)
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, apperr.Wrap(err, apperr.CodeNotFound, "API key 不存在")
+			}
+			if err != nil {
+				return nil, storeError(err, "撤销 API key 失败")
+			}
+			return key, nil
+		})
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/storage"
+)
+
+// maxArchiveBytes 导入归档的请求体上限
+const maxArchiveBytes = 100 << 20 // 100MB
+
+// ExportArchive 导出当前工作区的完整归档
+// 归档是与存储后端无关的 JSON，可以导入到另一个部署的空工作区
+// @Summary 导出工作区归档
+// @Description 导出当前工作区（X-Workspace 请求头，默认工作区）的待办事项、评论、链接、目标、习惯、自动化规则和工作区设置
+// @Description 附件文件不在归档中，链接、目标和习惯只在 DB_DRIVER=sqlite 时导出；async=true 时作为后台任务执行，返回 202 和 Location，完成后从 result_url 下载
+// @Tags admin
+// @Produce json
+// @Param X-Workspace header string false "工作区标识"
+// @Param async query bool false "作为后台任务执行"
+// @Success 200 {object} storage.Archive
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/export [get]
+func (h *Handler) ExportArchive(w http.ResponseWriter, r *http.Request) {
+	if wantsAsync(r) {
+		h.serve(w, r, endpoint{name: "ExportArchive", timeout: UpdateTimeout, status: http.StatusAccepted, message: jobSubmittedMessage},
+			func(ctx context.Context, r *http.Request) (interface{}, error) {
+				return h.submitJob(ctx, w, jobKindExportArchive, nil)
+			})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ExportTimeout)
+	defer cancel()
+
+	archive, err := h.repos.ExportArchiveContext(ctx)
+	if err != nil {
+		h.sendAPIError(w, "ExportArchive", storeError(err, "导出归档失败"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=workspace-%s.json", archive.Workspace.Slug))
+	if err := json.NewEncoder(w).Encode(h.presenter().Apply(archive)); err != nil {
+		log.Printf("写入归档失败: %v", err)
+	}
+}
+
+// ImportArchive 把归档导入当前工作区
+// 目标工作区必须为空；所有记录重新分配 ID，全部成功或全部回滚
+// @Summary 导入工作区归档
+// @Description 把 GET /api/v1/admin/export 导出的归档导入当前工作区（必须为空），记录重新分配 ID
+// @Description 归档可以来自其他存储后端；当前存储不支持的链接、目标和习惯不导入，数量见结果中的 skipped
+// @Description async=true 时校验版本后作为后台任务执行，返回 202 和 Location
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param X-Workspace header string false "工作区标识"
+// @Param async query bool false "作为后台任务执行"
+// @Param archive body storage.Archive true "归档"
+// @Success 200 {object} handler.Response{data=storage.ArchiveImportResult}
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/import [post]
+func (h *Handler) ImportArchive(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxArchiveBytes)
+	e := endpoint{name: "ImportArchive", timeout: ImportTimeout, message: "导入归档成功"}
+	async := wantsAsync(r)
+	if async {
+		e.status, e.message = http.StatusAccepted, jobSubmittedMessage
+	}
+	h.serve(w, r, e,
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var archive storage.Archive
+			if err := decodeJSON(r, &archive); err != nil {
+				return nil, err
+			}
+			if archive.Version != storage.ArchiveVersion {
+				return nil, apperr.New(apperr.CodeInvalidArchive,
+					fmt.Sprintf("不支持的归档版本 %d，当前版本为 %d", archive.Version, storage.ArchiveVersion))
+			}
+			if async {
+				return h.submitJob(ctx, w, jobKindImportArchive, &archive)
+			}
+			return h.importArchive(ctx, &archive)
+		})
+}
+
+// importArchive 导入归档并把错误转换为接口错误，同步导入和后台任务共用
+func (h *Handler) importArchive(ctx context.Context, archive *storage.Archive) (interface{}, error) {
+	result, err := h.repos.ImportArchiveContext(ctx, archive)
+	var archiveErr *storage.ArchiveError
+	switch {
+	case errors.Is(err, storage.ErrWorkspaceNotEmpty):
+		return nil, apperr.Wrap(err, apperr.CodeWorkspaceNotEmpty, "目标工作区已有数据，请导入到新建的空工作区")
+	case errors.As(err, &archiveErr):
+		return nil, apperr.Wrap(err, apperr.CodeInvalidArchive, archiveErr.Message)
+	case err != nil:
+		return nil, storeError(err, "导入归档失败")
+	}
+	return result, nil
+}
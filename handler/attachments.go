@@ -0,0 +1,366 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// 文件名长度上限（字符）
+const maxAttachmentFilename = 255
+
+// errAttachmentTooLarge 上传的文件超过大小上限
+var errAttachmentTooLarge = errors.New("附件超过大小上限")
+
+// UploadAttachment 上传附件（multipart 字段 file）
+// 配置了扫描器时先扫描：发现病毒的文件隔离保存并返回 422，扫描器不可用时返回 503
+// @Summary 上传附件
+// @Description 配置了扫描器时先扫描：发现病毒的文件隔离保存并返回 422，扫描器不可用时返回 503
+// @Description 工作区的附件总大小超过 QUOTA_MAX_ATTACHMENT_BYTES 时返回 403
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param file formData file true "附件"
+// @Success 201 {object} handler.Response{data=model.Attachment}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 413 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 503 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/attachments [post]
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UploadAttachment", timeout: UploadTimeout, status: http.StatusCreated, message: "附件已上传"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			cfg := h.cfg.Attachments
+			if cfg.Scanner == nil && cfg.RequireScan {
+				return nil, apperr.New(apperr.CodeScanUnavailable, "未配置病毒扫描，暂不允许上传附件")
+			}
+
+			if _, err := h.todos.GetTodoByIDContext(ctx, todoID); err != nil {
+				return nil, storeError(err, "获取待办事项失败")
+			}
+
+			// 多留 1MB 给 multipart 边界和其他字段
+			r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxBytes+1<<20)
+			defer r.Body.Close()
+
+			tmp, filename, err := h.receiveUpload(r, cfg.MaxBytes)
+			if tmp != nil {
+				defer os.Remove(tmp.Name())
+				defer tmp.Close()
+			}
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.Is(err, errAttachmentTooLarge) || errors.As(err, &maxErr) {
+					return nil, apperr.New(apperr.CodeAttachmentTooLarge, "附件超过大小上限").
+						WithDetails(map[string]interface{}{"max_bytes": cfg.MaxBytes})
+				}
+				return nil, apperr.New(apperr.CodeParseError, err.Error())
+			}
+
+			return h.saveAttachment(ctx, w, todoID, tmp, filename)
+		})
+}
+
+// saveAttachment 扫描已接收的临时文件，写入存储并保存记录
+// 普通上传和分片上传（见 uploads.go）共用；调用方负责删除临时文件
+func (h *Handler) saveAttachment(ctx context.Context, w http.ResponseWriter, todoID int, tmp *os.File, filename string) (*model.Attachment, error) {
+	cfg := h.cfg.Attachments
+	attachment := &model.Attachment{
+		TodoID:      todoID,
+		Filename:    filename,
+		ContentType: sniffContentType(tmp, filename),
+		Status:      model.AttachmentUnscanned,
+		CreatedAt:   h.clock.Now().UTC(),
+	}
+	if info, err := tmp.Stat(); err == nil {
+		attachment.Size = info.Size()
+	}
+	if err := h.checkAttachmentQuota(ctx, attachment.Size); err != nil {
+		return nil, h.quotaAPIError(w, err)
+	}
+
+	if cfg.Scanner != nil {
+		verdict, err := cfg.Scanner.Scan(ctx, tmp.Name())
+		if err != nil {
+			return nil, apperr.Wrap(fmt.Errorf("scanner %s: %w", cfg.Scanner.Name(), err),
+				apperr.CodeScanUnavailable, "病毒扫描失败，请稍后重试")
+		}
+		attachment.Status = model.AttachmentClean
+		if verdict.Infected {
+			attachment.Status = model.AttachmentQuarantined
+			attachment.ScanResult = verdict.Signature
+		}
+	}
+
+	// 隔离的文件单独存放，方便管理员排查，但不会通过接口下载
+	prefix := "files"
+	if attachment.Status == model.AttachmentQuarantined {
+		prefix = "quarantine"
+	}
+	attachment.StorageKey = prefix + "/" + randomKey()
+
+	if err := h.storeAttachment(ctx, tmp, attachment); err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeStorageError, "保存附件失败")
+	}
+
+	if attachment.Status == model.AttachmentQuarantined {
+		log.Printf("Attachment quarantined: todo_id=%d, attachment_id=%d, signature=%s", todoID, attachment.ID, attachment.ScanResult)
+		return nil, apperr.New(apperr.CodeAttachmentInfected, "附件未通过病毒扫描，已被隔离").
+			WithDetails(map[string]interface{}{"attachment_id": attachment.ID, "signature": attachment.ScanResult})
+	}
+	return attachment, nil
+}
+
+// receiveUpload 把 multipart 中的 file 字段写入临时文件，超过 maxBytes 时返回 errAttachmentTooLarge
+func (h *Handler) receiveUpload(r *http.Request, maxBytes int64) (*os.File, string, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", fmt.Errorf("请使用 multipart/form-data 上传：%w", err)
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, "", fmt.Errorf("缺少 file 字段")
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("解析表单失败：%w", err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		filename := cleanFilename(part.FileName())
+		if filename == "" {
+			return nil, "", fmt.Errorf("缺少文件名")
+		}
+
+		tmp, err := os.CreateTemp("", "todo-upload-*")
+		if err != nil {
+			return nil, "", fmt.Errorf("创建临时文件失败：%w", err)
+		}
+
+		n, err := io.Copy(tmp, io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			return tmp, "", fmt.Errorf("读取文件失败：%w", err)
+		}
+		if n > maxBytes {
+			return tmp, "", errAttachmentTooLarge
+		}
+		return tmp, filename, nil
+	}
+}
+
+// storeAttachment 把临时文件写入存储并保存记录，记录保存失败时删除已写入的文件
+func (h *Handler) storeAttachment(ctx context.Context, tmp *os.File, a *model.Attachment) error {
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("读取临时文件失败：%w", err)
+	}
+	if err := h.files.Put(ctx, a.StorageKey, tmp, a.Size, a.ContentType); err != nil {
+		return err
+	}
+
+	if err := h.db.CreateAttachmentContext(ctx, a); err != nil {
+		if delErr := h.files.Delete(context.Background(), a.StorageKey); delErr != nil {
+			log.Printf("Failed to clean up attachment file: key=%s, error=%v", a.StorageKey, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// ListAttachments 获取待办事项的附件列表（包含隔离中的附件，便于用户知道上传失败的原因）
+// @Summary 附件列表
+// @Description 包含隔离中的附件
+// @Tags attachments
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Success 200 {object} handler.Response{data=[]model.Attachment}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/attachments [get]
+func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListAttachments", timeout: ListTimeout, message: "获取附件成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			attachments, err := h.db.ListAttachmentsContext(ctx, todoID)
+			if err != nil {
+				return nil, storeError(err, "查询附件失败")
+			}
+			return attachments, nil
+		})
+}
+
+// DownloadAttachment 下载附件内容，隔离中的附件返回 403
+// 对象存储支持临时地址时重定向过去，文件内容不经过本服务
+// @Summary 下载附件
+// @Description 对象存储支持临时地址时返回 302 重定向
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "待办事项ID或public_id"
+// @Param attachmentId path int true "附件ID"
+// @Success 200 {file} file
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/attachments/{attachmentId} [get]
+func (h *Handler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	// 下载写出的是文件内容而不是响应信封，只有错误交给 sendAPIError
+	ctx, cancel := context.WithTimeout(r.Context(), UploadTimeout)
+	defer cancel()
+
+	attachment, err := h.loadAttachment(ctx, r)
+	if err != nil {
+		h.sendAPIError(w, "DownloadAttachment", err)
+		return
+	}
+	if attachment.Status == model.AttachmentQuarantined {
+		h.sendError(w, apperr.CodeAttachmentQuarantined, "附件未通过病毒扫描，不允许下载")
+		return
+	}
+
+	if signer, ok := h.files.(storage.URLSigner); ok {
+		downloadURL, err := signer.PresignGet(attachment.StorageKey, attachment.Filename, h.cfg.Attachments.PresignTTL)
+		if err != nil {
+			h.sendAPIError(w, "DownloadAttachment", apperr.Wrap(err, apperr.CodeStorageError, "生成下载地址失败"))
+			return
+		}
+		http.Redirect(w, r, downloadURL, http.StatusFound)
+		return
+	}
+
+	body, err := h.files.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			h.sendError(w, apperr.CodeNotFound, "附件文件不存在")
+			return
+		}
+		h.sendAPIError(w, "DownloadAttachment", apperr.Wrap(err, apperr.CodeStorageError, "读取附件失败"))
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", fmt.Sprint(attachment.Size))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		log.Printf("Failed to send attachment: %v", err)
+	}
+}
+
+// DeleteAttachment 删除附件（记录和文件）
+// @Summary 删除附件
+// @Tags attachments
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param attachmentId path int true "附件ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/attachments/{attachmentId} [delete]
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteAttachment", timeout: DeleteTimeout, message: "附件已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			attachment, err := h.loadAttachment(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := h.db.DeleteAttachmentContext(ctx, attachment.TodoID, attachment.ID); err != nil {
+				return nil, storeError(err, "删除附件失败")
+			}
+
+			// 记录已删除，文件删除失败只留下孤儿文件，不影响结果
+			if err := h.files.Delete(ctx, attachment.StorageKey); err != nil {
+				log.Printf("Failed to delete attachment file: key=%s, error=%v", attachment.StorageKey, err)
+			}
+			return nil, nil
+		})
+}
+
+// loadAttachment 解析路径中的 id / attachmentId 并查询附件
+func (h *Handler) loadAttachment(ctx context.Context, r *http.Request) (*model.Attachment, error) {
+	todoID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	attachmentID, err := pathID(r, "attachmentId")
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, err := h.db.GetAttachmentContext(ctx, todoID, attachmentID)
+	if err != nil {
+		return nil, storeError(err, "查询附件失败")
+	}
+	if attachment == nil {
+		return nil, apperr.New(apperr.CodeNotFound, "附件不存在")
+	}
+	return attachment, nil
+}
+
+// cleanFilename 去掉客户端传来的目录部分，只保留文件名
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	if len([]rune(name)) > maxAttachmentFilename {
+		name = string([]rune(name)[:maxAttachmentFilename])
+	}
+	return name
+}
+
+// sniffContentType 根据文件内容判断类型，无法判断时按扩展名
+func sniffContentType(f *os.File, filename string) string {
+	buf := make([]byte, 512)
+	n, _ := f.ReadAt(buf, 0)
+	contentType := http.DetectContentType(buf[:n])
+	if contentType == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+			contentType = byExt
+		}
+	}
+	return contentType
+}
+
+// randomKey 生成随机的存储键
+func randomKey() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
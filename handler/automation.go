@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"todo-list/apperr"
+	"todo-list/extension"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// automation 某个事件下已启用的自动化规则，以及计算相对日期使用的时区
+type automation struct {
+	rules []model.AutomationRule
+	loc   *time.Location
+}
+
+// loadAutomation 读取当前工作区某个事件的规则
+// 规则是尽力而为的：读取失败只记录日志，不影响待办事项本身的保存
+func (h *Handler) loadAutomation(ctx context.Context, event string) *automation {
+	rules, err := h.repos.ListAutomationRulesContext(ctx, event)
+	if err != nil {
+		log.Printf("Failed to load automation rules for %s: %v", event, err)
+		return &automation{}
+	}
+	a := &automation{rules: rules, loc: time.UTC}
+	if len(rules) == 0 {
+		return a
+	}
+
+	// 规则不是在某个请求的上下文中定义的，相对日期按默认用户的时区计算
+	prefs, err := h.repos.GetNotificationPreferencesContext(ctx, DefaultUserID)
+	if err == nil {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			a.loc = loc
+		}
+	}
+	return a
+}
+
+// applyAutomation 依次执行满足条件的规则，返回是否有规则生效
+func (h *Handler) applyAutomation(a *automation, todo *model.Todo) bool {
+	applied := false
+	for i := range a.rules {
+		rule := &a.rules[i]
+		matched, err := rule.Matches(todo)
+		if err != nil {
+			log.Printf("automation rule %d (%s) condition failed: %v", rule.ID, rule.Name, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		status := todo.Status
+		if err := rule.Apply(todo, h.clock.Now(), a.loc); err != nil {
+			log.Printf("automation rule %d (%s) failed: %v", rule.ID, rule.Name, err)
+			continue
+		}
+		if todo.Status != status {
+			switch {
+			case h.workflow.IsTerminal(todo.Status) && todo.CompletedAt == nil:
+				now := h.clock.Now()
+				todo.CompletedAt = &now
+			case !h.workflow.IsTerminal(todo.Status):
+				todo.CompletedAt = nil
+			}
+		}
+		log.Printf("automation rule %d (%s) applied to todo %q", rule.ID, rule.Name, todo.Title)
+		applied = true
+	}
+	return applied
+}
+
+// beforeCreate 新建待办事项之前执行 todo.created 规则和扩展的 BeforeCreate
+func (h *Handler) beforeCreate(ctx context.Context, todo *model.Todo) error {
+	h.applyAutomation(h.loadAutomation(ctx, model.EventTodoCreated), todo)
+	return extension.BeforeCreate(ctx, todo)
+}
+
+// completed 待办事项进入终态并保存之后执行 todo.completed 规则、累加习惯的连续记录并调用扩展的 AfterComplete
+// 规则修改了待办事项时再保存一次
+func (h *Handler) completed(ctx context.Context, todo *model.Todo) {
+	if h.applyAutomation(h.loadAutomation(ctx, model.EventTodoCompleted), todo) {
+		if err := h.todos.UpdateTodoContext(ctx, todo); err != nil {
+			log.Printf("Failed to save todo %d after automation: %v", todo.ID, err)
+		}
+	}
+	// 习惯生成的待办事项只会在 SQLite 中，其他存储中的 ID 与习惯记录无关
+	if h.sqlite {
+		if err := h.db.CompleteHabitTodoContext(ctx, todo.ID, h.clock.Now()); err != nil {
+			log.Printf("Failed to record habit completion for todo %d: %v", todo.ID, err)
+		}
+	}
+	extension.AfterComplete(ctx, todo)
+	if todo.Recurrence != "" {
+		h.spawnRecurrences()
+	}
+}
+
+// watchesCompletion 是否有规则、习惯或扩展关心完成事件，没有时批量操作可以省掉额外的查询
+func (h *Handler) watchesCompletion(ctx context.Context) bool {
+	if extension.HasAfterComplete() {
+		return true
+	}
+	if len(h.loadAutomation(ctx, model.EventTodoCompleted).rules) > 0 {
+		return true
+	}
+	if !h.sqlite {
+		return false
+	}
+	habits, err := h.db.ListHabitsContext(ctx)
+	return err != nil || len(habits) > 0
+}
+
+// AutomationRuleRequest 创建或替换自动化规则的请求
+type AutomationRuleRequest struct {
+	Name      string                   `json:"name" example:"账单"`
+	Event     string                   `json:"event" example:"todo.created"`
+	Condition string                   `json:"condition" example:"todo.title.contains('账单')"` // CEL 表达式，为空表示总是满足
+	Actions   []model.AutomationAction `json:"actions"`
+	Enabled   *bool                    `json:"enabled,omitempty"` // 默认 true
+
+	// Conditions 旧版的条件列表，仍然接受，保存时转换为等价的 condition 表达式
+	Conditions []model.AutomationCondition `json:"conditions,omitempty"`
+}
+
+// automationRule 校验请求并转换为规则
+func (h *Handler) automationRule(req AutomationRuleRequest) (*model.AutomationRule, error) {
+	rule := &model.AutomationRule{
+		Name:       req.Name,
+		Event:      req.Event,
+		Condition:  req.Condition,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	for _, a := range rule.Actions {
+		if a.Set == "status" && !h.workflow.Has(a.Value) {
+			return nil, apperr.New(apperr.CodeInvalidStatus, "未知的状态 "+a.Value).
+				WithDetails(map[string]interface{}{"status": a.Value, "statuses": h.workflow.Names()})
+		}
+	}
+	return rule, nil
+}
+
+// ruleStoreError 规则不存在时返回 404，其他错误按 storeError 处理
+func ruleStoreError(err error, message string) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperr.Wrap(err, apperr.CodeNotFound, "自动化规则不存在")
+	}
+	return storeError(err, message)
+}
+
+// ListAutomationRules 查看自动化规则（管理接口）
+// @Summary 自动化规则列表
+// @Description 管理接口：当前工作区的所有自动化规则
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.AutomationRule}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/automation-rules [get]
+func (h *Handler) ListAutomationRules(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListAutomationRules", timeout: ListTimeout, message: "获取自动化规则成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			rules, err := h.repos.ListAutomationRulesContext(ctx, "")
+			if err != nil {
+				return nil, storeError(err, "查询自动化规则失败")
+			}
+			return rules, nil
+		})
+}
+
+// CreateAutomationRule 创建自动化规则（管理接口）
+// @Summary 创建自动化规则
+// @Description 管理接口：事件发生且 condition（CEL 表达式，变量 todo 包含 title、description、status、priority、project_id、estimated_minutes、due_date）为 true 时依次执行动作
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body handler.AutomationRuleRequest true "规则"
+// @Success 201 {object} handler.Response{data=model.AutomationRule}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/automation-rules [post]
+func (h *Handler) CreateAutomationRule(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateAutomationRule", timeout: CreateTimeout, status: http.StatusCreated, message: "自动化规则已创建"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req AutomationRuleRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			rule, err := h.automationRule(req)
+			if err != nil {
+				return nil, err
+			}
+			if err := h.repos.CreateAutomationRuleContext(ctx, rule); err != nil {
+				return nil, storeError(err, "创建自动化规则失败")
+			}
+			return rule, nil
+		})
+}
+
+// UpdateAutomationRule 替换自动化规则（管理接口）
+// @Summary 替换自动化规则
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Param request body handler.AutomationRuleRequest true "规则"
+// @Success 200 {object} handler.Response{data=model.AutomationRule}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/automation-rules/{id} [put]
+func (h *Handler) UpdateAutomationRule(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateAutomationRule", timeout: UpdateTimeout, message: "自动化规则已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req AutomationRuleRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			rule, err := h.automationRule(req)
+			if err != nil {
+				return nil, err
+			}
+			rule.ID = id
+			if err := h.repos.UpdateAutomationRuleContext(ctx, rule); err != nil {
+				return nil, ruleStoreError(err, "更新自动化规则失败")
+			}
+			updated, err := h.repos.GetAutomationRuleContext(ctx, id)
+			if err != nil {
+				return nil, ruleStoreError(err, "获取自动化规则失败")
+			}
+			return updated, nil
+		})
+}
+
+// DeleteAutomationRule 删除自动化规则（管理接口）
+// @Summary 删除自动化规则
+// @Tags admin
+// @Produce json
+// @Param id path int true "规则ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/automation-rules/{id} [delete]
+func (h *Handler) DeleteAutomationRule(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteAutomationRule", timeout: DeleteTimeout, message: "自动化规则已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.repos.DeleteAutomationRuleContext(ctx, id); err != nil {
+				return nil, ruleStoreError(err, "删除自动化规则失败")
+			}
+			return nil, nil
+		})
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/database"
+)
+
+// ndjsonType 逐行输出进度时使用的内容类型
+const ndjsonType = "application/x-ndjson"
+
+// BatchFilterRequest 按条件批量操作的请求，至少需要一个过滤条件
+type BatchFilterRequest struct {
+	Status          string     `json:"status,omitempty" example:"completed"`
+	Search          string     `json:"search,omitempty"`
+	CreatedBefore   *time.Time `json:"created_before,omitempty"`
+	UpdatedBefore   *time.Time `json:"updated_before,omitempty"`
+	CompletedBefore *time.Time `json:"completed_before,omitempty"`
+	DryRun          bool       `json:"dry_run,omitempty"` // 只返回命中的数量，不执行
+}
+
+// BatchFilterResult 按条件批量操作的结果（逐行输出时也是每一行的进度）
+type BatchFilterResult struct {
+	DryRun    bool `json:"dry_run,omitempty"`
+	Matched   int  `json:"matched"`   // 开始时命中的数量
+	Chunks    int  `json:"chunks"`    // 已提交的批数
+	Processed int  `json:"processed"` // 已处理的数量
+}
+
+// BatchCompleteByFilter 批量完成符合条件的待办事项
+// @Summary 按条件批量完成
+// @Description 在服务端按 ID 顺序分批（每批 X-Batch-Max-Size 条）在各自的事务中完成符合条件的 pending 事项，不需要传 ID 列表
+// @Description 中途失败时已提交的批次保留；Accept: application/x-ndjson 时每提交一批输出一行进度
+// @Description async=true 时作为后台任务执行，返回 202 和 Location（GET /api/v1/jobs/{id}）
+// @Tags todos
+// @Accept json
+// @Produce json,application/x-ndjson
+// @Param request body handler.BatchFilterRequest true "过滤条件"
+// @Param async query bool false "作为后台任务执行"
+// @Success 200 {object} handler.Response{data=handler.BatchFilterResult}
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/batch/complete-by-filter [post]
+func (h *Handler) BatchCompleteByFilter(w http.ResponseWriter, r *http.Request) {
+	h.batchByFilter(w, r, database.BatchOpComplete, "BatchCompleteByFilter", "批量完成操作完成")
+}
+
+// BatchDeleteByFilter 批量删除符合条件的待办事项
+// @Summary 按条件批量删除
+// @Description 在服务端按 ID 顺序分批（每批 X-Batch-Max-Size 条）在各自的事务中删除符合条件的事项，不需要传 ID 列表
+// @Description 例如 {"status":"completed","completed_before":"2024-01-01T00:00:00Z"}；中途失败时已提交的批次保留；
+// @Description Accept: application/x-ndjson 时每提交一批输出一行进度；async=true 时作为后台任务执行，返回 202 和 Location
+// @Tags todos
+// @Accept json
+// @Produce json,application/x-ndjson
+// @Param request body handler.BatchFilterRequest true "过滤条件"
+// @Param async query bool false "作为后台任务执行"
+// @Success 200 {object} handler.Response{data=handler.BatchFilterResult}
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/batch/delete-by-filter [post]
+func (h *Handler) BatchDeleteByFilter(w http.ResponseWriter, r *http.Request) {
+	h.batchByFilter(w, r, database.BatchOpDelete, "BatchDeleteByFilter", "批量删除操作完成")
+}
+
+// batchByFilter 按条件批量操作的公共流程：校验条件，预览时只计数，否则分批执行并报告进度
+func (h *Handler) batchByFilter(w http.ResponseWriter, r *http.Request, op, name, message string) {
+	ctx, cancel := context.WithTimeout(r.Context(), ImportTimeout)
+	defer cancel()
+
+	var req BatchFilterRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.sendAPIError(w, name, err)
+		return
+	}
+	filter := database.BatchFilter{
+		Status:          req.Status,
+		Search:          req.Search,
+		CreatedBefore:   req.CreatedBefore,
+		UpdatedBefore:   req.UpdatedBefore,
+		CompletedBefore: req.CompletedBefore,
+	}
+	if filter.Empty() {
+		h.sendError(w, apperr.CodeValidationError, "至少需要一个过滤条件")
+		return
+	}
+	if filter.Status != "" && !h.workflow.Has(filter.Status) {
+		h.sendError(w, apperr.CodeValidationError, "未知的状态")
+		return
+	}
+
+	matched, err := h.db.CountByFilterContext(ctx, op, filter)
+	if err != nil {
+		h.sendAPIError(w, name, storeError(err, "统计待办事项失败"))
+		return
+	}
+	result := BatchFilterResult{DryRun: req.DryRun, Matched: matched}
+	if req.DryRun {
+		h.sendJSON(w, http.StatusOK, Response{Success: true, Data: result, Message: "预览完成，未做任何修改"})
+		return
+	}
+	if wantsAsync(r) {
+		status, err := h.submitJob(ctx, w, jobKindBatchByFilter, batchFilterJob{Op: op, Filter: filter})
+		if err != nil {
+			h.sendAPIError(w, name, err)
+			return
+		}
+		h.sendJSON(w, http.StatusAccepted, Response{Success: true, Data: status, Message: jobSubmittedMessage})
+		return
+	}
+
+	// 数据量大时整体耗时可能超过服务器的写超时，这里由 ctx 的超时来限制
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("%s: failed to clear write deadline: %v", name, err)
+	}
+
+	stream := newProgressStream(w, r)
+	watch := op == database.BatchOpComplete && h.watchesCompletion(ctx)
+	done, err := h.db.BatchByFilterContext(ctx, op, filter, func(p database.BatchProgress) {
+		if watch {
+			h.afterComplete(ctx, p.IDs, nil)
+		}
+		result.Chunks, result.Processed = p.Chunks, p.Processed
+		stream.progress(result)
+	})
+	result.Chunks, result.Processed = done.Chunks, done.Processed
+
+	if err != nil {
+		apiErr := storeError(err, fmt.Sprintf("批量操作失败，已处理 %d 条", done.Processed))
+		if stream == nil {
+			h.sendAPIError(w, name, apiErr)
+			return
+		}
+		stream.fail(name, apiErr, result)
+		return
+	}
+	if stream == nil {
+		h.sendJSON(w, http.StatusOK, Response{Success: true, Data: result, Message: message})
+		return
+	}
+	stream.send(Response{Success: true, Data: result, Message: message})
+}
+
+// progressStream 以 NDJSON 逐行输出进度，每一行都是完整的响应信封
+type progressStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newProgressStream 客户端接受 NDJSON 时返回逐行输出的 progressStream，否则返回 nil
+func newProgressStream(w http.ResponseWriter, r *http.Request) *progressStream {
+	if !strings.Contains(r.Header.Get("Accept"), ndjsonType) {
+		return nil
+	}
+	w.Header().Set("Content-Type", ndjsonType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	return &progressStream{w: w, rc: http.NewResponseController(w)}
+}
+
+// progress 输出一行进度，s 为 nil 时什么也不做
+func (s *progressStream) progress(result BatchFilterResult) {
+	if s != nil {
+		s.send(Response{Success: true, Data: result})
+	}
+}
+
+// fail 输出最后一行错误，data 中带上已经完成的进度
+// 响应头已经发出，状态码只能是 200，客户端以最后一行的 success 为准
+func (s *progressStream) fail(name string, err error, result BatchFilterResult) {
+	if errors.Is(err, context.Canceled) {
+		log.Printf("%s canceled: %v", name, err)
+		return
+	}
+	var info *ErrorInfo
+	var appErr *apperr.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		info = &ErrorInfo{Code: apperr.CodeTimeout, Message: "请求超时，请稍后重试"}
+	case errors.As(err, &appErr):
+		info = &ErrorInfo{Code: appErr.Code, Message: appErr.Message}
+	default:
+		info = &ErrorInfo{Code: apperr.CodeInternalError, Message: "服务器内部错误"}
+	}
+	log.Printf("%s failed: %v", name, err)
+	s.send(Response{Success: false, Data: result, Error: info})
+}
+
+func (s *progressStream) send(resp Response) {
+	if err := json.NewEncoder(s.w).Encode(resp); err != nil {
+		log.Printf("写入进度失败: %v", err)
+		return
+	}
+	if err := s.rc.Flush(); err != nil {
+		log.Printf("刷新进度失败: %v", err)
+	}
+}
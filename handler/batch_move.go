@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"todo-list/apperr"
+	"todo-list/database"
+)
+
+// BatchMoveRequest 把待办事项移到另一个列表（项目）
+type BatchMoveRequest struct {
+	IDs       []int `json:"ids"`
+	ProjectID int   `json:"project_id" example:"2"` // 目标项目，0 表示移出项目（收件箱等不属于任何项目的列表）
+	Index     *int  `json:"index,omitempty"`        // 插入到目标列表的第几个位置（从 0 开始），不传时放在最后
+}
+
+// BatchMoveTodos 批量移动待办事项
+// @Summary 批量移动到另一个项目
+// @Description 拖放时一次调用完成：在一个事务中把 ids 按顺序移到 project_id 指定的项目，插入到目标列表（sort=position 的顺序）的 index 位置，
+// @Description 目标列表重新编号；移动的事项产生 updated 事件（SSE、webhook）。ids 中有不存在的事项时整批失败，上限通过 X-Batch-Max-Size 响应头返回
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param request body handler.BatchMoveRequest true "待办事项ID列表和目标项目"
+// @Success 200 {object} handler.Response{data=handler.ProjectTodosResult}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/batch/move [post]
+func (h *Handler) BatchMoveTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "BatchMove", timeout: BatchTimeout, message: "待办事项已移动"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req BatchMoveRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			if len(req.IDs) == 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "IDs 不能为空")
+			}
+			if err := h.checkBatchSize(len(req.IDs)); err != nil {
+				return nil, err
+			}
+			if req.ProjectID < 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "project_id 无效")
+			}
+			if req.Index != nil && *req.Index < 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "index 不能为负数")
+			}
+
+			// 重复的 ID 只保留第一次出现的位置
+			ids := make([]int, 0, len(req.IDs))
+			for _, id := range req.IDs {
+				if !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+
+			err := h.db.BatchMoveTodosContext(ctx, ids, req.ProjectID, req.Index)
+			switch {
+			case errors.Is(err, database.ErrTodosNotFound):
+				return nil, apperr.Wrap(err, apperr.CodeNotFound, "待办事项不存在")
+			case errors.Is(err, database.ErrTargetProjectNotFound):
+				return nil, apperr.Wrap(err, apperr.CodeValidationError, "目标项目不存在")
+			case err != nil:
+				return nil, operationError(err, apperr.CodeBatchError)
+			}
+			return ProjectTodosResult{Count: len(ids), IDs: ids}, nil
+		})
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"todo-list/model"
+	"todo-list/notify"
+	"todo-list/storage"
+)
+
+// Capabilities 当前部署启用的功能和限制，供通用客户端 / CLI 按部署配置调整行为
+type Capabilities struct {
+	APIVersions   []string              `json:"api_versions"`
+	LegacyRoutes  bool                  `json:"legacy_routes"` // 是否仍提供 /api/todos 旧路由
+	AuthMode      string                `json:"auth_mode"`     // none：未启用认证；api_key：必须带 X-API-Key；extension：由认证扩展校验，同时接受 X-API-Key
+	Workspaces    bool                  `json:"workspaces"`
+	TodoStorage   string                `json:"todo_storage"`      // sqlite、postgres 或 memory；不是 sqlite 时链接、附件等功能返回 FEATURE_DISABLED
+	StrictVersion bool                  `json:"strict_versioning"` // 更新是否必须带 version / If-Match
+	APIDocs       bool                  `json:"api_docs"`          // 是否提供 /swagger/ 接口文档
+	ReadOnly      bool                  `json:"read_only"`         // 只读模式下所有修改请求返回 503
+	Limits        CapabilityLimits      `json:"limits"`
+	Priorities    []model.PriorityLabel `json:"priority_labels"` // 优先级名称与数值，请求中两种写法都接受
+	ExportFormats []string              `json:"export_formats"`
+	ImportFormats []string              `json:"import_formats"`
+	Integrations  []string              `json:"integrations"`
+	Notifications []string              `json:"notification_channels"`
+	EventSchema   int                   `json:"event_schema_version"` // 通知事件载荷的结构版本，见 /api/v1/events/schema
+	RateLimit     *RateLimitSetting     `json:"rate_limit,omitempty"` // 未启用限流时为空
+	Features      []string              `json:"features"`             // 已开启的实验性功能
+	Views         []string              `json:"views"`                // GET /api/v1/todos?view= 支持的视图
+}
+
+// CapabilityLimits 请求大小和配额限制，0 表示不限制
+type CapabilityLimits struct {
+	MaxBatchSize    int `json:"max_batch_size"`
+	DefaultPageSize int `json:"default_page_size"`
+	MaxPageSize     int `json:"max_page_size"`
+	MaxImportSize   int `json:"max_import_size"`
+	MaxTodos        int `json:"max_todos"`
+	MaxTodosPerDay  int `json:"max_todos_per_day"`
+	MaxLinksPerTodo int `json:"max_links_per_todo"`
+
+	MaxTitleLength       int `json:"max_title_length"` // 按字符数计算
+	MaxDescriptionLength int `json:"max_description_length"`
+	MaxSecretNoteBytes   int `json:"max_secret_note_bytes"` // 加密备注解码后的密文字节数
+
+	MaxAttachmentBytes int64 `json:"max_attachment_bytes"`
+
+	MaxConcurrent map[string]int `json:"max_concurrent"` // 导出、导入等接口同时执行的请求数上限，超出返回 503
+}
+
+// RateLimitSetting 限流配置
+type RateLimitSetting struct {
+	PerMinute int  `json:"per_minute"`
+	Soft      bool `json:"soft"`
+}
+
+// GetCapabilities 返回当前部署的功能发现信息
+// @Summary 功能发现
+// @Description 当前部署启用的功能和限制
+// @Tags meta
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.Capabilities}
+// @Router /api/v1/capabilities [get]
+func (h *Handler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.capabilities(),
+		Message: "获取功能信息成功",
+	})
+}
+
+// capabilities 根据配置汇总功能信息
+func (h *Handler) capabilities() Capabilities {
+	// 始终可用的集成
+	integrations := []string{"simple_api", "share_links", "event_stream", "webhooks"}
+	if h.cfg.MailgunSigningKey != "" {
+		integrations = append(integrations, "inbound_email")
+	}
+	if h.cfg.LinkPreview {
+		integrations = append(integrations, "link_preview")
+	}
+	if h.cfg.EscalationPolicyFile != "" {
+		integrations = append(integrations, "escalation")
+	}
+	if _, ok := h.files.(storage.URLSigner); ok {
+		integrations = append(integrations, "object_storage")
+	}
+	if h.cfg.Cache.Backend != nil {
+		integrations = append(integrations, "cache:redis")
+	}
+	if h.events != nil {
+		integrations = append(integrations, "event_notify:postgres")
+	}
+	if scanner := h.cfg.Attachments.Scanner; scanner != nil {
+		integrations = append(integrations, "scan:"+scanner.Name())
+	}
+	if h.cfg.Mail.Enabled() {
+		integrations = append(integrations, "email:smtp")
+	}
+	if h.cfg.Invites.Enabled {
+		integrations = append(integrations, "calendar_invites")
+	}
+	if h.issues != nil {
+		for _, name := range h.issues.Sources() {
+			integrations = append(integrations, "issues:"+name)
+		}
+	}
+	for _, name := range h.hooks.Providers() {
+		integrations = append(integrations, "hook:"+name)
+	}
+	sort.Strings(integrations)
+
+	caps := Capabilities{
+		APIVersions:   []string{"v1"},
+		LegacyRoutes:  h.cfg.LegacyRoutes,
+		AuthMode:      h.authMode(),
+		Workspaces:    true,
+		TodoStorage:   h.cfg.DBDriver,
+		StrictVersion: h.cfg.StrictVersioning,
+		APIDocs:       h.cfg.Swagger.Enabled,
+		ReadOnly:      h.cfg.ReadOnly,
+		Priorities:    h.cfg.PriorityScale,
+		Limits: CapabilityLimits{
+			MaxBatchSize:    h.cfg.BatchMaxSize,
+			DefaultPageSize: h.cfg.DefaultPageSize,
+			MaxPageSize:     h.cfg.MaxPageSize,
+			MaxImportSize:   storage.MaxImportSize,
+			MaxTodos:        h.cfg.Quota.MaxTodos,
+			MaxTodosPerDay:  h.cfg.Quota.MaxTodosPerDay,
+			MaxLinksPerTodo: h.cfg.Quota.MaxLinksPerTodo,
+
+			MaxTitleLength:       h.cfg.TextLimits.MaxTitle,
+			MaxDescriptionLength: h.cfg.TextLimits.MaxDescription,
+			MaxSecretNoteBytes:   model.MaxSecretNoteBytes,
+
+			MaxAttachmentBytes: h.cfg.Attachments.MaxBytes,
+			MaxConcurrent:      h.cfg.ConcurrencyLimits,
+		},
+		ExportFormats: []string{"json", "csv"},
+		ImportFormats: []string{"json", "csv"},
+		Integrations:  integrations,
+		Notifications: model.NotificationChannels,
+		EventSchema:   notify.SchemaVersion,
+		Features:      h.features.EnabledFlags(),
+		Views:         TodoViews,
+	}
+
+	if h.limiter != nil {
+		caps.RateLimit = &RateLimitSetting{
+			PerMinute: h.cfg.RateLimitPerMinute,
+			Soft:      h.cfg.RateLimitSoft,
+		}
+	}
+
+	return caps
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/model"
+)
+
+// AddCommentRequest 添加评论请求体
+type AddCommentRequest struct {
+	Body string `json:"body" example:"@alice can you review this?"`
+}
+
+// AddComment 为待办事项添加评论，正文中 @ 到的用户会收到站内通知
+// @Summary 添加评论
+// @Description 正文中 @ 到的用户会收到站内通知
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param request body handler.AddCommentRequest true "评论内容"
+// @Success 201 {object} handler.Response{data=model.Comment}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/comments [post]
+func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	defer r.Body.Close()
+
+	h.serve(w, r, endpoint{name: "AddComment", timeout: CreateTimeout, status: http.StatusCreated, message: "评论已添加"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			var req AddCommentRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+
+			comment := model.NewComment(todoID, currentUserID(r), req.Body, h.clock.Now())
+			if err := comment.Validate(); err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
+
+			todo, err := h.todos.GetTodoByIDContext(ctx, todoID)
+			if err != nil {
+				return nil, storeError(err, "获取待办事项失败")
+			}
+
+			if err := h.repos.CreateCommentContext(ctx, comment, mentionNotifications(comment, todo)); err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "添加评论失败")
+			}
+			return comment, nil
+		})
+}
+
+// ListComments 获取待办事项的评论列表
+// @Summary 评论列表
+// @Tags comments
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Success 200 {object} handler.Response{data=[]model.Comment}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/comments [get]
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListComments", timeout: ListTimeout, message: "获取评论成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			comments, err := h.repos.ListCommentsContext(ctx, todoID)
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "查询评论失败")
+			}
+			return comments, nil
+		})
+}
+
+// mentionNotifications 为评论中提及的每个用户生成一条通知（不通知作者自己）
+func mentionNotifications(comment *model.Comment, todo *model.Todo) []model.Notification {
+	notifications := make([]model.Notification, 0, len(comment.Mentions))
+	for _, user := range comment.Mentions {
+		if user == comment.Author {
+			continue
+		}
+		todoID := todo.ID
+		notifications = append(notifications, model.Notification{
+			UserID:    user,
+			Kind:      model.NotificationMention,
+			TodoID:    &todoID,
+			Title:     fmt.Sprintf("%s 在「%s」中提到了你", comment.Author, todo.Title),
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+	return notifications
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"todo-list/apperr"
+)
+
+// newSemaphores 按 CONCURRENCY_LIMITS 为每个分组创建信号量，上限为 0 的分组不限制
+func newSemaphores(limits map[string]int) map[string]chan struct{} {
+	sems := make(map[string]chan struct{}, len(limits))
+	for group, n := range limits {
+		if n > 0 {
+			sems[group] = make(chan struct{}, n)
+		}
+	}
+	return sems
+}
+
+// LimitConcurrency 中间件：限制同一分组同时执行的请求数（分组见 config.Concurrency*）
+// 超出上限时不排队，直接返回 503 和 Retry-After，避免长时间的导入、导出占满 SQLite 唯一的写连接
+func (h *Handler) LimitConcurrency(group string, next http.HandlerFunc) http.HandlerFunc {
+	sem := h.semaphores[group]
+	if sem == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			next(w, r)
+		default:
+			log.Printf("concurrency limit reached: group=%s, limit=%d, path=%s", group, cap(sem), r.URL.Path)
+			w.Header().Set("Retry-After", "1")
+			h.sendErrorDetails(w, apperr.CodeTooBusy, "同类请求正在执行的数量已达上限，请稍后重试",
+				map[string]interface{}{"group": group, "limit": cap(sem)})
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// GetConfig 查看实际生效的配置（管理接口）
+// 每一项标明来自环境变量还是默认值，密钥只显示是否设置
+// @Summary 生效的配置
+// @Description 管理接口：合并环境变量和默认值之后的配置，密钥已脱敏
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]config.Setting}
+// @Router /api/v1/admin/config [get]
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.cfg.Settings(),
+		Message: "获取配置成功",
+	})
+}
+
+// ListBreakers 查看外部集成熔断器的状态（管理接口）
+// 只列出发生过调用的下游；通知渠道名为 notify:渠道，出站请求名为 http:主机名
+// @Summary 熔断器状态
+// @Description 管理接口：通知渠道和出站请求的熔断器状态，BREAKER_THRESHOLD 为 0 时为空列表
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]breaker.Status}
+// @Router /api/v1/admin/breakers [get]
+func (h *Handler) ListBreakers(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.cfg.Outbound.Breakers.Statuses(),
+		Message: "获取熔断器状态成功",
+	})
+}
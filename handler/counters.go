@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+)
+
+// RebuildStatsCounters 按实际数据重建统计计数（管理接口）
+// 计数由数据库触发器在每次写入时维护，只在怀疑计数与数据不一致时使用
+// @Summary 重建统计计数
+// @Description 管理接口：按 todos 的实际数据重建所有工作区的统计计数（状态、项目、截止日期、完成日期、完成耗时），返回重建后按状态的计数 工作区 -> 状态 -> 数量
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=map[string]map[string]int}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/stats/rebuild [post]
+func (h *Handler) RebuildStatsCounters(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "RebuildStatsCounters", timeout: ImportTimeout, message: "统计计数已重建"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			counts, err := h.db.RebuildCountersContext(ctx)
+			if err != nil {
+				return nil, storeError(err, "重建统计计数失败")
+			}
+			return counts, nil
+		})
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// legacyRouteWarning 访问旧版路由时在响应信封中返回的提示
+const legacyRouteWarning = "此路由已弃用，请改用 /api/v1/todos"
+
+// Deprecated 中间件：为旧版 /api/todos 路由加上 Deprecation / Sunset / Link 响应头
+// successor 为替代路由的前缀，例如 /api/v1/todos
+func (h *Handler) Deprecated(legacyPrefix, successor string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			if !h.cfg.LegacySunset.IsZero() {
+				w.Header().Set("Sunset", h.cfg.LegacySunset.UTC().Format(http.TimeFormat))
+			}
+
+			link := successor + strings.TrimPrefix(r.URL.Path, legacyPrefix)
+			w.Header().Set("Link", "<"+link+`>; rel="successor-version"`)
+
+			next(w, r)
+		}
+	}
+}
+
+// LegacyRoutesEnabled 是否注册旧版 /api/todos 路由
+func (h *Handler) LegacyRoutesEnabled() bool {
+	return h.cfg.LegacyRoutes
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"todo-list/apperr"
+)
+
+// SwaggerEnabled 是否提供 /swagger/ 接口文档
+func (h *Handler) SwaggerEnabled() bool {
+	return h.cfg.Swagger.Enabled
+}
+
+// DocsAuth 中间件：配置了 SWAGGER_USER / SWAGGER_PASSWORD 时，访问接口文档需要 Basic 认证
+func (h *Handler) DocsAuth(next http.Handler) http.Handler {
+	user, password := h.cfg.Swagger.User, h.cfg.Swagger.Password
+	if user == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		// 用户名和密码都要比较，避免按耗时猜出哪一项错了
+		userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+		passwordOK := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+		if !ok || !userOK || !passwordOK {
+			w.Header().Set("WWW-Authenticate", `Basic realm="api-docs", charset="UTF-8"`)
+			h.sendError(w, apperr.CodeUnauthorized, "访问接口文档需要认证")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"todo-list/apperr"
+)
+
+// drainState 摘流状态：摘流期间就绪检查失败，负载均衡不再分配新请求，已有请求照常处理
+type drainState struct {
+	mu      sync.Mutex
+	since   *time.Time          // 为空表示未摘流
+	onDrain func(draining bool) // 摘流开始和取消时调用，见 OnDrain
+}
+
+// DrainStatus 摘流状态
+type DrainStatus struct {
+	Draining      bool       `json:"draining"`
+	Since         *time.Time `json:"since,omitempty"`
+	PeriodSeconds int        `json:"period_seconds"`       // 摘流等待时间（DRAIN_PERIOD_SECONDS）
+	DrainedAt     *time.Time `json:"drained_at,omitempty"` // 预计负载均衡已摘除本实例的时间，之后可以安全停止
+}
+
+// ReadyStatus 就绪检查结果
+type ReadyStatus struct {
+	Status string `json:"status" example:"ready"` // ready / draining / unavailable
+}
+
+// OnDrain 注册摘流状态变化时的回调（例如关闭 HTTP Keep-Alive，让客户端尽快换到其他实例），必须在启动服务前调用
+func (h *Handler) OnDrain(fn func(draining bool)) {
+	h.drain.onDrain = fn
+}
+
+// StartDrain 开始摘流（已经在摘流时保持原来的开始时间），返回还需要等待多久负载均衡才会摘除本实例
+func (h *Handler) StartDrain() time.Duration {
+	h.drain.mu.Lock()
+	started := h.drain.since == nil
+	if started {
+		now := h.clock.Now()
+		h.drain.since = &now
+	}
+	since := *h.drain.since
+	h.drain.mu.Unlock()
+
+	if started {
+		log.Printf("开始摘流，就绪检查将返回 503，等待 %v", h.cfg.DrainPeriod)
+		if h.drain.onDrain != nil {
+			h.drain.onDrain(true)
+		}
+	}
+	if wait := h.cfg.DrainPeriod - h.clock.Now().Sub(since); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// stopDrain 取消摘流，恢复接收新请求
+func (h *Handler) stopDrain() {
+	h.drain.mu.Lock()
+	stopped := h.drain.since != nil
+	h.drain.since = nil
+	h.drain.mu.Unlock()
+
+	if stopped {
+		log.Println("已取消摘流")
+		if h.drain.onDrain != nil {
+			h.drain.onDrain(false)
+		}
+	}
+}
+
+// drainStatus 返回当前摘流状态
+func (h *Handler) drainStatus() DrainStatus {
+	h.drain.mu.Lock()
+	defer h.drain.mu.Unlock()
+
+	status := DrainStatus{PeriodSeconds: int(h.cfg.DrainPeriod / time.Second)}
+	if h.drain.since != nil {
+		since := *h.drain.since
+		drainedAt := since.Add(h.cfg.DrainPeriod)
+		status.Draining = true
+		status.Since = &since
+		status.DrainedAt = &drainedAt
+	}
+	return status
+}
+
+// ReadyCheck 就绪检查，供负载均衡判断是否分配新请求
+// @Summary 就绪检查
+// @Description 摘流期间或数据库不可用时返回 503；和 /health 不同，摘流不影响 /health，避免存活检查把实例重启
+// @Tags health
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.ReadyStatus}
+// @Failure 503 {object} handler.Response{data=handler.ReadyStatus,error=handler.ErrorInfo}
+// @Router /ready [get]
+func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
+	if h.drainStatus().Draining {
+		h.sendJSON(w, apperr.CodeDraining.Status(), Response{
+			Success: false,
+			Data:    ReadyStatus{Status: "draining"},
+			Error:   &ErrorInfo{Code: apperr.CodeDraining, Message: "实例正在摘流，不再接收新请求"},
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), HealthPingTimeout)
+	defer cancel()
+	if err := h.repos.PingContext(ctx); err != nil {
+		log.Printf("Ready check: database unavailable: %v", err)
+		h.sendJSON(w, apperr.CodeDatabaseUnavailable.Status(), Response{
+			Success: false,
+			Data:    ReadyStatus{Status: "unavailable"},
+			Error:   &ErrorInfo{Code: apperr.CodeDatabaseUnavailable, Message: "数据库不可用"},
+		})
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    ReadyStatus{Status: "ready"},
+		Message: "服务已就绪",
+	})
+}
+
+// GetDrain 查看摘流状态（管理接口）
+// @Summary 摘流状态
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.DrainStatus}
+// @Router /api/v1/admin/drain [get]
+func (h *Handler) GetDrain(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.drainStatus(),
+		Message: "获取摘流状态成功",
+	})
+}
+
+// StartDrainHandler 开始摘流（管理接口）
+// 滚动发布时先调用本接口，等到 drained_at 之后再停止实例；直接发送 SIGTERM 也会先摘流再关闭
+// @Summary 开始摘流
+// @Description 管理接口：/ready 开始返回 503 并关闭 Keep-Alive，已有请求和新到达的请求照常处理；重复调用不会重新计时
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.DrainStatus}
+// @Router /api/v1/admin/drain [post]
+func (h *Handler) StartDrainHandler(w http.ResponseWriter, r *http.Request) {
+	h.StartDrain()
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.drainStatus(),
+		Message: "已开始摘流",
+	})
+}
+
+// StopDrainHandler 取消摘流（管理接口）
+// @Summary 取消摘流
+// @Description 管理接口：/ready 恢复正常，用于放弃本次发布
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.DrainStatus}
+// @Router /api/v1/admin/drain [delete]
+func (h *Handler) StopDrainHandler(w http.ResponseWriter, r *http.Request) {
+	h.stopDrain()
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.drainStatus(),
+		Message: "已取消摘流",
+	})
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+	"todo-list/apperr"
+)
+
+// GetErrorCatalog 返回错误码目录：错误码、HTTP 状态码、类别和说明
+// 客户端应按 error.code 判断错误，不要解析 error.message
+// @Summary 错误码目录
+// @Description 所有错误码及其 HTTP 状态码、类别和说明；客户端应按 error.code 判断错误
+// @Tags meta
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]apperr.Entry}
+// @Router /api/v1/errors [get]
+func (h *Handler) GetErrorCatalog(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    apperr.Catalog(),
+		Message: "获取错误码目录成功",
+	})
+}
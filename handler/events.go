@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+	"todo-list/notify"
+)
+
+// GetEventSchema 返回通知事件载荷的结构说明和兼容性约定
+// 发给各渠道（如 webhook）的每条通知都带 schema_version，消费方可以据此判断是否需要适配
+// @Summary 事件载荷结构
+// @Description 通知事件载荷的字段、通知类型、当前 schema_version 和兼容性约定
+// @Tags meta
+// @Produce json
+// @Success 200 {object} handler.Response{data=notify.EventSchema}
+// @Router /api/v1/events/schema [get]
+func (h *Handler) GetEventSchema(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    notify.Schema(),
+		Message: "获取事件结构成功",
+	})
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/extension"
+	"todo-list/storage"
+)
+
+// Authenticate 中间件：带 X-API-Key 时校验 API key，否则在注册了认证扩展时由扩展校验，
+// 通过后把请求方标识放入 Context；既没有 API key 也没有认证扩展时，
+// 只有在没有开启 AUTH_REQUIRED 且从未创建过 API key 的部署（本机单用户使用）中放行，否则返回 401
+func (h *Handler) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authenticate := h.authenticate
+		if raw := r.Header.Get(APIKeyHeader); raw != "" {
+			key, err := h.authenticateAPIKey(r, raw)
+			if err != nil {
+				h.sendAPIError(w, "Authenticate", err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), apiKeyContextKey{}, key)
+			next(w, r.WithContext(extension.WithSubject(ctx, key.UserID)))
+			return
+		}
+		if authenticate == nil {
+			if err := h.requireAuth(r); err != nil {
+				h.sendAPIError(w, "Authenticate", err)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		subject, err := authenticate(r)
+		if err != nil {
+			var appErr *apperr.Error
+			if errors.As(err, &appErr) {
+				h.sendError(w, appErr.Code, appErr.Message)
+				return
+			}
+			h.sendError(w, apperr.CodeUnauthorized, "需要身份认证")
+			return
+		}
+		next(w, r.WithContext(extension.WithSubject(r.Context(), subject)))
+	}
+}
+
+// SetAuthenticator 替换认证扩展提供的认证函数（测试中使用），默认为 extension.Authenticator()，为 nil 表示没有认证扩展
+func (h *Handler) SetAuthenticator(fn func(r *http.Request) (string, error)) {
+	h.authenticate = fn
+}
+
+// requireAuth 没有认证扩展、请求也没有带 API key 时是否拒绝：开启了 AUTH_REQUIRED，
+// 或者已经创建过 API key（创建了 key 就说明接口不再是公开的，包括创建 key 和管理接口本身）；
+// 浏览器的 CORS 预检请求不会带自定义请求头，始终放行
+func (h *Handler) requireAuth(r *http.Request) error {
+	if r.Method == http.MethodOptions {
+		return nil
+	}
+	required := h.cfg.AuthRequired || h.keysExist.Load()
+	if !required {
+		ctx, cancel := context.WithTimeout(r.Context(), DefaultTimeout)
+		defer cancel()
+		exists, err := h.repos.HasAPIKeysContext(ctx)
+		if err != nil {
+			return storeError(err, "校验 API key 失败")
+		}
+		if exists {
+			h.keysExist.Store(true)
+		}
+		required = exists
+	}
+	if required {
+		return apperr.New(apperr.CodeUnauthorized, "需要身份认证，请在 "+APIKeyHeader+" 请求头中携带 API key")
+	}
+	return nil
+}
+
+// authMode 能力接口中的认证方式
+func (h *Handler) authMode() string {
+	switch {
+	case h.authenticate != nil:
+		return "extension"
+	case h.cfg.AuthRequired || h.keysExist.Load():
+		return "api_key"
+	}
+	return "none"
+}
+
+// extensionText 纯文本接口展示扩展拒绝创建的原因：*apperr.Error 使用其提示和状态码，其他错误按 500 处理
+func extensionText(name string, err error) (int, string) {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		return appErr.Status(), appErr.Message
+	}
+	log.Printf("%s extension failed: %v", name, err)
+	return http.StatusInternalServerError, "创建失败"
+}
+
+// incompleteTodos 返回 ids 中尚未进入终态的待办事项，批量完成之后只对它们触发完成事件
+// 没有规则或扩展关心完成事件时返回 nil，不做额外查询
+func (h *Handler) incompleteTodos(ctx context.Context, ids []int) []int {
+	if !h.watchesCompletion(ctx) {
+		return nil
+	}
+	var pending []int
+	for _, id := range ids {
+		todo, err := h.todos.GetTodoByIDContext(ctx, id)
+		if err != nil {
+			if !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("Failed to load todo %d for extensions: %v", id, err)
+			}
+			continue
+		}
+		if !h.workflow.IsTerminal(todo.Status) {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// afterComplete 对刚完成的待办事项触发完成事件
+// skip 为批量操作中失败的 ID
+func (h *Handler) afterComplete(ctx context.Context, ids []int, skip map[int]bool) {
+	// 没有规则或扩展关心完成事件时 ids 为空，批量完成的重复待办事项也要生成下一次
+	defer h.spawnRecurrences()
+
+	for _, id := range ids {
+		if skip[id] {
+			continue
+		}
+		todo, err := h.todos.GetTodoByIDContext(ctx, id)
+		if err != nil {
+			log.Printf("Failed to load todo %d for extensions: %v", id, err)
+			continue
+		}
+		h.completed(ctx, todo)
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/features"
+)
+
+// UpdateFeatureRequest 切换功能开关的请求
+type UpdateFeatureRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// RequireFeature 中间件：功能开关关闭时返回 404 FEATURE_DISABLED，用于挂载实验性功能的路由
+func (h *Handler) RequireFeature(flag features.Flag) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !h.features.Enabled(flag) {
+				h.sendError(w, apperr.CodeFeatureDisabled, "功能未启用: "+string(flag))
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// FeatureEnabled 功能开关是否开启，供需要在处理过程中分支的代码使用
+func (h *Handler) FeatureEnabled(flag features.Flag) bool {
+	return h.features.Enabled(flag)
+}
+
+// ListFeatures 查看所有功能开关（管理接口）
+// @Summary 功能开关
+// @Description 管理接口：每个实验性功能开关的当前值、配置值以及是否在运行时被修改过
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]features.Status}
+// @Router /api/v1/admin/features [get]
+func (h *Handler) ListFeatures(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    h.features.List(),
+		Message: "获取功能开关成功",
+	})
+}
+
+// UpdateFeature 切换功能开关（管理接口）
+// 修改立即生效但只保存在内存中，重启后恢复为 FEATURE_FLAGS 中的配置
+// @Summary 切换功能开关
+// @Description 管理接口：立即生效，重启后恢复为 FEATURE_FLAGS 的配置
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "开关名称"
+// @Param request body handler.UpdateFeatureRequest true "是否开启"
+// @Success 200 {object} handler.Response{data=features.Status}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/features/{name} [put]
+func (h *Handler) UpdateFeature(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateFeature", timeout: UpdateTimeout, message: "功能开关已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req UpdateFeatureRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			if req.Enabled == nil {
+				return nil, apperr.New(apperr.CodeValidationError, "enabled 不能为空")
+			}
+
+			flag := features.Flag(r.PathValue("name"))
+			if err := h.features.Toggle(flag, *req.Enabled); err != nil {
+				if errors.Is(err, features.ErrUnknownFlag) {
+					return nil, apperr.New(apperr.CodeNotFound, "功能开关不存在")
+				}
+				return nil, err
+			}
+			return h.features.Get(flag)
+		})
+}
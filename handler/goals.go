@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// GoalRequest 创建或修改目标的请求
+type GoalRequest struct {
+	Title       string `json:"title" example:"Q4 发布 2.0"`
+	Description string `json:"description" example:"完成所有 2.0 的功能和文档"`
+	TargetDate  string `json:"target_date,omitempty" example:"2024-12-31"`
+}
+
+// goal 校验请求并转换为目标
+func (req GoalRequest) goal() (*model.Goal, error) {
+	goal := &model.Goal{
+		Title:       req.Title,
+		Description: req.Description,
+		TargetDate:  req.TargetDate,
+	}
+	if err := goal.Validate(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	return goal, nil
+}
+
+// GoalTodosRequest 关联待办事项的请求
+type GoalTodosRequest struct {
+	TodoIDs []int `json:"todo_ids"`
+}
+
+// goalStoreError 目标不存在时返回 404，其他错误按 storeError 处理
+func goalStoreError(err error, message string) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperr.Wrap(err, apperr.CodeNotFound, "目标不存在")
+	}
+	return storeError(err, message)
+}
+
+// ListGoals 目标列表
+// @Summary 目标列表
+// @Description 当前工作区的所有目标及进度（已完成的关联待办事项占比）
+// @Tags goals
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.Goal}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals [get]
+func (h *Handler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListGoals", timeout: ListTimeout, message: "获取目标成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			goals, err := h.db.ListGoalsContext(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询目标失败")
+			}
+			return goals, nil
+		})
+}
+
+// CreateGoal 创建目标
+// @Summary 创建目标
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param request body handler.GoalRequest true "目标"
+// @Success 201 {object} handler.Response{data=model.Goal}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals [post]
+func (h *Handler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateGoal", timeout: CreateTimeout, status: http.StatusCreated, message: "目标已创建"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req GoalRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			goal, err := req.goal()
+			if err != nil {
+				return nil, err
+			}
+			if err := h.db.CreateGoalContext(ctx, goal); err != nil {
+				return nil, storeError(err, "创建目标失败")
+			}
+			return goal, nil
+		})
+}
+
+// GetGoal 查看目标
+// @Summary 查看目标
+// @Description 目标的进度和关联的待办事项
+// @Tags goals
+// @Produce json
+// @Param id path int true "目标ID"
+// @Success 200 {object} handler.Response{data=model.Goal}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals/{id} [get]
+func (h *Handler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetGoal", timeout: ListTimeout, message: "获取目标成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			return h.goalWithTodos(ctx, id)
+		})
+}
+
+// goalWithTodos 读取目标及其关联的待办事项
+func (h *Handler) goalWithTodos(ctx context.Context, id int) (*model.Goal, error) {
+	goal, err := h.db.GetGoalContext(ctx, id)
+	if err != nil {
+		return nil, goalStoreError(err, "获取目标失败")
+	}
+	todos, err := h.db.ListGoalTodosContext(ctx, id)
+	if err != nil {
+		return nil, storeError(err, "查询目标的待办事项失败")
+	}
+	goal.Todos = todos
+	return goal, nil
+}
+
+// UpdateGoal 修改目标
+// @Summary 修改目标
+// @Description 替换标题、描述和目标日期，关联的待办事项不变
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param request body handler.GoalRequest true "目标"
+// @Success 200 {object} handler.Response{data=model.Goal}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals/{id} [put]
+func (h *Handler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateGoal", timeout: UpdateTimeout, message: "目标已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req GoalRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			goal, err := req.goal()
+			if err != nil {
+				return nil, err
+			}
+			goal.ID = id
+			if err := h.db.UpdateGoalContext(ctx, goal); err != nil {
+				return nil, goalStoreError(err, "更新目标失败")
+			}
+			return h.goalWithTodos(ctx, id)
+		})
+}
+
+// DeleteGoal 删除目标
+// @Summary 删除目标
+// @Description 关联的待办事项保留
+// @Tags goals
+// @Produce json
+// @Param id path int true "目标ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals/{id} [delete]
+func (h *Handler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteGoal", timeout: DeleteTimeout, message: "目标已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.db.DeleteGoalContext(ctx, id); err != nil {
+				return nil, goalStoreError(err, "删除目标失败")
+			}
+			return nil, nil
+		})
+}
+
+// LinkGoalTodos 关联待办事项
+// @Summary 关联待办事项到目标
+// @Description 已经关联的待办事项忽略；任何一个待办事项不存在时整体失败
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param request body handler.GoalTodosRequest true "待办事项ID"
+// @Success 200 {object} handler.Response{data=model.Goal}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals/{id}/todos [post]
+func (h *Handler) LinkGoalTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "LinkGoalTodos", timeout: UpdateTimeout, message: "待办事项已关联"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req GoalTodosRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			if len(req.TodoIDs) == 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "todo_ids 不能为空")
+			}
+			if len(req.TodoIDs) > h.cfg.BatchMaxSize {
+				return nil, apperr.New(apperr.CodeBatchTooLarge,
+					fmt.Sprintf("一次最多关联 %d 个待办事项，当前: %d", h.cfg.BatchMaxSize, len(req.TodoIDs))).
+					WithDetails(map[string]interface{}{"max_batch_size": h.cfg.BatchMaxSize, "requested": len(req.TodoIDs)})
+			}
+
+			if _, err := h.db.GetGoalContext(ctx, id); err != nil {
+				return nil, goalStoreError(err, "获取目标失败")
+			}
+			if err := h.db.LinkGoalTodosContext(ctx, id, req.TodoIDs); err != nil {
+				return nil, storeError(err, "关联待办事项失败")
+			}
+			return h.goalWithTodos(ctx, id)
+		})
+}
+
+// UnlinkGoalTodo 取消关联
+// @Summary 取消待办事项与目标的关联
+// @Description 待办事项本身保留
+// @Tags goals
+// @Produce json
+// @Param id path int true "目标ID"
+// @Param todoId path int true "待办事项ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/goals/{id}/todos/{todoId} [delete]
+func (h *Handler) UnlinkGoalTodo(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UnlinkGoalTodo", timeout: DeleteTimeout, message: "已取消关联"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			todoID, err := pathID(r, "todoId")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.db.UnlinkGoalTodoContext(ctx, id, todoID); err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, apperr.Wrap(err, apperr.CodeNotFound, "待办事项未关联到该目标")
+				}
+				return nil, storeError(err, "取消关联失败")
+			}
+			return nil, nil
+		})
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// HabitRequest 创建或修改习惯的请求
+type HabitRequest struct {
+	Title       string `json:"title" example:"晨跑"`
+	Description string `json:"description" example:"至少 3 公里"`
+	Frequency   string `json:"frequency" example:"daily"`     // daily / weekly
+	Weekday     *int   `json:"weekday,omitempty" example:"1"` // weekly 时在星期几生成，0 表示星期日，默认 1
+	Active      *bool  `json:"active,omitempty"`              // 默认 true，false 表示暂停
+}
+
+// habit 校验请求并转换为习惯
+func (req HabitRequest) habit() (*model.Habit, error) {
+	habit := &model.Habit{
+		Title:       req.Title,
+		Description: req.Description,
+		Frequency:   req.Frequency,
+		Weekday:     1,
+		Active:      req.Active == nil || *req.Active,
+	}
+	if req.Weekday != nil {
+		habit.Weekday = *req.Weekday
+	}
+	if err := habit.Validate(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	return habit, nil
+}
+
+// habitStoreError 习惯不存在时返回 404，其他错误按 storeError 处理
+func habitStoreError(err error, message string) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperr.Wrap(err, apperr.CodeNotFound, "习惯不存在")
+	}
+	return storeError(err, message)
+}
+
+// ListHabits 习惯列表
+// @Summary 习惯列表
+// @Description 当前工作区的所有习惯，包括连续完成的周期数
+// @Tags habits
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.Habit}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/habits [get]
+func (h *Handler) ListHabits(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListHabits", timeout: ListTimeout, message: "获取习惯成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			habits, err := h.db.ListHabitsContext(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询习惯失败")
+			}
+			return habits, nil
+		})
+}
+
+// CreateHabit 创建习惯
+// @Summary 创建习惯
+// @Description 每天或每周自动生成一个新的待办事项，按时完成累加连续记录，错过一个周期则清零
+// @Tags habits
+// @Accept json
+// @Produce json
+// @Param request body handler.HabitRequest true "习惯"
+// @Success 201 {object} handler.Response{data=model.Habit}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/habits [post]
+func (h *Handler) CreateHabit(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateHabit", timeout: CreateTimeout, status: http.StatusCreated, message: "习惯已创建"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req HabitRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			habit, err := req.habit()
+			if err != nil {
+				return nil, err
+			}
+			if err := h.db.CreateHabitContext(ctx, habit); err != nil {
+				return nil, storeError(err, "创建习惯失败")
+			}
+			return habit, nil
+		})
+}
+
+// GetHabit 查看习惯
+// @Summary 查看习惯
+// @Tags habits
+// @Produce json
+// @Param id path int true "习惯ID"
+// @Success 200 {object} handler.Response{data=model.Habit}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/habits/{id} [get]
+func (h *Handler) GetHabit(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetHabit", timeout: ListTimeout, message: "获取习惯成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			habit, err := h.db.GetHabitContext(ctx, id)
+			if err != nil {
+				return nil, habitStoreError(err, "获取习惯失败")
+			}
+			return habit, nil
+		})
+}
+
+// UpdateHabit 修改习惯
+// @Summary 修改习惯
+// @Description 替换标题、描述和频率，或者通过 active 暂停/恢复；连续记录保留，已生成的待办事项不受影响
+// @Tags habits
+// @Accept json
+// @Produce json
+// @Param id path int true "习惯ID"
+// @Param request body handler.HabitRequest true "习惯"
+// @Success 200 {object} handler.Response{data=model.Habit}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/habits/{id} [put]
+func (h *Handler) UpdateHabit(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateHabit", timeout: UpdateTimeout, message: "习惯已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req HabitRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			habit, err := req.habit()
+			if err != nil {
+				return nil, err
+			}
+			habit.ID = id
+			if err := h.db.UpdateHabitContext(ctx, habit); err != nil {
+				return nil, habitStoreError(err, "更新习惯失败")
+			}
+			updated, err := h.db.GetHabitContext(ctx, id)
+			if err != nil {
+				return nil, habitStoreError(err, "获取习惯失败")
+			}
+			return updated, nil
+		})
+}
+
+// DeleteHabit 删除习惯
+// @Summary 删除习惯
+// @Description 不再生成新的待办事项，已经生成的待办事项保留
+// @Tags habits
+// @Produce json
+// @Param id path int true "习惯ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/habits/{id} [delete]
+func (h *Handler) DeleteHabit(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteHabit", timeout: DeleteTimeout, message: "习惯已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.db.DeleteHabitContext(ctx, id); err != nil {
+				return nil, habitStoreError(err, "删除习惯失败")
+			}
+			return nil, nil
+		})
+}
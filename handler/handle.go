@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// endpoint 描述一个接口的公共行为，由 serve 统一处理
+type endpoint struct {
+	name    string        // 日志中的名称，一般与方法名相同
+	timeout time.Duration // 处理超时
+	status  int           // 成功时的状态码，默认 200
+	message string        // 成功时的提示
+}
+
+// apiFunc 接口的业务逻辑：返回响应数据，或者返回错误交给 serve 统一转换为响应
+type apiFunc func(ctx context.Context, r *http.Request) (interface{}, error)
+
+// result 成功响应的状态码、提示或警告取决于处理结果时，apiFunc 返回它代替响应数据；零值字段使用 endpoint 中的设置
+type result struct {
+	data    interface{}
+	status  int
+	message string
+	warning string
+}
+
+// serve 统一处理超时、错误到状态码的映射和响应格式：
+//
+//	超时              408 TIMEOUT
+//	客户端取消        只记日志，不写响应
+//	*apperr.Error     按错误码目录中的状态码返回
+//	其他错误          500 INTERNAL_ERROR
+//
+// 返回 JSON 信封的接口都通过 serve（或 respond）写响应；以下接口的响应不是信封，
+// 自己管理超时，错误仍交给 sendAPIError：文件下载和导出（ExportTodos、ExportArchive、
+// DownloadAttachment、GetJobResult）、NDJSON 进度流（batchByFilter）、纯文本接口（simple.go）、
+// Slack 斜杠命令的回复格式、分享页的 HTML（GetSharedTodo 的 JSON 仍走 serve），以及健康检查（HealthCheck、Ready 不健康时也要带数据返回 503）
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, e endpoint, fn apiFunc) {
+	ctx, cancel := context.WithTimeout(r.Context(), e.timeout)
+	defer cancel()
+
+	data, err := fn(ctx, r)
+	h.respond(w, e, data, err)
+}
+
+// respond 写出 apiFunc 的结果，供不能整体交给 serve 的接口（如分片上传完成后的导入）使用
+func (h *Handler) respond(w http.ResponseWriter, e endpoint, data interface{}, err error) {
+	if err != nil {
+		h.sendAPIError(w, e.name, err)
+		return
+	}
+
+	res, ok := data.(result)
+	if !ok {
+		res = result{data: data}
+	}
+	if res.status == 0 {
+		res.status = e.status
+	}
+	if res.status == 0 {
+		res.status = http.StatusOK
+	}
+	if res.message == "" {
+		res.message = e.message
+	}
+	h.sendJSON(w, res.status, Response{
+		Success: true,
+		Data:    res.data,
+		Message: res.message,
+		Warning: res.warning,
+	})
+}
+
+// pageLimit 解析 limit 查询参数：未传或无效时使用 DEFAULT_PAGE_SIZE，
+// 超过 MAX_PAGE_SIZE 时按上限处理，并返回给客户端的 warning
+func (h *Handler) pageLimit(r *http.Request) (int, string) {
+	limit := h.cfg.DefaultPageSize
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > h.cfg.MaxPageSize {
+		return h.cfg.MaxPageSize, fmt.Sprintf("limit 超过上限，已按 %d 返回", h.cfg.MaxPageSize)
+	}
+	return limit, ""
+}
+
+// maxTruncateDescription truncate_description 的上限，超过时和不截断没有区别
+const maxTruncateDescription = model.DefaultMaxDescriptionLength
+
+// descriptionPreview 解析 truncate_description 查询参数：列表中的描述只保留前若干个字符，只需要预览的客户端可以减小响应体积
+// 未传时返回 0（不截断），传入的不是 1 到上限之间的整数时返回 INVALID_PARAM
+func descriptionPreview(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("truncate_description")
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 || n > maxTruncateDescription {
+		return 0, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("truncate_description 必须是 1 到 %d 之间的整数", maxTruncateDescription))
+	}
+	return n, nil
+}
+
+// parsePriorityFilter 解析 priority / min_priority 查询参数写入 filter，名称和数值都接受，
+// 超出 PRIORITY_LABELS 范围或名称未知时返回 INVALID_PARAM
+func (h *Handler) parsePriorityFilter(r *http.Request, filter *storage.TodoFilter) error {
+	for _, p := range []struct {
+		key    string
+		target **int
+	}{
+		{"priority", &filter.Priority},
+		{"min_priority", &filter.MinPriority},
+	} {
+		v := r.URL.Query().Get(p.key)
+		if v == "" {
+			continue
+		}
+		value, err := model.ParsePriority(v).Resolve(h.cfg.PriorityScale)
+		if err != nil {
+			return apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("%s 无效：%v", p.key, err))
+		}
+		*p.target = &value
+	}
+	return nil
+}
+
+// sendAPIError 把错误转换为响应（规则见 serve）
+func (h *Handler) sendAPIError(w http.ResponseWriter, name string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("%s timeout: %v", name, err)
+		h.sendError(w, apperr.CodeTimeout, "请求超时，请稍后重试")
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		// 客户端取消请求，不需要响应
+		log.Printf("%s canceled: %v", name, err)
+		return
+	}
+
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		// 4xx 是预期内的结果（如记录不存在），只记录服务端错误的原因
+		if appErr.Err != nil && appErr.Status() >= http.StatusInternalServerError {
+			log.Printf("%s failed: %v", name, appErr.Err)
+		}
+		h.sendErrorDetails(w, appErr.Code, appErr.Message, appErr.Details)
+		return
+	}
+
+	log.Printf("%s failed: %v", name, err)
+	h.sendError(w, apperr.CodeInternalError, "服务器内部错误")
+}
+
+// storeError 把数据库错误转换为响应错误，数据库错误到错误码的映射只在这里维护：
+//
+//	storage.ErrNotFound        404 NOT_FOUND（目前只有待办事项使用）
+//	storage.ErrVersionConflict 409 VERSION_CONFLICT
+//	超时、取消                  原样返回，由 sendAPIError 处理
+//	其他错误                    500 DATABASE_ERROR，message 是返回给客户端的提示
+func storeError(err error, message string) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return err
+	case errors.Is(err, storage.ErrNotFound):
+		return apperr.Wrap(err, apperr.CodeNotFound, "待办事项不存在")
+	case errors.Is(err, storage.ErrVersionConflict):
+		return apperr.Wrap(err, apperr.CodeVersionConflict, "版本冲突，请刷新后重试")
+	default:
+		return apperr.Wrap(err, apperr.CodeDatabaseError, message)
+	}
+}
+
+// pathID 解析路径中的正整数 ID
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		return 0, apperr.New(apperr.CodeInvalidID, "无效的ID")
+	}
+	return id, nil
+}
+
+// decodeJSON 解析 JSON 请求体
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return apperr.New(apperr.CodeInvalidJSON, fmt.Sprintf("JSON解析失败: %v", err))
+	}
+	return nil
+}
@@ -12,9 +12,28 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"todo-list/aging"
+	"todo-list/apperr"
+	"todo-list/clock"
+	"todo-list/config"
 	"todo-list/database"
+	"todo-list/eventbus"
+	"todo-list/extension"
+	"todo-list/features"
+	"todo-list/hooks"
+	"todo-list/issues"
+	"todo-list/jobs"
 	"todo-list/model"
+	"todo-list/outbound"
+	"todo-list/ratelimit"
+	"todo-list/scheduler"
+	"todo-list/storage"
+	"todo-list/traffic"
+	"todo-list/webhook"
+	"todo-list/workflow"
 )
 
 // Response 统一响应格式
@@ -23,32 +42,89 @@ type Response struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   *ErrorInfo  `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	Warning string      `json:"warning,omitempty"` // 例如访问了已弃用的路由
 }
 
 // CreateTodoRequest 创建待办事项请求体
 type CreateTodoRequest struct {
-	Title       string `json:"title" example:"Buy groceries"`
-	Description string `json:"description" example:"Milk, bread, and fruits"`
+	Title       string   `json:"title" example:"Buy groceries"`
+	Description string   `json:"description" example:"Milk, bread, and fruits"`
+	DueDate     *string  `json:"due_date,omitempty" example:"2024-05-30 16:00"` // 见 model.ParseDueDate
+	Latitude    *float64 `json:"latitude,omitempty" example:"31.2304"`
+	Longitude   *float64 `json:"longitude,omitempty" example:"121.4737"`
+	Radius      *float64 `json:"radius,omitempty" example:"300"`
+
+	EstimatedMinutes *int `json:"estimated_minutes,omitempty" example:"45"`
+
+	Priority *model.Priority `json:"priority,omitempty" swaggertype:"string" example:"high"` // 数值或名称，名称见 GET /api/v1/capabilities
+
+	Recurrence string `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO"` // RFC 5545 RRULE，完成后自动生成下一次
+
+	ProjectID *int `json:"project_id,omitempty" example:"1"` // 见 GET /api/v1/projects
+
+	SecretNote *model.SecretNote `json:"secret_note,omitempty"` // 客户端加密后的备注，服务端原样保存，从不解密
 }
 
 // UpdateTodoRequest 更新待办事项请求体
 type UpdateTodoRequest struct {
-	Version     *int       `json:"version,omitempty" example:"2"`
-	Title       *string    `json:"title,omitempty" example:"Update weekly report"`
-	Description *string    `json:"description,omitempty" example:"Finish and send by EOD"`
-	Status      *string    `json:"status,omitempty" example:"DONE"`
-	DueDate     *time.Time `json:"due_date,omitempty" example:"2024-05-30T16:00:00Z"`
+	Version     *int     `json:"version,omitempty" example:"2"`
+	Title       *string  `json:"title,omitempty" example:"Update weekly report"`
+	Description *string  `json:"description,omitempty" example:"Finish and send by EOD"`
+	Status      *string  `json:"status,omitempty" example:"DONE"`
+	DueDate     *string  `json:"due_date,omitempty" example:"2024-05-30 16:00"` // 见 model.ParseDueDate
+	Latitude    *float64 `json:"latitude,omitempty" example:"31.2304"`
+	Longitude   *float64 `json:"longitude,omitempty" example:"121.4737"`
+	Radius      *float64 `json:"radius,omitempty" example:"300"`
+
+	EstimatedMinutes *int `json:"estimated_minutes,omitempty" example:"45"` // 传 0 清除预估
+
+	Priority *model.Priority `json:"priority,omitempty" swaggertype:"string" example:"high"` // 数值或名称，名称见 GET /api/v1/capabilities
+
+	Recurrence *string `json:"recurrence,omitempty" example:"FREQ=MONTHLY;BYMONTHDAY=1"` // 传空字符串取消重复
+
+	ProjectID *int `json:"project_id,omitempty" example:"1"` // 传 0 移出项目
+
+	SecretNote *model.SecretNote `json:"secret_note,omitempty"` // 整体替换，ciphertext 为空时清除备注
 }
 
 // ErrorInfo 错误信息
+// Details 放机器可读的补充信息（例如超出的上限值），客户端不需要解析 Message
 type ErrorInfo struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    apperr.Code            `json:"code" example:"NOT_FOUND"` // 完整列表见 GET /api/v1/errors
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
 }
 
 // Handler 处理器结构体
 type Handler struct {
-	db *database.DB
+	todos    storage.TodoRepository // 待办事项的存取
+	repos    storage.Repositories   // 项目、评论、webhook、API key、工作区等
+	db       Store                  // 只保存在 SQLite 中的数据，DB_DRIVER=memory 时为 nil
+	cfg      *config.Config
+	sqlite   bool               // 待办事项也保存在 db 中，只有这时才能使用 SQLite 专属的功能，见 RequireSQLiteTodos
+	shared   bool               // 待办事项和 repos 是同一个实现，项目级操作、评论和变更事件依赖这一点，见 RequireTodoRepositories
+	outbound *http.Client       // 访问外部 URL（带 SSRF 防护）
+	hooks    *hooks.Registry    // 入站 webhook 集成
+	limiter  *ratelimit.Limiter // 按客户端限流，未启用时为 nil
+	workflow *workflow.Workflow // 状态及允许的流转
+	aging    *aging.Rules       // 停滞事项规则，未配置时为 nil
+	files    storage.Store      // 附件文件存储（本地目录或对象存储）
+
+	scheduler *scheduler.Scheduler // 定时任务调度器，用于管理接口查看和调整计划
+	features  *features.Set        // 实验性功能开关
+	queue     *jobs.Queue          // 后台任务队列，长时间操作通过它异步执行，见 SetJobQueue
+	issues    *issues.Importer     // 工单导入，没有配置来源时为 nil，见 SetIssueImporter
+	webhooks  *webhook.Dispatcher  // 出站 webhook 推送，见 SetWebhookDispatcher
+	events    *eventbus.Bus        // 新变更事件的通知，没有跨实例的通知时为 nil，见 SetEventBus
+	clock     clock.Clock          // 当前时间的来源，见 SetClock
+
+	uploadLocks sync.Map                 // 正在写入或处理的分片上传 ID，见 lockUpload
+	semaphores  map[string]chan struct{} // 开销大的接口按分组限制并发，见 LimitConcurrency
+	drain       drainState               // 摘流状态，见 StartDrain
+	traffic     *traffic.Recorder        // 按接口统计请求和响应大小，见 TrackTraffic
+	keysExist   atomic.Bool              // 已经创建过 API key，见 requireAuth；key 只撤销不删除，变为 true 后不会再变回去
+
+	authenticate func(r *http.Request) (string, error) // 认证扩展的认证函数，没有认证扩展时为 nil，见 Authenticate
 }
 
 // 超时配置
@@ -62,15 +138,69 @@ const (
 	BatchTimeout   = 10 * time.Second // 批量操作超时
 	ExportTimeout  = 30 * time.Second // 导出超时（可能数据量大）
 	ImportTimeout  = 60 * time.Second // 导入超时（可能数据量大）
+
+	InboundEmailTimeout = 5 * time.Second  // 邮件入站超时
+	LinkFetchTimeout    = 15 * time.Second // 后台抓取链接元数据超时
+	WebhookTestTimeout  = 15 * time.Second // 测试推送 webhook 超时
+	UploadTimeout       = 60 * time.Second // 上传附件（含病毒扫描）超时
+	HealthPingTimeout   = 2 * time.Second  // 健康检查中检查数据库的超时
 )
 
 // NewHandler 创建新的处理器
-func NewHandler(db *database.DB) *Handler {
-	return &Handler{db: db}
+// 待办事项通过 todos 读写，项目、webhook 等通过 repos 读写，可以都是同一个 database.DB，也可以是其他后端；
+// db 为 nil 时（DB_DRIVER=memory）不使用 SQLite，上传、后台任务和备份接口返回 FEATURE_DISABLED
+func NewHandler(todos storage.TodoRepository, repos storage.Repositories, db Store, cfg *config.Config) *Handler {
+	h := &Handler{
+		todos:    todos,
+		repos:    repos,
+		db:       db,
+		cfg:      cfg,
+		sqlite:   sameStore(todos, db),
+		shared:   sameRepositories(todos, repos),
+		outbound: outbound.NewClient(cfg.Outbound),
+		clock:    clock.Real{},
+		traffic:  traffic.NewRecorder(cfg.Traffic),
+
+		authenticate: extension.Authenticator(),
+	}
+	h.semaphores = newSemaphores(cfg.ConcurrencyLimits)
+	h.hooks = h.newHookRegistry(cfg)
+	h.workflow = workflow.Default()
+	h.features = features.New(cfg.Features)
+	h.files = cfg.Attachments.Storage
+	if cfg.RateLimitPerMinute > 0 {
+		h.limiter = ratelimit.New(cfg.RateLimitPerMinute, time.Minute)
+	}
+	return h
+}
+
+// SetClock 替换取当前时间的时钟（测试中使用 clock.Fake），限流窗口和入站 webhook 的时间戳校验也改用它
+func (h *Handler) SetClock(c clock.Clock) {
+	h.clock = c
+	h.hooks.SetClock(c)
+	if h.limiter != nil {
+		h.limiter.SetClock(c)
+	}
+}
+
+// presenter 输出待办事项前计算 is_overdue、due_in_seconds 和 priority_label，
+// 时钟、未完成状态和优先级映射都取处理器当前的设置
+func (h *Handler) presenter() model.Presenter {
+	return model.Presenter{
+		Clock:        h.clock,
+		OpenStatuses: storage.NewStatusSets(h.workflow.Names(), h.workflow.IsTerminal).Open,
+		Scale:        h.cfg.PriorityScale,
+	}
 }
 
 // sendJSON 发送JSON响应
 func (h *Handler) sendJSON(w http.ResponseWriter, status int, response Response) {
+	// Deprecated 中间件已经设置了 Deprecation 头，在信封里同步给出提示
+	if response.Warning == "" && w.Header().Get("Deprecation") != "" {
+		response.Warning = legacyRouteWarning
+	}
+	response.Data = h.presenter().Apply(response.Data)
+
 	buf := new(bytes.Buffer)
 	if err := json.NewEncoder(buf).Encode(response); err != nil {
 		// JSON编码失败，直接返回纯文本错误，不要再尝试调用sendError（会递归）
@@ -86,8 +216,8 @@ func (h *Handler) sendJSON(w http.ResponseWriter, status int, response Response)
 	w.Write(buf.Bytes())
 }
 
-// sendError 发送错误响应
-func (h *Handler) sendError(w http.ResponseWriter, status int, code, message string) {
+// sendError 发送错误响应，状态码由错误码目录决定
+func (h *Handler) sendError(w http.ResponseWriter, code apperr.Code, message string) {
 	response := Response{
 		Success: false,
 		Error: &ErrorInfo{
@@ -95,23 +225,56 @@ func (h *Handler) sendError(w http.ResponseWriter, status int, code, message str
 			Message: message,
 		},
 	}
-	h.sendJSON(w, status, response)
+	h.sendJSON(w, code.Status(), response)
+}
+
+// sendErrorDetails 发送带机器可读详情的错误响应
+func (h *Handler) sendErrorDetails(w http.ResponseWriter, code apperr.Code, message string, details map[string]interface{}) {
+	h.sendJSON(w, code.Status(), Response{
+		Success: false,
+		Error: &ErrorInfo{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
 }
 
 // HealthCheck 健康检查
 // @Summary 健康检查
-// @Description 返回应用当前健康状态
+// @Description 返回应用当前健康状态，包括数据库连通性和连接池状态
 // @Tags health
 // @Produce json
-// @Success 200 {object} handler.Response
+// @Success 200 {object} handler.Response{data=handler.HealthStatus}
+// @Failure 503 {object} handler.Response{data=handler.HealthStatus,error=handler.ErrorInfo}
 // @Router /health [get]
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), HealthPingTimeout)
+	defer cancel()
+
+	health := HealthStatus{
+		Status:    "ok",
+		Timestamp: h.clock.Now().UTC(),
+		Database: DatabaseHealth{
+			Status: "ok",
+			Pool:   newPoolStats(h.poolStats()),
+		},
+	}
+	if err := h.repos.PingContext(ctx); err != nil {
+		log.Printf("Health check: database unavailable: %v", err)
+		health.Status = "degraded"
+		health.Database.Status = "unavailable"
+		h.sendJSON(w, apperr.CodeDatabaseUnavailable.Status(), Response{
+			Success: false,
+			Data:    health,
+			Error:   &ErrorInfo{Code: apperr.CodeDatabaseUnavailable, Message: "数据库不可用"},
+		})
+		return
+	}
+
 	response := Response{
 		Success: true,
-		Data: map[string]interface{}{
-			"status":    "ok",
-			"timestamp": "server-time",
-		},
+		Data:    health,
 		Message: "服务运行正常",
 	}
 	h.sendJSON(w, http.StatusOK, response)
@@ -123,84 +286,116 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 // @Tags todos
 // @Param status query string false "状态过滤"
 // @Param search query string false "搜索关键字"
-// @Param sort query string false "排序字段"
+// @Param sort query string false "排序字段，position 为拖放调整的顺序（只有 sqlite 支持）" Enums(created_at,due_date,status,priority,updated_at,position)
 // @Param order query string false "排序方式" Enums(asc,desc)
-// @Param limit query int false "返回条数" default(50)
+// @Param priority query string false "只看该优先级，数值或名称（见 GET /api/v1/capabilities）"
+// @Param min_priority query string false "只看不低于该优先级的事项，数值或名称"
+// @Param project query string false "只看该项目的事项，传项目 ID，或 none 只看不属于任何项目的事项"
+// @Param include_archived query bool false "不按项目查看时也列出已归档项目中的事项" default(false)
+// @Param limit query int false "返回条数，默认和上限见 GET /api/v1/capabilities" default(50)
 // @Param offset query int false "偏移量" default(0)
+// @Param include_total query bool false "是否返回 total，传 false 时跳过计数" default(true)
+// @Param truncate_description query int false "描述只返回前若干个字符，被截断的条目 description_truncated 为 true"
+// @Param near query string false "当前位置 lat,lng"
+// @Param radius query number false "搜索半径（米），不传则使用每条待办事项自己的半径"
+// @Param view query string false "预定义视图，展开为服务端的筛选条件，可以和其他参数组合" Enums(today,week,overdue,inbox,stale)
+// @Param numbering query string false "编号范围（如命令行会话 ID），传入时按列表顺序给每条分配 number（offset+1 开始），见 GET /api/v1/todos/numbers"
 // @Produce json
 // @Success 200 {object} handler.Response
-// @Failure 500 {object} handler.Response
-// @Router /todos [get]
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos [get]
 func (h *Handler) ListTodos(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 Context
-	ctx, cancel := context.WithTimeout(r.Context(), ListTimeout)
-	defer cancel()
+	h.serve(w, r, endpoint{name: "ListTodos", timeout: ListTimeout, message: "获取待办事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			// 限制最大值，防止恶意请求
+			limit, warning := h.pageLimit(r)
 
-	// 解析查询参数
-	status := r.URL.Query().Get("status")
-	search := r.URL.Query().Get("search")
-	sort := r.URL.Query().Get("sort")
-	order := r.URL.Query().Get("order")
+			offset := 0
+			if o := r.URL.Query().Get("offset"); o != "" {
+				if o, err := strconv.Atoi(o); err == nil && o >= 0 {
+					offset = o
+				}
+			}
 
-	limit := 50
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if l, err := strconv.Atoi(l); err == nil && l > 0 {
-			limit = l
-			// 限制最大值，防止恶意请求
-			if limit > 200 {
-				limit = 200
+			// 构建过滤器
+			filter := storage.TodoFilter{
+				Status: r.URL.Query().Get("status"),
+				Search: r.URL.Query().Get("search"),
+				Sort:   r.URL.Query().Get("sort"),
+				Order:  r.URL.Query().Get("order"),
+				Limit:  limit,
+				Offset: offset,
 			}
-		}
-	}
 
-	offset := 0
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if o, err := strconv.Atoi(o); err == nil && o >= 0 {
-			offset = o
-		}
-	}
+			if v := r.URL.Query().Get("include_total"); v != "" {
+				include, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, apperr.New(apperr.CodeInvalidParam, "include_total 必须是 true 或 false")
+				}
+				filter.SkipTotal = !include
+			}
 
-	// 构建过滤器
-	filter := database.TodoFilter{
-		Status: status,
-		Search: search,
-		Sort:   sort,
-		Order:  order,
-		Limit:  limit,
-		Offset: offset,
-	}
+			preview, err := descriptionPreview(r)
+			if err != nil {
+				return nil, err
+			}
+			if err := parseNearFilter(r, &filter); err != nil {
+				return nil, err
+			}
+			if err := h.parsePriorityFilter(r, &filter); err != nil {
+				return nil, err
+			}
+			if err := parseProjectFilter(r, &filter); err != nil {
+				return nil, err
+			}
+			if v := r.URL.Query().Get("include_archived"); v != "" {
+				include, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, apperr.New(apperr.CodeInvalidParam, "include_archived 必须是 true 或 false")
+				}
+				filter.IncludeArchived = include
+			}
+			if err := h.applyView(r, &filter); err != nil {
+				return nil, err
+			}
+			scope, err := parseNumberingScope(r)
+			if err != nil {
+				return nil, err
+			}
+			if scope != "" || filter.Sort == "position" {
+				// 编号保存在 SQLite 中并引用 todos 表，拖放的顺序也只保存在 SQLite 中
+				if err := h.requireSQLite(); err != nil {
+					return nil, err
+				}
+			}
 
-	// 调用带 Context 的数据库方法
-	todos, total, err := h.db.ListTodosContext(ctx, filter)
-	if err != nil {
-		// 区分超时错误和其他错误
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("ListTodos timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "查询超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ListTodos canceled: %v", err)
-			// 客户端取消请求,不需要响应
-			return
-		}
-		log.Printf("Failed to list todos: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "DATABASE_ERROR", "查询失败")
-		return
-	}
+			todos, total, err := h.todos.ListTodosContext(ctx, filter)
+			if err != nil {
+				return nil, storeError(err, "查询失败")
+			}
 
-	// 返回结果（包含分页信息）
-	response := Response{
-		Success: true,
-		Data: map[string]interface{}{
-			"todos":  todos,
-			"total":  total,
-			"limit":  limit,
-			"offset": offset,
-		},
-		Message: "获取待办事项成功",
-	}
-	h.sendJSON(w, http.StatusOK, response)
+			for i := range todos {
+				todos[i].TruncateDescription(preview)
+			}
+
+			if scope != "" {
+				if err := h.assignNumbers(ctx, scope, offset, todos); err != nil {
+					return nil, err
+				}
+			}
+
+			// 返回结果（包含分页信息，include_total=false 时没有 total）
+			data := map[string]interface{}{
+				"todos":  todos,
+				"limit":  limit,
+				"offset": offset,
+			}
+			if !filter.SkipTotal {
+				data["total"] = total
+			}
+			return result{data: data, warning: warning}, nil
+		})
 }
 
 // CreateTodo 创建待办事项(带超时控制)
@@ -211,62 +406,124 @@ func (h *Handler) ListTodos(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param todo body handler.CreateTodoRequest true "待办事项内容"
 // @Success 201 {object} handler.Response
-// @Failure 400 {object} handler.Response
-// @Failure 500 {object} handler.Response
-// @Router /todos [post]
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos [post]
 func (h *Handler) CreateTodo(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), CreateTimeout)
-	defer cancel()
-
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 限制1MB
 	defer r.Body.Close()
 
-	if r.Method != http.MethodPost {
-		h.sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-		return
-	}
+	h.serve(w, r, endpoint{name: "CreateTodo", timeout: CreateTimeout, status: http.StatusCreated, message: "创建待办事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req CreateTodoRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
 
-	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 限制1MB
+			// 验证数据：标题去掉多余空白后不能为空，标题和描述不能超过长度上限，截止日期统一为 UTC
+			dueDate, dueViolations, err := h.resolveDueDate(ctx, r, req.DueDate)
+			if err != nil {
+				return nil, err
+			}
+			violations := h.todoTextViolations(&req.Title, &req.Description)
+			violations = append(violations, dueViolations...)
+			violations = append(violations, secretNoteViolations(req.SecretNote)...)
+			if err := violationError(violations); err != nil {
+				return nil, err
+			}
 
-	// 解析请求体
-	var req CreateTodoRequest
+			if err := validateLocation(req.Latitude, req.Longitude, req.Radius); err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
+			if err := validateEstimate(req.EstimatedMinutes); err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", fmt.Sprintf("JSON解析失败: %v", err))
-		return
-	}
+			priority := model.DefaultPriority
+			if req.Priority != nil {
+				p, err := req.Priority.Resolve(h.cfg.PriorityScale)
+				if err != nil {
+					return nil, apperr.New(apperr.CodeValidationError, err.Error())
+				}
+				priority = p
+			}
 
-	// 验证数据
-	if req.Title == "" {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", "标题不能为空")
-		return
-	}
+			rule, err := h.resolveRecurrence(req.Recurrence)
+			if err != nil {
+				return nil, err
+			}
 
-	// 创建Todo
-	todo := model.NewTodo(req.Title, req.Description)
+			if req.ProjectID != nil {
+				if err := h.checkProject(ctx, *req.ProjectID); err != nil {
+					return nil, err
+				}
+			}
 
-	if err := h.db.CreateTodoContext(ctx, todo); err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("CreateTodo timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "创建超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ListTodos canceled: %v", err)
-			// 客户端取消请求,不需要响应
-			return
-		}
-		log.Printf("Failed to create todo: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "DATABASE_ERROR", "创建失败")
-		return
-	}
+			if err := h.checkTodoQuota(ctx, 1); err != nil {
+				return nil, h.quotaAPIError(w, err)
+			}
 
-	response := Response{
-		Success: true,
-		Data:    todo,
-		Message: "创建待办事项成功",
-	}
+			// 创建Todo
+			todo := model.NewTodo(req.Title, req.Description, h.clock.Now())
+			todo.DueDate = dueDate
+			todo.Priority = priority
+			if req.Latitude != nil {
+				todo.SetLocation(*req.Latitude, *req.Longitude, req.Radius, todo.CreatedAt)
+			}
+			if req.EstimatedMinutes != nil && *req.EstimatedMinutes > 0 {
+				todo.EstimatedMinutes = req.EstimatedMinutes
+			}
+			setRecurrence(todo, rule)
+			if req.ProjectID != nil && *req.ProjectID > 0 {
+				todo.ProjectID = req.ProjectID
+			}
+			if req.SecretNote != nil && req.SecretNote.Ciphertext != "" {
+				todo.SecretNote = req.SecretNote
+			}
+
+			if err := h.beforeCreate(ctx, todo); err != nil {
+				return nil, err
+			}
 
-	h.sendJSON(w, http.StatusCreated, response)
+			if err := h.todos.CreateTodoContext(ctx, todo); err != nil {
+				return nil, storeError(err, "创建失败")
+			}
+			h.recordAccess(ctx, r, todo.ID, database.AccessModified)
+			if todo.DueDate != nil && todo.Priority >= h.cfg.Invites.MinPriority {
+				h.checkInvites()
+			}
+			return todo, nil
+		})
+}
+
+// GetTodo 获取单个待办事项(带超时控制)
+// @Summary 获取待办事项
+// @Description 根据 ID 获取单个待办事项，ETag 为当前版本号，更新时可以放进 If-Match
+// @Tags todos
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Success 200 {object} handler.Response{data=model.Todo}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id} [get]
+func (h *Handler) GetTodo(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetTodo", timeout: DefaultTimeout, message: "获取待办事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			todo, err := h.todos.GetTodoByIDContext(ctx, id)
+			if err != nil {
+				return nil, storeError(err, "获取待办事项失败")
+			}
+			h.recordAccess(ctx, r, todo.ID, database.AccessViewed)
+			setETag(w, todo.Version)
+			return todo, nil
+		})
 }
 
 // UpdateTodo 更新待办事项(带超时控制)
@@ -275,63 +532,79 @@ func (h *Handler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 // @Tags todos
 // @Accept json
 // @Produce json
-// @Param id path int true "待办事项ID"
+// @Param id path string true "待办事项ID或public_id"
 // @Param todo body handler.UpdateTodoRequest true "待办事项更新内容"
 // @Success 200 {object} handler.Response
-// @Failure 400 {object} handler.Response
-// @Failure 404 {object} handler.Response
-// @Failure 409 {object} handler.Response
-// @Failure 500 {object} handler.Response
-// @Router /todos/{id} [put]
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Param If-Match header string false "期望的版本号（与请求体中的 version 等价）"
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 428 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id} [put]
 func (h *Handler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), UpdateTimeout)
-	defer cancel()
-
 	defer r.Body.Close()
 
-	if r.Method != http.MethodPut {
-		h.sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-		return
-	}
+	h.serve(w, r, endpoint{name: "UpdateTodo", timeout: UpdateTimeout, message: "更新待办事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
 
-	idStr := r.PathValue("id")
-	if idStr == "" {
-		h.sendError(w, http.StatusBadRequest, "INVALID_ID", "无效的ID")
-		return
+			var req UpdateTodoRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			return h.updateTodo(ctx, w, r, id, &req, todoClears{})
+		})
+}
+
+// todoClears PATCH 中显式设为 null 的字段，PUT 的指针字段无法表达"清除"
+type todoClears struct {
+	DueDate  bool // 清除截止日期
+	Location bool // 清除位置提醒（latitude、longitude、radius 一起清除）
+	Radius   bool // 只清除提醒半径，改用默认半径
+}
+
+// updateTodo PUT 和 PATCH 共用的更新流程：校验、处理版本号、修改字段并保存，返回更新后的待办事项（w 用于设置 ETag）
+func (h *Handler) updateTodo(ctx context.Context, w http.ResponseWriter, r *http.Request, id int, req *UpdateTodoRequest, clears todoClears) (*model.Todo, error) {
+	if req.Version != nil && *req.Version < 1 {
+		return nil, apperr.New(apperr.CodeValidationError, "版本号无效")
 	}
 
-	id, err := strconv.Atoi(idStr)
+	dueDate, dueViolations, err := h.resolveDueDate(ctx, r, req.DueDate)
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_ID", fmt.Sprintf("无效的ID格式: %v", err))
-		return
+		return nil, err
 	}
-
-	if id <= 0 {
-		h.sendError(w, http.StatusBadRequest, "INVALID_ID", "无效的ID")
-		return
+	violations := h.todoTextViolations(req.Title, req.Description)
+	violations = append(violations, dueViolations...)
+	violations = append(violations, secretNoteViolations(req.SecretNote)...)
+	if err := violationError(violations); err != nil {
+		return nil, err
 	}
 
-	var req UpdateTodoRequest
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", fmt.Sprintf("Invalid JSON format: %v", err))
-		return
+	// 版本号也可以放在 If-Match 请求头中，两处都给出时必须一致
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	if ifMatch != nil {
+		if req.Version != nil && *req.Version != *ifMatch {
+			return nil, apperr.New(apperr.CodeValidationError, "请求体中的 version 与 If-Match 不一致")
+		}
+		req.Version = ifMatch
 	}
 
-	if req.Version != nil && *req.Version < 1 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", "版本号无效")
-		return
+	// 严格模式下必须带版本号，避免无条件覆盖别人的修改
+	if req.Version == nil && h.cfg.StrictVersioning {
+		return nil, apperr.New(apperr.CodePreconditionRequired, "更新必须提供 version 字段或 If-Match 请求头")
 	}
 
-	existingTodo, err := h.db.GetTodoByID(id)
+	existingTodo, err := h.todos.GetTodoByIDContext(ctx, id)
 	if err != nil {
-		log.Printf("failed to get todo: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "DATABASE_ERROR", "获取待办事项失败")
-		return
-	}
-	if existingTodo == nil {
-		h.sendError(w, http.StatusNotFound, "NOT_FOUND", "待办事项不存在")
-		return
+		return nil, storeError(err, "获取待办事项失败")
 	}
 
 	// 更新字段
@@ -341,18 +614,79 @@ func (h *Handler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	if req.Description != nil {
 		existingTodo.Description = *req.Description
 	}
+	wasTerminal := h.workflow.IsTerminal(existingTodo.Status)
 	if req.Status != nil {
-		existingTodo.Status = *req.Status
-		switch *req.Status {
-		case "completed":
-			now := time.Now()
-			existingTodo.CompletedAt = &now
-		case "pending":
-			existingTodo.CompletedAt = nil
+		if err := h.applyStatus(existingTodo, *req.Status); err != nil {
+			return nil, err
 		}
 	}
-	if req.DueDate != nil {
-		existingTodo.SetDueDate(*req.DueDate)
+	if dueDate != nil {
+		existingTodo.SetDueDate(*dueDate, h.clock.Now())
+	}
+	if clears.DueDate {
+		existingTodo.DueDate = nil
+	}
+	if clears.Location {
+		existingTodo.Latitude, existingTodo.Longitude, existingTodo.Radius = nil, nil, nil
+	}
+	if clears.Radius {
+		existingTodo.Radius = nil
+	}
+	if req.Latitude != nil || req.Longitude != nil || req.Radius != nil {
+		lat, lng, radius := existingTodo.Latitude, existingTodo.Longitude, existingTodo.Radius
+		if req.Latitude != nil {
+			lat = req.Latitude
+		}
+		if req.Longitude != nil {
+			lng = req.Longitude
+		}
+		if req.Radius != nil {
+			radius = req.Radius
+		}
+		if err := validateLocation(lat, lng, radius); err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, err.Error())
+		}
+		existingTodo.SetLocation(*lat, *lng, radius, h.clock.Now())
+	}
+	if req.EstimatedMinutes != nil {
+		if err := validateEstimate(req.EstimatedMinutes); err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, err.Error())
+		}
+		existingTodo.EstimatedMinutes = req.EstimatedMinutes
+		if *req.EstimatedMinutes == 0 {
+			existingTodo.EstimatedMinutes = nil
+		}
+	}
+	if req.Priority != nil {
+		p, err := req.Priority.Resolve(h.cfg.PriorityScale)
+		if err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, err.Error())
+		}
+		existingTodo.Priority = p
+	}
+	if req.Recurrence != nil {
+		rule, err := h.resolveRecurrence(*req.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		if rule != existingTodo.Recurrence {
+			setRecurrence(existingTodo, rule)
+		}
+	}
+	if req.ProjectID != nil {
+		if err := h.checkProject(ctx, *req.ProjectID); err != nil {
+			return nil, err
+		}
+		existingTodo.ProjectID = req.ProjectID
+		if *req.ProjectID == 0 {
+			existingTodo.ProjectID = nil
+		}
+	}
+	if req.SecretNote != nil {
+		existingTodo.SecretNote = req.SecretNote
+		if req.SecretNote.Ciphertext == "" {
+			existingTodo.SecretNote = nil
+		}
 	}
 
 	// 处理乐观锁
@@ -360,33 +694,19 @@ func (h *Handler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		existingTodo.Version = *req.Version
 	}
 
-	if err := h.db.UpdateTodoContext(ctx, existingTodo); err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("UpdateTodo timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "更新超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, database.ErrVersionConflict) {
-			h.sendError(w, http.StatusConflict, "VERSION_CONFLICT", "版本冲突，请刷新后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ListTodos canceled: %v", err)
-			// 客户端取消请求,不需要响应
-			return
-		}
-		log.Printf("Failed to update todo: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "DATABASE_ERROR", "更新失败")
-		return
+	if err := h.todos.UpdateTodoContext(ctx, existingTodo); err != nil {
+		return nil, storeError(err, "更新失败")
 	}
-
-	response := Response{
-		Success: true,
-		Data:    existingTodo,
-		Message: "更新待办事项成功",
+	if !wasTerminal && h.workflow.IsTerminal(existingTodo.Status) {
+		h.completed(ctx, existingTodo)
+	}
+	if dueDate != nil || clears.DueDate || req.Priority != nil || req.Status != nil {
+		h.checkInvites()
 	}
+	h.recordAccess(ctx, r, existingTodo.ID, database.AccessModified)
 
-	h.sendJSON(w, http.StatusOK, response)
+	setETag(w, existingTodo.Version)
+	return existingTodo, nil
 }
 
 // DeleteTodo 删除待办事项(带超时控制)
@@ -394,87 +714,72 @@ func (h *Handler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 // @Description 根据 ID 删除待办事项
 // @Tags todos
 // @Produce json
-// @Param id path int true "待办事项ID"
+// @Param id path string true "待办事项ID或public_id"
 // @Success 200 {object} handler.Response
-// @Failure 400 {object} handler.Response
-// @Failure 500 {object} handler.Response
-// @Router /todos/{id} [delete]
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id} [delete]
 func (h *Handler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), DeleteTimeout)
-	defer cancel()
-
 	defer r.Body.Close()
 
-	if r.Method != http.MethodDelete {
-		h.sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
-		return
-	}
-
-	idStr := r.PathValue("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_ID", fmt.Sprintf("无效的Id格式: %v", err))
-		return
-	}
-
-	if id <= 0 {
-		h.sendError(w, http.StatusBadRequest, "INVALID_ID", "无效的ID")
-		return
-	}
-
-	if err := h.db.DeleteTodoContext(ctx, id); err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("DeleteTodo timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "删除超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ListTodos canceled: %v", err)
-			// 客户端取消请求,不需要响应
-			return
-		}
-		log.Printf("Failed to delete todo: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "DATABASE_ERROR", "删除失败")
-		return
-	}
-
-	response := Response{
-		Success: true,
-		Message: "删除待办事项成功",
-	}
-
-	h.sendJSON(w, http.StatusOK, response)
+	h.serve(w, r, endpoint{name: "DeleteTodo", timeout: DeleteTimeout, message: "删除待办事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.todos.DeleteTodoContext(ctx, id); err != nil {
+				return nil, storeError(err, "删除失败")
+			}
+			return nil, nil
+		})
 }
 
 // GetStats 获取统计信息(带超时控制)
+// 接受与列表相同的过滤参数（status 除外），仪表盘可以显示当前筛选结果的统计
+// @Summary 待办事项统计
+// @Description 总数、完成情况、逾期和到期分布、按状态分组以及完成耗时
+// @Description 可以传入与列表相同的 search、near、radius、priority、min_priority、project、view 过滤参数，只统计符合条件的事项；status 不参与过滤
+// @Tags todos
+// @Produce json
+// @Param search query string false "搜索关键字"
+// @Param priority query string false "只统计该优先级，数值或名称"
+// @Param min_priority query string false "只统计不低于该优先级的事项，数值或名称"
+// @Param project query string false "只统计该项目的事项，传项目 ID，或 none 只统计不属于任何项目的事项"
+// @Param view query string false "只统计该视图中的事项" Enums(today,week,overdue,inbox,stale)
+// @Param near query string false "当前位置 lat,lng"
+// @Param radius query number false "搜索半径（米），不传则使用每条待办事项自己的半径"
+// @Success 200 {object} handler.Response{data=storage.TodoStats}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/stats [get]
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), StatsTimeout)
-	defer cancel()
-
-	stats, err := h.db.GetStatsContext(ctx)
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("GetStats timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "统计查询超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ListTodos canceled: %v", err)
-			// 客户端取消请求,不需要响应
-			return
-		}
-		log.Printf("Failed to get stats: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "DATABASE_ERROR", "获取统计信息失败")
-		return
-	}
-
-	response := Response{
-		Success: true,
-		Data:    stats,
-		Message: "获取统计信息成功",
-	}
+	h.serve(w, r, endpoint{name: "GetStats", timeout: StatsTimeout, message: "获取统计信息成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			// 按状态分组本身就是统计的一部分，status 参数不参与过滤
+			filter := storage.TodoFilter{Search: r.URL.Query().Get("search")}
+			if err := parseNearFilter(r, &filter); err != nil {
+				return nil, err
+			}
+			if err := h.parsePriorityFilter(r, &filter); err != nil {
+				return nil, err
+			}
+			if err := parseProjectFilter(r, &filter); err != nil {
+				return nil, err
+			}
+			if err := h.applyView(r, &filter); err != nil {
+				return nil, err
+			}
 
-	h.sendJSON(w, http.StatusOK, response)
+			stats, err := h.todos.GetFilteredStatsContext(ctx, filter)
+			if err != nil {
+				return nil, storeError(err, "获取统计信息失败")
+			}
+			h.fillStatusCounts(stats.ByStatus)
+			return stats, nil
+		})
 }
 
 // BatchRequest 批量操作请求
@@ -482,198 +787,171 @@ type BatchRequest struct {
 	IDs []int `json:"ids"`
 }
 
-// BatchCompleteTodos 批量完成待办事项
-func (h *Handler) BatchCompleteTodos(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 Context
-	ctx, cancel := context.WithTimeout(r.Context(), BatchTimeout)
-	defer cancel()
-
-	defer r.Body.Close()
+// checkBatchSize 检查批量操作的 ID 数量，超出上限时返回 BATCH_TOO_LARGE
+func (h *Handler) checkBatchSize(n int) error {
+	limit := h.cfg.BatchMaxSize
+	if n <= limit {
+		return nil
+	}
+	return apperr.New(apperr.CodeBatchTooLarge, fmt.Sprintf("批量操作最多支持 %d 个 ID，当前: %d", limit, n)).
+		WithDetails(map[string]interface{}{
+			"max_batch_size": limit,
+			"requested":      n,
+		})
+}
 
-	var req BatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", "请求格式错误")
-		return
+// BatchLimitHeader 在批量操作路由（包括 OPTIONS 预检）上返回 X-Batch-Max-Size 响应头
+func (h *Handler) BatchLimitHeader(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Batch-Max-Size", strconv.Itoa(h.cfg.BatchMaxSize))
+		next(w, r)
 	}
+}
 
-	// 验证请求
-	if len(req.IDs) == 0 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", "IDs不能为空")
-		return
-	}
+// BatchCompleteTodos 批量完成待办事项
+func (h *Handler) BatchCompleteTodos(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
 
-	// 执行批量操作
-	if err := h.db.BatchCompleteTodosContext(ctx, req.IDs); err != nil {
-		// 区分超时错误和其他错误
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("BatchComplete timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "批量操作超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("BatchComplete canceled: %v", err)
-			return // 客户端取消，不响应
-		}
-		log.Printf("批量完成失败：%v", err)
-		h.sendError(w, http.StatusInternalServerError, "BATCH_ERROR", err.Error())
-		return
-	}
+	h.serve(w, r, endpoint{name: "BatchComplete", timeout: BatchTimeout},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			ids, err := h.decodeBatchIDs(r)
+			if err != nil {
+				return nil, err
+			}
 
-	h.sendJSON(w, http.StatusOK, Response{
-		Success: true,
-		Message: fmt.Sprintf("成功完成 %d 个待办事项", len(req.IDs)),
-	})
+			pending := h.incompleteTodos(ctx, ids)
+			if err := h.todos.BatchCompleteTodosContext(ctx, ids); err != nil {
+				return nil, operationError(err, apperr.CodeBatchError)
+			}
+			h.afterComplete(ctx, pending, nil)
+			return result{message: fmt.Sprintf("成功完成 %d 个待办事项", len(ids))}, nil
+		})
 }
 
 // BatchDeleteTodos 批量删除待办事项
 func (h *Handler) BatchDeleteTodos(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 context
-	ctx, cancel := context.WithTimeout(r.Context(), BatchTimeout)
-	defer cancel()
-
 	defer r.Body.Close()
 
+	h.serve(w, r, endpoint{name: "BatchDelete", timeout: BatchTimeout},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			ids, err := h.decodeBatchIDs(r)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := h.todos.BatchDeleteTodosContext(ctx, ids); err != nil {
+				return nil, operationError(err, apperr.CodeBatchError)
+			}
+			return result{message: fmt.Sprintf("成功删除 %d 个待办事项", len(ids))}, nil
+		})
+}
+
+// decodeBatchIDs 解析批量操作的请求体并检查 ID 数量
+func (h *Handler) decodeBatchIDs(r *http.Request) ([]int, error) {
 	var req BatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", "请求格式错误")
-		return
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
 	}
-
-	// 验证请求
 	if len(req.IDs) == 0 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", "IDs不能为空")
-		return
+		return nil, apperr.New(apperr.CodeValidationError, "IDs 不能为空")
 	}
-
-	// 执行批量操作
-	if err := h.db.BatchDeleteTodosContext(ctx, req.IDs); err != nil {
-		// 区分超时错误和其他错误
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("BatchDelete timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "批量操作超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("BatchDelete canceled: %v", err)
-			return // 客户端取消，不响应
-		}
-		log.Printf("批量删除失败：%v", err)
-		h.sendError(w, http.StatusInternalServerError, "BATCH_ERROR", err.Error())
-		return
+	// 数据库层也有上限，这里先检查以便返回详情
+	if err := h.checkBatchSize(len(req.IDs)); err != nil {
+		return nil, err
 	}
+	return req.IDs, nil
+}
 
-	h.sendJSON(w, http.StatusOK, Response{
-		Success: true,
-		Message: fmt.Sprintf("成功删除 %d 个待办事项", len(req.IDs)),
-	})
+// operationError 批量、导入、导出失败时把原因返回给客户端（如"待办事项 3 不存在或已完成"），超时和取消原样返回
+func operationError(err error, code apperr.Code) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return apperr.Wrap(err, code, err.Error())
 }
 
 // BatchCompleteTodosPartial 批量完成待办事项（部分成功策略）
+// @Summary 批量完成待办事项
+// @Description 部分成功策略：逐个处理，失败的 ID 列在 errors 中；上限通过 X-Batch-Max-Size 响应头返回
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param request body handler.BatchRequest true "待办事项ID列表"
+// @Success 200 {object} handler.Response{data=storage.BatchResult}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/batch/complete [post]
 func (h *Handler) BatchCompleteTodosPartial(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 Context
-	ctx, cancel := context.WithTimeout(r.Context(), BatchTimeout)
-	defer cancel()
-
 	defer r.Body.Close()
 
-	var req BatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", fmt.Sprintf("JSON 解析失败: %v", err))
-		return
-	}
-
-	// 验证请求
-	if len(req.IDs) == 0 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", "IDs 不能为空")
-		return
-	}
-
-	// 批量大小限制（Handler 层也做校验，双重保护）
-	if len(req.IDs) > 100 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("批量操作最多支持 100 个 ID，当前: %d", len(req.IDs)))
-		return
-	}
-
-	// 执行批量操作（使用部分成功策略的函数）
-	result, err := h.db.BatchCompleteTodosPartialContext(ctx, req.IDs)
-	if err != nil {
-		// 区分超时错误和其他错误
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("BatchCompletePartial timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "批量操作超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("BatchCompletePartial canceled: %v", err)
-			return // 客户端取消，不响应
-		}
-		log.Printf("Failed to batch complete todos: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "BATCH_OPERATION_ERROR", err.Error())
-		return
-	}
+	h.serve(w, r, endpoint{name: "BatchCompletePartial", timeout: BatchTimeout, message: "批量完成操作完成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			ids, err := h.decodeBatchIDs(r)
+			if err != nil {
+				return nil, err
+			}
 
-	response := Response{
-		Success: true,
-		Data:    result,
-		Message: "批量完成操作完成",
-	}
-	h.sendJSON(w, http.StatusOK, response)
+			// 部分成功策略：逐个处理，失败的 ID 记录在结果中
+			pending := h.incompleteTodos(ctx, ids)
+			res, err := h.todos.BatchCompleteTodosPartialContext(ctx, ids)
+			if err != nil {
+				return nil, operationError(err, apperr.CodeBatchOperationError)
+			}
+			failed := make(map[int]bool, len(res.Errors))
+			for _, e := range res.Errors {
+				failed[e.ID] = true
+			}
+			h.afterComplete(ctx, pending, failed)
+			return res, nil
+		})
 }
 
 // BatchDeleteTodosPartial 批量删除待办事项（部分成功策略）
+// @Summary 批量删除待办事项
+// @Description 部分成功策略：逐个处理，失败的 ID 列在 errors 中；上限通过 X-Batch-Max-Size 响应头返回
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param request body handler.BatchRequest true "待办事项ID列表"
+// @Success 200 {object} handler.Response{data=storage.BatchResult}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/batch/delete [post]
 func (h *Handler) BatchDeleteTodosPartial(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 Context
-	ctx, cancel := context.WithTimeout(r.Context(), BatchTimeout)
-	defer cancel()
-
 	defer r.Body.Close()
 
-	var req BatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", fmt.Sprintf("JSON 解析失败: %v", err))
-		return
-	}
-
-	// 验证请求
-	if len(req.IDs) == 0 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", "IDs 不能为空")
-		return
-	}
-
-	// 批量大小限制
-	if len(req.IDs) > 100 {
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", fmt.Sprintf("批量操作最多支持 100 个 ID，当前: %d", len(req.IDs)))
-		return
-	}
-
-	// 执行批量操作
-	result, err := h.db.BatchDeleteTodosPartialContext(ctx, req.IDs)
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("BatchDeletePartial timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "批量操作超时，请稍后重试")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("BatchDeletePartial canceled: %v", err)
-			return
-		}
-		log.Printf("Failed to batch delete todos: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "BATCH_OPERATION_ERROR", err.Error())
-		return
-	}
+	h.serve(w, r, endpoint{name: "BatchDeletePartial", timeout: BatchTimeout, message: "批量删除操作完成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			ids, err := h.decodeBatchIDs(r)
+			if err != nil {
+				return nil, err
+			}
 
-	response := Response{
-		Success: true,
-		Data:    result,
-		Message: "批量删除操作完成",
-	}
-	h.sendJSON(w, http.StatusOK, response)
+			res, err := h.todos.BatchDeleteTodosPartialContext(ctx, ids)
+			if err != nil {
+				return nil, operationError(err, apperr.CodeBatchOperationError)
+			}
+			return res, nil
+		})
 }
 
 // ExportTodos 导出待办事项（带超时控制）
+// @Summary 导出待办事项
+// @Description 按 format 导出为 JSON 数组或 CSV 文件（支持与列表相同的筛选参数）
+// @Description format=taskwarrior 时按 task export 的 JSON 格式导出，可以直接 task import：
+// @Description 描述和评论作为注释，项目按名称，预估耗时和位置作为 UDA（estimate、latitude、longitude、radius）
+// @Tags todos
+// @Produce json,text/csv
+// @Param format query string false "导出格式" Enums(json,csv,taskwarrior) default(json)
+// @Success 200 {array} model.Todo
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/export [get]
 func (h *Handler) ExportTodos(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 Context（导出可能数据量大，超时设长一些）
+	// 导出写出的是文件而不是响应信封，只有错误交给 sendAPIError；数据量可能较大，超时设长一些
 	ctx, cancel := context.WithTimeout(r.Context(), ExportTimeout)
 	defer cancel()
 
@@ -681,33 +959,32 @@ func (h *Handler) ExportTodos(w http.ResponseWriter, r *http.Request) {
 	if format == "" {
 		format = "json"
 	}
-
-	// 获取所有数据（使用 Context 版本）
-	todos, err := h.db.ExportTodosContext(ctx)
-	if err != nil {
-		// 区分超时错误和其他错误
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("ExportTodos timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "导出超时，数据量过大")
-			return
-		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ExportTodos canceled: %v", err)
+	switch format {
+	case "taskwarrior":
+		// 注释来自评论，导出读取的是工作区归档
+		if err := h.requireRepositories(); err != nil {
+			h.sendAPIError(w, "ExportTodos", err)
 			return
 		}
-		log.Printf("导出失败：%v", err)
-		h.sendError(w, http.StatusInternalServerError, "EXPORT_ERROR", "导出失败")
+		h.exportTaskwarrior(ctx, w)
+		return
+	case "csv", "json":
+	default:
+		h.sendError(w, apperr.CodeInvalidFormat, "不支持的格式，请使用 json、csv 或 taskwarrior")
 		return
 	}
 
-	switch format {
-	case "csv":
+	todos, err := h.todos.ExportTodosContext(ctx)
+	if err != nil {
+		h.sendAPIError(w, "ExportTodos", apperr.Wrap(err, apperr.CodeExportError, "导出失败"))
+		return
+	}
+
+	if format == "csv" {
 		h.exportCSV(w, todos)
-	case "json":
-		h.exportJSON(w, todos)
-	default:
-		h.sendError(w, http.StatusBadRequest, "INVALID_FORMAT", "不支持的格式，请使用 json 或 csv")
+		return
 	}
+	h.exportJSON(w, todos)
 }
 
 // exportCSV 导出为 CSV 格式
@@ -723,7 +1000,7 @@ func (h *Handler) exportCSV(w http.ResponseWriter, todos []model.Todo) {
 	defer writer.Flush()
 
 	// 写入表头
-	headers := []string{"ID", "标题", "描述", "状态", "截止日期", "创建时间", "完成时间"}
+	headers := []string{"ID", "标题", "描述", "状态", "截止日期", "创建时间", "完成时间", "加密备注", "密钥提示"}
 	if err := writer.Write(headers); err != nil {
 		log.Printf("写入 CSV 表头失败: %v", err)
 		return
@@ -739,6 +1016,12 @@ func (h *Handler) exportCSV(w http.ResponseWriter, todos []model.Todo) {
 			formatTimePtr(todo.DueDate),
 			todo.CreatedAt.Format("2006-01-02 15:04:05"),
 			formatTimePtr(todo.CompletedAt),
+			"",
+			"",
+		}
+		// 加密备注按原样导出密文，导入到其他客户端后仍需原来的密钥解密
+		if todo.SecretNote != nil {
+			row[7], row[8] = todo.SecretNote.Ciphertext, todo.SecretNote.KeyHint
 		}
 		if err := writer.Write(row); err != nil {
 			log.Printf("写入 CSV 行失败：%v", err)
@@ -757,7 +1040,7 @@ func (h *Handler) exportJSON(w http.ResponseWriter, todos []model.Todo) {
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 
-	if err := encoder.Encode(todos); err != nil {
+	if err := encoder.Encode(h.presenter().Apply(todos)); err != nil {
 		log.Printf("写入 JSON 失败: %v", err)
 	}
 }
@@ -781,71 +1064,272 @@ type ImportTodoItem struct {
 	Description string  `json:"description"`
 	Status      string  `json:"status"`
 	DueDate     *string `json:"due_date"`
+
+	Priority *model.Priority `json:"priority,omitempty" swaggertype:"string" example:"high"` // 数值或名称，无法识别时使用默认优先级
+
+	SecretNote *model.SecretNote `json:"secret_note,omitempty"` // 加密备注，原样导入
 }
 
 // ImportTodos 导入待办事项（带超时控制）
+// @Summary 导入待办事项
+// @Description JSON 请求体，或 multipart 上传 .json / .csv 文件（字段 file）；空标题的记录会被跳过
+// @Description async=true 时校验通过后作为后台任务写入，返回 202 和 Location（GET /api/v1/jobs/{id}）
+// @Description format=taskwarrior 时请求体（或上传的文件）是 task export 的输出：已删除的任务跳过，注释合并为描述，
+// @Description 标签和其他 UDA 按行追加到描述末尾，项目不存在时按名称创建，uuid 转换为 public_id；
+// @Description 再次导入时 public_id 已存在的任务更新原有的待办事项，不会重复创建
+// @Tags todos
+// @Accept json,mpfd
+// @Produce json
+// @Param request body handler.ImportRequest false "JSON 请求体方式"
+// @Param file formData file false "multipart 上传方式"
+// @Param X-Timezone header string false "不带时区的截止日期按该时区解释"
+// @Param async query bool false "作为后台任务执行"
+// @Param format query string false "请求体格式，默认按 Content-Type 和文件扩展名判断" Enums(taskwarrior)
+// @Success 200 {object} handler.Response
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 429 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/import [post]
 func (h *Handler) ImportTodos(w http.ResponseWriter, r *http.Request) {
-	// 创建带超时的 Context（导入可能数据量大，超时设长一些）
-	ctx, cancel := context.WithTimeout(r.Context(), ImportTimeout)
-	defer cancel()
-
 	// 限制请求体大小
 	r.Body = http.MaxBytesReader(w, r.Body, 10<<20) // 10MB
 	defer r.Body.Close()
 
+	// 导入可能数据量大，超时设长一些
+	h.serve(w, r, endpoint{name: "ImportTodos", timeout: ImportTimeout},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todos, err := h.parseImportRequest(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+			return h.importTodos(ctx, w, r, todos)
+		})
+}
+
+// parseImportRequest 按 format 参数和 Content-Type 解析导入的请求体
+func (h *Handler) parseImportRequest(ctx context.Context, r *http.Request) ([]model.Todo, error) {
 	contentType := r.Header.Get("Content-Type")
 
 	var todos []model.Todo
 	var err error
-
-	if strings.HasPrefix(contentType, "multipart/form-data") {
+	if r.URL.Query().Get("format") == "taskwarrior" {
+		// taskwarrior 的导出，可以是请求体或上传的文件
+		var body io.Reader = r.Body
+		if strings.HasPrefix(contentType, "multipart/form-data") {
+			if err = r.ParseMultipartForm(100 << 20); err == nil {
+				file, _, ferr := r.FormFile("file")
+				if ferr != nil {
+					return nil, apperr.New(apperr.CodeParseError, fmt.Sprintf("获取文件失败：%v", ferr))
+				}
+				defer file.Close()
+				body = file
+			}
+		}
+		if err == nil {
+			todos, err = h.parseTaskwarrior(ctx, body)
+		}
+	} else if strings.HasPrefix(contentType, "multipart/form-data") {
 		// 文件上传方式
 		todos, err = h.parseImportFile(r)
 	} else {
-		// JSON 请求体方式
-		todos, err = h.parseImportJSON(r)
+		// JSON 请求体方式，不带时区的截止日期按用户时区解释
+		loc, lerr := h.userLocation(ctx, r)
+		if lerr != nil {
+			return nil, lerr
+		}
+		todos, err = h.parseImportJSON(r, loc)
 	}
 
 	if err != nil {
-		h.sendError(w, http.StatusBadRequest, "PARSE_ERROR", err.Error())
-		return
+		return nil, parseError(err)
 	}
+	return todos, nil
+}
 
+// parseError 解析导入内容失败：已经是响应错误（如超时、项目创建失败）时原样返回，否则返回 PARSE_ERROR
+func parseError(err error) error {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return apperr.Wrap(err, apperr.CodeParseError, err.Error())
+}
+
+// importTodos 校验解析出的待办事项并导入，返回响应数据（async=true 时提交后台任务，返回 202）
+// 请求体导入和分片上传的导入文件（见 uploads.go）共用，w 用于设置 Location 等响应头
+func (h *Handler) importTodos(ctx context.Context, w http.ResponseWriter, r *http.Request, todos []model.Todo) (interface{}, error) {
 	if len(todos) == 0 {
-		h.sendError(w, http.StatusBadRequest, "EMPTY_DATA", "没有可导入的数据")
-		return
+		return nil, apperr.New(apperr.CodeEmptyData, "没有可导入的数据")
+	}
+
+	// 导入的状态也必须是工作流中定义的状态（为空时由数据库层默认为 pending）
+	for i := range todos {
+		todo := &todos[i]
+		// 空标题的记录由数据库层跳过，这里只规范化并检查长度
+		todo.Title = model.NormalizeTitle(todo.Title)
+		todo.Description = model.NormalizeDescription(todo.Description)
+		var violations []model.Violation
+		if todo.Title != "" {
+			violations = append(violations, h.cfg.TextLimits.CheckTitle(todo.Title)...)
+		}
+		violations = append(violations, h.cfg.TextLimits.CheckDescription(todo.Description)...)
+		violations = append(violations, secretNoteViolations(todo.SecretNote)...)
+		if len(violations) > 0 {
+			return nil, apperr.New(apperr.CodeConstraintViolation, fmt.Sprintf("第 %d 条：%s", i+1, violations[0].Message)).
+				WithDetails(map[string]interface{}{"index": i + 1, "violations": violations})
+		}
+
+		if todo.Status != "" && !h.workflow.Has(todo.Status) {
+			return nil, apperr.New(apperr.CodeInvalidStatus, fmt.Sprintf("第 %d 条：未知的状态 %q", i+1, todo.Status)).
+				WithDetails(map[string]interface{}{"status": todo.Status, "statuses": h.workflow.Names()})
+		}
+		if todo.ProjectID != nil && *todo.ProjectID == 0 {
+			todo.ProjectID = nil
+		}
+		if todo.ProjectID != nil {
+			if err := h.checkProject(ctx, *todo.ProjectID); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// 执行导入（使用 Context 版本）
-	imported, err := h.db.ImportTodosContext(ctx, todos)
+	// 先完成所有检查（自动化规则、扩展钩子、配额），都通过之后才修改数据：
+	// 被拒绝的请求不会留下一半的更新，async=true 时更新和新建都在后台任务中执行
+	total := len(todos)
+	fresh, updates, err := h.splitImported(ctx, todos)
 	if err != nil {
-		// 区分超时错误和其他错误
-		if errors.Is(err, context.DeadlineExceeded) {
-			log.Printf("ImportTodos timeout: %v", err)
-			h.sendError(w, http.StatusRequestTimeout, "TIMEOUT", "导入超时，数据量过大")
-			return
+		return nil, err
+	}
+
+	// 规则只读取一次，逐条执行；已有事项的更新不算新建，不执行创建规则
+	rules := h.loadAutomation(ctx, model.EventTodoCreated)
+	for i := range fresh {
+		h.applyAutomation(rules, &fresh[i])
+		if err := extension.BeforeCreate(ctx, &fresh[i]); err != nil {
+			return nil, err
 		}
-		if errors.Is(err, context.Canceled) {
-			log.Printf("ImportTodos canceled: %v", err)
-			return
+	}
+
+	if len(fresh) > 0 {
+		if err := h.checkTodoQuota(ctx, len(fresh)); err != nil {
+			return nil, h.quotaAPIError(w, err)
 		}
-		log.Printf("导入失败：%v", err)
-		h.sendError(w, http.StatusInternalServerError, "IMPORT_ERROR", err.Error())
-		return
 	}
 
-	h.sendJSON(w, http.StatusOK, Response{
-		Success: true,
-		Data: map[string]interface{}{
+	// async=true 时校验通过后提交后台任务，立即返回
+	if wantsAsync(r) {
+		status, err := h.submitJob(ctx, w, jobKindImportTodos, importTodosJob{Todos: fresh, Updates: updates})
+		if err != nil {
+			return nil, err
+		}
+		return result{data: status, status: http.StatusAccepted, message: jobSubmittedMessage}, nil
+	}
+
+	imported, updated, err := h.runImport(ctx, fresh, updates)
+	if err != nil {
+		return nil, err
+	}
+	if len(fresh) == 0 {
+		return result{
+			data:    map[string]interface{}{"imported": 0, "updated": updated, "total": total},
+			message: fmt.Sprintf("没有新的待办事项，更新了 %d 条", updated),
+		}, nil
+	}
+	message := fmt.Sprintf("成功导入 %d 条待办事项", imported)
+	if updated > 0 {
+		message += fmt.Sprintf("，更新 %d 条", updated)
+	}
+	return result{
+		data: map[string]interface{}{
 			"imported": imported,
-			"total":    len(todos),
+			"updated":  updated,
+			"total":    total,
 		},
-		Message: fmt.Sprintf("成功导入 %d 条待办事项", imported),
-	})
+		message: message,
+	}, nil
+}
+
+// runImport 先按 public_id 更新已有的事项，再新建其余的事项，返回新建和更新的数量（同步导入和后台任务共用）
+func (h *Handler) runImport(ctx context.Context, fresh, updates []model.Todo) (int, int, error) {
+	updated, err := h.updateImported(ctx, updates)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(fresh) == 0 {
+		return 0, updated, nil
+	}
+	imported, err := h.todos.ImportTodosContext(ctx, fresh)
+	if err != nil {
+		return 0, updated, operationError(err, apperr.CodeImportError)
+	}
+	return imported, updated, nil
+}
+
+// splitImported 导入的待办事项带有当前工作区中已存在的 public_id 时（例如再次导入同一份 taskwarrior 导出，
+// uuid 转换出的 public_id 不变），应当更新已有的事项，而不是再创建一条；
+// 只查询、不修改，返回需要新建的事项和需要更新的事项
+func (h *Handler) splitImported(ctx context.Context, todos []model.Todo) (fresh, updates []model.Todo, err error) {
+	fresh = make([]model.Todo, 0, len(todos))
+	for i := range todos {
+		todo := &todos[i]
+		if todo.PublicID == "" || todo.Title == "" {
+			fresh = append(fresh, *todo)
+			continue
+		}
+		_, err := h.todos.GetTodoIDByPublicIDContext(ctx, todo.PublicID)
+		if errors.Is(err, storage.ErrNotFound) {
+			fresh = append(fresh, *todo)
+			continue
+		}
+		if err != nil {
+			return nil, nil, storeError(err, "查询待办事项失败")
+		}
+		updates = append(updates, *todo)
+	}
+	return fresh, updates, nil
+}
+
+// updateImported 用导入的内容更新 public_id 相同的已有事项，返回更新的数量
+// 提交后台任务之后事项可能已被删除，这时跳过，不再重新创建
+func (h *Handler) updateImported(ctx context.Context, updates []model.Todo) (int, error) {
+	updated := 0
+	for i := range updates {
+		todo := &updates[i]
+		id, err := h.todos.GetTodoIDByPublicIDContext(ctx, todo.PublicID)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return updated, storeError(err, "查询待办事项失败")
+		}
+
+		existing, err := h.todos.GetTodoByIDContext(ctx, id)
+		if err != nil {
+			return updated, storeError(err, "查询待办事项失败")
+		}
+		// 导入的内容里没有的字段保持原样
+		todo.ID, todo.Version, todo.CreatedAt = existing.ID, existing.Version, existing.CreatedAt
+		todo.NextOccurrenceID = existing.NextOccurrenceID
+		if todo.SecretNote == nil {
+			todo.SecretNote = existing.SecretNote
+		}
+		if todo.Status == "" {
+			todo.Status = existing.Status
+		}
+		if err := h.todos.UpdateTodoContext(ctx, todo); err != nil {
+			return updated, storeError(err, fmt.Sprintf("更新待办事项 %s 失败", todo.PublicID))
+		}
+		updated++
+	}
+	return updated, nil
 }
 
 // parseImportJSON 解析 JSON 请求体
-func (h *Handler) parseImportJSON(r *http.Request) ([]model.Todo, error) {
+func (h *Handler) parseImportJSON(r *http.Request, loc *time.Location) ([]model.Todo, error) {
 	var req ImportRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return nil, fmt.Errorf("JSON 解析失败：%w", err)
@@ -856,13 +1340,20 @@ func (h *Handler) parseImportJSON(r *http.Request) ([]model.Todo, error) {
 			Title:       strings.TrimSpace(item.Title),
 			Description: strings.TrimSpace(item.Description),
 			Status:      item.Status,
+			Priority:    model.DefaultPriority,
+		}
+		if item.Priority != nil {
+			if p, err := item.Priority.Resolve(h.cfg.PriorityScale); err == nil {
+				todo.Priority = p
+			}
+		}
+		if item.SecretNote != nil && item.SecretNote.Ciphertext != "" {
+			todo.SecretNote = item.SecretNote
 		}
 
-		// 解析截止日期
+		// 解析截止日期，无法识别的日期忽略
 		if item.DueDate != nil && *item.DueDate != "" {
-			if t, err := time.Parse("2006-01-02", *item.DueDate); err == nil {
-				todo.DueDate = &t
-			} else if t, err := time.Parse(time.RFC3339, *item.DueDate); err == nil {
+			if t, err := model.ParseDueDate(*item.DueDate, loc); err == nil {
 				todo.DueDate = &t
 			}
 		}
@@ -885,8 +1376,12 @@ func (h *Handler) parseImportFile(r *http.Request) ([]model.Todo, error) {
 	}
 	defer file.Close()
 
-	filename := strings.ToLower(header.Filename)
+	return h.parseImportReader(header.Filename, file)
+}
 
+// parseImportReader 按文件扩展名解析导入文件（.json / .csv）
+func (h *Handler) parseImportReader(filename string, file io.Reader) ([]model.Todo, error) {
+	filename = strings.ToLower(filename)
 	if strings.HasSuffix(filename, ".json") {
 		return h.parseJSONFile(file)
 	} else if strings.HasSuffix(filename, ".csv") {
@@ -905,6 +1400,16 @@ func (h *Handler) parseJSONFile(file io.Reader) ([]model.Todo, error) {
 	return todos, nil
 }
 
+// csvField 按列名（中文表头或英文表头）取出一行中的值，去掉首尾空白，没有该列时返回空字符串
+func csvField(record []string, colIndex map[string]int, names ...string) string {
+	for _, name := range names {
+		if idx, ok := colIndex[name]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+	}
+	return ""
+}
+
 // parseCSVFile 解析 CSV 文件
 func (h *Handler) parseCSVFile(file io.Reader) ([]model.Todo, error) {
 	reader := csv.NewReader(file)
@@ -915,10 +1420,10 @@ func (h *Handler) parseCSVFile(file io.Reader) ([]model.Todo, error) {
 		return nil, fmt.Errorf("读取 CSV 表头失败：%w", err)
 	}
 
-	// 建立列名到索引的映射
+	// 建立列名到索引的映射；导出的文件以 UTF-8 BOM 开头，第一个列名要去掉它
 	colIndex := make(map[string]int)
 	for i, h := range headers {
-		colIndex[strings.ToLower(strings.TrimSpace(h))] = i
+		colIndex[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
 	}
 
 	// 检查必需列
@@ -944,7 +1449,8 @@ func (h *Handler) parseCSVFile(file io.Reader) ([]model.Todo, error) {
 		}
 
 		todo := model.Todo{
-			Title: strings.TrimSpace(record[titleIdx]),
+			Title:    strings.TrimSpace(record[titleIdx]),
+			Priority: model.DefaultPriority,
 		}
 
 		// 可选列
@@ -960,6 +1466,14 @@ func (h *Handler) parseCSVFile(file io.Reader) ([]model.Todo, error) {
 			todo.Status = strings.TrimSpace(record[idx])
 		}
 
+		// 导出的加密备注列原样读回，没有密文时不带备注
+		if ciphertext := csvField(record, colIndex, "加密备注", "secret_note"); ciphertext != "" {
+			todo.SecretNote = &model.SecretNote{
+				Ciphertext: ciphertext,
+				KeyHint:    csvField(record, colIndex, "密钥提示", "key_hint"),
+			}
+		}
+
 		todos = append(todos, todo)
 	}
 
@@ -0,0 +1,1427 @@
+package handler_test
+
+// 接口测试：通过 api.SetupRoutes 注册的路由发送请求，检查响应信封和状态码
+// 待办事项分别保存在 SQLite（默认）和内存（DB_DRIVER=memory）中，两种后端的行为必须一致
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/api"
+	"todo-list/clock"
+	"todo-list/config"
+	"todo-list/database"
+	"todo-list/handler"
+	"todo-list/hooks"
+	"todo-list/model"
+	"todo-list/storage"
+	"todo-list/storage/memory"
+	"todo-list/webhook"
+	"todo-list/workflow"
+)
+
+// backends 待办事项的存储后端（DB_DRIVER）
+var backends = []string{"sqlite", "memory"}
+
+// testServer 测试用的服务端，db 是临时目录中的 SQLite，memory 时为 nil
+type testServer struct {
+	*httptest.Server
+	db    *database.DB
+	repos storage.Repositories // 项目、API key 等数据所在的存储
+	h     *handler.Handler
+}
+
+// newTestServer 按 driver 创建服务端：sqlite 时所有数据保存在临时目录的 SQLite 中，memory 时只使用内存存储；
+// env 为额外的环境变量
+func newTestServer(t *testing.T, driver string, env ...string) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DB_DRIVER", driver)
+	t.Setenv("DB_PATH", filepath.Join(dir, "todos.db"))
+	t.Setenv("SHARE_SECRET", "test-secret")
+	t.Setenv("UPLOAD_DIR", filepath.Join(dir, "uploads"))
+	t.Setenv("ATTACHMENT_DIR", filepath.Join(dir, "attachments"))
+	for i := 0; i+1 < len(env); i += 2 {
+		t.Setenv(env[i], env[i+1])
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{}
+	if driver == "memory" {
+		mem := memory.New()
+		s.repos, s.h = mem, handler.NewHandler(mem, mem, nil, cfg)
+	} else {
+		db, err := database.New(cfg.DBPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		s.db, s.repos, s.h = db, db, handler.NewHandler(db, db, db, cfg)
+	}
+	s.Server = httptest.NewServer(api.SetupRoutes(s.h))
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// envelope 统一响应格式，data 留给调用方按需解析
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// code 错误码，成功时为空
+func (e *envelope) code() string {
+	if e.Error == nil {
+		return ""
+	}
+	return e.Error.Code
+}
+
+// decode 把 data 解析到 v
+func (e *envelope) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		t.Fatalf("decode data %s: %v", e.Data, err)
+	}
+}
+
+// request 一次请求的可选参数
+type request struct {
+	body        interface{} // 序列化为 JSON；string 原样发送
+	contentType string
+	header      http.Header
+}
+
+// do 发送请求并解析响应信封
+func (s *testServer) do(t *testing.T, method, path string, req request) (int, *envelope) {
+	t.Helper()
+	var body io.Reader
+	switch b := req.body.(type) {
+	case nil:
+	case string:
+		body = bytes.NewBufferString(b)
+	default:
+		buf, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(buf)
+	}
+	r, err := http.NewRequest(method, s.URL+path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range req.header {
+		r.Header[k] = v
+	}
+	if req.contentType != "" {
+		r.Header.Set("Content-Type", req.contentType)
+	} else if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		t.Fatalf("%s %s: response is not an envelope: %s", method, path, raw)
+	}
+	return resp.StatusCode, &env
+}
+
+// todoJSON 响应中的待办事项
+type todoJSON struct {
+	ID       int    `json:"id"`
+	PublicID string `json:"public_id"`
+	Version  int    `json:"version"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Priority int    `json:"priority"`
+	DueDate  string `json:"due_date"`
+}
+
+// listJSON 列表响应
+type listJSON struct {
+	Todos []todoJSON `json:"todos"`
+	Total *int       `json:"total"`
+}
+
+// create 创建一条待办事项，失败时终止测试
+func (s *testServer) create(t *testing.T, body map[string]interface{}) todoJSON {
+	t.Helper()
+	status, env := s.do(t, http.MethodPost, "/api/v1/todos", request{body: body})
+	if status != http.StatusCreated {
+		t.Fatalf("create %v: status %d, error %s", body, status, env.code())
+	}
+	var todo todoJSON
+	env.decode(t, &todo)
+	return todo
+}
+
+// titles 列表中的标题
+func titles(list listJSON) []string {
+	s := make([]string, len(list.Todos))
+	for i, todo := range list.Todos {
+		s[i] = todo.Title
+	}
+	return s
+}
+
+func TestTodoCRUD(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+
+			todo := s.create(t, map[string]interface{}{"title": "  write   report ", "priority": "high"})
+			if todo.ID == 0 || todo.PublicID == "" || todo.Version != 1 || todo.Title != "write report" || todo.Priority != 2 {
+				t.Fatalf("created = %+v, want normalized title, high priority, version 1", todo)
+			}
+
+			// {id} 既可以是整数 ID，也可以是 public_id
+			for _, id := range []string{fmt.Sprint(todo.ID), todo.PublicID} {
+				status, env := s.do(t, http.MethodGet, "/api/v1/todos/"+id, request{})
+				var got todoJSON
+				env.decode(t, &got)
+				if status != http.StatusOK || got.ID != todo.ID {
+					t.Errorf("GET /todos/%s = %d, %+v", id, status, got)
+				}
+			}
+
+			path := fmt.Sprintf("/api/v1/todos/%d", todo.ID)
+			status, env := s.do(t, http.MethodPut, path, request{body: map[string]interface{}{"version": 1, "title": "final report", "status": "completed"}})
+			var updated todoJSON
+			env.decode(t, &updated)
+			if status != http.StatusOK || updated.Version != 2 || updated.Title != "final report" || updated.Status != "completed" {
+				t.Fatalf("PUT = %d %s, %+v", status, env.code(), updated)
+			}
+
+			// 旧版本号
+			status, env = s.do(t, http.MethodPut, path, request{body: map[string]interface{}{"version": 1, "title": "stale"}})
+			if status != http.StatusConflict || env.code() != "VERSION_CONFLICT" {
+				t.Errorf("PUT with stale version = %d %s, want 409 VERSION_CONFLICT", status, env.code())
+			}
+
+			status, env = s.do(t, http.MethodPatch, path, request{body: map[string]interface{}{"version": 2, "title": "patched"}, contentType: "application/merge-patch+json"})
+			env.decode(t, &updated)
+			if status != http.StatusOK || updated.Title != "patched" || updated.Version != 3 {
+				t.Errorf("PATCH = %d %s, %+v", status, env.code(), updated)
+			}
+
+			if status, env := s.do(t, http.MethodDelete, path, request{}); status != http.StatusOK {
+				t.Fatalf("DELETE = %d %s", status, env.code())
+			}
+			status, env = s.do(t, http.MethodGet, path, request{})
+			if status != http.StatusNotFound || env.code() != "NOT_FOUND" {
+				t.Errorf("GET after delete = %d %s, want 404 NOT_FOUND", status, env.code())
+			}
+		})
+	}
+}
+
+// is_overdue、due_in_seconds 和 priority_label 按处理器的时钟和优先级映射计算，列表中的待办事项同样填入
+func TestComputedFields(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver, "PRIORITY_LABELS", "low=0,normal=1,high=5")
+			fake := clock.NewFake(time.Now().UTC().Truncate(time.Second))
+			s.h.SetClock(fake)
+
+			due := fake.Now().Add(time.Hour).Format(time.RFC3339)
+			todo := s.create(t, map[string]interface{}{"title": "pay rent", "due_date": due})
+
+			type computed struct {
+				IsOverdue     bool   `json:"is_overdue"`
+				DueInSeconds  *int64 `json:"due_in_seconds"`
+				PriorityLabel string `json:"priority_label"`
+			}
+			check := func(wantOverdue bool, wantSeconds int64) {
+				t.Helper()
+				var got computed
+				_, env := s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", todo.ID), request{})
+				env.decode(t, &got)
+				if got.IsOverdue != wantOverdue || got.DueInSeconds == nil || *got.DueInSeconds != wantSeconds || got.PriorityLabel != "normal" {
+					t.Errorf("GET = %+v, want overdue %v, %d seconds, label normal", got, wantOverdue, wantSeconds)
+				}
+
+				var list struct {
+					Todos []computed `json:"todos"`
+				}
+				_, env = s.do(t, http.MethodGet, "/api/v1/todos", request{})
+				env.decode(t, &list)
+				if len(list.Todos) != 1 || list.Todos[0].IsOverdue != wantOverdue || list.Todos[0].PriorityLabel != "normal" {
+					t.Errorf("list = %+v, want overdue %v, label normal", list.Todos, wantOverdue)
+				}
+			}
+
+			check(false, 3600)
+			fake.Advance(2 * time.Hour)
+			check(true, -3600)
+		})
+	}
+}
+
+func TestCreateTodoValidation(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+
+			status, env := s.do(t, http.MethodPost, "/api/v1/todos", request{body: map[string]interface{}{"title": "   "}})
+			if status != http.StatusUnprocessableEntity || env.code() != "CONSTRAINT_VIOLATION" {
+				t.Errorf("empty title = %d %s, want 422 CONSTRAINT_VIOLATION", status, env.code())
+			}
+			status, env = s.do(t, http.MethodPost, "/api/v1/todos", request{body: "{"})
+			if status != http.StatusBadRequest || env.code() != "INVALID_JSON" {
+				t.Errorf("malformed body = %d %s, want 400 INVALID_JSON", status, env.code())
+			}
+		})
+	}
+}
+
+func TestListTodos(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			for _, title := range []string{"buy milk", "call mom", "buy bread"} {
+				s.create(t, map[string]interface{}{"title": title})
+			}
+			done := s.create(t, map[string]interface{}{"title": "buy stamps"})
+			s.do(t, http.MethodPut, fmt.Sprintf("/api/v1/todos/%d", done.ID), request{body: map[string]interface{}{"version": 1, "status": "completed"}})
+
+			tests := []struct {
+				query string
+				want  []string
+				total int
+			}{
+				{"?sort=created_at&order=asc", []string{"buy milk", "call mom", "buy bread", "buy stamps"}, 4},
+				{"?search=buy&status=pending&sort=created_at&order=asc", []string{"buy milk", "buy bread"}, 2},
+				{"?status=completed", []string{"buy stamps"}, 1},
+				{"?sort=created_at&order=asc&limit=2&offset=1", []string{"call mom", "buy bread"}, 4},
+			}
+			for _, tt := range tests {
+				status, env := s.do(t, http.MethodGet, "/api/v1/todos"+tt.query, request{})
+				var list listJSON
+				env.decode(t, &list)
+				if status != http.StatusOK || fmt.Sprint(titles(list)) != fmt.Sprint(tt.want) || list.Total == nil || *list.Total != tt.total {
+					t.Errorf("GET /todos%s = %d, %q (total %v); want %q of %d", tt.query, status, titles(list), list.Total, tt.want, tt.total)
+				}
+			}
+
+			status, env := s.do(t, http.MethodGet, "/api/v1/todos?include_total=false", request{})
+			var list listJSON
+			env.decode(t, &list)
+			if status != http.StatusOK || list.Total != nil || len(list.Todos) != 4 {
+				t.Errorf("include_total=false = %d, %d todos, total %v; want 4 todos without total", status, len(list.Todos), list.Total)
+			}
+		})
+	}
+}
+
+func TestStatsAndViews(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			s.create(t, map[string]interface{}{"title": "unsorted"})
+			s.create(t, map[string]interface{}{"title": "overdue", "due_date": "2000-01-01T00:00:00Z"})
+			s.create(t, map[string]interface{}{"title": "later", "due_date": "2999-01-01T00:00:00Z"})
+
+			status, env := s.do(t, http.MethodGet, "/api/v1/todos/stats", request{})
+			var stats storage.TodoStats
+			env.decode(t, &stats)
+			if status != http.StatusOK || stats.Total != 3 || stats.Pending != 3 || stats.Overdue != 1 || stats.Inbox != 1 {
+				t.Errorf("stats = %d, %+v; want total 3, pending 3, overdue 1, inbox 1", status, stats)
+			}
+
+			// 收件箱视图与统计中的 inbox 一致
+			status, env = s.do(t, http.MethodGet, "/api/v1/todos/views/inbox", request{})
+			var inbox listJSON
+			env.decode(t, &inbox)
+			if status != http.StatusOK || fmt.Sprint(titles(inbox)) != "[unsorted]" {
+				t.Errorf("inbox = %d, %q; want [unsorted]", status, titles(inbox))
+			}
+
+			status, env = s.do(t, http.MethodGet, "/api/v1/todos?view=overdue", request{})
+			var overdue listJSON
+			env.decode(t, &overdue)
+			if status != http.StatusOK || fmt.Sprint(titles(overdue)) != "[overdue]" {
+				t.Errorf("view=overdue = %d %s, %q; want [overdue]", status, env.code(), titles(overdue))
+			}
+		})
+	}
+}
+
+func TestBatchOperations(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			a := s.create(t, map[string]interface{}{"title": "a"})
+			b := s.create(t, map[string]interface{}{"title": "b"})
+
+			status, env := s.do(t, http.MethodPost, "/api/v1/todos/batch/complete", request{body: map[string]interface{}{"ids": []int{a.ID, 999}}})
+			var result storage.BatchResult
+			env.decode(t, &result)
+			if status != http.StatusOK || result.SuccessCount != 1 || result.FailedCount != 1 || result.Errors[0].ID != 999 {
+				t.Errorf("batch complete = %d %s, %+v; want 1 succeeded, 999 failed", status, env.code(), result)
+			}
+
+			status, env = s.do(t, http.MethodPost, "/api/v1/todos/batch/delete", request{body: map[string]interface{}{"ids": []int{a.ID, b.ID}}})
+			env.decode(t, &result)
+			if status != http.StatusOK || result.SuccessCount != 2 {
+				t.Errorf("batch delete = %d %s, %+v; want 2 deleted", status, env.code(), result)
+			}
+
+			status, env = s.do(t, http.MethodPost, "/api/v1/todos/batch/delete", request{body: map[string]interface{}{"ids": []int{}}})
+			if status != http.StatusBadRequest {
+				t.Errorf("empty batch = %d %s, want 400", status, env.code())
+			}
+		})
+	}
+}
+
+func TestImportTodos(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			body := map[string]interface{}{"todos": []map[string]interface{}{
+				{"title": "imported", "status": "completed"},
+				{"title": ""},
+			}}
+			status, env := s.do(t, http.MethodPost, "/api/v1/todos/import", request{body: body})
+			if status != http.StatusOK {
+				t.Fatalf("import = %d %s", status, env.code())
+			}
+
+			status, env = s.do(t, http.MethodGet, "/api/v1/todos?status=completed", request{})
+			var list listJSON
+			env.decode(t, &list)
+			if status != http.StatusOK || fmt.Sprint(titles(list)) != "[imported]" {
+				t.Errorf("after import = %d, %q; want [imported]", status, titles(list))
+			}
+		})
+	}
+}
+
+// 再次导入时按 public_id 更新已有事项；请求因配额被拒绝时已有事项也不能被修改
+func TestImportUpdatesAfterChecks(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver, "QUOTA_MAX_TODOS", "1")
+			const first = `{"uuid":"0b5f3c6e-1d2a-4f8b-9c3d-2e4f6a8b0c1d","description":"original","status":"pending"}`
+			const second = `{"uuid":"7a1e9d2c-3b4f-4a6e-8d0c-1f2e3d4c5b6a","description":"new","status":"pending"}`
+			send := func(tasks ...string) (int, *envelope) {
+				t.Helper()
+				return s.do(t, http.MethodPost, "/api/v1/todos/import?format=taskwarrior", request{body: "[" + strings.Join(tasks, ",") + "]"})
+			}
+			list := func() []string {
+				t.Helper()
+				_, env := s.do(t, http.MethodGet, "/api/v1/todos", request{})
+				var l listJSON
+				env.decode(t, &l)
+				return titles(l)
+			}
+
+			if status, env := send(first); status != http.StatusOK {
+				t.Fatalf("first import = %d %s", status, env.code())
+			}
+			edited := strings.Replace(first, "original", "edited", 1)
+			if status, env := send(edited, second); status != http.StatusForbidden || env.code() != "QUOTA_EXCEEDED" {
+				t.Fatalf("import over quota = %d %s, want 403 QUOTA_EXCEEDED", status, env.code())
+			}
+			if got := list(); fmt.Sprint(got) != "[original]" {
+				t.Errorf("after rejected import = %q, want [original] unchanged", got)
+			}
+
+			// 只更新不新建时不占配额
+			status, env := send(edited)
+			var res struct {
+				Imported int `json:"imported"`
+				Updated  int `json:"updated"`
+			}
+			env.decode(t, &res)
+			if status != http.StatusOK || res.Imported != 0 || res.Updated != 1 {
+				t.Errorf("re-import = %d %s %+v, want 1 updated", status, env.code(), res)
+			}
+			if got := list(); fmt.Sprint(got) != "[edited]" {
+				t.Errorf("after re-import = %q, want [edited]", got)
+			}
+		})
+	}
+}
+
+// CSV 导出的加密备注和密钥提示在导入时原样读回
+func TestCSVRoundTripKeepsSecretNote(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			s.create(t, map[string]interface{}{
+				"title":       "with note",
+				"secret_note": map[string]string{"ciphertext": "c2VjcmV0", "key_hint": "laptop"},
+			})
+			s.create(t, map[string]interface{}{"title": "plain"})
+
+			resp, err := http.Get(s.URL + "/api/v1/todos/export?format=csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			exported, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Fatalf("export = %d, %v", resp.StatusCode, err)
+			}
+
+			// 导入到另一个工作区，和原来的事项分开
+			if status, env := s.do(t, http.MethodPost, "/api/v1/workspaces", request{body: map[string]interface{}{"slug": "copy", "name": "Copy"}}); status != http.StatusCreated {
+				t.Fatalf("create workspace = %d %s", status, env.code())
+			}
+			copyWS := http.Header{"X-Workspace": {"copy"}}
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, err := mw.CreateFormFile("file", "todos.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write(exported)
+			mw.Close()
+			if status, env := s.do(t, http.MethodPost, "/api/v1/todos/import", request{body: body.String(), contentType: mw.FormDataContentType(), header: copyWS}); status != http.StatusOK {
+				t.Fatalf("import = %d %s %s", status, env.code(), env.Message)
+			}
+
+			status, env := s.do(t, http.MethodGet, "/api/v1/todos", request{header: copyWS})
+			var list struct {
+				Todos []struct {
+					Title      string            `json:"title"`
+					SecretNote *model.SecretNote `json:"secret_note"`
+				} `json:"todos"`
+			}
+			env.decode(t, &list)
+			if status != http.StatusOK || len(list.Todos) != 2 {
+				t.Fatalf("imported todos = %d %+v, want 2", status, list.Todos)
+			}
+			for _, todo := range list.Todos {
+				switch todo.Title {
+				case "with note":
+					if todo.SecretNote == nil || *todo.SecretNote != (model.SecretNote{Ciphertext: "c2VjcmV0", KeyHint: "laptop"}) {
+						t.Errorf("secret_note after round trip = %+v, want the exported ciphertext and key hint", todo.SecretNote)
+					}
+				case "plain":
+					if todo.SecretNote != nil {
+						t.Errorf("plain todo got secret_note %+v", todo.SecretNote)
+					}
+				default:
+					t.Errorf("unexpected title %q", todo.Title)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkspaceIsolation(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			if status, env := s.do(t, http.MethodPost, "/api/v1/workspaces", request{body: map[string]interface{}{"slug": "home", "name": "Home"}}); status != http.StatusCreated {
+				t.Fatalf("create workspace = %d %s", status, env.code())
+			}
+
+			status, env := s.do(t, http.MethodPost, "/api/v1/workspaces/home/todos", request{body: map[string]interface{}{"title": "private"}})
+			var todo todoJSON
+			env.decode(t, &todo)
+			if status != http.StatusCreated {
+				t.Fatalf("create in workspace = %d %s", status, env.code())
+			}
+
+			if status, env := s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", todo.ID), request{}); status != http.StatusNotFound {
+				t.Errorf("GET from default workspace = %d %s, want 404", status, env.code())
+			}
+			status, env = s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", todo.ID), request{header: http.Header{"X-Workspace": {"home"}}})
+			if status != http.StatusOK {
+				t.Errorf("GET with X-Workspace = %d %s, want 200", status, env.code())
+			}
+		})
+	}
+}
+
+func TestSQLiteOnlyFeatures(t *testing.T) {
+	s := newTestServer(t, "memory")
+	todo := s.create(t, map[string]interface{}{"title": "x"})
+
+	// 依赖 SQLite 中其他数据的功能在内存存储下返回 FEATURE_DISABLED，而不是外键错误
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodGet, fmt.Sprintf("/api/v1/todos/%d/links", todo.ID)},
+		{http.MethodPost, "/api/v1/uploads"},
+		{http.MethodGet, "/api/v1/jobs/1"},
+		{http.MethodGet, "/api/v1/todos?numbering=global"},
+		{http.MethodGet, "/api/v1/todos/views/workload"},
+		{http.MethodPost, "/api/v1/todos/batch/move"},
+		{http.MethodGet, "/api/v1/todos?sort=position"},
+	} {
+		status, env := s.do(t, tt.method, tt.path, request{body: map[string]interface{}{"body": "hi"}})
+		if status != http.StatusNotFound || env.code() != "FEATURE_DISABLED" {
+			t.Errorf("%s %s = %d %s, want 404 FEATURE_DISABLED", tt.method, tt.path, status, env.code())
+		}
+	}
+
+	// 评论与待办事项一样保存在内存存储中
+	status, env := s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/comments", todo.ID), request{body: map[string]interface{}{"body": "hi"}})
+	if status != http.StatusCreated {
+		t.Errorf("POST comment = %d %s, want 201", status, env.code())
+	}
+
+	status, env = s.do(t, http.MethodGet, "/api/v1/capabilities", request{})
+	var caps struct {
+		TodoStorage string `json:"todo_storage"`
+	}
+	env.decode(t, &caps)
+	if status != http.StatusOK || caps.TodoStorage != "memory" {
+		t.Errorf("capabilities todo_storage = %q, want memory", caps.TodoStorage)
+	}
+}
+
+func TestProjectOperations(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			project := func(name string) int {
+				t.Helper()
+				status, env := s.do(t, http.MethodPost, "/api/v1/projects", request{body: map[string]interface{}{"name": name}})
+				var p model.Project
+				env.decode(t, &p)
+				if status != http.StatusCreated {
+					t.Fatalf("create project %s = %d %s", name, status, env.code())
+				}
+				return p.ID
+			}
+			list := func(query string) []string {
+				t.Helper()
+				status, env := s.do(t, http.MethodGet, "/api/v1/todos?sort=id&order=asc"+query, request{})
+				var l listJSON
+				env.decode(t, &l)
+				if status != http.StatusOK {
+					t.Fatalf("list %s = %d %s", query, status, env.code())
+				}
+				return titles(l)
+			}
+			work, home := project("work"), project("home")
+			s.create(t, map[string]interface{}{"title": "report", "project_id": work})
+			s.create(t, map[string]interface{}{"title": "slides", "project_id": work})
+			s.create(t, map[string]interface{}{"title": "loose"})
+
+			// 归档后项目中的事项默认不出现在列表中，按项目查看或 include_archived 时仍然可见
+			status, env := s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/archive", work), request{})
+			var archived model.Project
+			env.decode(t, &archived)
+			if status != http.StatusOK || archived.ArchivedAt == nil {
+				t.Fatalf("archive = %d %s, %+v; want archived_at", status, env.code(), archived)
+			}
+			if got := fmt.Sprint(list("")); got != "[loose]" {
+				t.Errorf("list after archive = %s, want [loose]", got)
+			}
+			if got := fmt.Sprint(list(fmt.Sprintf("&project=%d", work))); got != "[report slides]" {
+				t.Errorf("list archived project = %s, want [report slides]", got)
+			}
+			if got := fmt.Sprint(list("&include_archived=true")); got != "[report slides loose]" {
+				t.Errorf("list include_archived = %s, want all three", got)
+			}
+
+			// 移入已归档项目的事项同样隐藏
+			s.create(t, map[string]interface{}{"title": "notes", "project_id": work})
+			if got := fmt.Sprint(list("")); got != "[loose]" {
+				t.Errorf("list after adding to archived project = %s, want [loose]", got)
+			}
+
+			status, env = s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/complete", work), request{})
+			var done handler.ProjectTodosResult
+			env.decode(t, &done)
+			if status != http.StatusOK || done.Count != 3 {
+				t.Errorf("complete = %d %s, %+v; want 3 completed", status, env.code(), done)
+			}
+
+			// 移到未归档的项目后恢复显示
+			status, env = s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/move", work), request{body: map[string]interface{}{"project_id": home}})
+			var moved handler.ProjectTodosResult
+			env.decode(t, &moved)
+			if status != http.StatusOK || moved.Count != 3 {
+				t.Errorf("move = %d %s, %+v; want 3 moved", status, env.code(), moved)
+			}
+			if got := fmt.Sprint(list("&status=completed")); got != "[report slides notes]" {
+				t.Errorf("list after move = %s, want the moved todos", got)
+			}
+			status, env = s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/projects/%d", home), request{})
+			var p model.Project
+			env.decode(t, &p)
+			if status != http.StatusOK || p.TodoCount != 3 || p.OpenCount != 0 {
+				t.Errorf("home project = %+v, want 3 todos, 0 open", p)
+			}
+
+			for _, body := range []map[string]interface{}{{"project_id": 999}, {"project_id": home}} {
+				status, env = s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/move", home), request{body: body})
+				if status != http.StatusBadRequest {
+					t.Errorf("move to %v = %d %s, want 400", body, status, env.code())
+				}
+			}
+
+			status, env = s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/projects/%d/unarchive", work), request{})
+			var unarchived model.Project
+			env.decode(t, &unarchived)
+			if status != http.StatusOK || unarchived.ArchivedAt != nil {
+				t.Errorf("unarchive = %d %s, %+v; want no archived_at", status, env.code(), unarchived)
+			}
+		})
+	}
+}
+
+func TestBatchMove(t *testing.T) {
+	s := newTestServer(t, "sqlite")
+	status, env := s.do(t, http.MethodPost, "/api/v1/projects", request{body: map[string]interface{}{"name": "work"}})
+	var p model.Project
+	env.decode(t, &p)
+	if status != http.StatusCreated {
+		t.Fatalf("create project = %d %s", status, env.code())
+	}
+	for _, title := range []string{"a", "b", "c"} {
+		s.create(t, map[string]interface{}{"title": title, "project_id": p.ID})
+	}
+	x := s.create(t, map[string]interface{}{"title": "x"})
+	y := s.create(t, map[string]interface{}{"title": "y"})
+	list := func(project string) string {
+		t.Helper()
+		status, env := s.do(t, http.MethodGet, "/api/v1/todos?sort=position&order=asc&project="+project, request{})
+		var l listJSON
+		env.decode(t, &l)
+		if status != http.StatusOK {
+			t.Fatalf("list project %s = %d %s", project, status, env.code())
+		}
+		return fmt.Sprint(titles(l))
+	}
+
+	// 有不存在的 ID 时整批失败
+	status, env = s.do(t, http.MethodPost, "/api/v1/todos/batch/move", request{body: map[string]interface{}{
+		"ids": []int{y.ID, 999}, "project_id": p.ID}})
+	if status != http.StatusNotFound || list("none") != "[x y]" {
+		t.Errorf("move with missing id = %d %s, inbox %s; want 404 and nothing moved", status, env.code(), list("none"))
+	}
+
+	index := 1
+	status, env = s.do(t, http.MethodPost, "/api/v1/todos/batch/move", request{body: map[string]interface{}{
+		"ids": []int{y.ID, x.ID, y.ID}, "project_id": p.ID, "index": index}})
+	var moved handler.ProjectTodosResult
+	env.decode(t, &moved)
+	if status != http.StatusOK || moved.Count != 2 {
+		t.Fatalf("move = %d %s, %+v; want 2 moved", status, env.code(), moved)
+	}
+	if got := list(fmt.Sprint(p.ID)); got != "[a y x b c]" {
+		t.Errorf("project order = %s, want [a y x b c]", got)
+	}
+	if got := list("none"); got != "[]" {
+		t.Errorf("inbox = %s, want empty", got)
+	}
+
+	status, env = s.do(t, http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", y.ID), request{})
+	var got todoJSON
+	env.decode(t, &got)
+	if status != http.StatusOK || got.Version != y.Version+1 {
+		t.Errorf("moved todo version = %d, want %d", got.Version, y.Version+1)
+	}
+
+	// 移回不属于任何项目的列表，不传 index 时放在最后
+	status, env = s.do(t, http.MethodPost, "/api/v1/todos/batch/move", request{body: map[string]interface{}{
+		"ids": []int{x.ID}, "project_id": 0}})
+	if status != http.StatusOK || list("none") != "[x]" || list(fmt.Sprint(p.ID)) != "[a y b c]" {
+		t.Errorf("move back = %d %s, inbox %s, project %s", status, env.code(), list("none"), list(fmt.Sprint(p.ID)))
+	}
+}
+
+func TestJSONPatchOrder(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			todo := s.create(t, map[string]interface{}{"title": "x", "due_date": "2030-01-01T00:00:00Z"})
+			path := fmt.Sprintf("/api/v1/todos/%d", todo.ID)
+
+			// 同一字段以最后一个操作为准
+			tests := []struct {
+				name string
+				ops  string
+				want string
+			}{
+				{"remove then add", `[{"op":"test","path":"/version","value":%d},{"op":"remove","path":"/due_date"},{"op":"add","path":"/due_date","value":"2031-06-01T00:00:00Z"}]`, "2031-06-01T00:00:00Z"},
+				{"add then remove", `[{"op":"test","path":"/version","value":%d},{"op":"add","path":"/due_date","value":"2032-06-01T00:00:00Z"},{"op":"remove","path":"/due_date"}]`, ""},
+			}
+			version := todo.Version
+			for _, tt := range tests {
+				status, env := s.do(t, http.MethodPatch, path, request{body: fmt.Sprintf(tt.ops, version), contentType: "application/json-patch+json"})
+				var got todoJSON
+				env.decode(t, &got)
+				if status != http.StatusOK || got.DueDate != tt.want {
+					t.Errorf("%s: %d %s, due_date %q; want %q", tt.name, status, env.code(), got.DueDate, tt.want)
+				}
+				version = got.Version
+			}
+
+			status, env := s.do(t, http.MethodPatch, path, request{body: `[{"op":"remove","path":"/title"}]`, contentType: "application/json-patch+json"})
+			if status != http.StatusUnprocessableEntity && status != http.StatusBadRequest {
+				t.Errorf("remove /title = %d %s, want a validation error", status, env.code())
+			}
+		})
+	}
+}
+
+func TestShareLink(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			if status, env := s.do(t, http.MethodPost, "/api/v1/workspaces", request{body: map[string]interface{}{"slug": "home", "name": "Home"}}); status != http.StatusCreated {
+				t.Fatalf("create workspace = %d %s", status, env.code())
+			}
+			home := http.Header{"X-Workspace": {"home"}}
+			status, env := s.do(t, http.MethodPost, "/api/v1/todos", request{body: map[string]interface{}{"title": "shared"}, header: home})
+			var todo todoJSON
+			env.decode(t, &todo)
+			if status != http.StatusCreated {
+				t.Fatalf("create = %d %s", status, env.code())
+			}
+
+			// 其他工作区不能分享这条待办事项
+			path := fmt.Sprintf("/api/v1/todos/%d/share", todo.ID)
+			if status, env := s.do(t, http.MethodPost, path, request{}); status != http.StatusNotFound {
+				t.Errorf("share from default workspace = %d %s, want 404", status, env.code())
+			}
+
+			status, env = s.do(t, http.MethodPost, path, request{body: map[string]interface{}{"expires_in_hours": 1}, header: home})
+			var link handler.ShareResponse
+			env.decode(t, &link)
+			if status != http.StatusCreated || link.Token == "" {
+				t.Fatalf("share = %d %s", status, env.code())
+			}
+
+			// 公开访问不带工作区，按令牌中的工作区查找
+			status, env = s.do(t, http.MethodGet, "/share/"+link.Token, request{})
+			var shared handler.SharedTodo
+			env.decode(t, &shared)
+			if status != http.StatusOK || shared.Title != "shared" {
+				t.Errorf("GET /share = %d %s, %+v", status, env.code(), shared)
+			}
+
+			status, env = s.do(t, http.MethodGet, "/share/"+link.Token+"x", request{})
+			if status != http.StatusNotFound || env.code() != "NOT_FOUND" {
+				t.Errorf("tampered token = %d %s, want 404 NOT_FOUND", status, env.code())
+			}
+
+			status, env = s.do(t, http.MethodPost, path, request{body: map[string]interface{}{"expires_in_hours": -1}, header: home})
+			if status != http.StatusBadRequest || env.code() != "VALIDATION_ERROR" {
+				t.Errorf("negative expiry = %d %s, want 400 VALIDATION_ERROR", status, env.code())
+			}
+		})
+	}
+}
+
+func TestAdminRequiresAdminRole(t *testing.T) {
+	s := newTestServer(t, "sqlite")
+
+	// 没有创建过 key 的本机部署不需要认证，管理接口同样可用
+	if status, env := s.do(t, http.MethodGet, "/api/v1/admin/features", request{}); status != http.StatusOK {
+		t.Fatalf("admin without keys = %d %s, want 200", status, env.code())
+	}
+
+	adminKey := model.APIKey{Name: "ops", UserID: handler.DefaultUserID, Role: model.APIKeyRoleAdmin}
+	rawAdmin := model.NewAPIKey(&adminKey)
+	if err := s.repos.CreateAPIKeyContext(context.Background(), &adminKey, model.HashAPIKey(rawAdmin)); err != nil {
+		t.Fatal(err)
+	}
+	asAdmin := http.Header{handler.APIKeyHeader: {rawAdmin}}
+
+	status, env := s.do(t, http.MethodPost, "/api/v1/api-keys", request{body: map[string]interface{}{"name": "script"}, header: asAdmin})
+	var created handler.CreatedAPIKey
+	env.decode(t, &created)
+	if status != http.StatusCreated || created.Role != model.APIKeyRoleMember {
+		t.Fatalf("create key = %d %s, role %q; want 201 member", status, env.code(), created.Role)
+	}
+	asMember := http.Header{handler.APIKeyHeader: {created.Key}}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		header http.Header
+		status int
+		code   string
+	}{
+		{"admin reads features", http.MethodGet, "/api/v1/admin/features", nil, asAdmin, http.StatusOK, ""},
+		{"admin reads config", http.MethodGet, "/api/v1/admin/config", nil, asAdmin, http.StatusOK, ""},
+		{"member reads features", http.MethodGet, "/api/v1/admin/features", nil, asMember, http.StatusForbidden, "FORBIDDEN"},
+		{"member starts backup", http.MethodPost, "/api/v1/admin/backup", nil, asMember, http.StatusForbidden, "FORBIDDEN"},
+		{"member drains", http.MethodPost, "/api/v1/admin/drain", nil, asMember, http.StatusForbidden, "FORBIDDEN"},
+		{"member lists todos", http.MethodGet, "/api/v1/todos", nil, asMember, http.StatusOK, ""},
+		{"member creates admin key", http.MethodPost, "/api/v1/api-keys", map[string]interface{}{"name": "x", "role": "admin"}, asMember, http.StatusForbidden, "FORBIDDEN"},
+		{"unknown role", http.MethodPost, "/api/v1/api-keys", map[string]interface{}{"name": "x", "role": "root"}, asAdmin, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{"no key", http.MethodGet, "/api/v1/admin/features", nil, nil, http.StatusUnauthorized, "UNAUTHORIZED"},
+	}
+	for _, tt := range tests {
+		status, env := s.do(t, tt.method, tt.path, request{body: tt.body, header: tt.header})
+		if status != tt.status || env.code() != tt.code {
+			t.Errorf("%s: %d %s, want %d %s", tt.name, status, env.code(), tt.status, tt.code)
+		}
+	}
+
+	status, env = s.do(t, http.MethodPost, "/api/v1/api-keys", request{body: map[string]interface{}{"name": "second admin", "role": "admin"}, header: asAdmin})
+	env.decode(t, &created)
+	if status != http.StatusCreated || created.Role != model.APIKeyRoleAdmin {
+		t.Errorf("admin creates admin key = %d %s, role %q", status, env.code(), created.Role)
+	}
+
+	// member key 只能看到和撤销自己，不能撤销 admin key
+	memberID := 0
+	status, env = s.do(t, http.MethodGet, "/api/v1/api-keys", request{header: asMember})
+	var visible []model.APIKey
+	env.decode(t, &visible)
+	if status != http.StatusOK || len(visible) != 1 || visible[0].Role != model.APIKeyRoleMember {
+		t.Errorf("member lists keys = %d %s, %+v; want only itself", status, env.code(), visible)
+	} else {
+		memberID = visible[0].ID
+	}
+	revokeAdmin := fmt.Sprintf("/api/v1/api-keys/%d", adminKey.ID)
+	if status, env := s.do(t, http.MethodDelete, revokeAdmin, request{header: asMember}); status != http.StatusForbidden || env.code() != "FORBIDDEN" {
+		t.Errorf("member revokes admin key = %d %s, want 403 FORBIDDEN", status, env.code())
+	}
+	if status, env := s.do(t, http.MethodGet, "/api/v1/admin/features", request{header: asAdmin}); status != http.StatusOK {
+		t.Errorf("admin key after member revoke attempt = %d %s, want 200", status, env.code())
+	}
+	if status, env := s.do(t, http.MethodDelete, fmt.Sprintf("/api/v1/api-keys/%d", memberID), request{header: asMember}); status != http.StatusOK {
+		t.Errorf("member revokes itself = %d %s, want 200", status, env.code())
+	}
+}
+
+// 认证扩展放行但没有给出请求方标识时，管理接口同样拒绝
+func TestAdminRequiresExtensionSubject(t *testing.T) {
+	s := newTestServer(t, "sqlite", "ADMIN_SUBJECTS", "alice")
+	s.h.SetAuthenticator(func(r *http.Request) (string, error) {
+		return r.Header.Get("X-Test-Subject"), nil
+	})
+
+	tests := []struct {
+		name    string
+		subject string
+		path    string
+		status  int
+	}{
+		{"admin subject", "alice", "/api/v1/admin/features", http.StatusOK},
+		{"other subject", "bob", "/api/v1/admin/features", http.StatusForbidden},
+		{"empty subject", "", "/api/v1/admin/features", http.StatusForbidden},
+		{"empty subject lists todos", "", "/api/v1/todos", http.StatusOK},
+	}
+	for _, tt := range tests {
+		status, env := s.do(t, http.MethodGet, tt.path, request{header: http.Header{"X-Test-Subject": {tt.subject}}})
+		if status != tt.status {
+			t.Errorf("%s: %d %s, want %d", tt.name, status, env.code(), tt.status)
+		}
+	}
+}
+
+// 流量统计中的请求路径不含查询参数，分享令牌保留为占位符
+func TestTrafficRedactsTokens(t *testing.T) {
+	s := newTestServer(t, "sqlite", "TRAFFIC_HEAVY_BYTES", "1")
+	todo := s.create(t, map[string]interface{}{"title": "shared"})
+	path := fmt.Sprintf("/api/v1/todos/%d", todo.ID)
+	status, env := s.do(t, http.MethodPost, path+"/share", request{})
+	var link handler.ShareResponse
+	env.decode(t, &link)
+	if status != http.StatusCreated {
+		t.Fatalf("share = %d %s", status, env.code())
+	}
+	s.do(t, http.MethodGet, "/share/"+link.Token+"?access_token=secret", request{})
+	s.do(t, http.MethodGet, path+"?api_key=secret", request{})
+
+	status, env = s.do(t, http.MethodGet, "/api/v1/admin/traffic?limit=0", request{})
+	var report handler.TrafficReport
+	env.decode(t, &report)
+	if status != http.StatusOK {
+		t.Fatalf("traffic = %d %s", status, env.code())
+	}
+	uris := map[string]bool{}
+	for _, req := range report.Requests {
+		if strings.Contains(req.URI, link.Token) || strings.Contains(req.URI, "secret") || strings.Contains(req.URI, "?") {
+			t.Errorf("recorded uri %q leaks the token or query string", req.URI)
+		}
+		uris[req.URI] = true
+	}
+	for _, want := range []string{"/share/{token}", path} {
+		if !uris[want] {
+			t.Errorf("recorded uris %v, want %q", uris, want)
+		}
+	}
+}
+
+func TestAPIKeyWorkspaceMembership(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver)
+			for _, slug := range []string{"alpha", "beta"} {
+				if status, env := s.do(t, http.MethodPost, "/api/v1/workspaces", request{body: map[string]interface{}{"slug": slug, "name": slug}}); status != http.StatusCreated {
+					t.Fatalf("create workspace %s = %d %s", slug, status, env.code())
+				}
+			}
+			status, env := s.do(t, http.MethodPost, "/api/v1/workspaces/beta/todos", request{body: map[string]interface{}{"title": "beta secret"}})
+			var secret todoJSON
+			env.decode(t, &secret)
+			if status != http.StatusCreated {
+				t.Fatalf("create in beta = %d %s", status, env.code())
+			}
+
+			// 第一个 key 只能从命令行创建（admin），再用它创建只属于 alpha 的 member key
+			adminKey := model.APIKey{Name: "ops", UserID: handler.DefaultUserID, Role: model.APIKeyRoleAdmin}
+			rawAdmin := model.NewAPIKey(&adminKey)
+			if err := s.repos.CreateAPIKeyContext(context.Background(), &adminKey, model.HashAPIKey(rawAdmin)); err != nil {
+				t.Fatal(err)
+			}
+			asAdmin := http.Header{handler.APIKeyHeader: {rawAdmin}}
+			status, env = s.do(t, http.MethodPost, "/api/v1/api-keys", request{body: map[string]interface{}{"name": "alpha bot", "workspaces": []string{"alpha"}}, header: asAdmin})
+			var created handler.CreatedAPIKey
+			env.decode(t, &created)
+			if status != http.StatusCreated || fmt.Sprint(created.Workspaces) != "[alpha]" {
+				t.Fatalf("create alpha key = %d %s, %+v", status, env.code(), created.APIKey)
+			}
+			alphaKey := created.Key
+			asAlpha := func(extra ...string) http.Header {
+				h := http.Header{handler.APIKeyHeader: {alphaKey}}
+				if len(extra) == 2 {
+					h.Set(extra[0], extra[1])
+				}
+				return h
+			}
+
+			tests := []struct {
+				name   string
+				method string
+				path   string
+				header http.Header
+				status int
+				code   string
+			}{
+				{"own workspace by path", http.MethodGet, "/api/v1/workspaces/alpha/todos", asAlpha(), http.StatusOK, ""},
+				{"own workspace by header", http.MethodGet, "/api/v1/todos", asAlpha("X-Workspace", "alpha"), http.StatusOK, ""},
+				{"other workspace by path", http.MethodGet, "/api/v1/workspaces/beta/todos", asAlpha(), http.StatusNotFound, "WORKSPACE_NOT_FOUND"},
+				{"other todo by path", http.MethodGet, fmt.Sprintf("/api/v1/workspaces/beta/todos/%d", secret.ID), asAlpha(), http.StatusNotFound, "WORKSPACE_NOT_FOUND"},
+				{"other workspace by header", http.MethodGet, fmt.Sprintf("/api/v1/todos/%d", secret.ID), asAlpha("X-Workspace", "beta"), http.StatusNotFound, "WORKSPACE_NOT_FOUND"},
+				{"other workspace usage", http.MethodGet, "/api/v1/workspaces/beta/usage", asAlpha(), http.StatusNotFound, "WORKSPACE_NOT_FOUND"},
+				{"default workspace", http.MethodGet, "/api/v1/todos", asAlpha("X-Workspace", "default"), http.StatusNotFound, "WORKSPACE_NOT_FOUND"},
+				{"admin reads beta", http.MethodGet, fmt.Sprintf("/api/v1/workspaces/beta/todos/%d", secret.ID), asAdmin, http.StatusOK, ""},
+			}
+			for _, tt := range tests {
+				status, env := s.do(t, tt.method, tt.path, request{header: tt.header})
+				if status != tt.status || env.code() != tt.code {
+					t.Errorf("%s: %d %s, want %d %s", tt.name, status, env.code(), tt.status, tt.code)
+				}
+			}
+
+			// 未指定工作区时使用 key 绑定的工作区
+			status, env = s.do(t, http.MethodPost, "/api/v1/todos", request{body: map[string]interface{}{"title": "alpha task"}, header: asAlpha()})
+			if status != http.StatusCreated {
+				t.Fatalf("create with alpha key = %d %s", status, env.code())
+			}
+			status, env = s.do(t, http.MethodGet, "/api/v1/workspaces/alpha/todos", request{header: asAdmin})
+			var alphaList listJSON
+			env.decode(t, &alphaList)
+			if status != http.StatusOK || fmt.Sprint(titles(alphaList)) != "[alpha task]" {
+				t.Errorf("alpha todos = %d, %q; want [alpha task]", status, titles(alphaList))
+			}
+
+			status, env = s.do(t, http.MethodGet, "/api/v1/workspaces", request{header: asAlpha()})
+			var workspaces []model.Workspace
+			env.decode(t, &workspaces)
+			if status != http.StatusOK || len(workspaces) != 1 || workspaces[0].Slug != "alpha" {
+				t.Errorf("list workspaces with alpha key = %d, %+v; want only alpha", status, workspaces)
+			}
+
+			// member key 不能创建工作区，也不能把 beta 授权给新 key
+			if status, env := s.do(t, http.MethodPost, "/api/v1/workspaces", request{body: map[string]interface{}{"slug": "gamma", "name": "gamma"}, header: asAlpha()}); status != http.StatusForbidden {
+				t.Errorf("member creates workspace = %d %s, want 403", status, env.code())
+			}
+			if status, env := s.do(t, http.MethodPost, "/api/v1/api-keys", request{body: map[string]interface{}{"name": "escalate", "workspaces": []string{"beta"}}, header: asAlpha()}); status != http.StatusForbidden {
+				t.Errorf("member grants beta = %d %s, want 403", status, env.code())
+			}
+			if status, env := s.do(t, http.MethodPost, "/api/v1/api-keys", request{body: map[string]interface{}{"name": "typo", "workspaces": []string{"nope"}}, header: asAdmin}); status != http.StatusNotFound {
+				t.Errorf("key for missing workspace = %d %s, want 404", status, env.code())
+			}
+		})
+	}
+}
+
+func TestGitHubHookReplay(t *testing.T) {
+	s := newTestServer(t, "sqlite", "GITHUB_WEBHOOK_SECRET", "hook-secret")
+	send := func(srv *httptest.Server, body, delivery string) (int, *envelope) {
+		mac := hmac.New(sha256.New, []byte("hook-secret"))
+		mac.Write([]byte(body))
+		ts := &testServer{Server: srv}
+		return ts.do(t, http.MethodPost, "/api/v1/hooks/github", request{body: body, header: http.Header{
+			"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))},
+			"X-Github-Event":      {"ping"},
+			"X-Github-Delivery":   {delivery},
+		}})
+	}
+
+	const body = `{"zen":"Keep it logically awesome."}`
+	if status, env := send(s.Server, body, "d-1"); status != http.StatusCreated || env.Message != "pong" {
+		t.Fatalf("first delivery = %d %s %q, want pong", status, env.code(), env.Message)
+	}
+
+	// X-GitHub-Delivery 不在签名范围内，换一个值仍然是同一个请求
+	if status, env := send(s.Server, body, "d-2"); status != http.StatusOK || env.Message == "pong" {
+		t.Errorf("replay with new delivery id = %d %q, want ignored", status, env.Message)
+	}
+
+	// 投递记录在数据库中，重启后（新的 Handler）仍然有效
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted := httptest.NewServer(api.SetupRoutes(handler.NewHandler(s.db, s.db, s.db, cfg)))
+	defer restarted.Close()
+	if status, env := send(restarted, body, "d-3"); status != http.StatusOK || env.Message == "pong" {
+		t.Errorf("replay after restart = %d %q, want ignored", status, env.Message)
+	}
+	if status, env := send(restarted, `{"zen":"Design for failure."}`, "d-4"); status != http.StatusCreated || env.Message != "pong" {
+		t.Errorf("new body = %d %s %q, want pong", status, env.code(), env.Message)
+	}
+
+	status, env := s.do(t, http.MethodPost, "/api/v1/hooks/github", request{body: body, header: http.Header{"X-Hub-Signature-256": {"sha256=00"}}})
+	if status != http.StatusUnauthorized || env.code() != "INVALID_SIGNATURE" {
+		t.Errorf("bad signature = %d %s, want 401 INVALID_SIGNATURE", status, env.code())
+	}
+}
+
+// issue 创建时生成一条待办事项，之后的指派不再生成；标题来自请求体，同样规范化并检查长度
+func TestGitHubHookIssues(t *testing.T) {
+	for _, driver := range backends {
+		t.Run(driver, func(t *testing.T) {
+			s := newTestServer(t, driver, "GITHUB_WEBHOOK_SECRET", "hook-secret", "MAX_TITLE_LENGTH", "40")
+			send := func(action, title string) (int, *envelope) {
+				t.Helper()
+				body := fmt.Sprintf(`{"action":%q,"issue":{"number":7,"title":%q,"html_url":"https://github.com/acme/app/issues/7"},"repository":{"full_name":"acme/app"}}`, action, title)
+				mac := hmac.New(sha256.New, []byte("hook-secret"))
+				mac.Write([]byte(body))
+				return s.do(t, http.MethodPost, "/api/v1/hooks/github", request{body: body, header: http.Header{
+					"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))},
+					"X-Github-Event":      {"issues"},
+				}})
+			}
+
+			for _, tc := range []struct {
+				name   string
+				action string
+				title  string
+				status int
+				code   string
+			}{
+				{"opened", "opened", "  Crash\n on   start ", http.StatusCreated, ""},
+				{"assigned", "assigned", "Crash on start", http.StatusAccepted, ""},
+				{"reassigned", "assigned", "Crash on start (again)", http.StatusAccepted, ""},
+				{"title too long", "opened", strings.Repeat("x", 40), http.StatusUnprocessableEntity, "CONSTRAINT_VIOLATION"},
+			} {
+				if status, env := send(tc.action, tc.title); status != tc.status || env.code() != tc.code {
+					t.Errorf("%s = %d %s, want %d %s", tc.name, status, env.code(), tc.status, tc.code)
+				}
+			}
+
+			status, env := s.do(t, http.MethodGet, "/api/v1/todos", request{})
+			var list listJSON
+			env.decode(t, &list)
+			if got := titles(list); status != http.StatusOK || len(got) != 1 || got[0] != "[acme/app#7] Crash on start" {
+				t.Errorf("todos = %d %q, want only the normalized title from the opened event", status, got)
+			}
+		})
+	}
+}
+
+// Slack 斜杠命令的回复都是 200 + {"text": ...}，标题同样规范化并检查长度
+func TestSlackCommandHook(t *testing.T) {
+	s := newTestServer(t, "sqlite", "SLACK_SIGNING_SECRET", "slack-secret", "MAX_TITLE_LENGTH", "20")
+	now := time.Now()
+	send := func(text string) string {
+		t.Helper()
+		body := url.Values{"text": {text}}.Encode()
+		ts := strconv.FormatInt(now.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte("slack-secret"))
+		mac.Write([]byte("v0:" + ts + ":" + body))
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/api/v1/hooks/slack", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var reply struct {
+			Text    string `json:"text"`
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%q: status = %d, want 200", text, resp.StatusCode)
+		}
+		return reply.Text + reply.Message
+	}
+
+	for _, tc := range []struct {
+		name string
+		text string
+		want string
+	}{
+		{"create", "  buy\t milk ", "已添加：buy milk"},
+		{"empty", "   ", "用法：/todo 待办事项标题"},
+		{"title too long", strings.Repeat("x", 21), "不能超过"},
+		{"replayed", "  buy\t milk ", "重复请求，已忽略"},
+	} {
+		if got := send(tc.text); !strings.Contains(got, tc.want) {
+			t.Errorf("%s = %q, want it to contain %q", tc.name, got, tc.want)
+		}
+	}
+
+	status, env := s.do(t, http.MethodGet, "/api/v1/todos", request{})
+	var list listJSON
+	env.decode(t, &list)
+	if got := titles(list); status != http.StatusOK || len(got) != 1 || got[0] != "buy milk" {
+		t.Errorf("todos = %d %q, want only the normalized title", status, got)
+	}
+}
+
+// 分享页面按工作流的终态显示是否完成，自定义的终态同样显示为已完成
+func TestSharePageUsesWorkflowTerminalStatus(t *testing.T) {
+	s := newTestServer(t, "sqlite")
+	wf := &workflow.Workflow{
+		Statuses: []workflow.Status{
+			{Name: "pending"}, {Name: "completed", Terminal: true}, {Name: "cancelled", Terminal: true},
+		},
+		Transitions: map[string][]string{"pending": {"completed", "cancelled"}, "completed": {"pending"}, "cancelled": {"pending"}},
+	}
+	s.h.SetWorkflow(wf)
+	s.db.SetStatuses(storage.NewStatusSets(wf.Names(), wf.IsTerminal))
+
+	todo := s.create(t, map[string]interface{}{"title": "dropped"})
+	path := fmt.Sprintf("/api/v1/todos/%d", todo.ID)
+	page := func() string {
+		t.Helper()
+		status, env := s.do(t, http.MethodPost, path+"/share", request{})
+		var link handler.ShareResponse
+		env.decode(t, &link)
+		if status != http.StatusCreated {
+			t.Fatalf("share = %d %s", status, env.code())
+		}
+		resp, err := http.Get(s.URL + "/share/" + link.Token + "?format=html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	if body := page(); !strings.Contains(body, "进行中") {
+		t.Errorf("pending todo page does not say 进行中:\n%s", body)
+	}
+	status, env := s.do(t, http.MethodPatch, path, request{body: map[string]interface{}{"version": todo.Version, "status": "cancelled"}, contentType: "application/merge-patch+json"})
+	if status != http.StatusOK {
+		t.Fatalf("cancel = %d %s", status, env.code())
+	}
+	if body := page(); !strings.Contains(body, "已完成") {
+		t.Errorf("cancelled todo page does not say 已完成:\n%s", body)
+	}
+}
+
+func TestHookRetryAfterFailure(t *testing.T) {
+	s := newTestServer(t, "sqlite")
+
+	// 处理器第一次返回 500，之后成功
+	var calls int
+	s.h.RegisterHookProvider(hooks.Provider{
+		Name:     "flaky",
+		Verifier: hooks.GitHubVerifier{Secret: "hook-secret"},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"success":false}`))
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"success":true}`))
+		},
+	})
+
+	const body = `{"action":"opened"}`
+	mac := hmac.New(sha256.New, []byte("hook-secret"))
+	mac.Write([]byte(body))
+	send := func() (int, *envelope) {
+		return s.do(t, http.MethodPost, "/api/v1/hooks/flaky", request{body: body, header: http.Header{
+			"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))},
+		}})
+	}
+
+	if status, _ := send(); status != http.StatusInternalServerError {
+		t.Fatalf("first delivery = %d, want 500", status)
+	}
+	// 投递记录已经撤销，重试会被处理而不是当作重复请求忽略
+	if status, _ := send(); status != http.StatusAccepted {
+		t.Fatalf("retry after failure = %d, want 202", status)
+	}
+	if status, env := send(); status != http.StatusOK || env.Message != "重复请求，已忽略" {
+		t.Errorf("replay after success = %d %q, want ignored", status, env.Message)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	s := newTestServer(t, "sqlite")
+	s.h.SetWebhookDispatcher(webhook.NewDispatcher(s.db, http.DefaultClient, nil, clock.Real{}))
+
+	// 接收方第一次返回 503，之后返回 200
+	var received int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		if received == 1 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer receiver.Close()
+
+	status, env := s.do(t, http.MethodPost, "/api/v1/webhooks", request{body: map[string]interface{}{"url": receiver.URL}})
+	if status != http.StatusCreated {
+		t.Fatalf("create webhook = %d %s", status, env.code())
+	}
+	var hook model.Webhook
+	env.decode(t, &hook)
+	base := fmt.Sprintf("/api/v1/webhooks/%d", hook.ID)
+
+	if status, env := s.do(t, http.MethodPost, base+"/test", request{}); status != http.StatusOK {
+		t.Fatalf("test webhook = %d %s", status, env.code())
+	}
+	status, env = s.do(t, http.MethodGet, base+"/deliveries?failed=true", request{})
+	if status != http.StatusOK {
+		t.Fatalf("list deliveries = %d %s", status, env.code())
+	}
+	var failed []model.WebhookDelivery
+	env.decode(t, &failed)
+	if len(failed) != 1 || failed[0].Success || failed[0].Status != http.StatusServiceUnavailable ||
+		failed[0].Response != "try later\n" || failed[0].Attempt != 1 {
+		t.Fatalf("failed deliveries = %+v, want one 503 attempt", failed)
+	}
+
+	retry := fmt.Sprintf("%s/deliveries/%d/retry", base, failed[0].ID)
+	status, env = s.do(t, http.MethodPost, retry, request{})
+	if status != http.StatusOK {
+		t.Fatalf("retry = %d %s", status, env.code())
+	}
+	var attempt model.WebhookDelivery
+	env.decode(t, &attempt)
+	if !attempt.Success || attempt.Delivery != failed[0].Delivery || attempt.Attempt != 2 {
+		t.Errorf("retry attempt = %+v, want second successful attempt of %s", attempt, failed[0].Delivery)
+	}
+
+	// 已经送达的推送不再重试
+	if status, env := s.do(t, http.MethodPost, retry, request{}); status != http.StatusConflict || env.code() != "ALREADY_DELIVERED" {
+		t.Errorf("retry delivered = %d %s, want 409 ALREADY_DELIVERED", status, env.code())
+	}
+	if status, env := s.do(t, http.MethodPost, base+"/deliveries/999/retry", request{}); status != http.StatusNotFound {
+		t.Errorf("retry unknown delivery = %d %s, want 404", status, env.code())
+	}
+
+	// 超过保留期的记录被清理
+	n, err := s.db.PurgeWebhookDeliveriesContext(context.Background(), time.Now().Add(time.Hour))
+	if err != nil || n != 2 {
+		t.Errorf("purge = %d, %v, want 2 deliveries", n, err)
+	}
+}
+
+func TestWebhookAndAttachmentQuotas(t *testing.T) {
+	s := newTestServer(t, "sqlite", "QUOTA_MAX_WEBHOOKS", "1", "QUOTA_MAX_ATTACHMENT_BYTES", "10")
+	todo := s.create(t, map[string]interface{}{"title": "with files"})
+
+	hook := map[string]interface{}{"url": "https://hooks.example.com/todo"}
+	if status, env := s.do(t, http.MethodPost, "/api/v1/webhooks", request{body: hook}); status != http.StatusCreated {
+		t.Fatalf("first webhook = %d %s", status, env.code())
+	}
+	if status, env := s.do(t, http.MethodPost, "/api/v1/webhooks", request{body: hook}); status != http.StatusForbidden || env.code() != "QUOTA_EXCEEDED" {
+		t.Errorf("second webhook = %d %s, want 403 QUOTA_EXCEEDED", status, env.code())
+	}
+
+	upload := func(content string) (int, *envelope) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("file", "notes.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(part, content)
+		mw.Close()
+		return s.do(t, http.MethodPost, fmt.Sprintf("/api/v1/todos/%d/attachments", todo.ID),
+			request{body: body.String(), contentType: mw.FormDataContentType()})
+	}
+	if status, env := upload("12345678"); status != http.StatusCreated {
+		t.Fatalf("first attachment = %d %s", status, env.code())
+	}
+	if status, env := upload("123"); status != http.StatusForbidden || env.code() != "QUOTA_EXCEEDED" {
+		t.Errorf("attachment over total = %d %s, want 403 QUOTA_EXCEEDED", status, env.code())
+	}
+	chunked := map[string]interface{}{"purpose": "attachment", "todo_id": todo.ID, "filename": "big.txt", "size": 5}
+	if status, env := s.do(t, http.MethodPost, "/api/v1/uploads", request{body: chunked}); status != http.StatusForbidden || env.code() != "QUOTA_EXCEEDED" {
+		t.Errorf("chunked upload over total = %d %s, want 403 QUOTA_EXCEEDED", status, env.code())
+	}
+
+	status, env := s.do(t, http.MethodGet, "/api/v1/usage", request{})
+	var usage handler.UsageResponse
+	env.decode(t, &usage)
+	if status != http.StatusOK {
+		t.Fatalf("usage = %d %s", status, env.code())
+	}
+	got := map[string]handler.UsageItem{}
+	for _, item := range usage.Items {
+		got[item.Resource] = item
+	}
+	if w := got["webhooks"]; w.Used != 1 || w.Limit != 1 {
+		t.Errorf("webhooks usage = %+v, want 1/1", w)
+	}
+	if a := got["attachment_bytes"]; a.Used != 8 || a.Limit != 10 {
+		t.Errorf("attachment_bytes usage = %+v, want 8/10", a)
+	}
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/config"
+	"todo-list/hooks"
+	"todo-list/model"
+)
+
+// newHookRegistry 根据配置注册内置的入站集成，未配置密钥的集成不会启用
+func (h *Handler) newHookRegistry(cfg *config.Config) *hooks.Registry {
+	reg := hooks.NewRegistry()
+	// 投递记录与项目、webhook 等数据保存在一起（SQLite，或 DB_DRIVER=postgres 时各实例共享的 PostgreSQL），
+	// 重新投递到任何一个实例都会被识别；DB_DRIVER=memory 时只在内存中去重
+	if store, ok := h.repos.(hooks.ReplayStore); ok {
+		reg.SetReplayStore(store)
+	}
+
+	if cfg.GitHubWebhookSecret != "" {
+		reg.Register(hooks.Provider{
+			Name:     "github",
+			Verifier: hooks.GitHubVerifier{Secret: cfg.GitHubWebhookSecret},
+			Handler:  h.githubHook,
+		})
+	}
+	if cfg.SlackSigningSecret != "" {
+		reg.Register(hooks.Provider{
+			Name:     "slack",
+			Verifier: hooks.SlackVerifier{Secret: cfg.SlackSigningSecret},
+			Handler:  h.slackCommandHook,
+		})
+	}
+	if cfg.MailgunSigningKey != "" {
+		reg.Register(hooks.Provider{
+			Name:     "mailgun",
+			Verifier: hooks.MailgunVerifier{Secret: cfg.MailgunSigningKey},
+			Handler:  h.inboundEmailHook,
+		})
+	}
+
+	return reg
+}
+
+// RegisterHookProvider 注册额外的入站集成（供扩展使用）
+func (h *Handler) RegisterHookProvider(p hooks.Provider) {
+	h.hooks.Register(p)
+}
+
+// ReceiveHook 入站 webhook 统一入口：/api/v1/hooks/{provider}
+// @Summary 入站 webhook
+// @Description 按集成校验签名（github / slack / mailgun）
+// @Tags integrations
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param provider path string true "集成名称"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 401 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/hooks/{provider} [post]
+func (h *Handler) ReceiveHook(w http.ResponseWriter, r *http.Request) {
+	// 处理器返回 5xx 时 Serve 会撤销投递记录，发送方重试时再次处理
+	if err := h.hooks.Serve(w, r, r.PathValue("provider")); err != nil {
+		switch {
+		case errors.Is(err, hooks.ErrUnknownProvider):
+			h.sendError(w, apperr.CodeNotFound, "未启用的集成")
+		case errors.Is(err, hooks.ErrInvalidSignature):
+			h.sendError(w, apperr.CodeInvalidSignature, "签名校验失败")
+		case errors.Is(err, hooks.ErrStaleRequest):
+			h.sendError(w, apperr.CodeStaleRequest, "请求时间戳已过期")
+		case errors.Is(err, hooks.ErrReplayCheck):
+			h.sendAPIError(w, "ReceiveHook", storeError(err, "检查重复投递失败"))
+		case errors.Is(err, hooks.ErrReplayed):
+			// 重复投递按成功处理，避免发送方无限重试
+			h.sendJSON(w, http.StatusOK, Response{Success: true, Message: "重复请求，已忽略"})
+		default:
+			h.sendError(w, apperr.CodeInvalidRequest, err.Error())
+		}
+	}
+}
+
+// githubIssueEvent GitHub issues 事件中用到的字段
+type githubIssueEvent struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+	} `json:"issue"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// githubHook 处理 GitHub webhook：issue 被创建时生成待办事项
+func (h *Handler) githubHook(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "githubHook", timeout: CreateTimeout, status: http.StatusCreated, message: "已根据 GitHub issue 创建待办事项"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			event := r.Header.Get("X-GitHub-Event")
+			if event == "ping" {
+				return result{message: "pong"}, nil
+			}
+			if event != "issues" {
+				return result{status: http.StatusAccepted, message: "事件已忽略"}, nil
+			}
+
+			var payload githubIssueEvent
+			if err := decodeJSON(r, &payload); err != nil {
+				return nil, err
+			}
+			// 只在 issue 创建时生成：指派、重新指派是另一次投递（签名不同），同一个 issue 会重复生成待办事项
+			if payload.Action != "opened" {
+				return result{status: http.StatusAccepted, message: "事件已忽略"}, nil
+			}
+
+			// 标题和链接来自请求体，与其他入口一样规范化并检查长度
+			title := model.NormalizeTitle(fmt.Sprintf("[%s#%d] %s", payload.Repository.FullName, payload.Issue.Number, payload.Issue.Title))
+			description := model.NormalizeDescription(payload.Issue.HTMLURL)
+			if err := violationError(append(h.cfg.TextLimits.CheckTitle(title), h.cfg.TextLimits.CheckDescription(description)...)); err != nil {
+				return nil, err
+			}
+			if err := h.checkTodoQuota(ctx, 1); err != nil {
+				return nil, h.quotaAPIError(w, err)
+			}
+
+			todo := model.NewTodo(title, description, h.clock.Now())
+			if err := h.beforeCreate(ctx, todo); err != nil {
+				return nil, err
+			}
+			if err := h.todos.CreateTodoContext(ctx, todo); err != nil {
+				return nil, storeError(err, "创建失败")
+			}
+			return todo, nil
+		})
+}
+
+// slackCommandHook 处理 Slack 斜杠命令，例如 /todo 买牛奶
+func (h *Handler) slackCommandHook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), CreateTimeout)
+	defer cancel()
+
+	if err := r.ParseForm(); err != nil {
+		h.sendError(w, apperr.CodeParseError, "解析表单失败")
+		return
+	}
+
+	// Slack 期望 200 + {"text": ...}，错误信息也要用这种格式展示给用户
+	reply := func(text string) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(map[string]string{
+			"response_type": "ephemeral",
+			"text":          text,
+		})
+	}
+
+	title := model.NormalizeTitle(r.PostForm.Get("text"))
+	if title == "" {
+		reply("用法：/todo 待办事项标题")
+		return
+	}
+	if v := h.cfg.TextLimits.CheckTitle(title); len(v) > 0 {
+		reply(v[0].Message)
+		return
+	}
+
+	if err := h.checkTodoQuota(ctx, 1); err != nil {
+		if quotaErrorCode(err) == apperr.CodeDatabaseError {
+			log.Printf("Failed to check quota: %v", err)
+			reply("创建失败，请稍后重试")
+			return
+		}
+		reply(err.Error())
+		return
+	}
+
+	todo := model.NewTodo(title, "", h.clock.Now())
+	if err := h.beforeCreate(ctx, todo); err != nil {
+		_, text := extensionText("slackCommandHook", err)
+		reply(text)
+		return
+	}
+	if err := h.todos.CreateTodoContext(ctx, todo); err != nil {
+		log.Printf("Failed to create todo from slack command: %v", err)
+		reply("创建失败，请稍后重试")
+		return
+	}
+
+	reply(fmt.Sprintf("已添加：%s", todo.Title))
+}
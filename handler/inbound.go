@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/model"
+)
+
+// InboundEmailRequest 入站邮件请求体（JSON 方式，适配 SES + Lambda 转发等场景）
+type InboundEmailRequest struct {
+	Subject   string `json:"subject" example:"Renew passport"`
+	Body      string `json:"body" example:"Appointment at 10am"`
+	Recipient string `json:"recipient" example:"todo+personal@example.com"`
+}
+
+// inboundEmailHook 将一封入站邮件转换为待办事项（/api/v1/hooks/mailgun，签名和重放已由 ReceiveHook 校验）
+// 兼容 Mailgun inbound webhook 的表单字段（subject / stripped-text / body-plain / recipient），
+// 也接受 JSON 请求体。主题映射为标题，正文映射为描述，收件地址的 plus 部分映射为项目。
+func (h *Handler) inboundEmailHook(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 10<<20) // 邮件可能带附件，限制 10MB
+	defer r.Body.Close()
+
+	h.serve(w, r, endpoint{name: "inboundEmailHook", timeout: InboundEmailTimeout, status: http.StatusCreated, message: "邮件已转换为待办事项"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			req, err := parseInboundEmail(r)
+			if err != nil {
+				return nil, apperr.New(apperr.CodeParseError, err.Error())
+			}
+
+			title := model.NormalizeTitle(req.Subject)
+			if title == "" {
+				return nil, apperr.New(apperr.CodeValidationError, "邮件主题不能为空")
+			}
+			description := model.NormalizeDescription(req.Body)
+			if err := violationError(append(h.cfg.TextLimits.CheckTitle(title), h.cfg.TextLimits.CheckDescription(description)...)); err != nil {
+				return nil, err
+			}
+
+			if err := h.checkTodoQuota(ctx, 1); err != nil {
+				return nil, h.quotaAPIError(w, err)
+			}
+
+			todo := model.NewTodo(title, description, h.clock.Now())
+			// todo+work@example.com 放入 work 项目，项目不存在时自动创建（与 Taskwarrior 导入一致）
+			if tag := plusAddressTag(req.Recipient); tag != "" {
+				project := model.Project{Name: tag}
+				if err := project.Validate(); err != nil {
+					return nil, apperr.New(apperr.CodeValidationError, err.Error())
+				}
+				projectID, err := h.repos.EnsureProjectContext(ctx, project.Name)
+				if err != nil {
+					return nil, projectStoreError(err, "创建项目失败")
+				}
+				todo.ProjectID = &projectID
+			}
+			if err := h.beforeCreate(ctx, todo); err != nil {
+				return nil, err
+			}
+
+			if err := h.todos.CreateTodoContext(ctx, todo); err != nil {
+				return nil, storeError(err, "创建失败")
+			}
+			return todo, nil
+		})
+}
+
+// parseInboundEmail 根据 Content-Type 解析入站邮件
+func parseInboundEmail(r *http.Request) (*InboundEmailRequest, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	if strings.HasPrefix(contentType, "application/json") {
+		var req InboundEmailRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, fmt.Errorf("JSON 解析失败：%w", err)
+		}
+		return &req, nil
+	}
+
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			return nil, fmt.Errorf("解析表单失败：%w", err)
+		}
+	} else if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("解析表单失败：%w", err)
+	}
+
+	// Mailgun 优先提供去掉引用和签名的 stripped-text
+	body := r.FormValue("stripped-text")
+	if body == "" {
+		body = r.FormValue("body-plain")
+	}
+
+	recipient := r.FormValue("recipient")
+	if recipient == "" {
+		recipient = r.FormValue("To")
+	}
+
+	return &InboundEmailRequest{
+		Subject:   r.FormValue("subject"),
+		Body:      body,
+		Recipient: recipient,
+	}, nil
+}
+
+// plusAddressTag 提取收件地址中的 plus 部分（项目名称），例如 todo+work@example.com -> work
+func plusAddressTag(recipient string) string {
+	if recipient == "" {
+		return ""
+	}
+
+	// 收件人可能带显示名，例如 "Todo <todo+work@example.com>"
+	if addr, err := mail.ParseAddress(recipient); err == nil {
+		recipient = addr.Address
+	}
+
+	local, _, found := strings.Cut(recipient, "@")
+	if !found {
+		return ""
+	}
+
+	_, tag, found := strings.Cut(local, "+")
+	if !found {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(tag))
+}
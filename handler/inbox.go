@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// InboxResponse 收件箱视图，分页字段与列表接口一致
+type InboxResponse struct {
+	Todos  []model.Todo `json:"todos"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// GetInbox 收件箱：快速记录下来、还没有整理的事项
+// 没有截止日期、不属于任何项目、没有关联目标的未完成事项都在收件箱中，设置截止日期、移入项目或关联目标后自动离开
+// @Summary 收件箱
+// @Description 还没有整理的未完成事项（没有截止日期、不属于任何项目、没有关联目标），最早记录的在前
+// @Tags views
+// @Produce json
+// @Param limit query int false "返回条数，默认和上限见 GET /api/v1/capabilities" default(50)
+// @Param offset query int false "偏移量" default(0)
+// @Param truncate_description query int false "描述只返回前若干个字符，被截断的条目 description_truncated 为 true"
+// @Success 200 {object} handler.Response{data=handler.InboxResponse}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/views/inbox [get]
+func (h *Handler) GetInbox(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetInbox", timeout: ListTimeout, message: "获取收件箱成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var resp InboxResponse
+			var warning string
+			resp.Limit, warning = h.pageLimit(r)
+			preview, err := descriptionPreview(r)
+			if err != nil {
+				return nil, err
+			}
+			if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+				resp.Offset = o
+			}
+
+			filter := storage.TodoFilter{InboxOnly: true, Sort: "created_at", Order: "asc", Limit: resp.Limit, Offset: resp.Offset}
+			todos, total, err := h.todos.ListTodosContext(ctx, filter)
+			if err != nil {
+				return nil, storeError(err, "查询收件箱失败")
+			}
+			if todos == nil {
+				todos = []model.Todo{}
+			}
+			for i := range todos {
+				todos[i].TruncateDescription(preview)
+			}
+			resp.Todos, resp.Total = todos, total
+			if warning != "" {
+				return result{data: resp, warning: warning}, nil
+			}
+			return resp, nil
+		})
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"log"
+	"todo-list/invite"
+)
+
+// checkInvites 截止日期、优先级或状态的修改可能需要发送或取消日历邀请，立即触发一轮检查，不必等到下次轮询
+func (h *Handler) checkInvites() {
+	if h.scheduler == nil || !h.cfg.Invites.Enabled {
+		return
+	}
+	if err := h.scheduler.RunNow(invite.TaskName); err != nil {
+		log.Printf("触发截止日期邀请检查失败: %v", err)
+	}
+}
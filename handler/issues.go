@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/issues"
+	"todo-list/model"
+)
+
+// IssueSource 一个已配置的工单来源及其导入记录
+type IssueSource struct {
+	Source  string              `json:"source"`
+	Imports []model.IssueImport `json:"imports"`
+}
+
+// SetIssueImporter 设置工单导入器（启动时调用，没有配置任何来源时不调用）
+func (h *Handler) SetIssueImporter(im *issues.Importer) {
+	h.issues = im
+}
+
+// ListIssueImports 列出已配置的工单来源和当前工作区导入过的工单
+// @Summary 工单导入记录
+// @Description 已配置的 Jira / Linear 来源，以及每个工单对应的待办事项和最近同步到的外部状态
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]handler.IssueSource}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/integrations/issues [get]
+func (h *Handler) ListIssueImports(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListIssueImports", timeout: ListTimeout, message: "获取工单导入记录成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			if h.issues == nil {
+				return nil, apperr.New(apperr.CodeNotFound, "未配置工单来源")
+			}
+
+			sources := []IssueSource{}
+			for _, name := range h.issues.Sources() {
+				imports, err := h.db.ListIssueImportsContext(ctx, name)
+				if err != nil {
+					return nil, storeError(err, "获取工单导入记录失败")
+				}
+				if imports == nil {
+					imports = []model.IssueImport{}
+				}
+				sources = append(sources, IssueSource{Source: name, Imports: imports})
+			}
+			return sources, nil
+		})
+}
+
+// SyncIssues 立即从所有来源拉取指派给自己的工单，并同步已导入工单的状态
+// 一个来源失败不影响其他来源，失败原因写在该来源结果的 error 中
+// @Summary 立即同步工单
+// @Description 导入新指派的工单，按外部状态完成或重新打开已导入的待办事项
+// @Tags integrations
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]issues.SourceResult}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/integrations/issues/sync [post]
+func (h *Handler) SyncIssues(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "SyncIssues", timeout: ImportTimeout, message: "工单同步完成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			if h.issues == nil {
+				return nil, apperr.New(apperr.CodeNotFound, "未配置工单来源")
+			}
+			return h.issues.Sync(ctx), nil
+		})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+	"todo-list/model"
+)
+
+// 任务列表默认 / 最大返回数量
+const (
+	defaultJobsLimit = 50
+	maxJobsLimit     = 500
+)
+
+// ListJobs 查看后台任务（管理接口）
+// 查询参数：status（pending / running / done / dead，默认全部）、limit
+// @Summary 后台任务列表
+// @Description 管理接口
+// @Tags admin
+// @Produce json
+// @Param status query string false "任务状态" Enums(pending,running,done,dead)
+// @Param limit query int false "返回条数（最多 500）" default(50)
+// @Success 200 {object} handler.Response{data=[]model.Job}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/jobs [get]
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListJobs", timeout: ListTimeout, message: "获取任务成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			status := r.URL.Query().Get("status")
+			switch status {
+			case "", model.JobPending, model.JobRunning, model.JobDone, model.JobDead:
+			default:
+				return nil, apperr.New(apperr.CodeInvalidParam, "status 必须是 pending、running、done 或 dead")
+			}
+
+			limit := defaultJobsLimit
+			if v := r.URL.Query().Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 || n > maxJobsLimit {
+					return nil, apperr.New(apperr.CodeInvalidParam, "limit 必须在 1 到 500 之间")
+				}
+				limit = n
+			}
+
+			jobs, err := h.db.ListJobsContext(ctx, status, limit)
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "查询任务失败")
+			}
+			return jobs, nil
+		})
+}
+
+// RequeueJob 把死信任务重新放回队列（管理接口）
+// @Summary 重新入队死信任务
+// @Description 管理接口
+// @Tags admin
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/jobs/{id}/requeue [post]
+func (h *Handler) RequeueJob(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "RequeueJob", timeout: UpdateTimeout, message: "任务已重新入队"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			job, err := h.db.RequeueJobContext(ctx, id)
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "重新入队失败")
+			}
+			if job == nil {
+				return nil, apperr.New(apperr.CodeNotFound, "任务不存在或不在死信中")
+			}
+			return job, nil
+		})
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/linkpreview"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// AddLinkRequest 添加链接请求体
+type AddLinkRequest struct {
+	URL string `json:"url" example:"https://go.dev/doc/"`
+}
+
+// AddLink 为待办事项添加链接，标题和图标在后台异步抓取
+// @Summary 添加链接
+// @Description 标题和图标在后台异步抓取；关闭链接预览（LINK_PREVIEW=false）时不抓取，状态为 skipped，返回 201
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param request body handler.AddLinkRequest true "链接地址"
+// @Success 202 {object} handler.Response{data=model.TodoLink}
+// @Success 201 {object} handler.Response{data=model.TodoLink}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/links [post]
+func (h *Handler) AddLink(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	defer r.Body.Close()
+
+	h.serve(w, r, endpoint{name: "AddLink", timeout: CreateTimeout, status: http.StatusAccepted, message: "链接已添加，正在获取标题"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			var req AddLinkRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+
+			rawURL, err := normalizeLinkURL(req.URL)
+			if err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
+
+			if _, err := h.todos.GetTodoByIDContext(ctx, todoID); err != nil {
+				return nil, storeError(err, "获取待办事项失败")
+			}
+
+			if err := h.checkLinkQuota(ctx, todoID); err != nil {
+				return nil, h.quotaAPIError(w, err)
+			}
+
+			link := &model.TodoLink{
+				TodoID:    todoID,
+				URL:       rawURL,
+				Status:    model.LinkStatusPending,
+				CreatedAt: h.clock.Now().UTC(),
+			}
+			if !h.cfg.LinkPreview {
+				link.Status = model.LinkStatusSkipped
+			}
+
+			if err := h.db.CreateLinkContext(ctx, link); err != nil {
+				return nil, storeError(err, "添加链接失败")
+			}
+
+			if !h.cfg.LinkPreview {
+				return result{data: link, status: http.StatusCreated, message: "链接已添加"}, nil
+			}
+
+			// 抓取与请求生命周期无关，使用独立的 Context
+			go h.fetchLinkMetadata(link.ID, link.URL)
+			return link, nil
+		})
+}
+
+// ListLinks 获取待办事项的链接列表
+// @Summary 链接列表
+// @Tags links
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Success 200 {object} handler.Response{data=[]model.TodoLink}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/links [get]
+func (h *Handler) ListLinks(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListLinks", timeout: ListTimeout, message: "获取链接成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			links, err := h.db.ListLinksContext(ctx, todoID)
+			if err != nil {
+				return nil, storeError(err, "查询链接失败")
+			}
+			return links, nil
+		})
+}
+
+// DeleteLink 删除待办事项的链接
+// @Summary 删除链接
+// @Tags links
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param linkId path int true "链接ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/links/{linkId} [delete]
+func (h *Handler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteLink", timeout: DeleteTimeout, message: "删除链接成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			todoID, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			linkID, err := pathID(r, "linkId")
+			if err != nil {
+				return nil, err
+			}
+
+			if err := h.db.DeleteLinkContext(ctx, todoID, linkID); err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					return nil, apperr.Wrap(err, apperr.CodeNotFound, "链接不存在")
+				}
+				return nil, storeError(err, "删除链接失败")
+			}
+			return nil, nil
+		})
+}
+
+// fetchLinkMetadata 后台抓取链接标题和图标并写回数据库
+func (h *Handler) fetchLinkMetadata(linkID int, rawURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), LinkFetchTimeout)
+	defer cancel()
+
+	status := model.LinkStatusFetched
+	meta, err := linkpreview.Fetch(ctx, h.outbound, rawURL)
+	if err != nil {
+		log.Printf("抓取链接元数据失败: link_id=%d, url=%s, error=%v", linkID, rawURL, err)
+		status = model.LinkStatusFailed
+		meta = &linkpreview.Metadata{}
+	}
+
+	if err := h.db.UpdateLinkMetadataContext(ctx, linkID, truncateRunes(meta.Title, 300), meta.FaviconURL, status); err != nil {
+		log.Printf("写入链接元数据失败: link_id=%d, error=%v", linkID, err)
+	}
+}
+
+// parsePathID 解析路径中的正整数 ID，失败时直接写入 400 响应
+func (h *Handler) parsePathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		h.sendError(w, apperr.CodeInvalidID, "无效的ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// normalizeLinkURL 校验链接，只允许 http/https
+func normalizeLinkURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("url 不能为空")
+	}
+	if len(raw) > 2048 {
+		return "", fmt.Errorf("url 过长")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("无效的 url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("只支持 http/https 链接")
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("url 缺少主机名")
+	}
+
+	return u.String(), nil
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
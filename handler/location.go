@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/storage"
+)
+
+// parseNearFilter 解析 near / radius 查询参数写入 filter，未传 near 时不过滤
+func parseNearFilter(r *http.Request, filter *storage.TodoFilter) error {
+	near := r.URL.Query().Get("near")
+	if near == "" {
+		return nil
+	}
+	point, err := parseGeoPoint(near)
+	if err != nil {
+		return apperr.New(apperr.CodeInvalidParam, err.Error())
+	}
+	filter.Near = point
+
+	if rs := r.URL.Query().Get("radius"); rs != "" {
+		radius, err := strconv.ParseFloat(rs, 64)
+		if err != nil || radius <= 0 {
+			return apperr.New(apperr.CodeInvalidParam, "radius 必须是正数（米）")
+		}
+		filter.RadiusMeters = radius
+	}
+	return nil
+}
+
+// parseGeoPoint 解析 "lat,lng" 格式的坐标
+func parseGeoPoint(s string) (*storage.GeoPoint, error) {
+	latStr, lngStr, found := strings.Cut(s, ",")
+	if !found {
+		return nil, fmt.Errorf("near 格式应为 lat,lng")
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的纬度: %q", latStr)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的经度: %q", lngStr)
+	}
+
+	if err := validateLocation(&lat, &lng, nil); err != nil {
+		return nil, err
+	}
+
+	return &storage.GeoPoint{Lat: lat, Lng: lng}, nil
+}
+
+// validateLocation 校验位置字段：经纬度必须成对出现，半径必须为正数
+func validateLocation(lat, lng, radius *float64) error {
+	if lat == nil && lng == nil {
+		if radius != nil {
+			return fmt.Errorf("设置半径前必须先设置经纬度")
+		}
+		return nil
+	}
+	if lat == nil || lng == nil {
+		return fmt.Errorf("纬度和经度必须同时提供")
+	}
+	if *lat < -90 || *lat > 90 {
+		return fmt.Errorf("纬度必须在 -90 到 90 之间")
+	}
+	if *lng < -180 || *lng > 180 {
+		return fmt.Errorf("经度必须在 -180 到 180 之间")
+	}
+	if radius != nil && *radius <= 0 {
+		return fmt.Errorf("半径必须大于 0（米）")
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"todo-list/breaker"
+)
+
+// HealthStatus 健康检查结果，数据库不可用时 status 为 degraded
+type HealthStatus struct {
+	Status    string         `json:"status" example:"ok"`
+	Timestamp time.Time      `json:"timestamp"`
+	Database  DatabaseHealth `json:"database"`
+}
+
+// DatabaseHealth 数据库连通性和连接池状态
+type DatabaseHealth struct {
+	Status string    `json:"status" example:"ok"` // ok / unavailable
+	Pool   PoolStats `json:"pool"`
+}
+
+// PoolStats 连接池状态（健康检查中返回）
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"` // 0 表示不限制
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// newPoolStats 转换 sql.DBStats
+func newPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
+
+// poolStats SQLite 连接池状态，不使用 SQLite 时（DB_DRIVER=memory）全部为 0
+func (h *Handler) poolStats() sql.DBStats {
+	if h.db == nil {
+		return sql.DBStats{}
+	}
+	return h.db.PoolStats()
+}
+
+// Metrics 以 Prometheus 文本格式输出运行指标
+// @Summary 运行指标
+// @Description Prometheus 文本格式的数据库连接池、各接口请求量和收发字节数、外部集成熔断器指标
+// @Tags health
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (h *Handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	s := h.poolStats()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writeMetric(w, "todo_db_max_open_connections", "gauge", "连接池最大打开连接数（0 表示不限制）", float64(s.MaxOpenConnections))
+	writeMetric(w, "todo_db_open_connections", "gauge", "当前打开的连接数", float64(s.OpenConnections))
+	writeMetric(w, "todo_db_in_use_connections", "gauge", "正在使用的连接数", float64(s.InUse))
+	writeMetric(w, "todo_db_idle_connections", "gauge", "空闲连接数", float64(s.Idle))
+	writeMetric(w, "todo_db_wait_count_total", "counter", "等待空闲连接的总次数", float64(s.WaitCount))
+	writeMetric(w, "todo_db_wait_duration_seconds_total", "counter", "等待空闲连接的总时长", s.WaitDuration.Seconds())
+	writeMetric(w, "todo_db_max_idle_closed_total", "counter", "因超过最大空闲连接数而关闭的连接数", float64(s.MaxIdleClosed))
+	writeMetric(w, "todo_db_max_idle_time_closed_total", "counter", "因空闲超时而关闭的连接数", float64(s.MaxIdleTimeClosed))
+	writeMetric(w, "todo_db_max_lifetime_closed_total", "counter", "因超过最长存活时间而关闭的连接数", float64(s.MaxLifetimeClosed))
+
+	var requests, requestBytes, responseBytes, maxResponseBytes, duration []labeledValue
+	for _, s := range h.traffic.Endpoints() {
+		requests = append(requests, labeledValue{s.Endpoint, float64(s.Requests)})
+		requestBytes = append(requestBytes, labeledValue{s.Endpoint, float64(s.RequestBytes)})
+		responseBytes = append(responseBytes, labeledValue{s.Endpoint, float64(s.ResponseBytes)})
+		maxResponseBytes = append(maxResponseBytes, labeledValue{s.Endpoint, float64(s.MaxResponseBytes)})
+		duration = append(duration, labeledValue{s.Endpoint, s.DurationSeconds})
+	}
+	writeLabeledMetric(w, "todo_http_requests_total", "counter", "各接口的请求数", "endpoint", requests)
+	writeLabeledMetric(w, "todo_http_request_bytes_total", "counter", "各接口收到的请求体字节数", "endpoint", requestBytes)
+	writeLabeledMetric(w, "todo_http_response_bytes_total", "counter", "各接口发出的响应体字节数", "endpoint", responseBytes)
+	writeLabeledMetric(w, "todo_http_response_bytes_max", "gauge", "各接口单次响应体的最大字节数", "endpoint", maxResponseBytes)
+	writeLabeledMetric(w, "todo_http_request_duration_seconds_total", "counter", "各接口的累计耗时", "endpoint", duration)
+
+	var state, trips, rejected []labeledValue
+	for _, s := range h.cfg.Outbound.Breakers.Statuses() {
+		state = append(state, labeledValue{s.Name, breakerStateValue[s.State]})
+		trips = append(trips, labeledValue{s.Name, float64(s.Trips)})
+		rejected = append(rejected, labeledValue{s.Name, float64(s.Rejected)})
+	}
+	writeLabeledMetric(w, "todo_breaker_state", "gauge", "熔断器状态（0 关闭，1 半开，2 打开）", "name", state)
+	writeLabeledMetric(w, "todo_breaker_trips_total", "counter", "熔断器打开的总次数", "name", trips)
+	writeLabeledMetric(w, "todo_breaker_rejected_total", "counter", "熔断期间被直接拒绝的调用数", "name", rejected)
+}
+
+// breakerStateValue 熔断器状态在指标中的取值
+var breakerStateValue = map[string]float64{
+	breaker.StateClosed:   0,
+	breaker.StateHalfOpen: 1,
+	breaker.StateOpen:     2,
+}
+
+// writeMetric 输出一个没有标签的指标
+func writeMetric(w io.Writer, name, typ, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", name, help, name, typ, name, value)
+}
+
+// labeledValue 带一个标签的指标取值
+type labeledValue struct {
+	label string
+	value float64
+}
+
+// writeLabeledMetric 输出按一个标签区分的指标
+func writeLabeledMetric(w io.Writer, name, typ, help, label string, values []labeledValue) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	for _, v := range values {
+		fmt.Fprintf(w, "%s{%s=%q} %g\n", name, label, v.label, v.value)
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/model"
+)
+
+// DefaultUserID 尚未接入认证前，所有请求都视为同一个默认用户
+const DefaultUserID = "default"
+
+// currentUserID 获取当前请求的用户 ID
+func currentUserID(r *http.Request) string {
+	return DefaultUserID
+}
+
+// GetNotificationPreferences 获取当前用户的通知偏好
+// @Summary 获取通知偏好
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} handler.Response{data=model.NotificationPreferences}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/notification-preferences [get]
+func (h *Handler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetNotificationPreferences", timeout: DefaultTimeout, message: "获取通知偏好成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			prefs, err := h.repos.GetNotificationPreferencesContext(ctx, currentUserID(r))
+			if err != nil {
+				return nil, storeError(err, "获取通知偏好失败")
+			}
+			return prefs, nil
+		})
+}
+
+// UpdateNotificationPreferences 整体替换当前用户的通知偏好
+// @Summary 更新通知偏好
+// @Description 整体替换当前用户的通知偏好；timezone 也用于解释不带时区的截止日期
+// @Description muted_projects 为项目 ID 列表，这些项目中的待办事项不再发送提醒，项目必须存在
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body model.NotificationPreferences true "通知偏好"
+// @Success 200 {object} handler.Response{data=model.NotificationPreferences}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/notification-preferences [put]
+func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+	defer r.Body.Close()
+
+	h.serve(w, r, endpoint{name: "UpdateNotificationPreferences", timeout: UpdateTimeout, message: "更新通知偏好成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			userID := currentUserID(r)
+
+			// 以默认值为底，未提交的字段保持默认
+			prefs := model.DefaultNotificationPreferences(userID)
+			if err := decodeJSON(r, prefs); err != nil {
+				return nil, err
+			}
+			prefs.UserID = userID // 不允许通过请求体修改别人的偏好
+
+			if err := prefs.Validate(); err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
+			for _, id := range prefs.MutedProjects {
+				if err := h.checkProject(ctx, id); err != nil {
+					return nil, err
+				}
+			}
+
+			if err := h.repos.SaveNotificationPreferencesContext(ctx, prefs); err != nil {
+				return nil, storeError(err, "保存通知偏好失败")
+			}
+			return prefs, nil
+		})
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+)
+
+// ListNotifications 获取当前用户的站内通知
+// 查询参数：unread=true 只返回未读，limit 默认且最多 100
+// @Summary 站内通知
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "只返回未读"
+// @Param limit query int false "返回条数（最多 100）" default(100)
+// @Success 200 {object} handler.Response{data=[]model.Notification}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/notifications [get]
+func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListNotifications", timeout: ListTimeout, message: "获取通知成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			unreadOnly := r.URL.Query().Get("unread") == "true"
+
+			limit := 0
+			if v := r.URL.Query().Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					return nil, apperr.New(apperr.CodeInvalidParam, "limit 必须是正整数")
+				}
+				limit = n
+			}
+
+			notifications, err := h.repos.ListNotificationsContext(ctx, currentUserID(r), unreadOnly, limit)
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "查询通知失败")
+			}
+			return notifications, nil
+		})
+}
+
+// GetUnreadNotificationCount 获取当前用户的未读通知数量（用于角标）
+// @Summary 未读通知数量
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} handler.Response
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/notifications/unread-count [get]
+func (h *Handler) GetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetUnreadNotificationCount", timeout: DefaultTimeout, message: "获取未读数量成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			count, err := h.repos.CountUnreadNotificationsContext(ctx, currentUserID(r))
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "查询未读数量失败")
+			}
+			return map[string]int{"unread": count}, nil
+		})
+}
+
+// MarkAllNotificationsRead 将当前用户的所有通知标记为已读
+// @Summary 全部标记已读
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} handler.Response
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/notifications/read-all [post]
+func (h *Handler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "MarkAllNotificationsRead", timeout: DefaultTimeout, message: "已全部标记为已读"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			marked, err := h.repos.MarkAllNotificationsReadContext(ctx, currentUserID(r))
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "标记已读失败")
+			}
+			return map[string]int{"marked": marked}, nil
+		})
+}
+
+// MarkNotificationRead 将一条通知标记为已读
+// @Summary 标记通知已读
+// @Tags notifications
+// @Produce json
+// @Param id path int true "通知ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/notifications/{id}/read [post]
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "MarkNotificationRead", timeout: DefaultTimeout, message: "已标记为已读"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			found, err := h.repos.MarkNotificationReadContext(ctx, currentUserID(r), id)
+			if err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "标记已读失败")
+			}
+			if !found {
+				return nil, apperr.New(apperr.CodeNotFound, "通知不存在")
+			}
+			return nil, nil
+		})
+}
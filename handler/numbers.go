@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/model"
+)
+
+// numberingScope 编号范围的名称，例如命令行的会话 ID 或保存的过滤条件名称
+var numberingScope = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// parseNumberingScope 解析 numbering 查询参数，未传时返回空字符串
+func parseNumberingScope(r *http.Request) (string, error) {
+	scope := r.URL.Query().Get("numbering")
+	if scope == "" {
+		return "", nil
+	}
+	if !numberingScope.MatchString(scope) {
+		return "", apperr.New(apperr.CodeInvalidParam, "numbering 只能包含字母、数字、_、.、-，最长 64 个字符")
+	}
+	return scope, nil
+}
+
+// assignNumbers 按列表顺序给这一页的待办事项编号（offset+1 开始）并保存，命令行之后可以用编号指代它们
+func (h *Handler) assignNumbers(ctx context.Context, scope string, offset int, todos []model.Todo) error {
+	ids := make([]int, len(todos))
+	for i := range todos {
+		todos[i].Number = offset + i + 1
+		ids[i] = todos[i].ID
+	}
+	if err := h.db.AssignNumbersContext(ctx, scope, offset+1, ids); err != nil {
+		return storeError(err, "分配编号失败")
+	}
+	return nil
+}
+
+// ResolveTodoNumbers 把列表接口分配的显示编号换成待办事项 ID
+// 命令行先用 GET /api/v1/todos?numbering={scope} 列出，再用 todoctl done 3 这样的编号操作
+// @Summary 解析显示编号
+// @Description 返回 numbering 范围内各编号对应的待办事项 ID；编号在该范围下次从第一页列出时重新分配，
+// @Description 待办事项删除后编号失效。任何一个编号不存在时返回 404，details.missing 列出这些编号
+// @Tags todos
+// @Produce json
+// @Param numbering query string true "编号范围，与列表接口的 numbering 参数相同"
+// @Param n query string true "编号，多个用逗号分隔，例如 1,3,5"
+// @Success 200 {object} handler.Response{data=[]database.TodoNumber}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/numbers [get]
+func (h *Handler) ResolveTodoNumbers(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ResolveTodoNumbers", timeout: DefaultTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			scope, err := parseNumberingScope(r)
+			if err != nil {
+				return nil, err
+			}
+			if scope == "" {
+				return nil, apperr.New(apperr.CodeInvalidParam, "缺少 numbering 参数")
+			}
+
+			raw := r.URL.Query().Get("n")
+			if raw == "" {
+				return nil, apperr.New(apperr.CodeInvalidParam, "缺少 n 参数")
+			}
+			parts := strings.Split(raw, ",")
+			if len(parts) > h.cfg.BatchMaxSize {
+				return nil, apperr.New(apperr.CodeBatchTooLarge,
+					fmt.Sprintf("一次最多解析 %d 个编号，当前: %d", h.cfg.BatchMaxSize, len(parts)))
+			}
+			numbers := make([]int, 0, len(parts))
+			for _, p := range parts {
+				n, err := strconv.Atoi(strings.TrimSpace(p))
+				if err != nil || n <= 0 {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("无效的编号: %q", p))
+				}
+				numbers = append(numbers, n)
+			}
+
+			resolved, err := h.db.ResolveNumbersContext(ctx, scope, numbers)
+			if err != nil {
+				return nil, storeError(err, "查询编号失败")
+			}
+			found := make(map[int]bool, len(resolved))
+			for _, n := range resolved {
+				found[n.Number] = true
+			}
+			var missing []int
+			for _, n := range numbers {
+				if !found[n] {
+					missing = append(missing, n)
+				}
+			}
+			if len(missing) > 0 {
+				return nil, apperr.New(apperr.CodeNotFound, "编号不存在或已失效，请重新列出").
+					WithDetails(map[string]interface{}{"missing": missing})
+			}
+			return resolved, nil
+		})
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"todo-list/apperr"
+)
+
+// PATCH 请求体的内容类型
+const (
+	mergePatchType = "application/merge-patch+json" // RFC 7396
+	jsonPatchType  = "application/json-patch+json"  // RFC 6902
+)
+
+// patchField PATCH 可以修改的字段：null 时怎样处理
+type patchField struct {
+	nullable bool              // 是否允许设为 null
+	clear    func(*todoClears) // 设为 null 的效果，nil 表示与零值相同
+	zero     string            // 设为 null 时等价的 JSON 值
+}
+
+// patchFields 字段名 -> null 的含义；title、status、priority、version 不能为 null
+var patchFields = map[string]patchField{
+	"version":     {},
+	"title":       {},
+	"status":      {},
+	"priority":    {},
+	"description": {nullable: true, zero: `""`},
+	"due_date": {nullable: true, clear: func(c *todoClears) {
+		c.DueDate = true
+	}},
+	"latitude": {nullable: true, clear: func(c *todoClears) {
+		c.Location = true
+	}},
+	"longitude": {nullable: true, clear: func(c *todoClears) {
+		c.Location = true
+	}},
+	"radius": {nullable: true, clear: func(c *todoClears) {
+		c.Radius = true
+	}},
+	"estimated_minutes": {nullable: true, zero: `0`},
+	"recurrence":        {nullable: true, zero: `""`},
+	"project_id":        {nullable: true, zero: `0`},
+	"secret_note":       {nullable: true, zero: `{"ciphertext":""}`},
+}
+
+// todoPatch 解析后的 PATCH：要设置的字段和要清除的字段
+type todoPatch struct {
+	req    UpdateTodoRequest
+	clears todoClears
+}
+
+// set 把一个字段的新值写入 patch，value 为 null 时按 patchFields 清除
+func (p *todoPatch) set(name string, value json.RawMessage) error {
+	field, ok := patchFields[name]
+	if !ok {
+		return fmt.Errorf("不支持修改字段 %s", name)
+	}
+	if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		switch {
+		case !field.nullable:
+			return fmt.Errorf("字段 %s 不能为 null", name)
+		case field.clear != nil:
+			field.clear(&p.clears)
+			return nil
+		default:
+			value = json.RawMessage(field.zero)
+		}
+	}
+
+	// 借助 UpdateTodoRequest 的 JSON 标签解析单个字段，类型错误时与 PUT 的报错一致
+	doc, err := json.Marshal(map[string]json.RawMessage{name: value})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(doc, &p.req); err != nil {
+		return fmt.Errorf("字段 %s 的值无效：%v", name, err)
+	}
+	return nil
+}
+
+// check 拒绝同时清除和设置位置
+func (p *todoPatch) check() error {
+	if p.clears.Location && (p.req.Latitude != nil || p.req.Longitude != nil || p.req.Radius != nil) {
+		return fmt.Errorf("清除位置时不能同时设置 latitude、longitude 或 radius")
+	}
+	return nil
+}
+
+// parseMergePatch 解析 RFC 7396 JSON Merge Patch：出现的字段覆盖原值，null 表示清除，没出现的字段不变
+func parseMergePatch(body []byte) (*todoPatch, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, apperr.New(apperr.CodeInvalidJSON, fmt.Sprintf("JSON解析失败: %v", err))
+	}
+	if doc == nil {
+		return nil, apperr.New(apperr.CodeInvalidJSON, "merge patch 必须是 JSON 对象")
+	}
+
+	// 按字段名处理，报错的字段与请求内容无关地稳定
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := &todoPatch{}
+	for _, name := range names {
+		if err := p.set(name, doc[name]); err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, err.Error())
+		}
+	}
+	if err := p.check(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	return p, nil
+}
+
+// jsonPatchOp RFC 6902 的一个操作
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// parseJSONPatch 解析 RFC 6902 JSON Patch，待办事项只有一层字段，支持的操作：
+// add / replace 设置字段，remove 等同于设为 null，test 只支持 /version（相当于 If-Match）
+// 操作按顺序生效：同一字段以最后一个操作为准（先 remove 再 add /due_date 结果是设置截止日期）
+func parseJSONPatch(body []byte) (*todoPatch, error) {
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, apperr.New(apperr.CodeInvalidJSON, fmt.Sprintf("JSON解析失败: %v", err))
+	}
+
+	// 每个字段最后一个操作的序号和值
+	type lastOp struct {
+		index int
+		value json.RawMessage
+	}
+	last := make(map[string]lastOp, len(ops))
+	for i, op := range ops {
+		name, ok := strings.CutPrefix(op.Path, "/")
+		if !ok || name == "" || strings.Contains(name, "/") {
+			return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("第 %d 个操作的 path 无效：%q", i+1, op.Path))
+		}
+
+		var err error
+		value := op.Value
+		switch op.Op {
+		case "add", "replace":
+			if op.Value == nil {
+				err = fmt.Errorf("缺少 value")
+			}
+		case "remove":
+			value = json.RawMessage("null")
+		case "test":
+			if name != "version" {
+				err = fmt.Errorf("test 只支持 /version")
+			}
+		default:
+			err = fmt.Errorf("不支持的操作 %q", op.Op)
+		}
+		if err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("第 %d 个操作：%v", i+1, err))
+		}
+		last[name] = lastOp{index: i, value: value}
+	}
+
+	// 按操作顺序写入，报错时仍指向原来的序号
+	names := make([]string, 0, len(last))
+	for name := range last {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return last[names[i]].index < last[names[j]].index })
+
+	p := &todoPatch{}
+	for _, name := range names {
+		if err := p.set(name, last[name].value); err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("第 %d 个操作：%v", last[name].index+1, err))
+		}
+	}
+	if err := p.check(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	return p, nil
+}
+
+// PatchTodo 部分更新待办事项
+// @Summary 部分更新待办事项
+// @Description Content-Type 为 application/merge-patch+json（或 application/json）时按 RFC 7396 处理：只修改出现的字段，
+// @Description null 表示清除（如 {"due_date": null} 去掉截止日期）；title、status、priority 不能为 null。
+// @Description Content-Type 为 application/json-patch+json 时按 RFC 6902 处理，支持 add、replace、remove 和 test /version。
+// @Description 版本号校验与 PUT 相同（version 字段或 If-Match）
+// @Tags todos
+// @Accept json,application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param todo body handler.UpdateTodoRequest true "要修改的字段"
+// @Param If-Match header string false "期望的版本号（与请求体中的 version 等价）"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 415 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 428 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id} [patch]
+func (h *Handler) PatchTodo(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "PatchTodo", timeout: UpdateTimeout, message: "更新待办事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+			if err != nil {
+				return nil, apperr.New(apperr.CodeInvalidJSON, fmt.Sprintf("读取请求体失败: %v", err))
+			}
+
+			var patch *todoPatch
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			switch mediaType {
+			case mergePatchType, "application/json", "":
+				patch, err = parseMergePatch(body)
+			case jsonPatchType:
+				patch, err = parseJSONPatch(body)
+			default:
+				return nil, apperr.New(apperr.CodeUnsupportedMedia,
+					fmt.Sprintf("PATCH 只支持 %s 和 %s", mergePatchType, jsonPatchType))
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			return h.updateTodo(ctx, w, r, id, &patch.req, patch.clears)
+		})
+}
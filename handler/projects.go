@@ -0,0 +1,406 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+	"todo-list/database"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// ProjectRequest 创建或修改项目的请求
+type ProjectRequest struct {
+	Name        string `json:"name" example:"工作"`
+	Description string `json:"description" example:"公司里的事情"`
+}
+
+// project 校验请求并转换为项目
+func (req ProjectRequest) project() (*model.Project, error) {
+	project := &model.Project{Name: req.Name, Description: req.Description}
+	if err := project.Validate(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	return project, nil
+}
+
+// projectStoreError 项目不存在时返回 404，重名时返回 409，其他错误按 storeError 处理
+func projectStoreError(err error, message string) error {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return apperr.Wrap(err, apperr.CodeNotFound, "项目不存在")
+	case errors.Is(err, database.ErrProjectExists):
+		return apperr.Wrap(err, apperr.CodeProjectExists, "项目名称已存在")
+	default:
+		return storeError(err, message)
+	}
+}
+
+// parseProjectFilter 解析 project 查询参数写入 filter：项目 ID，或 none 表示不属于任何项目
+func parseProjectFilter(r *http.Request, filter *storage.TodoFilter) error {
+	v := r.URL.Query().Get("project")
+	if v == "" {
+		return nil
+	}
+	if v == "none" {
+		none := 0
+		filter.ProjectID = &none
+		return nil
+	}
+	id, err := strconv.Atoi(v)
+	if err != nil || id <= 0 {
+		return apperr.New(apperr.CodeInvalidParam, "project 必须是项目 ID 或 none")
+	}
+	filter.ProjectID = &id
+	return nil
+}
+
+// checkProject 确认待办事项要移入的项目存在，0 表示移出项目，不需要检查
+func (h *Handler) checkProject(ctx context.Context, id int) error {
+	if id < 0 {
+		return apperr.New(apperr.CodeValidationError, "project_id 无效")
+	}
+	if id == 0 {
+		return nil
+	}
+	if err := h.repos.CheckProjectContext(ctx, id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return apperr.Wrap(err, apperr.CodeValidationError, "项目不存在")
+		}
+		return storeError(err, "查询项目失败")
+	}
+	return nil
+}
+
+// ListProjects 项目列表
+// @Summary 项目列表
+// @Description 当前工作区的所有项目及其待办事项数量，按名称排列
+// @Tags projects
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.Project}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects [get]
+func (h *Handler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListProjects", timeout: ListTimeout, message: "获取项目成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			projects, err := h.repos.ListProjectsContext(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询项目失败")
+			}
+			for i := range projects {
+				if err := h.countProjectTodos(ctx, &projects[i]); err != nil {
+					return nil, err
+				}
+			}
+			return projects, nil
+		})
+}
+
+// CreateProject 创建项目
+// @Summary 创建项目
+// @Description 名称在工作区内不区分大小写唯一
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param request body handler.ProjectRequest true "项目"
+// @Success 201 {object} handler.Response{data=model.Project}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects [post]
+func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateProject", timeout: CreateTimeout, status: http.StatusCreated, message: "项目已创建"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req ProjectRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			project, err := req.project()
+			if err != nil {
+				return nil, err
+			}
+			if err := h.repos.CreateProjectContext(ctx, project); err != nil {
+				return nil, projectStoreError(err, "创建项目失败")
+			}
+			return project, nil
+		})
+}
+
+// GetProject 查看项目
+// @Summary 查看项目
+// @Description 项目中的待办事项用 GET /api/v1/todos?project={id} 查询
+// @Tags projects
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} handler.Response{data=model.Project}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id} [get]
+func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetProject", timeout: DefaultTimeout, message: "获取项目成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			project, err := h.repos.GetProjectContext(ctx, id)
+			if err != nil {
+				return nil, projectStoreError(err, "获取项目失败")
+			}
+			if err := h.countProjectTodos(ctx, project); err != nil {
+				return nil, err
+			}
+			return project, nil
+		})
+}
+
+// UpdateProject 修改项目
+// @Summary 修改项目
+// @Description 替换名称和描述
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param request body handler.ProjectRequest true "项目"
+// @Success 200 {object} handler.Response{data=model.Project}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id} [put]
+func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateProject", timeout: UpdateTimeout, message: "项目已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req ProjectRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			project, err := req.project()
+			if err != nil {
+				return nil, err
+			}
+			project.ID = id
+			if err := h.repos.UpdateProjectContext(ctx, project); err != nil {
+				return nil, projectStoreError(err, "更新项目失败")
+			}
+			project, err = h.repos.GetProjectContext(ctx, id)
+			if err != nil {
+				return nil, projectStoreError(err, "获取项目失败")
+			}
+			if err := h.countProjectTodos(ctx, project); err != nil {
+				return nil, err
+			}
+			return project, nil
+		})
+}
+
+// DeleteProject 删除项目
+// @Summary 删除项目
+// @Description 项目中的待办事项保留，改为不属于任何项目
+// @Tags projects
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id} [delete]
+func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteProject", timeout: DeleteTimeout, message: "项目已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.repos.DeleteProjectContext(ctx, id); err != nil {
+				return nil, projectStoreError(err, "删除项目失败")
+			}
+			return nil, h.detachProjectTodos(ctx, id)
+		})
+}
+
+// countProjectTodos 待办事项和项目不在同一个存储中时，项目的待办事项数量改从待办事项的存储统计（项目所在的存储中得到的是 0）
+func (h *Handler) countProjectTodos(ctx context.Context, project *model.Project) error {
+	if h.shared {
+		return nil
+	}
+	stats, err := h.todos.GetFilteredStatsContext(ctx, storage.TodoFilter{ProjectID: &project.ID})
+	if err != nil {
+		return storeError(err, "统计项目中的待办事项失败")
+	}
+	project.TodoCount, project.OpenCount = stats.Total, stats.Total
+	for status, n := range stats.ByStatus {
+		if h.workflow.IsTerminal(status) {
+			project.OpenCount -= n
+		}
+	}
+	return nil
+}
+
+// detachProjectTodos 待办事项和项目不在同一个存储中时，删除项目后逐个把其中的待办事项移出项目
+// 在同一个存储中时由 DeleteProjectContext 一并完成
+func (h *Handler) detachProjectTodos(ctx context.Context, projectID int) error {
+	if h.shared {
+		return nil
+	}
+	filter := storage.TodoFilter{ProjectID: &projectID, SkipTotal: true}
+	for {
+		// 移出后不再符合过滤条件，每次都取第一页
+		todos, _, err := h.todos.ListTodosContext(ctx, filter)
+		if err != nil {
+			return storeError(err, "查询项目中的待办事项失败")
+		}
+		if len(todos) == 0 {
+			return nil
+		}
+		for i := range todos {
+			todos[i].ProjectID = nil
+			if err := h.todos.UpdateTodoContext(ctx, &todos[i]); err != nil {
+				return storeError(err, "移出项目中的待办事项失败")
+			}
+		}
+	}
+}
+
+// ProjectMoveRequest 把项目中的所有待办事项移到另一个项目
+type ProjectMoveRequest struct {
+	ProjectID int `json:"project_id" example:"2"` // 目标项目，0 表示移出项目
+}
+
+// ProjectTodosResult 项目级操作修改的待办事项
+type ProjectTodosResult struct {
+	Count int   `json:"count"`
+	IDs   []int `json:"ids"`
+}
+
+// ArchiveProject 归档项目
+// @Summary 归档项目
+// @Description 在一个事务中归档项目并隐藏其中的待办事项：不按项目查看时列表默认不再列出（include_archived=true 时列出），统计不受影响
+// @Description 之后移入该项目的待办事项同样隐藏，移出后恢复显示；已经归档时不做修改
+// @Tags projects
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} handler.Response{data=model.Project}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id}/archive [post]
+func (h *Handler) ArchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.setProjectArchived(w, r, true, endpoint{name: "ArchiveProject", timeout: BatchTimeout, message: "项目已归档"})
+}
+
+// UnarchiveProject 取消归档项目
+// @Summary 取消归档项目
+// @Description 在一个事务中取消归档并恢复显示项目中的待办事项；没有归档时不做修改
+// @Tags projects
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} handler.Response{data=model.Project}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id}/unarchive [post]
+func (h *Handler) UnarchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.setProjectArchived(w, r, false, endpoint{name: "UnarchiveProject", timeout: BatchTimeout, message: "项目已取消归档"})
+}
+
+// setProjectArchived 归档和取消归档的公共流程，返回修改后的项目
+func (h *Handler) setProjectArchived(w http.ResponseWriter, r *http.Request, archived bool, e endpoint) {
+	h.serve(w, r, e, func(ctx context.Context, r *http.Request) (interface{}, error) {
+		id, err := pathID(r, "id")
+		if err != nil {
+			return nil, err
+		}
+		if err := h.repos.SetProjectArchivedContext(ctx, id, archived); err != nil {
+			return nil, projectStoreError(err, "归档项目失败")
+		}
+		project, err := h.repos.GetProjectContext(ctx, id)
+		if err != nil {
+			return nil, projectStoreError(err, "获取项目失败")
+		}
+		return project, nil
+	})
+}
+
+// CompleteProjectTodos 完成项目中的所有待办事项
+// @Summary 完成项目中剩余的待办事项
+// @Description 在一个事务中把项目中所有未完成的待办事项改为 completed，之后与批量完成一样触发完成事件（自动化规则、习惯、重复规则）
+// @Tags projects
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} handler.Response{data=handler.ProjectTodosResult}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id}/complete [post]
+func (h *Handler) CompleteProjectTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CompleteProjectTodos", timeout: BatchTimeout, message: "项目中的待办事项已完成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			ids, err := h.repos.CompleteProjectTodosContext(ctx, id)
+			if err != nil {
+				return nil, projectStoreError(err, "完成项目中的待办事项失败")
+			}
+			// 没有规则或扩展关心完成事件时只生成重复待办事项的下一次
+			watched := ids
+			if !h.watchesCompletion(ctx) {
+				watched = nil
+			}
+			h.afterComplete(ctx, watched, nil)
+			return ProjectTodosResult{Count: len(ids), IDs: ids}, nil
+		})
+}
+
+// MoveProjectTodos 把项目中的所有待办事项移到另一个项目
+// @Summary 移动项目中的所有待办事项
+// @Description 在一个事务中把项目中的所有待办事项（包括已完成的）移到 project_id 指定的项目，0 表示移出项目；来源项目保留
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param request body handler.ProjectMoveRequest true "目标项目"
+// @Success 200 {object} handler.Response{data=handler.ProjectTodosResult}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/projects/{id}/move [post]
+func (h *Handler) MoveProjectTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "MoveProjectTodos", timeout: BatchTimeout, message: "项目中的待办事项已移动"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req ProjectMoveRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			if req.ProjectID < 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "project_id 无效")
+			}
+			if req.ProjectID == id {
+				return nil, apperr.New(apperr.CodeValidationError, "目标项目与来源项目相同")
+			}
+			ids, err := h.repos.MoveProjectTodosContext(ctx, id, req.ProjectID)
+			if errors.Is(err, database.ErrTargetProjectNotFound) {
+				return nil, apperr.Wrap(err, apperr.CodeValidationError, "目标项目不存在")
+			}
+			if err != nil {
+				return nil, projectStoreError(err, "移动项目中的待办事项失败")
+			}
+			return ProjectTodosResult{Count: len(ids), IDs: ids}, nil
+		})
+}
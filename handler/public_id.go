@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"todo-list/model"
+)
+
+// ResolveTodoID 允许在 /todos/{id} 路径中使用 public_id 代替整数 ID
+// 查到后把路径参数替换为整数 ID，后续处理函数不需要区分两种写法；
+// 需要放在 ResolveWorkspace 之后，只在当前工作区中查找
+func (h *Handler) ResolveTodoID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		publicID, ok := model.NormalizePublicID(r.PathValue("id"))
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		id, err := h.todos.GetTodoIDByPublicIDContext(r.Context(), publicID)
+		if err != nil {
+			h.sendAPIError(w, "ResolveTodoID", storeError(err, "查询待办事项失败"))
+			return
+		}
+		r.SetPathValue("id", strconv.Itoa(id))
+		next(w, r)
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/apperr"
+	"todo-list/storage"
+)
+
+// 配额错误
+var (
+	errQuotaExceeded      = errors.New("quota exceeded")       // 硬上限，返回 403
+	errDailyQuotaExceeded = errors.New("daily quota exceeded") // 按天重置，返回 429
+)
+
+// quotaError 带有面向用户说明的配额错误，errors.Is 可以匹配到对应的哨兵错误
+type quotaError struct {
+	kind    error
+	message string
+}
+
+func (e *quotaError) Error() string { return e.message }
+func (e *quotaError) Unwrap() error { return e.kind }
+
+// UsageItem 某项资源的用量，Limit 为 0 表示不限制
+type UsageItem struct {
+	Resource string `json:"resource"`
+	Used     int    `json:"used"`
+	Limit    int    `json:"limit"`
+}
+
+// UsageResponse 当前工作区的配额使用情况
+type UsageResponse struct {
+	Workspace string      `json:"workspace"`
+	Items     []UsageItem `json:"items"`
+}
+
+// todoLimit 当前工作区的待办事项总数上限：工作区设置优先，其次是全局配置
+func (h *Handler) todoLimit(ctx context.Context) (int, error) {
+	ws, err := h.repos.GetWorkspaceContext(ctx, storage.WorkspaceFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	if ws != nil && ws.MaxTodos > 0 {
+		return ws.MaxTodos, nil
+	}
+	return h.cfg.Quota.MaxTodos, nil
+}
+
+// startOfDay 每日配额按 UTC 自然日重置
+func startOfDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// checkTodoQuota 检查当前工作区再新建 adding 条待办事项是否超出配额
+func (h *Handler) checkTodoQuota(ctx context.Context, adding int) error {
+	limit, err := h.todoLimit(ctx)
+	if err != nil {
+		return err
+	}
+	if limit > 0 {
+		count, err := h.todos.CountTodosContext(ctx)
+		if err != nil {
+			return err
+		}
+		if count+adding > limit {
+			return &quotaError{errQuotaExceeded, fmt.Sprintf("待办事项数量已达上限（%d）", limit)}
+		}
+	}
+
+	if perDay := h.cfg.Quota.MaxTodosPerDay; perDay > 0 {
+		count, err := h.todos.CountTodosCreatedSinceContext(ctx, startOfDay(h.clock.Now()))
+		if err != nil {
+			return err
+		}
+		if count+adding > perDay {
+			return &quotaError{errDailyQuotaExceeded, fmt.Sprintf("今日新建待办事项已达上限（%d），请明天再试", perDay)}
+		}
+	}
+
+	return nil
+}
+
+// checkLinkQuota 检查待办事项的链接数量是否已达上限
+func (h *Handler) checkLinkQuota(ctx context.Context, todoID int) error {
+	limit := h.cfg.Quota.MaxLinksPerTodo
+	if limit == 0 {
+		return nil
+	}
+
+	count, err := h.db.CountLinksContext(ctx, todoID)
+	if err != nil {
+		return err
+	}
+	if count >= limit {
+		return &quotaError{errQuotaExceeded, fmt.Sprintf("每个待办事项最多 %d 个链接", limit)}
+	}
+	return nil
+}
+
+// checkWebhookQuota 检查当前工作区的 webhook 数量是否已达上限
+func (h *Handler) checkWebhookQuota(ctx context.Context) error {
+	limit := h.cfg.Quota.MaxWebhooks
+	if limit == 0 {
+		return nil
+	}
+
+	count, err := h.repos.CountWebhooksContext(ctx)
+	if err != nil {
+		return err
+	}
+	if count >= limit {
+		return &quotaError{errQuotaExceeded, fmt.Sprintf("每个工作区最多 %d 个 webhook", limit)}
+	}
+	return nil
+}
+
+// checkAttachmentQuota 检查当前工作区再保存 size 字节的附件是否超出附件总大小上限
+func (h *Handler) checkAttachmentQuota(ctx context.Context, size int64) error {
+	limit := h.cfg.Quota.MaxAttachmentBytes
+	if limit == 0 {
+		return nil
+	}
+
+	used, err := h.db.SumAttachmentBytesContext(ctx)
+	if err != nil {
+		return err
+	}
+	if used+size > limit {
+		return &quotaError{errQuotaExceeded, fmt.Sprintf("附件总大小已达上限（%d 字节，已用 %d 字节）", limit, used)}
+	}
+	return nil
+}
+
+// quotaErrorCode 将配额检查错误映射为错误码
+func quotaErrorCode(err error) apperr.Code {
+	switch {
+	case errors.Is(err, errQuotaExceeded):
+		return apperr.CodeQuotaExceeded
+	case errors.Is(err, errDailyQuotaExceeded):
+		return apperr.CodeDailyQuotaExceeded
+	default:
+		return apperr.CodeDatabaseError
+	}
+}
+
+// quotaAPIError 将配额检查错误转换为响应错误，每日配额超限时同时设置 Retry-After
+func (h *Handler) quotaAPIError(w http.ResponseWriter, err error) error {
+	code := quotaErrorCode(err)
+	if code == apperr.CodeDatabaseError {
+		return storeError(err, "检查配额失败")
+	}
+	h.setRetryAfter(w, code)
+	return apperr.Wrap(err, code, err.Error())
+}
+
+// setRetryAfter 每日配额超限时告诉客户端多久之后重试
+func (h *Handler) setRetryAfter(w http.ResponseWriter, code apperr.Code) {
+	if code != apperr.CodeDailyQuotaExceeded {
+		return
+	}
+	now := h.clock.Now()
+	reset := startOfDay(now).Add(24 * time.Hour)
+	w.Header().Set("Retry-After", strconv.Itoa(int(reset.Sub(now).Seconds())+1))
+}
+
+// GetUsage 返回当前工作区的配额使用情况
+// @Summary 配额用量
+// @Description 当前工作区的配额使用情况（也可以通过 /api/v1/workspaces/{workspace}/usage 访问）
+// @Tags usage
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.UsageResponse}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/usage [get]
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetUsage", timeout: StatsTimeout, message: "获取用量成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			usage, err := h.collectUsage(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询用量失败")
+			}
+			return usage, nil
+		})
+}
+
+// collectUsage 汇总当前工作区各项资源的用量
+func (h *Handler) collectUsage(ctx context.Context) (*UsageResponse, error) {
+	todoLimit, err := h.todoLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := h.todos.CountTodosContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today, err := h.todos.CountTodosCreatedSinceContext(ctx, startOfDay(h.clock.Now()))
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := h.repos.CountWebhooksContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 链接和附件只保存在 SQLite 中，DB_DRIVER=memory 时没有
+	var links int
+	var attachmentBytes int64
+	if h.db != nil {
+		if links, err = h.db.MaxLinksPerTodoContext(ctx); err != nil {
+			return nil, err
+		}
+		if attachmentBytes, err = h.db.SumAttachmentBytesContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return &UsageResponse{
+		Workspace: storage.WorkspaceFromContext(ctx),
+		Items: []UsageItem{
+			{Resource: "todos", Used: todos, Limit: todoLimit},
+			{Resource: "todos_today", Used: today, Limit: h.cfg.Quota.MaxTodosPerDay},
+			{Resource: "links_per_todo", Used: links, Limit: h.cfg.Quota.MaxLinksPerTodo},
+			{Resource: "webhooks", Used: webhooks, Limit: h.cfg.Quota.MaxWebhooks},
+			{Resource: "attachment_bytes", Used: int(attachmentBytes), Limit: int(h.cfg.Quota.MaxAttachmentBytes)},
+		},
+	}, nil
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+	"todo-list/ratelimit"
+)
+
+// MyUsageResponse 当前客户端的限流状态和所在工作区的配额用量
+type MyUsageResponse struct {
+	Client    string            `json:"client"`
+	RateLimit *ratelimit.Result `json:"rate_limit,omitempty"` // 未启用限流时为空
+	Quota     *UsageResponse    `json:"quota"`
+}
+
+// clientKey 限流使用的客户端标识
+// 目前没有用户体系，按来源 IP 区分；不信任 X-Forwarded-For，避免被伪造绕过
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// setRateLimitHeaders 写入 X-RateLimit-* 响应头，Reset 为 Unix 秒
+func setRateLimitHeaders(w http.ResponseWriter, res ratelimit.Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+}
+
+// RateLimit 中间件：为每个响应加上限流头，超限时返回 429（软限流模式下只记录日志）
+func (h *Handler) RateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.limiter == nil {
+			next(w, r)
+			return
+		}
+
+		key := clientKey(r)
+		res := h.limiter.Allow(key)
+		setRateLimitHeaders(w, res)
+
+		if !res.Allowed {
+			if h.cfg.RateLimitSoft {
+				log.Printf("rate limit exceeded (soft): client=%s", key)
+			} else {
+				retry := int(res.RetryAfter(h.clock.Now()).Seconds()) + 1
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				h.sendError(w, apperr.CodeRateLimited, "请求过于频繁，请稍后重试")
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+// GetMyUsage 返回当前客户端的限流状态和配额用量，方便客户端在触发 429 之前主动降速
+// @Summary 当前客户端用量
+// @Description 限流状态和配额用量
+// @Tags usage
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.MyUsageResponse}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/me/usage [get]
+func (h *Handler) GetMyUsage(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetMyUsage", timeout: StatsTimeout, message: "获取用量成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			key := clientKey(r)
+			resp := MyUsageResponse{Client: key}
+
+			if h.limiter != nil {
+				res := h.limiter.Peek(key)
+				resp.RateLimit = &res
+			}
+
+			quota, err := h.collectUsage(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询用量失败")
+			}
+			resp.Quota = quota
+			return resp, nil
+		})
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"todo-list/apperr"
+)
+
+// readOnlySafePatterns 使用 POST / DELETE 但不修改数据的路由（与 api.SetupRoutes 中注册的模式完全一致），只读模式下仍然可用
+var readOnlySafePatterns = map[string]bool{
+	// 截止日期建议只做计算
+	"POST /api/v1/todos/suggest":                        true,
+	"POST /api/todos/suggest":                           true,
+	"POST /api/v1/workspaces/{workspace}/todos/suggest": true,
+	// 摘流只改变进程内状态
+	"POST /api/v1/admin/drain":   true,
+	"DELETE /api/v1/admin/drain": true,
+}
+
+// ReadOnly 中间件：开启 READ_ONLY 时拒绝所有修改请求（GET、HEAD、OPTIONS 以外的方法），返回 503
+func (h *Handler) ReadOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.cfg.ReadOnly || !mutates(r) {
+			next(w, r)
+			return
+		}
+		h.sendError(w, apperr.CodeReadOnly, "服务处于只读模式，暂不接受修改")
+	}
+}
+
+// mutates 请求是否可能修改数据
+func mutates(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return !readOnlySafePatterns[r.Pattern]
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+	"todo-list/database"
+)
+
+// 最近访问返回条数的默认值和上限
+const (
+	defaultRecentLimit = 20
+	maxRecentLimit     = 100
+)
+
+// recordAccess 记录当前用户查看或修改了待办事项；只影响"最近访问"列表，失败时记日志，不影响请求本身
+// 只读模式下 GET 详情也不能写入，不记录；访问记录引用 SQLite 的 todos 表，待办事项在其他存储中时也不记录
+func (h *Handler) recordAccess(ctx context.Context, r *http.Request, todoID int, kind string) {
+	if h.cfg.ReadOnly || !h.sqlite {
+		return
+	}
+	if err := h.db.RecordAccessContext(ctx, currentUserID(r), todoID, kind); err != nil {
+		log.Printf("Failed to record %s access for todo %d: %v", kind, todoID, err)
+	}
+}
+
+// ListRecentTodos 最近查看或修改过的待办事项，访问记录保存在服务端，界面可以在不同设备上"回到刚才"
+// @Summary 最近访问的待办事项
+// @Description 当前用户在当前工作区最近查看（GET 详情）或创建、修改过的待办事项，最近的在前；已删除的事项不再出现
+// @Tags todos
+// @Produce json
+// @Param kind query string false "只看查看过或只看修改过的，默认两类都算" Enums(viewed,modified)
+// @Param limit query int false "最多返回条数，默认 20，最大 100"
+// @Success 200 {object} handler.Response{data=[]database.RecentTodo}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/recent [get]
+func (h *Handler) ListRecentTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListRecentTodos", timeout: ListTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			kind := query.Get("kind")
+			if kind != "" && kind != database.AccessViewed && kind != database.AccessModified {
+				return nil, apperr.New(apperr.CodeInvalidParam, "kind 只能是 viewed 或 modified")
+			}
+
+			limit := defaultRecentLimit
+			if v := query.Get("limit"); v != "" {
+				l, err := strconv.Atoi(v)
+				if err != nil || l < 1 || l > maxRecentLimit {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxRecentLimit))
+				}
+				limit = l
+			}
+
+			recent, err := h.db.ListRecentTodosContext(ctx, currentUserID(r), kind, limit)
+			if err != nil {
+				return nil, storeError(err, "查询最近访问失败")
+			}
+			return recent, nil
+		})
+}
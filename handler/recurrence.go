@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/recurrence"
+)
+
+// 预览的发生次数：默认值和上限
+const (
+	defaultPreviewCount = 5
+	maxPreviewCount     = 50
+)
+
+// RecurrencePreview 重复规则接下来的发生时间
+type RecurrencePreview struct {
+	Recurrence  string      `json:"recurrence"` // 规范化之后的 RRULE
+	Start       time.Time   `json:"start"`      // 系列的起点（DTSTART）
+	Timezone    string      `json:"timezone"`   // 按该时区展开日期
+	Occurrences []time.Time `json:"occurrences"`
+}
+
+// resolveRecurrence 校验并规范化请求中的 RRULE，空字符串表示不重复
+// 下一次由后台任务在 SQLite 的 todos 表中生成，待办事项在其他存储中时不能设置重复规则
+func (h *Handler) resolveRecurrence(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+	if err := h.requireSQLite(); err != nil {
+		return "", err
+	}
+	rule, err := recurrence.Parse(raw)
+	if err != nil {
+		return "", apperr.New(apperr.CodeValidationError, fmt.Sprintf("recurrence 无效：%v", err))
+	}
+	return rule.String(), nil
+}
+
+// setRecurrence 设置重复规则，系列从当前截止日期（没有时从创建时间）重新开始
+func setRecurrence(todo *model.Todo, rule string) {
+	todo.Recurrence = rule
+	todo.RecurrenceStart = nil
+	if rule != "" {
+		start := recurrence.Anchor(todo).UTC()
+		todo.RecurrenceStart = &start
+	}
+}
+
+// spawnRecurrences 重复待办事项完成后立即触发一轮生成，不必等到下次定时执行
+func (h *Handler) spawnRecurrences() {
+	if h.scheduler == nil || !h.sqlite {
+		return
+	}
+	if err := h.scheduler.RunNow(recurrence.TaskName); err != nil {
+		log.Printf("触发重复待办事项生成失败: %v", err)
+	}
+}
+
+// previewCount 解析 count 查询参数
+func previewCount(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("count")
+	if v == "" {
+		return defaultPreviewCount, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxPreviewCount {
+		return 0, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("count 必须是 1 到 %d 之间的整数", maxPreviewCount))
+	}
+	return n, nil
+}
+
+// preview 计算 start 开始、晚于 after 的 n 次发生时间，按 loc 展开，结果统一为 UTC
+func preview(rule *recurrence.Rule, start, after time.Time, n int, loc *time.Location) RecurrencePreview {
+	occurrences := rule.Occurrences(start.In(loc), after, n)
+	for i := range occurrences {
+		occurrences[i] = occurrences[i].UTC()
+	}
+	return RecurrencePreview{
+		Recurrence:  rule.String(),
+		Start:       start.UTC(),
+		Timezone:    loc.String(),
+		Occurrences: occurrences,
+	}
+}
+
+// GetTodoOccurrences 预览重复待办事项接下来的发生时间
+// @Summary 预览重复待办事项
+// @Description 按待办事项的重复规则列出当前这一次之后的发生时间，即依次完成时会生成的截止时间
+// @Tags todos
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param count query int false "返回的次数，默认 5，最多 50"
+// @Success 200 {object} handler.Response{data=handler.RecurrencePreview}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/occurrences [get]
+func (h *Handler) GetTodoOccurrences(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetTodoOccurrences", timeout: DefaultTimeout, message: "获取重复计划成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			n, err := previewCount(r)
+			if err != nil {
+				return nil, err
+			}
+			todo, err := h.todos.GetTodoByIDContext(ctx, id)
+			if err != nil {
+				return nil, storeError(err, "获取待办事项失败")
+			}
+			if todo.Recurrence == "" {
+				return nil, apperr.New(apperr.CodeValidationError, "该待办事项没有设置重复规则")
+			}
+			rule, err := recurrence.Parse(todo.Recurrence)
+			if err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("recurrence 无效：%v", err))
+			}
+			loc, err := h.userLocation(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+
+			start := recurrence.Anchor(todo)
+			after := start
+			if todo.DueDate != nil && todo.DueDate.After(after) {
+				after = *todo.DueDate
+			}
+			return preview(rule, start, after, n, loc), nil
+		})
+}
+
+// PreviewRecurrence 保存之前预览重复规则
+// @Summary 预览重复规则
+// @Description 按 RRULE 列出从 start 开始的发生时间，供客户端在设置重复规则时展示
+// @Tags todos
+// @Produce json
+// @Param rrule query string true "RRULE，例如 FREQ=WEEKLY;BYDAY=MO,WE（分号需要编码为 %3B）"
+// @Param start query string false "系列的起点，格式同 due_date，默认当前时间"
+// @Param count query int false "返回的次数，默认 5，最多 50"
+// @Success 200 {object} handler.Response{data=handler.RecurrencePreview}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/recurrence/preview [get]
+func (h *Handler) PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "PreviewRecurrence", timeout: DefaultTimeout, message: "获取重复计划成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			raw := r.URL.Query().Get("rrule")
+			if strings.TrimSpace(raw) == "" {
+				return nil, apperr.New(apperr.CodeInvalidParam, "缺少 rrule 参数")
+			}
+			rule, err := recurrence.Parse(raw)
+			if err != nil {
+				return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("rrule 无效：%v", err))
+			}
+			n, err := previewCount(r)
+			if err != nil {
+				return nil, err
+			}
+			loc, err := h.userLocation(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+
+			start := h.clock.Now().Truncate(time.Second)
+			if v := r.URL.Query().Get("start"); v != "" {
+				startLoc := loc
+				if model.HasZoneOffset(v) {
+					startLoc = time.UTC
+				}
+				if start, err = model.ParseDueDate(v, startLoc); err != nil {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("start 无效：%v", err))
+				}
+			}
+			return preview(rule, start, start.Add(-time.Nanosecond), n, loc), nil
+		})
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+	"todo-list/database"
+	"todo-list/model"
+)
+
+// 周回顾的默认参数
+const (
+	defaultReviewStaleDays = 14
+	defaultReviewLimit     = 20
+	maxReviewLimit         = 100
+)
+
+// ReviewBucket 一组待回顾的事项，Total 为该组总数，Todos 最多 limit 条
+type ReviewBucket struct {
+	Total int          `json:"total"`
+	Todos []model.Todo `json:"todos"`
+}
+
+// ReviewResponse 周回顾：每个待办事项只出现在一组中，优先级为逾期 > 停滞 > 没有截止日期
+type ReviewResponse struct {
+	StaleDays int          `json:"stale_days"`
+	Overdue   ReviewBucket `json:"overdue"`
+	Stale     ReviewBucket `json:"stale"`
+	Undated   ReviewBucket `json:"undated"`
+}
+
+// ReviewDecision 对一个待办事项的处理
+type ReviewDecision struct {
+	ID      int     `json:"id" example:"1"`
+	Action  string  `json:"action" example:"reschedule"`                   // reschedule / drop / keep
+	DueDate *string `json:"due_date,omitempty" example:"2024-06-07 09:00"` // reschedule 时必填，见 model.ParseDueDate
+}
+
+// ReviewDecisionsRequest 周回顾的处理结果
+type ReviewDecisionsRequest struct {
+	Decisions []ReviewDecision `json:"decisions"`
+}
+
+// ReviewDecisionsResponse 各种处理方式的数量
+type ReviewDecisionsResponse struct {
+	Rescheduled int `json:"rescheduled"`
+	Dropped     int `json:"dropped"`
+	Kept        int `json:"kept"`
+}
+
+// GetReview 周回顾
+// @Summary 周回顾
+// @Description 分组列出需要回顾的未完成事项：逾期、停滞（stale_days 天没有更新）、没有截止日期
+// @Description 每组最多返回 limit 条，处理完后再次请求获取下一批
+// @Tags review
+// @Produce json
+// @Param stale_days query int false "多少天没有更新算作停滞，默认 14"
+// @Param limit query int false "每组最多返回的数量，默认 20，最大 100"
+// @Success 200 {object} handler.Response{data=handler.ReviewResponse}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/review [get]
+func (h *Handler) GetReview(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetReview", timeout: ListTimeout, message: "获取回顾事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			staleDays := defaultReviewStaleDays
+			if v := r.URL.Query().Get("stale_days"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > maxStaleDays {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("stale_days 必须在 1 到 %d 之间", maxStaleDays))
+				}
+				staleDays = n
+			}
+			limit := defaultReviewLimit
+			if v := r.URL.Query().Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > maxReviewLimit {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxReviewLimit))
+				}
+				limit = n
+			}
+
+			now := h.clock.Now()
+			staleBefore := now.AddDate(0, 0, -staleDays)
+			resp := ReviewResponse{StaleDays: staleDays}
+			for group, bucket := range map[string]*ReviewBucket{
+				database.ReviewOverdue: &resp.Overdue,
+				database.ReviewStale:   &resp.Stale,
+				database.ReviewUndated: &resp.Undated,
+			} {
+				todos, total, err := h.db.ListReviewTodosContext(ctx, group, now, staleBefore, limit)
+				if err != nil {
+					return nil, storeError(err, "查询回顾事项失败")
+				}
+				bucket.Total, bucket.Todos = total, todos
+			}
+			return resp, nil
+		})
+}
+
+// ApplyReviewDecisions 提交周回顾的处理结果
+// @Summary 提交周回顾的处理结果
+// @Description 在一个事务中执行：reschedule 设置新的截止日期，drop 删除，keep 保持不变（刷新更新时间，不再算作停滞）
+// @Description 任何一项无效或待办事项不存在时全部不生效
+// @Tags review
+// @Accept json
+// @Produce json
+// @Param request body handler.ReviewDecisionsRequest true "处理结果"
+// @Success 200 {object} handler.Response{data=handler.ReviewDecisionsResponse}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/review/decisions [post]
+func (h *Handler) ApplyReviewDecisions(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ApplyReviewDecisions", timeout: UpdateTimeout, message: "回顾结果已保存"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req ReviewDecisionsRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			if len(req.Decisions) == 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "decisions 不能为空")
+			}
+			if len(req.Decisions) > h.cfg.BatchMaxSize {
+				return nil, apperr.New(apperr.CodeBatchTooLarge,
+					fmt.Sprintf("批量操作最多支持 %d 个 ID，当前: %d", h.cfg.BatchMaxSize, len(req.Decisions))).
+					WithDetails(map[string]interface{}{"max_batch_size": h.cfg.BatchMaxSize, "requested": len(req.Decisions)})
+			}
+
+			var resp ReviewDecisionsResponse
+			decisions := make([]database.ReviewDecision, 0, len(req.Decisions))
+			seen := make(map[int]bool, len(req.Decisions))
+			for i, d := range req.Decisions {
+				if d.ID <= 0 {
+					return nil, apperr.New(apperr.CodeInvalidID, fmt.Sprintf("第 %d 项：无效的ID", i+1))
+				}
+				if seen[d.ID] {
+					return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("待办事项 %d 重复出现", d.ID))
+				}
+				seen[d.ID] = true
+
+				decision := database.ReviewDecision{TodoID: d.ID, Action: d.Action}
+				switch d.Action {
+				case database.ReviewReschedule:
+					due, violations, err := h.resolveDueDate(ctx, r, d.DueDate)
+					if err != nil {
+						return nil, err
+					}
+					if err := violationError(violations); err != nil {
+						return nil, err
+					}
+					if due == nil {
+						return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("第 %d 项：reschedule 需要 due_date", i+1))
+					}
+					decision.DueDate = due
+					resp.Rescheduled++
+				case database.ReviewDrop:
+					resp.Dropped++
+				case database.ReviewKeep:
+					resp.Kept++
+				default:
+					return nil, apperr.New(apperr.CodeValidationError,
+						fmt.Sprintf("第 %d 项：不支持的处理方式 %q，可选 reschedule、drop、keep", i+1, d.Action))
+				}
+				decisions = append(decisions, decision)
+			}
+
+			if err := h.db.ApplyReviewDecisionsContext(ctx, decisions); err != nil {
+				return nil, storeError(err, "保存回顾结果失败")
+			}
+			return resp, nil
+		})
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/scheduler"
+)
+
+// UpdateScheduleRequest 修改任务计划的请求
+type UpdateScheduleRequest struct {
+	Schedule string `json:"schedule"` // cron 表达式，例如 "0 3 * * *"、"@daily"
+}
+
+// SetScheduler 设置定时任务调度器（启动时调用）
+func (h *Handler) SetScheduler(s *scheduler.Scheduler) {
+	h.scheduler = s
+}
+
+// GetSchedule 查看所有定时任务的计划、上次和下次执行时间（管理接口）
+// @Summary 定时任务计划
+// @Description 管理接口：每个任务的计划、上次和下次执行时间
+// @Tags admin
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]scheduler.TaskStatus}
+// @Router /api/v1/admin/schedule [get]
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	tasks := []scheduler.TaskStatus{}
+	if h.scheduler != nil {
+		tasks = h.scheduler.Status()
+	}
+
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    tasks,
+		Message: "获取任务计划成功",
+	})
+}
+
+// UpdateSchedule 修改定时任务的 cron 表达式（管理接口）
+// 修改立即生效但不写回计划文件，重启后恢复为 SCHEDULE_FILE 中的配置
+// @Summary 修改定时任务计划
+// @Description 管理接口：立即生效但不写回计划文件
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "任务名称"
+// @Param request body handler.UpdateScheduleRequest true "cron 表达式"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/schedule/{name} [put]
+func (h *Handler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateSchedule", timeout: UpdateTimeout, message: "任务计划已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			if h.scheduler == nil {
+				return nil, apperr.New(apperr.CodeNotFound, "定时任务不存在")
+			}
+
+			var req UpdateScheduleRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+
+			name := r.PathValue("name")
+			if err := h.scheduler.Reschedule(name, req.Schedule); err != nil {
+				if errors.Is(err, scheduler.ErrTaskNotFound) {
+					return nil, apperr.New(apperr.CodeNotFound, "定时任务不存在")
+				}
+				return nil, apperr.New(apperr.CodeInvalidSchedule, err.Error())
+			}
+
+			for _, t := range h.scheduler.Status() {
+				if t.Name == name {
+					return t, nil
+				}
+			}
+			return nil, nil
+		})
+}
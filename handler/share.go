@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// ErrInvalidShareToken 分享令牌无效（格式错误或签名不匹配）
+var ErrInvalidShareToken = errors.New("invalid share token")
+
+// ErrShareTokenExpired 分享令牌已过期
+var ErrShareTokenExpired = errors.New("share token expired")
+
+// ShareRequest 创建分享链接请求体（可选）
+type ShareRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty" example:"72"`
+}
+
+// ShareResponse 分享链接
+type ShareResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SharedTodo 公开分享时返回的只读视图（只包含展示需要的字段）
+type SharedTodo struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CreateShareLink 生成带签名和过期时间的公开只读链接
+// 令牌是无状态的：id、过期时间和工作区编码在令牌中，用 HMAC 防篡改
+// @Summary 生成分享链接
+// @Description 带签名和过期时间的公开只读链接
+// @Tags share
+// @Accept json
+// @Produce json
+// @Param id path string true "待办事项ID或public_id"
+// @Param request body handler.ShareRequest false "有效期"
+// @Success 201 {object} handler.Response{data=handler.ShareResponse}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/{id}/share [post]
+func (h *Handler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateShareLink", timeout: DefaultTimeout, status: http.StatusCreated, message: "分享链接已生成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			var req ShareRequest
+			body := http.MaxBytesReader(w, r.Body, 4<<10)
+			if err := json.NewDecoder(body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				return nil, apperr.New(apperr.CodeInvalidJSON, fmt.Sprintf("JSON解析失败: %v", err))
+			}
+
+			ttl := h.cfg.ShareLinkTTL
+			if req.ExpiresInHours < 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "expires_in_hours 不能为负数")
+			}
+			if req.ExpiresInHours > 0 {
+				ttl = time.Duration(req.ExpiresInHours) * time.Hour
+			}
+			if ttl > h.cfg.ShareLinkMaxTTL {
+				return nil, apperr.New(apperr.CodeValidationError,
+					fmt.Sprintf("分享链接有效期最长 %d 小时", int(h.cfg.ShareLinkMaxTTL.Hours())))
+			}
+
+			// 只能分享当前工作区的待办事项，工作区写入令牌，公开访问时按它查找
+			if _, err := h.todos.GetTodoByIDContext(ctx, id); err != nil {
+				return nil, storeError(err, "获取待办事项失败")
+			}
+			expiresAt := h.clock.Now().Add(ttl).UTC().Truncate(time.Second)
+			token := h.signShareToken(id, storage.WorkspaceFromContext(ctx), expiresAt)
+
+			return ShareResponse{
+				Token:     token,
+				URL:       requestBaseURL(r) + "/share/" + token,
+				ExpiresAt: expiresAt,
+			}, nil
+		})
+}
+
+// GetSharedTodo 通过分享令牌查看待办事项（无需登录）
+// 默认返回 JSON；浏览器访问（Accept: text/html）或 ?format=html 时返回简单的 HTML 页面
+// @Summary 查看分享的待办事项
+// @Description 无需登录；浏览器访问（Accept: text/html）或 format=html 时返回 HTML 页面
+// @Tags share
+// @Produce json,html
+// @Param token path string true "分享令牌"
+// @Param format query string false "返回格式" Enums(json,html)
+// @Success 200 {object} handler.Response{data=handler.SharedTodo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 410 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /share/{token} [get]
+func (h *Handler) GetSharedTodo(w http.ResponseWriter, r *http.Request) {
+	e := endpoint{name: "GetSharedTodo", timeout: DefaultTimeout, message: "获取分享内容成功"}
+
+	// 分享页面不应被搜索引擎收录
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Cache-Control", "private, max-age=60")
+
+	if r.URL.Query().Get("format") == "html" ||
+		(r.URL.Query().Get("format") == "" && strings.Contains(r.Header.Get("Accept"), "text/html")) {
+		// HTML 页面不是 JSON 信封，出错时仍返回信封
+		ctx, cancel := context.WithTimeout(r.Context(), e.timeout)
+		defer cancel()
+		shared, err := h.sharedTodo(ctx, r.PathValue("token"))
+		if err != nil {
+			h.respond(w, e, nil, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page := sharePage{SharedTodo: shared, Done: h.workflow.IsTerminal(shared.Status)}
+		if err := sharePageTemplate.Execute(w, page); err != nil {
+			log.Printf("渲染分享页面失败: %v", err)
+		}
+		return
+	}
+
+	h.serve(w, r, e, func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return h.sharedTodo(ctx, r.PathValue("token"))
+	})
+}
+
+// sharedTodo 校验令牌，在令牌记录的工作区中查找待办事项
+func (h *Handler) sharedTodo(ctx context.Context, token string) (SharedTodo, error) {
+	id, workspace, err := h.verifyShareToken(token)
+	if errors.Is(err, ErrShareTokenExpired) {
+		return SharedTodo{}, apperr.New(apperr.CodeShareExpired, "分享链接已过期")
+	}
+	if err != nil {
+		return SharedTodo{}, apperr.New(apperr.CodeNotFound, "分享链接无效")
+	}
+
+	todo, err := h.todos.GetTodoByIDContext(storage.WithWorkspace(ctx, workspace), id)
+	if err != nil {
+		return SharedTodo{}, storeError(err, "获取待办事项失败")
+	}
+	return toSharedTodo(todo), nil
+}
+
+// toSharedTodo 转换为公开视图
+func toSharedTodo(todo *model.Todo) SharedTodo {
+	return SharedTodo{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Status:      todo.Status,
+		DueDate:     todo.DueDate,
+		CompletedAt: todo.CompletedAt,
+		UpdatedAt:   todo.UpdatedAt,
+	}
+}
+
+// signShareToken 生成令牌：base64url("id.expires.workspace") + "." + base64url(HMAC-SHA256)
+func (h *Handler) signShareToken(id int, workspace string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d.%s", id, expiresAt.Unix(), workspace)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(h.shareMAC(payload))
+}
+
+// verifyShareToken 校验令牌并返回待办事项 ID 和工作区；不带工作区的旧令牌属于默认工作区
+func (h *Handler) verifyShareToken(token string) (int, string, error) {
+	enc := base64.RawURLEncoding
+
+	payloadPart, sigPart, found := strings.Cut(token, ".")
+	if !found {
+		return 0, "", ErrInvalidShareToken
+	}
+
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return 0, "", ErrInvalidShareToken
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil {
+		return 0, "", ErrInvalidShareToken
+	}
+
+	// 使用常量时间比较，防止计时攻击
+	if !hmac.Equal(sig, h.shareMAC(string(payload))) {
+		return 0, "", ErrInvalidShareToken
+	}
+
+	parts := strings.SplitN(string(payload), ".", 3)
+	if len(parts) < 2 {
+		return 0, "", ErrInvalidShareToken
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", ErrInvalidShareToken
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidShareToken
+	}
+	workspace := model.DefaultWorkspace
+	if len(parts) == 3 && parts[2] != "" {
+		workspace = parts[2]
+	}
+
+	if h.clock.Now().Unix() > exp {
+		return 0, "", ErrShareTokenExpired
+	}
+
+	return id, workspace, nil
+}
+
+// shareMAC 计算分享令牌签名
+func (h *Handler) shareMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(h.cfg.ShareSecret))
+	mac.Write([]byte("share:" + payload))
+	return mac.Sum(nil)
+}
+
+// requestBaseURL 根据请求推断对外访问地址（支持反向代理的 X-Forwarded-* 头）
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := r.Host
+	if fwdHost := r.Header.Get("X-Forwarded-Host"); fwdHost != "" {
+		host = fwdHost
+	}
+
+	return scheme + "://" + host
+}
+
+// sharePage 分享页面的模板数据，Done 按工作流的终态判断，自定义的完成状态同样显示为已完成
+type sharePage struct {
+	SharedTodo
+	Done bool
+}
+
+// sharePageTemplate 分享页面（html/template 会自动转义用户内容）
+var sharePageTemplate = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 640px; margin: 40px auto; padding: 0 16px; }
+.card { border: 3px solid #000; box-shadow: 6px 6px 0 #000; padding: 20px; background: #FFE066; }
+.status { display: inline-block; border: 2px solid #000; padding: 2px 8px; font-weight: bold; }
+.desc { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<div class="card">
+<h1>{{.Title}}</h1>
+<p><span class="status">{{if .Done}}已完成{{else}}进行中{{end}}</span></p>
+{{if .Description}}<p class="desc">{{.Description}}</p>{{end}}
+{{if .DueDate}}<p>截止日期：{{.DueDate.Format "2006-01-02 15:04"}}</p>{{end}}
+<p><small>更新于 {{.UpdatedAt.Format "2006-01-02 15:04"}}</small></p>
+</div>
+</body>
+</html>
+`))
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// 简易接口的列表条数上限（语音助手只需要播报前几条）
+const simpleListLimit = 20
+
+// sendText 发送纯文本响应（语音助手 / 快捷指令直接朗读响应体）
+func (h *Handler) sendText(w http.ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(text))
+}
+
+// SimpleList 以纯文本返回未完成的待办事项
+// 面向 Siri 快捷指令 / Google Assistant webhook，不需要解析 JSON 信封
+// @Summary 纯文本列表
+// @Description 面向语音助手 / 快捷指令，返回未完成的待办事项
+// @Tags simple
+// @Produce plain
+// @Success 200 {string} string
+// @Router /api/v1/simple [get]
+func (h *Handler) SimpleList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ListTimeout)
+	defer cancel()
+
+	todos, total, err := h.todos.ListTodosContext(ctx, storage.TodoFilter{
+		Status: "pending",
+		Sort:   "created_at",
+		Order:  "ASC",
+		Limit:  simpleListLimit,
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("SimpleList timeout: %v", err)
+			h.sendText(w, http.StatusRequestTimeout, "查询超时，请稍后重试")
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("SimpleList canceled: %v", err)
+			return
+		}
+		log.Printf("Failed to list todos: %v", err)
+		h.sendText(w, http.StatusInternalServerError, "查询失败")
+		return
+	}
+
+	if total == 0 {
+		h.sendText(w, http.StatusOK, "没有待办事项")
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共有 %d 个待办事项", total)
+	for i, todo := range todos {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, todo.Title)
+	}
+	if total > len(todos) {
+		fmt.Fprintf(&sb, "\n……还有 %d 个", total-len(todos))
+	}
+
+	h.sendText(w, http.StatusOK, sb.String())
+}
+
+// SimpleCreate 用一句话创建待办事项
+// 标题可以放在查询参数 / 表单字段 title 中，也可以直接作为纯文本请求体
+// @Summary 纯文本创建
+// @Description 标题放在查询参数 / 表单字段 title 中，或直接作为纯文本请求体
+// @Tags simple
+// @Accept plain,x-www-form-urlencoded
+// @Produce plain
+// @Param title query string false "标题"
+// @Success 201 {string} string
+// @Router /api/v1/simple [post]
+func (h *Handler) SimpleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), CreateTimeout)
+	defer cancel()
+
+	r.Body = http.MaxBytesReader(w, r.Body, 4<<10) // 一句话足够，限制 4KB
+	defer r.Body.Close()
+
+	title, err := parseSimpleTitle(r)
+	if err != nil {
+		h.sendText(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	title = model.NormalizeTitle(title)
+	if title == "" {
+		h.sendText(w, http.StatusBadRequest, "标题不能为空")
+		return
+	}
+	if v := h.cfg.TextLimits.CheckTitle(title); len(v) > 0 {
+		h.sendText(w, apperr.CodeConstraintViolation.Status(), v[0].Message)
+		return
+	}
+
+	if err := h.checkTodoQuota(ctx, 1); err != nil {
+		code := quotaErrorCode(err)
+		if code == apperr.CodeDatabaseError {
+			log.Printf("Failed to check quota: %v", err)
+			h.sendText(w, code.Status(), "创建失败")
+			return
+		}
+		h.setRetryAfter(w, code)
+		h.sendText(w, code.Status(), err.Error())
+		return
+	}
+
+	todo := model.NewTodo(title, "", h.clock.Now())
+	if err := h.beforeCreate(ctx, todo); err != nil {
+		status, text := extensionText("SimpleCreate", err)
+		h.sendText(w, status, text)
+		return
+	}
+
+	if err := h.todos.CreateTodoContext(ctx, todo); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("SimpleCreate timeout: %v", err)
+			h.sendText(w, http.StatusRequestTimeout, "创建超时，请稍后重试")
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("SimpleCreate canceled: %v", err)
+			return
+		}
+		log.Printf("Failed to create todo: %v", err)
+		h.sendText(w, http.StatusInternalServerError, "创建失败")
+		return
+	}
+
+	h.sendText(w, http.StatusCreated, fmt.Sprintf("已添加：%s", todo.Title))
+}
+
+// parseSimpleTitle 从查询参数、表单或纯文本请求体中取出标题
+func parseSimpleTitle(r *http.Request) (string, error) {
+	if title := r.URL.Query().Get("title"); title != "" {
+		return strings.TrimSpace(title), nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取请求体失败")
+	}
+
+	// 快捷指令常用表单提交；curl -d 等工具也会默认带上表单类型，取不到 title 时按纯文本处理
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if form, err := url.ParseQuery(string(body)); err == nil && form.Get("title") != "" {
+			return strings.TrimSpace(form.Get("title")), nil
+		}
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"todo-list/apperr"
+)
+
+// RequireSQLiteTodos 中间件：待办事项不在 SQLite 中时（DB_DRIVER=postgres 或 memory）返回 404 FEATURE_DISABLED
+//
+// storage.TodoRepository 只包含待办事项本身的读写，项目、评论、变更事件等见 storage.Repositories。链接、附件、编号、
+// 最近访问、目标、习惯等数据只保存在 SQLite 中，通过外键引用 todos 表；周回顾、工作量、标题建议、按条件批量操作和归档
+// 直接在 SQLite 的 todos 表上查询和修改。这些功能只能用于同样保存在 SQLite 中的待办事项，路由需要挂上这个中间件
+func (h *Handler) RequireSQLiteTodos(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.requireSQLite(); err != nil {
+			h.sendAPIError(w, "RequireSQLiteTodos", err)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireSQLite 待办事项不在 SQLite 中时返回 FEATURE_DISABLED，供只有部分参数依赖 SQLite 的接口使用
+func (h *Handler) requireSQLite() error {
+	if h.sqlite {
+		return nil
+	}
+	return apperr.New(apperr.CodeFeatureDisabled, "当前的待办事项存储（DB_DRIVER="+h.cfg.DBDriver+"）不支持该功能，只能在 sqlite 下使用")
+}
+
+// RequireDatabase 中间件：没有使用 SQLite 时（DB_DRIVER=memory）返回 404 FEATURE_DISABLED
+// 后台任务、备份和分片上传的记录只保存在 SQLite 中，与待办事项保存在哪里无关
+func (h *Handler) RequireDatabase(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.requireDatabase(); err != nil {
+			h.sendAPIError(w, "RequireDatabase", err)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireDatabase 没有使用 SQLite 时返回 FEATURE_DISABLED
+func (h *Handler) requireDatabase() error {
+	if h.db != nil {
+		return nil
+	}
+	return apperr.New(apperr.CodeFeatureDisabled, "当前的存储（DB_DRIVER="+h.cfg.DBDriver+"）不支持该功能，需要 SQLite（DB_PATH）")
+}
+
+// RequireTodoRepositories 中间件：待办事项和项目、评论、变更事件等不在同一个存储中时返回 404 FEATURE_DISABLED
+// 项目级操作、评论、变更事件流和出站 webhook 需要与待办事项在同一个实现中读写，见 storage.Repositories
+func (h *Handler) RequireTodoRepositories(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := h.requireRepositories(); err != nil {
+			h.sendAPIError(w, "RequireTodoRepositories", err)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireRepositories 待办事项和 storage.Repositories 不是同一个实现时返回 FEATURE_DISABLED
+func (h *Handler) requireRepositories() error {
+	if h.shared {
+		return nil
+	}
+	return apperr.New(apperr.CodeFeatureDisabled, "当前的待办事项存储（DB_DRIVER="+h.cfg.DBDriver+"）不支持该功能")
+}
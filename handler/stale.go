@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"todo-list/aging"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+	"todo-list/workflow"
+)
+
+// 临时查询停滞事项时 days 的上限
+const maxStaleDays = 365
+
+// StaleGroup 一条规则命中的停滞事项
+type StaleGroup struct {
+	Rule      string       `json:"rule"`
+	Status    string       `json:"status"`
+	AfterDays int          `json:"after_days"`
+	Todos     []model.Todo `json:"todos"`
+}
+
+// SetAgingRules 设置停滞事项规则（启动时调用）
+func (h *Handler) SetAgingRules(rules *aging.Rules) {
+	h.aging = rules
+}
+
+// GetStaleTodos 列出长时间没有更新的待办事项，按规则分组
+// 默认使用配置的老化规则；传 days（可选 status，默认 pending）时按临时条件查询
+// @Summary 停滞事项
+// @Description 默认按配置的老化规则分组；传 days 时按临时条件查询
+// @Tags views
+// @Produce json
+// @Param days query int false "多少天没有更新"
+// @Param status query string false "状态，默认 pending"
+// @Success 200 {object} handler.Response{data=[]handler.StaleGroup}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/views/stale [get]
+func (h *Handler) GetStaleTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetStaleTodos", timeout: ListTimeout, message: "获取停滞事项成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var rules []aging.Rule
+			if v := r.URL.Query().Get("days"); v != "" {
+				days, err := strconv.Atoi(v)
+				if err != nil || days < 1 || days > maxStaleDays {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("days 必须在 1 到 %d 之间", maxStaleDays))
+				}
+				status := r.URL.Query().Get("status")
+				if status == "" {
+					status = workflow.StatusPending
+				}
+				if !h.workflow.Has(status) {
+					return nil, apperr.New(apperr.CodeInvalidParam, "未知的状态")
+				}
+				rules = []aging.Rule{{Name: "query", Status: status, AfterDays: days}}
+			} else if h.aging != nil {
+				rules = h.aging.Rules
+			}
+
+			now := h.clock.Now()
+			groups := make([]StaleGroup, 0, len(rules))
+			for _, rule := range rules {
+				// 停滞事项按规则分组全部返回，不分页；最久未动的在前
+				before := rule.Threshold(now)
+				todos, _, err := h.todos.ListTodosContext(ctx, storage.TodoFilter{
+					Status: rule.Status, UpdatedBefore: &before,
+					Sort: "updated_at", Order: "asc", Limit: math.MaxInt32, SkipTotal: true,
+				})
+				if err != nil {
+					return nil, storeError(err, "查询失败")
+				}
+				if todos == nil {
+					todos = []model.Todo{}
+				}
+
+				groups = append(groups, StaleGroup{
+					Rule:      rule.Name,
+					Status:    rule.Status,
+					AfterDays: rule.AfterDays,
+					Todos:     todos,
+				})
+			}
+			return groups, nil
+		})
+}
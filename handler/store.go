@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"todo-list/database"
+	"todo-list/hooks"
+	"todo-list/maintenance"
+	"todo-list/model"
+	"todo-list/storage"
+	"todo-list/suggest"
+)
+
+// Store 只保存在 SQLite 中的数据：链接、附件、目标、习惯、后台任务、备份等，database.DB 实现了该接口
+// 待办事项通过 storage.TodoRepository、项目和 webhook 等通过 storage.Repositories 读写；
+// 待办事项也是同一个 database.DB 时才能使用依赖 SQLite 的功能，见 RequireSQLiteTodos。DB_DRIVER=memory 时为 nil
+type Store interface {
+	maintenance.BackupStore
+	hooks.ReplayStore
+
+	// 附件
+	CreateAttachmentContext(ctx context.Context, a *model.Attachment) error
+	DeleteAttachmentContext(ctx context.Context, todoID, id int) error
+	GetAttachmentContext(ctx context.Context, todoID, id int) (*model.Attachment, error)
+	ListAttachmentsContext(ctx context.Context, todoID int) ([]model.Attachment, error)
+
+	// 按条件批量操作
+	BatchByFilterContext(ctx context.Context, op string, f database.BatchFilter, progress func(database.BatchProgress)) (database.BatchProgress, error)
+	CountByFilterContext(ctx context.Context, op string, f database.BatchFilter) (int, error)
+
+	// 统计计数表
+	RebuildCountersContext(ctx context.Context) (map[string]map[string]int, error)
+
+	// 分享链接按 ID 读取（不限工作区）
+
+	// 目标
+	CreateGoalContext(ctx context.Context, goal *model.Goal) error
+	DeleteGoalContext(ctx context.Context, id int) error
+	GetGoalContext(ctx context.Context, id int) (*model.Goal, error)
+	LinkGoalTodosContext(ctx context.Context, goalID int, todoIDs []int) error
+	ListGoalTodosContext(ctx context.Context, goalID int) ([]model.Todo, error)
+	ListGoalsContext(ctx context.Context) ([]model.Goal, error)
+	UnlinkGoalTodoContext(ctx context.Context, goalID, todoID int) error
+	UpdateGoalContext(ctx context.Context, goal *model.Goal) error
+
+	// 习惯
+	CompleteHabitTodoContext(ctx context.Context, todoID int, at time.Time) error
+	CreateHabitContext(ctx context.Context, habit *model.Habit) error
+	DeleteHabitContext(ctx context.Context, id int) error
+	GetHabitContext(ctx context.Context, id int) (*model.Habit, error)
+	ListHabitsContext(ctx context.Context) ([]model.Habit, error)
+	UpdateHabitContext(ctx context.Context, habit *model.Habit) error
+
+	// 工单导入
+	ListIssueImportsContext(ctx context.Context, source string) ([]model.IssueImport, error)
+
+	// 后台任务
+	GetWorkspaceJobContext(ctx context.Context, id int) (*model.Job, error)
+	ListJobsContext(ctx context.Context, status string, limit int) ([]model.Job, error)
+	RequeueJobContext(ctx context.Context, id int) (*model.Job, error)
+
+	// 链接
+	CreateLinkContext(ctx context.Context, link *model.TodoLink) error
+	DeleteLinkContext(ctx context.Context, todoID, linkID int) error
+	ListLinksContext(ctx context.Context, todoID int) ([]model.TodoLink, error)
+	UpdateLinkMetadataContext(ctx context.Context, id int, title, faviconURL, status string) error
+
+	// 编号
+	AssignNumbersContext(ctx context.Context, scope string, first int, todoIDs []int) error
+	ResolveNumbersContext(ctx context.Context, scope string, numbers []int) ([]database.TodoNumber, error)
+
+	// 连接池
+	PoolStats() sql.DBStats
+
+	// 拖放排序和移动
+	BatchMoveTodosContext(ctx context.Context, ids []int, target int, index *int) error
+
+	// 最近访问
+	ListRecentTodosContext(ctx context.Context, userID, kind string, limit int) ([]database.RecentTodo, error)
+	RecordAccessContext(ctx context.Context, userID string, todoID int, kind string) error
+
+	// 周回顾
+	ApplyReviewDecisionsContext(ctx context.Context, decisions []database.ReviewDecision) error
+	ListReviewTodosContext(ctx context.Context, group string, now, staleBefore time.Time, limit int) ([]model.Todo, int, error)
+
+	// 标题建议
+	ListTitleCandidatesContext(ctx context.Context, includeCompleted bool) ([]suggest.Candidate, error)
+	SuggestTitlesContext(ctx context.Context, prefix string, limit int) ([]database.TitleSuggestion, error)
+
+	// 完成耗时
+	GetCompletionLatencyContext(ctx context.Context) (*database.CompletionLatency, error)
+
+	// 分片上传
+	CreateUploadContext(ctx context.Context, u *model.Upload) error
+	DeleteExpiredUploadsContext(ctx context.Context, before time.Time) ([]string, error)
+	DeleteUploadContext(ctx context.Context, id string) error
+	GetUploadContext(ctx context.Context, id string) (*model.Upload, error)
+	SetUploadOffsetContext(ctx context.Context, id string, offset int64) error
+
+	// 用量
+	CountLinksContext(ctx context.Context, todoID int) (int, error)
+	MaxLinksPerTodoContext(ctx context.Context) (int, error)
+	SumAttachmentBytesContext(ctx context.Context) (int64, error)
+
+	// 工作量
+	OverdueWorkloadContext(ctx context.Context, before time.Time) (minutes, todos int, err error)
+	WorkloadContext(ctx context.Context, from time.Time, days int) ([]database.WorkloadDay, error)
+}
+
+// sameStore 待办事项和其他数据保存在同一个存储中（都是同一个 database.DB）
+func sameStore(todos storage.TodoRepository, db Store) bool {
+	if db == nil {
+		return false
+	}
+	other, ok := db.(storage.TodoRepository)
+	return ok && other == todos
+}
+
+// sameRepositories 待办事项和项目、评论、变更事件等保存在同一个实现中
+func sameRepositories(todos storage.TodoRepository, repos storage.Repositories) bool {
+	other, ok := repos.(storage.TodoRepository)
+	return ok && other == todos
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+	"todo-list/suggest"
+)
+
+// SuggestRequest 截止日期 / 优先级推荐请求
+type SuggestRequest struct {
+	Title       string `json:"title" example:"Submit report by Friday, urgent"`
+	Description string `json:"description" example:""`
+	Timezone    string `json:"timezone,omitempty" example:"Asia/Shanghai"` // 默认 UTC
+}
+
+// SuggestDueDate 根据标题 / 描述和历史完成情况推荐截止日期和优先级（不会创建待办事项）
+// @Summary 推荐截止日期
+// @Description 根据标题、描述和历史完成情况推荐截止日期和优先级，不会创建待办事项
+// @Tags todos
+// @Accept json
+// @Produce json
+// @Param request body handler.SuggestRequest true "待办事项内容"
+// @Success 200 {object} handler.Response{data=suggest.Suggestion}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/suggest [post]
+func (h *Handler) SuggestDueDate(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	defer r.Body.Close()
+
+	h.serve(w, r, endpoint{name: "SuggestDueDate", timeout: StatsTimeout, message: "推荐成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req SuggestRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(req.Title) == "" {
+				return nil, apperr.New(apperr.CodeValidationError, "标题不能为空")
+			}
+
+			loc := time.UTC
+			if req.Timezone != "" {
+				var err error
+				if loc, err = time.LoadLocation(req.Timezone); err != nil {
+					return nil, apperr.New(apperr.CodeValidationError, "无效的时区")
+				}
+			}
+
+			latency, err := h.completionLatency(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询历史数据失败")
+			}
+
+			return suggest.Suggest(
+				suggest.Input{Title: req.Title, Description: req.Description, Location: loc},
+				suggest.History{
+					CompletedCount:     latency.Overall.Count,
+					MedianLatencyHours: latency.Overall.MedianHours,
+				},
+				h.clock.Now(),
+			), nil
+		})
+}
+
+// completionLatency 当前工作区已完成事项的耗时：SQLite 读统计计数表，其他存储从统计结果中取
+func (h *Handler) completionLatency(ctx context.Context) (*storage.CompletionLatency, error) {
+	if h.sqlite {
+		return h.db.GetCompletionLatencyContext(ctx)
+	}
+	stats, err := h.todos.GetFilteredStatsContext(ctx, storage.TodoFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return stats.CompletionLatency, nil
+}
+
+// 查重结果条数的默认值和上限
+const (
+	defaultSimilarLimit = 5
+	maxSimilarLimit     = 20
+
+	defaultTitleSuggestLimit = 10
+	maxTitleSuggestLimit     = 20
+)
+
+// FindSimilarTodos 查找与标题相近的已有待办事项，供快速添加界面在创建前提示"已经有这一项了"
+// 标题规范化（忽略大小写、标点和多余空白）后按三元组相似度匹配
+// @Summary 查找相似的待办事项
+// @Description 标题规范化（忽略大小写、标点和多余空白）后按三元组相似度匹配，按相似度从高到低返回，不会创建待办事项
+// @Tags todos
+// @Produce json
+// @Param title query string true "待检查的标题"
+// @Param threshold query number false "最低相似度（0-1），默认 0.5"
+// @Param limit query int false "最多返回条数，默认 5，最大 20"
+// @Param include_completed query bool false "是否包含已完成的事项，默认 false"
+// @Success 200 {object} handler.Response{data=[]suggest.Match}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/similar [get]
+func (h *Handler) FindSimilarTodos(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "FindSimilarTodos", timeout: ListTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			title := strings.TrimSpace(query.Get("title"))
+			if title == "" {
+				return nil, apperr.New(apperr.CodeInvalidParam, "title 不能为空")
+			}
+
+			threshold := suggest.DefaultSimilarThreshold
+			if v := query.Get("threshold"); v != "" {
+				t, err := strconv.ParseFloat(v, 64)
+				if err != nil || t <= 0 || t > 1 {
+					return nil, apperr.New(apperr.CodeInvalidParam, "threshold 必须是大于 0、不超过 1 的数")
+				}
+				threshold = t
+			}
+
+			limit := defaultSimilarLimit
+			if v := query.Get("limit"); v != "" {
+				l, err := strconv.Atoi(v)
+				if err != nil || l < 1 || l > maxSimilarLimit {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxSimilarLimit))
+				}
+				limit = l
+			}
+
+			includeCompleted := false
+			if v := query.Get("include_completed"); v != "" {
+				include, err := strconv.ParseBool(v)
+				if err != nil {
+					return nil, apperr.New(apperr.CodeInvalidParam, "include_completed 必须是 true 或 false")
+				}
+				includeCompleted = include
+			}
+
+			candidates, err := h.db.ListTitleCandidatesContext(ctx, includeCompleted)
+			if err != nil {
+				return nil, storeError(err, "查询待办事项失败")
+			}
+			return suggest.Similar(title, candidates, threshold, limit), nil
+		})
+}
+
+// SuggestTitles 按前缀补全以前完成过的标题，快速添加界面一键重新添加"浇花"这类经常手工录入的事项
+// @Summary 补全历史标题
+// @Description 返回当前工作区中以 q 开头（不区分大小写）的已完成待办事项标题，按完成次数从多到少排列；q 为空时返回最常用的标题
+// @Tags todos
+// @Produce json
+// @Param q query string false "标题前缀"
+// @Param limit query int false "最多返回条数，默认 10，最大 20"
+// @Success 200 {object} handler.Response{data=[]database.TitleSuggestion}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/suggest [get]
+func (h *Handler) SuggestTitles(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "SuggestTitles", timeout: ListTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			query := r.URL.Query()
+			prefix := model.NormalizeTitle(query.Get("q"))
+
+			limit := defaultTitleSuggestLimit
+			if v := query.Get("limit"); v != "" {
+				l, err := strconv.Atoi(v)
+				if err != nil || l < 1 || l > maxTitleSuggestLimit {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("limit 必须在 1 到 %d 之间", maxTitleSuggestLimit))
+				}
+				limit = l
+			}
+
+			suggestions, err := h.db.SuggestTitlesContext(ctx, prefix, limit)
+			if err != nil {
+				return nil, storeError(err, "查询待办事项失败")
+			}
+			return suggestions, nil
+		})
+}
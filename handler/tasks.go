@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+	"todo-list/apperr"
+	"todo-list/database"
+	"todo-list/jobs"
+	"todo-list/maintenance"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// 通过接口提交的长时间操作（后台任务类型）
+const (
+	jobKindImportArchive = "import_archive"
+	jobKindImportTodos   = "import_todos"
+	jobKindExportArchive = "export_archive"
+	jobKindBatchByFilter = "batch_by_filter"
+	jobKindBackup        = "backup"
+)
+
+// jobSubmittedMessage 提交后台任务成功时的提示
+const jobSubmittedMessage = "任务已提交，请通过 Location 查询进度"
+
+// JobStatus 后台任务的状态，不包含提交时的参数
+type JobStatus struct {
+	ID        int         `json:"id"`
+	Kind      string      `json:"kind" example:"import_archive"`
+	Status    string      `json:"status" example:"running"` // pending / running / done / dead
+	Progress  JobProgress `json:"progress"`
+	Result    interface{} `json:"result,omitempty"`     // 执行结果，结果较大的任务改为 result_url
+	ResultURL string      `json:"result_url,omitempty"` // 下载结果的地址，只对导出等任务在完成后给出
+	Error     string      `json:"error,omitempty"`      // 失败原因（status 为 dead 时）
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// JobProgress 任务进度，total 为 0 表示总量未知
+type JobProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// batchFilterJob 按条件批量操作任务的参数
+type batchFilterJob struct {
+	Op     string               `json:"op"`
+	Filter database.BatchFilter `json:"filter"`
+}
+
+// importTodosJob 导入待办事项任务的参数：todos 为要新建的事项（已执行自动化规则和扩展钩子），
+// updates 为按 public_id 更新已有事项的内容
+type importTodosJob struct {
+	Todos   []model.Todo `json:"todos"`
+	Updates []model.Todo `json:"updates,omitempty"`
+}
+
+// SetJobQueue 设置后台任务队列，并注册可以通过接口提交的任务，必须在调度器启动前调用
+func (h *Handler) SetJobQueue(q *jobs.Queue) {
+	h.queue = q
+	q.RegisterTask(jobKindImportArchive, h.task("ImportArchive", h.runImportArchive))
+	q.RegisterTask(jobKindImportTodos, h.task("ImportTodos", h.runImportTodos))
+	q.RegisterTask(jobKindExportArchive, h.task("ExportArchive", h.runExportArchive))
+	q.RegisterTask(jobKindBatchByFilter, h.task("BatchByFilter", h.runBatchByFilter))
+	q.RegisterTask(jobKindBackup, h.task("Backup", h.runBackup))
+}
+
+// wantsAsync 请求是否要求作为后台任务执行（查询参数 async=true）
+func wantsAsync(r *http.Request) bool {
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	return async
+}
+
+// submitJob 在当前工作区提交后台任务，Location 响应头指向任务状态
+func (h *Handler) submitJob(ctx context.Context, w http.ResponseWriter, kind string, payload interface{}) (*JobStatus, error) {
+	if err := h.requireDatabase(); err != nil {
+		return nil, err
+	}
+	if h.queue == nil {
+		return nil, apperr.New(apperr.CodeInternalError, "后台任务队列未启用")
+	}
+	job, err := h.queue.Submit(ctx, kind, storage.WorkspaceFromContext(ctx), payload)
+	if err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "提交任务失败")
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	return jobStatus(job), nil
+}
+
+// task 把执行逻辑包装为队列的 TaskFunc：在提交时的工作区中执行，
+// 错误只保留错误码和提示写入任务（不暴露内部细节），完整错误记在日志中
+func (h *Handler) task(name string, fn jobs.TaskFunc) jobs.TaskFunc {
+	return func(ctx context.Context, job *model.Job) (interface{}, error) {
+		result, err := fn(storage.WithWorkspace(ctx, job.Workspace), job)
+		if err == nil {
+			return result, nil
+		}
+		log.Printf("%s job failed: job_id=%d, error=%v", name, job.ID, err)
+		var appErr *apperr.Error
+		switch {
+		case errors.As(err, &appErr):
+			return nil, apperr.New(appErr.Code, appErr.Message)
+		case errors.Is(err, context.DeadlineExceeded):
+			return nil, apperr.New(apperr.CodeTimeout, "任务执行超时")
+		default:
+			return nil, apperr.New(apperr.CodeInternalError, "任务执行失败")
+		}
+	}
+}
+
+func (h *Handler) runImportArchive(ctx context.Context, job *model.Job) (interface{}, error) {
+	var archive storage.Archive
+	if err := decodePayload(job, &archive); err != nil {
+		return nil, err
+	}
+	return h.importArchive(ctx, &archive)
+}
+
+func (h *Handler) runImportTodos(ctx context.Context, job *model.Job) (interface{}, error) {
+	var p importTodosJob
+	if len(job.Payload) > 0 && job.Payload[0] == '[' {
+		// 旧版本提交的任务只有要新建的事项
+		if err := decodePayload(job, &p.Todos); err != nil {
+			return nil, err
+		}
+	} else if err := decodePayload(job, &p); err != nil {
+		return nil, err
+	}
+	imported, updated, err := h.runImport(ctx, p.Todos, p.Updates)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"imported": imported, "updated": updated, "total": len(p.Todos) + len(p.Updates)}, nil
+}
+
+func (h *Handler) runExportArchive(ctx context.Context, job *model.Job) (interface{}, error) {
+	archive, err := h.repos.ExportArchiveContext(ctx)
+	if err != nil {
+		return nil, storeError(err, "导出归档失败")
+	}
+	return archive, nil
+}
+
+func (h *Handler) runBatchByFilter(ctx context.Context, job *model.Job) (interface{}, error) {
+	var p batchFilterJob
+	if err := decodePayload(job, &p); err != nil {
+		return nil, err
+	}
+	matched, err := h.db.CountByFilterContext(ctx, p.Op, p.Filter)
+	if err != nil {
+		return nil, storeError(err, "统计待办事项失败")
+	}
+	jobs.ReportProgress(ctx, 0, matched)
+
+	watch := p.Op == database.BatchOpComplete && h.watchesCompletion(ctx)
+	done, err := h.db.BatchByFilterContext(ctx, p.Op, p.Filter, func(progress database.BatchProgress) {
+		if watch {
+			h.afterComplete(ctx, progress.IDs, nil)
+		}
+		jobs.ReportProgress(ctx, progress.Processed, matched)
+	})
+	if err != nil {
+		return nil, storeError(err, fmt.Sprintf("批量操作失败，已处理 %d 条", done.Processed))
+	}
+	return BatchFilterResult{Matched: matched, Chunks: done.Chunks, Processed: done.Processed}, nil
+}
+
+func (h *Handler) runBackup(ctx context.Context, job *model.Job) (interface{}, error) {
+	path, err := maintenance.NewBackup(h.db, h.cfg.BackupDir, h.cfg.BackupKeep, h.clock).RunOnce(ctx)
+	if err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeStorageError, "数据库备份失败")
+	}
+	return map[string]string{"file": filepath.Base(path)}, nil
+}
+
+// decodePayload 解析任务参数
+func decodePayload(job *model.Job, v interface{}) error {
+	if err := json.Unmarshal(job.Payload, v); err != nil {
+		return apperr.Wrap(err, apperr.CodeInternalError, "任务参数无效")
+	}
+	return nil
+}
+
+// jobStatus 把任务转换为对外的状态
+// 导出的结果可能很大，完成后只给出下载地址
+func jobStatus(job *model.Job) *JobStatus {
+	status := &JobStatus{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Progress:  JobProgress{Done: job.ProgressDone, Total: job.ProgressTotal},
+		Error:     job.LastError,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+	if job.Status == model.JobDone && len(job.Result) > 0 {
+		if job.Kind == jobKindExportArchive {
+			status.ResultURL = fmt.Sprintf("/api/v1/jobs/%d/result", job.ID)
+		} else {
+			status.Result = job.Result
+		}
+	}
+	return status
+}
+
+// getWorkspaceJob 查询当前工作区的任务
+func (h *Handler) getWorkspaceJob(ctx context.Context, r *http.Request) (*model.Job, error) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	job, err := h.db.GetWorkspaceJobContext(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apperr.Wrap(err, apperr.CodeNotFound, "任务不存在")
+	}
+	if err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "查询任务失败")
+	}
+	return job, nil
+}
+
+// GetJob 查询通过接口提交的后台任务的状态、进度和结果
+// @Summary 查询后台任务
+// @Description 导入、导出、按条件批量操作传 async=true 时以及备份接口返回 202 和 Location，按该地址轮询
+// @Description 只能查到当前工作区（X-Workspace 请求头）提交的任务；导出任务完成后通过 result_url 下载结果
+// @Tags jobs
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param X-Workspace header string false "工作区标识"
+// @Success 200 {object} handler.Response{data=handler.JobStatus}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/jobs/{id} [get]
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetJob", timeout: DefaultTimeout, message: "获取任务成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			job, err := h.getWorkspaceJob(ctx, r)
+			if err != nil {
+				return nil, err
+			}
+			return jobStatus(job), nil
+		})
+}
+
+// GetJobResult 下载已完成任务的结果（例如导出的归档）
+// @Summary 下载后台任务结果
+// @Description 任务未完成时返回 409
+// @Tags jobs
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param X-Workspace header string false "工作区标识"
+// @Success 200 {object} object
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/jobs/{id}/result [get]
+func (h *Handler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), ExportTimeout)
+	defer cancel()
+
+	job, err := h.getWorkspaceJob(ctx, r)
+	if err != nil {
+		h.sendAPIError(w, "GetJobResult", err)
+		return
+	}
+	if job.Status != model.JobDone || len(job.Result) == 0 {
+		h.sendError(w, apperr.CodeJobNotFinished, "任务尚未完成或没有结果")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%d.json", job.Kind, job.ID))
+	if _, err := w.Write(job.Result); err != nil {
+		log.Printf("写入任务结果失败: %v", err)
+	}
+}
+
+// StartBackup 提交一次数据库备份（管理接口），备份完成后按 BACKUP_KEEP 清理旧备份
+// @Summary 立即备份数据库
+// @Description 管理接口：以后台任务执行，返回 202 和 Location，通过 GET /api/v1/jobs/{id} 查询结果
+// @Tags admin
+// @Produce json
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/backup [post]
+func (h *Handler) StartBackup(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "StartBackup", timeout: UpdateTimeout, status: http.StatusAccepted, message: jobSubmittedMessage},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			return h.submitJob(ctx, w, jobKindBackup, nil)
+		})
+}
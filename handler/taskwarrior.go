@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"todo-list/model"
+	"todo-list/recurrence"
+	"todo-list/taskwarrior"
+	"todo-list/workflow"
+)
+
+// 导出时写入的 UDA，导入时读回对应字段；其余 UDA 按"名称: 值"追加到描述末尾
+const (
+	udaStatus    = "todo_status" // 工作流中 pending / completed 以外的状态
+	udaEstimate  = "estimate"    // 预估耗时（分钟）
+	udaLatitude  = "latitude"
+	udaLongitude = "longitude"
+	udaRadius    = "radius"
+)
+
+// exportTaskwarrior 按 task export 的格式导出当前工作区的待办事项，评论作为注释导出
+func (h *Handler) exportTaskwarrior(ctx context.Context, w http.ResponseWriter) {
+	archive, err := h.repos.ExportArchiveContext(ctx)
+	if err != nil {
+		h.sendAPIError(w, "ExportTodos", storeError(err, "导出失败"))
+		return
+	}
+
+	projects := make(map[int]string, len(archive.Projects))
+	for _, p := range archive.Projects {
+		projects[p.ID] = p.Name
+	}
+	comments := make(map[int][]model.Comment)
+	for _, c := range archive.Comments {
+		comments[c.TodoID] = append(comments[c.TodoID], c)
+	}
+
+	tasks := make([]taskwarrior.Task, 0, len(archive.Todos))
+	for i := range archive.Todos {
+		todo := &archive.Todos[i]
+		task := h.todoToTask(todo, comments[todo.ID])
+		if todo.ProjectID != nil {
+			task.Project = projects[*todo.ProjectID]
+		}
+		tasks = append(tasks, task)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=todos.taskwarrior.json")
+	if err := json.NewEncoder(w).Encode(tasks); err != nil {
+		log.Printf("写入 taskwarrior JSON 失败: %v", err)
+	}
+}
+
+// todoToTask 把待办事项转换为 taskwarrior 任务：描述作为第一条注释，评论依次作为后面的注释
+func (h *Handler) todoToTask(todo *model.Todo, comments []model.Comment) taskwarrior.Task {
+	task := taskwarrior.Task{
+		Description: todo.Title,
+		Status:      taskwarrior.StatusPending,
+		Entry:       taskwarrior.FormatTime(&todo.CreatedAt),
+		Modified:    taskwarrior.FormatTime(&todo.UpdatedAt),
+		Due:         taskwarrior.FormatTime(todo.DueDate),
+	}
+	task.UUID, _ = model.PublicIDToUUID(todo.PublicID)
+
+	if h.workflow.IsTerminal(todo.Status) {
+		task.Status = taskwarrior.StatusCompleted
+		task.End = taskwarrior.FormatTime(todo.CompletedAt)
+	}
+	if todo.Status != workflow.StatusPending && todo.Status != workflow.StatusCompleted {
+		task.SetUDA(udaStatus, todo.Status)
+	}
+
+	// taskwarrior 只有三档，默认优先级不输出，与 taskwarrior 里没设优先级的任务对应
+	switch {
+	case todo.Priority > model.DefaultPriority:
+		task.Priority = "H"
+	case todo.Priority < model.DefaultPriority:
+		task.Priority = "L"
+	}
+
+	if todo.Description != "" {
+		task.Annotations = append(task.Annotations, taskwarrior.Annotation{
+			Entry: task.Entry, Description: todo.Description,
+		})
+	}
+	for _, c := range comments {
+		text := c.Body
+		if c.Author != "" {
+			text = c.Author + ": " + c.Body
+		}
+		task.Annotations = append(task.Annotations, taskwarrior.Annotation{
+			Entry: taskwarrior.FormatTime(&c.CreatedAt), Description: text,
+		})
+	}
+
+	if todo.Recurrence != "" {
+		if rule, err := recurrence.Parse(todo.Recurrence); err == nil {
+			if recur, ok := taskwarrior.RRuleToRecur(rule); ok && todo.DueDate != nil {
+				task.Recur = recur
+			}
+		}
+	}
+
+	if todo.EstimatedMinutes != nil {
+		task.SetUDA(udaEstimate, *todo.EstimatedMinutes)
+	}
+	if todo.Latitude != nil && todo.Longitude != nil {
+		task.SetUDA(udaLatitude, *todo.Latitude)
+		task.SetUDA(udaLongitude, *todo.Longitude)
+		if todo.Radius != nil {
+			task.SetUDA(udaRadius, *todo.Radius)
+		}
+	}
+	return task
+}
+
+// parseTaskwarrior 解析 task export 的输出并转换为待办事项
+// 已删除的任务不导入；重复任务只导入每个系列中截止时间最晚的未完成实例，并带上重复规则，
+// 没有未完成实例的系列导入模板本身
+func (h *Handler) parseTaskwarrior(ctx context.Context, r io.Reader) ([]model.Todo, error) {
+	tasks, err := taskwarrior.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个重复系列中保留重复规则的实例（截止时间最晚的未完成实例）
+	templates := make(map[string]*taskwarrior.Task)
+	latest := make(map[string]int)
+	for i := range tasks {
+		task := &tasks[i]
+		switch {
+		case task.Status == taskwarrior.StatusRecurring:
+			templates[task.UUID] = task
+		case task.Parent != "" && task.Recur != "" && isOpenTask(task):
+			if j, ok := latest[task.Parent]; !ok || tasks[j].Due < task.Due {
+				latest[task.Parent] = i
+			}
+		}
+	}
+
+	todos := make([]model.Todo, 0, len(tasks))
+	for i := range tasks {
+		task := &tasks[i]
+		var recur, start string
+		switch {
+		case task.Status == taskwarrior.StatusDeleted:
+			continue
+		case task.Status == taskwarrior.StatusRecurring:
+			if _, ok := latest[task.UUID]; ok {
+				continue
+			}
+			recur, start = task.Recur, task.Due
+		case task.Parent != "":
+			if j, ok := latest[task.Parent]; ok && j == i {
+				recur, start = task.Recur, task.Due
+				if tpl := templates[task.Parent]; tpl != nil && tpl.Due != "" {
+					start = tpl.Due
+				}
+			}
+		}
+
+		todo, err := h.taskToTodo(ctx, task)
+		if err != nil {
+			return nil, fmt.Errorf("任务 %s：%w", task.UUID, err)
+		}
+		if recur != "" && todo.Status == workflow.StatusPending {
+			if rule, ok := taskwarrior.RecurToRRule(recur); ok {
+				todo.Recurrence = rule
+				todo.RecurrenceStart = todo.DueDate
+				if t, err := taskwarrior.ParseTime(start); err == nil && t != nil {
+					todo.RecurrenceStart = t
+				}
+			} else {
+				todo.Description = appendLine(todo.Description, "recur: "+recur)
+			}
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// isOpenTask 任务是否还没有完成
+func isOpenTask(task *taskwarrior.Task) bool {
+	return task.Status == taskwarrior.StatusPending || task.Status == taskwarrior.StatusWaiting
+}
+
+// taskToTodo 把一个 taskwarrior 任务转换为待办事项
+// 注释合并为描述；标签和不认识的 UDA 按行追加在描述末尾，项目不存在时按名称创建
+func (h *Handler) taskToTodo(ctx context.Context, task *taskwarrior.Task) (model.Todo, error) {
+	todo := model.Todo{
+		Title:    task.Description,
+		Status:   workflow.StatusPending,
+		Priority: h.taskPriority(task.Priority),
+	}
+	publicID, ok := model.PublicIDFromUUID(task.UUID)
+	if !ok {
+		return todo, fmt.Errorf("无效的 uuid %q", task.UUID)
+	}
+	todo.PublicID = publicID
+
+	if task.Status == taskwarrior.StatusCompleted {
+		todo.Status = workflow.StatusCompleted
+	}
+	var custom string
+	if task.TakeUDA(udaStatus, &custom) && h.workflow.Has(custom) &&
+		h.workflow.IsTerminal(custom) == (todo.Status == workflow.StatusCompleted) {
+		todo.Status = custom
+	}
+
+	var err error
+	if todo.DueDate, err = taskwarrior.ParseTime(task.Due); err != nil {
+		return todo, err
+	}
+	entry, err := taskwarrior.ParseTime(task.Entry)
+	if err != nil {
+		return todo, err
+	}
+	if entry != nil {
+		todo.CreatedAt = *entry
+	}
+	if h.workflow.IsTerminal(todo.Status) {
+		end, err := taskwarrior.ParseTime(task.End)
+		if err != nil {
+			return todo, err
+		}
+		if end == nil {
+			now := h.clock.Now().UTC()
+			end = &now
+		}
+		todo.CompletedAt = end
+	}
+
+	if task.Project != "" {
+		project := model.Project{Name: task.Project}
+		if err := project.Validate(); err != nil {
+			return todo, err
+		}
+		id, err := h.repos.EnsureProjectContext(ctx, project.Name)
+		if err != nil {
+			return todo, fmt.Errorf("创建项目 %q 失败：%w", project.Name, err)
+		}
+		todo.ProjectID = &id
+	}
+
+	var minutes int
+	if task.TakeUDA(udaEstimate, &minutes) && minutes > 0 {
+		todo.EstimatedMinutes = &minutes
+	}
+	var lat, lng, radius float64
+	if task.TakeUDA(udaLatitude, &lat) && task.TakeUDA(udaLongitude, &lng) {
+		var radiusPtr *float64
+		if task.TakeUDA(udaRadius, &radius) {
+			radiusPtr = &radius
+		}
+		if validateLocation(&lat, &lng, radiusPtr) == nil {
+			todo.Latitude, todo.Longitude, todo.Radius = &lat, &lng, radiusPtr
+		}
+	}
+
+	for _, a := range task.Annotations {
+		todo.Description = appendLine(todo.Description, a.Description)
+	}
+	if len(task.Tags) > 0 {
+		todo.Description = appendLine(todo.Description, "tags: +"+strings.Join(task.Tags, " +"))
+	}
+	names := make([]string, 0, len(task.UDA))
+	for name := range task.UDA {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		todo.Description = appendLine(todo.Description, name+": "+udaText(task.UDA[name]))
+	}
+	return todo, nil
+}
+
+// taskPriority 把 H / M / L 转换为优先级数值：H 对应 high（没有时取最高一档），L 对应 low（没有时取最低一档）
+func (h *Handler) taskPriority(p string) int {
+	scale := h.cfg.PriorityScale
+	switch strings.ToUpper(p) {
+	case "H":
+		if v, ok := scale.Value("high"); ok {
+			return v
+		}
+		if len(scale) > 0 {
+			return scale[len(scale)-1].Value
+		}
+	case "L":
+		if v, ok := scale.Value("low"); ok {
+			return v
+		}
+		if len(scale) > 0 {
+			return scale[0].Value
+		}
+	}
+	return model.DefaultPriority
+}
+
+// appendLine 在文本末尾追加一行
+func appendLine(text, line string) string {
+	if text == "" {
+		return line
+	}
+	return text + "\n" + line
+}
+
+// udaText UDA 的值：字符串取原文，其余按 JSON 输出
+func udaText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
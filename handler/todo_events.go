@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/eventbus"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// 变更事件流的轮询间隔、有事件通知时的兜底轮询间隔、心跳间隔、每次读取的事件数和建议客户端重连的等待时间
+// 事件由数据库触发器记录，流从数据库读取，多实例部署时任意实例上的修改都能收到；
+// 设置了事件通知（见 SetEventBus）时收到通知立即读取，只偶尔轮询兜底
+const (
+	eventPollInterval     = time.Second
+	eventFallbackInterval = 5 * time.Second
+	eventHeartbeat        = 15 * time.Second
+	eventBatchSize        = 100
+	eventRetry            = 3 * time.Second
+)
+
+// SetEventBus 设置新变更事件的通知，事件流收到通知后立即推送，不必等到下一次轮询
+func (h *Handler) SetEventBus(bus *eventbus.Bus) {
+	h.events = bus
+}
+
+// StreamTodoEvents 以 Server-Sent Events 推送当前工作区待办事项的变更，可以代替轮询列表
+// @Summary 待办事项变更事件流
+// @Description 长连接，按 text/event-stream 格式推送 created、updated、completed、deleted 事件，data 为 model.TodoEvent（deleted 事件不带 todo）。
+// @Description 每条事件带 id，断线重连时浏览器 EventSource 会自动带上 Last-Event-ID，服务端从该事件之后补发；
+// @Description 中间的事件已被清理、无法补发时先推送一条 reset 事件，客户端应重新拉取列表。不带 Last-Event-ID 时只推送连接之后的变更。
+// @Description 服务端每 15 秒发送一次注释行作为心跳；实例摘流时断开连接，客户端按 retry 重连。
+// @Description 可以用查询参数过滤事件，在服务端判断，同时满足才推送；项目和优先级按变更后的值判断（deleted 按删除前的值）。
+// @Tags todos
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "上次收到的事件 ID"
+// @Param last_event_id query int false "同 Last-Event-ID，供不能设置请求头的客户端使用"
+// @Param events query string false "只推送这些类型的事件，逗号分隔，例如 created,completed"
+// @Param project query string false "只推送该项目的事件：项目 ID，或 none 表示不属于任何项目的"
+// @Param min_priority query string false "只推送优先级不低于该值的事件，数值或名称"
+// @Success 200 {object} model.TodoEvent
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/events [get]
+func (h *Handler) StreamTodoEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	lastID, err := lastEventID(r)
+	if err != nil {
+		h.sendAPIError(w, "StreamTodoEvents", err)
+		return
+	}
+	filter, err := h.parseEventFilter(r)
+	if err != nil {
+		h.sendAPIError(w, "StreamTodoEvents", err)
+		return
+	}
+	// 在读取事件范围之前订阅，之后提交的事件一定会唤醒这个连接
+	var wake <-chan struct{}
+	pollInterval := eventPollInterval
+	if h.events != nil {
+		var cancel func()
+		wake, cancel = h.events.Subscribe()
+		defer cancel()
+		pollInterval = eventFallbackInterval
+	}
+	first, last, err := h.repos.TodoEventRangeContext(ctx)
+	if err != nil {
+		h.sendAPIError(w, "StreamTodoEvents", storeError(err, "查询变更事件失败"))
+		return
+	}
+
+	// 事件流是长连接，不受服务器 WriteTimeout 的限制
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("StreamTodoEvents: failed to clear write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry.Milliseconds())
+
+	switch {
+	case lastID < 0:
+		lastID = last
+	case lastID < first-1 || lastID > last:
+		// 要补发的事件已被清理（或 ID 来自别的数据库），只能让客户端重新拉取
+		reset := map[string]string{"message": "部分变更事件已不可用，请重新获取列表"}
+		if err := writeEvent(w, last, "reset", reset); err != nil {
+			return
+		}
+		lastID = last
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastWrite := h.clock.Now()
+	// 上一次读满了一批时不等待，接着读剩下的事件
+	more := false
+	for {
+		if !more {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+		// 摘流时主动断开，客户端会重连到其他实例
+		if h.drainStatus().Draining {
+			return
+		}
+
+		events, err := h.repos.ListTodoEventsContext(ctx, lastID, eventBatchSize)
+		more = err == nil && len(events) == eventBatchSize
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("StreamTodoEvents: %v", err)
+			}
+			continue
+		}
+		written := 0
+		for i := range events {
+			e := &events[i]
+			lastID = e.ID
+			if !filter.Matches(e) {
+				continue
+			}
+			if e.Type != model.TodoEventDeleted {
+				// 取当前内容；事件之后已被删除时不带 todo，随后会收到 deleted 事件
+				if todo, err := h.todos.GetTodoByIDContext(ctx, e.TodoID); err == nil {
+					h.presenter().Present(todo)
+					e.Todo = todo
+				}
+			}
+			if err := writeEvent(w, e.ID, e.Type, e); err != nil {
+				return
+			}
+			written++
+		}
+
+		if written == 0 {
+			if h.clock.Now().Sub(lastWrite) < eventHeartbeat {
+				continue
+			}
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		lastWrite = h.clock.Now()
+	}
+}
+
+// lastEventID 读取重连时的 Last-Event-ID（请求头优先，其次是 last_event_id 查询参数），没有时返回 -1
+func lastEventID(r *http.Request) (int64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return -1, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return 0, apperr.New(apperr.CodeInvalidParam, "Last-Event-ID 必须是非负整数")
+	}
+	return id, nil
+}
+
+// parseEventFilter 解析事件流的过滤参数：events（逗号分隔）、project（项目 ID 或 none）、min_priority（数值或名称）
+func (h *Handler) parseEventFilter(r *http.Request) (model.EventFilter, error) {
+	var filter model.EventFilter
+	query := r.URL.Query()
+	if v := query.Get("events"); v != "" {
+		filter.Events = strings.Split(v, ",")
+	}
+
+	var todoFilter storage.TodoFilter
+	if err := parseProjectFilter(r, &todoFilter); err != nil {
+		return filter, err
+	}
+	filter.ProjectID = todoFilter.ProjectID
+
+	if v := query.Get("min_priority"); v != "" {
+		value, err := model.ParsePriority(v).Resolve(h.cfg.PriorityScale)
+		if err != nil {
+			return filter, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("min_priority 无效：%v", err))
+		}
+		filter.MinPriority = &value
+	}
+
+	if err := filter.Validate(); err != nil {
+		return filter, apperr.New(apperr.CodeInvalidParam, err.Error())
+	}
+	return filter, nil
+}
+
+// writeEvent 按 text/event-stream 格式写出一条事件
+func writeEvent(w io.Writer, id int64, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo-list/apperr"
+	"todo-list/traffic"
+)
+
+// trafficWriter 记录写出的状态码和响应体字节数
+type trafficWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *trafficWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trafficWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *trafficWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingBody 记录读取的请求体字节数
+type countingBody struct {
+	io.ReadCloser
+	bytes int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	return n, err
+}
+
+// TrackTraffic 中间件：按路由统计请求和响应大小，大响应和慢请求记入 GET /api/v1/admin/traffic
+// 放在中间件链最外层，被限流、认证拒绝的请求也计入
+func (h *Handler) TrackTraffic(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := h.clock.Now()
+		tw := &trafficWriter{ResponseWriter: w}
+		body := &countingBody{ReadCloser: r.Body}
+		r.Body = body
+
+		next(tw, r)
+
+		endpoint := r.Pattern
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+		h.traffic.Record(traffic.Request{
+			Time:          start.UTC(),
+			Endpoint:      endpoint,
+			URI:           trafficURI(r),
+			Client:        clientKey(r),
+			Workspace:     r.Header.Get("X-Workspace"),
+			UserAgent:     r.UserAgent(),
+			Status:        tw.status,
+			RequestBytes:  body.bytes,
+			ResponseBytes: tw.bytes,
+		}, h.clock.Now().Sub(start))
+	}
+}
+
+// redactedWildcards 路由中值为凭据的通配符，流量统计中保留占位符，不记录实际的值
+var redactedWildcards = map[string]bool{"token": true}
+
+// trafficURI 记录到流量统计中的请求路径：按匹配的路由模式填入通配符的值，凭据类的通配符（如分享令牌）保留占位符；
+// 查询参数中也可能带有令牌，一律不记录
+func trafficURI(r *http.Request) string {
+	if r.Pattern == "" {
+		return r.URL.Path
+	}
+	path := r.Pattern
+	if _, p, found := strings.Cut(path, " "); found {
+		path = p
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, "{") || !strings.HasSuffix(seg, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(seg[1:len(seg)-1], "...")
+		switch {
+		case name == "$":
+			segments[i] = ""
+		case !redactedWildcards[name]:
+			segments[i] = r.PathValue(name)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// TrafficReport 请求统计
+type TrafficReport struct {
+	Endpoints []traffic.EndpointStats `json:"endpoints"` // 按响应总字节数从大到小
+	Requests  []traffic.Request       `json:"requests"`  // 最近的大响应和慢请求，按 sort 排序
+	Clients   []traffic.ClientStats   `json:"clients"`   // 按客户端汇总 requests 中的记录
+}
+
+// GetTraffic 查看请求和响应大小统计（管理接口）
+// @Summary 请求流量统计
+// @Description 管理接口：各接口的请求数和收发字节数，以及最近响应超过 TRAFFIC_HEAVY_BYTES 或耗时超过 TRAFFIC_SLOW_MS 的请求（最多 TRAFFIC_LOG_SIZE 条）
+// @Description 只统计当前实例自启动以来的请求
+// @Tags admin
+// @Produce json
+// @Param sort query string false "请求排序方式" Enums(bytes,duration) default(bytes)
+// @Param limit query int false "最多返回的请求条数，0 表示全部" default(20)
+// @Success 200 {object} handler.Response{data=handler.TrafficReport}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/admin/traffic [get]
+func (h *Handler) GetTraffic(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sortBy := q.Get("sort")
+	switch sortBy {
+	case "":
+		sortBy = traffic.SortBytes
+	case traffic.SortBytes, traffic.SortDuration:
+	default:
+		h.sendError(w, apperr.CodeValidationError, "sort 只能是 bytes 或 duration")
+		return
+	}
+	limit := 20
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			h.sendError(w, apperr.CodeValidationError, "limit 必须是非负整数")
+			return
+		}
+		limit = n
+	}
+
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: TrafficReport{
+			Endpoints: h.traffic.Endpoints(),
+			Requests:  h.traffic.Requests(sortBy, limit),
+			Clients:   h.traffic.Clients(),
+		},
+		Message: "获取流量统计成功",
+	})
+}
@@ -0,0 +1,439 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// 分片上传：移动端网络不稳定时，大文件（附件、导入文件）分段上传，断线后从已接收的位置继续
+//
+//	POST   /api/v1/uploads                创建上传，声明用途、文件名和总大小
+//	PUT    /api/v1/uploads/{id}           上传一段，Content-Range: bytes 起始-结束/总大小，起始必须等于 offset
+//	GET    /api/v1/uploads/{id}           查询 offset（断线后从这里继续）
+//	POST   /api/v1/uploads/{id}/complete  全部接收后处理：保存为附件，或者导入待办事项
+//	DELETE /api/v1/uploads/{id}           放弃上传
+//
+// 已接收的数据暂存在 UPLOAD_DIR 中，超过 UPLOAD_TTL_HOURS 未完成的上传由定时任务清理
+
+// CreateUploadRequest 创建分片上传的请求
+type CreateUploadRequest struct {
+	Purpose  string `json:"purpose" example:"attachment"` // attachment / import
+	TodoID   int    `json:"todo_id,omitempty"`            // purpose 为 attachment 时必填
+	Filename string `json:"filename" example:"photo.jpg"` // 导入文件必须是 .json 或 .csv
+	Size     int64  `json:"size" example:"83886080"`      // 文件总大小（字节）
+}
+
+// statusWriter 记录写出的状态码，用于判断上传的文件是否已经处理完毕
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// uploadPath 已接收数据的暂存文件
+func (h *Handler) uploadPath(id string) string {
+	return filepath.Join(h.cfg.UploadDir, id+".part")
+}
+
+// CreateUpload 创建分片上传
+// @Summary 创建分片上传
+// @Description 声明用途和文件总大小，之后用 PUT /api/v1/uploads/{id} 按 Content-Range 分段上传，全部接收后调用 complete
+// @Description 附件的大小上限为 ATTACHMENT_MAX_BYTES，导入文件与归档导入相同（100MB）；工作区的附件总大小超过 QUOTA_MAX_ATTACHMENT_BYTES 时返回 403
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param request body handler.CreateUploadRequest true "上传信息"
+// @Success 201 {object} handler.Response{data=model.Upload}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 413 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 503 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/uploads [post]
+func (h *Handler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateUpload", timeout: CreateTimeout, status: http.StatusCreated, message: "上传已创建"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req CreateUploadRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			filename := cleanFilename(req.Filename)
+			if filename == "" {
+				return nil, apperr.New(apperr.CodeValidationError, "缺少文件名")
+			}
+			if req.Size <= 0 {
+				return nil, apperr.New(apperr.CodeValidationError, "size 必须大于 0")
+			}
+
+			switch req.Purpose {
+			case model.UploadPurposeAttachment:
+				cfg := h.cfg.Attachments
+				if cfg.Scanner == nil && cfg.RequireScan {
+					return nil, apperr.New(apperr.CodeScanUnavailable, "未配置病毒扫描，暂不允许上传附件")
+				}
+				if req.Size > cfg.MaxBytes {
+					return nil, apperr.New(apperr.CodeAttachmentTooLarge, "附件超过大小上限").
+						WithDetails(map[string]interface{}{"max_bytes": cfg.MaxBytes})
+				}
+				if req.TodoID <= 0 {
+					return nil, apperr.New(apperr.CodeValidationError, "附件上传必须提供 todo_id")
+				}
+				if err := h.requireSQLite(); err != nil {
+					return nil, err
+				}
+				if _, err := h.todos.GetTodoByIDContext(ctx, req.TodoID); err != nil {
+					return nil, storeError(err, "获取待办事项失败")
+				}
+				// 完成上传时按实际大小再检查一次
+				if err := h.checkAttachmentQuota(ctx, req.Size); err != nil {
+					return nil, h.quotaAPIError(w, err)
+				}
+			case model.UploadPurposeImport:
+				ext := strings.ToLower(filepath.Ext(filename))
+				if ext != ".json" && ext != ".csv" {
+					return nil, apperr.New(apperr.CodeValidationError, "不支持的文件格式，请使用 .json 或 .csv")
+				}
+				if req.Size > maxArchiveBytes {
+					return nil, apperr.New(apperr.CodeAttachmentTooLarge, "导入文件超过大小上限").
+						WithDetails(map[string]interface{}{"max_bytes": maxArchiveBytes})
+				}
+				req.TodoID = 0
+			default:
+				return nil, apperr.New(apperr.CodeValidationError, "purpose 必须是 attachment 或 import")
+			}
+
+			now := h.clock.Now().UTC()
+			upload := &model.Upload{
+				ID:        randomKey(),
+				Purpose:   req.Purpose,
+				TodoID:    req.TodoID,
+				Filename:  filename,
+				Size:      req.Size,
+				CreatedAt: now,
+				ExpiresAt: now.Add(h.cfg.UploadTTL),
+			}
+			if err := os.MkdirAll(h.cfg.UploadDir, 0o755); err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeStorageError, "创建上传目录失败")
+			}
+			if err := os.WriteFile(h.uploadPath(upload.ID), nil, 0o600); err != nil {
+				return nil, apperr.Wrap(err, apperr.CodeStorageError, "创建上传文件失败")
+			}
+			if err := h.db.CreateUploadContext(ctx, upload); err != nil {
+				os.Remove(h.uploadPath(upload.ID))
+				return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "创建上传失败")
+			}
+
+			w.Header().Set("Location", "/api/v1/uploads/"+upload.ID)
+			return upload, nil
+		})
+}
+
+// GetUpload 查询分片上传的进度，断线后从 offset 继续上传
+// @Summary 查询分片上传
+// @Tags uploads
+// @Produce json
+// @Param id path string true "上传ID"
+// @Success 200 {object} handler.Response{data=model.Upload}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/uploads/{id} [get]
+func (h *Handler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetUpload", timeout: DefaultTimeout, message: "获取上传成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			return h.getUpload(ctx, r.PathValue("id"))
+		})
+}
+
+// UploadChunk 上传一段数据
+// 连接中断时已收到的部分也会保存，客户端查询 offset 后从断点继续
+// @Summary 上传分片
+// @Description Content-Range: bytes 起始-结束/总大小（结束位置包含在内），起始必须等于当前 offset，否则返回 409 和当前 offset
+// @Tags uploads
+// @Accept octet-stream
+// @Produce json
+// @Param id path string true "上传ID"
+// @Param Content-Range header string true "例如 bytes 0-1048575/83886080"
+// @Success 200 {object} handler.Response{data=model.Upload}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/uploads/{id} [put]
+func (h *Handler) UploadChunk(w http.ResponseWriter, r *http.Request) {
+	// 慢速网络上一段数据可能超过服务器的读写超时，这里放宽到上传超时
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(UploadTimeout)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		log.Printf("UploadChunk: failed to extend read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		log.Printf("UploadChunk: failed to extend write deadline: %v", err)
+	}
+
+	h.serve(w, r, endpoint{name: "UploadChunk", timeout: UploadTimeout, message: "分片已接收"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id := r.PathValue("id")
+			unlock, err := h.lockUpload(id)
+			if err != nil {
+				return nil, err
+			}
+			defer unlock()
+
+			upload, err := h.getUpload(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			start, end, err := parseContentRange(r.Header.Get("Content-Range"), upload.Size)
+			if err != nil {
+				return nil, err
+			}
+			if start != upload.Offset {
+				return nil, apperr.New(apperr.CodeUploadOffsetMismatch, fmt.Sprintf("应从 %d 开始上传", upload.Offset)).
+					WithDetails(map[string]interface{}{"offset": upload.Offset})
+			}
+
+			n, writeErr := h.appendChunk(upload, r.Body, end-start+1)
+			if n > 0 {
+				// 客户端断开时 ctx 已经取消，已收到的部分仍然要记下来
+				upload.Offset += n
+				if err := h.db.SetUploadOffsetContext(context.WithoutCancel(ctx), upload.ID, upload.Offset); err != nil {
+					return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "更新上传进度失败")
+				}
+			}
+			if writeErr != nil {
+				return nil, writeErr
+			}
+			if n < end-start+1 {
+				return nil, apperr.New(apperr.CodeInvalidRequest, "分片数据不完整，请查询 offset 后继续").
+					WithDetails(map[string]interface{}{"offset": upload.Offset})
+			}
+			return upload, nil
+		})
+}
+
+// appendChunk 从 offset 处写入最多 length 字节，返回实际写入的字节数
+// 先截断到 offset，丢弃上次中断时写入但没有记录的数据
+func (h *Handler) appendChunk(upload *model.Upload, body io.Reader, length int64) (int64, error) {
+	f, err := os.OpenFile(h.uploadPath(upload.ID), os.O_WRONLY, 0)
+	if err != nil {
+		return 0, apperr.Wrap(err, apperr.CodeStorageError, "打开上传文件失败")
+	}
+	defer f.Close()
+
+	if err := f.Truncate(upload.Offset); err != nil {
+		return 0, apperr.Wrap(err, apperr.CodeStorageError, "写入上传文件失败")
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		return 0, apperr.Wrap(err, apperr.CodeStorageError, "写入上传文件失败")
+	}
+
+	n, err := io.Copy(f, io.LimitReader(body, length))
+	if err != nil {
+		var writeErr *os.PathError
+		if errors.As(err, &writeErr) {
+			return n, apperr.Wrap(err, apperr.CodeStorageError, "写入上传文件失败")
+		}
+		// 读取请求体失败（连接中断），已收到的部分保留
+		return n, apperr.Wrap(err, apperr.CodeInvalidRequest, "分片数据不完整，请查询 offset 后继续")
+	}
+	return n, nil
+}
+
+// CompleteUpload 全部数据接收后处理文件：附件的响应与 POST /todos/{id}/attachments 相同，
+// 导入的响应与 POST /todos/import 相同（同样支持 async=true）
+// 处理成功或文件本身被拒绝（例如格式错误、发现病毒）后上传被删除；超时、扫描器不可用等临时错误时保留，可以重试
+// @Summary 完成分片上传
+// @Tags uploads
+// @Produce json
+// @Param id path string true "上传ID"
+// @Param async query bool false "导入文件作为后台任务执行"
+// @Success 200 {object} handler.Response
+// @Success 201 {object} handler.Response{data=model.Attachment}
+// @Success 202 {object} handler.Response{data=handler.JobStatus}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 422 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 503 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/uploads/{id}/complete [post]
+func (h *Handler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), UploadTimeout)
+	defer cancel()
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(UploadTimeout)); err != nil {
+		log.Printf("CompleteUpload: failed to extend write deadline: %v", err)
+	}
+
+	id := r.PathValue("id")
+	unlock, err := h.lockUpload(id)
+	if err != nil {
+		h.sendAPIError(w, "CompleteUpload", err)
+		return
+	}
+	defer unlock()
+
+	upload, err := h.getUpload(ctx, id)
+	if err != nil {
+		h.sendAPIError(w, "CompleteUpload", err)
+		return
+	}
+	if !upload.Complete() {
+		h.sendErrorDetails(w, apperr.CodeUploadIncomplete, fmt.Sprintf("已接收 %d / %d 字节", upload.Offset, upload.Size),
+			map[string]interface{}{"offset": upload.Offset, "size": upload.Size})
+		return
+	}
+
+	f, err := os.Open(h.uploadPath(upload.ID))
+	if err != nil {
+		log.Printf("CompleteUpload: failed to open upload: %v", err)
+		h.sendError(w, apperr.CodeStorageError, "读取上传文件失败")
+		return
+	}
+	defer f.Close()
+
+	sw := &statusWriter{ResponseWriter: w}
+	switch upload.Purpose {
+	case model.UploadPurposeAttachment:
+		attachment, err := h.saveAttachment(ctx, sw, upload.TodoID, f, upload.Filename)
+		h.respond(sw, endpoint{name: "CompleteUpload", status: http.StatusCreated, message: "附件已上传"}, attachment, err)
+	case model.UploadPurposeImport:
+		todos, err := h.parseImportReader(upload.Filename, f)
+		if err != nil {
+			h.respond(sw, endpoint{name: "CompleteUpload"}, nil, parseError(err))
+			break
+		}
+		data, err := h.importTodos(ctx, sw, r, todos)
+		h.respond(sw, endpoint{name: "CompleteUpload"}, data, err)
+	}
+
+	// 没有写出响应（客户端已断开）或临时错误时保留上传，客户端可以重试 complete
+	if sw.status == 0 || sw.status == http.StatusRequestTimeout || sw.status >= http.StatusInternalServerError {
+		return
+	}
+	f.Close()
+	h.discardUpload(context.WithoutCancel(ctx), upload.ID)
+}
+
+// DeleteUpload 放弃分片上传，删除已接收的数据
+// @Summary 放弃分片上传
+// @Tags uploads
+// @Produce json
+// @Param id path string true "上传ID"
+// @Success 200 {object} handler.Response
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/uploads/{id} [delete]
+func (h *Handler) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteUpload", timeout: DeleteTimeout, message: "上传已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id := r.PathValue("id")
+			unlock, err := h.lockUpload(id)
+			if err != nil {
+				return nil, err
+			}
+			defer unlock()
+
+			if _, err := h.getUpload(ctx, id); err != nil {
+				return nil, err
+			}
+			h.discardUpload(ctx, id)
+			return nil, nil
+		})
+}
+
+// PurgeExpiredUploads 删除过期的分片上传和已接收的数据（接受 Context 参数，供调度器使用）
+func (h *Handler) PurgeExpiredUploads(ctx context.Context) {
+	ids, err := h.db.DeleteExpiredUploadsContext(ctx, h.clock.Now())
+	if err != nil {
+		log.Printf("清理过期上传失败: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if err := os.Remove(h.uploadPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("删除上传文件失败: id=%s, error=%v", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("已清理过期上传: count=%d", len(ids))
+	}
+}
+
+// getUpload 查询当前工作区未过期的上传
+func (h *Handler) getUpload(ctx context.Context, id string) (*model.Upload, error) {
+	upload, err := h.db.GetUploadContext(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, apperr.Wrap(err, apperr.CodeNotFound, "上传不存在或已过期")
+	}
+	if err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeDatabaseError, "查询上传失败")
+	}
+	return upload, nil
+}
+
+// lockUpload 同一个上传同时只允许一个请求写入或处理（仅限本实例）
+func (h *Handler) lockUpload(id string) (func(), error) {
+	if _, busy := h.uploadLocks.LoadOrStore(id, struct{}{}); busy {
+		return nil, apperr.New(apperr.CodeUploadOffsetMismatch, "另一个请求正在处理该上传，请稍后查询 offset")
+	}
+	return func() { h.uploadLocks.Delete(id) }, nil
+}
+
+// discardUpload 删除上传会话和已接收的数据，失败只记日志
+func (h *Handler) discardUpload(ctx context.Context, id string) {
+	if err := h.db.DeleteUploadContext(ctx, id); err != nil {
+		log.Printf("删除上传会话失败: id=%s, error=%v", id, err)
+	}
+	if err := os.Remove(h.uploadPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("删除上传文件失败: id=%s, error=%v", id, err)
+	}
+}
+
+// parseContentRange 解析 "bytes 起始-结束/总大小"，总大小必须与创建上传时声明的一致
+func parseContentRange(header string, size int64) (start, end int64, err error) {
+	invalid := apperr.New(apperr.CodeInvalidParam, "Content-Range 格式应为 bytes 起始-结束/总大小")
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes ")
+	if !ok {
+		return 0, 0, invalid
+	}
+	rng, total, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, invalid
+	}
+	first, last, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, invalid
+	}
+	start, err1 := strconv.ParseInt(first, 10, 64)
+	end, err2 := strconv.ParseInt(last, 10, 64)
+	n, err3 := strconv.ParseInt(total, 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || start < 0 || end < start {
+		return 0, 0, invalid
+	}
+	if n != size || end >= size {
+		return 0, 0, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("Content-Range 超出文件大小 %d", size))
+	}
+	return start, end, nil
+}
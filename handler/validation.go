@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"todo-list/apperr"
+	"todo-list/model"
+)
+
+// todoTextViolations 规范化标题和描述，返回违反的约束
+// 为 nil 的字段表示本次不修改（例如部分更新），不做校验
+func (h *Handler) todoTextViolations(title, description *string) []model.Violation {
+	var violations []model.Violation
+	if title != nil {
+		*title = model.NormalizeTitle(*title)
+		violations = append(violations, h.cfg.TextLimits.CheckTitle(*title)...)
+	}
+	if description != nil {
+		*description = model.NormalizeDescription(*description)
+		violations = append(violations, h.cfg.TextLimits.CheckDescription(*description)...)
+	}
+	return violations
+}
+
+// secretNoteViolations 校验加密备注；nil 或密文为空（表示没有备注或清除备注）时不校验
+func secretNoteViolations(note *model.SecretNote) []model.Violation {
+	if note == nil || note.Ciphertext == "" {
+		return nil
+	}
+	return note.Check()
+}
+
+// resolveDueDate 解析请求中的截止日期（nil 或空字符串表示未设置），结果为 UTC
+// 不带时区的日期按用户时区解释（见 userLocation）；格式无法识别、或开启 REJECT_PAST_DUE_DATES 时
+// 早于当前时间，返回对应的约束；查询用户时区失败时返回 error
+func (h *Handler) resolveDueDate(ctx context.Context, r *http.Request, raw *string) (*time.Time, []model.Violation, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil, nil, nil
+	}
+
+	loc := time.UTC
+	if !model.HasZoneOffset(*raw) {
+		var err error
+		if loc, err = h.userLocation(ctx, r); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	due, err := model.ParseDueDate(*raw, loc)
+	if err != nil {
+		return nil, []model.Violation{{Field: "due_date", Constraint: "format", Message: err.Error()}}, nil
+	}
+	if h.cfg.RejectPastDueDates && due.Before(h.clock.Now()) {
+		return nil, []model.Violation{{Field: "due_date", Constraint: "not_past", Message: "截止日期不能早于当前时间"}}, nil
+	}
+	return &due, nil, nil
+}
+
+// userLocation 用户所在时区：X-Timezone 请求头优先，其次是通知偏好中设置的时区，默认 UTC
+func (h *Handler) userLocation(ctx context.Context, r *http.Request) (*time.Location, error) {
+	if tz := r.Header.Get("X-Timezone"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("无效的时区: %s", tz))
+		}
+		return loc, nil
+	}
+
+	prefs, err := h.repos.GetNotificationPreferencesContext(ctx, currentUserID(r))
+	if err != nil {
+		return nil, storeError(err, "获取用户时区失败")
+	}
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		return time.UTC, nil
+	}
+	return loc, nil
+}
+
+// violationError 把违反的约束转换为 422 错误，details.violations 列出每一项
+func violationError(violations []model.Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Message
+	}
+	return apperr.New(apperr.CodeConstraintViolation, strings.Join(messages, "；")).
+		WithDetails(map[string]interface{}{"violations": violations})
+}
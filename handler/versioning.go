@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// parseIfMatch 解析 If-Match 请求头中的版本号，支持 "3"、W/"3" 和 3 三种写法
+// 没有该请求头或值为 * 时返回 nil
+func parseIfMatch(r *http.Request) (*int, error) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" || raw == "*" {
+		return nil, nil
+	}
+
+	raw = strings.TrimPrefix(raw, "W/")
+	raw = strings.Trim(raw, `"`)
+
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("If-Match 版本号无效")
+	}
+	return &version, nil
+}
+
+// setETag 以版本号作为 ETag 返回，客户端下次更新时放进 If-Match
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+	"todo-list/apperr"
+	"todo-list/storage"
+	"todo-list/workflow"
+)
+
+// 列表视图（GET /api/v1/todos?view=name），名称是对前端稳定的深链接，筛选条件可以在服务端调整
+const (
+	ViewToday   = "today"   // 今天到期
+	ViewWeek    = "week"    // 今天起 7 天内到期
+	ViewOverdue = "overdue" // 已逾期
+	ViewInbox   = "inbox"   // 收件箱，见 GET /api/v1/todos/views/inbox
+	ViewStale   = "stale"   // 停滞：待处理且长时间没有更新
+)
+
+// TodoViews 支持的视图名称，顺序即功能发现中的顺序
+var TodoViews = []string{ViewToday, ViewWeek, ViewOverdue, ViewInbox, ViewStale}
+
+// defaultStaleViewDays 没有为待处理状态配置老化规则时，stale 视图的天数
+const defaultStaleViewDays = 14
+
+// applyView 把视图名称展开为筛选条件，和 status、search 等参数同时生效；
+// 没有传 sort 时使用视图的默认排序（到期类视图按截止日期升序，其余按创建时间升序）
+func (h *Handler) applyView(r *http.Request, filter *storage.TodoFilter) error {
+	view := r.URL.Query().Get("view")
+	if view == "" {
+		return nil
+	}
+
+	now := h.clock.Now().UTC()
+	today := now.Format("2006-01-02")
+	sortField := "due_date"
+	switch view {
+	case ViewToday:
+		filter.PendingOnly = true
+		filter.DueDateFrom, filter.DueDateTo = today, today
+	case ViewWeek:
+		filter.PendingOnly = true
+		filter.DueDateFrom, filter.DueDateTo = today, now.AddDate(0, 0, 7).Format("2006-01-02")
+	case ViewOverdue:
+		filter.PendingOnly = true
+		filter.DueBefore = &now
+	case ViewInbox:
+		filter.InboxOnly = true
+		sortField = "created_at"
+	case ViewStale:
+		before := h.staleViewThreshold(now)
+		filter.PendingOnly = true
+		filter.UpdatedBefore = &before
+		sortField = "created_at"
+	default:
+		return apperr.New(apperr.CodeInvalidParam, "未知的视图："+view).
+			WithDetails(map[string]interface{}{"views": TodoViews})
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = sortField
+		if filter.Order == "" {
+			filter.Order = "asc"
+		}
+	}
+	return nil
+}
+
+// staleViewThreshold stale 视图的时间界限：优先使用待处理状态的老化规则（AGING_RULES_FILE），否则为 14 天
+func (h *Handler) staleViewThreshold(now time.Time) time.Time {
+	if h.aging != nil {
+		for _, rule := range h.aging.Rules {
+			if rule.Status == workflow.StatusPending {
+				return rule.Threshold(now)
+			}
+		}
+	}
+	return now.AddDate(0, 0, -defaultStaleViewDays)
+}
@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/storage"
+	"todo-list/webhook"
+)
+
+// WebhookRequest 创建或修改 webhook 的请求（修改时整体替换）
+// 过滤条件在服务端判断，同时满足才推送，没有设置的条件不限制
+type WebhookRequest struct {
+	URL         string          `json:"url" example:"https://hooks.example.com/todo"`
+	Events      []string        `json:"events,omitempty" example:"created,completed"`               // created / updated / completed / deleted，为空表示全部
+	ProjectID   *int            `json:"project_id,omitempty" example:"3"`                           // 只推送该项目的事件，0 表示不属于任何项目的
+	MinPriority *model.Priority `json:"min_priority,omitempty" swaggertype:"string" example:"high"` // 只推送优先级不低于该值的事件，数值或名称
+	Active      *bool           `json:"active,omitempty"`                                           // 默认启用
+}
+
+// CreatedWebhook 新建的 webhook，签名密钥只在这里出现一次
+type CreatedWebhook struct {
+	model.Webhook
+	Secret string `json:"secret" example:"whsec_1a2b3c4d..."`
+}
+
+// WebhookTestResult 测试推送的结果
+type WebhookTestResult struct {
+	Delivered bool   `json:"delivered"`
+	Status    int    `json:"status,omitempty"` // 接收方的 HTTP 状态码，没有收到响应时为空
+	Error     string `json:"error,omitempty"`
+}
+
+// 推送记录默认 / 最大返回数量
+const (
+	defaultWebhookDeliveriesLimit = 50
+	maxWebhookDeliveriesLimit     = 100
+)
+
+// SetWebhookDispatcher 设置出站 webhook 分发器（测试推送和手动重试使用）
+func (h *Handler) SetWebhookDispatcher(d *webhook.Dispatcher) {
+	h.webhooks = d
+}
+
+// webhookFromRequest 把请求转换为 webhook 并校验，指定的项目必须存在
+func (h *Handler) webhookFromRequest(ctx context.Context, req *WebhookRequest) (*model.Webhook, error) {
+	w := &model.Webhook{
+		URL:         req.URL,
+		EventFilter: model.EventFilter{Events: req.Events, ProjectID: req.ProjectID},
+		Active:      true,
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+	if req.MinPriority != nil {
+		value, err := req.MinPriority.Resolve(h.cfg.PriorityScale)
+		if err != nil {
+			return nil, apperr.New(apperr.CodeValidationError, fmt.Sprintf("min_priority 无效：%v", err))
+		}
+		w.MinPriority = &value
+	}
+	if err := w.Validate(); err != nil {
+		return nil, apperr.New(apperr.CodeValidationError, err.Error())
+	}
+	if w.ProjectID != nil {
+		if err := h.checkProject(ctx, *w.ProjectID); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// webhookStoreError 把 ErrNotFound 转换为 404，其余同 storeError
+func webhookStoreError(err error, message string) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperr.Wrap(err, apperr.CodeNotFound, "webhook 不存在")
+	}
+	return storeError(err, message)
+}
+
+// ListWebhooks webhook 列表
+// @Summary webhook 列表
+// @Description 当前工作区的出站 webhook，包括最近一次推送的结果；不返回签名密钥
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.Webhook}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks [get]
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListWebhooks", timeout: ListTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			webhooks, err := h.repos.ListWebhooksContext(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询 webhook 失败")
+			}
+			return webhooks, nil
+		})
+}
+
+// CreateWebhook 创建 webhook
+// @Summary 创建 webhook
+// @Description 当前工作区的待办事项创建、修改、完成或删除、且满足过滤条件（事件类型、项目、最低优先级）时，向 url 发送 POST 请求，请求体为变更事件（同 /api/v1/todos/events 的 data，另带 workspace）。
+// @Description 请求头 X-Webhook-Event 为事件类型，X-Webhook-Delivery 为推送 ID（重试时不变，可用于去重），
+// @Description X-Webhook-Signature 为 sha256= 加上 HMAC-SHA256(secret, X-Webhook-Timestamp + "." + 请求体) 的十六进制。
+// @Description 非 2xx 响应或连接失败会按指数退避重试。只推送创建之后的变更；响应中的 secret 只返回这一次。
+// @Description 工作区的 webhook 数量达到 QUOTA_MAX_WEBHOOKS 时返回 403
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body handler.WebhookRequest true "推送地址和过滤条件"
+// @Success 201 {object} handler.Response{data=handler.CreatedWebhook}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "CreateWebhook", timeout: CreateTimeout, status: http.StatusCreated, message: "webhook 已创建，请妥善保存签名密钥"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			var req WebhookRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			hook, err := h.webhookFromRequest(ctx, &req)
+			if err != nil {
+				return nil, err
+			}
+			if err := h.checkWebhookQuota(ctx); err != nil {
+				return nil, h.quotaAPIError(w, err)
+			}
+			hook.Secret = model.NewWebhookSecret()
+			if err := h.repos.CreateWebhookContext(ctx, hook); err != nil {
+				return nil, storeError(err, "保存 webhook 失败")
+			}
+			return CreatedWebhook{Webhook: *hook, Secret: hook.Secret}, nil
+		})
+}
+
+// GetWebhook 查询 webhook
+// @Summary 查询 webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} handler.Response{data=model.Webhook}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks/{id} [get]
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetWebhook", timeout: DefaultTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			hook, err := h.repos.GetWebhookContext(ctx, id)
+			if err != nil {
+				return nil, webhookStoreError(err, "查询 webhook 失败")
+			}
+			return hook, nil
+		})
+}
+
+// UpdateWebhook 修改 webhook
+// @Summary 修改 webhook
+// @Description 整体替换推送地址、过滤条件和启用状态，签名密钥不变；停用期间的变更在重新启用后不补发
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param request body handler.WebhookRequest true "推送地址和过滤条件"
+// @Success 200 {object} handler.Response{data=model.Webhook}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks/{id} [put]
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "UpdateWebhook", timeout: UpdateTimeout, message: "webhook 已更新"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			var req WebhookRequest
+			if err := decodeJSON(r, &req); err != nil {
+				return nil, err
+			}
+			hook, err := h.webhookFromRequest(ctx, &req)
+			if err != nil {
+				return nil, err
+			}
+			hook.ID = id
+			if err := h.repos.UpdateWebhookContext(ctx, hook); err != nil {
+				return nil, webhookStoreError(err, "更新 webhook 失败")
+			}
+			updated, err := h.repos.GetWebhookContext(ctx, id)
+			if err != nil {
+				return nil, webhookStoreError(err, "查询 webhook 失败")
+			}
+			return updated, nil
+		})
+}
+
+// DeleteWebhook 删除 webhook
+// @Summary 删除 webhook
+// @Description 删除后不再推送，重试队列中尚未送达的事件也会放弃
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} handler.Response
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "DeleteWebhook", timeout: DeleteTimeout, message: "webhook 已删除"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			if err := h.repos.DeleteWebhookContext(ctx, id); err != nil {
+				return nil, webhookStoreError(err, "删除 webhook 失败")
+			}
+			return nil, nil
+		})
+}
+
+// TestWebhook 测试推送
+// @Summary 测试推送 webhook
+// @Description 立即向 url 发送一条 ping 事件（同样带签名），用于检查地址和签名校验；停用的 webhook 也可以测试。
+// @Description 推送失败不重试，结果在响应中返回，同时记入推送记录和最近一次推送的结果
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Success 200 {object} handler.Response{data=handler.WebhookTestResult}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks/{id}/test [post]
+func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "TestWebhook", timeout: WebhookTestTimeout, message: "测试推送已完成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			hook, err := h.repos.GetWebhookContext(ctx, id)
+			if err != nil {
+				return nil, webhookStoreError(err, "查询 webhook 失败")
+			}
+
+			status, err := h.webhooks.Ping(ctx, hook)
+			result := WebhookTestResult{Delivered: err == nil, Status: status}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			return result, nil
+		})
+}
+
+// ListWebhookDeliveries webhook 推送记录
+// 查询参数：failed（只看失败的尝试）、limit
+// @Summary webhook 推送记录
+// @Description 每次推送尝试一条记录（自动推送、队列重试、测试推送和手动重试），最新的在前，包括状态码、耗时和响应体的开头；
+// @Description 同一事件的多次尝试 delivery 相同。超过保留期（PURGE_RETENTION_DAYS）的记录会被定期删除
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param failed query bool false "只返回失败的尝试"
+// @Param limit query int false "返回条数（最多 100）" default(50)
+// @Success 200 {object} handler.Response{data=[]model.WebhookDelivery}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListWebhookDeliveries", timeout: ListTimeout, message: "查询成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+
+			failedOnly := false
+			if v := r.URL.Query().Get("failed"); v != "" {
+				failedOnly, err = strconv.ParseBool(v)
+				if err != nil {
+					return nil, apperr.New(apperr.CodeInvalidParam, "failed 必须是 true 或 false")
+				}
+			}
+			limit := defaultWebhookDeliveriesLimit
+			if v := r.URL.Query().Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 || n > maxWebhookDeliveriesLimit {
+					return nil, apperr.New(apperr.CodeInvalidParam, "limit 必须在 1 到 100 之间")
+				}
+				limit = n
+			}
+
+			if _, err := h.repos.GetWebhookContext(ctx, id); err != nil {
+				return nil, webhookStoreError(err, "查询 webhook 失败")
+			}
+			deliveries, err := h.repos.ListWebhookDeliveriesContext(ctx, id, failedOnly, limit)
+			if err != nil {
+				return nil, storeError(err, "查询推送记录失败")
+			}
+			return deliveries, nil
+		})
+}
+
+// RetryWebhookDelivery 手动重试失败的推送
+// @Summary 重试 webhook 推送
+// @Description 立即把推送记录中的请求体原样重发一次，X-Webhook-Delivery 不变，签名和时间戳按当前密钥重新生成；停用的 webhook 也可以重试。
+// @Description 同一推送 ID 已经有成功的尝试时返回 409。重试失败不再自动重试，结果作为新的推送记录返回
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "webhook ID"
+// @Param delivery_id path int true "推送记录 ID"
+// @Success 200 {object} handler.Response{data=model.WebhookDelivery}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 404 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id}/retry [post]
+func (h *Handler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "RetryWebhookDelivery", timeout: WebhookTestTimeout, message: "重试已完成"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			id, err := pathID(r, "id")
+			if err != nil {
+				return nil, err
+			}
+			deliveryID, err := pathID(r, "delivery_id")
+			if err != nil {
+				return nil, err
+			}
+			hook, err := h.repos.GetWebhookContext(ctx, id)
+			if err != nil {
+				return nil, webhookStoreError(err, "查询 webhook 失败")
+			}
+			prev, succeeded, err := h.repos.GetWebhookDeliveryContext(ctx, id, deliveryID)
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, apperr.Wrap(err, apperr.CodeNotFound, "推送记录不存在")
+			}
+			if err != nil {
+				return nil, storeError(err, "查询推送记录失败")
+			}
+			if succeeded {
+				return nil, apperr.New(apperr.CodeAlreadyDelivered, "该推送已经成功送达")
+			}
+
+			// 推送失败也正常返回，结果在新的推送记录中
+			attempt, _ := h.webhooks.Redeliver(ctx, hook, prev)
+			return attempt, nil
+		})
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+	"todo-list/apperr"
+	"todo-list/model"
+	"todo-list/workflow"
+)
+
+// WorkflowResponse 工作流定义
+type WorkflowResponse struct {
+	Statuses    []workflow.Status   `json:"statuses"`
+	Transitions map[string][]string `json:"transitions"`
+}
+
+// SetWorkflow 替换默认的状态工作流（启动时调用）
+func (h *Handler) SetWorkflow(wf *workflow.Workflow) {
+	h.workflow = wf
+}
+
+// GetWorkflow 返回当前部署的状态和允许的流转，供前端渲染状态选择器
+// @Summary 状态工作流
+// @Description 当前部署的状态和允许的流转
+// @Tags meta
+// @Produce json
+// @Success 200 {object} handler.Response{data=handler.WorkflowResponse}
+// @Router /api/v1/workflow [get]
+func (h *Handler) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data: WorkflowResponse{
+			Statuses:    h.workflow.Statuses,
+			Transitions: h.workflow.Transitions,
+		},
+		Message: "获取工作流成功",
+	})
+}
+
+// applyStatus 按工作流校验并应用状态变更，状态未知或不允许流转时返回 INVALID_STATUS / INVALID_TRANSITION
+func (h *Handler) applyStatus(todo *model.Todo, status string) error {
+	if !h.workflow.Has(status) {
+		return apperr.New(apperr.CodeInvalidStatus, "未知的状态").
+			WithDetails(map[string]interface{}{"status": status, "statuses": h.workflow.Names()})
+	}
+	if !h.workflow.CanTransition(todo.Status, status) {
+		return apperr.New(apperr.CodeInvalidTransition, "不允许的状态流转").
+			WithDetails(map[string]interface{}{"from": todo.Status, "to": status, "allowed": h.workflow.Next(todo.Status)})
+	}
+
+	wasTerminal := h.workflow.IsTerminal(todo.Status)
+	todo.Status = status
+	switch {
+	case h.workflow.IsTerminal(status) && !wasTerminal:
+		now := h.clock.Now()
+		todo.CompletedAt = &now
+	case !h.workflow.IsTerminal(status):
+		todo.CompletedAt = nil
+	}
+	return nil
+}
+
+// fillStatusCounts 补齐工作流中没有待办事项的状态，让统计结果的分组保持稳定
+func (h *Handler) fillStatusCounts(counts map[string]int) {
+	if counts == nil {
+		return
+	}
+	for _, name := range h.workflow.Names() {
+		if _, ok := counts[name]; !ok {
+			counts[name] = 0
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"todo-list/apperr"
+	"todo-list/database"
+)
+
+// 工作量视图参数
+const (
+	defaultWorkloadDays    = 7
+	maxWorkloadDays        = 31
+	defaultCapacityMinutes = 8 * 60      // 每天默认可用 8 小时
+	maxEstimateMinutes     = 7 * 24 * 60 // 单个待办事项的预估最多一周
+)
+
+// WorkloadDayView 某天的工作量，超过每日容量时 Overcommitted 为 true
+type WorkloadDayView struct {
+	database.WorkloadDay
+	Overcommitted bool `json:"overcommitted"`
+}
+
+// WorkloadResponse 工作量视图
+type WorkloadResponse struct {
+	CapacityMinutes int               `json:"capacity_minutes"`
+	OverdueMinutes  int               `json:"overdue_minutes"` // 已逾期但未完成的预估耗时，需要挪到接下来几天
+	OverdueTodos    int               `json:"overdue_todos"`
+	Days            []WorkloadDayView `json:"days"`
+}
+
+// validateEstimate 校验预估耗时（分钟），0 表示不设置
+func validateEstimate(minutes *int) error {
+	if minutes == nil {
+		return nil
+	}
+	if *minutes < 0 || *minutes > maxEstimateMinutes {
+		return fmt.Errorf("预估耗时必须在 0 到 %d 分钟之间", maxEstimateMinutes)
+	}
+	return nil
+}
+
+// GetWorkload 按到期日汇总接下来几天的预估耗时，标出超出每日容量的日期
+// 查询参数：days（默认 7，最多 31）、capacity（每日可用分钟数，默认 480）
+// @Summary 工作量视图
+// @Description 按到期日汇总接下来几天的预估耗时，标出超出每日容量的日期
+// @Tags views
+// @Produce json
+// @Param days query int false "天数（最多 31）" default(7)
+// @Param capacity query int false "每日可用分钟数" default(480)
+// @Success 200 {object} handler.Response{data=handler.WorkloadResponse}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/todos/views/workload [get]
+func (h *Handler) GetWorkload(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "GetWorkload", timeout: StatsTimeout, message: "获取工作量成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			days := defaultWorkloadDays
+			if v := r.URL.Query().Get("days"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > maxWorkloadDays {
+					return nil, apperr.New(apperr.CodeInvalidParam, fmt.Sprintf("days 必须在 1 到 %d 之间", maxWorkloadDays))
+				}
+				days = n
+			}
+
+			capacity := defaultCapacityMinutes
+			if v := r.URL.Query().Get("capacity"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > 24*60 {
+					return nil, apperr.New(apperr.CodeInvalidParam, "capacity 必须在 1 到 1440 分钟之间")
+				}
+				capacity = n
+			}
+
+			resp, err := h.buildWorkload(ctx, h.clock.Now(), days, capacity)
+			if err != nil {
+				return nil, storeError(err, "查询工作量失败")
+			}
+			return resp, nil
+		})
+}
+
+// buildWorkload 汇总从 now 开始 days 天的工作量
+func (h *Handler) buildWorkload(ctx context.Context, now time.Time, days, capacity int) (*WorkloadResponse, error) {
+	workload, err := h.db.WorkloadContext(ctx, now, days)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &WorkloadResponse{
+		CapacityMinutes: capacity,
+		Days:            make([]WorkloadDayView, 0, len(workload)),
+	}
+
+	resp.OverdueMinutes, resp.OverdueTodos, err = h.db.OverdueWorkloadContext(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, day := range workload {
+		resp.Days = append(resp.Days, WorkloadDayView{
+			WorkloadDay:   day,
+			Overcommitted: day.EstimatedMinutes > capacity,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"todo-list/apperr"
+	"todo-list/database"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// WorkspaceHeader 通过请求头切换工作区（也可以使用 /api/v1/workspaces/{workspace}/todos 路径前缀）
+const WorkspaceHeader = "X-Workspace"
+
+// ResolveWorkspace 中间件：从路径或请求头中解析工作区，写入请求 Context
+// 路径参数优先于请求头；两者都没有时使用默认工作区。
+// 带 member API key 的请求只能进入 key 绑定的工作区，其他工作区与不存在一样返回 404；
+// key 没有绑定默认工作区时，未指定工作区的请求使用 key 绑定的第一个工作区
+func (h *Handler) ResolveWorkspace(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := requestAPIKey(r)
+		slug := r.PathValue("workspace")
+		if slug == "" {
+			slug = r.Header.Get(WorkspaceHeader)
+		}
+		if slug == "" {
+			slug = model.DefaultWorkspace
+			if key != nil && !key.CanAccess(slug) && len(key.Workspaces) > 0 {
+				slug = key.Workspaces[0]
+			}
+		}
+		if key != nil && !key.CanAccess(slug) {
+			h.sendError(w, apperr.CodeWorkspaceNotFound, "工作区不存在")
+			return
+		}
+		if slug == model.DefaultWorkspace {
+			next(w, r)
+			return
+		}
+
+		if !model.ValidWorkspaceSlug(slug) {
+			h.sendError(w, apperr.CodeInvalidWorkspace, "无效的工作区标识")
+			return
+		}
+
+		ws, err := h.repos.GetWorkspaceContext(r.Context(), slug)
+		if err != nil {
+			log.Printf("Failed to resolve workspace %q: %v", slug, err)
+			h.sendError(w, apperr.CodeDatabaseError, "获取工作区失败")
+			return
+		}
+		if ws == nil {
+			h.sendError(w, apperr.CodeWorkspaceNotFound, "工作区不存在")
+			return
+		}
+
+		next(w, r.WithContext(storage.WithWorkspace(r.Context(), ws.Slug)))
+	}
+}
+
+// ListWorkspaces 获取所有工作区
+// @Summary 工作区列表
+// @Description 待办事项路由也可以挂在 /api/v1/workspaces/{workspace}/todos 下，或通过 X-Workspace 请求头切换工作区；
+// @Description 带 member API key 时只列出 key 绑定的工作区
+// @Tags workspaces
+// @Produce json
+// @Success 200 {object} handler.Response{data=[]model.Workspace}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/workspaces [get]
+func (h *Handler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, endpoint{name: "ListWorkspaces", timeout: ListTimeout, message: "获取工作区列表成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			workspaces, err := h.repos.ListWorkspacesContext(ctx)
+			if err != nil {
+				return nil, storeError(err, "查询工作区失败")
+			}
+			if key := requestAPIKey(r); key != nil {
+				workspaces = slices.DeleteFunc(workspaces, func(ws model.Workspace) bool {
+					return !key.CanAccess(ws.Slug)
+				})
+			}
+			return workspaces, nil
+		})
+}
+
+// CreateWorkspace 创建工作区
+// @Summary 创建工作区
+// @Description 只有管理员可以创建，member API key 返回 403
+// @Tags workspaces
+// @Accept json
+// @Produce json
+// @Param request body model.Workspace true "工作区"
+// @Success 201 {object} handler.Response{data=model.Workspace}
+// @Failure 400 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 403 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 408 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 409 {object} handler.Response{error=handler.ErrorInfo}
+// @Failure 500 {object} handler.Response{error=handler.ErrorInfo}
+// @Router /api/v1/workspaces [post]
+func (h *Handler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	defer r.Body.Close()
+
+	h.serve(w, r, endpoint{name: "CreateWorkspace", timeout: CreateTimeout, status: http.StatusCreated, message: "创建工作区成功"},
+		func(ctx context.Context, r *http.Request) (interface{}, error) {
+			if !h.isAdmin(r) {
+				return nil, apperr.New(apperr.CodeForbidden, "只有管理员可以创建工作区")
+			}
+			var ws model.Workspace
+			if err := decodeJSON(r, &ws); err != nil {
+				return nil, err
+			}
+			if err := ws.Validate(); err != nil {
+				return nil, apperr.New(apperr.CodeValidationError, err.Error())
+			}
+
+			if err := h.repos.CreateWorkspaceContext(ctx, &ws); err != nil {
+				if errors.Is(err, database.ErrWorkspaceExists) {
+					return nil, apperr.Wrap(err, apperr.CodeWorkspaceExists, "工作区标识已存在")
+				}
+				return nil, storeError(err, "创建工作区失败")
+			}
+			return ws, nil
+		})
+}
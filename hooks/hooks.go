@@ -0,0 +1,177 @@
+// Package hooks 入站 webhook 框架：统一处理签名校验、重放保护和分发
+// 每个集成（GitHub、Slack、邮件服务商…）只需要提供一个 Verifier 和一个普通的 http.HandlerFunc
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"todo-list/clock"
+)
+
+// 入站 webhook 错误
+var (
+	ErrUnknownProvider  = errors.New("hooks: unknown provider")
+	ErrInvalidSignature = errors.New("hooks: invalid signature")
+	ErrStaleRequest     = errors.New("hooks: request timestamp outside tolerance")
+	ErrReplayed         = errors.New("hooks: request already processed")
+	ErrReplayCheck      = errors.New("hooks: replay check failed")
+)
+
+// MaxBodyBytes 入站请求体上限
+const MaxBodyBytes = 5 << 20
+
+// Verifier 校验请求签名，带时间戳的签名按 now 检查是否在 TimestampTolerance 内
+// 返回本次投递的唯一标识（用于重放保护），必须是签名覆盖的内容，没有唯一标识时返回空字符串
+type Verifier interface {
+	Verify(r *http.Request, body []byte, now time.Time) (deliveryID string, err error)
+}
+
+// Provider 一个入站集成
+type Provider struct {
+	Name     string
+	Verifier Verifier
+	Handler  http.HandlerFunc // 校验通过后调用，r.Body 可以再次读取
+}
+
+// Registry 入站集成注册表
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	replay    *ReplayGuard
+	clock     clock.Clock
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[string]Provider),
+		replay:    NewReplayGuard(ReplayWindow),
+		clock:     clock.Real{},
+	}
+}
+
+// SetClock 替换校验时间戳和重放窗口使用的时钟（测试中使用 clock.Fake），只能在接收请求之前调用
+func (reg *Registry) SetClock(c clock.Clock) {
+	reg.clock = c
+	reg.replay.SetClock(c)
+}
+
+// SetReplayStore 把已处理的投递记录到 store 中（默认只在内存中，重启后丢失），只能在接收请求之前调用
+func (reg *Registry) SetReplayStore(store ReplayStore) {
+	reg.replay.SetStore(store)
+}
+
+// Register 注册（或替换）一个集成
+func (reg *Registry) Register(p Provider) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.providers[p.Name] = p
+}
+
+// Providers 返回已注册的集成名称
+func (reg *Registry) Providers() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	names := make([]string, 0, len(reg.providers))
+	for name := range reg.providers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Verify 读取请求体、校验签名并检查重放，成功后把请求体放回 r.Body
+// 返回匹配的集成，调用方负责把请求交给 Provider.Handler；需要在处理失败时允许重新投递的调用方使用 Serve
+func (reg *Registry) Verify(w http.ResponseWriter, r *http.Request, name string) (*Provider, error) {
+	p, _, err := reg.verify(w, r, name)
+	return p, err
+}
+
+// Serve 校验请求后交给 Provider.Handler 处理，校验失败时返回错误、不写响应
+// 处理器返回 5xx 时撤销投递记录，发送方的重试会被再次处理而不是当作重复请求忽略
+func (reg *Registry) Serve(w http.ResponseWriter, r *http.Request, name string) error {
+	p, key, err := reg.verify(w, r, name)
+	if err != nil {
+		return err
+	}
+
+	rec := &statusRecorder{ResponseWriter: w}
+	p.Handler(rec, r)
+
+	if key != "" && rec.status >= http.StatusInternalServerError {
+		// 请求的 context 可能已经取消（例如处理超时），撤销记录不能跟着失败
+		if err := reg.replay.Forget(context.WithoutCancel(r.Context()), key); err != nil {
+			log.Printf("撤销 webhook 投递记录失败: provider=%s, error=%v", name, err)
+		}
+	}
+	return nil
+}
+
+// verify 实现 Verify，同时返回记录到重放保护中的 key（没有投递标识时为空字符串）
+func (reg *Registry) verify(w http.ResponseWriter, r *http.Request, name string) (*Provider, string, error) {
+	reg.mu.RLock()
+	p, ok := reg.providers[name]
+	reg.mu.RUnlock()
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("读取请求体失败：%w", err)
+	}
+	r.Body.Close()
+
+	deliveryID, err := p.Verifier.Verify(r, body, reg.clock.Now())
+	if err != nil {
+		log.Printf("webhook 签名校验失败: provider=%s, error=%v", name, err)
+		return nil, "", err
+	}
+
+	var key string
+	if deliveryID != "" {
+		key = name + ":" + deliveryID
+		seen, err := reg.replay.Seen(r.Context(), key)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrReplayCheck, err)
+		}
+		if seen {
+			return nil, "", ErrReplayed
+		}
+	}
+
+	// 签名校验读走了请求体，放回去给具体的处理器使用
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return &p, key, nil
+}
+
+// statusRecorder 记录处理器写出的状态码，没有调用 WriteHeader 直接写入时视为 200
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
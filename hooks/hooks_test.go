@@ -0,0 +1,229 @@
+package hooks_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"todo-list/clock"
+	"todo-list/hooks"
+)
+
+// slackRequest 按 Slack 的方式签名，时间戳为 at
+func slackRequest(secret, body string, at time.Time) *http.Request {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/hooks/slack", strings.NewReader(body))
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestRegistryUsesClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 8, 6, 59, 30, 0, time.UTC))
+	reg := hooks.NewRegistry()
+	reg.SetClock(fake)
+	reg.Register(hooks.Provider{Name: "slack", Verifier: hooks.SlackVerifier{Secret: "s3cret"}})
+
+	verify := func(r *http.Request) error {
+		_, err := reg.Verify(httptest.NewRecorder(), r, "slack")
+		return err
+	}
+
+	signedAt := fake.Now()
+	if err := verify(slackRequest("s3cret", "text=hi", signedAt)); err != nil {
+		t.Fatalf("fresh request: %v", err)
+	}
+	if err := verify(slackRequest("s3cret", "text=hi", signedAt)); !errors.Is(err, hooks.ErrReplayed) {
+		t.Errorf("replayed request = %v, want ErrReplayed", err)
+	}
+
+	// 时间戳按注册表的时钟判断，而不是系统时间
+	fake.Advance(hooks.TimestampTolerance - time.Second)
+	if err := verify(slackRequest("s3cret", "text=again", signedAt)); err != nil {
+		t.Errorf("request within tolerance: %v", err)
+	}
+	fake.Advance(2 * time.Second)
+	if err := verify(slackRequest("s3cret", "text=late", signedAt)); !errors.Is(err, hooks.ErrStaleRequest) {
+		t.Errorf("request outside tolerance = %v, want ErrStaleRequest", err)
+	}
+}
+
+func TestReplayGuardExpiresWithClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC))
+	g := hooks.NewReplayGuard(time.Hour)
+	g.SetClock(fake)
+	ctx := context.Background()
+
+	for i, want := range []bool{false, true} {
+		if seen, err := g.Seen(ctx, "delivery"); err != nil || seen != want {
+			t.Fatalf("Seen #%d = %v, %v; want %v", i+1, seen, err, want)
+		}
+	}
+	fake.Advance(time.Hour + time.Second)
+	if seen, err := g.Seen(ctx, "delivery"); err != nil || seen {
+		t.Errorf("Seen after ttl = %v, %v; want false", seen, err)
+	}
+}
+
+func TestServeForgetsFailedDelivery(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 8, 6, 59, 30, 0, time.UTC))
+	reg := hooks.NewRegistry()
+	reg.SetClock(fake)
+
+	// 处理器第一次失败，之后成功
+	var calls int
+	reg.Register(hooks.Provider{Name: "slack", Verifier: hooks.SlackVerifier{Secret: "s3cret"},
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte("ok"))
+		}})
+
+	serve := func() (int, error) {
+		rec := httptest.NewRecorder()
+		err := reg.Serve(rec, slackRequest("s3cret", "text=hi", fake.Now()), "slack")
+		return rec.Code, err
+	}
+
+	if code, err := serve(); err != nil || code != http.StatusInternalServerError {
+		t.Fatalf("first delivery = %d, %v; want 500", code, err)
+	}
+	// 失败的投递不算已处理，重试会再次交给处理器
+	if code, err := serve(); err != nil || code != http.StatusOK {
+		t.Fatalf("retry after failure = %d, %v; want 200", code, err)
+	}
+	if _, err := serve(); !errors.Is(err, hooks.ErrReplayed) {
+		t.Errorf("replay after success = %v, want ErrReplayed", err)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+}
+
+// githubRequest 按 GitHub 的方式签名，签名不含时间戳
+func githubRequest(secret, body string, at time.Time) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/hooks/github", strings.NewReader(body))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+// mailgunRequest 按 Mailgun 的方式在表单字段中签名，token 由 body 决定（同一个 body 是同一次投递）
+func mailgunRequest(secret, body string, at time.Time) *http.Request {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	token := hex.EncodeToString([]byte(body))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + token))
+
+	form := url.Values{
+		"subject":   {body},
+		"timestamp": {ts},
+		"token":     {token},
+		"signature": {hex.EncodeToString(mac.Sum(nil))},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/hooks/mailgun", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// 每个内置集成都要拒绝错误的签名、过期的时间戳和重复投递，处理失败（5xx）的投递可以重试
+func TestProviderVerification(t *testing.T) {
+	providers := []struct {
+		name        string
+		verifier    hooks.Verifier
+		request     func(secret, body string, at time.Time) *http.Request
+		timestamped bool // 签名带时间戳，超出 TimestampTolerance 时拒绝
+	}{
+		{"github", hooks.GitHubVerifier{Secret: "s3cret"}, githubRequest, false},
+		{"slack", hooks.SlackVerifier{Secret: "s3cret"}, slackRequest, true},
+		{"mailgun", hooks.MailgunVerifier{Secret: "s3cret"}, mailgunRequest, true},
+	}
+
+	for _, p := range providers {
+		t.Run(p.name, func(t *testing.T) {
+			fake := clock.NewFake(time.Date(2026, 3, 8, 6, 59, 30, 0, time.UTC))
+			reg := hooks.NewRegistry()
+			reg.SetClock(fake)
+
+			// 内容带 fail 的投递第一次返回 500；按内容计数，签名字段不影响
+			calls := map[string]int{}
+			reg.Register(hooks.Provider{Name: p.name, Verifier: p.verifier, Handler: func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				key := "first"
+				if strings.Contains(string(body), "fail") {
+					key = "fail"
+				}
+				calls[key]++
+				if key == "fail" && calls[key] == 1 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte("ok"))
+			}})
+			serve := func(r *http.Request) (int, error) {
+				rec := httptest.NewRecorder()
+				err := reg.Serve(rec, r, p.name)
+				return rec.Code, err
+			}
+
+			// 去掉签名（Mailgun 的签名在表单字段中，保留时间戳）
+			unsigned := p.request("s3cret", "unsigned", fake.Now())
+			unsigned.Header.Del("X-Hub-Signature-256")
+			unsigned.Header.Del("X-Slack-Signature")
+			if p.name == "mailgun" {
+				form := url.Values{"subject": {"unsigned"}, "timestamp": {strconv.FormatInt(fake.Now().Unix(), 10)}, "token": {"t"}}
+				unsigned = httptest.NewRequest(http.MethodPost, "/api/v1/hooks/mailgun", strings.NewReader(form.Encode()))
+				unsigned.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			type verifyCase struct {
+				name    string
+				request *http.Request
+				status  int
+				err     error
+			}
+			cases := []verifyCase{
+				{"valid", p.request("s3cret", "first", fake.Now()), http.StatusOK, nil},
+				{"bad signature", p.request("wrong", "second", fake.Now()), 0, hooks.ErrInvalidSignature},
+				{"missing signature", unsigned, 0, hooks.ErrInvalidSignature},
+				{"replayed", p.request("s3cret", "first", fake.Now()), 0, hooks.ErrReplayed},
+				{"handler fails", p.request("s3cret", "fail", fake.Now()), http.StatusInternalServerError, nil},
+				{"retry after 5xx", p.request("s3cret", "fail", fake.Now()), http.StatusOK, nil},
+				{"replay after retry", p.request("s3cret", "fail", fake.Now()), 0, hooks.ErrReplayed},
+			}
+			if p.timestamped {
+				cases = append(cases,
+					verifyCase{"stale timestamp", p.request("s3cret", "late", fake.Now().Add(-hooks.TimestampTolerance-time.Second)), 0, hooks.ErrStaleRequest},
+					verifyCase{"future timestamp", p.request("s3cret", "early", fake.Now().Add(hooks.TimestampTolerance+time.Second)), 0, hooks.ErrStaleRequest},
+				)
+			}
+
+			for _, tc := range cases {
+				status, err := serve(tc.request)
+				if !errors.Is(err, tc.err) || (tc.err == nil && status != tc.status) {
+					t.Errorf("%s = %d, %v; want %d, %v", tc.name, status, err, tc.status, tc.err)
+				}
+			}
+			if calls["first"] != 1 || calls["fail"] != 2 {
+				t.Errorf("handler calls = %v, want first once and fail twice", calls)
+			}
+		})
+	}
+}
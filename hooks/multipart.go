@@ -0,0 +1,41 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// parseMultipartFields 只读取 multipart 请求中的普通字段，跳过文件
+func parseMultipartFields(r *http.Request, body []byte) (url.Values, error) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("解析 Content-Type 失败：%w", err)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	values := url.Values{}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析表单失败：%w", err)
+		}
+
+		if part.FileName() == "" {
+			data, err := io.ReadAll(io.LimitReader(part, 64<<10))
+			if err != nil {
+				return nil, fmt.Errorf("读取表单字段失败：%w", err)
+			}
+			values.Add(part.FormName(), string(data))
+		}
+		part.Close()
+	}
+}
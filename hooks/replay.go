@@ -0,0 +1,88 @@
+package hooks
+
+import (
+	"context"
+	"sync"
+	"time"
+	"todo-list/clock"
+)
+
+// ReplayWindow 已处理投递的保留时间
+// GitHub 的签名不含时间戳，窗口要覆盖发送方可以重新投递的期限（GitHub 为 3 天）；
+// 带时间戳的集成超出 TimestampTolerance 的旧请求已经由 Verifier 拒绝
+const ReplayWindow = 72 * time.Hour
+
+// ReplayStore 持久化已处理的投递，重启和多个实例之间共享
+type ReplayStore interface {
+	// MarkHookDeliveryContext 记录 key 到 expiresAt 为止，key 已经记录过（且未过期）时返回 true
+	MarkHookDeliveryContext(ctx context.Context, key string, expiresAt time.Time) (bool, error)
+	// ForgetHookDeliveryContext 删除 key 的记录，之后同一个 key 会被当作新的投递
+	ForgetHookDeliveryContext(ctx context.Context, key string) error
+}
+
+// ReplayGuard 记录近期处理过的投递，拒绝重复请求
+// 投递标识必须被签名覆盖（签名本身或签名中的 token），否则攻击者改一个请求头就能绕过
+type ReplayGuard struct {
+	ttl   time.Duration
+	store ReplayStore // 为 nil 时只在内存中记录
+	clock clock.Clock
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewReplayGuard 创建重放保护，ttl 应不小于签名时间戳的容忍窗口
+func NewReplayGuard(ttl time.Duration) *ReplayGuard {
+	return &ReplayGuard{
+		ttl:   ttl,
+		seen:  make(map[string]time.Time),
+		clock: clock.Real{},
+	}
+}
+
+// SetClock 替换判断过期使用的时钟（测试中使用 clock.Fake）
+func (g *ReplayGuard) SetClock(c clock.Clock) {
+	g.clock = c
+}
+
+// SetStore 改为在 store 中记录已处理的投递
+func (g *ReplayGuard) SetStore(store ReplayStore) {
+	g.store = store
+}
+
+// Seen 如果 key 在 ttl 内出现过返回 true，否则记录并返回 false
+func (g *ReplayGuard) Seen(ctx context.Context, key string) (bool, error) {
+	if g.store != nil {
+		return g.store.MarkHookDeliveryContext(ctx, key, g.clock.Now().Add(g.ttl))
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+
+	// 顺便清理过期记录，避免 map 无限增长
+	for k, at := range g.seen {
+		if now.Sub(at) > g.ttl {
+			delete(g.seen, k)
+		}
+	}
+
+	if _, ok := g.seen[key]; ok {
+		return true, nil
+	}
+	g.seen[key] = now
+	return false, nil
+}
+
+// Forget 撤销 key 的记录：投递处理失败时调用，让发送方的重试能够再次处理
+func (g *ReplayGuard) Forget(ctx context.Context, key string) error {
+	if g.store != nil {
+		return g.store.ForgetHookDeliveryContext(ctx, key)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.seen, key)
+	return nil
+}
@@ -0,0 +1,128 @@
+package hooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestampTolerance 签名时间戳允许的最大偏差
+const TimestampTolerance = 5 * time.Minute
+
+// GitHubVerifier 校验 X-Hub-Signature-256（sha256=HMAC(secret, body)）
+type GitHubVerifier struct {
+	Secret string
+}
+
+// Verify 实现 Verifier 接口，使用签名作为投递 ID：签名只覆盖请求体，
+// X-GitHub-Delivery 不在签名范围内，换一个值就能重放同一个请求体
+func (v GitHubVerifier) Verify(r *http.Request, body []byte, now time.Time) (string, error) {
+	sig, found := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	if !found {
+		return "", ErrInvalidSignature
+	}
+	if !validHexMAC(v.Secret, body, sig) {
+		return "", ErrInvalidSignature
+	}
+	return "sha256=" + strings.ToLower(sig), nil
+}
+
+// SlackVerifier 校验 Slack 签名（v0=HMAC(secret, "v0:{timestamp}:{body}")）
+type SlackVerifier struct {
+	Secret string
+}
+
+// Verify 实现 Verifier 接口，使用时间戳 + 签名作为投递 ID
+func (v SlackVerifier) Verify(r *http.Request, body []byte, now time.Time) (string, error) {
+	ts := r.Header.Get("X-Slack-Request-Timestamp")
+	if err := checkTimestamp(ts, now); err != nil {
+		return "", err
+	}
+
+	sig, found := strings.CutPrefix(r.Header.Get("X-Slack-Signature"), "v0=")
+	if !found {
+		return "", ErrInvalidSignature
+	}
+
+	base := append([]byte("v0:"+ts+":"), body...)
+	if !validHexMAC(v.Secret, base, sig) {
+		return "", ErrInvalidSignature
+	}
+	return ts + ":" + sig, nil
+}
+
+// MailgunVerifier 校验 Mailgun 签名（表单字段 timestamp / token / signature）
+type MailgunVerifier struct {
+	Secret string
+}
+
+// Verify 实现 Verifier 接口，使用 token 作为投递 ID
+func (v MailgunVerifier) Verify(r *http.Request, body []byte, now time.Time) (string, error) {
+	fields, err := formFields(r, body, "timestamp", "token", "signature")
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkTimestamp(fields["timestamp"], now); err != nil {
+		return "", err
+	}
+	if !validHexMAC(v.Secret, []byte(fields["timestamp"]+fields["token"]), fields["signature"]) {
+		return "", ErrInvalidSignature
+	}
+	return fields["token"], nil
+}
+
+// validHexMAC 常量时间比较 HMAC-SHA256 签名
+func validHexMAC(secret string, message []byte, sigHex string) bool {
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// checkTimestamp 检查 Unix 时间戳与 now 的偏差是否在容忍窗口内
+func checkTimestamp(ts string, now time.Time) error {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrStaleRequest
+	}
+	skew := now.Sub(time.Unix(sec, 0))
+	if skew < -TimestampTolerance || skew > TimestampTolerance {
+		return ErrStaleRequest
+	}
+	return nil
+}
+
+// formFields 从 urlencoded 或 multipart 请求体中读取指定字段（不消费 r.Body）
+func formFields(r *http.Request, body []byte, names ...string) (map[string]string, error) {
+	fields := make(map[string]string, len(names))
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		form, err := parseMultipartFields(r, body)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			fields[name] = form.Get(name)
+		}
+		return fields, nil
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("解析表单失败：%w", err)
+	}
+	for _, name := range names {
+		fields[name] = values.Get(name)
+	}
+	return fields, nil
+}
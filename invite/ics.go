@@ -0,0 +1,108 @@
+package invite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// iCalendar METHOD
+const (
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// Event 截止日期对应的日程
+type Event struct {
+	UID         string
+	Sequence    int
+	Summary     string
+	Description string
+	Due         time.Time
+	AllDay      bool // 截止日期没有具体时间（当天零点）时作为全天日程
+	Organizer   string
+	Attendee    string
+	Stamp       time.Time
+}
+
+// eventDuration 有具体时间的截止日程的长度；alarmBefore 提前提醒的时间
+const (
+	eventDuration = 30 * time.Minute
+	alarmBefore   = time.Hour
+)
+
+// ICS 生成 iCalendar 内容（RFC 5545），method 为 MethodRequest 或 MethodCancel
+// 时间统一写成 UTC，全天日程只写日期，客户端按自己的时区显示
+func (e *Event) ICS(method string) []byte {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(fold(s))
+		b.WriteString("\r\n")
+	}
+	const utc = "20060102T150405Z"
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//todo-list//deadline invites//ZH")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:" + method)
+	line("BEGIN:VEVENT")
+	line("UID:" + e.UID)
+	line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+	line("DTSTAMP:" + e.Stamp.UTC().Format(utc))
+	if e.AllDay {
+		line("DTSTART;VALUE=DATE:" + e.Due.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + e.Due.AddDate(0, 0, 1).Format("20060102"))
+	} else {
+		line("DTSTART:" + e.Due.UTC().Format(utc))
+		line("DTEND:" + e.Due.Add(eventDuration).UTC().Format(utc))
+	}
+	line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	line("ORGANIZER:mailto:" + e.Organizer)
+	line("ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=ACCEPTED;RSVP=FALSE:mailto:" + e.Attendee)
+	// 截止日程只是标记，不占用忙闲时间
+	line("TRANSP:TRANSPARENT")
+	if method == MethodCancel {
+		line("STATUS:CANCELLED")
+	} else {
+		line("STATUS:CONFIRMED")
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("DESCRIPTION:" + escapeText(e.Summary))
+		line(fmt.Sprintf("TRIGGER:-PT%dM", int(alarmBefore.Minutes())))
+		line("END:VALARM")
+	}
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// escapeText 按 RFC 5545 转义 TEXT 值中的反斜杠、分号、逗号和换行
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// fold 把超过 75 字节的行折行（续行以空格开头），不拆开多字节字符
+func fold(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := utf8.RuneLen(r)
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
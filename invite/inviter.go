@@ -0,0 +1,210 @@
+// Package invite 为高优先级待办事项的截止日期发送日历邀请（iCalendar METHOD:REQUEST），让截止日期自动出现在工作日历中
+//
+// 每个待办事项对应一个日程：截止日期变化时用更高的 SEQUENCE 重新发送，日历会更新原来的日程；
+// 待办事项完成、调低优先级或去掉截止日期后发送 METHOD:CANCEL 取消日程。
+package invite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"strconv"
+	"time"
+	"todo-list/clock"
+	"todo-list/database"
+	"todo-list/mail"
+	"todo-list/model"
+	"todo-list/workflow"
+)
+
+// TaskName 调度器中的任务名
+const TaskName = "截止日期邀请"
+
+// Store 发送邀请需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	ListInviteCandidatesContext(ctx context.Context, minPriority int) ([]database.InviteCandidate, error)
+	RecordInviteContext(ctx context.Context, invite *model.CalendarInvite) error
+	GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+}
+
+// Options 邀请设置
+type Options struct {
+	MinPriority   int                 // 只为优先级 >= MinPriority 的待办事项发送
+	To            string              // 收件人，也是日程的参与人
+	From          string              // 发件地址，作为日程的组织者
+	PriorityScale model.PriorityScale // 模板中显示优先级名称
+}
+
+// TemplateData 邀请邮件模板的数据（mail.TemplateDeadlineInvite、mail.TemplateDeadlineCancel）
+type TemplateData struct {
+	TodoID      int
+	Title       string
+	Description string
+	Project     string
+	Priority    string // 优先级名称
+	Due         string // 按用户时区格式化的截止时间
+}
+
+// Inviter 检查需要发送或取消的邀请，由调度器周期调用；截止日期或优先级修改后会立即触发一次
+type Inviter struct {
+	store     Store
+	mailer    mail.Mailer
+	templates *mail.Templates
+	workflow  *workflow.Workflow
+	opts      Options
+	userID    string // 日期按默认用户的时区解释
+	clock     clock.Clock
+}
+
+// NewInviter 创建截止日期邀请器
+func NewInviter(store Store, mailer mail.Mailer, templates *mail.Templates, wf *workflow.Workflow, opts Options, userID string, clk clock.Clock) *Inviter {
+	return &Inviter{
+		store:     store,
+		mailer:    mailer,
+		templates: templates,
+		workflow:  wf,
+		opts:      opts,
+		userID:    userID,
+		clock:     clk,
+	}
+}
+
+// Run 执行一轮检查（接受 Context 参数，供调度器使用）
+func (iv *Inviter) Run(ctx context.Context) {
+	sent, err := iv.evaluate(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("截止日期邀请检查超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("截止日期邀请检查已取消")
+			return
+		}
+		log.Printf("截止日期邀请检查失败: %v", err)
+		return
+	}
+
+	if sent > 0 {
+		log.Printf("截止日期邀请已发送: count=%d", sent)
+	}
+}
+
+// evaluate 比较每个待办事项的现状和上次发送的邀请，返回发送的邮件数量
+// 发送失败只记录日志，不写入记录，下一轮再试
+func (iv *Inviter) evaluate(ctx context.Context) (int, error) {
+	now := iv.clock.Now().UTC()
+	candidates, err := iv.store.ListInviteCandidatesContext(ctx, iv.opts.MinPriority)
+	if err != nil {
+		return 0, err
+	}
+
+	loc := iv.location(ctx)
+	sent := 0
+	for i := range candidates {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		c := &candidates[i]
+		active := c.Invite != nil && !c.Invite.Canceled
+		wanted := c.DueDate != nil && c.Priority >= iv.opts.MinPriority && !iv.workflow.IsTerminal(c.Status)
+
+		invite := &model.CalendarInvite{TodoID: c.ID, SentAt: now}
+		if c.Invite != nil {
+			invite.Sequence = c.Invite.Sequence + 1
+		}
+		var method string
+		switch {
+		case wanted && active && c.Invite.DueDate.Equal(*c.DueDate):
+			continue
+		case wanted:
+			// 没发过邀请的已过期事项（例如刚开启邀请时的旧数据）不再补发
+			if !active && c.DueDate.Before(now) {
+				continue
+			}
+			method, invite.DueDate = MethodRequest, *c.DueDate
+		case active:
+			method, invite.DueDate, invite.Canceled = MethodCancel, c.Invite.DueDate, true
+		default:
+			continue
+		}
+
+		if err := iv.send(ctx, c, invite, method, loc); err != nil {
+			log.Printf("发送截止日期邀请失败: todo_id=%d, method=%s, error=%v", c.ID, method, err)
+			continue
+		}
+		if err := iv.store.RecordInviteContext(ctx, invite); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// send 渲染邮件模板并连同 iCalendar 内容一起发送
+func (iv *Inviter) send(ctx context.Context, c *database.InviteCandidate, invite *model.CalendarInvite, method string, loc *time.Location) error {
+	due := invite.DueDate.In(loc)
+	allDay := due.Hour() == 0 && due.Minute() == 0 && due.Second() == 0
+
+	data := TemplateData{
+		TodoID:      c.ID,
+		Title:       c.Title,
+		Description: c.Description,
+		Project:     c.Project,
+		Priority:    iv.opts.PriorityScale.Label(c.Priority),
+		Due:         due.Format("2006-01-02 15:04 MST"),
+	}
+	if allDay {
+		data.Due = due.Format("2006-01-02")
+	}
+	if data.Priority == "" {
+		data.Priority = strconv.Itoa(c.Priority)
+	}
+
+	name := mail.TemplateDeadlineInvite
+	if method == MethodCancel {
+		name = mail.TemplateDeadlineCancel
+	}
+	subject, text, err := iv.templates.Render(name, data)
+	if err != nil {
+		return err
+	}
+
+	organizer := iv.opts.From
+	if addr, err := netmail.ParseAddress(organizer); err == nil {
+		organizer = addr.Address
+	}
+	uid := c.PublicID
+	if uid == "" {
+		uid = fmt.Sprintf("todo-%d", c.ID)
+	}
+	event := &Event{
+		UID:         uid + "@todo-list",
+		Sequence:    invite.Sequence,
+		Summary:     subject,
+		Description: text,
+		Due:         due,
+		AllDay:      allDay,
+		Organizer:   organizer,
+		Attendee:    iv.opts.To,
+		Stamp:       invite.SentAt,
+	}
+	return iv.mailer.Send(ctx, &mail.Message{
+		To:       []string{iv.opts.To},
+		Subject:  subject,
+		Text:     text,
+		Calendar: &mail.Calendar{Method: method, Data: event.ICS(method)},
+	})
+}
+
+// location 默认用户的时区，读取失败时使用 UTC
+func (iv *Inviter) location(ctx context.Context) *time.Location {
+	if prefs, err := iv.store.GetNotificationPreferencesContext(ctx, iv.userID); err == nil {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
@@ -0,0 +1,254 @@
+// Package issues 把 Jira、Linear 中指派给自己的工单导入为待办事项
+//
+// 每个工单只导入一次，放进指定的项目，工单地址作为链接挂在待办事项上；
+// 之后每轮同步只在外部状态变化时更新待办事项的完成状态，本地对标题、截止日期等的修改不会被覆盖。
+package issues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+)
+
+// TaskName 调度器中的任务名
+const TaskName = "工单导入"
+
+// Issue 外部系统中的一个工单
+type Issue struct {
+	Key      string       // 工单编号，例如 PROJ-123
+	Title    string       // 标题
+	URL      string       // 在浏览器中打开的地址
+	Status   string       // 状态名称，例如 In Progress
+	Done     bool         // 是否已完成（包括取消、不处理等终态）
+	Due      string       // 截止日期（YYYY-MM-DD），没有时为空
+	Priority PriorityTier // 优先级档位
+}
+
+// PriorityTier 外部系统的优先级归为三档，导入时映射到 PRIORITY_LABELS
+type PriorityTier int
+
+// 优先级档位
+const (
+	TierNone PriorityTier = iota
+	TierLow
+	TierMedium
+	TierHigh
+)
+
+// Source 一个外部工单系统
+type Source interface {
+	// Name 来源名称，例如 jira，记录在导入表中区分不同来源的相同编号
+	Name() string
+	// Fetch 返回指派给自己的未完成工单，以及 known 中列出的工单（不论状态和指派人，用于同步状态）
+	Fetch(ctx context.Context, known []string) ([]Issue, error)
+}
+
+// Store 导入需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	EnsureProjectContext(ctx context.Context, name string) (int, error)
+	ListIssueImportsContext(ctx context.Context, source string) ([]model.IssueImport, error)
+	CreateIssueTodoContext(ctx context.Context, imp *model.IssueImport, todo *model.Todo, link *model.TodoLink) (bool, error)
+	SyncIssueStatusContext(ctx context.Context, imp *model.IssueImport) (bool, error)
+	GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+}
+
+// Options 导入设置
+type Options struct {
+	Project       string              // 导入到的项目名称，不存在时创建
+	SyncStatus    bool                // 是否按外部状态更新已导入的待办事项
+	PriorityScale model.PriorityScale // 优先级映射，三档分别对应 low / 默认 / high
+}
+
+// SourceResult 一个来源一轮同步的结果
+type SourceResult struct {
+	Source    string `json:"source"`
+	Fetched   int    `json:"fetched"`   // 拉取到的工单数
+	Created   int    `json:"created"`   // 新建的待办事项数
+	Completed int    `json:"completed"` // 因外部完成而完成的待办事项数
+	Reopened  int    `json:"reopened"`  // 因外部重新打开而改回未完成的待办事项数
+	Error     string `json:"error,omitempty"`
+}
+
+// Importer 按来源依次同步，由调度器周期调用，也可以通过管理接口立即执行
+type Importer struct {
+	store   Store
+	sources []Source
+	opts    Options
+	userID  string // 日期按默认用户的时区解释
+	clock   clock.Clock
+}
+
+// NewImporter 创建工单导入器
+func NewImporter(store Store, sources []Source, opts Options, userID string, clk clock.Clock) *Importer {
+	return &Importer{
+		store:   store,
+		sources: sources,
+		opts:    opts,
+		userID:  userID,
+		clock:   clk,
+	}
+}
+
+// Sources 已配置的来源名称
+func (im *Importer) Sources() []string {
+	names := make([]string, len(im.sources))
+	for i, s := range im.sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// Run 执行一轮同步（接受 Context 参数，供调度器使用）
+func (im *Importer) Run(ctx context.Context) {
+	for _, r := range im.Sync(ctx) {
+		switch {
+		case r.Error != "":
+			log.Printf("工单导入失败: source=%s error=%s", r.Source, r.Error)
+		case r.Created > 0 || r.Completed > 0 || r.Reopened > 0:
+			log.Printf("工单导入完成: source=%s created=%d completed=%d reopened=%d",
+				r.Source, r.Created, r.Completed, r.Reopened)
+		}
+	}
+}
+
+// Sync 依次同步每个来源；一个来源失败不影响其他来源，错误写在该来源的结果中
+func (im *Importer) Sync(ctx context.Context) []SourceResult {
+	results := make([]SourceResult, 0, len(im.sources))
+	for _, source := range im.sources {
+		result, err := im.syncSource(ctx, source)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("同步超时")
+			}
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return results
+}
+
+// syncSource 同步一个来源：先拉取工单，再创建新工单的待办事项、同步已导入工单的状态
+func (im *Importer) syncSource(ctx context.Context, source Source) (SourceResult, error) {
+	result := SourceResult{Source: source.Name()}
+
+	imports, err := im.store.ListIssueImportsContext(ctx, source.Name())
+	if err != nil {
+		return result, err
+	}
+	known := make(map[string]*model.IssueImport, len(imports))
+	keys := make([]string, 0, len(imports))
+	for i := range imports {
+		known[imports[i].Key] = &imports[i]
+		// 本地已删除的待办事项不再同步状态
+		if imports[i].TodoID > 0 {
+			keys = append(keys, imports[i].Key)
+		}
+	}
+
+	issues, err := source.Fetch(ctx, keys)
+	if err != nil {
+		return result, err
+	}
+	result.Fetched = len(issues)
+
+	projectID := 0
+	loc := im.location(ctx)
+	for _, issue := range issues {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		imp, ok := known[issue.Key]
+		if !ok {
+			if issue.Done {
+				continue
+			}
+			if projectID == 0 && im.opts.Project != "" {
+				if projectID, err = im.store.EnsureProjectContext(ctx, im.opts.Project); err != nil {
+					return result, err
+				}
+			}
+			created, err := im.create(ctx, source.Name(), issue, projectID, loc)
+			if err != nil {
+				return result, fmt.Errorf("%s: %w", issue.Key, err)
+			}
+			if created {
+				result.Created++
+			}
+			continue
+		}
+
+		if !im.opts.SyncStatus || (imp.Status == issue.Status && imp.Done == issue.Done) {
+			continue
+		}
+		imp.Status, imp.Done = issue.Status, issue.Done
+		changed, err := im.store.SyncIssueStatusContext(ctx, imp)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", issue.Key, err)
+		}
+		if changed && issue.Done {
+			result.Completed++
+		} else if changed {
+			result.Reopened++
+		}
+	}
+	return result, nil
+}
+
+// create 为新工单创建待办事项，标题带上工单编号，描述和链接都是工单地址
+func (im *Importer) create(ctx context.Context, source string, issue Issue, projectID int, loc *time.Location) (bool, error) {
+	title := model.NormalizeTitle(fmt.Sprintf("[%s] %s", issue.Key, issue.Title))
+	todo := model.NewTodo(title, issue.URL, im.clock.Now())
+	todo.Priority = im.priority(issue.Priority)
+	if projectID > 0 {
+		todo.ProjectID = &projectID
+	}
+	if issue.Due != "" {
+		if due, err := model.ParseDueDate(issue.Due, loc); err == nil {
+			todo.DueDate = &due
+		}
+	}
+
+	imp := &model.IssueImport{Source: source, Key: issue.Key, Status: issue.Status, Done: issue.Done}
+	link := &model.TodoLink{URL: issue.URL, Title: issue.Title, Status: model.LinkStatusSkipped}
+	return im.store.CreateIssueTodoContext(ctx, imp, todo, link)
+}
+
+// priority 三档优先级对应 low / 默认优先级 / high，映射中没有同名档位时取最低、最高一档
+func (im *Importer) priority(tier PriorityTier) int {
+	scale := im.opts.PriorityScale
+	switch tier {
+	case TierHigh:
+		if v, ok := scale.Value("high"); ok {
+			return v
+		}
+		if len(scale) > 0 {
+			return scale[len(scale)-1].Value
+		}
+	case TierLow:
+		if v, ok := scale.Value("low"); ok {
+			return v
+		}
+		if len(scale) > 0 {
+			return scale[0].Value
+		}
+	}
+	return model.DefaultPriority
+}
+
+// location 默认用户的时区，读取失败时使用 UTC
+func (im *Importer) location(ctx context.Context) *time.Location {
+	if prefs, err := im.store.GetNotificationPreferencesContext(ctx, im.userID); err == nil {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
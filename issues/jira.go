@@ -0,0 +1,224 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultJiraJQL 默认拉取指派给自己、尚未完成的工单
+const DefaultJiraJQL = "assignee = currentUser() AND statusCategory != Done ORDER BY updated DESC"
+
+// jiraPageSize 每页工单数；jiraKeyBatch 按编号查询时每次查询的编号数，避免 URL 过长
+const (
+	jiraPageSize = 100
+	jiraKeyBatch = 50
+	jiraMaxPages = 20
+)
+
+// jiraFields 请求的字段
+const jiraFields = "summary,status,duedate,priority"
+
+// Jira Jira Cloud 或 Jira Server / Data Center
+// 设置了 Email 时按 Jira Cloud 处理（邮箱 + API token 的 Basic 认证），否则把 Token 作为个人访问令牌（Bearer）
+type Jira struct {
+	BaseURL string // 例如 https://example.atlassian.net
+	Email   string
+	Token   string
+	JQL     string // 为空时使用 DefaultJiraJQL
+	Client  *http.Client
+}
+
+// Name 来源名称
+func (j *Jira) Name() string { return "jira" }
+
+// jiraIssue 搜索结果中的工单
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		DueDate string `json:"duedate"`
+		Status  struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"` // new / indeterminate / done
+			} `json:"statusCategory"`
+		} `json:"status"`
+		Priority *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+	} `json:"fields"`
+}
+
+// jiraSearchResult 搜索接口的响应：Cloud 的 /search/jql 用 nextPageToken 分页，Server 的 /search 用 startAt 分页
+type jiraSearchResult struct {
+	Issues        []jiraIssue `json:"issues"`
+	StartAt       int         `json:"startAt"`
+	Total         int         `json:"total"`
+	NextPageToken string      `json:"nextPageToken"`
+	IsLast        bool        `json:"isLast"`
+}
+
+// Fetch 返回 JQL 匹配的工单，以及 known 中的工单
+func (j *Jira) Fetch(ctx context.Context, known []string) ([]Issue, error) {
+	jql := j.JQL
+	if jql == "" {
+		jql = DefaultJiraJQL
+	}
+	found, err := j.search(ctx, jql)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(found))
+	for _, issue := range found {
+		seen[issue.Key] = true
+	}
+	var missing []string
+	for _, key := range known {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	for start := 0; start < len(missing); start += jiraKeyBatch {
+		batch := missing[start:min(start+jiraKeyBatch, len(missing))]
+		issues, err := j.search(ctx, "key in ("+quoteKeys(batch)+")")
+		if err != nil {
+			// 其中有已删除或无权访问的工单时整条 JQL 报错，改为逐个查询并跳过查不到的
+			if issues, err = j.lookupEach(ctx, batch); err != nil {
+				return nil, err
+			}
+		}
+		found = append(found, issues...)
+	}
+	return found, nil
+}
+
+// search 按 JQL 分页拉取工单
+func (j *Jira) search(ctx context.Context, jql string) ([]Issue, error) {
+	var issues []Issue
+	query := url.Values{
+		"jql":        {jql},
+		"fields":     {jiraFields},
+		"maxResults": {strconv.Itoa(jiraPageSize)},
+	}
+	path := "/rest/api/2/search"
+	if j.Email != "" {
+		path = "/rest/api/3/search/jql"
+	}
+
+	for page := 0; page < jiraMaxPages; page++ {
+		var result jiraSearchResult
+		if err := j.get(ctx, path+"?"+query.Encode(), &result); err != nil {
+			return nil, err
+		}
+		for i := range result.Issues {
+			issues = append(issues, j.convert(&result.Issues[i]))
+		}
+
+		switch {
+		case result.NextPageToken != "" && !result.IsLast:
+			query.Set("nextPageToken", result.NextPageToken)
+		case result.NextPageToken == "" && result.Total > result.StartAt+len(result.Issues) && len(result.Issues) > 0:
+			query.Set("startAt", strconv.Itoa(result.StartAt+len(result.Issues)))
+		default:
+			return issues, nil
+		}
+	}
+	return issues, nil
+}
+
+// lookupEach 逐个查询工单，查不到（404）的跳过
+func (j *Jira) lookupEach(ctx context.Context, keys []string) ([]Issue, error) {
+	var issues []Issue
+	for _, key := range keys {
+		var issue jiraIssue
+		err := j.get(ctx, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields="+jiraFields, &issue)
+		if err == errNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, j.convert(&issue))
+	}
+	return issues, nil
+}
+
+// convert 把 Jira 工单转换为 Issue
+func (j *Jira) convert(ji *jiraIssue) Issue {
+	issue := Issue{
+		Key:    ji.Key,
+		Title:  ji.Fields.Summary,
+		URL:    strings.TrimRight(j.BaseURL, "/") + "/browse/" + ji.Key,
+		Status: ji.Fields.Status.Name,
+		Done:   ji.Fields.Status.StatusCategory.Key == "done",
+		Due:    ji.Fields.DueDate,
+	}
+	if ji.Fields.Priority != nil {
+		switch strings.ToLower(ji.Fields.Priority.Name) {
+		case "highest", "high", "blocker", "critical":
+			issue.Priority = TierHigh
+		case "medium", "major":
+			issue.Priority = TierMedium
+		case "low", "lowest", "minor", "trivial":
+			issue.Priority = TierLow
+		}
+	}
+	return issue
+}
+
+// get 发送带认证的 GET 请求并解析 JSON 响应
+func (j *Jira) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(j.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if j.Email != "" {
+		req.SetBasicAuth(j.Email, j.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+j.Token)
+	}
+	return doJSON(j.Client, req, v)
+}
+
+// quoteKeys 把工单编号拼成 JQL 的列表
+func quoteKeys(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = strconv.Quote(key)
+	}
+	return strings.Join(quoted, ",")
+}
+
+// errNotFound 外部系统返回 404
+var errNotFound = errors.New("issues: not found")
+
+// doJSON 发送请求并把 2xx 响应解析到 v；其他状态码返回带响应摘要的错误
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败：%w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s 返回 HTTP %d：%s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析 %s 的响应失败：%w", req.URL.Host, err)
+	}
+	return nil
+}
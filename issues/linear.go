@@ -0,0 +1,190 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultLinearEndpoint Linear 的 GraphQL 接口地址
+const DefaultLinearEndpoint = "https://api.linear.app/graphql"
+
+// linearPageSize 每页工单数；linearKeyBatch 按编号查询时每次请求的编号数
+const (
+	linearPageSize = 100
+	linearKeyBatch = 50
+	linearMaxPages = 20
+)
+
+// linearIssueFields 请求的工单字段
+const linearIssueFields = "identifier title url dueDate priority state { name type }"
+
+// linearAssignedQuery 指派给自己、未完成也未取消的工单
+const linearAssignedQuery = `query($first: Int!, $after: String) {
+  viewer {
+    assignedIssues(first: $first, after: $after, filter: { state: { type: { nin: ["completed", "canceled"] } } }) {
+      nodes { ` + linearIssueFields + ` }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+// Linear 通过个人 API key 访问 Linear
+type Linear struct {
+	APIKey   string
+	Endpoint string // 为空时使用 DefaultLinearEndpoint
+	Client   *http.Client
+}
+
+// Name 来源名称
+func (l *Linear) Name() string { return "linear" }
+
+// linearIssue GraphQL 返回的工单
+type linearIssue struct {
+	Identifier string  `json:"identifier"`
+	Title      string  `json:"title"`
+	URL        string  `json:"url"`
+	DueDate    string  `json:"dueDate"`
+	Priority   float64 `json:"priority"` // 0 无，1 紧急，2 高，3 中，4 低
+	State      struct {
+		Name string `json:"name"`
+		Type string `json:"type"` // triage / backlog / unstarted / started / completed / canceled
+	} `json:"state"`
+}
+
+// linearError GraphQL 错误
+type linearError struct {
+	Message string `json:"message"`
+}
+
+// Fetch 返回指派给自己的未完成工单，以及 known 中的工单
+func (l *Linear) Fetch(ctx context.Context, known []string) ([]Issue, error) {
+	var found []Issue
+	var after *string
+	for page := 0; page < linearMaxPages; page++ {
+		var data struct {
+			Viewer struct {
+				AssignedIssues struct {
+					Nodes    []linearIssue `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"assignedIssues"`
+			} `json:"viewer"`
+		}
+		vars := map[string]interface{}{"first": linearPageSize, "after": after}
+		if err := l.query(ctx, linearAssignedQuery, vars, &data, false); err != nil {
+			return nil, err
+		}
+		for i := range data.Viewer.AssignedIssues.Nodes {
+			found = append(found, convertLinear(&data.Viewer.AssignedIssues.Nodes[i]))
+		}
+		info := data.Viewer.AssignedIssues.PageInfo
+		if !info.HasNextPage || info.EndCursor == "" {
+			break
+		}
+		after = &info.EndCursor
+	}
+
+	seen := make(map[string]bool, len(found))
+	for _, issue := range found {
+		seen[issue.Key] = true
+	}
+	var missing []string
+	for _, key := range known {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	for start := 0; start < len(missing); start += linearKeyBatch {
+		issues, err := l.lookup(ctx, missing[start:min(start+linearKeyBatch, len(missing))])
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, issues...)
+	}
+	return found, nil
+}
+
+// lookup 按编号批量查询工单，每个编号一个别名字段；已删除或无权访问的工单返回 null，跳过
+func (l *Linear) lookup(ctx context.Context, keys []string) ([]Issue, error) {
+	var q strings.Builder
+	q.WriteString("query {")
+	for i, key := range keys {
+		keyJSON, _ := json.Marshal(key)
+		fmt.Fprintf(&q, " i%d: issue(id: %s) { %s }", i, keyJSON, linearIssueFields)
+	}
+	q.WriteString(" }")
+
+	var data map[string]*linearIssue
+	if err := l.query(ctx, q.String(), nil, &data, true); err != nil {
+		return nil, err
+	}
+	var issues []Issue
+	for _, issue := range data {
+		if issue != nil {
+			issues = append(issues, convertLinear(issue))
+		}
+	}
+	return issues, nil
+}
+
+// query 执行 GraphQL 查询；partial 为 true 时只要有 data 就忽略 errors（批量查询中部分工单不存在）
+func (l *Linear) query(ctx context.Context, query string, vars map[string]interface{}, data interface{}, partial bool) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		return err
+	}
+	endpoint := l.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultLinearEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 个人 API key 直接放在 Authorization 中，不带 Bearer 前缀
+	req.Header.Set("Authorization", l.APIKey)
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []linearError   `json:"errors"`
+	}
+	if err := doJSON(l.Client, req, &resp); err != nil {
+		return err
+	}
+	hasData := len(resp.Data) > 0 && string(resp.Data) != "null"
+	if len(resp.Errors) > 0 && (!partial || !hasData) {
+		return fmt.Errorf("linear 返回错误：%s", resp.Errors[0].Message)
+	}
+	if !hasData {
+		return nil
+	}
+	return json.Unmarshal(resp.Data, data)
+}
+
+// convertLinear 把 Linear 工单转换为 Issue
+func convertLinear(li *linearIssue) Issue {
+	issue := Issue{
+		Key:    li.Identifier,
+		Title:  li.Title,
+		URL:    li.URL,
+		Status: li.State.Name,
+		Done:   li.State.Type == "completed" || li.State.Type == "canceled",
+		Due:    li.DueDate,
+	}
+	switch int(li.Priority) {
+	case 1, 2:
+		issue.Priority = TierHigh
+	case 3:
+		issue.Priority = TierMedium
+	case 4:
+		issue.Priority = TierLow
+	}
+	return issue
+}
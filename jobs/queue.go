@@ -0,0 +1,232 @@
+// Package jobs 持久化的后台任务队列
+// 任务先写入 jobs 表再执行，失败后按指数退避重试，重试次数用完进入死信，
+// 进程重启不会丢失任务，死信可以通过管理接口查看并重新入队
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+)
+
+// 重试退避：第 n 次失败后等待 baseBackoff * 2^(n-1)，最长 maxBackoff
+const (
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+
+	// 每轮最多领取的任务数
+	claimBatch = 20
+)
+
+// HandlerFunc 执行某一类任务，返回 error 表示需要重试
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// TaskFunc 执行通过接口提交的长时间操作，返回值序列化后作为任务结果，供客户端查询
+// 执行过程中可以用 ReportProgress 报告进度
+type TaskFunc func(ctx context.Context, job *model.Job) (interface{}, error)
+
+// Store 任务队列需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	EnqueueJobContext(ctx context.Context, job *model.Job) error
+	ClaimJobsContext(ctx context.Context, now time.Time, limit int) ([]model.Job, error)
+	CompleteJobContext(ctx context.Context, id int, result json.RawMessage) error
+	FailJobContext(ctx context.Context, id int, lastError string, retryAt *time.Time) error
+	ResetRunningJobsContext(ctx context.Context) (int, error)
+	UpdateJobProgressContext(ctx context.Context, id, done, total int) error
+}
+
+// Queue 任务队列
+type Queue struct {
+	store       Store
+	handlers    map[string]TaskFunc
+	maxAttempts int
+	clock       clock.Clock
+	wake        func() // 提交任务后调用，让队列尽快执行，见 OnSubmit
+}
+
+// NewQueue 创建任务队列，maxAttempts 为每个任务的最大执行次数
+func NewQueue(store Store, maxAttempts int, clk clock.Clock) *Queue {
+	return &Queue{
+		store:       store,
+		handlers:    make(map[string]TaskFunc),
+		maxAttempts: maxAttempts,
+		clock:       clk,
+	}
+}
+
+// Register 注册某一类任务的处理函数，必须在调度器启动前调用
+func (q *Queue) Register(kind string, handler HandlerFunc) {
+	q.handlers[kind] = func(ctx context.Context, job *model.Job) (interface{}, error) {
+		return nil, handler(ctx, job.Payload)
+	}
+}
+
+// RegisterTask 注册通过 Submit 提交的长时间操作，必须在调度器启动前调用
+func (q *Queue) RegisterTask(kind string, task TaskFunc) {
+	q.handlers[kind] = task
+}
+
+// OnSubmit 设置提交任务后的回调（通常是让调度器立即执行一轮队列），不设置时等下一次轮询
+func (q *Queue) OnSubmit(wake func()) {
+	q.wake = wake
+}
+
+// Enqueue 任务入队，payload 会被序列化为 JSON
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}) error {
+	if _, ok := q.handlers[kind]; !ok {
+		return fmt.Errorf("未注册的任务类型：%s", kind)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化任务参数失败：%w", err)
+	}
+
+	return q.store.EnqueueJobContext(ctx, &model.Job{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: q.maxAttempts,
+	})
+}
+
+// Submit 提交一个长时间操作，立即返回任务，客户端按 ID 查询进度和结果
+// 这类操作大多不是幂等的（例如导入），失败后不自动重试，只执行一次
+func (q *Queue) Submit(ctx context.Context, kind, workspace string, payload interface{}) (*model.Job, error) {
+	if _, ok := q.handlers[kind]; !ok {
+		return nil, fmt.Errorf("未注册的任务类型：%s", kind)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务参数失败：%w", err)
+	}
+
+	job := &model.Job{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: 1,
+		Workspace:   workspace,
+	}
+	if err := q.store.EnqueueJobContext(ctx, job); err != nil {
+		return nil, err
+	}
+	if q.wake != nil {
+		q.wake()
+	}
+	return job, nil
+}
+
+// progressKey 执行中的任务在 Context 中的键
+type progressKey struct{}
+
+// progressReporter 把进度写入执行中的任务
+type progressReporter struct {
+	store Store
+	id    int
+}
+
+// ReportProgress 在 TaskFunc 中报告进度，total 为 0 表示总量未知
+// ctx 不是由队列执行任务时传入的（例如同步执行同一段逻辑）时什么也不做
+func ReportProgress(ctx context.Context, done, total int) {
+	p, ok := ctx.Value(progressKey{}).(progressReporter)
+	if !ok {
+		return
+	}
+	if err := p.store.UpdateJobProgressContext(ctx, p.id, done, total); err != nil {
+		log.Printf("更新任务进度失败: job_id=%d, error=%v", p.id, err)
+	}
+}
+
+// Recover 启动时调用：上次进程退出时还在执行的任务重新放回队列
+func (q *Queue) Recover(ctx context.Context) error {
+	n, err := q.store.ResetRunningJobsContext(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("已恢复未完成的后台任务: count=%d", n)
+	}
+	return nil
+}
+
+// Run 执行一轮：领取到期任务并逐个执行（接受 Context 参数，供调度器使用）
+func (q *Queue) Run(ctx context.Context) {
+	jobs, err := q.store.ClaimJobsContext(ctx, q.clock.Now(), claimBatch)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		log.Printf("领取后台任务失败: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		q.execute(ctx, job)
+	}
+}
+
+// execute 执行单个任务并记录结果
+func (q *Queue) execute(ctx context.Context, job model.Job) {
+	handler, ok := q.handlers[job.Kind]
+	if !ok {
+		// 没有处理函数的任务重试也没用，直接进入死信
+		q.fail(ctx, job, fmt.Errorf("未注册的任务类型：%s", job.Kind), false)
+		return
+	}
+
+	taskCtx := context.WithValue(ctx, progressKey{}, progressReporter{store: q.store, id: job.ID})
+	value, err := handler(taskCtx, &job)
+	if err != nil {
+		q.fail(ctx, job, err, job.Attempts < job.MaxAttempts)
+		return
+	}
+
+	var result json.RawMessage
+	if value != nil {
+		if result, err = json.Marshal(value); err != nil {
+			q.fail(ctx, job, fmt.Errorf("序列化任务结果失败：%w", err), false)
+			return
+		}
+	}
+	if err := q.store.CompleteJobContext(ctx, job.ID, result); err != nil {
+		log.Printf("更新后台任务状态失败: job_id=%d, error=%v", job.ID, err)
+	}
+}
+
+// fail 记录失败，retry 为 false 时任务进入死信
+func (q *Queue) fail(ctx context.Context, job model.Job, jobErr error, retry bool) {
+	var retryAt *time.Time
+	if retry {
+		at := q.clock.Now().Add(Backoff(job.Attempts))
+		retryAt = &at
+		log.Printf("后台任务失败，稍后重试: job_id=%d, kind=%s, attempt=%d/%d, error=%v",
+			job.ID, job.Kind, job.Attempts, job.MaxAttempts, jobErr)
+	} else {
+		log.Printf("后台任务进入死信: job_id=%d, kind=%s, attempts=%d, error=%v",
+			job.ID, job.Kind, job.Attempts, jobErr)
+	}
+
+	if err := q.store.FailJobContext(ctx, job.ID, jobErr.Error(), retryAt); err != nil {
+		log.Printf("更新后台任务状态失败: job_id=%d, error=%v", job.ID, err)
+	}
+}
+
+// Backoff 第 attempt 次失败后的等待时间
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,119 @@
+// Package linkpreview 抓取网页标题和图标，用于待办事项中的链接展示
+package linkpreview
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxBodyBytes 只读取页面前 1MB，<title> 和 <link rel="icon"> 通常都在 <head> 里
+const maxBodyBytes = 1 << 20
+
+// Metadata 页面元数据
+type Metadata struct {
+	Title      string
+	FaviconURL string
+}
+
+// Fetch 抓取页面标题和图标地址
+func Fetch(ctx context.Context, client *http.Client, rawURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败：%w", err)
+	}
+	req.Header.Set("User-Agent", "todo-list-linkpreview/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败：%w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("非预期的状态码：%d", resp.StatusCode)
+	}
+
+	// 重定向之后以最终地址为准解析相对路径
+	base := resp.Request.URL
+	meta := &Metadata{
+		FaviconURL: base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String(),
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.Contains(contentType, "html") {
+		return meta, nil
+	}
+
+	title, icon := parseHead(io.LimitReader(resp.Body, maxBodyBytes))
+	meta.Title = title
+	if icon != "" {
+		if ref, err := url.Parse(icon); err == nil {
+			meta.FaviconURL = base.ResolveReference(ref).String()
+		}
+	}
+
+	return meta, nil
+}
+
+// parseHead 从 HTML 中提取 <title> 和图标链接，遇到 <body> 即停止
+func parseHead(r io.Reader) (title, icon string) {
+	z := html.NewTokenizer(r)
+	inTitle := false
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(title), icon
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "title":
+				inTitle = title == ""
+			case "link":
+				if icon == "" {
+					icon = iconHref(tok)
+				}
+			case "body":
+				return strings.TrimSpace(title), icon
+			}
+
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+
+		case html.EndTagToken:
+			if tok := z.Token(); tok.Data == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+// iconHref 如果 <link> 是图标声明则返回其 href
+func iconHref(tok html.Token) string {
+	var rel, href string
+	for _, attr := range tok.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "rel":
+			rel = strings.ToLower(attr.Val)
+		case "href":
+			href = strings.TrimSpace(attr.Val)
+		}
+	}
+
+	for _, r := range strings.Fields(rel) {
+		if r == "icon" || r == "apple-touch-icon" {
+			return href
+		}
+	}
+	return ""
+}
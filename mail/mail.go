@@ -0,0 +1,128 @@
+// Package mail 通过 SMTP 发送邮件
+// 主题和正文由模板生成（见 Templates），可以附带日历邀请（text/calendar），邮件客户端会把它显示为可以接受的会议邀请。
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message 一封待发送的邮件
+type Message struct {
+	To       []string
+	Subject  string
+	Text     string    // 纯文本正文
+	Calendar *Calendar // 日历邀请，为空时只发送正文
+}
+
+// Calendar 邮件附带的日历邀请
+type Calendar struct {
+	Method string // iCalendar METHOD：REQUEST 添加或更新日程，CANCEL 取消日程
+	Data   []byte // 完整的 iCalendar 内容，METHOD 必须与 Method 一致
+}
+
+// Mailer 发送邮件（SMTP 实现了该接口）
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// encode 生成完整的邮件内容（头部和正文，行尾为 CRLF）
+// 带日历邀请时正文为 multipart/alternative：不认识邀请的客户端显示纯文本，其余显示为日程
+func (m *Message) encode(from *netmail.Address, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+
+	if m.Calendar == nil {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeQuotedPrintable(part, m.Text); err != nil {
+		return nil, err
+	}
+
+	part, err = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/calendar; charset=utf-8; method=" + m.Calendar.Method},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeBase64(part, m.Calendar.Data); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable 按 quoted-printable 编码写入文本（文本模式下换行统一编码为 CRLF）
+func writeQuotedPrintable(w io.Writer, text string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, text); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 按 base64 编码写入，每行 76 个字符
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := fmt.Fprintf(w, "%s\r\n", encoded)
+	return err
+}
+
+// messageID 生成随机的 Message-ID，域名取发件地址的域名
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// 连接 SMTP 服务器的加密方式
+const (
+	SecurityStartTLS = "starttls" // 明文连接后升级（通常是 587 端口），服务器不支持时报错
+	SecurityTLS      = "tls"      // 直接建立 TLS 连接（通常是 465 端口）
+	SecurityNone     = "none"     // 不加密，只适合本机或内网的中继
+)
+
+// SMTP 通过 SMTP 服务器发送邮件
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string // 为空时不认证
+	Password string
+	From     string // 发件地址
+	Security string // SecurityStartTLS（默认）/ SecurityTLS / SecurityNone
+}
+
+// Send 实现 Mailer 接口，每封邮件单独建立一次连接
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("mail: no recipients")
+	}
+	from, err := netmail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("无效的发件地址 %q：%w", s.From, err)
+	}
+	data, err := msg.encode(from, time.Now())
+	if err != nil {
+		return fmt.Errorf("生成邮件失败：%w", err)
+	}
+
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器 %s 失败：%w", addr, err)
+	}
+	// 整个会话受 ctx 的截止时间约束，服务器无响应时不会一直阻塞
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if s.Security == SecurityTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.Host})
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP 握手失败：%w", err)
+	}
+	defer client.Close()
+
+	if s.Security == "" || s.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP 服务器不支持 STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return fmt.Errorf("STARTTLS 失败：%w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("SMTP 认证失败：%w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM 失败：%w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s 失败：%w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA 失败：%w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入邮件失败：%w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP 服务器拒绝邮件：%w", err)
+	}
+	return client.Quit()
+}
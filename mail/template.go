@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// 内置模板，每个模板由主题（<名称>.subject）和正文（<名称>.txt）两部分组成
+const (
+	TemplateNotification   = "notification"    // 邮件渠道的通知，数据为 notify.Notification
+	TemplateDeadlineInvite = "deadline_invite" // 截止日期日历邀请，数据为 invite.TemplateData
+	TemplateDeadlineCancel = "deadline_cancel" // 取消截止日期日历邀请，数据同上
+)
+
+// defaultTemplates 内置模板，可以用 MAIL_TEMPLATE_DIR 目录下的同名文件覆盖
+const defaultTemplates = `
+{{define "notification.subject"}}{{.Title}}{{end}}
+{{define "notification.txt"}}{{.Body}}
+{{end}}
+
+{{define "deadline_invite.subject"}}截止：{{.Title}}{{end}}
+{{define "deadline_invite.txt"}}{{.Title}}
+
+截止时间：{{.Due}}
+优先级：{{.Priority}}
+{{- if .Project}}
+项目：{{.Project}}
+{{- end}}
+{{- if .Description}}
+
+{{.Description}}
+{{- end}}
+{{end}}
+
+{{define "deadline_cancel.subject"}}已取消：{{.Title}}{{end}}
+{{define "deadline_cancel.txt"}}「{{.Title}}」已完成、调低了优先级或去掉了截止日期，日历中的截止日程已取消。
+{{end}}
+`
+
+// Templates 邮件模板
+type Templates struct {
+	tmpl *template.Template
+}
+
+// DefaultTemplates 返回内置模板
+func DefaultTemplates() *Templates {
+	return &Templates{tmpl: template.Must(template.New("mail").Parse(defaultTemplates))}
+}
+
+// LoadTemplates 在内置模板的基础上加载 dir 中的 *.tmpl 文件，
+// 文件名去掉 .tmpl 后就是模板名，例如 deadline_invite.subject.tmpl 覆盖截止日期邀请的主题
+func LoadTemplates(dir string) (*Templates, error) {
+	t := DefaultTemplates()
+	if dir == "" {
+		return t, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		if _, err := t.tmpl.New(name).Parse(string(data)); err != nil {
+			return nil, fmt.Errorf("解析邮件模板 %s 失败：%w", file, err)
+		}
+	}
+	return t, nil
+}
+
+// Render 用 data 渲染模板 name 的主题和正文，主题中的换行和连续空白合并为一个空格
+func (t *Templates) Render(name string, data interface{}) (subject, text string, err error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&buf, name+".subject", data); err != nil {
+		return "", "", fmt.Errorf("渲染邮件主题失败：%w", err)
+	}
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.tmpl.ExecuteTemplate(&buf, name+".txt", data); err != nil {
+		return "", "", fmt.Errorf("渲染邮件正文失败：%w", err)
+	}
+	return subject, buf.String(), nil
+}
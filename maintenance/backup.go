@@ -0,0 +1,120 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"todo-list/clock"
+)
+
+// BackupStore 备份需要的数据访问（database.DB 实现了该接口）
+type BackupStore interface {
+	BackupContext(ctx context.Context, path string) error
+}
+
+// backupPrefix 备份文件名前缀，清理旧备份时只处理带该前缀的文件
+const backupPrefix = "todos-"
+
+// Backup 把数据库备份到目录中，只保留最近 keep 份
+type Backup struct {
+	store BackupStore
+	dir   string
+	keep  int
+	clock clock.Clock
+}
+
+// NewBackup 创建备份任务
+func NewBackup(store BackupStore, dir string, keep int, clk clock.Clock) *Backup {
+	return &Backup{store: store, dir: dir, keep: keep, clock: clk}
+}
+
+// Run 执行一次备份（接受 Context 参数，供调度器使用）
+func (b *Backup) Run(ctx context.Context) {
+	path, err := b.backup(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("数据库备份超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("数据库备份已取消")
+			return
+		}
+		log.Printf("数据库备份失败: %v", err)
+		return
+	}
+	log.Printf("数据库备份完成: path=%s", path)
+
+	removed, err := b.prune()
+	if err != nil {
+		log.Printf("清理旧备份失败: %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("已删除旧备份: count=%d", removed)
+	}
+}
+
+// RunOnce 立即生成一份备份并清理旧备份，返回备份文件路径（通过管理接口提交的任务使用）
+// 清理失败只记日志，不影响本次备份的结果
+func (b *Backup) RunOnce(ctx context.Context) (string, error) {
+	path, err := b.backup(ctx)
+	if err != nil {
+		return "", err
+	}
+	if removed, err := b.prune(); err != nil {
+		log.Printf("清理旧备份失败: %v", err)
+	} else if removed > 0 {
+		log.Printf("已删除旧备份: count=%d", removed)
+	}
+	return path, nil
+}
+
+// backup 生成一份新备份，返回文件路径
+func (b *Backup) backup(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建备份目录失败：%w", err)
+	}
+
+	name := backupPrefix + b.clock.Now().UTC().Format("20060102-150405") + ".db"
+	path := filepath.Join(b.dir, name)
+	if err := b.store.BackupContext(ctx, path); err != nil {
+		// 失败时可能留下不完整的文件
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// prune 按文件名（即时间）排序，删除最旧的备份直到只剩 keep 份
+func (b *Backup) prune() (int, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return 0, fmt.Errorf("读取备份目录失败：%w", err)
+	}
+
+	var backups []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), backupPrefix) && strings.HasSuffix(e.Name(), ".db") {
+			backups = append(backups, e.Name())
+		}
+	}
+	if len(backups) <= b.keep {
+		return 0, nil
+	}
+
+	sort.Strings(backups)
+	removed := 0
+	for _, name := range backups[:len(backups)-b.keep] {
+		if err := os.Remove(filepath.Join(b.dir, name)); err != nil {
+			return removed, fmt.Errorf("删除备份 %s 失败：%w", name, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
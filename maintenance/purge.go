@@ -0,0 +1,82 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"todo-list/clock"
+)
+
+// PurgeStore 清理通知、变更事件和推送记录需要的数据访问（database.DB 和 storage/memory 实现了该接口）
+type PurgeStore interface {
+	PurgeReadNotificationsContext(ctx context.Context, before time.Time) (int, error)
+	PurgeTodoEventsContext(ctx context.Context, before time.Time) (int, error)
+	PurgeWebhookDeliveriesContext(ctx context.Context, before time.Time) (int, error)
+}
+
+// JobPurgeStore 清理后台任务需要的数据访问（database.DB 实现了该接口）
+type JobPurgeStore interface {
+	PurgeFinishedJobsContext(ctx context.Context, before time.Time) (int, error)
+}
+
+// Purger 删除超过保留期的已完成后台任务、已读通知、待办事项变更事件和 webhook 推送记录
+type Purger struct {
+	store     PurgeStore
+	jobs      JobPurgeStore // 没有后台任务队列时为 nil
+	retention time.Duration
+	clock     clock.Clock
+}
+
+// NewPurger 创建清理任务，后台任务和其他数据可以保存在不同的存储中，jobs 为 nil 时不清理后台任务
+func NewPurger(store PurgeStore, jobs JobPurgeStore, retention time.Duration, clk clock.Clock) *Purger {
+	return &Purger{store: store, jobs: jobs, retention: retention, clock: clk}
+}
+
+// Run 执行一次清理（接受 Context 参数，供调度器使用）
+func (p *Purger) Run(ctx context.Context) {
+	jobs, notifications, events, deliveries, err := p.purge(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("过期数据清理超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("过期数据清理已取消")
+			return
+		}
+		log.Printf("过期数据清理失败: %v", err)
+		return
+	}
+
+	log.Printf("过期数据清理完成: jobs=%d, notifications=%d, events=%d, webhook_deliveries=%d", jobs, notifications, events, deliveries)
+}
+
+// purge 删除保留期之前的数据，返回删除的任务数、通知数、变更事件数和 webhook 推送记录数
+func (p *Purger) purge(ctx context.Context) (int, int, int, int, error) {
+	before := p.clock.Now().Add(-p.retention)
+
+	var jobs int
+	if p.jobs != nil {
+		var err error
+		if jobs, err = p.jobs.PurgeFinishedJobsContext(ctx, before); err != nil {
+			return 0, 0, 0, 0, err
+		}
+	}
+
+	notifications, err := p.store.PurgeReadNotificationsContext(ctx, before)
+	if err != nil {
+		return jobs, 0, 0, 0, err
+	}
+
+	events, err := p.store.PurgeTodoEventsContext(ctx, before)
+	if err != nil {
+		return jobs, notifications, 0, 0, err
+	}
+
+	deliveries, err := p.store.PurgeWebhookDeliveriesContext(ctx, before)
+	if err != nil {
+		return jobs, notifications, events, 0, err
+	}
+	return jobs, notifications, events, deliveries, nil
+}
@@ -0,0 +1,90 @@
+// Package maintenance 内置的维护任务（数据库备份、过期数据清理），按 cron 计划执行
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"todo-list/scheduler"
+)
+
+// 内置任务名称，同时也是调度器中的任务名
+const (
+	JobBackup = "backup"
+	JobPurge  = "purge"
+)
+
+// Entry 一个内置任务的计划
+type Entry struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`           // cron 表达式，例如 "0 3 * * *"
+	Disabled bool   `json:"disabled,omitempty"` // 为 true 时不注册该任务
+}
+
+// Schedule 内置任务计划
+type Schedule struct {
+	Jobs []Entry `json:"jobs"`
+}
+
+// DefaultSchedule 默认计划：每天 3:00 备份，3:30 清理
+func DefaultSchedule() *Schedule {
+	return &Schedule{Jobs: []Entry{
+		{Name: JobBackup, Schedule: "0 3 * * *"},
+		{Name: JobPurge, Schedule: "30 3 * * *"},
+	}}
+}
+
+// LoadSchedule 从 JSON 文件加载计划，文件中没有出现的任务沿用默认计划
+//
+//	{
+//	  "jobs": [
+//	    {"name": "backup", "schedule": "0 */6 * * *"},
+//	    {"name": "purge", "schedule": "@weekly"}
+//	  ]
+//	}
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取任务计划失败：%w", err)
+	}
+
+	var file Schedule
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析任务计划失败：%w", err)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := DefaultSchedule()
+	for _, e := range file.Jobs {
+		for i := range s.Jobs {
+			if s.Jobs[i].Name == e.Name {
+				s.Jobs[i] = e
+			}
+		}
+	}
+	return s, nil
+}
+
+// Validate 校验任务名和 cron 表达式
+func (s *Schedule) Validate() error {
+	seen := make(map[string]bool)
+	for i, e := range s.Jobs {
+		if e.Name != JobBackup && e.Name != JobPurge {
+			return fmt.Errorf("第 %d 项: 未知的任务 %q", i+1, e.Name)
+		}
+		if seen[e.Name] {
+			return fmt.Errorf("第 %d 项: 任务 %q 重复", i+1, e.Name)
+		}
+		seen[e.Name] = true
+
+		if e.Disabled {
+			continue
+		}
+		if _, err := scheduler.ParseCron(e.Schedule); err != nil {
+			return fmt.Errorf("第 %d 项: %w", i+1, err)
+		}
+	}
+	return nil
+}
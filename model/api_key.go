@@ -0,0 +1,108 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// API key 的格式：固定前缀加 32 字节随机数的十六进制，前缀便于在日志和代码仓库中识别泄露的 key
+const (
+	APIKeyPrefix        = "tdl_"
+	apiKeyRandomBytes   = 32
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8 // 列表中展示的开头部分，用来区分不同的 key
+
+	// MaxAPIKeyNameLength API key 名称的长度上限（按字符数计算）
+	MaxAPIKeyNameLength = 100
+)
+
+// API key 的角色：admin 可以调用 /api/v1/admin/* 管理接口，member 只能使用普通接口
+const (
+	APIKeyRoleAdmin  = "admin"
+	APIKeyRoleMember = "member"
+)
+
+// APIKey 脚本和集成使用的 API key，服务端只保存哈希，明文只在创建时返回一次
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`   // 用途，例如 "备份脚本"
+	Prefix     string     `json:"prefix"` // key 的开头部分，例如 tdl_1a2b3c4d
+	UserID     string     `json:"user_id"`
+	Role       string     `json:"role" enums:"admin,member"`
+	Workspaces []string   `json:"workspaces,omitempty"` // member key 可以访问的工作区，admin key 不受限制
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Validate 规范化并校验 API key 的名称和角色，未指定角色时为 member
+func (k *APIKey) Validate() error {
+	k.Name = NormalizeTitle(k.Name)
+	if k.Name == "" {
+		return fmt.Errorf("名称不能为空")
+	}
+	if n := utf8.RuneCountInString(k.Name); n > MaxAPIKeyNameLength {
+		return fmt.Errorf("名称不能超过 %d 个字符，当前 %d 个", MaxAPIKeyNameLength, n)
+	}
+	switch k.Role {
+	case "":
+		k.Role = APIKeyRoleMember
+	case APIKeyRoleAdmin, APIKeyRoleMember:
+	default:
+		return fmt.Errorf("未知的角色 %q，只能是 %s 或 %s", k.Role, APIKeyRoleAdmin, APIKeyRoleMember)
+	}
+
+	// admin key 可以访问所有工作区，member key 未指定时只能访问默认工作区
+	if k.IsAdmin() {
+		if len(k.Workspaces) > 0 {
+			return fmt.Errorf("admin key 可以访问所有工作区，不能指定 workspaces")
+		}
+		return nil
+	}
+	if len(k.Workspaces) == 0 {
+		k.Workspaces = []string{DefaultWorkspace}
+	}
+	for _, slug := range k.Workspaces {
+		if !ValidWorkspaceSlug(slug) {
+			return fmt.Errorf("无效的工作区标识 %q", slug)
+		}
+	}
+	return nil
+}
+
+// IsAdmin 是否可以调用管理接口
+func (k *APIKey) IsAdmin() bool {
+	return k.Role == APIKeyRoleAdmin
+}
+
+// CanAccess 能否访问工作区 slug
+func (k *APIKey) CanAccess(slug string) bool {
+	return k.IsAdmin() || slices.Contains(k.Workspaces, slug)
+}
+
+// Active 在 now 时刻是否可以使用：没有撤销也没有过期
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// NewAPIKey 生成新的 API key 明文，并设置 k 的展示前缀
+func NewAPIKey(k *APIKey) string {
+	b := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic("读取随机数失败: " + err.Error())
+	}
+	key := APIKeyPrefix + hex.EncodeToString(b)
+	k.Prefix = key[:apiKeyDisplayLength]
+	return key
+}
+
+// HashAPIKey API key 明文的 SHA-256，数据库中按它查找；key 本身是高熵随机数，不需要加盐
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// 附件扫描状态
+const (
+	AttachmentClean       = "clean"       // 扫描通过
+	AttachmentUnscanned   = "unscanned"   // 未配置扫描器
+	AttachmentQuarantined = "quarantined" // 发现病毒，文件已隔离，不允许下载
+)
+
+// Attachment 待办事项的文件附件
+type Attachment struct {
+	ID          int       `json:"id"`
+	TodoID      int       `json:"todo_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	Status      string    `json:"status"`                // clean, unscanned, quarantined
+	ScanResult  string    `json:"scan_result,omitempty"` // 命中的病毒特征名
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,292 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// 自动化规则的触发事件
+const (
+	EventTodoCreated   = "todo.created"   // 新建之前触发，动作直接作用于将要保存的待办事项
+	EventTodoCompleted = "todo.completed" // 进入终态之后触发，动作修改后再保存一次
+)
+
+// AutomationEvents 支持的触发事件
+var AutomationEvents = []string{EventTodoCreated, EventTodoCompleted}
+
+// AutomationRule 自动化规则：事件发生且条件（CEL 表达式）为 true 时，依次执行动作
+//
+//	{"name": "账单", "event": "todo.created",
+//	 "condition": "todo.title.contains('账单') && todo.priority >= 1",
+//	 "actions": [{"set": "due_date", "value": "first_of_next_month"}]}
+//
+// 表达式中的 todo 包含 title、description、status、priority、project_id（没有项目时为 0）、
+// estimated_minutes（未预估时为 0）和 due_date（timestamp，没有截止日期时不存在，用 has(todo.due_date) 判断），
+// 可以使用 CEL 标准函数和字符串扩展（lowerAscii、split、trim 等）
+type AutomationRule struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	Event     string             `json:"event"`
+	Condition string             `json:"condition"` // 为空表示总是满足
+	Actions   []AutomationAction `json:"actions"`
+	Enabled   bool               `json:"enabled"`
+	CreatedAt time.Time          `json:"created_at"`
+
+	// Conditions 旧版的条件列表（全部满足），只用于读取旧数据和旧归档，由 MigrateConditions 转换为 Condition
+	Conditions []AutomationCondition `json:"conditions,omitempty"`
+}
+
+// AutomationCondition 旧版规则的一个条件，新规则请使用 CEL 表达式
+//
+//	field: title / description / status
+//	op:    contains（不区分大小写）/ equals / prefix / matches（正则表达式）
+type AutomationCondition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// AutomationAction 一个动作：把字段设为给定的值
+//
+//	due_date:          today / tomorrow / +3d / +2w / first_of_next_month / end_of_month，按用户时区计算到当天零点
+//	status:            工作流中的状态（由调用方校验）
+//	estimated_minutes: 正整数
+type AutomationAction struct {
+	Set   string `json:"set"`
+	Value string `json:"value"`
+}
+
+// relativeDatePattern +3d、+2w 形式的相对日期
+var relativeDatePattern = regexp.MustCompile(`^\+(\d{1,3})([dw])$`)
+
+// Validate 校验规则结构，状态值是否存在于工作流由调用方检查
+func (r *AutomationRule) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("规则名称不能为空")
+	}
+	if !slices.Contains(AutomationEvents, r.Event) {
+		return fmt.Errorf("不支持的事件 %q，可选：%s", r.Event, strings.Join(AutomationEvents, "、"))
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("至少需要一个动作")
+	}
+
+	if len(r.Conditions) > 0 && strings.TrimSpace(r.Condition) != "" {
+		return fmt.Errorf("condition 和 conditions 只能使用其中一个")
+	}
+	if err := r.MigrateConditions(); err != nil {
+		return err
+	}
+	r.Condition = strings.TrimSpace(r.Condition)
+	if r.Condition != "" {
+		prg, err := compileCondition(r.Condition)
+		if err != nil {
+			return fmt.Errorf("无效的条件表达式：%v", err)
+		}
+		// todo 的字段是 dyn，编译时无法确定 todo.title 这类表达式的类型，用一个空的待办事项试算一次；
+		// 求值出错（例如没有判断 has(todo.due_date)）只在执行时记日志，不在这里拒绝
+		if out, _, err := prg.Eval(map[string]interface{}{"todo": conditionVars(&Todo{})}); err == nil {
+			if _, ok := out.Value().(bool); !ok {
+				return fmt.Errorf("无效的条件表达式：结果必须是 bool，实际为 %s", out.Type().TypeName())
+			}
+		}
+	}
+
+	for i, a := range r.Actions {
+		switch a.Set {
+		case "due_date":
+			// 只检查写法，参考时间不影响结果
+			if _, err := resolveRuleDate(a.Value, time.Time{}, time.UTC); err != nil {
+				return fmt.Errorf("第 %d 个动作：%v", i+1, err)
+			}
+		case "status":
+			if a.Value == "" {
+				return fmt.Errorf("第 %d 个动作：状态不能为空", i+1)
+			}
+		case "estimated_minutes":
+			if n, err := strconv.Atoi(a.Value); err != nil || n <= 0 {
+				return fmt.Errorf("第 %d 个动作：预估耗时必须是正整数", i+1)
+			}
+		default:
+			return fmt.Errorf("第 %d 个动作：不支持的字段 %q", i+1, a.Set)
+		}
+	}
+	return nil
+}
+
+// Matches 待办事项是否满足规则的条件，表达式求值失败时返回错误
+func (r *AutomationRule) Matches(todo *Todo) (bool, error) {
+	if r.Condition == "" {
+		return true, nil
+	}
+	prg, err := compileCondition(r.Condition)
+	if err != nil {
+		return false, err
+	}
+	out, _, err := prg.Eval(map[string]interface{}{"todo": conditionVars(todo)})
+	if err != nil {
+		return false, err
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("条件表达式的结果不是 bool：%v", out)
+	}
+	return matched, nil
+}
+
+// MigrateConditions 把旧版的条件列表转换为等价的 CEL 表达式写入 Condition，并清空 Conditions
+func (r *AutomationRule) MigrateConditions() error {
+	if len(r.Conditions) == 0 {
+		r.Conditions = nil
+		return nil
+	}
+
+	exprs := make([]string, 0, len(r.Conditions))
+	for i, c := range r.Conditions {
+		switch c.Field {
+		case "title", "description", "status":
+		default:
+			return fmt.Errorf("第 %d 个条件：不支持的字段 %q", i+1, c.Field)
+		}
+		field := "todo." + c.Field
+		value := strconv.Quote(c.Value)
+
+		switch c.Op {
+		case "contains":
+			exprs = append(exprs, fmt.Sprintf("%s.lowerAscii().contains(%s)", field, strconv.Quote(strings.ToLower(c.Value))))
+		case "equals":
+			exprs = append(exprs, fmt.Sprintf("%s == %s", field, value))
+		case "prefix":
+			exprs = append(exprs, fmt.Sprintf("%s.startsWith(%s)", field, value))
+		case "matches":
+			if _, err := regexp.Compile(c.Value); err != nil {
+				return fmt.Errorf("第 %d 个条件：无效的正则表达式: %v", i+1, err)
+			}
+			exprs = append(exprs, fmt.Sprintf("%s.matches(%s)", field, value))
+		default:
+			return fmt.Errorf("第 %d 个条件：不支持的运算 %q", i+1, c.Op)
+		}
+	}
+	r.Condition = strings.Join(exprs, " && ")
+	r.Conditions = nil
+	return nil
+}
+
+// conditionCostLimit 单次求值的开销上限，防止表达式在大字段上做过多的运算
+const conditionCostLimit = 100000
+
+// conditionEnv 条件表达式的 CEL 环境，只声明一个 todo 变量
+var conditionEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("todo", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Strings(),
+	)
+})
+
+// conditionPrograms 已编译的条件表达式，规则在每次事件时重新读取，按表达式文本缓存编译结果
+var conditionPrograms sync.Map // string -> cel.Program
+
+// compileCondition 编译条件表达式，要求结果类型为 bool
+func compileCondition(expr string) (cel.Program, error) {
+	if prg, ok := conditionPrograms.Load(expr); ok {
+		return prg.(cel.Program), nil
+	}
+
+	env, err := conditionEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("结果必须是 bool，实际为 %s", ast.OutputType())
+	}
+	prg, err := env.Program(ast, cel.CostLimit(conditionCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	conditionPrograms.Store(expr, prg)
+	return prg, nil
+}
+
+// conditionVars 条件表达式中 todo 变量的内容
+func conditionVars(todo *Todo) map[string]interface{} {
+	vars := map[string]interface{}{
+		"title":             todo.Title,
+		"description":       todo.Description,
+		"status":            todo.Status,
+		"priority":          todo.Priority,
+		"project_id":        0,
+		"estimated_minutes": 0,
+	}
+	if todo.ProjectID != nil {
+		vars["project_id"] = *todo.ProjectID
+	}
+	if todo.EstimatedMinutes != nil {
+		vars["estimated_minutes"] = *todo.EstimatedMinutes
+	}
+	if todo.DueDate != nil {
+		vars["due_date"] = *todo.DueDate
+	}
+	return vars
+}
+
+// Apply 对待办事项执行规则的动作，日期按 loc 计算
+func (r *AutomationRule) Apply(todo *Todo, now time.Time, loc *time.Location) error {
+	for _, a := range r.Actions {
+		switch a.Set {
+		case "due_date":
+			due, err := resolveRuleDate(a.Value, now, loc)
+			if err != nil {
+				return err
+			}
+			todo.SetDueDate(due, now)
+		case "status":
+			todo.Status = a.Value
+		case "estimated_minutes":
+			n, err := strconv.Atoi(a.Value)
+			if err != nil {
+				return fmt.Errorf("无效的预估耗时 %q", a.Value)
+			}
+			todo.EstimatedMinutes = &n
+		}
+	}
+	return nil
+}
+
+// resolveRuleDate 计算相对日期，结果为 loc 中当天零点（UTC 表示）
+func resolveRuleDate(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	switch value {
+	case "today":
+		return today.UTC(), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1).UTC(), nil
+	case "first_of_next_month":
+		return time.Date(local.Year(), local.Month()+1, 1, 0, 0, 0, 0, loc).UTC(), nil
+	case "end_of_month":
+		return time.Date(local.Year(), local.Month()+1, 0, 0, 0, 0, 0, loc).UTC(), nil
+	}
+
+	if m := relativeDatePattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		return today.AddDate(0, 0, n).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期 %q，可选 today、tomorrow、+3d、+2w、first_of_next_month、end_of_month", value)
+}
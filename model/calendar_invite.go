@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// CalendarInvite 发给日历的截止日期邀请
+// 每个待办事项对应一个日程（UID 固定），截止日期变化时提高 Sequence 重新发送，日历会更新原来的日程
+type CalendarInvite struct {
+	TodoID   int       `json:"todo_id"`
+	DueDate  time.Time `json:"due_date"` // 最近一次邀请中的截止时间
+	Sequence int       `json:"sequence"` // iCalendar SEQUENCE，每发送一次加一
+	Canceled bool      `json:"canceled"` // 最近一次发送的是取消
+	SentAt   time.Time `json:"sent_at"`
+}
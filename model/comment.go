@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 评论正文长度上限（字符）
+const MaxCommentLength = 5000
+
+// mentionPattern 匹配 @username；前面必须是开头或空白，避免把邮箱地址当成提及
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9_][A-Za-z0-9_.-]{0,31})`)
+
+// Comment 待办事项的评论
+type Comment struct {
+	ID        int       `json:"id"`
+	TodoID    int       `json:"todo_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Mentions  []string  `json:"mentions"` // 正文中 @ 到的用户
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewComment 在 now 时刻创建评论并解析其中的提及
+func NewComment(todoID int, author, body string, now time.Time) *Comment {
+	body = strings.TrimSpace(body)
+	return &Comment{
+		TodoID:    todoID,
+		Author:    author,
+		Body:      body,
+		Mentions:  ParseMentions(body),
+		CreatedAt: now.UTC(),
+	}
+}
+
+// Validate 校验评论
+func (c *Comment) Validate() error {
+	if c.Body == "" {
+		return fmt.Errorf("评论内容不能为空")
+	}
+	if utf8.RuneCountInString(c.Body) > MaxCommentLength {
+		return fmt.Errorf("评论内容不能超过 %d 个字符", MaxCommentLength)
+	}
+	return nil
+}
+
+// ParseMentions 提取正文中 @ 到的用户名（小写、去重、保持出现顺序）
+func ParseMentions(body string) []string {
+	mentions := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// 句末的标点不属于用户名，例如 "@alice."
+		name := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		mentions = append(mentions, name)
+	}
+	return mentions
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dueDateLayouts 截止日期可接受的不带时区的格式，按调用方给出的时区解释
+var dueDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+}
+
+// ParseDueDate 解析截止日期，结果统一为 UTC
+// RFC3339（带时区偏移）按原样解析；2024-06-01、2024-06-01 17:00 等不带时区的格式按 loc 解释
+func ParseDueDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range dueDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别的日期 %q，请使用 2024-06-01、2024-06-01 17:00 或 RFC3339 格式", value)
+}
+
+// HasZoneOffset 日期字符串是否自带时区（RFC3339），带时区时不需要用户时区
+func HasZoneOffset(value string) bool {
+	_, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	return err == nil
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Goal 目标：例如季度目标，进度由关联的待办事项的完成情况计算
+type Goal struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	TargetDate  string       `json:"target_date,omitempty"` // 目标日期，格式 2006-01-02
+	Progress    GoalProgress `json:"progress"`
+	Todos       []Todo       `json:"todos,omitempty"` // 只在查看单个目标时返回
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// GoalProgress 目标进度：已完成（进入终态）的关联待办事项占比
+type GoalProgress struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Percent   int `json:"percent"` // 0-100，向下取整；没有关联待办事项时为 0
+}
+
+// NewGoalProgress 根据关联数量和完成数量计算进度
+func NewGoalProgress(total, completed int) GoalProgress {
+	p := GoalProgress{Total: total, Completed: completed}
+	if total > 0 {
+		p.Percent = completed * 100 / total
+	}
+	return p
+}
+
+// Validate 规范化并校验目标
+func (g *Goal) Validate() error {
+	g.Title = NormalizeTitle(g.Title)
+	g.Description = NormalizeDescription(g.Description)
+	if g.Title == "" {
+		return fmt.Errorf("标题不能为空")
+	}
+	if g.TargetDate != "" {
+		if _, err := time.Parse("2006-01-02", g.TargetDate); err != nil {
+			return fmt.Errorf("无效的目标日期 %q，请使用 2024-06-30 格式", g.TargetDate)
+		}
+	}
+	return nil
+}
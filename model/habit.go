@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// 习惯的重复频率
+const (
+	HabitDaily  = "daily"
+	HabitWeekly = "weekly"
+)
+
+// Habit 习惯：按频率自动生成新的待办事项，并记录连续完成的周期数
+// 与单个待办事项的重复规则不同，习惯是模板，每个周期生成一个独立的待办事项
+type Habit struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Frequency   string    `json:"frequency"`    // daily / weekly
+	Weekday     int       `json:"weekday"`      // weekly 时在星期几生成，0 表示星期日，默认 1（星期一）
+	Active      bool      `json:"active"`       // 暂停后不再生成新的待办事项，连续记录保留
+	Streak      int       `json:"streak"`       // 当前连续完成的周期数
+	BestStreak  int       `json:"best_streak"`  // 历史最长连续周期数
+	LastPeriod  string    `json:"last_period"`  // 最近一次生成的周期，例如 2024-06-01 或 2024-W22
+	LastTodoID  *int      `json:"last_todo_id"` // 最近一次生成的待办事项
+	CreatedAt   time.Time `json:"created_at"`
+	Workspace   string    `json:"-"`
+}
+
+// Validate 规范化并校验习惯
+func (h *Habit) Validate() error {
+	h.Title = NormalizeTitle(h.Title)
+	h.Description = NormalizeDescription(h.Description)
+	if h.Title == "" {
+		return fmt.Errorf("标题不能为空")
+	}
+	switch h.Frequency {
+	case HabitDaily:
+	case HabitWeekly:
+		if h.Weekday < 0 || h.Weekday > 6 {
+			return fmt.Errorf("weekday 必须在 0（星期日）到 6（星期六）之间")
+		}
+	default:
+		return fmt.Errorf("不支持的频率 %q，可选 daily、weekly", h.Frequency)
+	}
+	return nil
+}
+
+// Period 返回 now 所在的周期标识，尚未到生成时间（每周习惯还没到指定的星期几）时返回空字符串
+// now 应当已经转换到用户时区
+func (h *Habit) Period(now time.Time) string {
+	if h.Frequency == HabitDaily {
+		return now.Format("2006-01-02")
+	}
+
+	// 以星期一为一周的开始（ISO 周），星期日是一周的第 7 天
+	day := func(w time.Weekday) int {
+		if w == time.Sunday {
+			return 7
+		}
+		return int(w)
+	}
+	if day(now.Weekday()) < day(time.Weekday(h.Weekday)) {
+		return ""
+	}
+	year, week := now.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// NewTodo 为某个周期生成待办事项，截止时间为该周期的最后一天结束
+func (h *Habit) NewTodo(now time.Time) *Todo {
+	todo := NewTodo(h.Title, h.Description, now)
+
+	end := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+	if h.Frequency == HabitWeekly {
+		// 到本周星期日为止
+		if w := now.Weekday(); w != time.Sunday {
+			end = end.AddDate(0, 0, 7-int(w))
+		}
+	}
+	todo.SetDueDate(end.UTC(), now)
+	return todo
+}
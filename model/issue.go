@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// IssueImport 从 Jira、Linear 等外部系统导入的工单与待办事项的对应关系
+// 每个工单只导入一次；之后只在外部状态变化时同步完成状态，本地的修改不会被覆盖
+type IssueImport struct {
+	Source   string    `json:"source"` // jira / linear
+	Key      string    `json:"key"`    // 工单编号，例如 PROJ-123、ENG-42
+	TodoID   int       `json:"todo_id"`
+	Status   string    `json:"status"` // 外部系统中的状态名称
+	Done     bool      `json:"done"`   // 外部系统中是否已完成（包括取消）
+	SyncedAt time.Time `json:"synced_at"`
+}
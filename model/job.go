@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 后台任务状态
+const (
+	JobPending = "pending" // 等待执行（包括等待重试）
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead" // 重试次数用完，进入死信，需要人工重新入队
+)
+
+// Job 持久化的后台任务
+type Job struct {
+	ID          int             `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"` // 最早可以执行的时间
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// 以下字段只对通过接口提交的长时间操作有意义，内部任务（如通知重试）为零值
+	Workspace     string          `json:"workspace,omitempty"` // 提交任务时所在的工作区
+	ProgressDone  int             `json:"progress_done"`
+	ProgressTotal int             `json:"progress_total"` // 0 表示总量未知
+	Result        json.RawMessage `json:"result,omitempty"`
+}
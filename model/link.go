@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// 链接元数据抓取状态
+const (
+	LinkStatusPending = "pending"
+	LinkStatusFetched = "fetched"
+	LinkStatusFailed  = "failed"
+	LinkStatusSkipped = "skipped" // 关闭了链接预览（LINK_PREVIEW=false），不抓取
+)
+
+// TodoLink 待办事项关联的链接资源
+type TodoLink struct {
+	ID         int        `json:"id"`
+	TodoID     int        `json:"todo_id"`
+	URL        string     `json:"url"`
+	Title      string     `json:"title,omitempty"`
+	FaviconURL string     `json:"favicon_url,omitempty"`
+	Status     string     `json:"status"` // pending, fetched, failed, skipped
+	CreatedAt  time.Time  `json:"created_at"`
+	FetchedAt  *time.Time `json:"fetched_at,omitempty"`
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// 通知渠道
+const (
+	ChannelInApp   = "in_app"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// NotificationChannels 所有支持的通知渠道
+var NotificationChannels = []string{ChannelInApp, ChannelEmail, ChannelWebhook}
+
+// NotificationPreferences 用户的通知偏好
+// 提醒 / 摘要等后台任务在发送任何通知前都要先检查这里
+type NotificationPreferences struct {
+	UserID          string          `json:"user_id"`
+	Channels        map[string]bool `json:"channels"`                    // 渠道开关
+	QuietHoursStart string          `json:"quiet_hours_start,omitempty"` // 免打扰开始，HH:MM
+	QuietHoursEnd   string          `json:"quiet_hours_end,omitempty"`   // 免打扰结束，HH:MM（可以跨午夜）
+	Timezone        string          `json:"timezone"`                    // IANA 时区，例如 Asia/Shanghai
+	DigestTime      string          `json:"digest_time,omitempty"`       // 每日摘要发送时间，HH:MM
+	MutedProjects   []int           `json:"muted_projects"`              // 静音的项目 ID
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// DefaultNotificationPreferences 默认偏好：只开启站内通知，无免打扰
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID: userID,
+		Channels: map[string]bool{
+			ChannelInApp:   true,
+			ChannelEmail:   false,
+			ChannelWebhook: false,
+		},
+		Timezone:      "UTC",
+		MutedProjects: []int{},
+	}
+}
+
+// Validate 校验偏好设置
+func (p *NotificationPreferences) Validate() error {
+	for channel := range p.Channels {
+		if !isKnownChannel(channel) {
+			return fmt.Errorf("未知的通知渠道: %s", channel)
+		}
+	}
+
+	if (p.QuietHoursStart == "") != (p.QuietHoursEnd == "") {
+		return fmt.Errorf("免打扰开始和结束时间必须同时设置")
+	}
+	for _, v := range []string{p.QuietHoursStart, p.QuietHoursEnd, p.DigestTime} {
+		if v == "" {
+			continue
+		}
+		if _, err := parseClock(v); err != nil {
+			return err
+		}
+	}
+
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("无效的时区: %s", p.Timezone)
+	}
+
+	for _, id := range p.MutedProjects {
+		if id <= 0 {
+			return fmt.Errorf("无效的静音项目 ID: %d", id)
+		}
+	}
+
+	return nil
+}
+
+// ChannelEnabled 渠道是否开启
+func (p *NotificationPreferences) ChannelEnabled(channel string) bool {
+	return p.Channels[channel]
+}
+
+// ProjectMuted 项目是否被静音，projectID 为 0 表示不属于任何项目
+func (p *NotificationPreferences) ProjectMuted(projectID int) bool {
+	return projectID != 0 && slices.Contains(p.MutedProjects, projectID)
+}
+
+// InQuietHours 判断某个时刻是否处于免打扰时段（按用户时区计算）
+func (p *NotificationPreferences) InQuietHours(t time.Time) bool {
+	if p.QuietHoursStart == "" || p.QuietHoursEnd == "" {
+		return false
+	}
+
+	start, err1 := parseClock(p.QuietHoursStart)
+	end, err2 := parseClock(p.QuietHoursEnd)
+	if err1 != nil || err2 != nil || start == end {
+		return false
+	}
+
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+
+	if start < end {
+		return minutes >= start && minutes < end
+	}
+	// 跨午夜，例如 22:00 - 07:00
+	return minutes >= start || minutes < end
+}
+
+// Allows 综合渠道开关、项目静音和免打扰时段，判断此刻能否发送
+func (p *NotificationPreferences) Allows(channel string, projectID int, at time.Time) bool {
+	return p.ChannelEnabled(channel) && !p.ProjectMuted(projectID) && !p.InQuietHours(at)
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为 HH:MM: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func isKnownChannel(channel string) bool {
+	for _, c := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// 站内通知类型
+const (
+	NotificationMention  = "mention"  // 在评论中被 @
+	NotificationReminder = "reminder" // 逾期升级提醒
+	NotificationStale    = "stale"    // 停滞事项提醒
+)
+
+// Notification 一条站内通知，ReadAt 为空表示未读
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    string     `json:"user_id"`
+	Kind      string     `json:"kind"`
+	TodoID    *int       `json:"todo_id,omitempty"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body,omitempty"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
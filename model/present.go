@@ -0,0 +1,121 @@
+package model
+
+import (
+	"reflect"
+	"slices"
+	"time"
+	"todo-list/clock"
+)
+
+// Presenter 输出待办事项前计算不存储的字段：is_overdue、due_in_seconds 和 priority_label
+type Presenter struct {
+	Clock        clock.Clock
+	OpenStatuses []string      // 未完成的状态（工作流中的非终态），与存储统计 overdue 的口径一致
+	Scale        PriorityScale // PRIORITY_LABELS 映射
+}
+
+// DefaultPresenter 默认工作流和默认优先级映射下的 Presenter
+func DefaultPresenter(c clock.Clock) Presenter {
+	return Presenter{Clock: c, OpenStatuses: []string{"pending"}, Scale: DefaultPriorityScale}
+}
+
+// Present 按当前时间填入待办事项的 IsOverdue、DueInSeconds 和 PriorityLabel
+// 截止时间是确定的时刻，结果与请求所在时区无关
+func (p Presenter) Present(t *Todo) {
+	t.IsOverdue, t.DueInSeconds = p.dueProjection(t, p.Clock.Now())
+	t.PriorityLabel = p.Scale.Label(t.Priority)
+}
+
+// dueProjection 计算 now 时刻的逾期状态和距截止时间的秒数，没有截止日期时秒数为 nil
+func (p Presenter) dueProjection(t *Todo, now time.Time) (bool, *int64) {
+	if t.DueDate == nil {
+		return false, nil
+	}
+	seconds := int64(t.DueDate.Sub(now) / time.Second)
+	return slices.Contains(p.OpenStatuses, t.Status) && t.DueDate.Before(now), &seconds
+}
+
+var todoType = reflect.TypeOf(Todo{})
+
+// Apply 对 v 中包含的所有待办事项（结构体字段、切片、map 中的）调用 Present，返回处理后的值
+// 通过指针引用的待办事项原地修改，按值保存的会先复制一份，因此要使用返回值
+func (p Presenter) Apply(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !mayContainTodo(rv.Type()) {
+		return v
+	}
+	out := reflect.New(rv.Type()).Elem()
+	out.Set(rv)
+	p.walk(out, make(map[uintptr]bool))
+	return out.Interface()
+}
+
+// walk 递归处理可寻址的值 v，seen 记录已经处理过的指针，避免重复和循环引用
+func (p Presenter) walk(v reflect.Value, seen map[uintptr]bool) {
+	if !mayContainTodo(v.Type()) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		p.walk(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		elem := v.Elem()
+		if !mayContainTodo(elem.Type()) {
+			return
+		}
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		p.walk(cp, seen)
+		v.Set(cp)
+	case reflect.Struct:
+		if v.Type() == todoType {
+			if v.CanAddr() {
+				p.Present(v.Addr().Interface().(*Todo))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				p.walk(f, seen)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			p.walk(v.Index(i), seen)
+		}
+	case reflect.Map:
+		if !v.CanSet() {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			cp := reflect.New(v.Type().Elem()).Elem()
+			cp.Set(iter.Value())
+			p.walk(cp, seen)
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	}
+}
+
+// mayContainTodo 类型中是否可能包含待办事项：字符串、数字等基本类型（包括 []byte）直接跳过
+func mayContainTodo(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return mayContainTodo(t.Elem())
+	case reflect.Struct:
+		return t != reflect.TypeOf(time.Time{})
+	case reflect.Interface:
+		return true
+	}
+	return false
+}
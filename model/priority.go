@@ -0,0 +1,148 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultPriority 新建待办事项的默认优先级，与 todos.priority 列的默认值一致
+const DefaultPriority = 1
+
+// PriorityLabel 优先级名称和对应的数值
+type PriorityLabel struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// PriorityScale 优先级名称与数值的映射，按数值从低到高排列
+type PriorityScale []PriorityLabel
+
+// DefaultPriorityScale 未配置 PRIORITY_LABELS 时使用的映射
+var DefaultPriorityScale = PriorityScale{
+	{Name: "low", Value: 0},
+	{Name: "medium", Value: 1},
+	{Name: "high", Value: 2},
+	{Name: "urgent", Value: 3},
+}
+
+// ParsePriorityScale 解析 "low=0,medium=1,high=2,urgent=3" 格式的映射
+// 名称不区分大小写，名称和数值都不能重复
+func ParsePriorityScale(s string) (PriorityScale, error) {
+	var scale PriorityScale
+	names := make(map[string]bool)
+	values := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("格式应为 名称=数值：%q", part)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("名称不能为空：%q", part)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("数值无效：%q", part)
+		}
+		if names[name] || values[n] {
+			return nil, fmt.Errorf("名称或数值重复：%q", part)
+		}
+		names[name], values[n] = true, true
+		scale = append(scale, PriorityLabel{Name: name, Value: n})
+	}
+	sort.Slice(scale, func(i, j int) bool { return scale[i].Value < scale[j].Value })
+	return scale, nil
+}
+
+// String 按 PRIORITY_LABELS 的格式输出映射
+func (s PriorityScale) String() string {
+	parts := make([]string, len(s))
+	for i, l := range s {
+		parts[i] = l.Name + "=" + strconv.Itoa(l.Value)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Label 返回数值对应的名称：取数值不超过 value 的最高一档，低于最低一档时返回空字符串
+// 这样映射调整后，库里已有的数值仍然能落到某一档
+func (s PriorityScale) Label(value int) string {
+	label := ""
+	for _, l := range s {
+		if l.Value > value {
+			break
+		}
+		label = l.Name
+	}
+	return label
+}
+
+// Value 返回名称对应的数值，名称不区分大小写
+func (s PriorityScale) Value(name string) (int, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, l := range s {
+		if l.Name == name {
+			return l.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Priority 请求中的优先级，既可以是数值（2），也可以是名称（"high"）
+type Priority struct {
+	value int
+	label string
+}
+
+// UnmarshalJSON 接受数字或字符串
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var label string
+	if err := json.Unmarshal(data, &label); err == nil {
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("priority 不能为空字符串")
+		}
+		*p = Priority{label: label}
+		return nil
+	}
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("priority 必须是整数或优先级名称")
+	}
+	*p = Priority{value: value}
+	return nil
+}
+
+// ParsePriority 解析查询参数中的优先级：整数按数值，其余按名称，换算和校验见 Resolve
+func ParsePriority(s string) Priority {
+	s = strings.TrimSpace(s)
+	if value, err := strconv.Atoi(s); err == nil {
+		return Priority{value: value}
+	}
+	return Priority{label: s}
+}
+
+// Resolve 按映射换算为数值：名称必须在映射中，数值必须落在最低档和最高档之间
+func (p Priority) Resolve(scale PriorityScale) (int, error) {
+	if p.label != "" {
+		value, ok := scale.Value(p.label)
+		if !ok {
+			return 0, fmt.Errorf("未知的优先级 %q，可选 %s", p.label, scale.names())
+		}
+		return value, nil
+	}
+	if len(scale) > 0 && (p.value < scale[0].Value || p.value > scale[len(scale)-1].Value) {
+		return 0, fmt.Errorf("优先级必须在 %d 到 %d 之间", scale[0].Value, scale[len(scale)-1].Value)
+	}
+	return p.value, nil
+}
+
+// names 返回以顿号分隔的名称列表，用于错误提示
+func (s PriorityScale) names() string {
+	names := make([]string, len(s))
+	for i, l := range s {
+		names[i] = l.Name
+	}
+	return strings.Join(names, "、")
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxProjectNameLength 项目名称的长度上限（按字符数计算）
+const MaxProjectNameLength = 100
+
+// Project 项目（清单）：把待办事项分组，例如“工作”和“个人”；一个待办事项最多属于一个项目
+type Project struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"` // 同一工作区内不区分大小写唯一
+	Description string     `json:"description"`
+	TodoCount   int        `json:"todo_count"`
+	OpenCount   int        `json:"open_count"` // 未进入终态的待办事项数
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"` // 归档后其中的待办事项默认不出现在列表中
+}
+
+// Validate 规范化并校验项目
+func (p *Project) Validate() error {
+	p.Name = NormalizeTitle(p.Name)
+	p.Description = NormalizeDescription(p.Description)
+	if p.Name == "" {
+		return fmt.Errorf("项目名称不能为空")
+	}
+	if n := utf8.RuneCountInString(p.Name); n > MaxProjectNameLength {
+		return fmt.Errorf("项目名称不能超过 %d 个字符，当前 %d 个", MaxProjectNameLength, n)
+	}
+	return nil
+}
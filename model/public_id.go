@@ -0,0 +1,87 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// crockford ULID 使用的 Crockford Base32 字母表（去掉了 I、L、O、U）
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// PublicIDLength 外部标识的长度
+const PublicIDLength = 26
+
+// NewPublicID 生成待办事项的外部标识（ULID）：前 48 位是 t 的毫秒时间戳，后 80 位随机
+// 与自增 ID 不同，外部标识无法被猜出，且按字典序排列时与创建时间一致
+func NewPublicID(t time.Time) string {
+	var b [16]byte
+	ms := uint64(t.UnixMilli())
+	binary.BigEndian.PutUint16(b[0:2], uint16(ms>>32))
+	binary.BigEndian.PutUint32(b[2:6], uint32(ms))
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic("读取随机数失败: " + err.Error())
+	}
+	return encodePublicID(b)
+}
+
+// encodePublicID 把 128 位按 5 位一组从低位开始编码，共 26 个字符，第一个字符只用到 3 位
+func encodePublicID(b [16]byte) string {
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var out [PublicIDLength]byte
+	for i := PublicIDLength - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// NormalizePublicID 校验外部标识并转换为大写，格式不对时返回 false
+func NormalizePublicID(s string) (string, bool) {
+	if len(s) != PublicIDLength {
+		return "", false
+	}
+	s = strings.ToUpper(s)
+	if s[0] > '7' { // 超过 128 位
+		return "", false
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(crockford, s[i]) < 0 {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// PublicIDFromUUID 把 UUID（如 taskwarrior 的 uuid）按相同的 128 位转换为外部标识，格式不对时返回 false
+func PublicIDFromUUID(uuid string) (string, bool) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(uuid, "-", ""))
+	if err != nil || len(raw) != 16 {
+		return "", false
+	}
+	var b [16]byte
+	copy(b[:], raw)
+	return encodePublicID(b), true
+}
+
+// PublicIDToUUID 把外部标识的 128 位按 UUID 的格式输出，与 PublicIDFromUUID 互逆
+func PublicIDToUUID(id string) (string, bool) {
+	id, ok := NormalizePublicID(id)
+	if !ok {
+		return "", false
+	}
+	var hi, lo uint64
+	for i := 0; i < len(id); i++ {
+		v := uint64(strings.IndexByte(crockford, id[i]))
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | v
+	}
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], hi)
+	binary.BigEndian.PutUint64(b[8:], lo)
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], true
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// 加密备注的限制：密文按解码后的字节数计算，密钥提示按字符数计算
+const (
+	MaxSecretNoteBytes   = 64 << 10
+	MaxSecretNoteKeyHint = 200
+)
+
+// SecretNote 端到端加密的备注：客户端加密后上传密文，服务端原样保存和返回，从不解密
+// 加密算法和密钥由客户端决定，KeyHint 是帮助用户找回密钥的提示（如密钥名称），不能是密钥本身
+type SecretNote struct {
+	Ciphertext string `json:"ciphertext" example:"bm9uY2UuLi5jaXBoZXJ0ZXh0"` // 标准 base64 编码的密文
+	KeyHint    string `json:"key_hint,omitempty" example:"家里的密码本"`
+}
+
+// Check 校验密文格式、密文大小和密钥提示长度；密文为空表示清除备注，不在这里校验
+func (n *SecretNote) Check() []Violation {
+	var violations []Violation
+	raw, err := base64.StdEncoding.DecodeString(n.Ciphertext)
+	switch {
+	case err != nil:
+		violations = append(violations, Violation{
+			Field:      "secret_note.ciphertext",
+			Constraint: "format",
+			Message:    "加密备注的密文必须是标准 base64 编码",
+		})
+	case len(raw) > MaxSecretNoteBytes:
+		violations = append(violations, Violation{
+			Field:      "secret_note.ciphertext",
+			Constraint: "max_length",
+			Limit:      MaxSecretNoteBytes,
+			Actual:     len(raw),
+			Message:    fmt.Sprintf("加密备注的密文不能超过 %d 字节，当前 %d 字节", MaxSecretNoteBytes, len(raw)),
+		})
+	}
+	return append(violations, checkLength("secret_note.key_hint", "密钥提示", n.KeyHint, MaxSecretNoteKeyHint)...)
+}
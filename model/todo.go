@@ -1,52 +1,168 @@
 package model
 
 import (
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// 标题和描述的默认长度上限（按字符数计算）
+const (
+	DefaultMaxTitleLength       = 500
+	DefaultMaxDescriptionLength = 10000
+)
+
+// TextLimits 标题和描述的长度上限，0 表示不限制
+type TextLimits struct {
+	MaxTitle       int
+	MaxDescription int
+}
+
+// Violation 一项未通过的字段约束
+type Violation struct {
+	Field      string `json:"field"`
+	Constraint string `json:"constraint"`       // required / max_length / not_past / format
+	Limit      int    `json:"limit,omitempty"`  // max_length 的上限
+	Actual     int    `json:"actual,omitempty"` // max_length 时的实际字符数
+	Message    string `json:"message"`
+}
+
 // Todo 表示一个待办事项
 type Todo struct {
 	ID          int        `json:"id"`
+	PublicID    string     `json:"public_id"` // 外部标识（ULID），可以代替 id 用在路径中
 	Version     int        `json:"version"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
-	Status      string     `json:"status"` // pending, completed
+	Status      string     `json:"status"` // 由工作流决定，默认 pending / completed
 	DueDate     *time.Time `json:"due_date,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// 位置提醒：靠近该位置 Radius 米以内时提示
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Radius    *float64 `json:"radius,omitempty"`
+
+	// 预估耗时（分钟），用于工作量视图
+	EstimatedMinutes *int `json:"estimated_minutes,omitempty"`
+
+	// 所属项目，为空表示不属于任何项目
+	ProjectID *int `json:"project_id,omitempty"`
+
+	// 优先级数值，名称由 PRIORITY_LABELS 映射得到，输出前由 Presenter 填入 PriorityLabel
+	Priority      int    `json:"priority"`
+	PriorityLabel string `json:"priority_label,omitempty"`
+
+	// 重复规则（RFC 5545 RRULE，如 FREQ=WEEKLY;BYDAY=MO），完成后由后台任务按规则生成下一次
+	// RecurrenceStart 是整个系列的起点（相当于 DTSTART），NextOccurrenceID 是已经生成的下一次
+	Recurrence       string     `json:"recurrence,omitempty"`
+	RecurrenceStart  *time.Time `json:"recurrence_start,omitempty"`
+	NextOccurrenceID *int       `json:"next_occurrence_id,omitempty"`
+
+	// 端到端加密的备注，服务端只保存密文，见 SecretNote
+	SecretNote *SecretNote `json:"secret_note,omitempty"`
+
+	// 列表接口传 truncate_description 时描述只保留前若干个字符，被截断时为 true
+	DescriptionTruncated bool `json:"description_truncated,omitempty"`
+
+	// 列表接口传 numbering 时的显示编号，不存储在待办事项中，见 GET /api/v1/todos/numbers
+	Number int `json:"number,omitempty"`
+
+	// 以下两项不存储，每次输出 JSON 前按当前时间计算（见 Presenter），客户端不需要各自实现逾期判断
+	IsOverdue    bool   `json:"is_overdue"`               // 未完成且已过截止时间，与统计接口的 overdue 口径一致
+	DueInSeconds *int64 `json:"due_in_seconds,omitempty"` // 距截止时间的秒数，已过截止时间为负数
 }
 
-// NewTodo 创建一个新的待办事项
-func NewTodo(title, description string) *Todo {
-	now := time.Now()
+// NewTodo 创建一个新的待办事项，创建时间为 now
+func NewTodo(title, description string, now time.Time) *Todo {
 	return &Todo{
 		Version:     1,
 		Title:       title,
 		Description: description,
 		Status:      "pending",
+		Priority:    DefaultPriority,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 }
 
-// Complete 标记待办事项为完成
-func (t *Todo) Complete() {
-	now := time.Now()
+// NormalizeTitle 去掉首尾空白，并把连续的空白（包括换行、制表符）合并为一个空格
+func NormalizeTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
+
+// NormalizeDescription 去掉首尾空白，正文中的换行保持不变
+func NormalizeDescription(description string) string {
+	return strings.TrimSpace(description)
+}
+
+// CheckTitle 校验规范化之后的标题：不能为空，不能超过长度上限
+func (l TextLimits) CheckTitle(title string) []Violation {
+	if title == "" {
+		return []Violation{{Field: "title", Constraint: "required", Message: "标题不能为空"}}
+	}
+	return checkLength("title", "标题", title, l.MaxTitle)
+}
+
+// CheckDescription 校验规范化之后的描述长度
+func (l TextLimits) CheckDescription(description string) []Violation {
+	return checkLength("description", "描述", description, l.MaxDescription)
+}
+
+// checkLength 超过上限时返回一项 max_length 约束
+func checkLength(field, label, value string, limit int) []Violation {
+	if limit <= 0 {
+		return nil
+	}
+	n := utf8.RuneCountInString(value)
+	if n <= limit {
+		return nil
+	}
+	return []Violation{{
+		Field:      field,
+		Constraint: "max_length",
+		Limit:      limit,
+		Actual:     n,
+		Message:    fmt.Sprintf("%s不能超过 %d 个字符，当前 %d 个", label, limit, n),
+	}}
+}
+
+// Complete 在 now 时刻标记待办事项为完成
+func (t *Todo) Complete(now time.Time) {
 	t.Status = "completed"
 	t.UpdatedAt = now
 	t.CompletedAt = &now
 }
 
-// Reactivate 重新激活待办事项
-func (t *Todo) Reactivate() {
+// Reactivate 在 now 时刻重新激活待办事项
+func (t *Todo) Reactivate(now time.Time) {
 	t.Status = "pending"
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = now
 	t.CompletedAt = nil
 }
 
-// SetDueDate 设置截止日期
-func (t *Todo) SetDueDate(dueDate time.Time) {
+// TruncateDescription 描述超过 n 个字符时只保留前 n 个字符，并标记 DescriptionTruncated；n <= 0 时不截断
+func (t *Todo) TruncateDescription(n int) {
+	if n <= 0 || utf8.RuneCountInString(t.Description) <= n {
+		return
+	}
+	t.Description = string([]rune(t.Description)[:n])
+	t.DescriptionTruncated = true
+}
+
+// SetDueDate 在 now 时刻设置截止日期
+func (t *Todo) SetDueDate(dueDate, now time.Time) {
 	t.DueDate = &dueDate
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = now
+}
+
+// SetLocation 在 now 时刻设置位置提醒
+func (t *Todo) SetLocation(lat, lng float64, radius *float64, now time.Time) {
+	t.Latitude = &lat
+	t.Longitude = &lng
+	t.Radius = radius
+	t.UpdatedAt = now
 }
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// 待办事项变更事件类型
+const (
+	TodoEventCreated   = "created"
+	TodoEventUpdated   = "updated"
+	TodoEventCompleted = "completed"
+	TodoEventDeleted   = "deleted"
+)
+
+// TodoEventTypes 所有变更事件类型，订阅时可以从中选择
+var TodoEventTypes = []string{TodoEventCreated, TodoEventUpdated, TodoEventCompleted, TodoEventDeleted}
+
+// TodoEvent 待办事项的一次变更，由数据库触发器记录，ID 单调递增，作为 SSE 的事件 ID
+type TodoEvent struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"` // created / updated / completed / deleted
+	TodoID    int       `json:"todo_id"`
+	PublicID  string    `json:"public_id,omitempty"`
+	Version   int       `json:"version"`              // 变更后的版本号，deleted 为删除前的版本号
+	ProjectID *int      `json:"project_id,omitempty"` // 变更后所属的项目，deleted 为删除前的
+	Priority  int       `json:"priority"`             // 变更后的优先级，deleted 为删除前的
+	At        time.Time `json:"at"`
+	Todo      *Todo     `json:"todo,omitempty"` // 推送时的最新内容，deleted 或已被删除时为空
+}
+
+// EventFilter 变更事件的过滤条件，webhook 订阅和 SSE 连接都可以设置，在服务端判断；
+// 所有条件同时满足才推送，没有设置的条件不限制
+type EventFilter struct {
+	Events      []string `json:"events"`                 // 事件类型，为空表示全部
+	ProjectID   *int     `json:"project_id,omitempty"`   // 只推送该项目的事件，0 表示不属于任何项目的
+	MinPriority *int     `json:"min_priority,omitempty"` // 只推送优先级不低于该值的事件
+}
+
+// Validate 校验事件类型和项目，事件类型去重
+func (f *EventFilter) Validate() error {
+	seen := make(map[string]bool, len(f.Events))
+	events := make([]string, 0, len(f.Events))
+	for _, e := range f.Events {
+		if !slices.Contains(TodoEventTypes, e) {
+			return fmt.Errorf("未知的事件类型：%s", e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	f.Events = events
+
+	if f.ProjectID != nil && *f.ProjectID < 0 {
+		return fmt.Errorf("project_id 不能为负数")
+	}
+	return nil
+}
+
+// Matches 事件是否满足过滤条件；项目和优先级按事件记录的值（变更后，deleted 为删除前）判断
+func (f *EventFilter) Matches(e *TodoEvent) bool {
+	if len(f.Events) > 0 && !slices.Contains(f.Events, e.Type) {
+		return false
+	}
+	if f.ProjectID != nil {
+		project := 0
+		if e.ProjectID != nil {
+			project = *e.ProjectID
+		}
+		if project != *f.ProjectID {
+			return false
+		}
+	}
+	return f.MinPriority == nil || e.Priority >= *f.MinPriority
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// 分片上传的用途，决定上传完成后如何处理文件
+const (
+	UploadPurposeAttachment = "attachment" // 作为待办事项的附件保存
+	UploadPurposeImport     = "import"     // 作为导入文件（.json / .csv）导入待办事项
+)
+
+// Upload 分片上传会话：客户端按 Content-Range 逐段上传，断线后查询 offset 从断点继续
+type Upload struct {
+	ID        string    `json:"id"`
+	Workspace string    `json:"-"`
+	Purpose   string    `json:"purpose"`           // attachment / import
+	TodoID    int       `json:"todo_id,omitempty"` // 附件所属的待办事项
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`   // 文件总大小（字节）
+	Offset    int64     `json:"offset"` // 已接收的字节数，下一段从这里开始
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"` // 过期后会话和已接收的数据被清理
+}
+
+// Complete 是否已接收全部数据
+func (u *Upload) Complete() bool {
+	return u.Offset >= u.Size
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// 出站 webhook 的限制
+const (
+	MaxWebhookURLLength = 2048
+	webhookSecretBytes  = 32
+)
+
+// Webhook 出站 webhook 订阅：工作区内待办事项发生变更、且满足过滤条件时向 URL 推送事件
+type Webhook struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+	EventFilter
+	Active bool   `json:"active"`
+	Secret string `json:"-"` // 签名密钥，只在创建时返回
+
+	LastStatus     int        `json:"last_status,omitempty"` // 最近一次推送的 HTTP 状态码，连接失败时为 0
+	LastError      string     `json:"last_error,omitempty"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Validate 校验 URL 和过滤条件
+func (w *Webhook) Validate() error {
+	if len(w.URL) > MaxWebhookURLLength {
+		return fmt.Errorf("url 不能超过 %d 个字符", MaxWebhookURLLength)
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url 必须是 http 或 https 地址")
+	}
+	return w.EventFilter.Validate()
+}
+
+// NewWebhookSecret 生成签名密钥
+func NewWebhookSecret() string {
+	b := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		panic("读取随机数失败: " + err.Error())
+	}
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// WebhookDelivery 一次推送尝试的记录，包括自动推送、重试、测试推送和手动重试
+type WebhookDelivery struct {
+	ID        int       `json:"id"`
+	WebhookID int       `json:"webhook_id"`
+	Delivery  string    `json:"delivery"`           // X-Webhook-Delivery，同一事件的多次尝试相同
+	Event     string    `json:"event"`              // 事件类型，测试推送为 ping
+	EventID   int64     `json:"event_id,omitempty"` // 变更事件 ID，测试推送没有
+	Attempt   int       `json:"attempt"`            // 同一推送 ID 的第几次尝试，从 1 开始
+	Success   bool      `json:"success"`
+	Status    int       `json:"status,omitempty"` // 接收方的 HTTP 状态码，没有收到响应时为空
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	Response  string    `json:"response,omitempty"` // 响应体的开头部分
+	CreatedAt time.Time `json:"created_at"`
+
+	Payload []byte `json:"-"` // 请求体，手动重试时原样重发（签名和时间戳重新生成）
+}
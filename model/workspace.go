@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultWorkspace 未指定工作区时使用的默认工作区（旧数据都属于它）
+const DefaultWorkspace = "default"
+
+// workspaceSlugPattern 工作区标识：小写字母、数字和连字符，用在 URL 路径中
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// Workspace 工作区（租户），不同工作区的数据相互隔离
+type Workspace struct {
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	MaxTodos  int       `json:"max_todos"` // 待办事项数量上限，0 表示不限制
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate 校验工作区字段
+func (ws *Workspace) Validate() error {
+	ws.Slug = strings.ToLower(strings.TrimSpace(ws.Slug))
+	ws.Name = strings.TrimSpace(ws.Name)
+
+	if !ValidWorkspaceSlug(ws.Slug) {
+		return fmt.Errorf("工作区标识只能包含小写字母、数字和连字符，长度 1-40")
+	}
+	if ws.Name == "" {
+		ws.Name = ws.Slug
+	}
+	if len([]rune(ws.Name)) > 100 {
+		return fmt.Errorf("工作区名称不能超过 100 个字符")
+	}
+	if ws.MaxTodos < 0 {
+		return fmt.Errorf("max_todos 不能为负数")
+	}
+	return nil
+}
+
+// ValidWorkspaceSlug 判断工作区标识是否合法
+func ValidWorkspaceSlug(slug string) bool {
+	return workspaceSlugPattern.MatchString(slug)
+}
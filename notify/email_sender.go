@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"context"
+	"todo-list/mail"
+	"todo-list/model"
+)
+
+// EmailSender 邮件渠道：按 notification 模板生成邮件，发给配置的收件人
+type EmailSender struct {
+	Mailer    mail.Mailer
+	Templates *mail.Templates
+	To        []string
+}
+
+// Channel 实现 Sender 接口
+func (s EmailSender) Channel() string {
+	return model.ChannelEmail
+}
+
+// Send 实现 Sender 接口
+func (s EmailSender) Send(ctx context.Context, n Notification) error {
+	subject, text, err := s.Templates.Render(mail.TemplateNotification, n)
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, &mail.Message{To: s.To, Subject: subject, Text: text})
+}
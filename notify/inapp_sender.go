@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+)
+
+// NotificationStore 保存站内通知（database.DB 实现了该接口）
+type NotificationStore interface {
+	CreateNotificationContext(ctx context.Context, n *model.Notification) error
+}
+
+// InAppSender 站内通知渠道：写入通知表，由通知中心接口读取
+type InAppSender struct {
+	Store NotificationStore
+	Clock clock.Clock // 通知的创建时间，为 nil 时使用系统时钟
+}
+
+// Channel 实现 Sender 接口
+func (s InAppSender) Channel() string {
+	return model.ChannelInApp
+}
+
+// Send 实现 Sender 接口
+func (s InAppSender) Send(ctx context.Context, n Notification) error {
+	record := &model.Notification{
+		UserID:    n.UserID,
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Body:      n.Body,
+		CreatedAt: s.now().UTC(),
+	}
+	if n.TodoID > 0 {
+		todoID := n.TodoID
+		record.TodoID = &todoID
+	}
+	return s.Store.CreateNotificationContext(ctx, record)
+}
+
+// now 按 Clock 取当前时间
+func (s InAppSender) now() time.Time {
+	if s.Clock == nil {
+		return clock.Real{}.Now()
+	}
+	return s.Clock.Now()
+}
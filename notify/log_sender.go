@@ -0,0 +1,23 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogSender 只把通知写入日志的发送器
+// 在真正的渠道（邮件、webhook 等）接入之前作为占位，保证提醒流程可以端到端运行
+type LogSender struct {
+	ChannelName string
+}
+
+// Channel 实现 Sender 接口
+func (s LogSender) Channel() string {
+	return s.ChannelName
+}
+
+// Send 实现 Sender 接口
+func (s LogSender) Send(ctx context.Context, n Notification) error {
+	log.Printf("[notify:%s] user=%s todo_id=%d title=%q", s.ChannelName, n.UserID, n.TodoID, n.Title)
+	return nil
+}
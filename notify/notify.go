@@ -0,0 +1,179 @@
+// Package notify 负责把通知分发到各个渠道，发送前统一检查用户的通知偏好
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"todo-list/breaker"
+	"todo-list/clock"
+	"todo-list/model"
+)
+
+// ErrSuppressed 通知被用户偏好拦截（渠道关闭、项目静音或处于免打扰时段）
+var ErrSuppressed = errors.New("notify: suppressed by user preferences")
+
+// ErrUnknownChannel 没有注册对应渠道的发送器
+var ErrUnknownChannel = errors.New("notify: unknown channel")
+
+// RetryJobKind 发送失败后重试通知的后台任务类型
+const RetryJobKind = "notify"
+
+// Notification 一条待发送的通知，也是发给各渠道（如 webhook）的事件载荷
+// 字段的增减必须遵守 SchemaVersion 的兼容性约定，并同步 schema.go 中的说明
+type Notification struct {
+	SchemaVersion int `json:"schema_version"` // 发送时由 Dispatcher 填入 SchemaVersion
+
+	UserID    string `json:"user_id"`
+	Kind      string `json:"kind"`                 // 通知类型（model.Notification*），站内通知按类型展示
+	Project   string `json:"project"`              // 保留字段，始终为空；项目静音按 ProjectID 判断
+	ProjectID int    `json:"project_id,omitempty"` // 待办事项所属项目，0 表示不属于任何项目
+	TodoID    int    `json:"todo_id"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+}
+
+// RetryQueue 持久化任务队列（jobs.Queue 实现了该接口）
+type RetryQueue interface {
+	Enqueue(ctx context.Context, kind string, payload interface{}) error
+}
+
+// retryPayload 重试任务的参数
+type retryPayload struct {
+	Channel      string       `json:"channel"`
+	Notification Notification `json:"notification"`
+}
+
+// Sender 通知渠道的发送器
+type Sender interface {
+	Channel() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// PreferencesStore 读取通知偏好（database.DB 实现了该接口）
+type PreferencesStore interface {
+	GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+}
+
+// Dispatcher 通知分发器
+// 提醒、摘要等后台任务都应通过 Dispatcher 发送，而不是直接调用 Sender
+type Dispatcher struct {
+	store    PreferencesStore
+	senders  map[string]Sender
+	retry    RetryQueue   // 为空时发送失败直接返回错误
+	breakers *breaker.Set // 为空时不熔断
+	clock    clock.Clock  // 判断免打扰时段，见 SetClock
+}
+
+// NewDispatcher 创建通知分发器
+func NewDispatcher(store PreferencesStore, senders ...Sender) *Dispatcher {
+	d := &Dispatcher{
+		store:   store,
+		senders: make(map[string]Sender),
+		clock:   clock.Real{},
+	}
+	for _, s := range senders {
+		d.senders[s.Channel()] = s
+	}
+	return d
+}
+
+// Register 注册（或替换）某个渠道的发送器
+func (d *Dispatcher) Register(s Sender) {
+	d.senders[s.Channel()] = s
+}
+
+// SetClock 替换判断免打扰时段使用的时钟（测试中使用 clock.Fake）
+func (d *Dispatcher) SetClock(c clock.Clock) {
+	d.clock = c
+}
+
+// SetRetryQueue 设置重试队列：渠道发送失败时写入队列由后台重试，而不是把错误返回给调用方
+func (d *Dispatcher) SetRetryQueue(q RetryQueue) {
+	d.retry = q
+}
+
+// SetBreakers 设置熔断器：外部渠道连续失败后直接返回 breaker.ErrOpen（转入重试队列），
+// 不再等待下游超时，避免拖慢同一轮的其他提醒；站内通知写本地数据库，不熔断
+func (d *Dispatcher) SetBreakers(set *breaker.Set) {
+	d.breakers = set
+}
+
+// Send 检查偏好后通过指定渠道发送通知
+// 被偏好拦截时返回 ErrSuppressed，调用方可以据此决定稍后重试（免打扰结束后）或直接丢弃
+// 设置了重试队列时，渠道发送失败会转入队列并返回 nil
+func (d *Dispatcher) Send(ctx context.Context, channel string, n Notification) error {
+	err := d.deliver(ctx, channel, n)
+	if err == nil || d.retry == nil || errors.Is(err, ErrSuppressed) || errors.Is(err, ErrUnknownChannel) {
+		return err
+	}
+
+	if qerr := d.retry.Enqueue(ctx, RetryJobKind, retryPayload{Channel: channel, Notification: n}); qerr != nil {
+		return errors.Join(err, fmt.Errorf("写入重试队列失败：%w", qerr))
+	}
+	log.Printf("通知发送失败，已转入重试队列: channel=%s, todo_id=%d, error=%v", channel, n.TodoID, err)
+	return nil
+}
+
+// HandleRetryJob 执行重试任务（注册到 jobs.Queue）
+// 重试时仍然检查偏好，此时被拦截（例如用户已经关闭该渠道）视为完成，不再重试
+func (d *Dispatcher) HandleRetryJob(ctx context.Context, payload json.RawMessage) error {
+	var p retryPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("解析重试任务失败：%w", err)
+	}
+
+	err := d.deliver(ctx, p.Channel, p.Notification)
+	if errors.Is(err, ErrSuppressed) {
+		return nil
+	}
+	return err
+}
+
+// deliver 检查偏好后调用渠道发送器
+func (d *Dispatcher) deliver(ctx context.Context, channel string, n Notification) error {
+	sender, ok := d.senders[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	prefs, err := d.store.GetNotificationPreferencesContext(ctx, n.UserID)
+	if err != nil {
+		return fmt.Errorf("读取通知偏好失败：%w", err)
+	}
+
+	if !prefs.Allows(channel, n.ProjectID, d.clock.Now()) {
+		return ErrSuppressed
+	}
+
+	// 重试队列中的旧载荷没有版本号，统一按当前结构发出
+	n.SchemaVersion = SchemaVersion
+	if channel == model.ChannelInApp {
+		return sender.Send(ctx, n)
+	}
+	return d.breakers.Get("notify:" + channel).Do(func() error {
+		return sender.Send(ctx, n)
+	})
+}
+
+// Broadcast 向用户开启的所有渠道发送通知，返回实际发送成功的渠道
+func (d *Dispatcher) Broadcast(ctx context.Context, n Notification) ([]string, error) {
+	var sent []string
+	var errs []error
+
+	for channel := range d.senders {
+		err := d.Send(ctx, channel, n)
+		if errors.Is(err, ErrSuppressed) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		sent = append(sent, channel)
+	}
+
+	return sent, errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package notify
+
+import "todo-list/model"
+
+// SchemaVersion 通知事件载荷（Notification 的 JSON）的结构版本，随每条通知的 schema_version 发出
+//
+// 兼容性约定：
+//   - 同一版本内只会新增字段，已有字段不会删除、改名或改变类型，取值含义不变
+//   - 新增的通知类型（kind）不视为不兼容，消费方应忽略不认识的 kind 和字段
+//   - 不兼容的修改才会增加版本号，并在 EventSchema.Changes 中说明
+const SchemaVersion = 1
+
+// EventSchema 通知事件载荷的结构说明，由 GET /api/v1/events/schema 返回
+type EventSchema struct {
+	SchemaVersion int           `json:"schema_version"`
+	Compatibility []string      `json:"compatibility"`
+	Fields        []EventField  `json:"fields"`
+	Kinds         []EventKind   `json:"kinds"`
+	Changes       []EventChange `json:"changes"`
+}
+
+// EventField 载荷中的一个字段
+type EventField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // JSON 类型：string / integer
+	Description string `json:"description"`
+	Since       int    `json:"since"` // 从哪个 schema_version 开始提供
+}
+
+// EventKind 一种通知类型
+type EventKind struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Since       int    `json:"since"`
+}
+
+// EventChange 某个版本的变更说明
+type EventChange struct {
+	SchemaVersion int    `json:"schema_version"`
+	Description   string `json:"description"`
+}
+
+// compatibility 与 SchemaVersion 注释中的约定一致，随结构说明一起返回给消费方
+var compatibility = []string{
+	"同一 schema_version 内只新增字段，已有字段不会删除、改名或改变类型，取值含义不变",
+	"消费方应忽略不认识的字段和通知类型（kind）",
+	"不兼容的修改会增加 schema_version，并在 changes 中说明",
+}
+
+// eventFields 与 Notification 的 JSON 字段一一对应，新增字段时在这里补充并写明 since
+var eventFields = []EventField{
+	{"schema_version", "integer", "载荷结构版本", 1},
+	{"user_id", "string", "接收通知的用户", 1},
+	{"kind", "string", "通知类型，见 kinds", 1},
+	{"project", "string", "保留字段，始终为空字符串，请使用 project_id", 1},
+	{"project_id", "integer", "待办事项所属项目的 ID，不属于任何项目时省略", 1},
+	{"todo_id", "integer", "相关的待办事项 ID", 1},
+	{"title", "string", "标题", 1},
+	{"body", "string", "正文", 1},
+}
+
+// eventKinds 已定义的通知类型
+var eventKinds = []EventKind{
+	{model.NotificationMention, "在评论中被 @", 1},
+	{model.NotificationReminder, "逾期升级提醒", 1},
+	{model.NotificationStale, "停滞事项提醒", 1},
+}
+
+// eventChanges 各版本的变更记录
+var eventChanges = []EventChange{
+	{1, "首个带版本号的载荷结构"},
+}
+
+// Schema 返回当前版本的通知事件载荷结构说明
+func Schema() EventSchema {
+	return EventSchema{
+		SchemaVersion: SchemaVersion,
+		Compatibility: compatibility,
+		Fields:        eventFields,
+		Kinds:         eventKinds,
+		Changes:       eventChanges,
+	}
+}
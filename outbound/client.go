@@ -0,0 +1,146 @@
+// Package outbound 提供访问外部 URL 的 HTTP 客户端，内置 SSRF 防护
+// 所有出站请求（链接抓取、webhook 推送、同步等）都应通过这里创建的客户端发出，
+// 运维可以统一配置代理、超时、响应大小上限以及允许/禁止访问的主机和网段。
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+	"todo-list/breaker"
+)
+
+// 出站请求错误
+var (
+	ErrForbiddenAddress = errors.New("outbound: forbidden destination address")
+	ErrForbiddenHost    = errors.New("outbound: destination host not allowed")
+	ErrResponseTooLarge = errors.New("outbound: response body too large")
+)
+
+// 默认配置
+const (
+	DefaultTimeout          = 10 * time.Second
+	DefaultMaxRedirects     = 5
+	DefaultMaxResponseBytes = 5 << 20
+)
+
+// Options 出站客户端配置
+type Options struct {
+	Timeout          time.Duration // 整个请求（含读取响应体）的超时
+	MaxRedirects     int           // 最多跟随的重定向次数
+	MaxResponseBytes int64         // 响应体上限，超出时读取返回 ErrResponseTooLarge
+
+	// Proxy 出站代理，为空表示直连
+	// 走代理时无法在建连阶段检查目标 IP，改为发请求前解析目标主机并检查
+	Proxy *url.URL
+
+	AllowHosts []string     // 非空时只允许访问这些主机，支持 *.example.com 通配子域名
+	DenyHosts  []string     // 禁止访问的主机，优先级高于 AllowHosts
+	AllowCIDRs []*net.IPNet // 额外放行的网段（例如需要访问的内网服务），优先级高于内置禁止规则
+	DenyCIDRs  []*net.IPNet // 额外禁止的网段
+
+	// Breakers 按目标主机熔断（名称为 http:主机名），为空表示不熔断
+	// 连接失败和 5xx 响应计为失败，被策略拦截的请求不计入
+	Breakers *breaker.Set
+}
+
+// DefaultOptions 返回默认配置：直连、不限制主机、禁止内网地址
+func DefaultOptions() Options {
+	return Options{
+		Timeout:          DefaultTimeout,
+		MaxRedirects:     DefaultMaxRedirects,
+		MaxResponseBytes: DefaultMaxResponseBytes,
+	}
+}
+
+// NewClient 创建带 SSRF 防护的 HTTP 客户端
+// 直连时在建立 TCP 连接前检查解析后的真实 IP（而不是主机名），可以防御 DNS rebinding
+func NewClient(opts Options) *http.Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.MaxResponseBytes <= 0 {
+		opts.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+
+	p := &policy{opts: opts}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if opts.Proxy == nil {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return p.checkIP(net.ParseIP(host))
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy: nil, // 不走环境变量代理，否则检查的是代理地址而不是目标地址
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	if opts.Proxy != nil {
+		transport.Proxy = http.ProxyURL(opts.Proxy)
+	}
+
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &guardedTransport{policy: p, next: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= opts.MaxRedirects {
+				return fmt.Errorf("outbound: stopped after %d redirects", opts.MaxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// guardedTransport 在发送请求前检查目标主机，并限制响应体大小
+// 重定向后的每一跳也会经过这里
+type guardedTransport struct {
+	policy *policy
+	next   http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.checkRequest(req); err != nil {
+		return nil, err
+	}
+
+	b := t.policy.opts.Breakers.Get("http:" + req.URL.Hostname())
+	if err := b.Allow(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, req.URL.Hostname())
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		b.Record(err)
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		b.Record(fmt.Errorf("HTTP %d", resp.StatusCode))
+	} else {
+		b.Record(nil)
+	}
+
+	if resp.ContentLength > t.policy.opts.MaxResponseBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooLarge, resp.ContentLength)
+	}
+	resp.Body = &limitedBody{rc: resp.Body, remaining: t.policy.opts.MaxResponseBytes}
+	return resp, nil
+}
@@ -0,0 +1,161 @@
+package outbound
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// policy 出站访问规则
+type policy struct {
+	opts Options
+}
+
+// checkRequest 检查请求的目标主机是否允许访问
+func (p *policy) checkRequest(req *http.Request) error {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrForbiddenHost, req.URL.Scheme)
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	if matchHost(p.opts.DenyHosts, host) {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+	if len(p.opts.AllowHosts) > 0 && !matchHost(p.opts.AllowHosts, host) {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+
+	// 直连时在建连阶段检查真实 IP；走代理时只能在这里提前解析
+	if p.opts.Proxy != nil {
+		ips, err := net.DefaultResolver.LookupIPAddr(req.Context(), host)
+		if err != nil {
+			return fmt.Errorf("outbound: resolve %s: %w", host, err)
+		}
+		for _, ip := range ips {
+			if err := p.checkIP(ip.IP); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkIP 检查目标 IP 是否允许访问
+func (p *policy) checkIP(ip net.IP) error {
+	if ip == nil {
+		return fmt.Errorf("%w: invalid ip", ErrForbiddenAddress)
+	}
+	if containsIP(p.opts.DenyCIDRs, ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	if containsIP(p.opts.AllowCIDRs, ip) {
+		return nil
+	}
+	if isForbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
+
+// matchHost 判断主机是否匹配规则列表，*.example.com 匹配所有子域名（不含 example.com 本身）
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// containsIP 判断 IP 是否落在任一网段内
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forbiddenPrefixes 默认禁止访问的网段：不属于公网、或者可能经由网关转到内网的地址
+var forbiddenPrefixes = []netip.Prefix{
+	// IPv4
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络（0.0.0.0 在 Linux 上等同于本机）
+	netip.MustParsePrefix("10.0.0.0/8"),      // 私有网段
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级 NAT（CGNAT），常用于云厂商内网
+	netip.MustParsePrefix("127.0.0.0/8"),     // 回环
+	netip.MustParsePrefix("169.254.0.0/16"),  // 链路本地，包括云厂商元数据服务 169.254.169.254
+	netip.MustParsePrefix("172.16.0.0/12"),   // 私有网段
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF 协议分配
+	netip.MustParsePrefix("192.0.2.0/24"),    // 文档示例（TEST-NET-1）
+	netip.MustParsePrefix("192.168.0.0/16"),  // 私有网段
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试
+	netip.MustParsePrefix("198.51.100.0/24"), // 文档示例（TEST-NET-2）
+	netip.MustParsePrefix("203.0.113.0/24"),  // 文档示例（TEST-NET-3）
+	netip.MustParsePrefix("224.0.0.0/4"),     // 组播
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留，包括广播地址 255.255.255.255
+	// IPv6（IPv4 映射地址 ::ffff:0:0/96 先转换成 IPv4 再检查）
+	netip.MustParsePrefix("::/128"),         // 未指定地址
+	netip.MustParsePrefix("::1/128"),        // 回环
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64，内嵌的 IPv4 地址可能是内网
+	netip.MustParsePrefix("64:ff9b:1::/48"), // 本地 NAT64
+	netip.MustParsePrefix("100::/64"),       // 丢弃
+	netip.MustParsePrefix("2001:db8::/32"),  // 文档示例
+	netip.MustParsePrefix("2002::/16"),      // 6to4，内嵌的 IPv4 地址可能是内网
+	netip.MustParsePrefix("fc00::/7"),       // 唯一本地地址
+	netip.MustParsePrefix("fe80::/10"),      // 链路本地
+	netip.MustParsePrefix("ff00::/8"),       // 组播
+}
+
+// isForbiddenIP 判断是否落在 forbiddenPrefixes 中，无法解析的地址也视为禁止
+func isForbiddenIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedBody 超过上限时返回 ErrResponseTooLarge，而不是像 io.LimitReader 那样静默截断
+type limitedBody struct {
+	rc        io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// 多读一个字节确认是否真的超限
+		var one [1]byte
+		n, err := b.rc.Read(one[:])
+		if n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.rc.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
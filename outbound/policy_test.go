@@ -0,0 +1,109 @@
+package outbound
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestForbiddenIP(t *testing.T) {
+	// 每个禁止的网段取首尾两个地址
+	forbidden := []string{
+		"0.0.0.0", "0.255.255.255",
+		"10.0.0.1", "10.255.255.255",
+		"100.64.0.1", "100.127.255.255",
+		"127.0.0.1", "127.255.255.254",
+		"169.254.169.254", "169.254.0.1",
+		"172.16.0.1", "172.31.255.255",
+		"192.0.0.1", "192.0.0.255",
+		"192.0.2.1", "192.0.2.255",
+		"192.168.0.1", "192.168.255.255",
+		"198.18.0.1", "198.19.255.255",
+		"198.51.100.1", "198.51.100.255",
+		"203.0.113.1", "203.0.113.255",
+		"224.0.0.1", "239.255.255.255",
+		"240.0.0.1", "255.255.255.255",
+		"::", "::1",
+		"64:ff9b::7f00:1", "64:ff9b::a9fe:a9fe",
+		"64:ff9b:1::1", "64:ff9b:1:ffff::1",
+		"100::1", "100::ffff:ffff:ffff:ffff",
+		"2001:db8::1", "2001:db8:ffff::1",
+		"2002:7f00:1::1", "2002:ffff::1",
+		"fc00::1", "fdff:ffff::1",
+		"fe80::1", "febf::1",
+		"ff02::1", "ff01::1",
+		// IPv4 映射地址按内嵌的 IPv4 判断
+		"::ffff:127.0.0.1", "::ffff:10.0.0.1", "::ffff:100.64.0.1",
+	}
+	for _, s := range forbidden {
+		if !isForbiddenIP(net.ParseIP(s)) {
+			t.Errorf("isForbiddenIP(%s) = false, want true", s)
+		}
+	}
+
+	allowed := []string{
+		"1.1.1.1", "8.8.8.8", "100.63.255.255", "100.128.0.1", "172.32.0.1", "192.0.3.1", "198.20.0.1",
+		"223.255.255.255", "2606:4700:4700::1111", "64:ff9b:2::1", "::ffff:8.8.8.8",
+	}
+	for _, s := range allowed {
+		if isForbiddenIP(net.ParseIP(s)) {
+			t.Errorf("isForbiddenIP(%s) = true, want false", s)
+		}
+	}
+
+	if !isForbiddenIP(nil) {
+		t.Error("isForbiddenIP(nil) = false, want true")
+	}
+}
+
+func TestCheckIPOverrides(t *testing.T) {
+	_, internal, _ := net.ParseCIDR("10.1.0.0/16")
+	_, public, _ := net.ParseCIDR("8.8.8.0/24")
+	p := &policy{opts: Options{AllowCIDRs: []*net.IPNet{internal}, DenyCIDRs: []*net.IPNet{public}}}
+
+	if err := p.checkIP(net.ParseIP("10.1.2.3")); err != nil {
+		t.Errorf("allowed internal address: %v", err)
+	}
+	if err := p.checkIP(net.ParseIP("10.2.0.1")); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("internal address outside AllowCIDRs = %v, want ErrForbiddenAddress", err)
+	}
+	if err := p.checkIP(net.ParseIP("8.8.8.8")); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("denied public address = %v, want ErrForbiddenAddress", err)
+	}
+}
+
+// 直连时主机名在建连阶段才解析，检查的是实际连接的 IP：
+// 指向回环地址的域名（DNS rebinding 的结果）即使通过了主机规则也会被拒绝
+func TestDialRejectsResolvedAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := "http://localhost:" + u.Port() + "/"
+
+	client := NewClient(DefaultOptions())
+	if _, err := client.Get(target); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("GET %s = %v, want ErrForbiddenAddress", target, err)
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("GET %s = %v, want ErrForbiddenAddress", srv.URL, err)
+	}
+
+	// 明确放行的网段可以访问
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	opts := DefaultOptions()
+	opts.AllowCIDRs = []*net.IPNet{loopback}
+	resp, err := NewClient(opts).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET with loopback allowed: %v", err)
+	}
+	resp.Body.Close()
+}
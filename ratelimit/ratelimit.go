@@ -0,0 +1,107 @@
+// Package ratelimit 按客户端计数的固定窗口限流器
+// 只在单进程内存中计数，多实例部署时每个实例各自限流
+package ratelimit
+
+import (
+	"sync"
+	"time"
+	"todo-list/clock"
+)
+
+// Result 一次计数后的限流状态，对应 X-RateLimit-* 响应头
+type Result struct {
+	Limit     int       `json:"limit"`     // 每个窗口允许的请求数
+	Remaining int       `json:"remaining"` // 当前窗口剩余次数
+	Reset     time.Time `json:"reset"`     // 当前窗口结束时间
+	Allowed   bool      `json:"-"`         // 本次请求是否在限额内
+}
+
+// RetryAfter 距离窗口重置还有多久
+func (r Result) RetryAfter(now time.Time) time.Duration {
+	if d := r.Reset.Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter 固定窗口限流器
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int
+	period  time.Duration
+	windows map[string]*window
+	sweepAt time.Time
+	clock   clock.Clock
+}
+
+// New 创建限流器：每个 key 在 period 内最多 limit 次请求
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		period:  period,
+		windows: make(map[string]*window),
+		clock:   clock.Real{},
+	}
+}
+
+// SetClock 替换计算窗口使用的时钟（测试中使用 clock.Fake）
+func (l *Limiter) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+}
+
+// Allow 为 key 计数一次并返回限流状态
+func (l *Limiter) Allow(key string) Result {
+	return l.take(key, 1)
+}
+
+// Peek 查看 key 当前的限流状态，不计数
+func (l *Limiter) Peek(key string) Result {
+	return l.take(key, 0)
+}
+
+func (l *Limiter) take(key string, n int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.period {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	w.count += n
+
+	remaining := l.limit - w.count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return Result{
+		Limit:     l.limit,
+		Remaining: remaining,
+		Reset:     w.start.Add(l.period),
+		Allowed:   w.count <= l.limit,
+	}
+}
+
+// sweep 每个周期清理一次过期窗口，避免 map 无限增长
+func (l *Limiter) sweep(now time.Time) {
+	if now.Before(l.sweepAt) {
+		return
+	}
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.period {
+			delete(l.windows, key)
+		}
+	}
+	l.sweepAt = now.Add(l.period)
+}
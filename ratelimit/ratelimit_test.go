@@ -0,0 +1,39 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+	"todo-list/clock"
+	"todo-list/ratelimit"
+)
+
+func TestLimiterWindowFollowsClock(t *testing.T) {
+	start := time.Date(2026, 3, 8, 6, 59, 30, 0, time.UTC)
+	fake := clock.NewFake(start)
+	l := ratelimit.New(2, time.Minute)
+	l.SetClock(fake)
+
+	for i := 0; i < 2; i++ {
+		if res := l.Allow("client"); !res.Allowed {
+			t.Fatalf("request %d denied", i+1)
+		}
+	}
+	res := l.Allow("client")
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("third request = %+v, want denied", res)
+	}
+	if want := start.Add(time.Minute); !res.Reset.Equal(want) {
+		t.Errorf("Reset = %v, want %v", res.Reset, want)
+	}
+	if got := res.RetryAfter(fake.Now()); got != time.Minute {
+		t.Errorf("RetryAfter = %v, want 1m", got)
+	}
+
+	fake.Advance(time.Minute)
+	if res := l.Peek("client"); res.Remaining != 2 {
+		t.Errorf("Remaining after window = %d, want 2", res.Remaining)
+	}
+	if res := l.Allow("client"); !res.Allowed {
+		t.Error("request after window denied")
+	}
+}
@@ -0,0 +1,471 @@
+// Package recurrence 重复待办事项：解析 RFC 5545 RRULE，完成后生成下一次
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency 重复频率
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods 计算下一次时最多检查的周期数，避免永远不会匹配的规则（如 2 月 30 日）死循环
+const maxPeriods = 20000
+
+// maxScannedDays 计算下一次时最多检查的日期数：按年展开的规则每个周期要检查全年的每一天，
+// 只限制周期数时永远不会匹配的规则（如 FREQ=YEARLY;BYMONTHDAY=31;BYDAY=1MO）要检查几百万天
+const maxScannedDays = 50000
+
+// weekdays RRULE 中的星期缩写
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ByDay BYDAY 中的一项，N 为 0 表示每个该星期，1 / -1 表示范围内的第一个 / 最后一个
+type ByDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule 解析后的 RRULE，支持 FREQ（DAILY / WEEKLY / MONTHLY / YEARLY）、INTERVAL、COUNT、UNTIL、
+// BYDAY、BYMONTHDAY、BYMONTH 和 WKST；不支持按小时 / 分钟重复和 BYSETPOS 等其他部分
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int       // 0 表示不限次数
+	Until      time.Time // 零值表示不限结束时间
+	ByDay      []ByDay
+	ByMonthDay []int // 负数表示从月末倒数
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// Parse 解析 RRULE 字符串，可以带 "RRULE:" 前缀，例如 "FREQ=WEEKLY;BYDAY=MO,WE"
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return nil, fmt.Errorf("重复规则不能为空")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("无法解析重复规则中的 %q", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("重复规则中 %s 出现了多次", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				r.Freq = f
+			default:
+				err = fmt.Errorf("不支持的重复频率 %q，可选 DAILY、WEEKLY、MONTHLY、YEARLY", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = positive(key, value)
+		case "COUNT":
+			r.Count, err = positive(key, value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseInts(key, value, 1, 31, true)
+		case "BYMONTH":
+			var months []int
+			months, err = parseInts(key, value, 1, 12, false)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+			// YEARLY 规则按 BYMONTH 逐月展开，重复的月份会产生重复的日期
+			slices.Sort(r.ByMonth)
+			r.ByMonth = slices.Compact(r.ByMonth)
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				err = fmt.Errorf("无效的 WKST %q", value)
+			}
+			r.WeekStart = wd
+		default:
+			err = fmt.Errorf("不支持重复规则中的 %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("重复规则缺少 FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("重复规则不能同时包含 COUNT 和 UNTIL")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return nil, fmt.Errorf("只有 MONTHLY 和 YEARLY 规则的 BYDAY 可以带序号")
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Weekly {
+		return nil, fmt.Errorf("WEEKLY 规则不能使用 BYMONTHDAY")
+	}
+	if !r.monthDayPossible() {
+		return nil, fmt.Errorf("BYMONTHDAY 在 BYMONTH 指定的月份中都不存在，规则永远不会发生")
+	}
+	return r, nil
+}
+
+func positive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s 必须是正整数", key)
+	}
+	return n, nil
+}
+
+// parseUntil 支持 20261231、20261231T235959 和 20261231T235959Z；
+// 只有日期时包含当天，没有 Z 时按 UTC 处理
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的 UNTIL %q，格式为 20261231 或 20261231T235959Z", value)
+}
+
+func parseByDay(value string) ([]ByDay, error) {
+	var days []ByDay
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("无效的 BYDAY %q", item)
+		}
+		wd, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("无效的 BYDAY %q", item)
+		}
+		day := ByDay{Weekday: wd}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n > 53 || n < -53 {
+				return nil, fmt.Errorf("无效的 BYDAY %q", item)
+			}
+			day.N = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// monthDayPossible BYMONTHDAY 中是否至少有一天出现在 BYMONTH 的某个月份里（2 月按闰年的 29 天算）
+func (r *Rule) monthDayPossible() bool {
+	if len(r.ByMonthDay) == 0 || len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		days := date(2024, m+1, 0, time.Time{}).Day()
+		for _, n := range r.ByMonthDay {
+			if n <= days && -n <= days {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseInts 解析逗号分隔的整数列表，allowNegative 时 -max..-1 也合法；结果已排序、去重
+func parseInts(key, value string, min, max int, allowNegative bool) ([]int, error) {
+	var out []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		valid := err == nil && ((n >= min && n <= max) || (allowNegative && n <= -min && n >= -max))
+		if !valid {
+			return nil, fmt.Errorf("无效的 %s %q", key, item)
+		}
+		out = append(out, n)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// String 返回规范化的 RRULE（不带前缀），用于存储
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		items := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			items[i] = weekdayCode(d.Weekday)
+			if d.N != 0 {
+				items[i] = strconv.Itoa(d.N) + items[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(items, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+func weekdayCode(wd time.Weekday) string {
+	return strings.ToUpper(wd.String()[:2])
+}
+
+func joinInts(ns []int) string {
+	items := make([]string, len(ns))
+	for i, n := range ns {
+		items[i] = strconv.Itoa(n)
+	}
+	return strings.Join(items, ",")
+}
+
+// Occurrences 返回从 start（相当于 DTSTART）开始、晚于 after 的最多 n 次发生时间
+// 每次发生都沿用 start 的时刻和时区；COUNT 从 start 起计数，start 本身不符合规则时不计入
+func (r *Rule) Occurrences(start, after time.Time, n int) []time.Time {
+	var out []time.Time
+	if n <= 0 {
+		return out
+	}
+	r.iterate(start, func(t time.Time) bool {
+		if t.After(after) {
+			out = append(out, t)
+		}
+		return len(out) < n
+	})
+	return out
+}
+
+// Next 返回晚于 after 的下一次发生时间，规则已经结束时返回 false
+func (r *Rule) Next(start, after time.Time) (time.Time, bool) {
+	next := r.Occurrences(start, after, 1)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// iterate 按时间顺序逐个产生发生时间，fn 返回 false 时停止
+// 检查的周期数超过 maxPeriods 或日期数超过 maxScannedDays 时视为规则已经结束
+func (r *Rule) iterate(start time.Time, fn func(time.Time) bool) {
+	count, scanned := 0, 0
+	for period := 0; period < maxPeriods && scanned < maxScannedDays; period++ {
+		days, n := r.candidates(start, period)
+		scanned += n
+		for _, t := range days {
+			if t.Before(start) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return
+			}
+			count++
+			if !fn(t) || (r.Count > 0 && count >= r.Count) {
+				return
+			}
+		}
+	}
+}
+
+// candidates 第 period 个周期（从 start 所在的周期算起，按 INTERVAL 跳过）内符合规则的时间，已排序
+// 同时返回为此检查过的日期数
+func (r *Rule) candidates(start time.Time, period int) (days []time.Time, scanned int) {
+	step := period * r.Interval
+	y, m, d := start.Date()
+	// expand 逐日检查 [from, to) 范围
+	expand := func(from, to time.Time) []time.Time {
+		scanned += int(dayDiff(from, to))
+		return r.expand(from, to, d)
+	}
+
+	switch r.Freq {
+	case Daily:
+		day := date(y, m, d+step, start)
+		scanned = 1
+		if r.matchMonth(day) && r.matchMonthDay(day) && r.matchWeekday(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := date(y, m, d-offset+7*step, start)
+		scanned = 7
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && day.Weekday() != start.Weekday() {
+				continue
+			}
+			if r.matchWeekday(day) && r.matchMonth(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := date(y, m+time.Month(step), 1, start)
+		if r.matchMonth(first) {
+			days = expand(first, first.AddDate(0, 1, 0))
+		}
+	case Yearly:
+		year := y + step
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.sortedMonths() {
+				first := date(year, month, 1, start)
+				days = append(days, expand(first, first.AddDate(0, 1, 0))...)
+			}
+		case len(r.ByDay) > 0 || len(r.ByMonthDay) > 0:
+			// 没有 BYMONTH 时 BYDAY 的序号按全年计算，BYMONTHDAY 对每个月生效
+			if len(r.ByMonthDay) > 0 {
+				for month := time.January; month <= time.December; month++ {
+					first := date(year, month, 1, start)
+					days = append(days, expand(first, first.AddDate(0, 1, 0))...)
+				}
+			} else {
+				first := date(year, time.January, 1, start)
+				days = expand(first, first.AddDate(1, 0, 0))
+			}
+		default:
+			// 2 月 29 日开始的规则只在闰年发生
+			scanned = 1
+			if day := date(year, m, d, start); day.Day() == d {
+				days = append(days, day)
+			}
+		}
+	}
+	return days, scanned
+}
+
+// expand 在 [from, to) 范围内按 BYMONTHDAY / BYDAY 展开日期，两者都没有时取每月的 startDay 日（没有这一天的月份跳过）
+func (r *Rule) expand(from, to time.Time, startDay int) []time.Time {
+	var days []time.Time
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 && day.Day() != startDay {
+			continue
+		}
+		if len(r.ByMonthDay) > 0 && !r.matchMonthDay(day) {
+			continue
+		}
+		if len(r.ByDay) > 0 && !r.matchOrdinal(day, from, to) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// matchOrdinal day 是否匹配 BYDAY，带序号的项按 [from, to) 范围内的第 N 个 / 倒数第 N 个计算
+func (r *Rule) matchOrdinal(day, from, to time.Time) bool {
+	for _, bd := range r.ByDay {
+		if bd.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case bd.N == 0:
+			return true
+		case bd.N > 0 && int(dayDiff(from, day))/7+1 == bd.N:
+			return true
+		case bd.N < 0 && int(dayDiff(day, to)-1)/7+1 == -bd.N:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, bd := range r.ByDay {
+		if bd.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := date(day.Year(), day.Month()+1, 0, day).Day()
+	for _, n := range r.ByMonthDay {
+		if n == day.Day() || (n < 0 && last+n+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == day.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) sortedMonths() []time.Month {
+	months := append([]time.Month(nil), r.ByMonth...)
+	sort.Slice(months, func(i, j int) bool { return months[i] < months[j] })
+	return months
+}
+
+// date 构造沿用 ref 的时刻和时区的日期，日期超出范围时按 time.Date 的规则进位
+func date(y int, m time.Month, d int, ref time.Time) time.Time {
+	return time.Date(y, m, d, ref.Hour(), ref.Minute(), ref.Second(), 0, ref.Location())
+}
+
+// dayDiff 两个日期相差的天数（按日历日计算，不受夏令时影响）
+func dayDiff(a, b time.Time) int64 {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int64(db.Sub(da) / (24 * time.Hour))
+}
@@ -0,0 +1,74 @@
+package recurrence_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"todo-list/recurrence"
+)
+
+// 永远不会发生的规则要尽快返回，而不是逐日检查几万个周期
+func TestImpossibleRuleStopsQuickly(t *testing.T) {
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	for _, s := range []string{
+		"FREQ=YEARLY;BYMONTHDAY=31;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=15;BYDAY=1MO",
+		"FREQ=YEARLY;BYMONTH=1;BYMONTHDAY=31;BYDAY=2TU",
+	} {
+		r, err := recurrence.Parse(s)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", s, err)
+		}
+		begin := time.Now()
+		if next, ok := r.Next(start, start); ok {
+			t.Errorf("%s: Next = %v, want none", s, next)
+		}
+		if d := time.Since(begin); d > time.Second {
+			t.Errorf("%s: Next took %v", s, d)
+		}
+	}
+}
+
+func TestParseRejectsImpossibleMonthDay(t *testing.T) {
+	for _, s := range []string{
+		"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30,31",
+		"FREQ=YEARLY;BYMONTH=4,6,9,11;BYMONTHDAY=31",
+		"FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=-30",
+	} {
+		if _, err := recurrence.Parse(s); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", s)
+		}
+	}
+	// 2 月 29 日只在闰年发生，但不是不可能
+	r, err := recurrence.Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if next, ok := r.Next(start, start); !ok || !next.Equal(time.Date(2028, 2, 29, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next = %v, %v; want 2028-02-29", next, ok)
+	}
+}
+
+func TestParseDedupesMonthsAndMonthDays(t *testing.T) {
+	r, err := recurrence.Parse("FREQ=YEARLY;BYMONTH=3,1,3;BYMONTHDAY=15,1,15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "FREQ=YEARLY;BYMONTHDAY=1,15;BYMONTH=1,3"; r.String() != want {
+		t.Errorf("String() = %q, want %q", r.String(), want)
+	}
+
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	got := r.Occurrences(start, start.Add(-time.Second), 5)
+	want := []time.Time{
+		time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2027, 1, 1, 9, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Occurrences = %v, want %v", got, want)
+	}
+}
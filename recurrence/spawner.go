@@ -0,0 +1,151 @@
+package recurrence
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+)
+
+// TaskName 调度器中的任务名，完成重复待办事项时按这个名字立即触发一轮
+const TaskName = "重复待办事项生成"
+
+// batchSize 每次从数据库取出的待处理数量
+const batchSize = 100
+
+// Store 生成下一次需要的数据访问（database.DB 实现了该接口）
+type Store interface {
+	ListDueRecurrencesContext(ctx context.Context, limit int) ([]model.Todo, error)
+	CreateNextOccurrenceContext(ctx context.Context, prev *model.Todo, next *model.Todo) (bool, error)
+	GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+}
+
+// Anchor 重复系列的起点：创建时的截止日期，没有截止日期时为创建时间（精确到秒，与展开的发生时间一致）
+func Anchor(todo *model.Todo) time.Time {
+	switch {
+	case todo.RecurrenceStart != nil:
+		return *todo.RecurrenceStart
+	case todo.DueDate != nil:
+		return *todo.DueDate
+	default:
+		return todo.CreatedAt.Truncate(time.Second)
+	}
+}
+
+// NextOccurrence 按 prev 的重复规则生成下一次待办事项，规则已经结束时返回 nil
+// 下一次的截止时间是同时晚于 prev 的截止时间和完成时间的第一次发生，逾期很久才完成时不会补出一串已经过期的待办事项；
+// 日期按 loc 时区展开（例如每天 9 点在夏令时前后都是当地 9 点），下一次的创建时间为 now
+func NextOccurrence(prev *model.Todo, loc *time.Location, now time.Time) (*model.Todo, error) {
+	rule, err := Parse(prev.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor := Anchor(prev)
+	after := anchor
+	if prev.DueDate != nil && prev.DueDate.After(after) {
+		after = *prev.DueDate
+	}
+	if prev.CompletedAt != nil && prev.CompletedAt.After(after) {
+		after = *prev.CompletedAt
+	}
+
+	due, ok := rule.Next(anchor.In(loc), after)
+	if !ok {
+		return nil, nil
+	}
+
+	next := model.NewTodo(prev.Title, prev.Description, now)
+	next.Priority = prev.Priority
+	next.EstimatedMinutes = prev.EstimatedMinutes
+	next.ProjectID = prev.ProjectID
+	next.SecretNote = prev.SecretNote
+	next.Latitude, next.Longitude, next.Radius = prev.Latitude, prev.Longitude, prev.Radius
+	next.Recurrence = prev.Recurrence
+	start := anchor.UTC()
+	next.RecurrenceStart = &start
+	due = due.UTC()
+	next.DueDate = &due
+	return next, nil
+}
+
+// Spawner 为已完成的重复待办事项生成下一次，由调度器周期调用，完成待办事项时也会立即触发一次
+type Spawner struct {
+	store  Store
+	userID string // 日期按默认用户的时区展开
+	clock  clock.Clock
+}
+
+// NewSpawner 创建重复待办事项生成器
+func NewSpawner(store Store, userID string, clk clock.Clock) *Spawner {
+	return &Spawner{store: store, userID: userID, clock: clk}
+}
+
+// location 默认用户的时区，未设置时为 UTC
+func (s *Spawner) location(ctx context.Context) *time.Location {
+	if prefs, err := s.store.GetNotificationPreferencesContext(ctx, s.userID); err == nil {
+		if loc, err := time.LoadLocation(prefs.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// Run 执行一轮生成（接受 Context 参数，供调度器使用）
+func (s *Spawner) Run(ctx context.Context) {
+	created, err := s.spawn(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("重复待办事项生成超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("重复待办事项生成已取消")
+			return
+		}
+		log.Printf("重复待办事项生成失败: %v", err)
+		return
+	}
+
+	if created > 0 {
+		log.Printf("重复待办事项已生成: count=%d", created)
+	}
+}
+
+// spawn 处理所有待生成的重复待办事项，返回生成的数量
+func (s *Spawner) spawn(ctx context.Context) (int, error) {
+	loc := s.location(ctx)
+	created := 0
+	for {
+		todos, err := s.store.ListDueRecurrencesContext(ctx, batchSize)
+		if err != nil || len(todos) == 0 {
+			return created, err
+		}
+
+		for i := range todos {
+			if err := ctx.Err(); err != nil {
+				return created, err
+			}
+
+			prev := &todos[i]
+			next, err := NextOccurrence(prev, loc, s.clock.Now())
+			if err != nil {
+				// 规则在保存时已经校验过，这里只可能是手工改过数据库；标记为结束，避免每轮都重试
+				log.Printf("重复规则无效，不再生成: todo=%d, recurrence=%q, error=%v", prev.ID, prev.Recurrence, err)
+			}
+			ok, err := s.store.CreateNextOccurrenceContext(ctx, prev, next)
+			if err != nil {
+				return created, err
+			}
+			if ok && next != nil {
+				created++
+			}
+		}
+
+		if len(todos) < batchSize {
+			return created, nil
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package recurrence_test
+
+import (
+	"testing"
+	"time"
+	"todo-list/model"
+	"todo-list/recurrence"
+)
+
+// 每天当地 9 点的待办事项在夏令时切换前后都落在当地 9 点
+func TestNextOccurrenceAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		due       time.Time // 上一次的截止时间（当地）
+		completed time.Time // 完成时刻（当地）
+		want      time.Time // 下一次的截止时间（当地）
+	}{
+		{
+			name:      "spring forward",
+			due:       time.Date(2026, 3, 7, 9, 0, 0, 0, loc),
+			completed: time.Date(2026, 3, 7, 10, 0, 0, 0, loc),
+			want:      time.Date(2026, 3, 8, 9, 0, 0, 0, loc),
+		},
+		{
+			name:      "fall back",
+			due:       time.Date(2026, 10, 31, 9, 0, 0, 0, loc),
+			completed: time.Date(2026, 10, 31, 10, 0, 0, 0, loc),
+			want:      time.Date(2026, 11, 1, 9, 0, 0, 0, loc),
+		},
+		{
+			name:      "completed late across the switch",
+			due:       time.Date(2026, 3, 6, 9, 0, 0, 0, loc),
+			completed: time.Date(2026, 3, 9, 8, 0, 0, 0, loc),
+			want:      time.Date(2026, 3, 9, 9, 0, 0, 0, loc),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			due := tt.due.UTC()
+			prev := &model.Todo{Title: "standup", Recurrence: "FREQ=DAILY", DueDate: &due}
+			prev.Complete(tt.completed)
+
+			next, err := recurrence.NextOccurrence(prev, loc, tt.completed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next == nil || next.DueDate == nil {
+				t.Fatal("NextOccurrence returned no due date")
+			}
+			if !next.DueDate.Equal(tt.want) {
+				t.Errorf("next due = %v, want %v", next.DueDate.In(loc), tt.want)
+			}
+			if got := next.DueDate.In(loc).Hour(); got != 9 {
+				t.Errorf("next due hour = %d, want 9", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package scan 在保存附件之前调用外部病毒扫描器
+// 支持两种方式：执行外部命令（例如 clamscan / clamdscan），或者直接连接 clamd 套接字
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// clamd INSTREAM 每次发送的块大小
+const chunkSize = 64 << 10
+
+// Verdict 扫描结果
+type Verdict struct {
+	Infected  bool
+	Signature string // 命中的病毒特征名，未感染时为空
+}
+
+// Scanner 病毒扫描器
+// 扫描器不可用或返回无法识别的结果时返回 error，调用方应拒绝上传（fail closed）
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, path string) (Verdict, error)
+}
+
+// CommandScanner 执行外部命令扫描文件，文件路径作为最后一个参数
+// 约定与 clamscan 一致：退出码 0 表示干净，1 表示发现病毒，其他表示出错
+type CommandScanner struct {
+	Command []string
+}
+
+// Name 实现 Scanner 接口
+func (s CommandScanner) Name() string {
+	return "command"
+}
+
+// Scan 实现 Scanner 接口
+func (s CommandScanner) Scan(ctx context.Context, path string) (Verdict, error) {
+	if len(s.Command) == 0 {
+		return Verdict{}, errors.New("扫描命令为空")
+	}
+
+	args := append(append([]string{}, s.Command[1:]...), path)
+	out, err := exec.CommandContext(ctx, s.Command[0], args...).CombinedOutput()
+	if err == nil {
+		return Verdict{}, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		// clamscan 输出形如 "/tmp/x: Eicar-Test-Signature FOUND"，只保留特征名
+		signature := strings.TrimPrefix(lastLine(out), path+": ")
+		return Verdict{Infected: true, Signature: strings.TrimSuffix(signature, " FOUND")}, nil
+	}
+	return Verdict{}, fmt.Errorf("扫描命令执行失败：%w（%s）", err, lastLine(out))
+}
+
+// ClamdScanner 通过 clamd 的 INSTREAM 命令扫描文件，不要求 clamd 能访问本机文件系统
+// Network 为 "unix" 或 "tcp"
+type ClamdScanner struct {
+	Network string
+	Address string
+	Timeout time.Duration
+}
+
+// ParseClamdAddress 解析 clamd 地址：unix:/run/clamav/clamd.ctl、tcp:127.0.0.1:3310 或 127.0.0.1:3310
+func ParseClamdAddress(addr string) (ClamdScanner, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok && path != "" {
+		return ClamdScanner{Network: "unix", Address: path}, nil
+	}
+	hostport := strings.TrimPrefix(addr, "tcp:")
+	if _, _, err := net.SplitHostPort(hostport); err != nil {
+		return ClamdScanner{}, fmt.Errorf("无效的 clamd 地址 %q", addr)
+	}
+	return ClamdScanner{Network: "tcp", Address: hostport}, nil
+}
+
+// Name 实现 Scanner 接口
+func (s ClamdScanner) Name() string {
+	return "clamd"
+}
+
+// Scan 实现 Scanner 接口
+func (s ClamdScanner) Scan(ctx context.Context, path string) (Verdict, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("打开文件失败：%w", err)
+	}
+	defer f.Close()
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.Network, s.Address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("连接 clamd 失败：%w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Verdict{}, fmt.Errorf("发送扫描命令失败：%w", err)
+	}
+
+	// 数据按 <4 字节大端长度><数据> 分块发送，长度为 0 的块表示结束
+	buf := make([]byte, chunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, werr := conn.Write(append(size, buf[:n]...)); werr != nil {
+				return Verdict{}, fmt.Errorf("发送文件内容失败：%w", werr)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Verdict{}, fmt.Errorf("读取文件失败：%w", err)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return Verdict{}, fmt.Errorf("发送结束标记失败：%w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Verdict{}, fmt.Errorf("读取 clamd 响应失败：%w", err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamdReply 解析 "stream: OK" / "stream: <签名> FOUND" / "... ERROR"
+func parseClamdReply(reply string) (Verdict, error) {
+	_, result, _ := strings.Cut(reply, ": ")
+	switch {
+	case result == "OK":
+		return Verdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return Verdict{Infected: true, Signature: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return Verdict{}, fmt.Errorf("clamd 返回错误：%q", reply)
+	}
+}
+
+// lastLine 返回输出中最后一个非空行，用于提取病毒名或错误信息
+func lastLine(out []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(out), []byte("\n"))
+	return strings.TrimSpace(string(lines[len(lines)-1]))
+}
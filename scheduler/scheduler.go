@@ -0,0 +1,383 @@
+// Package scheduler 后台定时任务调度器
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"todo-list/clock"
+)
+
+// ErrTaskNotFound 任务不存在
+var ErrTaskNotFound = errors.New("定时任务不存在")
+
+// Locker 多实例之间的任务租约（database.DB 和 postgres.Store 实现了该接口，租约要放在各实例共享的数据库中）
+// 同一时刻一个任务的租约只属于一个 holder，过期后其他 holder 才能获取
+type Locker interface {
+	AcquireLeaseContext(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLeasesContext(ctx context.Context, holder string) error
+}
+
+// task 一个定时任务：按固定周期（interval）或 cron 表达式执行
+type task struct {
+	name     string
+	interval time.Duration
+	cron     *Cron
+	timeout  time.Duration
+	run      func(ctx context.Context)
+	reset    chan struct{}
+	trigger  chan struct{}
+
+	// 以下字段由 Scheduler.mu 保护
+	lastRun      time.Time
+	lastDuration time.Duration
+	nextRun      time.Time
+	lastSkipped  time.Time
+	running      bool
+	pausedRO     bool // 上一次因只读模式被跳过，用来只在开始跳过时记一次日志
+}
+
+// TaskStatus 任务运行状态
+type TaskStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule,omitempty"`
+	Interval       string     `json:"interval,omitempty"`
+	Running        bool       `json:"running"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+	LastSkipped    *time.Time `json:"last_skipped,omitempty"` // 最近一次因其他实例持有租约而跳过的时间
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	tasks  []*task
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	locker Locker // 为 nil 时不做多实例协调，每个实例都执行所有任务
+	holder string // 本实例的租约持有者标识
+
+	// 计算下次执行时间、记录执行时刻使用的时钟；等待本身仍使用真实的计时器
+	clock clock.Clock
+
+	// 只读模式：readOnly 返回 true 时只执行 readOnlySafe 中的任务，其余任务跳过本次执行
+	readOnly     func() bool
+	readOnlySafe map[string]bool
+}
+
+// New 创建调度器
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		clock:  clock.Real{},
+	}
+}
+
+// Register 注册周期任务，必须在 Start 之前调用
+func (s *Scheduler) Register(name string, interval, timeout time.Duration, run func(ctx context.Context)) {
+	s.tasks = append(s.tasks, &task{
+		name:     name,
+		interval: interval,
+		timeout:  timeout,
+		run:      run,
+		reset:    make(chan struct{}, 1),
+		trigger:  make(chan struct{}, 1),
+	})
+}
+
+// SetLocker 启用多实例协调：每次执行前先获取任务租约，拿不到就跳过本次执行，
+// 必须在 Start 之前调用
+func (s *Scheduler) SetLocker(locker Locker, holder string) {
+	s.locker = locker
+	s.holder = holder
+}
+
+// SetReadOnly 设置只读模式的判断函数（与 API 的只读模式使用同一个开关）：只读时跳过会修改数据的任务，
+// safe 是不修改数据的任务（例如备份），只读时照常执行；必须在 Start 之前调用
+func (s *Scheduler) SetReadOnly(readOnly func() bool, safe ...string) {
+	s.readOnly = readOnly
+	s.readOnlySafe = make(map[string]bool, len(safe))
+	for _, name := range safe {
+		s.readOnlySafe[name] = true
+	}
+}
+
+// SetClock 替换取当前时间的时钟（测试中使用 clock.Fake），必须在 Start 之前调用
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// RegisterCron 注册按 cron 表达式执行的任务，必须在 Start 之前调用
+func (s *Scheduler) RegisterCron(name, spec string, timeout time.Duration, run func(ctx context.Context)) error {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.tasks = append(s.tasks, &task{
+		name:    name,
+		cron:    c,
+		timeout: timeout,
+		run:     run,
+		reset:   make(chan struct{}, 1),
+		trigger: make(chan struct{}, 1),
+	})
+	return nil
+}
+
+// Reschedule 修改任务的 cron 表达式，立即生效（周期任务也会改为按 cron 执行）
+func (s *Scheduler) Reschedule(name, spec string) error {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	t := s.find(name)
+	if t == nil {
+		return ErrTaskNotFound
+	}
+
+	s.mu.Lock()
+	t.cron = c
+	t.nextRun = c.Next(s.clock.Now())
+	s.mu.Unlock()
+
+	// 通知任务协程重新计算下次执行时间
+	select {
+	case t.reset <- struct{}{}:
+	default:
+	}
+	log.Printf("定时任务已调整: name=%s, schedule=%s", name, spec)
+	return nil
+}
+
+// RunNow 让任务尽快执行一次，不影响之后的计划
+// 任务正在执行时，本次执行结束后会再执行一次；多次调用只会合并为一次
+func (s *Scheduler) RunNow(name string) error {
+	t := s.find(name)
+	if t == nil {
+		return ErrTaskNotFound
+	}
+	select {
+	case t.trigger <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Status 返回所有任务的运行状态，顺序与注册顺序一致
+func (s *Scheduler) Status() []TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]TaskStatus, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		st := TaskStatus{
+			Name:           t.name,
+			Running:        t.running,
+			LastDurationMs: t.lastDuration.Milliseconds(),
+		}
+		if t.cron != nil {
+			st.Schedule = t.cron.String()
+		} else {
+			st.Interval = t.interval.String()
+		}
+		if !t.lastRun.IsZero() {
+			lastRun := t.lastRun
+			st.LastRun = &lastRun
+		}
+		if !t.nextRun.IsZero() {
+			nextRun := t.nextRun
+			st.NextRun = &nextRun
+		}
+		if !t.lastSkipped.IsZero() {
+			lastSkipped := t.lastSkipped
+			st.LastSkipped = &lastSkipped
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// Start 启动所有定时任务
+func (s *Scheduler) Start() {
+	log.Printf("启动定时任务调度器: tasks=%d", len(s.tasks))
+
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.runTask(t)
+	}
+}
+
+// Stop 停止所有定时任务，等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	log.Println("停止定时任务调度器...")
+	s.cancel()
+	s.wg.Wait()
+
+	if s.locker != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.locker.ReleaseLeasesContext(ctx, s.holder); err != nil {
+			log.Printf("释放任务租约失败: %v", err)
+		}
+	}
+	log.Println("所有定时任务已停止")
+}
+
+// find 按名称查找任务
+func (s *Scheduler) find(name string) *task {
+	for _, t := range s.tasks {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// runTask 运行单个定时任务：周期任务启动时立即执行一次，之后按周期执行；
+// cron 任务等到下一个匹配时间再执行
+func (s *Scheduler) runTask(t *task) {
+	defer s.wg.Done()
+
+	s.mu.Lock()
+	immediate := t.cron == nil
+	s.mu.Unlock()
+
+	if immediate {
+		log.Printf("定时任务已注册: name=%s, interval=%s, timeout=%s", t.name, t.interval, t.timeout)
+		s.safeRun(t)
+	} else {
+		log.Printf("定时任务已注册: name=%s, schedule=%s, timeout=%s", t.name, t.cron, t.timeout)
+	}
+
+	for {
+		timer := time.NewTimer(s.scheduleNext(t, s.clock.Now()))
+
+		select {
+		case <-timer.C:
+			s.safeRun(t)
+		case <-t.reset:
+			timer.Stop()
+		case <-t.trigger:
+			timer.Stop()
+			s.safeRun(t)
+		case <-s.ctx.Done():
+			timer.Stop()
+			log.Printf("定时任务收到停止信号: name=%s", t.name)
+			return
+		}
+	}
+}
+
+// scheduleNext 计算并记录下次执行时间，返回距现在的等待时长
+func (s *Scheduler) scheduleNext(t *task, now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	if t.cron != nil {
+		next = t.cron.Next(now)
+	}
+	if next.IsZero() {
+		// 周期任务，或者 cron 表达式永远不会匹配（如 2 月 30 日）时退回固定周期
+		interval := t.interval
+		if interval <= 0 {
+			interval = 24 * time.Hour
+		}
+		next = now.Add(interval)
+	}
+	t.nextRun = next
+	return next.Sub(now)
+}
+
+// acquire 获取本次执行的租约
+// 租约覆盖到下一次执行之后（周期 + 超时），持有者每次执行都会续期，
+// 其他实例在持有者停止续期（退出或宕机）后才会接手，避免同一轮被执行两次
+func (s *Scheduler) acquire(t *task, now time.Time) bool {
+	if s.locker == nil {
+		return true
+	}
+
+	s.mu.Lock()
+	period := t.interval
+	if t.cron != nil {
+		if next := t.cron.Next(now); !next.IsZero() {
+			period = next.Sub(now)
+		}
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	ok, err := s.locker.AcquireLeaseContext(ctx, t.name, s.holder, period+t.timeout)
+	if err != nil {
+		log.Printf("获取任务租约失败，跳过本次执行: name=%s, error=%v", t.name, err)
+		return false
+	}
+	if !ok {
+		s.mu.Lock()
+		// 只在开始被跳过时记一次日志，避免短周期任务刷屏
+		if t.lastSkipped.IsZero() || t.lastRun.After(t.lastSkipped) {
+			log.Printf("任务由其他实例执行，跳过: name=%s", t.name)
+		}
+		t.lastSkipped = now
+		s.mu.Unlock()
+	}
+	return ok
+}
+
+// safeRun 安全执行任务（捕获 panic，支持 Context 超时）
+func (s *Scheduler) safeRun(t *task) {
+	// 只读模式下连租约也不获取，租约本身也是一次写入
+	readOnly := s.readOnly != nil && s.readOnly() && !s.readOnlySafe[t.name]
+	s.mu.Lock()
+	if readOnly && !t.pausedRO {
+		log.Printf("只读模式，暂停定时任务: name=%s", t.name)
+	}
+	t.pausedRO = readOnly
+	s.mu.Unlock()
+	if readOnly {
+		return
+	}
+
+	start := s.clock.Now()
+	if !s.acquire(t, start) {
+		return
+	}
+
+	s.mu.Lock()
+	t.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("定时任务 panic: name=%s, error=%v", t.name, err)
+		}
+
+		s.mu.Lock()
+		t.running = false
+		t.lastRun = start
+		t.lastDuration = s.clock.Now().Sub(start)
+		s.mu.Unlock()
+	}()
+
+	taskCtx, cancel := context.WithTimeout(s.ctx, t.timeout)
+	defer cancel()
+
+	t.run(taskCtx)
+	duration := s.clock.Now().Sub(start)
+
+	log.Printf("定时任务执行完成: name=%s, duration_ms=%d", t.name, duration.Milliseconds())
+
+	if duration > t.timeout/2 {
+		log.Printf("警告: 定时任务执行时间过长: name=%s, duration=%s", t.name, duration)
+	}
+}
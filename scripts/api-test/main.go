@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -15,7 +16,7 @@ func main() {
 	// 等待服务器启动
 	time.Sleep(2 * time.Second)
 
-	fmt.Println("=== Go Todo List API 测试 ===\n")
+	fmt.Print("=== Go Todo List API 测试 ===\n\n")
 
 	// 测试1: 健康检查
 	fmt.Println("1. 测试健康检查端点 /")
@@ -49,7 +50,6 @@ func TestEndpoint(baseURL, method, endpoint string, data []byte) {
 
 	if data != nil {
 		req, err = http.NewRequest(method, url, bytes.NewBuffer(data))
-		req.Header.Set("Content-Type", "application/json")
 	} else {
 		req, err = http.NewRequest(method, url, nil)
 	}
@@ -58,6 +58,13 @@ func TestEndpoint(baseURL, method, endpoint string, data []byte) {
 		fmt.Printf("❌ 创建请求失败: %v\n", err)
 		return
 	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// 服务端启用认证时，用 POST /api/v1/api-keys 创建的 key 调用
+	if key := os.Getenv("TODO_API_KEY"); key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
 
 	client := &http.Client{Timeout: 5 * time.Second}
 	resp, err := client.Do(req)
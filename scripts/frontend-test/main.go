@@ -13,7 +13,7 @@ func main() {
 	// 测试后端API
 	baseURL := "http://localhost:7789"
 
-	fmt.Println("=== 测试后端API ===\n")
+	fmt.Print("=== 测试后端API ===\n\n")
 
 	// 1. 健康检查
 	TestEndpoint(baseURL, "GET", "/", nil)
@@ -32,7 +32,7 @@ func main() {
 	// 4. 再次获取列表
 	TestEndpoint(baseURL, "GET", "/api/todos", nil)
 
-	fmt.Println("\n=== 测试前端代理 ===\n")
+	fmt.Print("\n=== 测试前端代理 ===\n\n")
 
 	// 测试前端代理到后端
 	frontendBaseURL := "http://localhost:3000"
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo-list/model"
+)
+
+// ArchiveVersion 当前的归档格式版本，格式有不兼容的修改时递增
+const ArchiveVersion = 1
+
+// ErrWorkspaceNotEmpty 导入归档时目标工作区已有数据
+var ErrWorkspaceNotEmpty = errors.New("workspace is not empty")
+
+// ArchiveError 归档内容不一致，例如评论引用了归档中不存在的待办事项
+type ArchiveError struct {
+	Message string
+}
+
+func (e *ArchiveError) Error() string {
+	return e.Message
+}
+
+// ArchiveRepository 工作区归档的导出和导入，归档与存储后端无关，可以在 sqlite、postgres 和 memory 之间迁移
+type ArchiveRepository interface {
+	// ExportArchiveContext 导出当前工作区的全部数据，各部分相互一致
+	ExportArchiveContext(ctx context.Context) (*Archive, error)
+	// ImportArchiveContext 把归档导入当前工作区，全部成功或全部回滚；目标工作区不为空时返回 ErrWorkspaceNotEmpty，
+	// 引用了归档中不存在的记录时返回 *ArchiveError。存储不支持的数据（例如非 SQLite 中的链接、目标和习惯）不导入，
+	// 数量记在 ArchiveImportResult.Skipped 中
+	ImportArchiveContext(ctx context.Context, archive *Archive) (ArchiveImportResult, error)
+}
+
+// Archive 一个工作区的完整数据，用于备份和在不同部署（存储后端）之间迁移
+// 只包含与存储无关的 JSON 数据；附件文件本身不在归档中，提醒、通知等运行时状态也不导出
+type Archive struct {
+	Version         int                    `json:"version"`
+	ExportedAt      time.Time              `json:"exported_at"`
+	Workspace       model.Workspace        `json:"workspace"` // 名称和配额，导入时写入目标工作区，标识不变
+	Projects        []model.Project        `json:"projects"`
+	Todos           []model.Todo           `json:"todos"`
+	Comments        []model.Comment        `json:"comments"`
+	Links           []model.TodoLink       `json:"links"`
+	Goals           []ArchiveGoal          `json:"goals"`
+	Habits          []ArchiveHabit         `json:"habits"`
+	AutomationRules []model.AutomationRule `json:"automation_rules"`
+}
+
+// NewArchive 创建各部分都为空列表的归档，导出的 JSON 中没有数据的部分是 [] 而不是 null
+func NewArchive(workspace model.Workspace, exportedAt time.Time) *Archive {
+	return &Archive{
+		Version:         ArchiveVersion,
+		ExportedAt:      exportedAt,
+		Workspace:       workspace,
+		Projects:        make([]model.Project, 0),
+		Todos:           make([]model.Todo, 0),
+		Comments:        make([]model.Comment, 0),
+		Links:           make([]model.TodoLink, 0),
+		Goals:           make([]ArchiveGoal, 0),
+		Habits:          make([]ArchiveHabit, 0),
+		AutomationRules: make([]model.AutomationRule, 0),
+	}
+}
+
+// ArchiveGoal 目标及其关联的待办事项
+type ArchiveGoal struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	TargetDate  string    `json:"target_date,omitempty"`
+	TodoIDs     []int     `json:"todo_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ArchiveHabit 习惯及其各周期的生成记录，记录一并导入，迁移后不会为已生成的周期重复生成
+type ArchiveHabit struct {
+	model.Habit
+	Instances []HabitInstance `json:"instances"`
+}
+
+// HabitInstance 习惯在一个周期生成的待办事项
+type HabitInstance struct {
+	Period      string     `json:"period"`
+	TodoID      *int       `json:"todo_id,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ArchiveImportResult 导入的各类数据数量
+type ArchiveImportResult struct {
+	Projects        int `json:"projects"`
+	Todos           int `json:"todos"`
+	Comments        int `json:"comments"`
+	Links           int `json:"links"`
+	Goals           int `json:"goals"`
+	Habits          int `json:"habits"`
+	AutomationRules int `json:"automation_rules"`
+
+	Skipped map[string]int `json:"skipped,omitempty"` // 当前存储不支持、没有导入的数据数量，键为 links、goals、habits
+}
+
+// SkipUnsupported 记录只在 SQLite 中实现的链接、目标和习惯没有导入，供其他存储的 ImportArchiveContext 使用
+func (r *ArchiveImportResult) SkipUnsupported(archive *Archive) {
+	for kind, n := range map[string]int{"links": len(archive.Links), "goals": len(archive.Goals), "habits": len(archive.Habits)} {
+		if n == 0 {
+			continue
+		}
+		if r.Skipped == nil {
+			r.Skipped = make(map[string]int)
+		}
+		r.Skipped[kind] = n
+	}
+}
+
+// ImportedOccurrence 导入的待办事项的 NextOccurrenceID：归档中的 ID 导入后没有意义，
+// 已完成的重复事项记为 0，表示下一次已经处理过、不再生成，其他为空（与 SQLite 的导入相同）
+func ImportedOccurrence(todo *model.Todo) *int {
+	if todo.Recurrence == "" || todo.CompletedAt == nil {
+		return nil
+	}
+	none := 0
+	return &none
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// apiKeyTouchInterval last_used_at 的更新间隔，与 SQLite 实现一致
+const apiKeyTouchInterval = time.Minute
+
+// apiKey 一个 API key 和它的哈希
+type apiKey struct {
+	model.APIKey
+	hash string
+}
+
+// copyAPIKey 复制 API key，避免调用方修改存储中的数据
+func copyAPIKey(k *model.APIKey) model.APIKey {
+	out := *k
+	out.Workspaces = slices.Clone(k.Workspaces)
+	out.ExpiresAt = copyPtr(k.ExpiresAt)
+	out.LastUsedAt = copyPtr(k.LastUsedAt)
+	out.RevokedAt = copyPtr(k.RevokedAt)
+	return out
+}
+
+// CreateAPIKeyContext 保存 API key 和它绑定的工作区，hash 为 model.HashAPIKey 的结果
+func (s *Store) CreateAPIKeyContext(ctx context.Context, key *model.APIKey, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.apiKeys {
+		if k.hash == hash {
+			return fmt.Errorf("保存 API key 失败：key 已存在")
+		}
+	}
+	key.CreatedAt = s.clock.Now().UTC()
+	key.ID = int(s.next("api_keys"))
+	stored := copyAPIKey(key)
+	slices.Sort(stored.Workspaces)
+	stored.Workspaces = slices.Compact(stored.Workspaces)
+	if len(stored.Workspaces) == 0 {
+		stored.Workspaces = nil
+	}
+	s.apiKeys[key.ID] = &apiKey{APIKey: stored, hash: hash}
+	return nil
+}
+
+// ListAPIKeysContext 用户的所有 API key（包括已撤销和已过期的），最新的在前
+func (s *Store) ListAPIKeysContext(ctx context.Context, userID string) ([]model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]model.APIKey, 0)
+	for _, k := range s.apiKeys {
+		if k.UserID == userID {
+			keys = append(keys, copyAPIKey(&k.APIKey))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+// HasAPIKeysContext 是否创建过 API key（包括已撤销和已过期的）
+func (s *Store) HasAPIKeysContext(ctx context.Context) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.apiKeys) > 0, nil
+}
+
+// RevokeAPIKeyContext 撤销用户的 API key，已撤销的保持原来的撤销时间；不存在时返回 storage.ErrNotFound
+func (s *Store) RevokeAPIKeyContext(ctx context.Context, userID string, id int) (*model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.apiKeys[id]
+	if !ok || k.UserID != userID {
+		return nil, fmt.Errorf("api key %d: %w", id, storage.ErrNotFound)
+	}
+	if k.RevokedAt == nil {
+		now := s.clock.Now().UTC()
+		k.RevokedAt = &now
+	}
+	out := copyAPIKey(&k.APIKey)
+	return &out, nil
+}
+
+// AuthenticateAPIKeyContext 按哈希查找可以使用的 API key 并记录使用时间
+// 不存在、已撤销或已过期时都返回 storage.ErrNotFound，调用方不区分这几种情况
+func (s *Store) AuthenticateAPIKeyContext(ctx context.Context, hash string) (*model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.apiKeys {
+		if k.hash != hash {
+			continue
+		}
+		now := s.clock.Now().UTC()
+		if !k.Active(now) {
+			return nil, fmt.Errorf("api key %d: %w", k.ID, storage.ErrNotFound)
+		}
+		if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+			k.LastUsedAt = &now
+		}
+		out := copyAPIKey(&k.APIKey)
+		return &out, nil
+	}
+	return nil, fmt.Errorf("api key: %w", storage.ErrNotFound)
+}
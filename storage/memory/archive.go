@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// ExportArchiveContext 导出当前工作区的全部数据，在一次加锁中读取；链接、目标和习惯只在 SQLite 中实现，归档中这几项为空
+func (s *Store) ExportArchiveContext(ctx context.Context) (*storage.Archive, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ws, ok := s.workspaces[workspace]
+	if !ok {
+		return nil, fmt.Errorf("workspace %s: %w", workspace, storage.ErrNotFound)
+	}
+	archive := storage.NewArchive(ws, s.clock.Now().UTC())
+
+	for _, p := range s.projects {
+		if p.workspace == workspace {
+			out := p.Project
+			out.ArchivedAt = copyPtr(p.ArchivedAt)
+			out.TodoCount, out.OpenCount = 0, 0
+			archive.Projects = append(archive.Projects, out)
+		}
+	}
+	sort.Slice(archive.Projects, func(i, j int) bool { return archive.Projects[i].ID < archive.Projects[j].ID })
+
+	archive.Todos = s.scoped(ctx, storage.TodoFilter{})
+	sort.Slice(archive.Todos, func(i, j int) bool { return archive.Todos[i].ID < archive.Todos[j].ID })
+
+	for _, c := range s.comments {
+		if e, ok := s.todos[c.TodoID]; ok && e.workspace == workspace {
+			c.Mentions = slices.Clone(c.Mentions)
+			archive.Comments = append(archive.Comments, c)
+		}
+	}
+
+	for _, r := range s.rules {
+		if r.workspace == workspace {
+			archive.AutomationRules = append(archive.AutomationRules, copyRule(&r.AutomationRule))
+		}
+	}
+	sort.Slice(archive.AutomationRules, func(i, j int) bool { return archive.AutomationRules[i].ID < archive.AutomationRules[j].ID })
+	return archive, nil
+}
+
+// ImportArchiveContext 把归档导入当前工作区，规则与 SQLite 实现相同：目标工作区必须为空，所有记录重新分配 ID，
+// 引用了归档中不存在的记录时返回 *storage.ArchiveError。先检查整个归档再写入，出错时不留下部分数据；
+// 链接、目标和习惯不导入，数量记在 Skipped 中
+func (s *Store) ImportArchiveContext(ctx context.Context, archive *storage.Archive) (storage.ArchiveImportResult, error) {
+	var result storage.ArchiveImportResult
+	for i := range archive.AutomationRules {
+		if err := archive.AutomationRules[i].MigrateConditions(); err != nil {
+			return result, err
+		}
+	}
+
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.workspaceInUse(workspace) {
+		return result, fmt.Errorf("workspace %s: %w", workspace, storage.ErrWorkspaceNotEmpty)
+	}
+	if err := checkArchive(archive); err != nil {
+		return result, err
+	}
+
+	if ws, ok := s.workspaces[workspace]; ok && archive.Workspace.Name != "" {
+		ws.Name, ws.MaxTodos = archive.Workspace.Name, archive.Workspace.MaxTodos
+		s.workspaces[workspace] = ws
+	}
+
+	projectIDs := make(map[int]int, len(archive.Projects))
+	for _, p := range archive.Projects {
+		id := int(s.next("projects"))
+		s.projects[id] = &project{Project: model.Project{
+			ID: id, Name: p.Name, Description: p.Description, CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt,
+			ArchivedAt: copyPtr(p.ArchivedAt),
+		}, workspace: workspace}
+		projectIDs[p.ID] = id
+		result.Projects++
+	}
+
+	todoIDs := make(map[int]int, len(archive.Todos))
+	for _, todo := range archive.Todos {
+		oldID := todo.ID
+		if todo.Version < 1 {
+			todo.Version = 1
+		}
+		if todo.ProjectID != nil {
+			id := projectIDs[*todo.ProjectID]
+			todo.ProjectID = &id
+		}
+		todo.NextOccurrenceID = storage.ImportedOccurrence(&todo)
+		s.insert(&todo, workspace)
+		todoIDs[oldID] = todo.ID
+		result.Todos++
+	}
+
+	for _, c := range archive.Comments {
+		c.ID = int(s.next("todo_comments"))
+		c.TodoID = todoIDs[c.TodoID]
+		c.Mentions = slices.Clone(c.Mentions)
+		s.comments = append(s.comments, c)
+		result.Comments++
+	}
+
+	for i := range archive.AutomationRules {
+		r := copyRule(&archive.AutomationRules[i])
+		r.ID = int(s.next("automation_rules"))
+		s.rules[r.ID] = &rule{AutomationRule: r, workspace: workspace}
+		result.AutomationRules++
+	}
+
+	result.SkipUnsupported(archive)
+	return result, nil
+}
+
+// workspaceInUse 工作区中是否已有待办事项、项目或自动化规则，调用方需要持有锁
+func (s *Store) workspaceInUse(workspace string) bool {
+	for _, e := range s.todos {
+		if e.workspace == workspace {
+			return true
+		}
+	}
+	for _, p := range s.projects {
+		if p.workspace == workspace {
+			return true
+		}
+	}
+	for _, r := range s.rules {
+		if r.workspace == workspace {
+			return true
+		}
+	}
+	return false
+}
+
+// checkArchive 检查归档内的引用和项目名称，写入前调用，保证导入不会中途失败
+func checkArchive(archive *storage.Archive) error {
+	projects := make(map[int]bool, len(archive.Projects))
+	names := make(map[string]bool, len(archive.Projects))
+	for _, p := range archive.Projects {
+		name := strings.ToLower(p.Name)
+		if names[name] {
+			return storage.ErrProjectExists
+		}
+		names[name] = true
+		projects[p.ID] = true
+	}
+
+	todos := make(map[int]bool, len(archive.Todos))
+	for _, t := range archive.Todos {
+		if t.ProjectID != nil && !projects[*t.ProjectID] {
+			return &storage.ArchiveError{Message: fmt.Sprintf("待办事项 %d 引用了不存在的项目 %d", t.ID, *t.ProjectID)}
+		}
+		todos[t.ID] = true
+	}
+	for _, c := range archive.Comments {
+		if !todos[c.TodoID] {
+			return &storage.ArchiveError{Message: fmt.Sprintf("评论引用了不存在的待办事项 %d", c.TodoID)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// rule 一条自动化规则和它所属的工作区
+type rule struct {
+	model.AutomationRule
+	workspace string
+}
+
+// copyRule 复制规则，避免调用方修改存储中的数据
+func copyRule(r *model.AutomationRule) model.AutomationRule {
+	out := *r
+	out.Actions = slices.Clone(r.Actions)
+	out.Conditions = nil
+	return out
+}
+
+// lookupRule 当前工作区中的规则，不存在时返回 storage.ErrNotFound，调用方需要持有锁
+func (s *Store) lookupRule(ctx context.Context, id int) (*rule, error) {
+	r, ok := s.rules[id]
+	if !ok || r.workspace != storage.WorkspaceFromContext(ctx) {
+		return nil, fmt.Errorf("automation rule %d: %w", id, storage.ErrNotFound)
+	}
+	return r, nil
+}
+
+// CreateAutomationRuleContext 保存自动化规则，旧版条件先转换为表达式
+func (s *Store) CreateAutomationRuleContext(ctx context.Context, r *model.AutomationRule) error {
+	if err := r.MigrateConditions(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.CreatedAt = s.clock.Now().UTC()
+	r.ID = int(s.next("automation_rules"))
+	s.rules[r.ID] = &rule{AutomationRule: copyRule(r), workspace: storage.WorkspaceFromContext(ctx)}
+	return nil
+}
+
+// GetAutomationRuleContext 获取一条规则，不存在时返回 storage.ErrNotFound
+func (s *Store) GetAutomationRuleContext(ctx context.Context, id int) (*model.AutomationRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, err := s.lookupRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := copyRule(&r.AutomationRule)
+	return &out, nil
+}
+
+// ListAutomationRulesContext 获取当前工作区的规则，按创建顺序排列
+// event 不为空时只返回该事件下已启用的规则，供规则执行使用
+func (s *Store) ListAutomationRulesContext(ctx context.Context, event string) ([]model.AutomationRule, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := make([]model.AutomationRule, 0)
+	for _, r := range s.rules {
+		if r.workspace != workspace || (event != "" && (r.Event != event || !r.Enabled)) {
+			continue
+		}
+		rules = append(rules, copyRule(&r.AutomationRule))
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+// UpdateAutomationRuleContext 整体替换规则内容，规则不存在时返回 storage.ErrNotFound
+func (s *Store) UpdateAutomationRuleContext(ctx context.Context, r *model.AutomationRule) error {
+	if err := r.MigrateConditions(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.lookupRule(ctx, r.ID)
+	if err != nil {
+		return err
+	}
+	updated := copyRule(r)
+	updated.CreatedAt = existing.CreatedAt
+	existing.AutomationRule = updated
+	return nil
+}
+
+// DeleteAutomationRuleContext 删除规则，规则不存在时返回 storage.ErrNotFound
+func (s *Store) DeleteAutomationRuleContext(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.lookupRule(ctx, id); err != nil {
+		return err
+	}
+	delete(s.rules, id)
+	return nil
+}
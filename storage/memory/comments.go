@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// CreateCommentContext 保存评论，并在同一次加锁中写入提及产生的通知
+func (s *Store) CreateCommentContext(ctx context.Context, comment *model.Comment, notifications []model.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comment.ID = int(s.next("todo_comments"))
+	c := *comment
+	c.Mentions = slices.Clone(comment.Mentions)
+	s.comments = append(s.comments, c)
+	for i := range notifications {
+		s.addNotification(&notifications[i])
+	}
+	return nil
+}
+
+// ListCommentsContext 获取当前工作区中待办事项的评论，按时间先后排列
+func (s *Store) ListCommentsContext(ctx context.Context, todoID int) ([]model.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	comments := make([]model.Comment, 0)
+	if e, ok := s.todos[todoID]; !ok || e.workspace != storage.WorkspaceFromContext(ctx) {
+		return comments, nil
+	}
+	for _, c := range s.comments {
+		if c.TodoID == todoID {
+			c.Mentions = slices.Clone(c.Mentions)
+			comments = append(comments, c)
+		}
+	}
+	return comments, nil
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// event 一条变更事件和它所属的工作区
+type event struct {
+	model.TodoEvent
+	workspace string
+}
+
+// record 记录待办事项 e 的一次变更，含义与 SQLite 中记录事件的触发器一致，调用方需要持有写锁
+func (s *Store) record(e *entry, kind string) {
+	version := e.todo.Version
+	if version < 1 {
+		version = 1
+	}
+	s.events = append(s.events, event{
+		TodoEvent: model.TodoEvent{
+			ID:        s.next("todo_events"),
+			Type:      kind,
+			TodoID:    e.todo.ID,
+			PublicID:  e.todo.PublicID,
+			Version:   version,
+			ProjectID: copyPtr(e.todo.ProjectID),
+			Priority:  e.todo.Priority,
+			At:        s.clock.Now().UTC(),
+		},
+		workspace: e.workspace,
+	})
+}
+
+// updateEventType 修改产生的事件类型：从未完成变为完成时为 completed，否则为 updated
+func updateEventType(old, updated *model.Todo) string {
+	if old.CompletedAt == nil && updated.CompletedAt != nil {
+		return model.TodoEventCompleted
+	}
+	return model.TodoEventUpdated
+}
+
+// touch 项目级操作修改待办事项后更新时间、增加版本号并记录事件，调用方需要持有写锁
+func (s *Store) touch(e *entry, kind string, now time.Time) {
+	e.todo.UpdatedAt = now
+	e.todo.Version++
+	s.record(e, kind)
+}
+
+// ListTodoEventsContext 查询当前工作区 ID 大于 after 的事件，按 ID 升序，最多 limit 条
+func (s *Store) ListTodoEventsContext(ctx context.Context, after int64, limit int) ([]model.TodoEvent, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []model.TodoEvent
+	for _, e := range s.events {
+		if len(events) >= limit {
+			break
+		}
+		if e.workspace == workspace && e.ID > after {
+			ev := e.TodoEvent
+			ev.ProjectID = copyPtr(e.ProjectID)
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// TodoEventRangeContext 返回仍然保留的最早事件 ID 和已分配的最新事件 ID（所有工作区共用一个序列）
+func (s *Store) TodoEventRangeContext(ctx context.Context) (first, last int64, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	last = s.seq["todo_events"]
+	if len(s.events) == 0 {
+		return last + 1, last, nil
+	}
+	return s.events[0].ID, last, nil
+}
+
+// PurgeTodoEventsContext 删除 before 之前的变更事件
+func (s *Store) PurgeTodoEventsContext(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.events)
+	s.events = slices.DeleteFunc(s.events, func(e event) bool { return e.At.Before(before) })
+	return n - len(s.events), nil
+}
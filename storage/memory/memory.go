@@ -0,0 +1,586 @@
+// Package memory 保存在内存中的数据（--storage=memory 或 DB_DRIVER=memory），实现 storage.TodoRepository 和 storage.Repositories
+//
+// 用于演示和测试：进程退出后数据全部丢失，不需要 SQLite（DB_PATH）。按工作区隔离、乐观锁版本号、外部标识、过滤条件、
+// 项目归档和变更事件与 SQLite 实现一致；链接、附件、目标、习惯等只在 SQLite 中实现的功能不可用，见 handler.RequireSQLiteTodos
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// entry 一条待办事项和它所属的工作区
+type entry struct {
+	todo      model.Todo
+	workspace string
+}
+
+// Store 内存中的待办事项和其他数据，并发安全；所有数据共用一把锁，修改待办事项和记录变更事件在同一次加锁中完成
+type Store struct {
+	mu        sync.RWMutex
+	todos     map[int]*entry
+	publicIDs map[string]int // 外部标识 -> ID，所有工作区共用，与 SQLite 的唯一索引一致
+	nextID    int
+
+	projects      map[int]*project
+	comments      []model.Comment
+	notifications []model.Notification
+	prefs         map[string]model.NotificationPreferences
+	events        []event
+	webhooks      map[int]*webhook
+	deliveries    []delivery
+	apiKeys       map[int]*apiKey
+	workspaces    map[string]model.Workspace
+	rules         map[int]*rule
+	seq           map[string]int64 // 各类记录已分配的最大 ID，与 SQLite 的自增序列一样删除后不复用
+
+	batchLimit int
+	clock      clock.Clock
+	statuses   storage.StatusSets
+}
+
+// New 创建空的内存存储
+func New() *Store {
+	s := &Store{
+		todos:      make(map[int]*entry),
+		publicIDs:  make(map[string]int),
+		projects:   make(map[int]*project),
+		prefs:      make(map[string]model.NotificationPreferences),
+		webhooks:   make(map[int]*webhook),
+		apiKeys:    make(map[int]*apiKey),
+		workspaces: make(map[string]model.Workspace),
+		rules:      make(map[int]*rule),
+		seq:        make(map[string]int64),
+		batchLimit: storage.DefaultBatchLimit,
+		clock:      clock.Real{},
+		statuses:   storage.DefaultStatusSets(),
+	}
+	s.workspaces[model.DefaultWorkspace] = model.Workspace{Slug: model.DefaultWorkspace, Name: "Default", CreatedAt: s.clock.Now().UTC()}
+	return s
+}
+
+// next 分配 kind 类记录的下一个 ID，调用方需要持有写锁
+func (s *Store) next(kind string) int64 {
+	s.seq[kind]++
+	return s.seq[kind]
+}
+
+// PingContext 内存存储总是可用
+func (s *Store) PingContext(ctx context.Context) error {
+	return nil
+}
+
+// SetBatchLimit 设置单次批量操作的上限，n <= 0 时使用默认值
+func (s *Store) SetBatchLimit(n int) {
+	if n <= 0 {
+		n = storage.DefaultBatchLimit
+	}
+	s.batchLimit = n
+}
+
+// SetClock 替换取当前时间的时钟（测试中使用 clock.Fake）
+func (s *Store) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetStatuses 按工作流设置未完成状态和终态，含义与 SQLite 实现相同
+func (s *Store) SetStatuses(sets storage.StatusSets) {
+	s.statuses = sets
+}
+
+// copyPtr 复制指针指向的值，避免调用方修改存储中的数据
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// clone 复制一条待办事项，指针字段也一起复制；只保存存储的字段，不保存输出时计算的字段
+func clone(t *model.Todo) model.Todo {
+	return model.Todo{
+		ID:               t.ID,
+		PublicID:         t.PublicID,
+		Version:          t.Version,
+		Title:            t.Title,
+		Description:      t.Description,
+		Status:           t.Status,
+		DueDate:          copyPtr(t.DueDate),
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+		CompletedAt:      copyPtr(t.CompletedAt),
+		Latitude:         copyPtr(t.Latitude),
+		Longitude:        copyPtr(t.Longitude),
+		Radius:           copyPtr(t.Radius),
+		EstimatedMinutes: copyPtr(t.EstimatedMinutes),
+		ProjectID:        copyPtr(t.ProjectID),
+		Priority:         t.Priority,
+		Recurrence:       t.Recurrence,
+		RecurrenceStart:  copyPtr(t.RecurrenceStart),
+		NextOccurrenceID: copyPtr(t.NextOccurrenceID),
+		SecretNote:       copyPtr(t.SecretNote),
+	}
+}
+
+// lookup 当前工作区中的待办事项，调用方需要持有锁
+func (s *Store) lookup(ctx context.Context, id int) (*entry, error) {
+	e, ok := s.todos[id]
+	if !ok || e.workspace != storage.WorkspaceFromContext(ctx) {
+		return nil, fmt.Errorf("todo %d: %w", id, storage.ErrNotFound)
+	}
+	return e, nil
+}
+
+// scoped 当前工作区中符合过滤条件的待办事项（副本），按 ID 排列，调用方需要持有锁
+func (s *Store) scoped(ctx context.Context, f storage.TodoFilter) []model.Todo {
+	workspace := storage.WorkspaceFromContext(ctx)
+	var todos []model.Todo
+	for _, e := range s.todos {
+		if e.workspace == workspace && matches(&e.todo, f, s.statuses) {
+			todos = append(todos, clone(&e.todo))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+	return todos
+}
+
+// insert 保存一条新的待办事项并分配 ID，外部标识为空或已被占用时重新生成，调用方需要持有写锁
+func (s *Store) insert(todo *model.Todo, workspace string) {
+	if id, ok := model.NormalizePublicID(todo.PublicID); ok {
+		todo.PublicID = id
+	} else {
+		todo.PublicID = ""
+	}
+	for todo.PublicID == "" || s.publicIDs[todo.PublicID] != 0 {
+		todo.PublicID = model.NewPublicID(todo.CreatedAt)
+	}
+	s.nextID++
+	todo.ID = s.nextID
+	e := &entry{todo: clone(todo), workspace: workspace}
+	s.todos[todo.ID] = e
+	s.publicIDs[todo.PublicID] = todo.ID
+	s.record(e, model.TodoEventCreated)
+}
+
+// remove 删除一条待办事项，评论和引用它的通知随之删除（与 SQLite 的外键级联一致），调用方需要持有写锁
+func (s *Store) remove(id int) {
+	e, ok := s.todos[id]
+	if !ok {
+		return
+	}
+	s.record(e, model.TodoEventDeleted)
+	delete(s.publicIDs, e.todo.PublicID)
+	delete(s.todos, id)
+	s.comments = slices.DeleteFunc(s.comments, func(c model.Comment) bool { return c.TodoID == id })
+	s.notifications = slices.DeleteFunc(s.notifications, func(n model.Notification) bool {
+		return n.TodoID != nil && *n.TodoID == id
+	})
+}
+
+// CreateTodoContext 在当前工作区创建待办事项
+func (s *Store) CreateTodoContext(ctx context.Context, todo *model.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.insert(todo, storage.WorkspaceFromContext(ctx))
+	return nil
+}
+
+// GetTodoByIDContext 根据 ID 获取当前工作区的待办事项，不存在时返回 storage.ErrNotFound
+func (s *Store) GetTodoByIDContext(ctx context.Context, id int) (*model.Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, err := s.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo := clone(&e.todo)
+	return &todo, nil
+}
+
+// GetTodoIDByPublicIDContext 按外部标识查找当前工作区中待办事项的 ID，不存在时返回 storage.ErrNotFound
+func (s *Store) GetTodoIDByPublicIDContext(ctx context.Context, publicID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.publicIDs[publicID]
+	if !ok || s.todos[id].workspace != storage.WorkspaceFromContext(ctx) {
+		return 0, fmt.Errorf("todo %s: %w", publicID, storage.ErrNotFound)
+	}
+	return id, nil
+}
+
+// ListTodosContext 获取当前工作区的待办事项列表，排序和分页与 SQLite 实现一致（空值排在最小的一端）
+func (s *Store) ListTodosContext(ctx context.Context, filter storage.TodoFilter) ([]model.Todo, int, error) {
+	filter = storage.NormalizeFilter(filter)
+
+	s.mu.RLock()
+	todos := s.scoped(ctx, filter)
+	// 已归档项目中的事项只在按项目查看或明确要求时列出
+	if filter.ProjectID == nil && !filter.IncludeArchived {
+		todos = s.withoutArchived(todos)
+	}
+	s.mu.RUnlock()
+
+	less := sortLess(filter.Sort)
+	sort.SliceStable(todos, func(i, j int) bool {
+		if filter.Order == "DESC" {
+			return less(&todos[j], &todos[i])
+		}
+		return less(&todos[i], &todos[j])
+	})
+
+	total := len(todos)
+	if filter.SkipTotal {
+		total = -1
+	}
+	start := min(filter.Offset, len(todos))
+	end := min(start+filter.Limit, len(todos))
+	return todos[start:end], total, nil
+}
+
+// sortLess 按排序字段比较两条待办事项，字段只能是 todoSortFields 中的值（已由 NormalizeFilter 校验）
+func sortLess(field string) func(a, b *model.Todo) bool {
+	switch field {
+	case "due_date":
+		return func(a, b *model.Todo) bool {
+			if a.DueDate == nil || b.DueDate == nil {
+				return a.DueDate == nil && b.DueDate != nil
+			}
+			return a.DueDate.Before(*b.DueDate)
+		}
+	case "status":
+		return func(a, b *model.Todo) bool { return a.Status < b.Status }
+	case "priority":
+		return func(a, b *model.Todo) bool { return a.Priority < b.Priority }
+	case "updated_at":
+		return func(a, b *model.Todo) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	default:
+		return func(a, b *model.Todo) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	}
+}
+
+// UpdateTodoContext 按版本号更新待办事项，版本号不一致时返回 storage.ErrVersionConflict
+// 与 SQLite 实现一样，创建时间、外部标识和已生成的下一次不会被修改
+func (s *Store) UpdateTodoContext(ctx context.Context, todo *model.Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, err := s.lookup(ctx, todo.ID)
+	if err != nil {
+		return err
+	}
+	if e.todo.Version != todo.Version {
+		return storage.ErrVersionConflict
+	}
+
+	todo.UpdatedAt = s.clock.Now()
+	updated := clone(todo)
+	updated.Version++
+	updated.PublicID = e.todo.PublicID
+	updated.CreatedAt = e.todo.CreatedAt
+	updated.NextOccurrenceID = e.todo.NextOccurrenceID
+	kind := updateEventType(&e.todo, &updated)
+	e.todo = updated
+	s.record(e, kind)
+
+	todo.Version++
+	return nil
+}
+
+// DeleteTodoContext 删除当前工作区的待办事项
+func (s *Store) DeleteTodoContext(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.lookup(ctx, id); err != nil {
+		return err
+	}
+	s.remove(id)
+	return nil
+}
+
+// GetFilteredStatsContext 统计符合列表过滤条件的待办事项，Status 不参与过滤
+func (s *Store) GetFilteredStatsContext(ctx context.Context, filter storage.TodoFilter) (*storage.TodoStats, error) {
+	filter.Status = ""
+	s.mu.RLock()
+	todos := s.scoped(ctx, filter)
+	// 与 ListTodosContext 一致：不按项目过滤时不统计已归档项目中的事项
+	if filter.ProjectID == nil {
+		todos = s.withoutArchived(todos)
+	}
+	s.mu.RUnlock()
+
+	now := s.clock.Now().UTC()
+	today := now.Format("2006-01-02")
+	weekLater := now.AddDate(0, 0, 7).Format("2006-01-02")
+
+	stats := storage.TodoStats{ByStatus: make(map[string]int)}
+	for i := range todos {
+		t := &todos[i]
+		stats.ByStatus[t.Status]++
+		stats.Total++
+		if t.CompletedAt != nil {
+			continue
+		}
+		if s.statuses.IsOpen(t.Status) && t.DueDate != nil {
+			due := utcDate(*t.DueDate)
+			if t.DueDate.Before(now) {
+				stats.Overdue++
+			}
+			if due == today {
+				stats.Today++
+			}
+			if due >= today && due <= weekLater {
+				stats.ThisWeek++
+			}
+		}
+		if inInbox(t) {
+			stats.Inbox++
+		}
+	}
+	stats.Pending = s.statuses.CountOpen(stats.ByStatus)
+	stats.Completed = s.statuses.CountDone(stats.ByStatus)
+	stats.OverdueBuckets = s.overdueBuckets(todos, now)
+	stats.CompletionLatency = s.completionLatency(todos)
+	stats.Focus = s.focusScore(todos, now)
+	return &stats, nil
+}
+
+// CountTodosContext 统计当前工作区的待办事项数量（用于数量上限检查）
+func (s *Store) CountTodosContext(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.scoped(ctx, storage.TodoFilter{})), nil
+}
+
+// CountTodosCreatedSinceContext 统计当前工作区在 since 之后创建的待办事项数量
+func (s *Store) CountTodosCreatedSinceContext(ctx context.Context, since time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for _, t := range s.scoped(ctx, storage.TodoFilter{}) {
+		if !t.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// checkBatch 检查批量大小
+func (s *Store) checkBatch(ids []int) error {
+	if len(ids) > s.batchLimit {
+		return fmt.Errorf("批量操作最多支持%d个项目，当前：%d", s.batchLimit, len(ids))
+	}
+	return nil
+}
+
+// completable 待办事项存在且未完成（工作流中的非终态），批量完成只处理这样的事项
+func (s *Store) completable(ctx context.Context, id int) bool {
+	e, err := s.lookup(ctx, id)
+	return err == nil && s.statuses.IsOpen(e.todo.Status)
+}
+
+// complete 标记为完成（与 SQLite 实现一样只修改状态和时间，不增加版本号）
+func (s *Store) complete(id int, now time.Time) {
+	e := s.todos[id]
+	e.todo.Status = "completed"
+	e.todo.CompletedAt = &now
+	e.todo.UpdatedAt = now
+	s.record(e, model.TodoEventCompleted)
+}
+
+// BatchCompleteTodosContext 批量完成待办事项（全有或全无）
+func (s *Store) BatchCompleteTodosContext(ctx context.Context, ids []int) error {
+	if err := s.checkBatch(ids); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先全部检查再修改，任何一个不满足都不做改动；同一个 ID 出现两次时第二次已不是 pending
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] || !s.completable(ctx, id) {
+			return fmt.Errorf("待办事项 %d 不存在或已完成", id)
+		}
+		seen[id] = true
+	}
+	now := s.clock.Now().UTC()
+	for _, id := range ids {
+		s.complete(id, now)
+	}
+	return nil
+}
+
+// BatchDeleteTodosContext 批量删除待办事项（全有或全无）
+func (s *Store) BatchDeleteTodosContext(ctx context.Context, ids []int) error {
+	if err := s.checkBatch(ids); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if _, err := s.lookup(ctx, id); seen[id] || err != nil {
+			return fmt.Errorf("待办事项 %d 不存在", id)
+		}
+		seen[id] = true
+	}
+	for _, id := range ids {
+		s.remove(id)
+	}
+	return nil
+}
+
+// BatchCompleteTodosPartialContext 批量完成待办事项（部分成功，失败的 ID 记录在结果中）
+func (s *Store) BatchCompleteTodosPartialContext(ctx context.Context, ids []int) (*storage.BatchResult, error) {
+	if err := s.checkBatch(ids); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &storage.BatchResult{Errors: make([]storage.BatchError, 0)}
+	now := s.clock.Now().UTC()
+	for _, id := range ids {
+		if !s.completable(ctx, id) {
+			result.FailedCount++
+			result.Errors = append(result.Errors, storage.BatchError{ID: id, Error: "待办事项不存在或已完成"})
+			continue
+		}
+		s.complete(id, now)
+		result.SuccessCount++
+	}
+	return result, nil
+}
+
+// BatchDeleteTodosPartialContext 批量删除待办事项（部分成功，失败的 ID 记录在结果中）
+func (s *Store) BatchDeleteTodosPartialContext(ctx context.Context, ids []int) (*storage.BatchResult, error) {
+	if err := s.checkBatch(ids); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := &storage.BatchResult{Errors: make([]storage.BatchError, 0)}
+	for _, id := range ids {
+		if _, err := s.lookup(ctx, id); err != nil {
+			result.FailedCount++
+			result.Errors = append(result.Errors, storage.BatchError{ID: id, Error: "待办事项不存在"})
+			continue
+		}
+		s.remove(id)
+		result.SuccessCount++
+	}
+	return result, nil
+}
+
+// ImportTodosContext 导入待办事项，空标题的跳过；全部写入后才对其他请求可见
+func (s *Store) ImportTodosContext(ctx context.Context, todos []model.Todo) (int, error) {
+	if len(todos) > storage.MaxImportSize {
+		return 0, fmt.Errorf("单次导入最多 %d 条，当前：%d", storage.MaxImportSize, len(todos))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workspace := storage.WorkspaceFromContext(ctx)
+	now := s.clock.Now().UTC()
+	imported := 0
+	for _, todo := range todos {
+		if todo.Title == "" {
+			continue // 跳过无效数据
+		}
+		if todo.Status == "" {
+			todo.Status = "pending"
+		}
+		if todo.CreatedAt.IsZero() {
+			todo.CreatedAt = now
+		}
+		todo.UpdatedAt = now
+		todo.Version = 1
+		s.insert(&todo, workspace)
+		imported++
+	}
+	return imported, nil
+}
+
+// ExportTodosContext 导出当前工作区的所有待办事项，按创建时间倒序
+func (s *Store) ExportTodosContext(ctx context.Context) ([]model.Todo, error) {
+	s.mu.RLock()
+	todos := s.scoped(ctx, storage.TodoFilter{})
+	s.mu.RUnlock()
+	sort.SliceStable(todos, func(i, j int) bool { return todos[j].CreatedAt.Before(todos[i].CreatedAt) })
+	return todos, nil
+}
+
+// matches 待办事项是否符合列表过滤条件，含义与 SQLite 实现的 todoQuery.filter 一致
+// 收件箱不考虑目标关联（目标保存在 SQLite 中）
+func matches(t *model.Todo, f storage.TodoFilter, sets storage.StatusSets) bool {
+	switch {
+	case f.Status != "" && f.Status != "all" && t.Status != f.Status:
+		return false
+	case f.Search != "" && !containsFold(t.Title, f.Search) && !containsFold(t.Description, f.Search):
+		return false
+	case f.Near != nil && !near(t, f):
+		return false
+	case f.Priority != nil && t.Priority != *f.Priority:
+		return false
+	case f.MinPriority != nil && t.Priority < *f.MinPriority:
+		return false
+	case f.ProjectID != nil && *f.ProjectID == 0 && t.ProjectID != nil:
+		return false
+	case f.ProjectID != nil && *f.ProjectID != 0 && (t.ProjectID == nil || *t.ProjectID != *f.ProjectID):
+		return false
+	case f.PendingOnly && !sets.IsOpen(t.Status):
+		return false
+	case f.DueDateFrom != "" && (t.DueDate == nil || utcDate(*t.DueDate) < f.DueDateFrom):
+		return false
+	case f.DueDateTo != "" && (t.DueDate == nil || utcDate(*t.DueDate) > f.DueDateTo):
+		return false
+	case f.DueBefore != nil && (t.DueDate == nil || !t.DueDate.Before(*f.DueBefore)):
+		return false
+	case f.InboxOnly && !inInbox(t):
+		return false
+	case f.UpdatedBefore != nil && !t.UpdatedAt.Before(*f.UpdatedBefore):
+		return false
+	}
+	return true
+}
+
+// near 待办事项与当前位置的距离不超过半径：请求指定 > 待办事项自身设置 > DefaultRadiusMeters
+func near(t *model.Todo, f storage.TodoFilter) bool {
+	if t.Latitude == nil || t.Longitude == nil {
+		return false
+	}
+	radius := storage.DefaultRadiusMeters
+	switch {
+	case f.RadiusMeters > 0:
+		radius = f.RadiusMeters
+	case t.Radius != nil:
+		radius = *t.Radius
+	}
+	return storage.HaversineMeters(*t.Latitude, *t.Longitude, f.Near.Lat, f.Near.Lng) <= radius
+}
+
+// inInbox 收件箱：未进入终态、没有截止日期、不属于任何项目
+func inInbox(t *model.Todo) bool {
+	return t.CompletedAt == nil && t.DueDate == nil && t.ProjectID == nil
+}
+
+// containsFold 不区分大小写的包含，与 SQLite 的 LIKE 一致
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// utcDate 按 UTC 取日期，格式 2006-01-02
+func utcDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
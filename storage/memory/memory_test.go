@@ -0,0 +1,20 @@
+package memory_test
+
+import (
+	"testing"
+	"todo-list/storage"
+	"todo-list/storage/memory"
+	"todo-list/storage/storagetest"
+)
+
+func TestRepository(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.TodoRepository {
+		return memory.New()
+	})
+}
+
+func TestRepositories(t *testing.T) {
+	storagetest.RunRepositories(t, func(t *testing.T) storagetest.Store {
+		return memory.New()
+	})
+}
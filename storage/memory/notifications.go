@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+	"todo-list/model"
+)
+
+// 单次最多返回的通知数量，与 SQLite 实现一致
+const maxNotificationsLimit = 100
+
+// addNotification 保存一条通知并分配 ID，调用方需要持有写锁
+func (s *Store) addNotification(n *model.Notification) {
+	n.ID = int(s.next("notifications"))
+	s.notifications = append(s.notifications, copyNotification(n))
+}
+
+// copyNotification 复制通知，避免调用方修改存储中的数据
+func copyNotification(n *model.Notification) model.Notification {
+	out := *n
+	out.TodoID = copyPtr(n.TodoID)
+	out.ReadAt = copyPtr(n.ReadAt)
+	return out
+}
+
+// CreateNotificationContext 写入一条站内通知
+func (s *Store) CreateNotificationContext(ctx context.Context, n *model.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addNotification(n)
+	return nil
+}
+
+// CountUnreadNotificationsContext 用户的未读通知数量
+func (s *Store) CountUnreadNotificationsContext(ctx context.Context, userID string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for _, n := range s.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MarkAllNotificationsReadContext 将用户的所有未读通知标记为已读，返回标记的数量
+func (s *Store) MarkAllNotificationsReadContext(ctx context.Context, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now().UTC()
+	count := 0
+	for i := range s.notifications {
+		n := &s.notifications[i]
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &now
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListNotificationsContext 获取用户的通知，最新的在前；unreadOnly 为 true 时只返回未读
+func (s *Store) ListNotificationsContext(ctx context.Context, userID string, unreadOnly bool, limit int) ([]model.Notification, error) {
+	if limit <= 0 || limit > maxNotificationsLimit {
+		limit = maxNotificationsLimit
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	notifications := make([]model.Notification, 0)
+	for i := len(s.notifications) - 1; i >= 0 && len(notifications) < limit; i-- {
+		n := &s.notifications[i]
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, copyNotification(n))
+		}
+	}
+	return notifications, nil
+}
+
+// MarkNotificationReadContext 将用户的某条通知标记为已读，通知不存在时返回 false
+// 已读的通知再次标记不会修改 read_at
+func (s *Store) MarkNotificationReadContext(ctx context.Context, userID string, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.notifications {
+		n := &s.notifications[i]
+		if n.ID == id && n.UserID == userID {
+			if n.ReadAt == nil {
+				now := s.clock.Now().UTC()
+				n.ReadAt = &now
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PurgeReadNotificationsContext 删除 before 之前已读的通知，未读通知不受影响
+func (s *Store) PurgeReadNotificationsContext(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.notifications)
+	s.notifications = slices.DeleteFunc(s.notifications, func(n model.Notification) bool {
+		return n.ReadAt != nil && n.ReadAt.Before(before)
+	})
+	return n - len(s.notifications), nil
+}
+
+// GetNotificationPreferencesContext 获取用户通知偏好，没有记录时返回默认值
+func (s *Store) GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	prefs, ok := s.prefs[userID]
+	if !ok {
+		return model.DefaultNotificationPreferences(userID), nil
+	}
+	return copyPreferences(&prefs), nil
+}
+
+// SaveNotificationPreferencesContext 保存用户通知偏好（不存在则插入）
+func (s *Store) SaveNotificationPreferencesContext(ctx context.Context, prefs *model.NotificationPreferences) error {
+	if prefs.MutedProjects == nil {
+		prefs.MutedProjects = []int{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs.UpdatedAt = s.clock.Now().UTC()
+	s.prefs[prefs.UserID] = *copyPreferences(prefs)
+	return nil
+}
+
+// copyPreferences 复制通知偏好中的 map 和切片
+func copyPreferences(p *model.NotificationPreferences) *model.NotificationPreferences {
+	out := *p
+	out.Channels = maps.Clone(p.Channels)
+	out.MutedProjects = slices.Clone(p.MutedProjects)
+	return &out
+}
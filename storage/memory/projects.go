@@ -0,0 +1,270 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// project 一个项目和它所属的工作区，TodoCount、OpenCount 在读取时统计
+type project struct {
+	model.Project
+	workspace string
+}
+
+// lookupProject 当前工作区中的项目，不存在时返回 storage.ErrNotFound，调用方需要持有锁
+func (s *Store) lookupProject(ctx context.Context, id int) (*project, error) {
+	p, ok := s.projects[id]
+	if !ok || p.workspace != storage.WorkspaceFromContext(ctx) {
+		return nil, fmt.Errorf("project %d: %w", id, storage.ErrNotFound)
+	}
+	return p, nil
+}
+
+// nameTaken 工作区中是否已有同名（不区分大小写）的其他项目，调用方需要持有锁
+func (s *Store) nameTaken(workspace, name string, except int) bool {
+	for _, p := range s.projects {
+		if p.workspace == workspace && p.ID != except && strings.EqualFold(p.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// archived 待办事项是否属于已归档的项目（对应 SQLite 中的 hidden 列），调用方需要持有锁
+func (s *Store) archived(t *model.Todo) bool {
+	if t.ProjectID == nil {
+		return false
+	}
+	p, ok := s.projects[*t.ProjectID]
+	return ok && p.ArchivedAt != nil
+}
+
+// withoutArchived 去掉已归档项目中的待办事项，调用方需要持有锁
+func (s *Store) withoutArchived(todos []model.Todo) []model.Todo {
+	visible := todos[:0]
+	for i := range todos {
+		if !s.archived(&todos[i]) {
+			visible = append(visible, todos[i])
+		}
+	}
+	return visible
+}
+
+// projectTodos 当前工作区中属于项目 id 的待办事项，按 ID 排列，调用方需要持有锁
+func (s *Store) projectTodos(workspace string, id int) []*entry {
+	var entries []*entry
+	for _, e := range s.todos {
+		if e.workspace == workspace && e.todo.ProjectID != nil && *e.todo.ProjectID == id {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].todo.ID < entries[j].todo.ID })
+	return entries
+}
+
+// counted 复制项目并统计其中的待办事项数量，未完成的数量按工作流的未完成状态统计，调用方需要持有锁
+func (s *Store) counted(p *project) model.Project {
+	out := p.Project
+	out.ArchivedAt = copyPtr(p.ArchivedAt)
+	out.TodoCount, out.OpenCount = 0, 0
+	for _, e := range s.projectTodos(p.workspace, p.ID) {
+		out.TodoCount++
+		if s.statuses.IsOpen(e.todo.Status) {
+			out.OpenCount++
+		}
+	}
+	return out
+}
+
+// CreateProjectContext 在当前工作区创建项目，同名时返回 storage.ErrProjectExists
+func (s *Store) CreateProjectContext(ctx context.Context, p *model.Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createProject(storage.WorkspaceFromContext(ctx), p)
+}
+
+// createProject 保存项目，调用方需要持有写锁
+func (s *Store) createProject(workspace string, p *model.Project) error {
+	if s.nameTaken(workspace, p.Name, 0) {
+		return storage.ErrProjectExists
+	}
+	now := s.clock.Now().UTC()
+	p.CreatedAt, p.UpdatedAt = now, now
+	p.ID = int(s.next("projects"))
+	s.projects[p.ID] = &project{Project: model.Project{
+		ID: p.ID, Name: p.Name, Description: p.Description, CreatedAt: now, UpdatedAt: now,
+	}, workspace: workspace}
+	return nil
+}
+
+// GetProjectContext 获取当前工作区的项目，不存在时返回 storage.ErrNotFound
+func (s *Store) GetProjectContext(ctx context.Context, id int) (*model.Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, err := s.lookupProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := s.counted(p)
+	return &out, nil
+}
+
+// ListProjectsContext 获取当前工作区的项目，按名称（不区分大小写）排列
+func (s *Store) ListProjectsContext(ctx context.Context) ([]model.Project, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	projects := make([]model.Project, 0)
+	for _, p := range s.projects {
+		if p.workspace == workspace {
+			projects = append(projects, s.counted(p))
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		a, b := strings.ToLower(projects[i].Name), strings.ToLower(projects[j].Name)
+		if a != b {
+			return a < b
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects, nil
+}
+
+// UpdateProjectContext 修改项目名称和描述，项目不存在时返回 storage.ErrNotFound
+func (s *Store) UpdateProjectContext(ctx context.Context, p *model.Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.lookupProject(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	if s.nameTaken(existing.workspace, p.Name, p.ID) {
+		return storage.ErrProjectExists
+	}
+	p.UpdatedAt = s.clock.Now().UTC()
+	existing.Name, existing.Description, existing.UpdatedAt = p.Name, p.Description, p.UpdatedAt
+	return nil
+}
+
+// DeleteProjectContext 删除项目，项目中的待办事项保留，改为不属于任何项目
+func (s *Store) DeleteProjectContext(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.lookupProject(ctx, id)
+	if err != nil {
+		return err
+	}
+	delete(s.projects, id)
+	now := s.clock.Now().UTC()
+	for _, e := range s.projectTodos(p.workspace, id) {
+		e.todo.ProjectID = nil
+		s.touch(e, model.TodoEventUpdated, now)
+	}
+	return nil
+}
+
+// CheckProjectContext 确认项目存在于当前工作区，不存在时返回 storage.ErrNotFound
+func (s *Store) CheckProjectContext(ctx context.Context, id int) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, err := s.lookupProject(ctx, id)
+	return err
+}
+
+// EnsureProjectContext 按名称（不区分大小写）查找当前工作区的项目，不存在时创建，返回项目 ID
+func (s *Store) EnsureProjectContext(ctx context.Context, name string) (int, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.projects {
+		if p.workspace == workspace && strings.EqualFold(p.Name, name) {
+			return p.ID, nil
+		}
+	}
+	p := &model.Project{Name: name}
+	if err := s.createProject(workspace, p); err != nil {
+		return 0, err
+	}
+	return p.ID, nil
+}
+
+// SetProjectArchivedContext 归档或取消归档项目，其中的待办事项随之隐藏或重新出现在列表中（各产生一个 updated 事件）
+// 项目不存在时返回 storage.ErrNotFound；已经是目标状态时不做修改
+func (s *Store) SetProjectArchivedContext(ctx context.Context, id int, archived bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.lookupProject(ctx, id)
+	if err != nil {
+		return err
+	}
+	if (p.ArchivedAt != nil) == archived {
+		return nil
+	}
+	now := s.clock.Now().UTC()
+	p.ArchivedAt, p.UpdatedAt = nil, now
+	if archived {
+		p.ArchivedAt = &now
+	}
+	for _, e := range s.projectTodos(p.workspace, id) {
+		s.touch(e, model.TodoEventUpdated, now)
+	}
+	return nil
+}
+
+// CompleteProjectTodosContext 完成项目中所有未完成（工作流中的非终态）的待办事项，返回完成的 ID
+// 与批量完成一样状态改为 completed；项目不存在时返回 storage.ErrNotFound
+func (s *Store) CompleteProjectTodosContext(ctx context.Context, id int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.lookupProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now().UTC()
+	ids := make([]int, 0)
+	for _, e := range s.projectTodos(p.workspace, id) {
+		if !s.statuses.IsOpen(e.todo.Status) {
+			continue
+		}
+		e.todo.Status = "completed"
+		e.todo.CompletedAt = &now
+		s.touch(e, model.TodoEventCompleted, now)
+		ids = append(ids, e.todo.ID)
+	}
+	return ids, nil
+}
+
+// MoveProjectTodosContext 把项目中的所有待办事项移到目标项目，target 为 0 表示移出项目，返回移动的 ID
+// 来源项目不存在时返回 storage.ErrNotFound，目标项目不存在时返回 storage.ErrTargetProjectNotFound
+func (s *Store) MoveProjectTodosContext(ctx context.Context, id, target int) ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.lookupProject(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var targetID *int
+	if target != 0 {
+		if _, err := s.lookupProject(ctx, target); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				err = fmt.Errorf("project %d: %w", target, storage.ErrTargetProjectNotFound)
+			}
+			return nil, err
+		}
+		targetID = &target
+	}
+	now := s.clock.Now().UTC()
+	ids := make([]int, 0)
+	for _, e := range s.projectTodos(p.workspace, id) {
+		e.todo.ProjectID = copyPtr(targetID)
+		s.touch(e, model.TodoEventUpdated, now)
+		ids = append(ids, e.todo.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"math"
+	"sort"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// overdueBuckets 按逾期时长给未完成且已逾期的事项分组，与 SQLite 实现相同
+func (s *Store) overdueBuckets(todos []model.Todo, now time.Time) *storage.OverdueBuckets {
+	buckets := &storage.OverdueBuckets{}
+	for i := range todos {
+		t := &todos[i]
+		if !s.statuses.IsOpen(t.Status) || t.DueDate == nil || !t.DueDate.Before(now) {
+			continue
+		}
+		switch age := now.Sub(*t.DueDate).Hours() / 24; {
+		case age < 1:
+			buckets.LessThanDay++
+		case age <= 7:
+			buckets.OneToSeven++
+		default:
+			buckets.OverSeven++
+		}
+	}
+	return buckets
+}
+
+// completionLatency 已完成事项从创建到完成的耗时：整体 + 按优先级，中位数的算法见 latencyStats
+func (s *Store) completionLatency(todos []model.Todo) *storage.CompletionLatency {
+	var overall []float64
+	byPriority := map[int][]float64{}
+	for i := range todos {
+		t := &todos[i]
+		if s.statuses.IsOpen(t.Status) || t.CompletedAt == nil {
+			continue
+		}
+		hours := t.CompletedAt.Sub(t.CreatedAt).Hours()
+		overall = append(overall, hours)
+		byPriority[t.Priority] = append(byPriority[t.Priority], hours)
+	}
+
+	result := &storage.CompletionLatency{Overall: latencyStats(overall), ByPriority: make([]storage.PriorityLatency, 0, len(byPriority))}
+	for priority, hours := range byPriority {
+		result.ByPriority = append(result.ByPriority, storage.PriorityLatency{Priority: priority, LatencyStats: latencyStats(hours)})
+	}
+	sort.Slice(result.ByPriority, func(i, j int) bool { return result.ByPriority[i].Priority < result.ByPriority[j].Priority })
+	return result
+}
+
+// latencyMinute 耗时（小时）所在的整分钟分桶：先四舍五入到毫秒再整除，与 SQLite 的 latencyMinuteSQL 相同
+func latencyMinute(hours float64) int64 {
+	return int64(math.Round(hours*3600000)) / 60000
+}
+
+// latencyStats 一组耗时（小时）的数量、平均值和中位数
+// 与 SQLite 实现相同，中位数取中间一条所在整分钟分桶的平均耗时，偶数条时取中间两条的平均
+func latencyStats(hours []float64) storage.LatencyStats {
+	if len(hours) == 0 {
+		return storage.LatencyStats{}
+	}
+	sort.Float64s(hours)
+	var sum float64
+	for _, h := range hours {
+		sum += h
+	}
+	// at 返回第 i 条（从 0 开始）所在分桶的平均耗时
+	at := func(i int) float64 {
+		minute := latencyMinute(hours[i])
+		lo, hi := i, i+1
+		for lo > 0 && latencyMinute(hours[lo-1]) == minute {
+			lo--
+		}
+		for hi < len(hours) && latencyMinute(hours[hi]) == minute {
+			hi++
+		}
+		var total float64
+		for _, h := range hours[lo:hi] {
+			total += h
+		}
+		return total / float64(hi-lo)
+	}
+	median := (at((len(hours)-1)/2) + at(len(hours)/2)) / 2
+	return storage.LatencyStats{Count: len(hours), AvgHours: sum / float64(len(hours)), MedianHours: median}
+}
+
+// focusScore 当天（UTC）的专注度，定义见 storage.FocusScore
+func (s *Store) focusScore(todos []model.Todo, now time.Time) *storage.FocusScore {
+	today := now.UTC().Format("2006-01-02")
+	focus := &storage.FocusScore{Date: today}
+	for i := range todos {
+		t := &todos[i]
+		weight := t.Priority + 1
+		if weight < 1 {
+			weight = 1
+		}
+		if t.CompletedAt != nil && utcDate(*t.CompletedAt) == today {
+			focus.Completed++
+			focus.Points += weight
+			if t.DueDate != nil && t.DueDate.Before(*t.CompletedAt) {
+				focus.OverdueCleared++
+				focus.Points += weight
+			}
+		}
+		if s.statuses.IsOpen(t.Status) && t.DueDate != nil && utcDate(*t.DueDate) <= today {
+			focus.Remaining += weight
+		}
+	}
+	if total := focus.Points + focus.Remaining; total > 0 {
+		focus.Score = int(math.Round(float64(focus.Points) * 100 / float64(total)))
+	}
+	return focus
+}
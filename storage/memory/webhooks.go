@@ -0,0 +1,263 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// 推送记录每次最多返回的条数，与 SQLite 实现一致
+const maxWebhookDeliveriesLimit = 100
+
+// webhook 一个出站 webhook、它所属的工作区和已处理到的变更事件 ID
+type webhook struct {
+	model.Webhook
+	workspace string
+	cursor    int64
+}
+
+// delivery 一次推送尝试和它所属的工作区
+type delivery struct {
+	model.WebhookDelivery
+	workspace string
+}
+
+// copyWebhook 复制 webhook，避免调用方修改存储中的数据
+func copyWebhook(w *model.Webhook) model.Webhook {
+	out := *w
+	out.Events = slices.Clone(w.Events)
+	if out.Events == nil {
+		out.Events = []string{}
+	}
+	out.ProjectID = copyPtr(w.ProjectID)
+	out.MinPriority = copyPtr(w.MinPriority)
+	out.LastDeliveryAt = copyPtr(w.LastDeliveryAt)
+	return out
+}
+
+// copyDelivery 复制推送记录，避免调用方修改存储中的数据
+func copyDelivery(d *model.WebhookDelivery) model.WebhookDelivery {
+	out := *d
+	out.Payload = slices.Clone(d.Payload)
+	return out
+}
+
+// lookupWebhook 当前工作区中的 webhook，不存在时返回 storage.ErrNotFound，调用方需要持有锁
+func (s *Store) lookupWebhook(ctx context.Context, id int) (*webhook, error) {
+	w, ok := s.webhooks[id]
+	if !ok || w.workspace != storage.WorkspaceFromContext(ctx) {
+		return nil, fmt.Errorf("webhook %d: %w", id, storage.ErrNotFound)
+	}
+	return w, nil
+}
+
+// sortedWebhooks 按 ID 排列的所有 webhook，调用方需要持有锁
+func (s *Store) sortedWebhooks() []*webhook {
+	webhooks := make([]*webhook, 0, len(s.webhooks))
+	for _, w := range s.webhooks {
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+// CreateWebhookContext 在当前工作区创建 webhook，从现有的最新变更事件之后开始推送
+func (s *Store) CreateWebhookContext(ctx context.Context, w *model.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now().UTC()
+	w.CreatedAt, w.UpdatedAt = now, now
+	w.ID = int(s.next("webhooks"))
+	s.webhooks[w.ID] = &webhook{
+		Webhook:   copyWebhook(w),
+		workspace: storage.WorkspaceFromContext(ctx),
+		cursor:    s.seq["todo_events"],
+	}
+	return nil
+}
+
+// GetWebhookContext 查询当前工作区的 webhook，不存在时返回 storage.ErrNotFound
+func (s *Store) GetWebhookContext(ctx context.Context, id int) (*model.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, err := s.lookupWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := copyWebhook(&w.Webhook)
+	return &out, nil
+}
+
+// ListWebhooksContext 当前工作区的所有 webhook
+func (s *Store) ListWebhooksContext(ctx context.Context) ([]model.Webhook, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := make([]model.Webhook, 0)
+	for _, w := range s.sortedWebhooks() {
+		if w.workspace == workspace {
+			webhooks = append(webhooks, copyWebhook(&w.Webhook))
+		}
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhookContext 更新当前工作区 webhook 的 URL、过滤条件和启用状态
+// 从停用恢复启用时推送进度跳到最新事件，停用期间的变更不补发
+func (s *Store) UpdateWebhookContext(ctx context.Context, w *model.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.lookupWebhook(ctx, w.ID)
+	if err != nil {
+		return err
+	}
+	w.UpdatedAt = s.clock.Now().UTC()
+	if !existing.Active && w.Active {
+		existing.cursor = s.seq["todo_events"]
+	}
+	updated := copyWebhook(w)
+	existing.URL, existing.EventFilter, existing.Active, existing.UpdatedAt =
+		updated.URL, updated.EventFilter, updated.Active, updated.UpdatedAt
+	return nil
+}
+
+// DeleteWebhookContext 删除当前工作区的 webhook，它的推送记录随之删除
+func (s *Store) DeleteWebhookContext(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.lookupWebhook(ctx, id); err != nil {
+		return err
+	}
+	delete(s.webhooks, id)
+	s.deliveries = slices.DeleteFunc(s.deliveries, func(d delivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+// CountWebhooksContext 当前工作区的 webhook 数量
+func (s *Store) CountWebhooksContext(ctx context.Context) (int, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := 0
+	for _, w := range s.webhooks {
+		if w.workspace == workspace {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListWebhookTargetsContext 所有工作区中启用的 webhook 及其推送进度
+func (s *Store) ListWebhookTargetsContext(ctx context.Context) ([]storage.WebhookTarget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var targets []storage.WebhookTarget
+	for _, w := range s.sortedWebhooks() {
+		if w.Active {
+			targets = append(targets, storage.WebhookTarget{Webhook: copyWebhook(&w.Webhook), Workspace: w.workspace, Cursor: w.cursor})
+		}
+	}
+	return targets, nil
+}
+
+// AdvanceWebhookContext 记录 webhook 已处理到的变更事件 ID（不会回退）
+func (s *Store) AdvanceWebhookContext(ctx context.Context, id int, eventID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.webhooks[id]; ok && eventID > w.cursor {
+		w.cursor = eventID
+	}
+	return nil
+}
+
+// RecordWebhookDeliveryContext 保存一次推送尝试，同时更新 webhook 最近一次推送的结果
+// 写入后 d 的 ID、Attempt（同一推送 ID 的第几次）和 CreatedAt 被填充；webhook 已删除时不保存
+func (s *Store) RecordWebhookDeliveryContext(ctx context.Context, d *model.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.webhooks[d.WebhookID]
+	if !ok {
+		return fmt.Errorf("记录 webhook 推送失败：webhook %d: %w", d.WebhookID, storage.ErrNotFound)
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = s.clock.Now()
+	}
+	d.CreatedAt = d.CreatedAt.UTC()
+
+	d.Attempt = 1
+	for _, prev := range s.deliveries {
+		if prev.WebhookID == d.WebhookID && prev.Delivery == d.Delivery {
+			d.Attempt++
+		}
+	}
+	d.ID = int(s.next("webhook_deliveries"))
+	s.deliveries = append(s.deliveries, delivery{WebhookDelivery: copyDelivery(d), workspace: storage.WorkspaceFromContext(ctx)})
+
+	at := d.CreatedAt
+	w.LastStatus, w.LastError, w.LastDeliveryAt = d.Status, d.Error, &at
+	return nil
+}
+
+// ListWebhookDeliveriesContext 当前工作区中 webhook 的推送记录，最新的在前
+// failedOnly 为 true 时只返回失败的尝试；limit 超出范围时使用上限
+func (s *Store) ListWebhookDeliveriesContext(ctx context.Context, webhookID int, failedOnly bool, limit int) ([]model.WebhookDelivery, error) {
+	if limit <= 0 || limit > maxWebhookDeliveriesLimit {
+		limit = maxWebhookDeliveriesLimit
+	}
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for i := len(s.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		d := &s.deliveries[i]
+		if d.WebhookID == webhookID && d.workspace == workspace && (!failedOnly || !d.Success) {
+			deliveries = append(deliveries, copyDelivery(&d.WebhookDelivery))
+		}
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDeliveryContext 查询当前工作区中 webhook 的一条推送记录，不存在时返回 storage.ErrNotFound
+// succeeded 表示同一推送 ID 是否已经有成功的尝试
+func (s *Store) GetWebhookDeliveryContext(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, bool, error) {
+	workspace := storage.WorkspaceFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *model.WebhookDelivery
+	for i := range s.deliveries {
+		d := &s.deliveries[i]
+		if d.ID == id && d.WebhookID == webhookID && d.workspace == workspace {
+			out := copyDelivery(&d.WebhookDelivery)
+			found = &out
+			break
+		}
+	}
+	if found == nil {
+		return nil, false, fmt.Errorf("webhook delivery %d: %w", id, storage.ErrNotFound)
+	}
+	succeeded := false
+	for _, d := range s.deliveries {
+		if d.WebhookID == webhookID && d.Delivery == found.Delivery && d.Success {
+			succeeded = true
+			break
+		}
+	}
+	return found, succeeded, nil
+}
+
+// PurgeWebhookDeliveriesContext 删除 before 之前的 webhook 推送记录
+func (s *Store) PurgeWebhookDeliveriesContext(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.deliveries)
+	s.deliveries = slices.DeleteFunc(s.deliveries, func(d delivery) bool { return d.CreatedAt.Before(before) })
+	return n - len(s.deliveries), nil
+}
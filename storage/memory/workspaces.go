@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// CreateWorkspaceContext 创建工作区，标识已被占用时返回 storage.ErrWorkspaceExists
+func (s *Store) CreateWorkspaceContext(ctx context.Context, ws *model.Workspace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.workspaces[ws.Slug]; ok {
+		return storage.ErrWorkspaceExists
+	}
+	ws.CreatedAt = s.clock.Now().UTC()
+	s.workspaces[ws.Slug] = *ws
+	return nil
+}
+
+// GetWorkspaceContext 获取工作区，不存在时返回 nil
+func (s *Store) GetWorkspaceContext(ctx context.Context, slug string) (*model.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ws, ok := s.workspaces[slug]
+	if !ok {
+		return nil, nil
+	}
+	return &ws, nil
+}
+
+// ListWorkspacesContext 获取所有工作区，按创建时间排列
+func (s *Store) ListWorkspacesContext(ctx context.Context) ([]model.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	workspaces := make([]model.Workspace, 0, len(s.workspaces))
+	for _, ws := range s.workspaces {
+		workspaces = append(workspaces, ws)
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		if !workspaces[i].CreatedAt.Equal(workspaces[j].CreatedAt) {
+			return workspaces[i].CreatedAt.Before(workspaces[j].CreatedAt)
+		}
+		return workspaces[i].Slug < workspaces[j].Slug
+	})
+	return workspaces, nil
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo-list/model"
+)
+
+// TodoRepository 待办事项的存取（database.DB、database/postgres 和 storage/memory 实现了该接口）
+// 处理器通过它读写待办事项本身：增删改查、列表和视图（收件箱、停滞等都是 TodoFilter 的条件）、统计、批量操作、导入导出；
+// 所有方法都作用于 Context 中的工作区（见 WithWorkspace），找不到时返回包装了 ErrNotFound 的错误，
+// 版本号不一致时返回包装了 ErrVersionConflict 的错误；参数和返回值的类型见 todo.go
+//
+// 项目、评论、通知、变更事件、webhook 等数据见 Repositories；链接、附件、编号、目标、习惯等数据和直接查询 todos 表的功能
+// （周回顾、工作量、按条件批量操作、后台任务）只在 SQLite 中实现，待办事项使用其他后端时不可用，见 handler.RequireSQLiteTodos
+type TodoRepository interface {
+	CreateTodoContext(ctx context.Context, todo *model.Todo) error
+	GetTodoByIDContext(ctx context.Context, id int) (*model.Todo, error)
+	GetTodoIDByPublicIDContext(ctx context.Context, publicID string) (int, error)
+	ListTodosContext(ctx context.Context, filter TodoFilter) ([]model.Todo, int, error)
+	UpdateTodoContext(ctx context.Context, todo *model.Todo) error
+	DeleteTodoContext(ctx context.Context, id int) error
+
+	GetFilteredStatsContext(ctx context.Context, filter TodoFilter) (*TodoStats, error)
+	CountTodosContext(ctx context.Context) (int, error)
+	CountTodosCreatedSinceContext(ctx context.Context, since time.Time) (int, error)
+
+	BatchCompleteTodosContext(ctx context.Context, ids []int) error
+	BatchDeleteTodosContext(ctx context.Context, ids []int) error
+	BatchCompleteTodosPartialContext(ctx context.Context, ids []int) (*BatchResult, error)
+	BatchDeleteTodosPartialContext(ctx context.Context, ids []int) (*BatchResult, error)
+
+	ImportTodosContext(ctx context.Context, todos []model.Todo) (int, error)
+	ExportTodosContext(ctx context.Context) ([]model.Todo, error)
+}
+
+// ErrProjectExists 同一工作区中已有同名项目（不区分大小写）
+var ErrProjectExists = errors.New("project already exists")
+
+// ErrTargetProjectNotFound 移动待办事项的目标项目不存在
+var ErrTargetProjectNotFound = errors.New("target project not found")
+
+// ErrWorkspaceExists 工作区标识已被占用
+var ErrWorkspaceExists = errors.New("workspace already exists")
+
+// WebhookTarget 需要推送事件的 webhook，带所属工作区和推送进度，供后台分发使用
+type WebhookTarget struct {
+	model.Webhook
+	Workspace string
+	Cursor    int64 // 已处理到的变更事件 ID
+}
+
+// Repositories 待办事项以外、不依赖 SQLite 的数据（database.DB、database/postgres 和 storage/memory 实现了该接口）
+// 项目、评论、站内通知、变更事件和归档引用待办事项，必须与待办事项保存在同一个实现中，见 handler.NewHandler
+type Repositories interface {
+	ProjectRepository
+	CommentRepository
+	NotificationRepository
+	EventRepository
+	WebhookRepository
+	APIKeyRepository
+	WorkspaceRepository
+	AutomationRepository
+	ArchiveRepository
+
+	// PingContext 检查存储是否可用，供健康检查使用
+	PingContext(ctx context.Context) error
+}
+
+// ProjectRepository 当前工作区的项目；TodoCount、OpenCount 按工作流的未完成状态统计
+// 项目归档后其中的待办事项默认不出现在不限项目的列表中（TodoFilter.IncludeArchived）
+type ProjectRepository interface {
+	CreateProjectContext(ctx context.Context, project *model.Project) error
+	GetProjectContext(ctx context.Context, id int) (*model.Project, error)
+	ListProjectsContext(ctx context.Context) ([]model.Project, error)
+	UpdateProjectContext(ctx context.Context, project *model.Project) error
+	// DeleteProjectContext 删除项目，其中的待办事项保留，改为不属于任何项目
+	DeleteProjectContext(ctx context.Context, id int) error
+	CheckProjectContext(ctx context.Context, id int) error
+	// EnsureProjectContext 按名称（不区分大小写）查找项目，不存在时创建
+	EnsureProjectContext(ctx context.Context, name string) (int, error)
+	SetProjectArchivedContext(ctx context.Context, id int, archived bool) error
+	// CompleteProjectTodosContext 完成项目中所有未完成的待办事项，返回完成的 ID
+	CompleteProjectTodosContext(ctx context.Context, id int) ([]int, error)
+	// MoveProjectTodosContext 把项目中的待办事项移到 target（0 表示移出项目），目标不存在时返回 ErrTargetProjectNotFound
+	MoveProjectTodosContext(ctx context.Context, id, target int) ([]int, error)
+}
+
+// CommentRepository 待办事项的评论，删除待办事项时一并删除
+type CommentRepository interface {
+	// CreateCommentContext 保存评论，并与提及产生的通知一起写入
+	CreateCommentContext(ctx context.Context, comment *model.Comment, notifications []model.Notification) error
+	ListCommentsContext(ctx context.Context, todoID int) ([]model.Comment, error)
+}
+
+// NotificationRepository 站内通知和通知偏好，按用户区分，不限工作区
+type NotificationRepository interface {
+	CreateNotificationContext(ctx context.Context, n *model.Notification) error
+	CountUnreadNotificationsContext(ctx context.Context, userID string) (int, error)
+	ListNotificationsContext(ctx context.Context, userID string, unreadOnly bool, limit int) ([]model.Notification, error)
+	MarkAllNotificationsReadContext(ctx context.Context, userID string) (int, error)
+	MarkNotificationReadContext(ctx context.Context, userID string, id int) (bool, error)
+
+	GetNotificationPreferencesContext(ctx context.Context, userID string) (*model.NotificationPreferences, error)
+	SaveNotificationPreferencesContext(ctx context.Context, prefs *model.NotificationPreferences) error
+}
+
+// EventRepository 待办事项的变更事件，由写入待办事项的操作记录，所有工作区共用一个递增的 ID 序列
+type EventRepository interface {
+	// ListTodoEventsContext 当前工作区 ID 大于 after 的事件，按 ID 升序，最多 limit 条
+	ListTodoEventsContext(ctx context.Context, after int64, limit int) ([]model.TodoEvent, error)
+	// TodoEventRangeContext 仍然保留的最早事件 ID 和已分配的最新事件 ID，没有保留的事件时 first 为 last + 1
+	TodoEventRangeContext(ctx context.Context) (first, last int64, err error)
+}
+
+// WebhookRepository 出站 webhook 和推送记录
+// 新建（以及从停用恢复启用）的 webhook 从当时最新的变更事件之后开始推送
+type WebhookRepository interface {
+	CreateWebhookContext(ctx context.Context, w *model.Webhook) error
+	GetWebhookContext(ctx context.Context, id int) (*model.Webhook, error)
+	ListWebhooksContext(ctx context.Context) ([]model.Webhook, error)
+	UpdateWebhookContext(ctx context.Context, w *model.Webhook) error
+	DeleteWebhookContext(ctx context.Context, id int) error
+	CountWebhooksContext(ctx context.Context) (int, error)
+
+	// ListWebhookTargetsContext 所有工作区中启用的 webhook 及其推送进度
+	ListWebhookTargetsContext(ctx context.Context) ([]WebhookTarget, error)
+	AdvanceWebhookContext(ctx context.Context, id int, eventID int64) error
+
+	RecordWebhookDeliveryContext(ctx context.Context, d *model.WebhookDelivery) error
+	ListWebhookDeliveriesContext(ctx context.Context, webhookID int, failedOnly bool, limit int) ([]model.WebhookDelivery, error)
+	GetWebhookDeliveryContext(ctx context.Context, webhookID, id int) (*model.WebhookDelivery, bool, error)
+}
+
+// APIKeyRepository API key，只保存 key 的哈希
+type APIKeyRepository interface {
+	CreateAPIKeyContext(ctx context.Context, key *model.APIKey, hash string) error
+	ListAPIKeysContext(ctx context.Context, userID string) ([]model.APIKey, error)
+	HasAPIKeysContext(ctx context.Context) (bool, error)
+	RevokeAPIKeyContext(ctx context.Context, userID string, id int) (*model.APIKey, error)
+	// AuthenticateAPIKeyContext 按哈希查找可以使用的 key，不存在、已撤销或已过期时都返回 ErrNotFound
+	AuthenticateAPIKeyContext(ctx context.Context, hash string) (*model.APIKey, error)
+}
+
+// WorkspaceRepository 工作区，默认工作区总是存在
+type WorkspaceRepository interface {
+	CreateWorkspaceContext(ctx context.Context, ws *model.Workspace) error
+	// GetWorkspaceContext 工作区不存在时返回 nil
+	GetWorkspaceContext(ctx context.Context, slug string) (*model.Workspace, error)
+	ListWorkspacesContext(ctx context.Context) ([]model.Workspace, error)
+}
+
+// AutomationRepository 当前工作区的自动化规则
+type AutomationRepository interface {
+	CreateAutomationRuleContext(ctx context.Context, rule *model.AutomationRule) error
+	GetAutomationRuleContext(ctx context.Context, id int) (*model.AutomationRule, error)
+	// ListAutomationRulesContext event 不为空时只返回该事件下已启用的规则
+	ListAutomationRulesContext(ctx context.Context, event string) ([]model.AutomationRule, error)
+	UpdateAutomationRuleContext(ctx context.Context, rule *model.AutomationRule) error
+	DeleteAutomationRuleContext(ctx context.Context, id int) error
+}
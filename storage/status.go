@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+)
+
+// StatusSets 工作流中的未完成状态和终态，存储按它们判断待办事项是否完成（未完成列表、逾期、统计等）
+// 部署可以通过 WORKFLOW_FILE 增加 in_progress、review 等状态，不能只看 pending / completed
+type StatusSets struct {
+	Open []string // 非终态，例如 pending、in_progress
+	Done []string // 终态，例如 completed
+}
+
+// StatusSetter 可以按工作流设置状态划分的存储（database.DB、postgres.Store、memory.Store）
+type StatusSetter interface {
+	SetStatuses(StatusSets)
+}
+
+// NewStatusSets 按工作流的状态划分，isTerminal 一般为 workflow.Workflow.IsTerminal
+func NewStatusSets(names []string, isTerminal func(string) bool) StatusSets {
+	var s StatusSets
+	for _, name := range names {
+		if isTerminal(name) {
+			s.Done = append(s.Done, name)
+		} else {
+			s.Open = append(s.Open, name)
+		}
+	}
+	return s
+}
+
+// DefaultStatusSets 默认工作流：pending 未完成，completed 是终态
+func DefaultStatusSets() StatusSets {
+	return StatusSets{Open: []string{"pending"}, Done: []string{"completed"}}
+}
+
+// IsOpen 状态是否未完成
+func (s StatusSets) IsOpen(status string) bool {
+	return slices.Contains(s.Open, status)
+}
+
+// IsDone 状态是否为终态
+func (s StatusSets) IsDone(status string) bool {
+	return slices.Contains(s.Done, status)
+}
+
+// OpenSQL 未完成的 SQL 条件，例如 status IN ('pending','in_progress')
+func (s StatusSets) OpenSQL() string {
+	return statusInSQL(s.Open)
+}
+
+// DoneSQL 已完成（终态）的 SQL 条件
+func (s StatusSets) DoneSQL() string {
+	return statusInSQL(s.Done)
+}
+
+// CountOpen 按状态计数中未完成的总数
+func (s StatusSets) CountOpen(byStatus map[string]int) int {
+	return sumStatuses(byStatus, s.Open)
+}
+
+// CountDone 按状态计数中已完成的总数
+func (s StatusSets) CountDone(byStatus map[string]int) int {
+	return sumStatuses(byStatus, s.Done)
+}
+
+// statusInSQL 状态名由工作流校验（小写字母、数字和下划线），可以直接写成字面量，
+// 不必在每个 SUM(CASE ...) 中传入参数；集合为空时条件恒为假
+func statusInSQL(names []string) string {
+	if len(names) == 0 {
+		return "1 = 0"
+	}
+	return "status IN ('" + strings.Join(names, "', '") + "')"
+}
+
+func sumStatuses(byStatus map[string]int, names []string) int {
+	n := 0
+	for _, name := range names {
+		n += byStatus[name]
+	}
+	return n
+}
@@ -0,0 +1,627 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// Store 同时实现待办事项和其他数据的存储，项目级操作、评论和变更事件需要两者在同一个实现中
+type Store interface {
+	storage.TodoRepository
+	storage.Repositories
+}
+
+// RunRepositories 对一个实现运行 storage.Repositories 的一致性测试，newStore 在每个子测试开始时调用，需要返回一个空的存储
+func RunRepositories(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s Store)
+	}{
+		{"Projects", testProjects},
+		{"ProjectArchive", testProjectArchive},
+		{"ProjectTodos", testProjectTodos},
+		{"Events", testEvents},
+		{"Comments", testComments},
+		{"Notifications", testNotifications},
+		{"Webhooks", testWebhooks},
+		{"APIKeys", testAPIKeys},
+		{"Workspaces", testWorkspaces},
+		{"AutomationRules", testAutomationRules},
+		{"Archive", testArchive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// createProject 在 ctx 的工作区创建项目并返回 ID
+func createProject(t *testing.T, s Store, ctx context.Context, name string) int {
+	t.Helper()
+	p := &model.Project{Name: name}
+	if err := s.CreateProjectContext(ctx, p); err != nil {
+		t.Fatalf("create project %s: %v", name, err)
+	}
+	return p.ID
+}
+
+// createTodo 在 ctx 的工作区创建待办事项，project 为 0 时不属于任何项目
+func createTodo(t *testing.T, s Store, ctx context.Context, title string, project int) *model.Todo {
+	t.Helper()
+	todo := model.NewTodo(title, "", time.Now())
+	if project != 0 {
+		todo.ProjectID = &project
+	}
+	if err := s.CreateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+func testProjects(t *testing.T, s Store) {
+	ctx := context.Background()
+	work := createProject(t, s, ctx, "work")
+	createProject(t, s, ctx, "Errands")
+
+	if err := s.CreateProjectContext(ctx, &model.Project{Name: "WORK"}); !errors.Is(err, storage.ErrProjectExists) {
+		t.Errorf("duplicate name: err = %v, want ErrProjectExists", err)
+	}
+	// 项目名称只在工作区内唯一
+	home := storage.WithWorkspace(ctx, "home")
+	if err := s.CreateProjectContext(home, &model.Project{Name: "work"}); err != nil {
+		t.Errorf("same name in another workspace: %v", err)
+	}
+
+	createTodo(t, s, ctx, "report", work)
+	done := createTodo(t, s, ctx, "slides", work)
+	done.Status = "completed"
+	if err := s.UpdateTodoContext(ctx, done); err != nil {
+		t.Fatal(err)
+	}
+
+	projects, err := s.ListProjectsContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	if fmt.Sprint(names) != "[Errands work]" {
+		t.Errorf("projects = %v, want [Errands work] ordered by name", names)
+	}
+	p, err := s.GetProjectContext(ctx, work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TodoCount != 2 || p.OpenCount != 1 {
+		t.Errorf("counts = %d / %d open, want 2 / 1", p.TodoCount, p.OpenCount)
+	}
+
+	p.Name, p.Description = "Work", "office"
+	if err := s.UpdateProjectContext(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateProjectContext(ctx, &model.Project{ID: work, Name: "errands"}); !errors.Is(err, storage.ErrProjectExists) {
+		t.Errorf("rename to existing name: err = %v, want ErrProjectExists", err)
+	}
+
+	if id, err := s.EnsureProjectContext(ctx, "WORK"); err != nil || id != work {
+		t.Errorf("EnsureProjectContext(WORK) = %d, %v; want %d", id, err, work)
+	}
+	if id, err := s.EnsureProjectContext(ctx, "garden"); err != nil || id == 0 || id == work {
+		t.Errorf("EnsureProjectContext(garden) = %d, %v; want a new project", id, err)
+	}
+
+	if _, err := s.GetProjectContext(home, work); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if err := s.CheckProjectContext(home, work); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("check from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteProjectContext(home, work); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("delete from another workspace: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testProjectArchive(t *testing.T, s Store) {
+	ctx := context.Background()
+	work := createProject(t, s, ctx, "work")
+	createTodo(t, s, ctx, "report", work)
+	createTodo(t, s, ctx, "loose", 0)
+
+	list := func(f storage.TodoFilter) string {
+		t.Helper()
+		f.Sort, f.Order = "id", "asc"
+		todos, total, err := s.ListTodosContext(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprintf("%v %d", titles(todos), total)
+	}
+
+	if err := s.SetProjectArchivedContext(ctx, work, true); err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.GetProjectContext(ctx, work)
+	if err != nil || p.ArchivedAt == nil {
+		t.Fatalf("archived project = %+v, %v; want archived_at", p, err)
+	}
+
+	// 归档项目中的事项不出现在不限项目的列表和统计中，按项目查看或 IncludeArchived 时仍然可见
+	if got := list(storage.TodoFilter{}); got != "[loose] 1" {
+		t.Errorf("list after archive = %s, want [loose] 1", got)
+	}
+	if got := list(storage.TodoFilter{ProjectID: &work}); got != "[report] 1" {
+		t.Errorf("list archived project = %s, want [report] 1", got)
+	}
+	if got := list(storage.TodoFilter{IncludeArchived: true}); got != "[report loose] 2" {
+		t.Errorf("list include archived = %s, want both", got)
+	}
+	if stats, err := s.GetFilteredStatsContext(ctx, storage.TodoFilter{}); err != nil || stats.Total != 1 {
+		t.Errorf("stats after archive = %+v, %v; want total 1", stats, err)
+	}
+
+	if err := s.SetProjectArchivedContext(ctx, work, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := list(storage.TodoFilter{}); got != "[report loose] 2" {
+		t.Errorf("list after unarchive = %s, want both", got)
+	}
+	if err := s.SetProjectArchivedContext(ctx, work+100, true); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("archive unknown project: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testProjectTodos(t *testing.T, s Store) {
+	ctx := context.Background()
+	work, home := createProject(t, s, ctx, "work"), createProject(t, s, ctx, "home")
+	report := createTodo(t, s, ctx, "report", work)
+	slides := createTodo(t, s, ctx, "slides", work)
+	slides.Status = "completed"
+	if err := s.UpdateTodoContext(ctx, slides); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := s.CompleteProjectTodosContext(ctx, work)
+	if err != nil || fmt.Sprint(ids) != fmt.Sprint([]int{report.ID}) {
+		t.Fatalf("CompleteProjectTodosContext = %v, %v; want only the open todo", ids, err)
+	}
+	got, err := s.GetTodoByIDContext(ctx, report.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != "completed" || got.CompletedAt == nil || got.Version != report.Version+1 {
+		t.Errorf("completed todo = %s, completed_at %v, v%d; want completed with a new version", got.Status, got.CompletedAt, got.Version)
+	}
+
+	if _, err := s.MoveProjectTodosContext(ctx, work, home+100); !errors.Is(err, storage.ErrTargetProjectNotFound) {
+		t.Errorf("move to unknown project: err = %v, want ErrTargetProjectNotFound", err)
+	}
+	if _, err := s.MoveProjectTodosContext(ctx, work+100, home); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("move from unknown project: err = %v, want ErrNotFound", err)
+	}
+	ids, err = s.MoveProjectTodosContext(ctx, work, home)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("MoveProjectTodosContext = %v, %v; want 2 moved", ids, err)
+	}
+	if p, err := s.GetProjectContext(ctx, home); err != nil || p.TodoCount != 2 {
+		t.Errorf("target project = %+v, %v; want 2 todos", p, err)
+	}
+
+	// 删除项目后其中的事项保留，改为不属于任何项目
+	if err := s.DeleteProjectContext(ctx, home); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.GetTodoByIDContext(ctx, report.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ProjectID != nil {
+		t.Errorf("ProjectID after project delete = %v, want nil", *got.ProjectID)
+	}
+	if _, err := s.GetProjectContext(ctx, home); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get deleted project: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testEvents(t *testing.T, s Store) {
+	ctx := context.Background()
+	first, last, err := s.TodoEventRangeContext(ctx)
+	if err != nil || first != last+1 {
+		t.Fatalf("empty range = %d..%d, %v; want first = last+1", first, last, err)
+	}
+
+	project := createProject(t, s, ctx, "work")
+	todo := createTodo(t, s, ctx, "report", project)
+	todo.Title = "final report"
+	if err := s.UpdateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	todo.Status = "completed"
+	now := time.Now()
+	todo.CompletedAt = &now
+	if err := s.UpdateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTodoContext(ctx, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	createTodo(t, s, storage.WithWorkspace(ctx, "home"), "private", 0)
+
+	events, err := s.ListTodoEventsContext(ctx, last, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, fmt.Sprintf("%s v%d", e.Type, e.Version))
+		if e.TodoID != todo.ID || e.PublicID != todo.PublicID || e.ProjectID == nil || *e.ProjectID != project {
+			t.Errorf("event %+v, want todo %d in project %d", e, todo.ID, project)
+		}
+	}
+	if want := "[created v1 updated v2 completed v3 deleted v3]"; fmt.Sprint(got) != want {
+		t.Errorf("events = %v, want %s", got, want)
+	}
+
+	// 所有工作区共用一个 ID 序列，每个工作区只能看到自己的事件
+	_, newLast, err := s.TodoEventRangeContext(ctx)
+	if err != nil || newLast != last+5 {
+		t.Errorf("last after 5 events = %d, %v; want %d", newLast, err, last+5)
+	}
+	if home, err := s.ListTodoEventsContext(storage.WithWorkspace(ctx, "home"), last, 100); err != nil || len(home) != 1 {
+		t.Errorf("events in home = %d, %v; want 1", len(home), err)
+	}
+	if page, err := s.ListTodoEventsContext(ctx, events[1].ID, 1); err != nil || len(page) != 1 || page[0].ID != events[2].ID {
+		t.Errorf("events after %d limit 1 = %+v, %v; want the third event", events[1].ID, page, err)
+	}
+}
+
+func testComments(t *testing.T, s Store) {
+	ctx := context.Background()
+	todo := createTodo(t, s, ctx, "report", 0)
+
+	comment := model.NewComment(todo.ID, "alice", "ping @bob", time.Now())
+	notifications := []model.Notification{{UserID: "bob", Kind: "mention", TodoID: &todo.ID, Title: "mentioned", CreatedAt: time.Now()}}
+	if err := s.CreateCommentContext(ctx, comment, notifications); err != nil {
+		t.Fatal(err)
+	}
+	if comment.ID == 0 || notifications[0].ID == 0 {
+		t.Errorf("comment ID = %d, notification ID = %d; want both assigned", comment.ID, notifications[0].ID)
+	}
+
+	comments, err := s.ListCommentsContext(ctx, todo.ID)
+	if err != nil || len(comments) != 1 || comments[0].Body != "ping @bob" || fmt.Sprint(comments[0].Mentions) != "[bob]" {
+		t.Fatalf("comments = %+v, %v; want the saved comment", comments, err)
+	}
+	if other, err := s.ListCommentsContext(storage.WithWorkspace(ctx, "home"), todo.ID); err != nil || len(other) != 0 {
+		t.Errorf("comments from another workspace = %d, %v; want none", len(other), err)
+	}
+
+	// 删除待办事项时评论和引用它的通知一并删除
+	if err := s.DeleteTodoContext(ctx, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+	if comments, err := s.ListCommentsContext(ctx, todo.ID); err != nil || len(comments) != 0 {
+		t.Errorf("comments after delete = %d, %v; want none", len(comments), err)
+	}
+	if n, err := s.CountUnreadNotificationsContext(ctx, "bob"); err != nil || n != 0 {
+		t.Errorf("notifications after delete = %d, %v; want 0", n, err)
+	}
+}
+
+func testNotifications(t *testing.T, s Store) {
+	ctx := context.Background()
+	var ids []int
+	for _, title := range []string{"first", "second", "third"} {
+		n := &model.Notification{UserID: "alice", Kind: "due", Title: title, CreatedAt: time.Now()}
+		if err := s.CreateNotificationContext(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.ID)
+	}
+	if err := s.CreateNotificationContext(ctx, &model.Notification{UserID: "bob", Kind: "due", Title: "other", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := s.MarkNotificationReadContext(ctx, "alice", ids[0]); err != nil || !ok {
+		t.Errorf("mark read = %v, %v; want true", ok, err)
+	}
+	if ok, err := s.MarkNotificationReadContext(ctx, "bob", ids[1]); err != nil || ok {
+		t.Errorf("mark another user's notification = %v, %v; want false", ok, err)
+	}
+	if n, err := s.CountUnreadNotificationsContext(ctx, "alice"); err != nil || n != 2 {
+		t.Errorf("unread = %d, %v; want 2", n, err)
+	}
+
+	list, err := s.ListNotificationsContext(ctx, "alice", false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, n := range list {
+		titles = append(titles, n.Title)
+	}
+	if fmt.Sprint(titles) != "[third second first]" || list[2].ReadAt == nil {
+		t.Errorf("notifications = %v, want newest first with first read", titles)
+	}
+	if unread, err := s.ListNotificationsContext(ctx, "alice", true, 1); err != nil || len(unread) != 1 || unread[0].Title != "third" {
+		t.Errorf("unread limit 1 = %+v, %v; want third", unread, err)
+	}
+	if n, err := s.MarkAllNotificationsReadContext(ctx, "alice"); err != nil || n != 2 {
+		t.Errorf("mark all read = %d, %v; want 2", n, err)
+	}
+
+	prefs, err := s.GetNotificationPreferencesContext(ctx, "alice")
+	if err != nil || prefs.UserID != "alice" || !prefs.Channels[model.ChannelInApp] {
+		t.Fatalf("default preferences = %+v, %v; want in-app enabled", prefs, err)
+	}
+	prefs.Timezone = "Asia/Shanghai"
+	prefs.MutedProjects = nil
+	if err := s.SaveNotificationPreferencesContext(ctx, prefs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetNotificationPreferencesContext(ctx, "alice")
+	if err != nil || got.Timezone != "Asia/Shanghai" || got.MutedProjects == nil || got.UpdatedAt.IsZero() {
+		t.Errorf("saved preferences = %+v, %v; want timezone, empty muted projects and updated_at", got, err)
+	}
+}
+
+func testWebhooks(t *testing.T, s Store) {
+	ctx := context.Background()
+	createTodo(t, s, ctx, "before", 0)
+	_, last, err := s.TodoEventRangeContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &model.Webhook{URL: "https://example.com/hook", Secret: "s", Active: true, EventFilter: model.EventFilter{Events: []string{"todo.created"}}}
+	if err := s.CreateWebhookContext(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.CountWebhooksContext(ctx); err != nil || n != 1 {
+		t.Errorf("CountWebhooksContext = %d, %v; want 1", n, err)
+	}
+	if _, err := s.GetWebhookContext(storage.WithWorkspace(ctx, "home"), w.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get from another workspace: err = %v, want ErrNotFound", err)
+	}
+
+	// 新建的 webhook 从当时最新的事件之后开始推送，进度不会回退
+	targets, err := s.ListWebhookTargetsContext(ctx)
+	if err != nil || len(targets) != 1 || targets[0].Cursor != last || targets[0].Workspace != model.DefaultWorkspace {
+		t.Fatalf("targets = %+v, %v; want cursor %d in default workspace", targets, err, last)
+	}
+	if err := s.AdvanceWebhookContext(ctx, w.ID, last+3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AdvanceWebhookContext(ctx, w.ID, last+1); err != nil {
+		t.Fatal(err)
+	}
+	if targets, _ := s.ListWebhookTargetsContext(ctx); targets[0].Cursor != last+3 {
+		t.Errorf("cursor = %d, want %d", targets[0].Cursor, last+3)
+	}
+
+	w.Active = false
+	if err := s.UpdateWebhookContext(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if targets, _ := s.ListWebhookTargetsContext(ctx); len(targets) != 0 {
+		t.Errorf("targets after deactivate = %d, want none", len(targets))
+	}
+
+	for _, success := range []bool{false, true} {
+		d := &model.WebhookDelivery{WebhookID: w.ID, Delivery: "d-1", Event: "todo.created", Success: success, Status: 500}
+		if err := s.RecordWebhookDeliveryContext(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries, err := s.ListWebhookDeliveriesContext(ctx, w.ID, false, 0)
+	if err != nil || len(deliveries) != 2 || deliveries[0].Attempt != 2 || deliveries[1].Attempt != 1 {
+		t.Fatalf("deliveries = %+v, %v; want attempts 2, 1", deliveries, err)
+	}
+	if failed, err := s.ListWebhookDeliveriesContext(ctx, w.ID, true, 0); err != nil || len(failed) != 1 || failed[0].Success {
+		t.Errorf("failed deliveries = %+v, %v; want the first attempt", failed, err)
+	}
+	d, succeeded, err := s.GetWebhookDeliveryContext(ctx, w.ID, deliveries[1].ID)
+	if err != nil || d.Attempt != 1 || !succeeded {
+		t.Errorf("GetWebhookDeliveryContext = %+v, %v, %v; want attempt 1 with a later success", d, succeeded, err)
+	}
+	got, err := s.GetWebhookContext(ctx, w.ID)
+	if err != nil || got.LastStatus != 500 || got.LastDeliveryAt == nil || got.Active {
+		t.Errorf("webhook after deliveries = %+v, %v; want last status and inactive", got, err)
+	}
+
+	// 删除 webhook 时推送记录一并删除
+	if err := s.DeleteWebhookContext(ctx, w.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.GetWebhookDeliveryContext(ctx, w.ID, deliveries[0].ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("delivery after webhook delete: err = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteWebhookContext(ctx, w.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("delete twice: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testAPIKeys(t *testing.T, s Store) {
+	ctx := context.Background()
+	if ok, err := s.HasAPIKeysContext(ctx); err != nil || ok {
+		t.Errorf("HasAPIKeysContext on empty store = %v, %v; want false", ok, err)
+	}
+
+	key := &model.APIKey{Name: "script", Prefix: "tdl_1234", UserID: "alice", Role: model.APIKeyRoleMember, Workspaces: []string{"work", "home"}}
+	if err := s.CreateAPIKeyContext(ctx, key, "hash-1"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.AuthenticateAPIKeyContext(ctx, "hash-1")
+	if err != nil || got.ID != key.ID || fmt.Sprint(got.Workspaces) != "[home work]" || got.LastUsedAt == nil {
+		t.Fatalf("authenticate = %+v, %v; want the key with sorted workspaces and last_used_at", got, err)
+	}
+	if _, err := s.AuthenticateAPIKeyContext(ctx, "hash-2"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unknown hash: err = %v, want ErrNotFound", err)
+	}
+	if keys, err := s.ListAPIKeysContext(ctx, "alice"); err != nil || len(keys) != 1 {
+		t.Errorf("ListAPIKeysContext = %d, %v; want 1", len(keys), err)
+	}
+
+	if _, err := s.RevokeAPIKeyContext(ctx, "bob", key.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("revoke another user's key: err = %v, want ErrNotFound", err)
+	}
+	revoked, err := s.RevokeAPIKeyContext(ctx, "alice", key.ID)
+	if err != nil || revoked.RevokedAt == nil {
+		t.Fatalf("revoke = %+v, %v; want revoked_at", revoked, err)
+	}
+	if _, err := s.AuthenticateAPIKeyContext(ctx, "hash-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("revoked key: err = %v, want ErrNotFound", err)
+	}
+	if ok, err := s.HasAPIKeysContext(ctx); err != nil || !ok {
+		t.Errorf("HasAPIKeysContext after revoke = %v, %v; want true", ok, err)
+	}
+}
+
+func testWorkspaces(t *testing.T, s Store) {
+	ctx := context.Background()
+	ws, err := s.GetWorkspaceContext(ctx, model.DefaultWorkspace)
+	if err != nil || ws == nil {
+		t.Fatalf("default workspace = %+v, %v; want it to exist", ws, err)
+	}
+	if err := s.CreateWorkspaceContext(ctx, &model.Workspace{Slug: "home", Name: "Home", MaxTodos: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateWorkspaceContext(ctx, &model.Workspace{Slug: "home", Name: "Again"}); !errors.Is(err, storage.ErrWorkspaceExists) {
+		t.Errorf("duplicate slug: err = %v, want ErrWorkspaceExists", err)
+	}
+	if ws, err := s.GetWorkspaceContext(ctx, "home"); err != nil || ws == nil || ws.MaxTodos != 10 {
+		t.Errorf("home = %+v, %v; want max_todos 10", ws, err)
+	}
+	if ws, err := s.GetWorkspaceContext(ctx, "missing"); err != nil || ws != nil {
+		t.Errorf("missing workspace = %+v, %v; want nil", ws, err)
+	}
+	if list, err := s.ListWorkspacesContext(ctx); err != nil || len(list) != 2 {
+		t.Errorf("ListWorkspacesContext = %d, %v; want 2", len(list), err)
+	}
+}
+
+func testAutomationRules(t *testing.T, s Store) {
+	ctx := context.Background()
+	rule := &model.AutomationRule{
+		Name: "flag urgent", Event: model.EventTodoCreated, Enabled: true,
+		Conditions: []model.AutomationCondition{{Field: "title", Op: "contains", Value: "urgent"}},
+		Actions:    []model.AutomationAction{{Set: "priority", Value: "3"}},
+	}
+	if err := s.CreateAutomationRuleContext(ctx, rule); err != nil {
+		t.Fatal(err)
+	}
+	disabled := &model.AutomationRule{Name: "off", Event: model.EventTodoCreated, Actions: []model.AutomationAction{{Set: "priority", Value: "1"}}}
+	if err := s.CreateAutomationRuleContext(ctx, disabled); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版条件在写入时转换为表达式
+	got, err := s.GetAutomationRuleContext(ctx, rule.ID)
+	if err != nil || got.Condition == "" || len(got.Conditions) != 0 || len(got.Actions) != 1 {
+		t.Fatalf("rule = %+v, %v; want migrated condition and one action", got, err)
+	}
+	if all, err := s.ListAutomationRulesContext(ctx, ""); err != nil || len(all) != 2 {
+		t.Errorf("all rules = %d, %v; want 2", len(all), err)
+	}
+	if enabled, err := s.ListAutomationRulesContext(ctx, model.EventTodoCreated); err != nil || len(enabled) != 1 || enabled[0].ID != rule.ID {
+		t.Errorf("enabled rules for event = %+v, %v; want only the enabled rule", enabled, err)
+	}
+
+	got.Name = "renamed"
+	if err := s.UpdateAutomationRuleContext(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := s.GetAutomationRuleContext(ctx, rule.ID); err != nil || again.Name != "renamed" {
+		t.Errorf("rule after update = %+v, %v; want renamed", again, err)
+	}
+	if _, err := s.GetAutomationRuleContext(storage.WithWorkspace(ctx, "home"), rule.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if err := s.DeleteAutomationRuleContext(ctx, rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteAutomationRuleContext(ctx, rule.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("delete twice: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testArchive(t *testing.T, s Store) {
+	ctx := context.Background()
+	work := createProject(t, s, ctx, "work")
+	report := createTodo(t, s, ctx, "report", work)
+	createTodo(t, s, ctx, "loose", 0)
+	if err := s.SetProjectArchivedContext(ctx, work, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateCommentContext(ctx, &model.Comment{TodoID: report.ID, Author: "me", Body: "draft", CreatedAt: time.Now()}, nil); err != nil {
+		t.Fatal(err)
+	}
+	rule := &model.AutomationRule{Name: "flag", Event: model.EventTodoCreated, Enabled: true,
+		Actions: []model.AutomationAction{{Set: "priority", Value: "3"}}}
+	if err := s.CreateAutomationRuleContext(ctx, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := s.ExportArchiveContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.Version != storage.ArchiveVersion || archive.Workspace.Slug != model.DefaultWorkspace ||
+		len(archive.Projects) != 1 || len(archive.Todos) != 2 || len(archive.Comments) != 1 || len(archive.AutomationRules) != 1 {
+		t.Fatalf("archive = %+v; want 1 project, 2 todos, 1 comment and 1 rule", archive)
+	}
+	// 链接只在 SQLite 中导入，其他存储记为跳过
+	archive.Links = append(archive.Links, model.TodoLink{TodoID: report.ID, URL: "https://example.com", Status: "pending", CreatedAt: time.Now()})
+
+	for _, slug := range []string{"copy", "broken"} {
+		if err := s.CreateWorkspaceContext(ctx, &model.Workspace{Slug: slug, Name: slug}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	copyCtx := storage.WithWorkspace(ctx, "copy")
+	result, err := s.ImportArchiveContext(copyCtx, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Projects != 1 || result.Todos != 2 || result.Comments != 1 || result.AutomationRules != 1 ||
+		result.Links+result.Skipped["links"] != 1 {
+		t.Errorf("import result = %+v; want 1 project, 2 todos, 1 comment, 1 rule and the link imported or skipped", result)
+	}
+
+	// 引用按新 ID 改写，项目仍然是归档状态
+	projects, err := s.ListProjectsContext(copyCtx)
+	if err != nil || len(projects) != 1 || projects[0].ArchivedAt == nil {
+		t.Fatalf("imported projects = %+v, %v; want one archived project", projects, err)
+	}
+	todos, _, err := s.ListTodosContext(copyCtx, storage.TodoFilter{ProjectID: &projects[0].ID})
+	if err != nil || len(todos) != 1 || todos[0].Title != "report" {
+		t.Fatalf("imported project todos = %+v, %v; want report", todos, err)
+	}
+	if comments, err := s.ListCommentsContext(copyCtx, todos[0].ID); err != nil || len(comments) != 1 || comments[0].Body != "draft" {
+		t.Errorf("imported comments = %+v, %v; want draft", comments, err)
+	}
+
+	if _, err := s.ImportArchiveContext(copyCtx, archive); !errors.Is(err, storage.ErrWorkspaceNotEmpty) {
+		t.Errorf("import into non-empty workspace: err = %v, want ErrWorkspaceNotEmpty", err)
+	}
+
+	// 引用不一致时整个归档都不导入
+	broken := storage.WithWorkspace(ctx, "broken")
+	archive.Links = nil
+	archive.Comments = append(archive.Comments, model.Comment{TodoID: 999, Author: "me", Body: "orphan"})
+	var archiveErr *storage.ArchiveError
+	if _, err := s.ImportArchiveContext(broken, archive); !errors.As(err, &archiveErr) {
+		t.Errorf("import with dangling comment: err = %v, want *ArchiveError", err)
+	}
+	if left, err := s.ExportArchiveContext(broken); err != nil || len(left.Projects) != 0 || len(left.Todos) != 0 {
+		t.Errorf("workspace after failed import = %+v, %v; want empty", left, err)
+	}
+}
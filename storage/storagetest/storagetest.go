@@ -0,0 +1,685 @@
+// Package storagetest storage.TodoRepository 的一致性测试
+//
+// database.DB、database/postgres 和 storage/memory 的测试都调用 Run，保证处理器换用任何一个实现时行为相同：
+// 工作区隔离、乐观锁版本号、外部标识、列表过滤排序分页、统计、批量操作和导入导出
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// Run 对一个实现运行全部一致性测试，newRepo 在每个子测试开始时调用，需要返回一个空的存储
+func Run(t *testing.T, newRepo func(t *testing.T) storage.TodoRepository) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo storage.TodoRepository)
+	}{
+		{"CreateGet", testCreateGet},
+		{"ReturnsCopies", testReturnsCopies},
+		{"UpdateVersion", testUpdateVersion},
+		{"Delete", testDelete},
+		{"WorkspaceIsolation", testWorkspaceIsolation},
+		{"ListFilters", testListFilters},
+		{"ListSortAndPaging", testListSortAndPaging},
+		{"Stats", testStats},
+		{"CompletionLatency", testCompletionLatency},
+		{"Counts", testCounts},
+		{"Batch", testBatch},
+		{"BatchPartial", testBatchPartial},
+		{"ImportExport", testImportExport},
+		{"WorkflowStatuses", testWorkflowStatuses},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+// base 导入数据的创建时间起点，取整秒，SQLite 按秒比较创建时间
+var base = time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+// shanghai 位置过滤使用的坐标
+var shanghai = storage.GeoPoint{Lat: 31.2304, Lng: 121.4737}
+
+// seed 导入五条待办事项，创建时间依次相隔一分钟，返回按创建顺序排列的 ID
+//
+//	buy milk    pending    优先级 0  项目 1  截止 2000-01-10  位于上海
+//	Buy bread   pending    优先级 2  项目 1  截止 2999-01-01
+//	call mom    completed  优先级 1  描述中含 buy
+//	read book   pending    优先级 3  （收件箱）
+//	file taxes  pending    优先级 1  项目 2  截止 2000-01-20
+func seed(t *testing.T, repo storage.TodoRepository, ctx context.Context) []int {
+	t.Helper()
+	date := func(s string) *time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	project := func(id int) *int { return &id }
+	completedAt := base.Add(time.Hour)
+
+	todos := []model.Todo{
+		{Title: "buy milk", Status: "pending", Priority: 0, ProjectID: project(1), DueDate: date("2000-01-10")},
+		{Title: "Buy bread", Status: "pending", Priority: 2, ProjectID: project(1), DueDate: date("2999-01-01")},
+		{Title: "call mom", Description: "and buy flowers", Status: "completed", Priority: 1, CompletedAt: &completedAt},
+		{Title: "read book", Status: "pending", Priority: 3},
+		{Title: "file taxes", Status: "pending", Priority: 1, ProjectID: project(2), DueDate: date("2000-01-20")},
+	}
+	todos[0].SetLocation(shanghai.Lat, shanghai.Lng, nil, base)
+	for i := range todos {
+		todos[i].CreatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+	if n, err := repo.ImportTodosContext(ctx, todos); err != nil || n != len(todos) {
+		t.Fatalf("ImportTodosContext = %d, %v; want %d", n, err, len(todos))
+	}
+
+	list, _, err := repo.ListTodosContext(ctx, storage.TodoFilter{Sort: "created_at", Order: "asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(list))
+	for i := range list {
+		ids[i] = list[i].ID
+	}
+	return ids
+}
+
+// titles 待办事项的标题，按原顺序
+func titles(todos []model.Todo) []string {
+	s := make([]string, 0, len(todos))
+	for i := range todos {
+		s = append(s, todos[i].Title)
+	}
+	return s
+}
+
+func intPtr(n int) *int { return &n }
+
+func testCreateGet(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+
+	due := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	minutes, project := 45, 7
+	todo := model.NewTodo("write report", "quarterly", time.Now())
+	todo.DueDate = &due
+	todo.ProjectID = &project
+	todo.EstimatedMinutes = &minutes
+	todo.Priority = 2
+	todo.SecretNote = &model.SecretNote{Ciphertext: "c2VjcmV0", KeyHint: "laptop"}
+	todo.SetLocation(shanghai.Lat, shanghai.Lng, nil, todo.CreatedAt)
+	if err := repo.CreateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	if todo.ID == 0 || todo.PublicID == "" {
+		t.Fatalf("created ID = %d, public_id = %q; want both assigned", todo.ID, todo.PublicID)
+	}
+
+	got, err := repo.GetTodoByIDContext(ctx, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "write report" || got.Description != "quarterly" || got.Status != "pending" || got.Version != 1 {
+		t.Errorf("got %q / %q / %s v%d, want write report / quarterly / pending v1", got.Title, got.Description, got.Status, got.Version)
+	}
+	if got.PublicID != todo.PublicID {
+		t.Errorf("PublicID = %q, want %q", got.PublicID, todo.PublicID)
+	}
+	if got.DueDate == nil || !got.DueDate.Equal(due) {
+		t.Errorf("DueDate = %v, want %v", got.DueDate, due)
+	}
+	if got.ProjectID == nil || *got.ProjectID != project {
+		t.Errorf("ProjectID = %v, want %d", got.ProjectID, project)
+	}
+	if got.EstimatedMinutes == nil || *got.EstimatedMinutes != minutes || got.Priority != 2 {
+		t.Errorf("EstimatedMinutes = %v, Priority = %d; want %d, 2", got.EstimatedMinutes, got.Priority, minutes)
+	}
+	if got.Latitude == nil || *got.Latitude != shanghai.Lat || got.Longitude == nil || *got.Longitude != shanghai.Lng {
+		t.Errorf("location = %v, %v; want %v", got.Latitude, got.Longitude, shanghai)
+	}
+	if got.SecretNote == nil || *got.SecretNote != *todo.SecretNote {
+		t.Errorf("SecretNote = %+v, want %+v", got.SecretNote, todo.SecretNote)
+	}
+
+	if id, err := repo.GetTodoIDByPublicIDContext(ctx, todo.PublicID); err != nil || id != todo.ID {
+		t.Errorf("GetTodoIDByPublicIDContext = %d, %v; want %d", id, err, todo.ID)
+	}
+	if _, err := repo.GetTodoIDByPublicIDContext(ctx, model.NewPublicID(time.Now())); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unknown public_id: err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetTodoByIDContext(ctx, todo.ID+100); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("unknown ID: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testReturnsCopies(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	todo := model.NewTodo("original", "", time.Now())
+	if err := repo.CreateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改传入和取出的对象都不影响存储中的数据
+	todo.Title = "changed after create"
+	got, err := repo.GetTodoByIDContext(ctx, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Title = "changed after get"
+	list, _, err := repo.ListTodosContext(ctx, storage.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list[0].Title = "changed after list"
+
+	if got, _ := repo.GetTodoByIDContext(ctx, todo.ID); got.Title != "original" {
+		t.Errorf("Title = %q, want original", got.Title)
+	}
+}
+
+func testUpdateVersion(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	todo := model.NewTodo("draft", "", time.Now())
+	if err := repo.CreateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.GetTodoByIDContext(ctx, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := *got
+	got.Title = "final"
+	got.Status = "completed"
+	// 创建时间和外部标识不随更新改变
+	got.CreatedAt = base
+	got.PublicID = model.NewPublicID(base)
+	if err := repo.UpdateTodoContext(ctx, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 2 {
+		t.Errorf("Version after update = %d, want 2", got.Version)
+	}
+
+	saved, err := repo.GetTodoByIDContext(ctx, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Title != "final" || saved.Status != "completed" || saved.Version != 2 {
+		t.Errorf("saved = %q %s v%d, want final completed v2", saved.Title, saved.Status, saved.Version)
+	}
+	if saved.PublicID != stale.PublicID || !saved.CreatedAt.Equal(stale.CreatedAt) {
+		t.Errorf("public_id / created_at changed to %q / %v", saved.PublicID, saved.CreatedAt)
+	}
+	if saved.UpdatedAt.Before(stale.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want not before %v", saved.UpdatedAt, stale.UpdatedAt)
+	}
+
+	if err := repo.UpdateTodoContext(ctx, &stale); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("update with stale version: err = %v, want ErrVersionConflict", err)
+	}
+	if saved, _ := repo.GetTodoByIDContext(ctx, todo.ID); saved.Title != "final" {
+		t.Errorf("Title after conflict = %q, want final", saved.Title)
+	}
+}
+
+func testDelete(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	todo := model.NewTodo("temporary", "", time.Now())
+	if err := repo.CreateTodoContext(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteTodoContext(ctx, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetTodoByIDContext(ctx, todo.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get after delete: err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetTodoIDByPublicIDContext(ctx, todo.PublicID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("public_id after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.UpdateTodoContext(ctx, todo); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("update after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.DeleteTodoContext(ctx, todo.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("delete twice: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testWorkspaceIsolation(t *testing.T, repo storage.TodoRepository) {
+	home := storage.WithWorkspace(context.Background(), "home")
+	work := storage.WithWorkspace(context.Background(), "work")
+
+	todo := model.NewTodo("private", "", time.Now())
+	if err := repo.CreateTodoContext(home, todo); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetTodoByIDContext(work, todo.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if _, err := repo.GetTodoIDByPublicIDContext(work, todo.PublicID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("public_id from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.UpdateTodoContext(work, todo); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("update from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.DeleteTodoContext(work, todo.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("delete from another workspace: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.BatchCompleteTodosContext(work, []int{todo.ID}); err == nil {
+		t.Error("batch complete from another workspace succeeded")
+	}
+
+	if todos, total, err := repo.ListTodosContext(work, storage.TodoFilter{}); err != nil || total != 0 || len(todos) != 0 {
+		t.Errorf("list in another workspace = %d todos (total %d), %v; want none", len(todos), total, err)
+	}
+	if n, err := repo.CountTodosContext(work); err != nil || n != 0 {
+		t.Errorf("CountTodosContext(work) = %d, %v; want 0", n, err)
+	}
+	if stats, err := repo.GetFilteredStatsContext(work, storage.TodoFilter{}); err != nil || stats.Total != 0 {
+		t.Errorf("stats in another workspace = %+v, %v; want total 0", stats, err)
+	}
+	if todos, err := repo.ExportTodosContext(work); err != nil || len(todos) != 0 {
+		t.Errorf("export from another workspace = %d todos, %v; want none", len(todos), err)
+	}
+
+	if got, err := repo.GetTodoByIDContext(home, todo.ID); err != nil || got.Status != "pending" {
+		t.Errorf("todo in its own workspace = %+v, %v; want untouched", got, err)
+	}
+}
+
+func testListFilters(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	seed(t, repo, ctx)
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	cutoff := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	all := []string{"buy milk", "Buy bread", "call mom", "read book", "file taxes"}
+	tests := []struct {
+		name   string
+		filter storage.TodoFilter
+		want   []string
+	}{
+		{"no filter", storage.TodoFilter{}, all},
+		{"status", storage.TodoFilter{Status: "completed"}, []string{"call mom"}},
+		{"status all", storage.TodoFilter{Status: "all"}, all},
+		{"search title and description", storage.TodoFilter{Search: "BUY"}, []string{"buy milk", "Buy bread", "call mom"}},
+		{"priority", storage.TodoFilter{Priority: intPtr(1)}, []string{"call mom", "file taxes"}},
+		{"min priority", storage.TodoFilter{MinPriority: intPtr(2)}, []string{"Buy bread", "read book"}},
+		{"project", storage.TodoFilter{ProjectID: intPtr(1)}, []string{"buy milk", "Buy bread"}},
+		{"no project", storage.TodoFilter{ProjectID: intPtr(0)}, []string{"call mom", "read book"}},
+		{"pending only", storage.TodoFilter{PendingOnly: true}, []string{"buy milk", "Buy bread", "read book", "file taxes"}},
+		{"due date range", storage.TodoFilter{DueDateFrom: "2000-01-15", DueDateTo: "2000-12-31"}, []string{"file taxes"}},
+		{"due before", storage.TodoFilter{DueBefore: &cutoff}, []string{"buy milk", "file taxes"}},
+		{"inbox", storage.TodoFilter{InboxOnly: true}, []string{"read book"}},
+		{"near", storage.TodoFilter{Near: &shanghai, RadiusMeters: 1000}, []string{"buy milk"}},
+		{"updated before future", storage.TodoFilter{UpdatedBefore: &future}, all},
+		{"updated before past", storage.TodoFilter{UpdatedBefore: &past}, []string{}},
+		{"combined", storage.TodoFilter{Search: "buy", PendingOnly: true, ProjectID: intPtr(1), Priority: intPtr(2)}, []string{"Buy bread"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Sort, tt.filter.Order = "created_at", "asc"
+			todos, total, err := repo.ListTodosContext(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(todos); !slices.Equal(got, tt.want) {
+				t.Errorf("titles = %q, want %q", got, tt.want)
+			}
+			if total != len(tt.want) {
+				t.Errorf("total = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func testListSortAndPaging(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	seed(t, repo, ctx)
+
+	tests := []struct {
+		name      string
+		filter    storage.TodoFilter
+		want      []string
+		wantTotal int
+	}{
+		{"created_at desc", storage.TodoFilter{Sort: "created_at", Order: "desc"},
+			[]string{"file taxes", "read book", "call mom", "Buy bread", "buy milk"}, 5},
+		{"due_date asc, nulls first", storage.TodoFilter{Sort: "due_date", Order: "asc", PendingOnly: true},
+			[]string{"read book", "buy milk", "file taxes", "Buy bread"}, 4},
+		{"due_date desc, nulls last", storage.TodoFilter{Sort: "due_date", Order: "desc", PendingOnly: true},
+			[]string{"Buy bread", "file taxes", "buy milk", "read book"}, 4},
+		{"priority desc", storage.TodoFilter{Sort: "priority", Order: "desc", MinPriority: intPtr(2)},
+			[]string{"read book", "Buy bread"}, 2},
+		{"page", storage.TodoFilter{Sort: "created_at", Order: "asc", Limit: 2, Offset: 2},
+			[]string{"call mom", "read book"}, 5},
+		{"past last page", storage.TodoFilter{Sort: "created_at", Order: "asc", Limit: 2, Offset: 10},
+			[]string{}, 5},
+		{"skip total", storage.TodoFilter{Sort: "created_at", Order: "asc", Limit: 1, SkipTotal: true},
+			[]string{"buy milk"}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, total, err := repo.ListTodosContext(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(todos); !slices.Equal(got, tt.want) {
+				t.Errorf("titles = %q, want %q", got, tt.want)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func testStats(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	seed(t, repo, ctx)
+
+	tests := []struct {
+		name                                      string
+		filter                                    storage.TodoFilter
+		total, pending, completed, overdue, inbox int
+	}{
+		{"no filter", storage.TodoFilter{}, 5, 4, 1, 2, 1},
+		{"status ignored", storage.TodoFilter{Status: "completed", Limit: 1}, 5, 4, 1, 2, 1},
+		{"project", storage.TodoFilter{ProjectID: intPtr(1)}, 2, 2, 0, 1, 0},
+		{"search", storage.TodoFilter{Search: "buy"}, 3, 2, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := repo.GetFilteredStatsContext(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Total != tt.total || stats.Pending != tt.pending || stats.Completed != tt.completed {
+				t.Errorf("total/pending/completed = %d/%d/%d, want %d/%d/%d",
+					stats.Total, stats.Pending, stats.Completed, tt.total, tt.pending, tt.completed)
+			}
+			if stats.Overdue != tt.overdue || stats.Inbox != tt.inbox {
+				t.Errorf("overdue/inbox = %d/%d, want %d/%d", stats.Overdue, stats.Inbox, tt.overdue, tt.inbox)
+			}
+			if stats.ByStatus["pending"] != tt.pending || stats.ByStatus["completed"] != tt.completed {
+				t.Errorf("ByStatus = %v, want pending %d, completed %d", stats.ByStatus, tt.pending, tt.completed)
+			}
+			// 逾期的两条都截止在 2000 年；完成的一条从创建到完成用了 58 分钟
+			if b := stats.OverdueBuckets; b == nil || b.LessThanDay != 0 || b.OneToSeven != 0 || b.OverSeven != tt.overdue {
+				t.Errorf("OverdueBuckets = %+v, want %d over 7 days", b, tt.overdue)
+			}
+			if l := stats.CompletionLatency; l == nil || l.Overall.Count != tt.completed ||
+				(tt.completed > 0 && (l.Overall.MedianHours < 0.96 || l.Overall.MedianHours > 0.97)) {
+				t.Errorf("CompletionLatency = %+v, want %d completed in 58 minutes", l, tt.completed)
+			}
+			if stats.Focus == nil {
+				t.Error("Focus = nil")
+			}
+		})
+	}
+}
+
+// 中位数按整分钟分桶：10 分钟和 10 分 30 秒落在同一个分桶，中位数取这个分桶的平均 10.25 分钟，
+// 计数表（不带过滤条件）和现场查询（带过滤条件）的结果相同
+func testCompletionLatency(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	var todos []model.Todo
+	for i, d := range []time.Duration{10 * time.Minute, 10*time.Minute + 30*time.Second, 95 * time.Minute} {
+		completedAt := base.Add(d)
+		todos = append(todos, model.Todo{Title: fmt.Sprintf("done %d", i), Status: "completed", Priority: 1, CreatedAt: base, CompletedAt: &completedAt})
+	}
+	if n, err := repo.ImportTodosContext(ctx, todos); err != nil || n != len(todos) {
+		t.Fatalf("ImportTodosContext = %d, %v; want %d", n, err, len(todos))
+	}
+
+	for _, filter := range []storage.TodoFilter{{}, {Search: "done"}} {
+		stats, err := repo.GetFilteredStatsContext(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := storage.LatencyStats{Count: 3, AvgHours: (10 + 10.5 + 95) / 3.0 / 60, MedianHours: 10.25 / 60}
+		l := stats.CompletionLatency
+		if l == nil || len(l.ByPriority) != 1 || !latencyNear(l.Overall, want) || !latencyNear(l.ByPriority[0].LatencyStats, want) {
+			t.Errorf("filter %+v: CompletionLatency = %+v, want %+v overall and for priority 1", filter, l, want)
+		}
+	}
+}
+
+// latencyNear 两个耗时统计是否相同，平均值和中位数允许浮点误差
+func latencyNear(got, want storage.LatencyStats) bool {
+	return got.Count == want.Count && math.Abs(got.AvgHours-want.AvgHours) < 1e-6 && math.Abs(got.MedianHours-want.MedianHours) < 1e-6
+}
+
+func testCounts(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	seed(t, repo, ctx)
+
+	if n, err := repo.CountTodosContext(ctx); err != nil || n != 5 {
+		t.Errorf("CountTodosContext = %d, %v; want 5", n, err)
+	}
+	// 创建时间恰好等于 since 的也计入
+	if n, err := repo.CountTodosCreatedSinceContext(ctx, base.Add(2*time.Minute)); err != nil || n != 3 {
+		t.Errorf("CountTodosCreatedSinceContext = %d, %v; want 3", n, err)
+	}
+}
+
+func testBatch(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	ids := seed(t, repo, ctx)
+	milk, bread, mom, book := ids[0], ids[1], ids[2], ids[3]
+	missing := ids[len(ids)-1] + 100
+
+	status := func(id int) string {
+		t.Helper()
+		todo, err := repo.GetTodoByIDContext(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return todo.Status
+	}
+
+	// 全有或全无：有一个不存在、已完成或重复时整体不生效
+	for _, batch := range [][]int{{milk, missing}, {milk, mom}, {milk, milk}} {
+		if err := repo.BatchCompleteTodosContext(ctx, batch); err == nil {
+			t.Errorf("BatchCompleteTodosContext(%v) succeeded", batch)
+		}
+		if s := status(milk); s != "pending" {
+			t.Fatalf("status after failed batch %v = %s, want pending", batch, s)
+		}
+	}
+	if err := repo.BatchCompleteTodosContext(ctx, []int{milk, bread}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{milk, bread} {
+		todo, err := repo.GetTodoByIDContext(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if todo.Status != "completed" || todo.CompletedAt == nil {
+			t.Errorf("todo %d = %s (completed_at %v), want completed", id, todo.Status, todo.CompletedAt)
+		}
+	}
+
+	if err := repo.BatchDeleteTodosContext(ctx, []int{book, missing}); err == nil {
+		t.Error("BatchDeleteTodosContext with a missing ID succeeded")
+	}
+	if _, err := repo.GetTodoByIDContext(ctx, book); err != nil {
+		t.Errorf("todo deleted by a failed batch: %v", err)
+	}
+	if err := repo.BatchDeleteTodosContext(ctx, []int{book, mom}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := repo.CountTodosContext(ctx); err != nil || n != 3 {
+		t.Errorf("CountTodosContext after delete = %d, %v; want 3", n, err)
+	}
+
+	tooMany := make([]int, storage.DefaultBatchLimit+1)
+	if err := repo.BatchCompleteTodosContext(ctx, tooMany); err == nil {
+		t.Error("BatchCompleteTodosContext over the batch limit succeeded")
+	}
+	if _, err := repo.BatchDeleteTodosPartialContext(ctx, tooMany); err == nil {
+		t.Error("BatchDeleteTodosPartialContext over the batch limit succeeded")
+	}
+}
+
+func testBatchPartial(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+	ids := seed(t, repo, ctx)
+	milk, bread, mom := ids[0], ids[1], ids[2]
+	missing := ids[len(ids)-1] + 100
+
+	// 失败的条目记录在结果中，其余照常处理
+	result, err := repo.BatchCompleteTodosPartialContext(ctx, []int{milk, missing, mom, bread})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SuccessCount != 2 || result.FailedCount != 2 || len(result.Errors) != 2 {
+		t.Fatalf("complete result = %+v, want 2 succeeded and 2 failed", result)
+	}
+	if result.Errors[0].ID != missing || result.Errors[1].ID != mom {
+		t.Errorf("failed IDs = %d, %d; want %d, %d", result.Errors[0].ID, result.Errors[1].ID, missing, mom)
+	}
+	if stats, err := repo.GetFilteredStatsContext(ctx, storage.TodoFilter{}); err != nil || stats.Completed != 3 {
+		t.Errorf("completed after partial batch = %+v, %v; want 3", stats, err)
+	}
+
+	result, err = repo.BatchDeleteTodosPartialContext(ctx, []int{missing, milk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SuccessCount != 1 || result.FailedCount != 1 || result.Errors[0].ID != missing {
+		t.Errorf("delete result = %+v, want %d deleted and %d failed", result, milk, missing)
+	}
+	if _, err := repo.GetTodoByIDContext(ctx, milk); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("get after partial delete: err = %v, want ErrNotFound", err)
+	}
+
+	// 全部成功时 Errors 是空列表而不是 nil，JSON 输出为 []
+	result, err = repo.BatchDeleteTodosPartialContext(ctx, []int{bread})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SuccessCount != 1 || result.Errors == nil {
+		t.Errorf("delete result = %+v, want 1 succeeded and empty errors", result)
+	}
+}
+
+func testImportExport(t *testing.T, repo storage.TodoRepository) {
+	ctx := context.Background()
+
+	publicID := model.NewPublicID(base)
+	completedAt := base.Add(time.Hour)
+	todos := []model.Todo{
+		{Title: "imported", PublicID: publicID, CreatedAt: base},
+		{Title: ""},
+		{Title: "done", Status: "completed", CompletedAt: &completedAt, CreatedAt: base.Add(time.Minute)},
+	}
+	n, err := repo.ImportTodosContext(ctx, todos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("imported = %d, want 2 (empty title skipped)", n)
+	}
+	id, err := repo.GetTodoIDByPublicIDContext(ctx, publicID)
+	if err != nil {
+		t.Fatalf("public_id from the import was not kept: %v", err)
+	}
+	imported, err := repo.GetTodoByIDContext(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Status != "pending" || imported.Version != 1 || !imported.CreatedAt.Equal(base) {
+		t.Errorf("imported = %s v%d created %v, want pending v1 created %v", imported.Status, imported.Version, imported.CreatedAt, base)
+	}
+
+	// 再次导入同一个外部标识时重新生成
+	if _, err := repo.ImportTodosContext(ctx, todos[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := repo.ExportTodosContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按创建时间倒序，两条 imported 创建时间相同
+	if got := titles(exported); len(got) != 3 || got[0] != "done" {
+		t.Fatalf("exported titles = %q, want done first of 3", got)
+	}
+	if exported[0].CompletedAt == nil || !exported[0].CompletedAt.Equal(completedAt) {
+		t.Errorf("exported CompletedAt = %v, want %v", exported[0].CompletedAt, completedAt)
+	}
+	if exported[1].PublicID == exported[2].PublicID {
+		t.Errorf("re-imported todo reused public_id %q", exported[1].PublicID)
+	}
+}
+
+// testWorkflowStatuses 自定义工作流：in_progress 也算未完成，cancelled 也是终态
+func testWorkflowStatuses(t *testing.T, repo storage.TodoRepository) {
+	setter, ok := repo.(storage.StatusSetter)
+	if !ok {
+		t.Skip("repository does not support custom statuses")
+	}
+	setter.SetStatuses(storage.StatusSets{
+		Open: []string{"pending", "in_progress"},
+		Done: []string{"completed", "cancelled"},
+	})
+
+	ctx := context.Background()
+	past := time.Date(2000, 1, 10, 0, 0, 0, 0, time.UTC)
+	completedAt := base.Add(time.Hour)
+	todos := []model.Todo{
+		{Title: "started", Status: "in_progress", DueDate: &past, CreatedAt: base},
+		{Title: "todo", Status: "pending", CreatedAt: base.Add(time.Minute)},
+		{Title: "done", Status: "completed", CompletedAt: &completedAt, CreatedAt: base.Add(2 * time.Minute)},
+		{Title: "dropped", Status: "cancelled", CompletedAt: &completedAt, CreatedAt: base.Add(3 * time.Minute)},
+	}
+	if n, err := repo.ImportTodosContext(ctx, todos); err != nil || n != len(todos) {
+		t.Fatalf("ImportTodosContext = %d, %v; want %d", n, err, len(todos))
+	}
+	all, _, err := repo.ListTodosContext(ctx, storage.TodoFilter{Sort: "created_at", Order: "asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, dropped := all[0].ID, all[3].ID
+
+	stats, err := repo.GetFilteredStatsContext(ctx, storage.TodoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pending != 2 || stats.Completed != 2 || stats.Overdue != 1 {
+		t.Errorf("pending/completed/overdue = %d/%d/%d, want 2/2/1", stats.Pending, stats.Completed, stats.Overdue)
+	}
+
+	pending, _, err := repo.ListTodosContext(ctx, storage.TodoFilter{PendingOnly: true, Sort: "created_at", Order: "asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := titles(pending), []string{"started", "todo"}; !slices.Equal(got, want) {
+		t.Errorf("PendingOnly = %v, want %v", got, want)
+	}
+
+	// 非终态都可以批量完成，终态不行
+	if err := repo.BatchCompleteTodosContext(ctx, []int{dropped}); err == nil {
+		t.Error("BatchCompleteTodosContext on a cancelled todo succeeded")
+	}
+	if err := repo.BatchCompleteTodosContext(ctx, []int{started}); err != nil {
+		t.Fatal(err)
+	}
+	if todo, err := repo.GetTodoByIDContext(ctx, started); err != nil || todo.Status != "completed" {
+		t.Errorf("in_progress todo after batch complete = %+v, %v; want completed", todo, err)
+	}
+}
@@ -0,0 +1,200 @@
+package storage
+
+// 待办事项存储的数据契约：TodoRepository 的参数、返回值和错误，
+// database、database/postgres、storage/memory 实现这些方法，处理器只依赖这里的定义
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+	"todo-list/model"
+)
+
+// ErrVersionConflict 更新时版本号与存储中的不一致（乐观锁）
+var ErrVersionConflict = errors.New("todo version conflict")
+
+// 批量写入上限，各个实现使用相同的值
+const (
+	DefaultBatchLimit = 100  // 默认的单次批量操作上限
+	MaxImportSize     = 1000 // 单次导入的最大条数
+)
+
+type workspaceKey struct{}
+
+// WithWorkspace 返回携带工作区标识的 Context
+// TodoRepository 和所有 *Context 结尾的数据库方法都只读写 Context 中的工作区，未设置时为默认工作区
+func WithWorkspace(ctx context.Context, slug string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, slug)
+}
+
+// WorkspaceFromContext 取出 Context 中的工作区标识
+func WorkspaceFromContext(ctx context.Context) string {
+	if slug, ok := ctx.Value(workspaceKey{}).(string); ok && slug != "" {
+		return slug
+	}
+	return model.DefaultWorkspace
+}
+
+// DefaultRadiusMeters 待办事项未设置提醒半径、请求也未指定时使用的默认半径（米）
+const DefaultRadiusMeters = 500.0
+
+// earthRadiusMeters 地球平均半径（米）
+const earthRadiusMeters = 6371000.0
+
+// GeoPoint 经纬度坐标
+type GeoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+// HaversineMeters 计算两个经纬度坐标之间的球面距离（米）
+func HaversineMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// todoSortFields 列表允许的排序字段
+var todoSortFields = map[string]bool{
+	"created_at": true,
+	"due_date":   true,
+	"status":     true,
+	"priority":   true,
+	"updated_at": true,
+	"position":   true, // 拖放调整的顺序（见 POST /todos/batch/move），只有 SQLite 支持
+}
+
+// TodoFilter 查询过滤器
+type TodoFilter struct {
+	Status string
+	Search string
+	Sort   string
+	Order  string
+	Limit  int
+	Offset int
+
+	// 位置过滤：Near 为空表示不过滤
+	// RadiusMeters 为 0 时使用每条待办事项自己的提醒半径（未设置则为 DefaultRadiusMeters）
+	Near         *GeoPoint
+	RadiusMeters float64
+
+	// SkipTotal 为 true 时不计算总数（客户端用 include_total=false 关闭），ListTodosContext 返回的 total 为 -1
+	SkipTotal bool
+
+	// 优先级过滤（数值，名称已在接口层换算）：Priority 为指定档位，MinPriority 为不低于该档位
+	Priority    *int
+	MinPriority *int
+
+	// 项目过滤：为空表示不过滤，0 表示只看不属于任何项目的事项
+	ProjectID *int
+
+	// IncludeArchived 列表中包含已归档项目中的事项；不按项目过滤时默认不包含（SQLite 中的 hidden 列），统计不受影响
+	IncludeArchived bool
+
+	// 视图条件，由接口的 view 参数展开（见 handler/views.go），和上面的条件同时生效；零值表示不过滤
+	// 判断方式与统计信息中的 overdue、today、this_week、inbox 一致
+	PendingOnly   bool       // 只看未完成（工作流中的非终态）
+	DueDateFrom   string     // 截止日期（按 UTC 日期）不早于该天，格式 2006-01-02
+	DueDateTo     string     // 截止日期（按 UTC 日期）不晚于该天
+	DueBefore     *time.Time // 截止时间早于该时刻（逾期）
+	InboxOnly     bool       // 只看收件箱：未进入终态、没有截止日期、不属于任何项目（SQLite 中还要求没有关联目标）
+	UpdatedBefore *time.Time // 该时刻之后没有更新过（停滞）
+}
+
+// NormalizeFilter 填充默认值并校验排序参数：默认按创建时间倒序、每页 50 条
+func NormalizeFilter(f TodoFilter) TodoFilter {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	if f.Status == "" {
+		f.Status = "all"
+	}
+	if !todoSortFields[f.Sort] {
+		f.Sort = "created_at"
+	}
+	f.Order = strings.ToUpper(f.Order)
+	if f.Order != "ASC" && f.Order != "DESC" {
+		f.Order = "DESC"
+	}
+	return f
+}
+
+// TodoStats 统计信息
+type TodoStats struct {
+	Total     int `json:"total"`     // 总数量
+	Pending   int `json:"pending"`   // 未完成
+	Completed int `json:"completed"` // 已完成
+	Overdue   int `json:"overdue"`   // 已逾期
+	Today     int `json:"today"`     // 今天到期
+	ThisWeek  int `json:"this_week"` // 本周到期
+	Inbox     int `json:"inbox"`     // 收件箱中还没有整理的事项，与 TodoFilter.InboxOnly 相同
+
+	// 以下几项只在 GetStatsContext 中计算
+	ByStatus          map[string]int     `json:"by_status,omitempty"`          // 按状态分组（包含自定义状态）
+	OverdueBuckets    *OverdueBuckets    `json:"overdue_buckets,omitempty"`    // 逾期时长分布
+	CompletionLatency *CompletionLatency `json:"completion_latency,omitempty"` // 完成耗时
+	Focus             *FocusScore        `json:"focus,omitempty"`              // 当天的专注度
+}
+
+// OverdueBuckets 按逾期时长分组的未完成待办事项数量，三项之和等于 TodoStats.Overdue
+type OverdueBuckets struct {
+	LessThanDay int `json:"lt_1d"` // 逾期不到 1 天
+	OneToSeven  int `json:"1d_7d"` // 逾期 1 到 7 天
+	OverSeven   int `json:"gt_7d"` // 逾期超过 7 天
+}
+
+// LatencyStats 从创建到完成的耗时（小时）
+type LatencyStats struct {
+	Count       int     `json:"count"`
+	AvgHours    float64 `json:"avg_hours"`
+	MedianHours float64 `json:"median_hours"` // 近似值：耗时按整分钟分桶，取中间一条所在分桶的平均耗时，误差小于 1 分钟
+}
+
+// PriorityLatency 某个优先级的完成耗时
+type PriorityLatency struct {
+	Priority int `json:"priority"`
+	LatencyStats
+}
+
+// CompletionLatency 完成耗时统计：整体 + 按优先级
+// 项目、标签功能上线后可以按同样的方式增加分组
+type CompletionLatency struct {
+	Overall    LatencyStats      `json:"overall"`
+	ByPriority []PriorityLatency `json:"by_priority"`
+}
+
+// FocusScore 当天（UTC）的专注度，前端的进度环使用 Score
+//
+// 每条待办事项按优先级加权，权重为 priority + 1（最小为 1，默认映射下 low 为 1、urgent 为 4）；
+// 今天完成的事项计入 Points，完成时已经逾期的再计一次（清理逾期）；
+// 仍未完成、今天到期或已逾期的事项计入 Remaining。
+// Score = Points / (Points + Remaining) * 100，今天既没有完成也没有待处理的事项时为 0
+type FocusScore struct {
+	Date           string `json:"date"`            // 统计日期 YYYY-MM-DD
+	Completed      int    `json:"completed"`       // 今天完成的数量
+	OverdueCleared int    `json:"overdue_cleared"` // 其中完成时已经逾期的数量
+	Points         int    `json:"points"`          // 加权后的完成分
+	Remaining      int    `json:"remaining"`       // 加权后仍待处理的分
+	Score          int    `json:"score"`           // 0-100
+}
+
+// BatchError 批量操作中的单个错误
+type BatchError struct {
+	ID    int    `json:"id"`
+	Error string `json:"error"`
+}
+
+// BatchResult 批量操作结果
+type BatchResult struct {
+	SuccessCount int          `json:"success_count"`
+	FailedCount  int          `json:"failed_count"`
+	Errors       []BatchError `json:"errors,omitempty"`
+}
@@ -0,0 +1,91 @@
+package suggest
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarThreshold 判定为疑似重复的最低相似度
+const DefaultSimilarThreshold = 0.5
+
+// Candidate 参与比较的已有标题
+type Candidate struct {
+	ID     int
+	Title  string
+	Status string
+}
+
+// Match 疑似重复的已有标题
+type Match struct {
+	ID     int     `json:"id"`
+	Title  string  `json:"title"`
+	Status string  `json:"status"`
+	Score  float64 `json:"score"` // 0-1，1 表示规范化后完全相同
+}
+
+// NormalizeForMatch 规范化标题用于比较：转小写，标点和空白都视为分隔符并合并为一个空格
+// "Buy milk!" 和 "buy  milk" 规范化后相同
+func NormalizeForMatch(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// trigrams 把规范化后的标题拆成按字符（rune）计的三元组，首尾补空格，短标题也能产生三元组
+func trigrams(s string) map[string]bool {
+	runes := []rune("  " + s + " ")
+	grams := make(map[string]bool, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = true
+	}
+	return grams
+}
+
+// jaccard 两组三元组的 Jaccard 系数
+func jaccard(a, b map[string]bool) float64 {
+	shared := 0
+	for g := range a {
+		if b[g] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// Similar 从候选中找出与 title 相似度不低于 threshold 的标题，按相似度从高到低返回最多 limit 条
+// 规范化后相同的相似度为 1，否则为三元组的 Jaccard 系数
+func Similar(title string, candidates []Candidate, threshold float64, limit int) []Match {
+	matches := make([]Match, 0)
+	normalized := NormalizeForMatch(title)
+	if normalized == "" {
+		return matches
+	}
+	grams := trigrams(normalized)
+
+	for _, c := range candidates {
+		other := NormalizeForMatch(c.Title)
+		if other == "" {
+			continue
+		}
+		score := 1.0
+		if other != normalized {
+			score = jaccard(grams, trigrams(other))
+		}
+		if score >= threshold {
+			matches = append(matches, Match{ID: c.ID, Title: c.Title, Status: c.Status, Score: math.Round(score*100) / 100})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,183 @@
+// Package suggest 根据标题 / 描述中的关键词和历史完成情况推荐截止日期与优先级
+// 只做简单的规则匹配，不追求准确，Confidence 用来告诉客户端该不该直接采用
+package suggest
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// 优先级取值与 todos.priority 列一致：数字越大越紧急，默认为 1
+const (
+	PriorityLow    = 0
+	PriorityNormal = 1
+	PriorityHigh   = 2
+	PriorityUrgent = 3
+)
+
+// Input 待推荐的待办事项
+type Input struct {
+	Title       string
+	Description string
+	Location    *time.Location // 用户所在时区，决定"今天""周五"是哪一天；为空时使用 UTC
+}
+
+// History 用户的历史完成情况
+type History struct {
+	CompletedCount     int     // 已完成的待办事项数量
+	MedianLatencyHours float64 // 从创建到完成的中位耗时
+}
+
+// Suggestion 推荐结果
+type Suggestion struct {
+	DueDate    *time.Time `json:"due_date,omitempty"` // 为空表示不建议设置截止日期
+	Priority   int        `json:"priority"`
+	Confidence float64    `json:"confidence"` // 0-1
+	Reasons    []string   `json:"reasons"`
+}
+
+// 关键词规则，匹配时不区分大小写
+var (
+	urgentKeywords = []string{"urgent", "asap", "immediately", "critical", "紧急", "立即", "马上", "尽快"}
+	highKeywords   = []string{"important", "deadline", "重要", "截止", "务必"}
+	lowKeywords    = []string{"someday", "maybe", "eventually", "以后", "有空", "随便"}
+
+	// 相对日期：关键词 -> 距今天数
+	relativeDays = []struct {
+		keywords []string
+		days     int
+	}{
+		{[]string{"day after tomorrow", "后天"}, 2},
+		{[]string{"tomorrow", "明天", "明日"}, 1},
+		{[]string{"today", "tonight", "今天", "今晚", "今日"}, 0},
+	}
+
+	weekdayNames = map[time.Weekday][]string{
+		time.Monday:    {"monday", "周一", "星期一", "礼拜一"},
+		time.Tuesday:   {"tuesday", "周二", "星期二", "礼拜二"},
+		time.Wednesday: {"wednesday", "周三", "星期三", "礼拜三"},
+		time.Thursday:  {"thursday", "周四", "星期四", "礼拜四"},
+		time.Friday:    {"friday", "周五", "星期五", "礼拜五"},
+		time.Saturday:  {"saturday", "周六", "星期六", "礼拜六"},
+		time.Sunday:    {"sunday", "周日", "周天", "星期日", "星期天", "礼拜天"},
+	}
+)
+
+// Suggest 生成推荐；now 由调用方传入，便于测试
+func Suggest(in Input, history History, now time.Time) Suggestion {
+	loc := in.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+	text := strings.ToLower(in.Title + " " + in.Description)
+
+	s := Suggestion{Priority: PriorityNormal, Reasons: make([]string, 0)}
+	var confidence float64
+
+	// 1. 优先级关键词
+	switch {
+	case containsAny(text, urgentKeywords):
+		s.Priority = PriorityUrgent
+		s.Reasons = append(s.Reasons, "包含紧急关键词")
+		confidence += 0.4
+	case containsAny(text, highKeywords):
+		s.Priority = PriorityHigh
+		s.Reasons = append(s.Reasons, "包含重要关键词")
+		confidence += 0.3
+	case containsAny(text, lowKeywords):
+		s.Priority = PriorityLow
+		s.Reasons = append(s.Reasons, "包含低优先级关键词，不建议设置截止日期")
+		s.Confidence = 0.5
+		return s
+	}
+
+	// 2. 明确的日期表达优先于历史习惯
+	if days, ok := matchRelativeDay(text); ok {
+		s.DueDate = endOfDay(now.AddDate(0, 0, days))
+		s.Reasons = append(s.Reasons, "包含相对日期")
+		confidence += 0.5
+	} else if wd, next, ok := matchWeekday(text); ok {
+		s.DueDate = endOfDay(nextWeekday(now, wd, next))
+		s.Reasons = append(s.Reasons, "包含星期")
+		confidence += 0.5
+	} else if strings.Contains(text, "next week") || strings.Contains(text, "下周") {
+		s.DueDate = endOfDay(nextWeekday(now, time.Friday, true))
+		s.Reasons = append(s.Reasons, "下周内完成")
+		confidence += 0.3
+	} else if strings.Contains(text, "this week") || strings.Contains(text, "本周") || strings.Contains(text, "这周") {
+		s.DueDate = endOfDay(nextWeekday(now, time.Friday, false))
+		s.Reasons = append(s.Reasons, "本周内完成")
+		confidence += 0.3
+	} else if s.Priority == PriorityUrgent {
+		s.DueDate = endOfDay(now)
+		s.Reasons = append(s.Reasons, "紧急事项建议当天完成")
+	} else if history.CompletedCount > 0 && history.MedianLatencyHours > 0 {
+		// 3. 没有任何线索时参考历史完成耗时，样本越多越可信
+		days := int(math.Ceil(history.MedianLatencyHours / 24))
+		s.DueDate = endOfDay(now.AddDate(0, 0, days))
+		s.Reasons = append(s.Reasons, "参考历史完成耗时的中位数")
+		confidence += math.Min(0.4, float64(history.CompletedCount)/50)
+	}
+
+	s.Confidence = math.Min(1, math.Round(confidence*100)/100)
+	return s
+}
+
+// containsAny 判断文本是否包含任一关键词
+func containsAny(text string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRelativeDay 匹配"今天""明天"等相对日期，按列表顺序优先匹配更长的表达
+func matchRelativeDay(text string) (int, bool) {
+	for _, rule := range relativeDays {
+		if containsAny(text, rule.keywords) {
+			return rule.days, true
+		}
+	}
+	return 0, false
+}
+
+// matchWeekday 匹配星期，next 表示带有"下"/"next"前缀
+func matchWeekday(text string) (time.Weekday, bool, bool) {
+	for wd, names := range weekdayNames {
+		for _, name := range names {
+			idx := strings.Index(text, name)
+			if idx < 0 {
+				continue
+			}
+			prefix := text[:idx]
+			next := strings.HasSuffix(prefix, "下") || strings.HasSuffix(prefix, "next ")
+			return wd, next, true
+		}
+	}
+	return 0, false, false
+}
+
+// nextWeekday 返回 now 之后（含今天）最近的 wd；next 为 true 时返回下一周的 wd
+func nextWeekday(now time.Time, wd time.Weekday, next bool) time.Time {
+	diff := (int(wd) - int(now.Weekday()) + 7) % 7
+	if next {
+		// "下周五"：跳到下周一，再找周五
+		toMonday := (int(time.Monday) - int(now.Weekday()) + 7) % 7
+		if toMonday == 0 {
+			toMonday = 7
+		}
+		diff = toMonday + (int(wd)-int(time.Monday)+7)%7
+	}
+	return now.AddDate(0, 0, diff)
+}
+
+// endOfDay 当天 23:59:59（所在时区）
+func endOfDay(t time.Time) *time.Time {
+	y, m, d := t.Date()
+	end := time.Date(y, m, d, 23, 59, 59, 0, t.Location())
+	return &end
+}
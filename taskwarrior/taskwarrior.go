@@ -0,0 +1,298 @@
+// Package taskwarrior 读写 taskwarrior 的 JSON 导出格式（task export / task import），
+// 让命令行用户可以迁移或同时使用两边；只负责格式本身，与待办事项的字段映射在 handler 中完成
+package taskwarrior
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/recurrence"
+)
+
+// TimeLayout taskwarrior 的时间格式（UTC）
+const TimeLayout = "20060102T150405Z"
+
+// 任务状态
+const (
+	StatusPending   = "pending"
+	StatusWaiting   = "waiting"
+	StatusCompleted = "completed"
+	StatusDeleted   = "deleted"
+	StatusRecurring = "recurring" // 重复任务的模板，实际要做的是带 parent 的各次实例
+)
+
+// Annotation 任务的注释
+type Annotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// Task taskwarrior 导出的一个任务；不认识的字段（用户自定义属性，UDA）放在 UDA 中，原样读写
+type Task struct {
+	ID          int          `json:"id,omitempty"`
+	UUID        string       `json:"uuid"`
+	Description string       `json:"description"`
+	Status      string       `json:"status"`
+	Entry       string       `json:"entry,omitempty"`
+	Modified    string       `json:"modified,omitempty"`
+	End         string       `json:"end,omitempty"`
+	Due         string       `json:"due,omitempty"`
+	Wait        string       `json:"wait,omitempty"`
+	Priority    string       `json:"priority,omitempty"` // H / M / L
+	Project     string       `json:"project,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Recur       string       `json:"recur,omitempty"`
+	Parent      string       `json:"parent,omitempty"`
+
+	UDA map[string]json.RawMessage `json:"-"`
+}
+
+// builtin taskwarrior 自带的属性，其余都视为 UDA；导入时不用的自带属性（urgency、mask 等）直接丢弃
+var builtin = map[string]bool{
+	"id": true, "uuid": true, "description": true, "status": true, "entry": true, "modified": true,
+	"end": true, "due": true, "wait": true, "priority": true, "project": true, "tags": true,
+	"annotations": true, "recur": true, "parent": true, "urgency": true, "mask": true, "imask": true,
+	"until": true, "scheduled": true, "start": true, "depends": true,
+}
+
+// UnmarshalJSON 读取自带属性，其余字段放入 UDA
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type plain Task // 去掉 UnmarshalJSON 方法，避免递归
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		if builtin[name] {
+			continue
+		}
+		if p.UDA == nil {
+			p.UDA = make(map[string]json.RawMessage)
+		}
+		p.UDA[name] = value
+	}
+	*t = Task(p)
+	return nil
+}
+
+// MarshalJSON 输出自带属性和 UDA，UDA 与自带属性同名时忽略
+func (t Task) MarshalJSON() ([]byte, error) {
+	type plain Task
+	data, err := json.Marshal(plain(t))
+	if err != nil || len(t.UDA) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range t.UDA {
+		if !builtin[name] {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// Decode 读取 task export 的输出：JSON 数组，或者旧版本每行一个任务（行尾可能带逗号）
+func Decode(r io.Reader) ([]Task, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取失败：%w", err)
+	}
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}))
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		var tasks []Task
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return nil, fmt.Errorf("taskwarrior JSON 解析失败：%w", err)
+		}
+		return tasks, nil
+	}
+
+	var tasks []Task
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ",")
+		if text == "" {
+			continue
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(text), &task); err != nil {
+			return nil, fmt.Errorf("第 %d 行 taskwarrior JSON 解析失败：%w", line, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取失败：%w", err)
+	}
+	return tasks, nil
+}
+
+// ParseTime 解析 taskwarrior 的时间，也接受 RFC 3339；空字符串返回 nil
+func ParseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{TimeLayout, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("无法识别的时间：%q", s)
+}
+
+// FormatTime 按 taskwarrior 的格式输出时间，nil 返回空字符串
+func FormatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(TimeLayout)
+}
+
+// recurNames taskwarrior 的命名周期 -> RRULE
+var recurNames = map[string]string{
+	"daily":      "FREQ=DAILY",
+	"day":        "FREQ=DAILY",
+	"weekdays":   "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+	"weekly":     "FREQ=WEEKLY",
+	"week":       "FREQ=WEEKLY",
+	"sennight":   "FREQ=WEEKLY",
+	"biweekly":   "FREQ=WEEKLY;INTERVAL=2",
+	"fortnight":  "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":    "FREQ=MONTHLY",
+	"month":      "FREQ=MONTHLY",
+	"bimonthly":  "FREQ=MONTHLY;INTERVAL=2",
+	"quarterly":  "FREQ=MONTHLY;INTERVAL=3",
+	"semiannual": "FREQ=MONTHLY;INTERVAL=6",
+	"annual":     "FREQ=YEARLY",
+	"yearly":     "FREQ=YEARLY",
+	"year":       "FREQ=YEARLY",
+	"biannual":   "FREQ=YEARLY;INTERVAL=2",
+	"biyearly":   "FREQ=YEARLY;INTERVAL=2",
+}
+
+// recurUnits 数字加单位（3d、2weeks、P1M）中的单位 -> 频率和倍数
+var recurUnits = map[string]struct {
+	freq  string
+	scale int
+}{
+	"d": {"DAILY", 1}, "day": {"DAILY", 1}, "days": {"DAILY", 1},
+	"w": {"WEEKLY", 1}, "wk": {"WEEKLY", 1}, "wks": {"WEEKLY", 1}, "week": {"WEEKLY", 1}, "weeks": {"WEEKLY", 1},
+	"mo": {"MONTHLY", 1}, "mos": {"MONTHLY", 1}, "month": {"MONTHLY", 1}, "months": {"MONTHLY", 1},
+	"q": {"MONTHLY", 3}, "qtr": {"MONTHLY", 3}, "quarter": {"MONTHLY", 3}, "quarters": {"MONTHLY", 3},
+	"y": {"YEARLY", 1}, "yr": {"YEARLY", 1}, "yrs": {"YEARLY", 1}, "year": {"YEARLY", 1}, "years": {"YEARLY", 1},
+}
+
+// RecurToRRule 把 taskwarrior 的 recur（weekly、3d、P2W 等）转换为 RRULE，按小时等无法表示的周期返回 false
+func RecurToRRule(recur string) (string, bool) {
+	s := strings.ToLower(strings.TrimSpace(recur))
+	if rule, ok := recurNames[s]; ok {
+		return rule, true
+	}
+
+	// ISO 8601 的 P1D、P2W、P1M、P1Y
+	unit := ""
+	if strings.HasPrefix(s, "p") && !strings.Contains(s, "t") && len(s) > 2 {
+		unit = s[len(s)-1:]
+		if unit == "m" {
+			unit = "mo"
+		}
+		s = s[1 : len(s)-1]
+	} else {
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i < 0 {
+			return "", false
+		}
+		s, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	n := 1
+	if s != "" {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil || n < 1 {
+			return "", false
+		}
+	}
+	u, ok := recurUnits[unit]
+	if !ok {
+		return "", false
+	}
+	n *= u.scale
+	if n == 1 {
+		return "FREQ=" + u.freq, true
+	}
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", u.freq, n), true
+}
+
+// RRuleToRecur 把简单的 RRULE（只有 FREQ 和 INTERVAL，或者工作日）转换为 taskwarrior 的 recur，
+// taskwarrior 表达不了的规则（COUNT、BYMONTHDAY 等）返回 false
+func RRuleToRecur(rule *recurrence.Rule) (string, bool) {
+	if rule.Count > 0 || !rule.Until.IsZero() || len(rule.ByMonthDay) > 0 || len(rule.ByMonth) > 0 {
+		return "", false
+	}
+	if len(rule.ByDay) > 0 {
+		if rule.String() == "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR" {
+			return "weekdays", true
+		}
+		return "", false
+	}
+
+	names := map[recurrence.Frequency][2]string{
+		recurrence.Daily:   {"daily", "days"},
+		recurrence.Weekly:  {"weekly", "weeks"},
+		recurrence.Monthly: {"monthly", "months"},
+		recurrence.Yearly:  {"yearly", "years"},
+	}
+	name, ok := names[rule.Freq]
+	if !ok {
+		return "", false
+	}
+	if rule.Interval <= 1 {
+		return name[0], true
+	}
+	return strconv.Itoa(rule.Interval) + name[1], true
+}
+
+// SetUDA 设置一个 UDA，value 按 JSON 编码
+func (t *Task) SetUDA(name string, value interface{}) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if t.UDA == nil {
+		t.UDA = make(map[string]json.RawMessage)
+	}
+	t.UDA[name] = raw
+}
+
+// TakeUDA 把 UDA 解码到 dst 并从 UDA 中移除；不存在或类型不符时返回 false，类型不符的值保留
+// 数值也接受字符串形式（taskwarrior 的 numeric UDA 在部分版本中以字符串导出）
+func (t *Task) TakeUDA(name string, dst interface{}) bool {
+	raw, ok := t.UDA[name]
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		var s string
+		if json.Unmarshal(raw, &s) != nil || json.Unmarshal([]byte(s), dst) != nil {
+			return false
+		}
+	}
+	delete(t.UDA, name)
+	return true
+}
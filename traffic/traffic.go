@@ -0,0 +1,192 @@
+// Package traffic 按接口统计请求和响应的大小，并在内存中保留最近的大响应和慢请求，
+// 用于找出频繁拉取大页面（例如每秒请求 200 条带完整描述的列表）的客户端。
+// 统计只保存在当前进程中，重启后清零；多实例部署时每个实例各自统计。
+package traffic
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	DefaultLogSize    = 100
+	DefaultHeavyBytes = 256 << 10
+	DefaultSlow       = time.Second
+)
+
+// 请求记录的排序方式
+const (
+	SortBytes    = "bytes"
+	SortDuration = "duration"
+)
+
+// Options 统计配置
+type Options struct {
+	LogSize    int           // 保留的大请求、慢请求条数，0 表示只统计接口不记录请求
+	HeavyBytes int64         // 响应体达到该大小的请求记入日志
+	Slow       time.Duration // 耗时达到该时长的请求记入日志
+}
+
+// Request 一次请求的概要
+type Request struct {
+	Time          time.Time `json:"time"`
+	Endpoint      string    `json:"endpoint" example:"GET /api/v1/todos"` // 路由模式
+	URI           string    `json:"uri" example:"/api/v1/todos/42"`       // 请求路径，不含查询参数，分享令牌等凭据保留为占位符
+	Client        string    `json:"client" example:"10.0.0.12"`
+	Workspace     string    `json:"workspace,omitempty"`
+	UserAgent     string    `json:"user_agent,omitempty"`
+	Status        int       `json:"status"`
+	RequestBytes  int64     `json:"request_bytes"`
+	ResponseBytes int64     `json:"response_bytes"`
+	DurationMs    int64     `json:"duration_ms"`
+}
+
+// EndpointStats 一个接口的累计统计
+type EndpointStats struct {
+	Endpoint         string  `json:"endpoint"`
+	Requests         int64   `json:"requests"`
+	RequestBytes     int64   `json:"request_bytes"`
+	ResponseBytes    int64   `json:"response_bytes"`
+	MaxResponseBytes int64   `json:"max_response_bytes"`
+	DurationSeconds  float64 `json:"duration_seconds"` // 累计耗时
+}
+
+// ClientStats 日志中某个客户端的汇总，用于定位大流量的来源
+type ClientStats struct {
+	Client        string `json:"client"`
+	UserAgent     string `json:"user_agent,omitempty"` // 最近一次请求的 User-Agent
+	Requests      int    `json:"requests"`
+	ResponseBytes int64  `json:"response_bytes"`
+}
+
+// Recorder 请求统计，可以并发使用
+type Recorder struct {
+	opts Options
+
+	mu        sync.Mutex
+	endpoints map[string]*EndpointStats
+	log       []Request // 环形缓冲区
+	next      int
+}
+
+// NewRecorder 创建统计器，HeavyBytes、Slow 为 0 时使用默认值
+func NewRecorder(opts Options) *Recorder {
+	if opts.LogSize < 0 {
+		opts.LogSize = 0
+	}
+	if opts.HeavyBytes <= 0 {
+		opts.HeavyBytes = DefaultHeavyBytes
+	}
+	if opts.Slow <= 0 {
+		opts.Slow = DefaultSlow
+	}
+	return &Recorder{
+		opts:      opts,
+		endpoints: make(map[string]*EndpointStats),
+		log:       make([]Request, 0, opts.LogSize),
+	}
+}
+
+// Record 记录一次请求：计入接口统计，响应较大或耗时较长时写入日志（日志满后覆盖最早的记录）
+func (r *Recorder) Record(req Request, duration time.Duration) {
+	req.DurationMs = duration.Milliseconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.endpoints[req.Endpoint]
+	if !ok {
+		s = &EndpointStats{Endpoint: req.Endpoint}
+		r.endpoints[req.Endpoint] = s
+	}
+	s.Requests++
+	s.RequestBytes += req.RequestBytes
+	s.ResponseBytes += req.ResponseBytes
+	s.DurationSeconds += duration.Seconds()
+	if req.ResponseBytes > s.MaxResponseBytes {
+		s.MaxResponseBytes = req.ResponseBytes
+	}
+
+	if r.opts.LogSize == 0 || (req.ResponseBytes < r.opts.HeavyBytes && duration < r.opts.Slow) {
+		return
+	}
+	if len(r.log) < r.opts.LogSize {
+		r.log = append(r.log, req)
+	} else {
+		r.log[r.next] = req
+	}
+	r.next = (r.next + 1) % r.opts.LogSize
+}
+
+// Endpoints 返回各接口的统计，按响应总字节数从大到小排序
+func (r *Recorder) Endpoints() []EndpointStats {
+	r.mu.Lock()
+	stats := make([]EndpointStats, 0, len(r.endpoints))
+	for _, s := range r.endpoints {
+		stats = append(stats, *s)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].ResponseBytes != stats[j].ResponseBytes {
+			return stats[i].ResponseBytes > stats[j].ResponseBytes
+		}
+		return stats[i].Endpoint < stats[j].Endpoint
+	})
+	return stats
+}
+
+// Requests 返回日志中的请求，按 sortBy（bytes / duration）从大到小排序，limit 为 0 时不限制条数
+func (r *Recorder) Requests(sortBy string, limit int) []Request {
+	r.mu.Lock()
+	reqs := make([]Request, len(r.log))
+	copy(reqs, r.log)
+	r.mu.Unlock()
+
+	sort.SliceStable(reqs, func(i, j int) bool {
+		if sortBy == SortDuration {
+			return reqs[i].DurationMs > reqs[j].DurationMs
+		}
+		return reqs[i].ResponseBytes > reqs[j].ResponseBytes
+	})
+	if limit > 0 && len(reqs) > limit {
+		reqs = reqs[:limit]
+	}
+	return reqs
+}
+
+// Clients 按客户端汇总日志中的请求，按响应总字节数从大到小排序
+func (r *Recorder) Clients() []ClientStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byClient := make(map[string]*ClientStats)
+	latest := make(map[string]time.Time)
+	for _, req := range r.log {
+		c, ok := byClient[req.Client]
+		if !ok {
+			c = &ClientStats{Client: req.Client}
+			byClient[req.Client] = c
+		}
+		c.Requests++
+		c.ResponseBytes += req.ResponseBytes
+		if req.Time.After(latest[req.Client]) {
+			latest[req.Client] = req.Time
+			c.UserAgent = req.UserAgent
+		}
+	}
+
+	clients := make([]ClientStats, 0, len(byClient))
+	for _, c := range byClient {
+		clients = append(clients, *c)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].ResponseBytes != clients[j].ResponseBytes {
+			return clients[i].ResponseBytes > clients[j].ResponseBytes
+		}
+		return clients[i].Client < clients[j].Client
+	})
+	return clients
+}
@@ -0,0 +1,327 @@
+// Package webhook 出站 webhook：把待办事项的变更事件（created、updated、completed、deleted）推送给订阅的 URL，
+// 方便 Slack、Zapier 等外部系统做出响应
+//
+// 事件来自数据库触发器记录的 todo_events 表，后台任务按每个 webhook 的推送进度依次推送满足过滤条件的事件，
+// 不论修改来自接口、导入还是后台任务都不会遗漏。推送失败的事件转入持久化任务队列按指数退避重试，
+// 重试次数用完进入死信；重试的事件可能晚于后续事件到达，接收方应以载荷中的 version 为准。
+// 同一事件可能推送不止一次，接收方可以按 X-Webhook-Delivery 去重。
+//
+// 每次推送尝试（包括重试和测试推送）都记录状态码、耗时和响应体的开头，失败的尝试可以手动重试，
+// 记录超过保留期后由清理任务删除。
+//
+// 每个请求都带签名，接收方用创建 webhook 时返回的密钥校验，并拒绝时间戳偏差过大的请求：
+//
+//	X-Webhook-Signature: sha256=HEX(HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body))
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo-list/clock"
+	"todo-list/model"
+	"todo-list/storage"
+)
+
+// TaskName 调度器中的任务名
+const TaskName = "webhook 推送"
+
+// RetryJobKind 推送失败后重试的后台任务类型
+const RetryJobKind = "webhook"
+
+// EventPing 测试推送（POST /api/v1/webhooks/{id}/test）的事件类型
+const EventPing = "ping"
+
+// 推送请求的请求头
+const (
+	HeaderEvent     = "X-Webhook-Event"     // 事件类型
+	HeaderDelivery  = "X-Webhook-Delivery"  // 推送 ID，重试时不变
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix 秒，参与签名
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// batchSize 每个 webhook 每轮最多处理的事件数；maxResponseBytes 读取的响应体上限；
+// maxResponseSnippet 推送记录中保存的响应体长度
+const (
+	batchSize          = 100
+	maxResponseBytes   = 64 << 10
+	maxResponseSnippet = 1 << 10
+)
+
+// Payload 推送的请求体：变更事件加上所属工作区
+type Payload struct {
+	model.TodoEvent
+	Workspace string `json:"workspace"`
+}
+
+// PingPayload 测试推送的请求体
+type PingPayload struct {
+	WebhookID int       `json:"webhook_id"`
+	Workspace string    `json:"workspace"`
+	At        time.Time `json:"at"`
+}
+
+// Store 推送需要的数据访问（database.DB 和 storage/memory 实现了该接口）
+type Store interface {
+	ListWebhookTargetsContext(ctx context.Context) ([]storage.WebhookTarget, error)
+	GetWebhookContext(ctx context.Context, id int) (*model.Webhook, error)
+	AdvanceWebhookContext(ctx context.Context, id int, eventID int64) error
+	RecordWebhookDeliveryContext(ctx context.Context, d *model.WebhookDelivery) error
+	ListTodoEventsContext(ctx context.Context, after int64, limit int) ([]model.TodoEvent, error)
+	GetTodoByIDContext(ctx context.Context, id int) (*model.Todo, error)
+}
+
+// RetryQueue 持久化任务队列（jobs.Queue 实现了该接口）
+type RetryQueue interface {
+	Enqueue(ctx context.Context, kind string, payload interface{}) error
+}
+
+// retryPayload 重试任务的参数
+type retryPayload struct {
+	WebhookID int     `json:"webhook_id"`
+	Payload   Payload `json:"payload"`
+}
+
+// Dispatcher 读取新的变更事件并推送给订阅的 webhook，由调度器周期调用
+type Dispatcher struct {
+	store  Store
+	client *http.Client // 出站客户端（带 SSRF 防护和按主机熔断）
+	retry  RetryQueue   // 为空时（DB_DRIVER=memory）失败的推送只记日志
+	clock  clock.Clock
+
+	// presenter 推送前计算待办事项的 is_overdue 和 priority_label，见 SetPresenter
+	presenter model.Presenter
+}
+
+// NewDispatcher 创建 webhook 分发器
+func NewDispatcher(store Store, client *http.Client, retry RetryQueue, clk clock.Clock) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: client,
+		retry:  retry,
+		clock:  clk,
+
+		presenter: model.DefaultPresenter(clk),
+	}
+}
+
+// SetPresenter 设置推送内容中待办事项的输出方式（工作流的未完成状态、优先级映射），与接口输出保持一致
+func (d *Dispatcher) SetPresenter(p model.Presenter) {
+	d.presenter = p
+}
+
+// Run 执行一轮推送（接受 Context 参数，供调度器使用）
+func (d *Dispatcher) Run(ctx context.Context) {
+	sent, err := d.evaluate(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("webhook 推送超时: %v", err)
+			return
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Printf("webhook 推送已取消")
+			return
+		}
+		log.Printf("webhook 推送失败: %v", err)
+		return
+	}
+
+	if sent > 0 {
+		log.Printf("webhook 推送完成: count=%d", sent)
+	}
+}
+
+// evaluate 依次处理每个启用的 webhook，返回推送成功的事件数
+func (d *Dispatcher) evaluate(ctx context.Context) (int, error) {
+	targets, err := d.store.ListWebhookTargetsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range targets {
+		n, err := d.dispatch(ctx, &targets[i])
+		sent += n
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// dispatch 推送 webhook 所在工作区中进度之后的事件
+// 推送失败的事件转入重试队列后照常推进进度；写入队列也失败时停在这里，下一轮再试
+func (d *Dispatcher) dispatch(ctx context.Context, t *storage.WebhookTarget) (int, error) {
+	ctx = storage.WithWorkspace(ctx, t.Workspace)
+	events, err := d.store.ListTodoEventsContext(ctx, t.Cursor, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range events {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		e := &events[i]
+		if t.Matches(e) {
+			if e.Type != model.TodoEventDeleted {
+				if todo, err := d.store.GetTodoByIDContext(ctx, e.TodoID); err == nil {
+					d.presenter.Present(todo)
+					e.Todo = todo
+				}
+			}
+			p := Payload{TodoEvent: *e, Workspace: t.Workspace}
+			if err := d.deliver(ctx, &t.Webhook, p); err != nil {
+				if d.retry == nil {
+					log.Printf("webhook 推送失败（没有重试队列，不再重试）: webhook_id=%d, event_id=%d, error=%v", t.ID, e.ID, err)
+				} else {
+					if qerr := d.retry.Enqueue(ctx, RetryJobKind, retryPayload{WebhookID: t.ID, Payload: p}); qerr != nil {
+						return sent, errors.Join(err, fmt.Errorf("写入重试队列失败：%w", qerr))
+					}
+					log.Printf("webhook 推送失败，已转入重试队列: webhook_id=%d, event_id=%d, error=%v", t.ID, e.ID, err)
+				}
+			} else {
+				sent++
+			}
+		}
+		if err := d.store.AdvanceWebhookContext(ctx, t.ID, e.ID); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// HandleRetryJob 执行重试任务（注册到 jobs.Queue）
+// webhook 已删除或已停用时视为完成，不再重试；签名使用当前的密钥
+func (d *Dispatcher) HandleRetryJob(ctx context.Context, payload json.RawMessage) error {
+	var p retryPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("解析重试任务失败：%w", err)
+	}
+
+	ctx = storage.WithWorkspace(ctx, p.Payload.Workspace)
+	w, err := d.store.GetWebhookContext(ctx, p.WebhookID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !w.Active {
+		return nil
+	}
+	return d.deliver(ctx, w, p.Payload)
+}
+
+// Ping 向 webhook 发送一条测试事件，返回接收方的 HTTP 状态码（没有收到响应时为 0）
+func (d *Dispatcher) Ping(ctx context.Context, w *model.Webhook) (int, error) {
+	now := d.clock.Now().UTC()
+	body, err := json.Marshal(PingPayload{WebhookID: w.ID, Workspace: storage.WorkspaceFromContext(ctx), At: now})
+	if err != nil {
+		return 0, err
+	}
+	attempt, err := d.send(ctx, w, &model.WebhookDelivery{
+		Delivery: fmt.Sprintf("%d-%s-%d", w.ID, EventPing, now.UnixNano()),
+		Event:    EventPing,
+		Payload:  body,
+	})
+	return attempt.Status, err
+}
+
+// Redeliver 手动重试一次推送：原样重发记录中的请求体，推送 ID 不变，签名和时间戳重新生成
+// 返回新的推送记录，推送失败时同时返回错误
+func (d *Dispatcher) Redeliver(ctx context.Context, w *model.Webhook, prev *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	return d.send(ctx, w, &model.WebhookDelivery{
+		Delivery: prev.Delivery,
+		Event:    prev.Event,
+		EventID:  prev.EventID,
+		Payload:  prev.Payload,
+	})
+}
+
+// deliver 推送一个变更事件并记录结果
+func (d *Dispatcher) deliver(ctx context.Context, w *model.Webhook, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败：%w", err)
+	}
+	_, err = d.send(ctx, w, &model.WebhookDelivery{
+		Delivery: fmt.Sprintf("%d-%d", w.ID, p.ID),
+		Event:    p.Type,
+		EventID:  p.ID,
+		Payload:  body,
+	})
+	return err
+}
+
+// send 推送 attempt 中的请求体，把结果填回 attempt 并保存为推送记录
+// 保存失败只记日志，不影响推送本身
+func (d *Dispatcher) send(ctx context.Context, w *model.Webhook, attempt *model.WebhookDelivery) (*model.WebhookDelivery, error) {
+	start := d.clock.Now()
+	status, response, err := d.post(ctx, w, attempt.Event, attempt.Delivery, attempt.Payload)
+	attempt.WebhookID = w.ID
+	attempt.Success = err == nil
+	attempt.Status = status
+	attempt.Response = response
+	attempt.LatencyMS = d.clock.Now().Sub(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	if rerr := d.store.RecordWebhookDeliveryContext(ctx, attempt); rerr != nil {
+		log.Printf("记录 webhook 推送结果失败: webhook_id=%d, delivery=%s, error=%v", w.ID, attempt.Delivery, rerr)
+	}
+	return attempt, err
+}
+
+// post 发送签名的 POST 请求，返回状态码和响应体的开头，2xx 以外的响应视为失败
+func (d *Dispatcher) post(ctx context.Context, w *model.Webhook, event, delivery string, body []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("创建请求失败：%w", err)
+	}
+	timestamp := strconv.FormatInt(d.clock.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-list-webhook/1.0")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, delivery)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(w.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	// 读完响应体才能复用连接，只保留开头写入推送记录
+	snippet := make([]byte, maxResponseSnippet)
+	n, _ := io.ReadFull(resp.Body, snippet)
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes-int64(n)))
+	response := strings.ToValidUTF8(string(snippet[:n]), "")
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, response, fmt.Errorf("接收方返回 %s", resp.Status)
+	}
+	return resp.StatusCode, response, nil
+}
+
+// Sign 计算 X-Webhook-Signature 的值：sha256= 加上 HMAC-SHA256(secret, timestamp + "." + body) 的十六进制
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,166 @@
+// Package workflow 定义待办事项的状态和允许的状态流转
+// 默认只有 pending / completed 两种状态，部署时可以通过 JSON 文件扩展，例如
+// pending → in_progress → review → completed
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// 内置状态：新建的待办事项总是 pending，批量完成写入 completed；未完成列表、逾期和统计按是否为终态区分（见 storage.StatusSets）
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+)
+
+var statusNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// Status 一个状态
+type Status struct {
+	Name     string `json:"name"`
+	Label    string `json:"label,omitempty"`
+	Terminal bool   `json:"terminal"` // 终态：进入时记录 completed_at，离开时清除
+}
+
+// Workflow 状态定义和流转规则
+type Workflow struct {
+	Statuses    []Status            `json:"statuses"`
+	Transitions map[string][]string `json:"transitions"` // 源状态 -> 允许的目标状态
+}
+
+// Default 默认工作流：pending 和 completed 之间可以互相切换
+func Default() *Workflow {
+	return &Workflow{
+		Statuses: []Status{
+			{Name: StatusPending, Label: "未完成"},
+			{Name: StatusCompleted, Label: "已完成", Terminal: true},
+		},
+		Transitions: map[string][]string{
+			StatusPending:   {StatusCompleted},
+			StatusCompleted: {StatusPending},
+		},
+	}
+}
+
+// Load 从 JSON 文件加载工作流
+//
+//	{
+//	  "statuses": [
+//	    {"name": "pending", "label": "待处理"},
+//	    {"name": "in_progress", "label": "进行中"},
+//	    {"name": "review", "label": "待审核"},
+//	    {"name": "completed", "label": "已完成", "terminal": true}
+//	  ],
+//	  "transitions": {
+//	    "pending": ["in_progress"],
+//	    "in_progress": ["review", "pending"],
+//	    "review": ["completed", "in_progress"],
+//	    "completed": ["pending"]
+//	  }
+//	}
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取工作流失败：%w", err)
+	}
+
+	var wf Workflow
+	if err := json.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("解析工作流失败：%w", err)
+	}
+
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Validate 校验工作流
+func (wf *Workflow) Validate() error {
+	seen := make(map[string]bool, len(wf.Statuses))
+	for i, s := range wf.Statuses {
+		if !statusNamePattern.MatchString(s.Name) {
+			return fmt.Errorf("第 %d 个状态: 名称 %q 无效（小写字母开头，只能包含小写字母、数字和下划线）", i+1, s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("状态 %q 重复定义", s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	if !seen[StatusPending] {
+		return fmt.Errorf("工作流必须包含 %q 状态", StatusPending)
+	}
+	if !wf.IsTerminal(StatusCompleted) {
+		return fmt.Errorf("工作流必须包含终态 %q", StatusCompleted)
+	}
+	if wf.IsTerminal(StatusPending) {
+		return fmt.Errorf("%q 不能是终态", StatusPending)
+	}
+
+	for from, targets := range wf.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("流转规则引用了未定义的状态 %q", from)
+		}
+		for _, to := range targets {
+			if !seen[to] {
+				return fmt.Errorf("流转规则 %s -> %s 引用了未定义的状态", from, to)
+			}
+		}
+	}
+	return nil
+}
+
+// Has 判断状态是否存在
+func (wf *Workflow) Has(name string) bool {
+	return wf.status(name) != nil
+}
+
+// IsTerminal 判断状态是否为终态
+func (wf *Workflow) IsTerminal(name string) bool {
+	s := wf.status(name)
+	return s != nil && s.Terminal
+}
+
+// CanTransition 判断 from -> to 是否允许，状态不变时总是允许
+func (wf *Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return wf.Has(to)
+	}
+	for _, allowed := range wf.Transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Next 返回 from 允许流转到的状态
+func (wf *Workflow) Next(from string) []string {
+	next := wf.Transitions[from]
+	if next == nil {
+		return []string{}
+	}
+	return next
+}
+
+// Names 按定义顺序返回所有状态名
+func (wf *Workflow) Names() []string {
+	names := make([]string, len(wf.Statuses))
+	for i, s := range wf.Statuses {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func (wf *Workflow) status(name string) *Status {
+	for i := range wf.Statuses {
+		if wf.Statuses[i].Name == name {
+			return &wf.Statuses[i]
+		}
+	}
+	return nil
+}